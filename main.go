@@ -1,16 +1,44 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/config"
 	"github.com/craigderington/lazyrestic/pkg/model"
+	"github.com/craigderington/lazyrestic/pkg/ops"
 )
 
 const version = "0.1.0"
 
 func main() {
+	jsonOutput := flag.Bool("json", false, "print the result of a command as JSON")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: lazyrestic [--json] [command]\n\nWithout a command the terminal UI starts. Commands:\n")
+		for _, usage := range ops.Commands {
+			fmt.Fprintf(flag.CommandLine.Output(), "  %s\n", usage)
+		}
+		fmt.Fprintln(flag.CommandLine.Output(), "\nFlags:")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// Run a single operation without the TUI, e.g. from cron or CI
+	if flag.NArg() > 0 {
+		if !ops.IsCommand(flag.Arg(0)) {
+			fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", flag.Arg(0))
+			flag.Usage()
+			os.Exit(2)
+		}
+		os.Exit(runCommand(flag.Args(), *jsonOutput))
+	}
+	if *jsonOutput {
+		fmt.Fprintln(os.Stderr, "--json needs a command; the terminal UI has no JSON output")
+		os.Exit(2)
+	}
+
 	// Create the initial model
 	m := model.NewModel()
 
@@ -23,3 +51,20 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// runCommand runs a non-interactive command and returns the process exit code
+func runCommand(args []string, jsonOutput bool) int {
+	cfg, err := config.LoadAndValidate("")
+	if err != nil {
+		if jsonOutput {
+			ops.WriteJSON(os.Stdout, ops.Result{Operation: args[0], Error: err.Error()})
+		} else {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		}
+		return 1
+	}
+	if err := ops.Run(cfg, args, os.Stdout, os.Stderr, jsonOutput); err != nil {
+		return 1
+	}
+	return 0
+}
@@ -1,16 +1,23 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 
 	"github.com/craigderington/lazyrestic/pkg/types"
 )
 
+// MinAutoRefresh is the shortest accepted auto_refresh interval
+const MinAutoRefresh = 10 * time.Second
+
 // DefaultConfigPath returns the default configuration file path
 func DefaultConfigPath() string {
 	home, err := os.UserHomeDir()
@@ -20,6 +27,9 @@ func DefaultConfigPath() string {
 	return filepath.Join(home, ".config", "lazyrestic", "config.yaml")
 }
 
+// ErrConfigNotFound is returned by Load when the config file doesn't exist
+var ErrConfigNotFound = errors.New("config file not found")
+
 // Load reads and parses the configuration file
 func Load(path string) (*types.ResticConfig, error) {
 	if path == "" {
@@ -28,7 +38,7 @@ func Load(path string) (*types.ResticConfig, error) {
 
 	// Check if file exists
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return nil, fmt.Errorf("config file not found: %s", path)
+		return nil, fmt.Errorf("%w: %s", ErrConfigNotFound, path)
 	}
 
 	// Read the file
@@ -37,37 +47,10 @@ func Load(path string) (*types.ResticConfig, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	// Parse YAML into a temporary structure to check for deprecated fields
-	var rawConfig map[string]interface{}
-	if err := yaml.Unmarshal(data, &rawConfig); err != nil {
-		return nil, fmt.Errorf("failed to parse config YAML: %w", err)
-	}
-
-	// Check for deprecated plain-text passwords
-	if repos, ok := rawConfig["repositories"].([]interface{}); ok {
-		for i, repo := range repos {
-			if repoMap, ok := repo.(map[interface{}]interface{}); ok {
-				if _, hasPassword := repoMap["password"]; hasPassword {
-					repoName := "unknown"
-					if name, ok := repoMap["name"].(string); ok {
-						repoName = name
-					}
-					return nil, fmt.Errorf("repository %d (%s) uses deprecated 'password' field\n\n"+
-						"Plain-text passwords are no longer supported for security.\n"+
-						"Please migrate to one of these secure methods:\n\n"+
-						"1. Password file (recommended):\n"+
-						"   password_file: /path/to/password-file  # File must have 0400 or 0600 permissions\n\n"+
-						"2. Password command (for password managers):\n"+
-						"   password_command: pass show restic/%s\n\n"+
-						"To migrate your existing password:\n"+
-						"  mkdir -p ~/.config/lazyrestic/passwords\n"+
-						"  echo 'YOUR_PASSWORD' > ~/.config/lazyrestic/passwords/%s.txt\n"+
-						"  chmod 400 ~/.config/lazyrestic/passwords/%s.txt\n"+
-						"  # Then update config to use: password_file: ~/.config/lazyrestic/passwords/%s.txt",
-						i, repoName, repoName, repoName, repoName, repoName)
-				}
-			}
-		}
+	// Upgrade configs written for older versions, e.g. with plain-text passwords
+	data, migrated, err := migrateConfig(data, path)
+	if err != nil {
+		return nil, err
 	}
 
 	// Parse YAML into proper structure
@@ -76,9 +59,83 @@ func Load(path string) (*types.ResticConfig, error) {
 		return nil, fmt.Errorf("failed to parse config YAML: %w", err)
 	}
 
+	config.Migrated = migrated
+
+	for i := range config.Repositories {
+		expandRepositoryPaths(&config.Repositories[i])
+	}
+
 	return &config, nil
 }
 
+// envVarPattern matches $VAR and ${VAR} references
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// expandPath expands a leading ~ to the home directory and $VAR/${VAR} to
+// environment values. References to undefined variables are left as-is so a
+// typo surfaces as a missing file (or a rejected password command) rather than
+// silently collapsing to an empty string.
+func expandPath(p string) string {
+	p = envVarPattern.ReplaceAllStringFunc(p, func(ref string) string {
+		name := strings.Trim(ref, "${}")
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		return ref
+	})
+
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			p = home + p[1:]
+		}
+	}
+
+	return p
+}
+
+// expandRepositoryPaths applies expandPath to the path-like fields of a
+// repository, keeping the values as written in repo.Raw for Save
+func expandRepositoryPaths(repo *types.RepositoryConfig) {
+	raw := *repo
+	raw.DefaultPaths = slices.Clone(repo.DefaultPaths)
+	repo.Raw = &raw
+
+	repo.Path = expandPath(repo.Path)
+	repo.PasswordFile = expandPath(repo.PasswordFile)
+	repo.PasswordCommand = expandPath(repo.PasswordCommand)
+	repo.CACert = expandPath(repo.CACert)
+	for i, path := range repo.DefaultPaths {
+		repo.DefaultPaths[i] = expandPath(path)
+	}
+}
+
+// unexpandRepositoryPaths returns repo with the path-like fields that still
+// match their expansion put back as written in the config file, so saving
+// doesn't replace ~ and $VAR with this machine's values
+func unexpandRepositoryPaths(repo types.RepositoryConfig) types.RepositoryConfig {
+	raw := repo.Raw
+	if raw == nil {
+		return repo
+	}
+	keep := func(value *string, written string) {
+		if *value == expandPath(written) {
+			*value = written
+		}
+	}
+	keep(&repo.Path, raw.Path)
+	keep(&repo.PasswordFile, raw.PasswordFile)
+	keep(&repo.PasswordCommand, raw.PasswordCommand)
+	keep(&repo.CACert, raw.CACert)
+	if len(repo.DefaultPaths) == len(raw.DefaultPaths) {
+		paths := slices.Clone(repo.DefaultPaths)
+		for i := range paths {
+			keep(&paths[i], raw.DefaultPaths[i])
+		}
+		repo.DefaultPaths = paths
+	}
+	return repo
+}
+
 // ValidateConfig checks the configuration for security issues
 func ValidateConfig(config *types.ResticConfig, configPath string) error {
 	// Check config file permissions
@@ -86,6 +143,10 @@ func ValidateConfig(config *types.ResticConfig, configPath string) error {
 		return fmt.Errorf("config file security check failed: %w", err)
 	}
 
+	if err := validateAutoRefresh(config.AutoRefresh); err != nil {
+		return err
+	}
+
 	// Validate each repository configuration
 	for i, repo := range config.Repositories {
 		if err := validateRepositoryConfig(&repo, i); err != nil {
@@ -96,6 +157,18 @@ func ValidateConfig(config *types.ResticConfig, configPath string) error {
 	return nil
 }
 
+// validateAutoRefresh checks that the auto-refresh interval is off or long enough
+// to avoid hammering remote backends
+func validateAutoRefresh(interval time.Duration) error {
+	if interval < 0 {
+		return fmt.Errorf("auto_refresh must not be negative, got %s", interval)
+	}
+	if interval > 0 && interval < MinAutoRefresh {
+		return fmt.Errorf("auto_refresh must be at least %s, got %s", MinAutoRefresh, interval)
+	}
+	return nil
+}
+
 // validateConfigFilePermissions checks that the config file has secure permissions
 func validateConfigFilePermissions(path string) error {
 	info, err := os.Stat(path)
@@ -112,8 +185,14 @@ func validateConfigFilePermissions(path string) error {
 	return nil
 }
 
-// validateRepositoryConfig validates a single repository configuration
-func validateRepositoryConfig(repo *types.RepositoryConfig, index int) error {
+// ValidateConfigFile checks that the config file exists and is readable only by its owner
+func ValidateConfigFile(path string) error {
+	return validateConfigFilePermissions(path)
+}
+
+// ValidatePasswordMethod checks that a repository sets exactly one password
+// method and that it is usable
+func ValidatePasswordMethod(repo types.RepositoryConfig) error {
 	passwordMethods := 0
 	if repo.PasswordFile != "" {
 		passwordMethods++
@@ -121,13 +200,16 @@ func validateRepositoryConfig(repo *types.RepositoryConfig, index int) error {
 	if repo.PasswordCommand != "" {
 		passwordMethods++
 	}
+	if repo.PasswordEnv != "" {
+		passwordMethods++
+	}
 
 	if passwordMethods == 0 {
-		return fmt.Errorf("no password method specified (password_file or password_command required)")
+		return fmt.Errorf("no password method specified (password_file, password_command or password_env required)")
 	}
 
 	if passwordMethods > 1 {
-		return fmt.Errorf("multiple password methods specified, use only one of: password_file or password_command")
+		return fmt.Errorf("multiple password methods specified, use only one of: password_file, password_command or password_env")
 	}
 
 	// Validate password file
@@ -144,11 +226,90 @@ func validateRepositoryConfig(repo *types.RepositoryConfig, index int) error {
 		}
 	}
 
+	// Validate password environment variable name
+	if repo.PasswordEnv != "" {
+		if err := validatePasswordEnv(repo.PasswordEnv); err != nil {
+			return fmt.Errorf("password_env validation failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateRepositoryConfig validates a single repository configuration
+func validateRepositoryConfig(repo *types.RepositoryConfig, index int) error {
+	if err := ValidatePasswordMethod(*repo); err != nil {
+		return err
+	}
+
+	// Validate custom CA certificate
+	if repo.CACert != "" {
+		if err := validateCACert(repo.CACert); err != nil {
+			return fmt.Errorf("cacert validation failed: %w", err)
+		}
+	}
+
+	// Validate backup schedule
+	if repo.Schedule != "" {
+		if _, err := types.ParseSchedule(repo.Schedule); err != nil {
+			return fmt.Errorf("schedule validation failed: %w", err)
+		}
+	}
+
+	// Validate the compression mode passed to backup and prune
+	switch repo.Compression {
+	case "", "auto", "max", "off":
+	default:
+		return fmt.Errorf("compression must be auto, max or off, got %q", repo.Compression)
+	}
+
+	// Validate the large file threshold for backups
+	if repo.DefaultMaxFileSize != "" {
+		if _, err := types.ParseFileSize(repo.DefaultMaxFileSize); err != nil {
+			return fmt.Errorf("default_max_file_size validation failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validatePasswordEnv checks that the password environment variable name is well-formed
+func validatePasswordEnv(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("environment variable name is empty")
+	}
+
+	for i, r := range name {
+		isLetter := (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || r == '_'
+		isDigit := r >= '0' && r <= '9'
+		if !isLetter && !(isDigit && i > 0) {
+			return fmt.Errorf("invalid environment variable name: %s", name)
+		}
+	}
+
+	return nil
+}
+
+// validateCACert checks that the CA certificate file exists and is a regular file
+func validateCACert(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("CA certificate file does not exist: %s", path)
+		}
+		return fmt.Errorf("cannot access CA certificate file: %w", err)
+	}
+
+	if info.IsDir() {
+		return fmt.Errorf("CA certificate path is a directory: %s", path)
+	}
+
 	return nil
 }
 
 // validatePasswordFile checks that the password file exists and has secure permissions
 func validatePasswordFile(path string) error {
+	path = expandPath(path)
 	info, err := os.Stat(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -160,22 +321,41 @@ func validatePasswordFile(path string) error {
 	mode := info.Mode()
 	// Password file should be readable only by owner (0400 or 0600)
 	if mode.Perm() != 0400 && mode.Perm() != 0600 {
-		return fmt.Errorf("password file permissions are %s, should be 0400 or 0600 for security", mode.Perm())
+		return &PasswordFilePermissionError{Path: path, Mode: mode.Perm()}
 	}
 
 	return nil
 }
 
-// validatePasswordCommand checks that the password command doesn't contain dangerous shell metacharacters
-func validatePasswordCommand(cmd string) error {
-	// Dangerous characters that could allow command injection
-	dangerousChars := []string{";", "|", "&", "`", "$", "(", ")", "<", ">", "\n", "\r"}
+// PasswordFilePermissionError reports a password file readable by users other than its owner
+type PasswordFilePermissionError struct {
+	Path string
+	Mode os.FileMode
+}
 
-	for _, char := range dangerousChars {
-		if strings.Contains(cmd, char) {
-			return fmt.Errorf("password command contains dangerous character '%s': %s", char, cmd)
+func (e *PasswordFilePermissionError) Error() string {
+	return fmt.Sprintf("password file permissions are %s, should be 0400 or 0600 for security", e.Mode)
+}
+
+// shellMetacharacters could allow command injection if a command line reached a shell
+var shellMetacharacters = []string{";", "|", "&", "`", "$", "(", ")", "<", ">", "\n", "\r"}
+
+// ShellMetacharacter returns the first shell metacharacter found in s, or ""
+// if there is none
+func ShellMetacharacter(s string) string {
+	for _, char := range shellMetacharacters {
+		if strings.Contains(s, char) {
+			return char
 		}
 	}
+	return ""
+}
+
+// validatePasswordCommand checks that the password command doesn't contain dangerous shell metacharacters
+func validatePasswordCommand(cmd string) error {
+	if char := ShellMetacharacter(cmd); char != "" {
+		return fmt.Errorf("password command contains dangerous character '%s': %s", char, cmd)
+	}
 
 	// Check for common dangerous commands
 	dangerousCommands := []string{"rm", "del", "format", "mkfs", "dd", "shred", "wget", "curl"}
@@ -192,6 +372,14 @@ func validatePasswordCommand(cmd string) error {
 
 // LoadAndValidate reads and parses the configuration file with validation
 func LoadAndValidate(path string) (*types.ResticConfig, error) {
+	config, _, err := LoadAndRepair(path)
+	return config, err
+}
+
+// LoadAndRepair loads and validates the config like LoadAndValidate. When the config
+// sets auto_fix_permissions, password files that are too permissive are changed to
+// 0600 and validation is retried. It returns the password files it repaired.
+func LoadAndRepair(path string) (*types.ResticConfig, []string, error) {
 	// Use default path if not specified
 	if path == "" {
 		path = DefaultConfigPath()
@@ -199,27 +387,58 @@ func LoadAndValidate(path string) (*types.ResticConfig, error) {
 
 	config, err := Load(path)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Validate configuration for security issues
-	if err := ValidateConfig(config, path); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
+	// Validate configuration for security issues, fixing one password file per pass
+	var repaired []string
+	for {
+		err = ValidateConfig(config, path)
+		if err == nil {
+			return config, repaired, nil
+		}
+
+		// Give up if a file is still too permissive after being fixed (e.g. on a
+		// filesystem that ignores chmod)
+		var permErr *PasswordFilePermissionError
+		if !config.AutoFixPermissions || !errors.As(err, &permErr) || slices.Contains(repaired, permErr.Path) {
+			return nil, repaired, fmt.Errorf("config validation failed: %w", err)
+		}
+		if fixErr := FixPasswordFilePermissions(permErr.Path); fixErr != nil {
+			return nil, repaired, fmt.Errorf("config validation failed: %w (automatic fix failed: %v)", err, fixErr)
+		}
+		repaired = append(repaired, permErr.Path)
 	}
+}
 
-	return config, nil
+// FixPasswordFilePermissions restricts a password file to its owner (0600)
+func FixPasswordFilePermissions(path string) error {
+	return os.Chmod(expandPath(path), 0600)
 }
 
-// LoadOrDefault loads the config file, or returns an empty config if not found
+// LoadOrDefault loads the config file, or returns an empty config if it can't be loaded
 func LoadOrDefault(path string) *types.ResticConfig {
-	config, err := LoadAndValidate(path)
+	config, _ := LoadOrDefaultWithError(path)
+	return config
+}
+
+// LoadOrDefaultWithError loads and validates the config file like LoadAndRepair,
+// recording repaired password files in Repaired. If that fails it returns an
+// empty config together with the reason, so callers can start up and still
+// report it. A missing config file is not an error.
+func LoadOrDefaultWithError(path string) (*types.ResticConfig, error) {
+	config, repaired, err := LoadAndRepair(path)
 	if err != nil {
-		// Return empty config - user needs to add repos or create config file
-		return &types.ResticConfig{
+		// Return empty config - user needs to add repos or fix the config file
+		config = &types.ResticConfig{
 			Repositories: []types.RepositoryConfig{},
 		}
+		if errors.Is(err, ErrConfigNotFound) {
+			err = nil
+		}
 	}
-	return config
+	config.Repaired = repaired
+	return config, err
 }
 
 // Save writes the configuration to a file
@@ -234,7 +453,13 @@ func Save(config *types.ResticConfig, path string) error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	data, err := yaml.Marshal(config)
+	config.Version = CurrentConfigVersion
+	written := *config
+	written.Repositories = make([]types.RepositoryConfig, len(config.Repositories))
+	for i, repo := range config.Repositories {
+		written.Repositories[i] = unexpandRepositoryPaths(repo)
+	}
+	data, err := yaml.Marshal(&written)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -277,3 +502,28 @@ func RemoveRepository(config *types.ResticConfig, name string) bool {
 	}
 	return false
 }
+
+// RenameRepository changes the display name of a repository in the config.
+// It fails without changes if newName is empty, already used by another
+// repository, or no repository is named oldName.
+func RenameRepository(config *types.ResticConfig, oldName, newName string) error {
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return fmt.Errorf("repository name cannot be empty")
+	}
+
+	index := -1
+	for i, repo := range config.Repositories {
+		if repo.Name == oldName {
+			index = i
+		} else if repo.Name == newName {
+			return fmt.Errorf("a repository named '%s' already exists", newName)
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("repository '%s' not found in config", oldName)
+	}
+
+	config.Repositories[index].Name = newName
+	return nil
+}
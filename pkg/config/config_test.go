@@ -1,10 +1,14 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/craigderington/lazyrestic/pkg/types"
 )
@@ -52,8 +56,12 @@ func TestLoad_ValidConfig(t *testing.T) {
 	if repo1.Path != "/tmp/test" {
 		t.Errorf("First repo path = %v, want /tmp/test", repo1.Path)
 	}
-	if repo1.PasswordFile != "testpass" {
-		t.Errorf("First repo password = %v, want testpass", repo1.PasswordFile)
+	// The deprecated plain-text password is migrated into a password file
+	if want := filepath.Join(tmpDir, "passwords", "test-repo.txt"); repo1.PasswordFile != want {
+		t.Errorf("First repo password_file = %v, want %v", repo1.PasswordFile, want)
+	}
+	if data, err := os.ReadFile(repo1.PasswordFile); err != nil || strings.TrimSpace(string(data)) != "testpass" {
+		t.Errorf("Migrated password file = %q (%v), want testpass", data, err)
 	}
 
 	// Check second repository
@@ -328,3 +336,566 @@ func TestRoundTrip_SaveAndLoad(t *testing.T) {
 		}
 	}
 }
+
+func TestRoundTrip_BackupDefaults(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "defaults.yaml")
+	original := &types.ResticConfig{
+		Repositories: []types.RepositoryConfig{
+			{
+				Name:                 "home",
+				Path:                 "/srv/restic/home",
+				PasswordEnv:          "HOME_REPO_PASSWORD",
+				DefaultPaths:         []string{"/home/user", "/etc"},
+				DefaultTags:          []string{"nightly", "laptop"},
+				DefaultExclude:       []string{"*.tmp", ".cache"},
+				DefaultExcludeCaches: true,
+				DefaultOneFileSystem: true,
+			},
+			{
+				Name:        "bare",
+				Path:        "/srv/restic/bare",
+				PasswordEnv: "BARE_REPO_PASSWORD",
+			},
+		},
+	}
+
+	if err := Save(original, configPath); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	loaded, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	for i, orig := range original.Repositories {
+		load := loaded.Repositories[i]
+		if !reflect.DeepEqual(orig.DefaultPaths, load.DefaultPaths) {
+			t.Errorf("Repo %d: DefaultPaths = %v, want %v", i, load.DefaultPaths, orig.DefaultPaths)
+		}
+		if !reflect.DeepEqual(orig.DefaultTags, load.DefaultTags) {
+			t.Errorf("Repo %d: DefaultTags = %v, want %v", i, load.DefaultTags, orig.DefaultTags)
+		}
+		if !reflect.DeepEqual(orig.DefaultExclude, load.DefaultExclude) {
+			t.Errorf("Repo %d: DefaultExclude = %v, want %v", i, load.DefaultExclude, orig.DefaultExclude)
+		}
+		if orig.DefaultExcludeCaches != load.DefaultExcludeCaches || orig.DefaultOneFileSystem != load.DefaultOneFileSystem {
+			t.Errorf("Repo %d: exclude caches/one file system = %v/%v, want %v/%v", i,
+				load.DefaultExcludeCaches, load.DefaultOneFileSystem, orig.DefaultExcludeCaches, orig.DefaultOneFileSystem)
+		}
+	}
+
+	// Repositories without defaults don't write empty keys
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	if count := strings.Count(string(data), "default_paths"); count != 1 {
+		t.Errorf("Expected default_paths once in the saved config, found %d times", count)
+	}
+}
+
+func TestValidateRepositoryConfig_CACert(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	passwordFile := filepath.Join(tmpDir, ".restic-pass")
+	if err := os.WriteFile(passwordFile, []byte("testpassword"), 0600); err != nil {
+		t.Fatalf("Failed to write test password file: %v", err)
+	}
+
+	caFile := filepath.Join(tmpDir, "ca.pem")
+	if err := os.WriteFile(caFile, []byte("-----BEGIN CERTIFICATE-----"), 0644); err != nil {
+		t.Fatalf("Failed to write test CA file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		cacert  string
+		wantErr bool
+	}{
+		{name: "No CA certificate", cacert: "", wantErr: false},
+		{name: "Existing CA certificate", cacert: caFile, wantErr: false},
+		{name: "Missing CA certificate", cacert: filepath.Join(tmpDir, "missing.pem"), wantErr: true},
+		{name: "Directory instead of file", cacert: tmpDir, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := types.RepositoryConfig{
+				Name:         "rest-repo",
+				Path:         "rest:https://host:8000/",
+				PasswordFile: passwordFile,
+				CACert:       tt.cacert,
+			}
+
+			err := validateRepositoryConfig(&repo, 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRepositoryConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRepositoryConfig_PasswordEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    types.RepositoryConfig
+		wantErr bool
+	}{
+		{
+			name:    "Valid env var name",
+			repo:    types.RepositoryConfig{Name: "env", Path: "/tmp/repo", PasswordEnv: "MY_REPO_PASSWORD"},
+			wantErr: false,
+		},
+		{
+			name:    "Invalid env var name",
+			repo:    types.RepositoryConfig{Name: "env", Path: "/tmp/repo", PasswordEnv: "1BAD-NAME"},
+			wantErr: true,
+		},
+		{
+			name:    "Whitespace env var name",
+			repo:    types.RepositoryConfig{Name: "env", Path: "/tmp/repo", PasswordEnv: "   "},
+			wantErr: true,
+		},
+		{
+			name: "Env combined with command",
+			repo: types.RepositoryConfig{
+				Name:            "env",
+				Path:            "/tmp/repo",
+				PasswordEnv:     "MY_REPO_PASSWORD",
+				PasswordCommand: "pass show restic",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRepositoryConfig(&tt.repo, 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRepositoryConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRepositoryConfig_Schedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule string
+		wantErr  bool
+	}{
+		{name: "No schedule", schedule: "", wantErr: false},
+		{name: "Keyword", schedule: "daily", wantErr: false},
+		{name: "Cron expression", schedule: "0 2 * * *", wantErr: false},
+		{name: "Unknown keyword", schedule: "fortnightly", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := types.RepositoryConfig{Name: "sched", Path: "/tmp/repo", PasswordEnv: "SCHED_PASSWORD", Schedule: tt.schedule}
+			err := validateRepositoryConfig(&repo, 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRepositoryConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRepositoryConfig_MaxFileSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    string
+		wantErr bool
+	}{
+		{name: "No limit", size: "", wantErr: false},
+		{name: "Megabytes", size: "100M", wantErr: false},
+		{name: "Fraction", size: "1.5G", wantErr: true},
+		{name: "Unknown unit", size: "100X", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := types.RepositoryConfig{Name: "sized", Path: "/tmp/repo", PasswordEnv: "SIZED_PASSWORD", DefaultMaxFileSize: tt.size}
+			err := validateRepositoryConfig(&repo, 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRepositoryConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRepositoryConfig_Compression(t *testing.T) {
+	for _, mode := range []string{"", "auto", "max", "off"} {
+		repo := types.RepositoryConfig{Name: "packed", Path: "/tmp/repo", PasswordEnv: "PACKED_PASSWORD", Compression: mode}
+		if err := validateRepositoryConfig(&repo, 0); err != nil {
+			t.Errorf("compression %q should be valid: %v", mode, err)
+		}
+	}
+	repo := types.RepositoryConfig{Name: "packed", Path: "/tmp/repo", PasswordEnv: "PACKED_PASSWORD", Compression: "fast"}
+	if err := validateRepositoryConfig(&repo, 0); err == nil {
+		t.Error("compression \"fast\" should be rejected")
+	}
+}
+
+func TestValidateAutoRefresh(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		wantErr  bool
+	}{
+		{"Disabled", 0, false},
+		{"Minimum", MinAutoRefresh, false},
+		{"Five minutes", 5 * time.Minute, false},
+		{"Too short", time.Second, true},
+		{"Negative", -time.Minute, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAutoRefresh(tt.interval)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateAutoRefresh(%v) error = %v, wantErr %v", tt.interval, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad_AutoRefreshDuration(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	content := "auto_refresh: 5m\nrepositories: []\n"
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if cfg.AutoRefresh != 5*time.Minute {
+		t.Errorf("AutoRefresh = %v, want 5m", cfg.AutoRefresh)
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("RESTIC_BASE", "/srv/restic")
+	os.Unsetenv("LAZYRESTIC_UNDEFINED_VAR")
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"Tilde prefix", "~/.config/lazyrestic/passwords/local.txt", filepath.Join(home, ".config/lazyrestic/passwords/local.txt")},
+		{"Bare tilde", "~", home},
+		{"Tilde not at start", "/data/~backup", "/data/~backup"},
+		{"HOME variable", "$HOME/passwords/repo.txt", home + "/passwords/repo.txt"},
+		{"Braced variable", "${RESTIC_BASE}/repo", "/srv/restic/repo"},
+		{"Undefined variable left as-is", "$LAZYRESTIC_UNDEFINED_VAR/repo", "$LAZYRESTIC_UNDEFINED_VAR/repo"},
+		{"Undefined braced variable left as-is", "${LAZYRESTIC_UNDEFINED_VAR}/repo", "${LAZYRESTIC_UNDEFINED_VAR}/repo"},
+		{"Remote backend untouched", "sftp:user@host:/srv/restic", "sftp:user@host:/srv/restic"},
+		{"Empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandPath(tt.path); got != tt.want {
+				t.Errorf("expandPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_ExpandsRepositoryPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("BACKUP_ROOT", "/mnt/backup")
+
+	passwordFile := filepath.Join(home, "password.txt")
+	if err := os.WriteFile(passwordFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatalf("Failed to write password file: %v", err)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := `repositories:
+  - name: local
+    path: ${BACKUP_ROOT}/restic
+    password_file: ~/password.txt
+`
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadAndValidate(configPath)
+	if err != nil {
+		t.Fatalf("LoadAndValidate() failed: %v", err)
+	}
+
+	repo := cfg.Repositories[0]
+	if repo.Path != "/mnt/backup/restic" {
+		t.Errorf("Path = %q, want /mnt/backup/restic", repo.Path)
+	}
+	if repo.PasswordFile != passwordFile {
+		t.Errorf("PasswordFile = %q, want %q", repo.PasswordFile, passwordFile)
+	}
+
+	// Validation also expands paths on configs built in code
+	if err := validatePasswordFile("~/password.txt"); err != nil {
+		t.Errorf("validatePasswordFile() should check the expanded path: %v", err)
+	}
+}
+
+func TestLoad_Keybindings(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := "keybindings:\n  backup: B\n  mark: space\nrepositories: []\n"
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if cfg.Keybindings["backup"] != "B" || cfg.Keybindings["mark"] != "space" {
+		t.Errorf("Keybindings = %v, want backup=B mark=space", cfg.Keybindings)
+	}
+}
+
+func TestLoad_Theme(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := "theme:\n  name: light\n  colors:\n    primary: \"#FF8800\"\nrepositories: []\n"
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if cfg.Theme.Name != "light" || cfg.Theme.Colors["primary"] != "#FF8800" {
+		t.Errorf("Theme = %+v, want light with primary #FF8800", cfg.Theme)
+	}
+}
+
+func TestRenameRepository_Success(t *testing.T) {
+	cfg := &types.ResticConfig{Repositories: []types.RepositoryConfig{
+		{Name: "alpha", Path: "/repo/alpha", PasswordFile: "/pw/alpha"},
+		{Name: "beta", Path: "/repo/beta"},
+	}}
+
+	if err := RenameRepository(cfg, "alpha", "  offsite  "); err != nil {
+		t.Fatalf("RenameRepository() failed: %v", err)
+	}
+	renamed := cfg.Repositories[0]
+	if renamed.Name != "offsite" {
+		t.Errorf("Name = %q, want %q", renamed.Name, "offsite")
+	}
+	if renamed.Path != "/repo/alpha" || renamed.PasswordFile != "/pw/alpha" {
+		t.Errorf("Rename should only change the name, got %+v", renamed)
+	}
+}
+
+func TestRenameRepository_Rejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldName string
+		newName string
+		wantErr string
+	}{
+		{"duplicate name", "alpha", "beta", "already exists"},
+		{"empty name", "alpha", "", "cannot be empty"},
+		{"blank name", "alpha", "   ", "cannot be empty"},
+		{"unknown repository", "gamma", "delta", "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &types.ResticConfig{Repositories: []types.RepositoryConfig{
+				{Name: "alpha"}, {Name: "beta"},
+			}}
+			err := RenameRepository(cfg, tt.oldName, tt.newName)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("RenameRepository(%q, %q) error = %v, want %q", tt.oldName, tt.newName, err, tt.wantErr)
+			}
+			if cfg.Repositories[0].Name != "alpha" || cfg.Repositories[1].Name != "beta" {
+				t.Errorf("Rejected rename should leave names unchanged, got %+v", cfg.Repositories)
+			}
+		})
+	}
+}
+
+// writePermissionTestConfig writes a config whose two repositories use 0644 password files
+func writePermissionTestConfig(t *testing.T, autoFix bool) (string, []string) {
+	t.Helper()
+	dir := t.TempDir()
+	passwordFiles := []string{filepath.Join(dir, "alpha.txt"), filepath.Join(dir, "beta.txt")}
+	for _, path := range passwordFiles {
+		if err := os.WriteFile(path, []byte("secret\n"), 0600); err != nil {
+			t.Fatalf("Failed to write password file: %v", err)
+		}
+		// Chmod explicitly so the umask can't mask the loose permissions
+		if err := os.Chmod(path, 0644); err != nil {
+			t.Fatalf("Failed to chmod password file: %v", err)
+		}
+	}
+
+	content := fmt.Sprintf("auto_fix_permissions: %t\nrepositories:\n"+
+		"  - name: alpha\n    path: /repo/alpha\n    password_file: %s\n"+
+		"  - name: beta\n    path: /repo/beta\n    password_file: %s\n", autoFix, passwordFiles[0], passwordFiles[1])
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return configPath, passwordFiles
+}
+
+func TestLoadAndRepair_FixesPasswordFilePermissions(t *testing.T) {
+	configPath, passwordFiles := writePermissionTestConfig(t, true)
+
+	cfg, repaired, err := LoadAndRepair(configPath)
+	if err != nil {
+		t.Fatalf("LoadAndRepair() failed: %v", err)
+	}
+	if len(cfg.Repositories) != 2 {
+		t.Errorf("Expected 2 repositories after the retry, got %d", len(cfg.Repositories))
+	}
+	if !reflect.DeepEqual(repaired, passwordFiles) {
+		t.Errorf("repaired = %v, want %v", repaired, passwordFiles)
+	}
+	for _, path := range passwordFiles {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("%s permissions = %s, want 0600", path, info.Mode().Perm())
+		}
+	}
+}
+
+func TestLoadAndRepair_WithoutAutoFixReportsPermissionError(t *testing.T) {
+	configPath, passwordFiles := writePermissionTestConfig(t, false)
+
+	_, repaired, err := LoadAndRepair(configPath)
+	var permErr *PasswordFilePermissionError
+	if !errors.As(err, &permErr) {
+		t.Fatalf("Expected a PasswordFilePermissionError, got %v", err)
+	}
+	if permErr.Path != passwordFiles[0] || permErr.Mode != 0644 {
+		t.Errorf("PasswordFilePermissionError = %+v, want %s with 0644", permErr, passwordFiles[0])
+	}
+	if len(repaired) != 0 {
+		t.Errorf("Nothing should be repaired without auto_fix_permissions, got %v", repaired)
+	}
+
+	info, _ := os.Stat(passwordFiles[0])
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("Permissions should be left alone, got %s", info.Mode().Perm())
+	}
+}
+
+func TestLoadOrDefaultWithError_RecordsRepairedFiles(t *testing.T) {
+	configPath, passwordFiles := writePermissionTestConfig(t, true)
+
+	cfg, err := LoadOrDefaultWithError(configPath)
+	if err != nil {
+		t.Fatalf("LoadOrDefaultWithError() failed: %v", err)
+	}
+	if len(cfg.Repositories) != 2 || !reflect.DeepEqual(cfg.Repaired, passwordFiles) {
+		t.Errorf("Got %d repositories and Repaired %v, want 2 and %v", len(cfg.Repositories), cfg.Repaired, passwordFiles)
+	}
+}
+
+func TestLoadOrDefaultWithError(t *testing.T) {
+	dir := t.TempDir()
+	missingPassword := filepath.Join(dir, "missing.txt")
+	tests := []struct {
+		name    string
+		content string // empty means no config file
+		wantErr string
+	}{
+		{"missing config file", "", ""},
+		{"invalid YAML", "repositories: [\n", "failed to parse config YAML"},
+		{"repository without password", "repositories:\n  - name: alpha\n    path: /repo\n  - name: s3-repo\n    path: s3:host/bucket\n    password_file: " + missingPassword + "\n",
+			"repository 0 (alpha) validation failed: no password method specified"},
+		{"second repository invalid", "repositories:\n  - name: alpha\n    path: /repo\n    password_env: RESTIC_PASSWORD\n  - name: s3-repo\n    path: s3:host/bucket\n    password_file: " + missingPassword + "\n",
+			"repository 1 (s3-repo) validation failed: password_file validation failed: password file does not exist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			if tt.content != "" {
+				if err := os.WriteFile(configPath, []byte(tt.content), 0600); err != nil {
+					t.Fatalf("Failed to write config: %v", err)
+				}
+			}
+
+			cfg, err := LoadOrDefaultWithError(configPath)
+			if cfg == nil || len(cfg.Repositories) != 0 {
+				t.Errorf("Expected an empty config, got %+v", cfg)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("A missing config file should not be an error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSave_KeepsUnexpandedPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("BACKUP_ROOT", "/mnt/backup")
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := `repositories:
+  - name: local
+    path: ${BACKUP_ROOT}/restic
+    password_file: ~/password.txt
+    default_paths: [~/Documents]
+  - name: other
+    path: ~/other
+`
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	cfg.Repositories[0].Name = "renamed"
+	cfg.Repositories[1].Path = "/srv/other"
+	if err := Save(cfg, configPath); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := string(data)
+	for _, want := range []string{"path: ${BACKUP_ROOT}/restic", "password_file: ~/password.txt", "- ~/Documents", "path: /srv/other"} {
+		if !strings.Contains(saved, want) {
+			t.Errorf("Saved config is missing %q:\n%s", want, saved)
+		}
+	}
+	if strings.Contains(saved, home) {
+		t.Errorf("Saved config contains the expanded home directory:\n%s", saved)
+	}
+	if cfg.Repositories[0].Path != "/mnt/backup/restic" {
+		t.Errorf("Save() changed the in-memory path to %q", cfg.Repositories[0].Path)
+	}
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"gopkg.in/yaml.v2"
+)
+
+// CurrentConfigVersion is the config schema version this build reads and writes.
+// Configs without a version are version 0.
+const CurrentConfigVersion = 1
+
+// configMigrations upgrade a raw config one version each: entry i upgrades
+// version i to i+1. A migration returns a note for each change it made.
+var configMigrations = []func(raw yaml.MapSlice, dir string) ([]string, error){
+	migratePlainPasswords,
+}
+
+// migrateConfig upgrades a raw config read from the file at path to
+// CurrentConfigVersion. When a migration changed anything, the upgraded config
+// is written back (the original, minus plain-text passwords, is kept as
+// path.bak) so it only migrates once;
+// a config that needed no changes is left untouched. It returns the upgraded
+// YAML and what was migrated.
+func migrateConfig(data []byte, path string) ([]byte, []string, error) {
+	var raw yaml.MapSlice
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config YAML: %w", err)
+	}
+
+	version := 0
+	if value, ok := mapValue(raw, "version"); ok {
+		v, ok := value.(int)
+		if !ok || v < 0 {
+			return nil, nil, fmt.Errorf("invalid config version %v", value)
+		}
+		version = v
+	}
+	if version > CurrentConfigVersion {
+		return nil, nil, fmt.Errorf("config version %d is newer than this LazyRestic supports (%d) - upgrade LazyRestic", version, CurrentConfigVersion)
+	}
+
+	var notes []string
+	for v := version; v < CurrentConfigVersion; v++ {
+		changes, err := configMigrations[v](raw, filepath.Dir(path))
+		if err != nil {
+			return nil, notes, fmt.Errorf("failed to migrate config from version %d: %w", v, err)
+		}
+		notes = append(notes, changes...)
+	}
+	if len(notes) == 0 {
+		return data, nil, nil
+	}
+
+	raw = setMapValue(raw, "version", CurrentConfigVersion)
+	migrated, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, notes, fmt.Errorf("failed to marshal migrated config: %w", err)
+	}
+	backup := path + ".bak"
+	original, err := withoutPlainPasswords(data)
+	if err != nil {
+		return nil, notes, fmt.Errorf("failed to back up config before migrating: %w", err)
+	}
+	if err := os.WriteFile(backup, original, 0600); err != nil {
+		return nil, notes, fmt.Errorf("failed to back up config before migrating: %w", err)
+	}
+	if err := os.WriteFile(path, migrated, 0600); err != nil {
+		return nil, notes, fmt.Errorf("failed to write migrated config: %w", err)
+	}
+
+	notes = append(notes, fmt.Sprintf("Config upgraded from version %d to %d; the previous config was saved to %s", version, CurrentConfigVersion, backup))
+	return migrated, notes, nil
+}
+
+// withoutPlainPasswords returns a config with the deprecated plain-text
+// `password` fields removed, so a backup of it doesn't keep the secrets a
+// migration moved out of the config
+func withoutPlainPasswords(data []byte) ([]byte, error) {
+	var raw yaml.MapSlice
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	value, _ := mapValue(raw, "repositories")
+	repos, _ := value.([]interface{})
+	for i, item := range repos {
+		if repo, ok := item.(yaml.MapSlice); ok {
+			repos[i] = deleteMapValue(repo, "password")
+		}
+	}
+	return yaml.Marshal(raw)
+}
+
+// passwordFileNameChars matches characters not kept in generated password file names
+var passwordFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// migratePlainPasswords (version 0 to 1) moves deprecated plain-text
+// `password` fields into password files in a passwords directory next to the
+// config. A repository that already has another password method just loses
+// the plain-text copy.
+func migratePlainPasswords(raw yaml.MapSlice, dir string) ([]string, error) {
+	value, _ := mapValue(raw, "repositories")
+	repos, _ := value.([]interface{})
+
+	var notes []string
+	for i, item := range repos {
+		repo, ok := item.(yaml.MapSlice)
+		if !ok {
+			continue
+		}
+		password, ok := mapValue(repo, "password")
+		if !ok {
+			continue
+		}
+
+		name := fmt.Sprintf("repository-%d", i)
+		if value, ok := mapValue(repo, "name"); ok {
+			name = fmt.Sprint(value)
+		}
+
+		hasMethod := false
+		for _, key := range []string{"password_file", "password_command", "password_env"} {
+			if _, ok := mapValue(repo, key); ok {
+				hasMethod = true
+			}
+		}
+		if hasMethod {
+			repos[i] = deleteMapValue(repo, "password")
+			notes = append(notes, fmt.Sprintf("Removed the plain-text password of '%s'; it already has another password method", name))
+			continue
+		}
+
+		file := filepath.Join(dir, "passwords", passwordFileNameChars.ReplaceAllString(name, "_")+".txt")
+		if _, err := os.Stat(file); err == nil {
+			return notes, fmt.Errorf("can't move the password of '%s' to %s: the file already exists", name, file)
+		}
+		if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+			return notes, fmt.Errorf("failed to create password directory: %w", err)
+		}
+		if err := os.WriteFile(file, []byte(fmt.Sprint(password)+"\n"), 0600); err != nil {
+			return notes, fmt.Errorf("failed to write password file for '%s': %w", name, err)
+		}
+
+		repos[i] = renameMapKey(repo, "password", "password_file", file)
+		notes = append(notes, fmt.Sprintf("Moved the plain-text password of '%s' to %s", name, file))
+	}
+	return notes, nil
+}
+
+// mapValue returns the value of key in a YAML mapping
+func mapValue(m yaml.MapSlice, key string) (interface{}, bool) {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+// setMapValue sets key in a YAML mapping, adding it first if missing
+func setMapValue(m yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i, item := range m {
+		if item.Key == key {
+			m[i].Value = value
+			return m
+		}
+	}
+	return append(yaml.MapSlice{{Key: key, Value: value}}, m...)
+}
+
+// renameMapKey replaces key with newKey and value, keeping its position
+func renameMapKey(m yaml.MapSlice, key, newKey string, value interface{}) yaml.MapSlice {
+	for i, item := range m {
+		if item.Key == key {
+			m[i] = yaml.MapItem{Key: newKey, Value: value}
+		}
+	}
+	return m
+}
+
+// deleteMapValue removes key from a YAML mapping
+func deleteMapValue(m yaml.MapSlice, key string) yaml.MapSlice {
+	kept := m[:0]
+	for _, item := range m {
+		if item.Key != key {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad_MigratesV0PlainPassword(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	original := `# my backups
+repositories:
+  - name: home/nas
+    path: /srv/restic
+    password: s3cret
+  - name: offsite
+    path: sftp:host:/repo
+    password: old
+    password_command: pass show restic
+auto_refresh: 5m
+`
+	if err := os.WriteFile(configPath, []byte(original), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	config, err := LoadAndValidate(configPath)
+	if err != nil {
+		t.Fatalf("LoadAndValidate() failed: %v", err)
+	}
+
+	passwordFile := filepath.Join(dir, "passwords", "home_nas.txt")
+	if config.Repositories[0].PasswordFile != passwordFile {
+		t.Errorf("password_file = %q, want %q", config.Repositories[0].PasswordFile, passwordFile)
+	}
+	data, err := os.ReadFile(passwordFile)
+	if err != nil || strings.TrimSpace(string(data)) != "s3cret" {
+		t.Errorf("Password file holds %q (%v), want the old password", data, err)
+	}
+	if info, err := os.Stat(passwordFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Password file should be 0600, got %v", info.Mode().Perm())
+	}
+	if config.Repositories[1].PasswordFile != "" || config.Repositories[1].PasswordCommand != "pass show restic" {
+		t.Errorf("A repository with another password method should keep it, got %+v", config.Repositories[1])
+	}
+	if config.Version != CurrentConfigVersion || len(config.Migrated) != 3 {
+		t.Errorf("Expected version %d and three migration notes, got %d and %q", CurrentConfigVersion, config.Version, config.Migrated)
+	}
+
+	// The file was rewritten once, without the plain-text passwords
+	rewritten, _ := os.ReadFile(configPath)
+	if strings.Contains(string(rewritten), "s3cret") || strings.Contains(string(rewritten), "password: ") || !strings.HasPrefix(string(rewritten), "version: 1\n") {
+		t.Errorf("Unexpected migrated config:\n%s", rewritten)
+	}
+	backup, err := os.ReadFile(configPath + ".bak")
+	if err != nil || !strings.Contains(string(backup), "pass show restic") || !strings.Contains(string(backup), "auto_refresh: 5m") {
+		t.Errorf("The original config should be kept as a backup, got:\n%s", backup)
+	}
+	if strings.Contains(string(backup), "s3cret") || strings.Contains(string(backup), "password: ") {
+		t.Errorf("The backup should not keep the plain-text passwords, got:\n%s", backup)
+	}
+
+	config, err = LoadAndValidate(configPath)
+	if err != nil || len(config.Migrated) != 0 {
+		t.Errorf("A migrated config should load without migrating again, got %q (%v)", config.Migrated, err)
+	}
+}
+
+func TestLoad_CurrentConfigUntouched(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	original := "# comments survive\nrepositories: []\n"
+	if err := os.WriteFile(configPath, []byte(original), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if _, err := Load(configPath); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != original {
+		t.Errorf("A config with nothing to migrate should not be rewritten, got:\n%s", data)
+	}
+}
+
+func TestLoad_RejectsNewerVersion(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("version: 99\nrepositories: []\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("Expected a newer-version error, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/craigderington/lazyrestic/pkg/config"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+// maxBackupHistory caps how many backups a repository's history keeps; the
+// oldest are dropped first
+const maxBackupHistory = 500
+
+// backupHistoryRows is how many recent backups the history overlay lists
+const backupHistoryRows = 15
+
+// defaultBackupHistoryDir returns the directory of the per-repository backup
+// history files, next to the config file
+func defaultBackupHistoryDir() string {
+	return filepath.Join(filepath.Dir(config.DefaultConfigPath()), "history")
+}
+
+// backupHistoryPath returns the history file of a repository in dir
+func backupHistoryPath(dir, repoName string) string {
+	return filepath.Join(dir, safeFileName(repoName)+".json")
+}
+
+// renameBackupHistory moves a repository's history file in dir to its new
+// name, replacing any history left under that name. A repository without
+// history has nothing to move.
+func renameBackupHistory(dir, oldName, newName string) error {
+	oldPath, newPath := backupHistoryPath(dir, oldName), backupHistoryPath(dir, newName)
+	if oldPath == newPath {
+		return nil
+	}
+	if err := os.Rename(oldPath, newPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// readBackupHistory reads a repository's backup history, oldest first. A
+// missing file is an empty history.
+func readBackupHistory(path string) ([]types.BackupRecord, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup history: %w", err)
+	}
+	var records []types.BackupRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse backup history %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// appendBackupHistory adds a backup to the history file at path, dropping the
+// oldest entries beyond maxBackupHistory
+func appendBackupHistory(path string, record types.BackupRecord) error {
+	records, err := readBackupHistory(path)
+	if err != nil {
+		return err
+	}
+	records = append(records, record)
+	if len(records) > maxBackupHistory {
+		records = records[len(records)-maxBackupHistory:]
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode backup history: %w", err)
+	}
+	if err := writeFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write backup history: %w", err)
+	}
+	return nil
+}
+
+// recordBackup appends a completed backup to its repository's history. It
+// does nothing when the model keeps no history.
+func (m *Model) recordBackup(repoName string, summary *types.BackupSummary, now time.Time) {
+	if m.historyDir == "" || repoName == "" || summary == nil {
+		return
+	}
+	record := types.BackupRecord{
+		SnapshotID:   summary.SnapshotID,
+		Time:         now,
+		FilesNew:     summary.FilesNew,
+		FilesChanged: summary.FilesChanged,
+		DataAdded:    summary.DataAdded,
+	}
+	if err := appendBackupHistory(backupHistoryPath(m.historyDir, repoName), record); err != nil {
+		m.opsPanel.Warning(fmt.Sprintf("Couldn't save the backup to the history of '%s': %v", repoName, err))
+	}
+}
+
+// openBackupHistory reads the history of a repository and shows it
+func (m *Model) openBackupHistory(repoName string) {
+	if m.historyDir == "" {
+		m.opsPanel.Warning("Backup history is not available")
+		return
+	}
+	records, err := readBackupHistory(backupHistoryPath(m.historyDir, repoName))
+	if err != nil {
+		m.opsPanel.Error(fmt.Sprintf("Failed to load the backup history of '%s': %v", repoName, err))
+		return
+	}
+	if len(records) == 0 {
+		m.opsPanel.Info(fmt.Sprintf("No backups of '%s' recorded yet - backups run from LazyRestic are added to its history", repoName))
+		return
+	}
+	m.backupHistory = records
+	m.backupHistoryRepo = repoName
+	m.showBackupHistory = true
+}
+
+// backupTrend compares the data added by the latest backup with the average
+// of the ones before it, e.g. "↑ 40% vs previous average"
+func backupTrend(records []types.BackupRecord) string {
+	if len(records) < 2 {
+		return ""
+	}
+	var total int64
+	previous := records[:len(records)-1]
+	for _, record := range previous {
+		total += record.DataAdded
+	}
+	average := total / int64(len(previous))
+	latest := records[len(records)-1].DataAdded
+
+	if average == 0 {
+		if latest == 0 {
+			return "→ same as previous average"
+		}
+		return "↑ previous backups added nothing"
+	}
+	change := (latest - average) * 100 / average
+	switch {
+	case change > 0:
+		return fmt.Sprintf("↑ %d%% vs previous average", change)
+	case change < 0:
+		return fmt.Sprintf("↓ %d%% vs previous average", -change)
+	}
+	return "→ same as previous average"
+}
+
+// renderBackupHistory renders the recorded backups of a repository, newest
+// first, with the trend of data added
+func (m Model) renderBackupHistory() string {
+	dimmedStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Dimmed)
+	records := m.backupHistory
+
+	var b strings.Builder
+	b.WriteString(ui.TitleStyle.Render(fmt.Sprintf("Backup history for '%s'", m.backupHistoryRepo)) + "\n\n")
+
+	var total int64
+	added := make([]int64, len(records))
+	for i, record := range records {
+		added[i] = record.DataAdded
+		total += record.DataAdded
+	}
+	b.WriteString(fmt.Sprintf("%d backups, %s added in total, %s on average\n",
+		len(records), ui.FormatBytes(total), ui.FormatBytes(total/int64(len(records)))))
+	if line := ui.Sparkline(added, 40); line != "" {
+		b.WriteString("Data added: " + line + "  " + backupTrend(records) + "\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString(dimmedStyle.Render(fmt.Sprintf("%-16s  %-8s  %8s  %8s  %10s", "Time", "Snapshot", "New", "Changed", "Added")) + "\n")
+	for i := len(records) - 1; i >= 0 && i >= len(records)-backupHistoryRows; i-- {
+		record := records[i]
+		b.WriteString(fmt.Sprintf("%-16s  %-8.8s  %8d  %8d  %10s\n",
+			record.Time.Local().Format("2006-01-02 15:04"), record.SnapshotID,
+			record.FilesNew, record.FilesChanged, ui.FormatBytes(record.DataAdded)))
+	}
+	if len(records) > backupHistoryRows {
+		b.WriteString(dimmedStyle.Render(fmt.Sprintf("… %d older backups", len(records)-backupHistoryRows)) + "\n")
+	}
+
+	b.WriteString("\n" + dimmedStyle.Render("Esc to close"))
+
+	borderStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.CurrentTheme().Active).
+		Padding(1, 2)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		borderStyle.Render(b.String()),
+	)
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+func TestBackupHistory_AppendAndReadBack(t *testing.T) {
+	path := backupHistoryPath(filepath.Join(t.TempDir(), "history"), "nas/photos")
+
+	records := []types.BackupRecord{
+		{SnapshotID: "aaaa1111", Time: time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC), FilesNew: 120, FilesChanged: 4, DataAdded: 52428800},
+		{SnapshotID: "bbbb2222", Time: time.Date(2024, 3, 2, 2, 0, 0, 0, time.UTC), FilesNew: 3, FilesChanged: 9, DataAdded: 1048576},
+	}
+	for _, record := range records {
+		if err := appendBackupHistory(path, record); err != nil {
+			t.Fatalf("appendBackupHistory() failed: %v", err)
+		}
+	}
+
+	got, err := readBackupHistory(path)
+	if err != nil {
+		t.Fatalf("readBackupHistory() failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, records) {
+		t.Errorf("History mismatch:\n got %+v\nwant %+v", got, records)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("History file permissions = %o, want 600", perm)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("Expected only the history file in its directory, found %d entries", len(entries))
+	}
+}
+
+func TestBackupHistory_MissingFileIsEmpty(t *testing.T) {
+	records, err := readBackupHistory(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || len(records) != 0 {
+		t.Errorf("readBackupHistory(missing) = %v, %v; want an empty history", records, err)
+	}
+}
+
+func TestBackupHistory_KeepsNewest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repo.json")
+	for i := 0; i < maxBackupHistory+2; i++ {
+		if err := appendBackupHistory(path, types.BackupRecord{DataAdded: int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, _ := readBackupHistory(path)
+	if len(records) != maxBackupHistory || records[0].DataAdded != 2 {
+		t.Errorf("Expected the newest %d backups, got %d starting at %d", maxBackupHistory, len(records), records[0].DataAdded)
+	}
+}
+
+func TestBackupTrend(t *testing.T) {
+	added := func(values ...int64) []types.BackupRecord {
+		records := make([]types.BackupRecord, len(values))
+		for i, v := range values {
+			records[i].DataAdded = v
+		}
+		return records
+	}
+
+	tests := []struct {
+		name    string
+		records []types.BackupRecord
+		want    string
+	}{
+		{"single backup", added(100), ""},
+		{"more than usual", added(100, 200, 210), "↑ 40% vs previous average"},
+		{"less than usual", added(100, 100, 25), "↓ 75% vs previous average"},
+		{"steady", added(100, 100), "→ same as previous average"},
+		{"first data", added(0, 0, 50), "↑ previous backups added nothing"},
+	}
+	for _, tt := range tests {
+		if got := backupTrend(tt.records); got != tt.want {
+			t.Errorf("%s: backupTrend() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
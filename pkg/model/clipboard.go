@@ -0,0 +1,41 @@
+package model
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+)
+
+// osc52Clipboard copies text with the OSC52 terminal escape sequence. The
+// terminal emulator sets the clipboard, so this also works over SSH where
+// there is no local X server.
+type osc52Clipboard struct {
+	out io.Writer // Defaults to the controlling terminal when nil
+}
+
+// Copy writes text to the clipboard through the terminal
+func (c osc52Clipboard) Copy(text string) error {
+	out := c.out
+	if out == nil {
+		tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("no terminal available: %w", err)
+		}
+		defer tty.Close()
+		out = tty
+	}
+
+	_, err := io.WriteString(out, osc52Sequence(text, os.Getenv("TMUX") != ""))
+	return err
+}
+
+// osc52Sequence builds the escape sequence that sets the clipboard to text,
+// wrapped in a DCS passthrough when running inside tmux
+func osc52Sequence(text string, tmux bool) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if tmux {
+		return "\x1bPtmux;\x1b" + seq + "\x1b\\"
+	}
+	return seq
+}
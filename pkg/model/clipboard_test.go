@@ -0,0 +1,28 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOSC52Sequence(t *testing.T) {
+	if got := osc52Sequence("abc123", false); got != "\x1b]52;c;YWJjMTIz\a" {
+		t.Errorf("osc52Sequence() = %q", got)
+	}
+
+	if got := osc52Sequence("abc123", true); got != "\x1bPtmux;\x1b\x1b]52;c;YWJjMTIz\a\x1b\\" {
+		t.Errorf("osc52Sequence() inside tmux = %q", got)
+	}
+}
+
+func TestOSC52Clipboard_Copy(t *testing.T) {
+	t.Setenv("TMUX", "")
+
+	var buf bytes.Buffer
+	if err := (osc52Clipboard{out: &buf}).Copy("abc123"); err != nil {
+		t.Fatalf("Copy() failed: %v", err)
+	}
+	if buf.String() != "\x1b]52;c;YWJjMTIz\a" {
+		t.Errorf("Copy() wrote %q", buf.String())
+	}
+}
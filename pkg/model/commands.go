@@ -3,14 +3,20 @@ package model
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/craigderington/lazyrestic/pkg/ops"
 	"github.com/craigderington/lazyrestic/pkg/restic"
 	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-// executeBackup performs a backup operation with progress tracking
-func (m Model) executeBackup(opts types.BackupOptions) tea.Cmd {
+// executeBackup performs a backup operation with progress tracking; cancelling
+// ctx interrupts it
+func (m Model) executeBackup(ctx context.Context, opts types.BackupOptions) tea.Cmd {
 	if m.currentRepoIndex >= len(m.config.Repositories) {
 		return func() tea.Msg {
 			return BackupSummaryMsg{Error: fmt.Errorf("no repository selected")}
@@ -18,14 +24,17 @@ func (m Model) executeBackup(opts types.BackupOptions) tea.Cmd {
 	}
 
 	repoConfig := m.config.Repositories[m.currentRepoIndex]
-	client := restic.NewClient(repoConfig)
+	client := m.newClient(repoConfig)
 
 	return func() tea.Msg {
+		if err := client.Ping(); err != nil {
+			return BackupSummaryMsg{Error: err}
+		}
+
 		// Create a channel for backup updates
 		updates := make(chan restic.BackupMessage, 10)
 
 		// Start the backup in a goroutine
-		ctx := context.Background()
 		go client.BackupWithChannel(ctx, opts, updates)
 
 		// Wait for the first message
@@ -53,8 +62,12 @@ func waitForBackupUpdate(updates <-chan restic.BackupMessage) tea.Msg {
 		}
 	}
 
+	if msg.StderrLine != "" {
+		return BackupProgressMsg{StderrLine: msg.StderrLine, Updates: updates}
+	}
+
 	if msg.Summary != nil {
-		return BackupSummaryMsg{Summary: msg.Summary, Error: nil}
+		return BackupSummaryMsg{Summary: msg.Summary, Warning: msg.Warning, Error: nil}
 	}
 
 	// Empty message, continue listening
@@ -68,8 +81,25 @@ func listenForBackupUpdates(updates <-chan restic.BackupMessage) tea.Cmd {
 	}
 }
 
-// executeRestore performs a restore operation with progress tracking
-func (m Model) executeRestore(opts types.RestoreOptions) tea.Cmd {
+// estimateExclusions measures what the exclude rules of a completed backup of
+// repoName skipped
+func (m Model) estimateExclusions(repoName string, opts types.BackupOptions, summary *types.BackupSummary) tea.Cmd {
+	for _, repoConfig := range m.config.Repositories {
+		if repoConfig.Name != repoName {
+			continue
+		}
+		client := m.newClient(repoConfig)
+		return func() tea.Msg {
+			excluded, err := client.EstimateExcluded(opts, summary)
+			return ExclusionSummaryMsg{RepoName: repoName, Summary: excluded, Error: err}
+		}
+	}
+	return nil
+}
+
+// executeRestore performs a restore operation with progress tracking; cancelling
+// ctx interrupts it
+func (m Model) executeRestore(ctx context.Context, opts types.RestoreOptions) tea.Cmd {
 	if m.currentRepoIndex >= len(m.config.Repositories) {
 		return func() tea.Msg {
 			return RestoreSummaryMsg{Error: fmt.Errorf("no repository selected")}
@@ -77,14 +107,17 @@ func (m Model) executeRestore(opts types.RestoreOptions) tea.Cmd {
 	}
 
 	repoConfig := m.config.Repositories[m.currentRepoIndex]
-	client := restic.NewClient(repoConfig)
+	client := m.newClient(repoConfig)
 
 	return func() tea.Msg {
+		if err := client.Ping(); err != nil {
+			return RestoreSummaryMsg{Error: err}
+		}
+
 		// Create a channel for restore updates
 		updates := make(chan restic.RestoreMessage, 10)
 
 		// Start the restore in a goroutine
-		ctx := context.Background()
 		go client.RestoreWithChannel(ctx, opts, updates)
 
 		// Wait for the first message
@@ -92,6 +125,37 @@ func (m Model) executeRestore(opts types.RestoreOptions) tea.Cmd {
 	}
 }
 
+// previewRestore lists the paths a restore with opts would write, without restoring,
+// and checks the target has room for the snapshot
+func (m Model) previewRestore(opts types.RestoreOptions) tea.Cmd {
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return func() tea.Msg {
+			return RestorePreviewMsg{Options: opts, Error: fmt.Errorf("no repository selected")}
+		}
+	}
+
+	client := m.newClient(m.config.Repositories[m.currentRepoIndex])
+	restoreSize := func() (int64, error) {
+		stats, err := client.GetSnapshotStats(opts.SnapshotID)
+		if err != nil {
+			return 0, err
+		}
+		return stats.TotalSize, nil
+	}
+	if size, ok := m.snapPanel.RestoreSize(opts.SnapshotID); ok {
+		restoreSize = func() (int64, error) { return size, nil }
+	}
+
+	return func() tea.Msg {
+		paths, err := client.RestoreDryRun(opts)
+		if err != nil {
+			return RestorePreviewMsg{Options: opts, Error: err}
+		}
+		space := restoreSpaceCheck(opts, restoreSize, freeDiskSpace)
+		return RestorePreviewMsg{Options: opts, Paths: paths, Space: space}
+	}
+}
+
 // waitForRestoreUpdate waits for a restore update from the channel
 func waitForRestoreUpdate(updates <-chan restic.RestoreMessage) tea.Msg {
 	msg, ok := <-updates
@@ -136,9 +200,12 @@ func (m Model) executeForgetDryRun(policy types.ForgetPolicy) tea.Cmd {
 	}
 
 	repoConfig := m.config.Repositories[m.currentRepoIndex]
-	client := restic.NewClient(repoConfig)
+	client := m.newClient(repoConfig)
 
 	return func() tea.Msg {
+		if err := client.Ping(); err != nil {
+			return ForgetDryRunMsg{Policy: policy, Error: err}
+		}
 		results, err := client.ForgetDryRun(policy)
 		return ForgetDryRunMsg{
 			Results: results,
@@ -157,11 +224,26 @@ func (m Model) executeForget(policy types.ForgetPolicy) tea.Cmd {
 	}
 
 	repoConfig := m.config.Repositories[m.currentRepoIndex]
-	client := restic.NewClient(repoConfig)
+	client := m.newClient(repoConfig)
 
 	return func() tea.Msg {
-		err := client.Forget(policy)
-		return ForgetCompleteMsg{Error: err}
+		return ForgetCompleteMsg{RepoName: repoConfig.Name, Error: ops.Forget(client, policy)}
+	}
+}
+
+// executeForgetByID forgets the given snapshots by ID
+func (m Model) executeForgetByID(ids []string) tea.Cmd {
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return func() tea.Msg {
+			return ForgetCompleteMsg{IDs: ids, Error: fmt.Errorf("no repository selected")}
+		}
+	}
+
+	repoConfig := m.config.Repositories[m.currentRepoIndex]
+	client := m.newClient(repoConfig)
+
+	return func() tea.Msg {
+		return ForgetCompleteMsg{IDs: ids, RepoName: repoConfig.Name, Error: ops.ForgetByID(client, ids)}
 	}
 }
 
@@ -174,7 +256,7 @@ func (m Model) executePruneDryRun() tea.Cmd {
 	}
 
 	repoConfig := m.config.Repositories[m.currentRepoIndex]
-	client := restic.NewClient(repoConfig)
+	client := m.newClient(repoConfig)
 
 	return func() tea.Msg {
 		output, err := client.PruneDryRun()
@@ -194,10 +276,557 @@ func (m Model) executePrune() tea.Cmd {
 	}
 
 	repoConfig := m.config.Repositories[m.currentRepoIndex]
-	client := restic.NewClient(repoConfig)
+	client := m.newClient(repoConfig)
 
 	return func() tea.Msg {
 		err := client.Prune()
 		return PruneCompleteMsg{Error: err}
 	}
 }
+
+// setSnapshotProtected adds or removes the protected tag on a snapshot
+func (m Model) setSnapshotProtected(snapshot types.Snapshot, protected bool) tea.Cmd {
+	name := "unprotect"
+	if protected {
+		name = "protect"
+	}
+	return m.runRepoCommand(name, func(client ResticClient) (string, error) {
+		return "", client.SetProtected(snapshot.ID, protected)
+	})
+}
+
+// loadRepoConfig reads the selected repository's config blob
+func (m Model) loadRepoConfig() tea.Cmd {
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return nil
+	}
+
+	repoConfig := m.config.Repositories[m.currentRepoIndex]
+	client := m.newClient(repoConfig)
+	return func() tea.Msg {
+		blob, err := client.GetConfigBlob()
+		return RepoConfigMsg{RepoName: repoConfig.Name, Config: blob, Error: err}
+	}
+}
+
+// mountRepository mounts the selected repository with restic mount on a new temporary directory
+func (m Model) mountRepository() tea.Cmd {
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return nil
+	}
+
+	repoConfig := m.config.Repositories[m.currentRepoIndex]
+	client := m.newClient(repoConfig)
+	return func() tea.Msg {
+		mountPoint, err := os.MkdirTemp("", "lazyrestic-mount-")
+		if err != nil {
+			return RepoMountedMsg{RepoName: repoConfig.Name, Error: fmt.Errorf("failed to create mount point: %w", err)}
+		}
+
+		cmd, err := client.Mount(mountPoint)
+		if err != nil {
+			os.Remove(mountPoint)
+			return RepoMountedMsg{RepoName: repoConfig.Name, Error: err}
+		}
+		return RepoMountedMsg{RepoName: repoConfig.Name, MountPoint: mountPoint, Cmd: cmd}
+	}
+}
+
+// unmountRepository stops the running restic mount and removes its mount point
+func (m Model) unmountRepository() tea.Cmd {
+	cmd, mountPoint, repoName := m.mountCmd, m.mountPoint, m.mountRepo
+	return func() tea.Msg {
+		err := restic.Unmount(cmd)
+		os.Remove(mountPoint)
+		return RepoUnmountedMsg{RepoName: repoName, MountPoint: mountPoint, Error: err}
+	}
+}
+
+// scheduleAutoRefresh waits for the configured auto-refresh interval.
+// Returns nil when auto-refresh is disabled or paused.
+func (m Model) scheduleAutoRefresh() tea.Cmd {
+	if m.config.AutoRefresh <= 0 || m.autoRefreshPaused {
+		return nil
+	}
+	gen := m.autoRefreshGen
+	return tea.Tick(m.config.AutoRefresh, func(time.Time) tea.Msg {
+		return AutoRefreshTickMsg{Gen: gen}
+	})
+}
+
+// confirmTimeoutSeconds is how long a dangerous confirmation may sit without input
+const confirmTimeoutSeconds = 120
+
+// scheduleConfirmTimeout waits for a confirmation dialog's inactivity timeout.
+// Call it whenever the dialog opens or receives input; only the tick matching
+// the latest input cancels the dialog. Returns nil when the dialog has no timeout.
+func scheduleConfirmTimeout(dialog *ui.ConfirmationDialog) tea.Cmd {
+	if dialog == nil || dialog.TimeoutSeconds() <= 0 {
+		return nil
+	}
+	activity := dialog.Activity()
+	return tea.Tick(time.Duration(dialog.TimeoutSeconds())*time.Second, func(time.Time) tea.Msg {
+		return ConfirmTimeoutMsg{Dialog: dialog, Activity: activity}
+	})
+}
+
+// quitWaitInterval is how often quitting checks whether the cancelled operation has stopped
+const quitWaitInterval = 100 * time.Millisecond
+
+// quitWaitTimeout bounds how long quitting waits for a cancelled operation.
+// restic kills an interrupted command after its grace period, so this only
+// matters for operations that can't be interrupted.
+const quitWaitTimeout = restic.CancelGracePeriod + 2*time.Second
+
+// runningOperation names the operation quitting would interrupt, or "" when idle.
+// Loading repositories and snapshots only reads, so it doesn't count.
+func (m Model) runningOperation() string {
+	switch {
+	case m.backupInProgress:
+		return "backup"
+	case m.restoreInProgress:
+		return "restore"
+	case m.checkInProgress:
+		return "data check"
+	case m.repairInProgress:
+		return "index repair"
+	case m.migrateInProgress:
+		return "migration"
+	case m.forgetInProgress:
+		return "forget"
+	case m.rawCommandInProgress:
+		return "restic command"
+	}
+	return m.operationInProgress
+}
+
+// quit releases what LazyRestic holds outside the process and exits
+func (m *Model) quit() tea.Cmd {
+	// Don't leave a restic mount behind
+	if m.mountCmd != nil {
+		restic.Unmount(m.mountCmd)
+		os.Remove(m.mountPoint)
+	}
+	m.opsPanel.CloseLogFile()
+	return tea.Quit
+}
+
+// cancelAndQuit cancels the running operation and quits once it has stopped
+func (m *Model) cancelAndQuit() tea.Cmd {
+	m.quitPending = true
+	m.opsPanel.Warning(fmt.Sprintf("Cancelling %s before quitting...", m.runningOperation()))
+	if m.cancelOperation != nil {
+		m.cancelOperation()
+	}
+	return waitToQuit(time.Now())
+}
+
+// waitToQuit checks again shortly whether the cancelled operation has stopped
+func waitToQuit(started time.Time) tea.Cmd {
+	return tea.Tick(quitWaitInterval, func(time.Time) tea.Msg {
+		return QuitWaitMsg{Started: started}
+	})
+}
+
+// operationTickInterval is how often the running operation spinner advances
+const operationTickInterval = 100 * time.Millisecond
+
+// startOperation marks name as running and shows a spinner with the elapsed time
+// in the operations panel until finishOperation. Returns cmd batched with the spinner ticker.
+func (m *Model) startOperation(name string, cmd tea.Cmd) tea.Cmd {
+	m.operationInProgress = name
+	m.operationStarted = time.Now()
+	m.operationGen++
+	m.spinnerFrame = 0
+	m.updateOperationActivity(m.operationStarted)
+	return tea.Batch(cmd, scheduleOperationTick(m.operationGen))
+}
+
+// operationContext returns the context for a backup, restore or maintenance
+// command. Quitting while it runs cancels it so restic can release its lock.
+func (m *Model) operationContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelOperation = cancel
+	return ctx
+}
+
+// finishOperation clears the running operation and its spinner
+func (m *Model) finishOperation() {
+	m.operationInProgress = ""
+	m.opsPanel.ClearActivity()
+}
+
+// runRepoCommand runs fn against a client for the current repository and reports
+// its output as a CommandResultMsg named name. Use it for one-shot restic
+// commands that only need their output logged. Queries whose result fills an
+// overlay (the config blob, migrations, keys) keep their own typed messages.
+func (m Model) runRepoCommand(name string, fn func(ResticClient) (string, error)) tea.Cmd {
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return func() tea.Msg {
+			return CommandResultMsg{Name: name, Error: fmt.Errorf("no repository selected")}
+		}
+	}
+	return m.runRepoCommandOn(m.config.Repositories[m.currentRepoIndex], name, fn)
+}
+
+// runRepoCommandOn runs fn like runRepoCommand against the given repository
+func (m Model) runRepoCommandOn(repoConfig types.RepositoryConfig, name string, fn func(ResticClient) (string, error)) tea.Cmd {
+	client := m.newClient(repoConfig)
+	return func() tea.Msg {
+		output, err := fn(client)
+		return CommandResultMsg{Name: name, RepoName: repoConfig.Name, Output: output, Error: err}
+	}
+}
+
+// commandResult describes how a successful runRepoCommand result is logged
+type commandResult struct {
+	success         string // Success line; defaults to "✓ <Name> completed"
+	detail          string // Dimmed line after the output
+	refresh         bool   // Reload the repositories afterwards
+	reloadSnapshots bool   // Reload the snapshots afterwards
+	relistKeys      bool   // List the repository's keys again afterwards
+}
+
+// commandResults holds the logging for each command run through runRepoCommand
+var commandResults = map[string]commandResult{
+	"cache cleanup": {
+		success: "✓ Cache cleanup completed successfully",
+		detail:  "Removed old/unused cache entries",
+	},
+	"unlock": {
+		success: "✓ Repository unlocked successfully",
+		detail:  "Stale locks removed - repository is now accessible",
+		refresh: true,
+	},
+	"unlock --remove-all": {
+		success: "✓ Repository unlocked successfully",
+		detail:  "All locks removed (restic unlock --remove-all) - repository is now accessible",
+		refresh: true,
+	},
+	"protect": {
+		success:         "✓ Snapshot protected - forget policies will always keep it",
+		detail:          "restic tag rewrites the snapshot, so it has a new ID",
+		reloadSnapshots: true,
+	},
+	"unprotect": {
+		success:         "✓ Snapshot is no longer protected",
+		detail:          "restic tag rewrites the snapshot, so it has a new ID",
+		reloadSnapshots: true,
+	},
+	"key add": {
+		success:    "✓ Key added",
+		relistKeys: true,
+	},
+	"key remove": {
+		success:    "✓ Key removed",
+		relistKeys: true,
+	},
+}
+
+// handleCommandResult logs the outcome of a runRepoCommand command
+func (m *Model) handleCommandResult(msg CommandResultMsg) tea.Cmd {
+	title := msg.Name
+	if title != "" {
+		title = strings.ToUpper(title[:1]) + title[1:]
+	}
+	if msg.Error != nil {
+		m.opsPanel.Error(fmt.Sprintf("%s failed: %s", title, m.errorText(msg.Error)))
+		return nil
+	}
+
+	result := commandResults[msg.Name]
+	if result.success == "" {
+		result.success = fmt.Sprintf("✓ %s completed", title)
+	}
+	m.opsPanel.Success(result.success)
+	if msg.Output != "" {
+		m.opsPanel.Info(msg.Output)
+	}
+	if result.detail != "" {
+		m.opsPanel.Dimmed(result.detail)
+	}
+	switch {
+	case result.refresh:
+		return m.loadRepositories
+	case result.reloadSnapshots:
+		return m.loadSnapshotsWithMessage()
+	case result.relistKeys:
+		return m.relistKeys(msg.RepoName)
+	}
+	return nil
+}
+
+// updateOperationActivity renders the spinner line for the running operation
+func (m *Model) updateOperationActivity(now time.Time) {
+	frame := ui.SpinnerFrames[m.spinnerFrame%len(ui.SpinnerFrames)]
+	elapsed := int(now.Sub(m.operationStarted).Seconds())
+	m.opsPanel.SetActivity(fmt.Sprintf("%s Running %s... %s", frame, m.operationInProgress, ui.FormatSeconds(elapsed)))
+}
+
+// scheduleOperationTick waits for the next spinner frame of operation gen
+func scheduleOperationTick(gen int) tea.Cmd {
+	return tea.Tick(operationTickInterval, func(time.Time) tea.Msg {
+		return OperationTickMsg{Gen: gen}
+	})
+}
+
+// resetAutoRefresh restarts the auto-refresh timer from now, dropping any pending tick
+func (m *Model) resetAutoRefresh() tea.Cmd {
+	m.autoRefreshGen++
+	return m.scheduleAutoRefresh()
+}
+
+// fetchVisibleSnapshotSizes computes on-disk sizes for visible snapshots that aren't cached yet.
+// Lookups run one at a time since each `restic stats --mode raw-data` is expensive.
+func (m Model) fetchVisibleSnapshotSizes() tea.Cmd {
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return nil
+	}
+	ids := m.snapPanel.MissingVisibleSizes()
+	if len(ids) == 0 {
+		return nil
+	}
+
+	client := m.newClient(m.config.Repositories[m.currentRepoIndex])
+	cmds := make([]tea.Cmd, 0, len(ids))
+	for _, id := range ids {
+		id := id
+		cmds = append(cmds, func() tea.Msg {
+			stats, err := client.GetSnapshotSize(id)
+			if err != nil {
+				return SnapshotSizeMsg{ID: id, Error: err}
+			}
+			return SnapshotSizeMsg{ID: id, Size: stats.TotalSize}
+		})
+	}
+	return tea.Sequence(cmds...)
+}
+
+// executeDataCheck runs restic check --read-data-subset, streaming output lines
+func (m Model) executeDataCheck(ctx context.Context, subset string) tea.Cmd {
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return func() tea.Msg {
+			return CheckCompleteMsg{Error: fmt.Errorf("no repository selected")}
+		}
+	}
+
+	client := m.newClient(m.config.Repositories[m.currentRepoIndex])
+
+	return func() tea.Msg {
+		updates := make(chan restic.CheckMessage, 10)
+		go client.CheckRepositoryDataWithChannel(ctx, subset, updates)
+		return waitForCheckUpdate(updates)
+	}
+}
+
+// waitForCheckUpdate waits for the next line or the final result of a data check
+func waitForCheckUpdate(updates <-chan restic.CheckMessage) tea.Msg {
+	msg, ok := <-updates
+	if !ok {
+		return CheckCompleteMsg{}
+	}
+	if msg.Error != nil {
+		return CheckCompleteMsg{Error: msg.Error}
+	}
+	return CheckOutputMsg{Line: msg.Line, Updates: updates}
+}
+
+// listenForCheckUpdates continues listening for data check output
+func listenForCheckUpdates(updates <-chan restic.CheckMessage) tea.Cmd {
+	return func() tea.Msg {
+		return waitForCheckUpdate(updates)
+	}
+}
+
+// executeRepairIndex runs restic repair index on the selected repository, streaming output lines
+func (m Model) executeRepairIndex(ctx context.Context) tea.Cmd {
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return func() tea.Msg {
+			return RepairCompleteMsg{Error: fmt.Errorf("no repository selected")}
+		}
+	}
+
+	repoConfig := m.config.Repositories[m.currentRepoIndex]
+	client := m.newClient(repoConfig)
+
+	return func() tea.Msg {
+		updates := make(chan restic.CheckMessage, 10)
+		go client.RepairIndexWithChannel(ctx, updates)
+		return waitForRepairUpdate(repoConfig.Name, updates)
+	}
+}
+
+// waitForRepairUpdate waits for the next line or the final result of an index repair
+func waitForRepairUpdate(repoName string, updates <-chan restic.CheckMessage) tea.Msg {
+	msg, ok := <-updates
+	if !ok {
+		return RepairCompleteMsg{RepoName: repoName}
+	}
+	if msg.Error != nil {
+		return RepairCompleteMsg{RepoName: repoName, Error: msg.Error}
+	}
+	return RepairOutputMsg{RepoName: repoName, Line: msg.Line, Updates: updates}
+}
+
+// listenForRepairUpdates continues listening for index repair output
+func listenForRepairUpdates(repoName string, updates <-chan restic.CheckMessage) tea.Cmd {
+	return func() tea.Msg {
+		return waitForRepairUpdate(repoName, updates)
+	}
+}
+
+// listMigrations asks restic which migrations the selected repository can apply
+func (m Model) listMigrations() tea.Cmd {
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return nil
+	}
+
+	repoConfig := m.config.Repositories[m.currentRepoIndex]
+	client := m.newClient(repoConfig)
+	return func() tea.Msg {
+		migrations, err := client.ListMigrations()
+		return MigrationsListedMsg{RepoName: repoConfig.Name, Migrations: migrations, Error: err}
+	}
+}
+
+// executeMigration applies a migration to the named repository, streaming output lines
+func (m Model) executeMigration(ctx context.Context, repoName, migration string) tea.Cmd {
+	var client ResticClient
+	for _, repoConfig := range m.config.Repositories {
+		if repoConfig.Name == repoName {
+			client = m.newClient(repoConfig)
+		}
+	}
+	if client == nil {
+		return func() tea.Msg {
+			return MigrationCompleteMsg{RepoName: repoName, Migration: migration, Error: fmt.Errorf("repository '%s' not found", repoName)}
+		}
+	}
+
+	return func() tea.Msg {
+		updates := make(chan restic.CheckMessage, 10)
+		go client.ApplyMigrationWithChannel(ctx, migration, updates)
+		return waitForMigrationUpdate(repoName, migration, updates)
+	}
+}
+
+// waitForMigrationUpdate waits for the next line or the final result of a migration
+func waitForMigrationUpdate(repoName, migration string, updates <-chan restic.CheckMessage) tea.Msg {
+	msg, ok := <-updates
+	if !ok {
+		return MigrationCompleteMsg{RepoName: repoName, Migration: migration}
+	}
+	if msg.Error != nil {
+		return MigrationCompleteMsg{RepoName: repoName, Migration: migration, Error: msg.Error}
+	}
+	return MigrationOutputMsg{RepoName: repoName, Migration: migration, Line: msg.Line, Updates: updates}
+}
+
+// listenForMigrationUpdates continues listening for migration output
+func listenForMigrationUpdates(repoName, migration string, updates <-chan restic.CheckMessage) tea.Cmd {
+	return func() tea.Msg {
+		return waitForMigrationUpdate(repoName, migration, updates)
+	}
+}
+
+// fetchSelectedSnapshotStats computes the restore size of the selected snapshot unless cached
+func (m Model) fetchSelectedSnapshotStats() tea.Cmd {
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return nil
+	}
+	id, ok := m.snapPanel.SelectedNeedsRestoreSize()
+	if !ok {
+		return nil
+	}
+
+	client := m.newClient(m.config.Repositories[m.currentRepoIndex])
+	return func() tea.Msg {
+		stats, err := client.GetSnapshotStats(id)
+		return SnapshotStatsMsg{ID: id, Stats: stats, Error: err}
+	}
+}
+
+// sizeHistoryLength is how many recent snapshots the metrics growth sparkline covers
+const sizeHistoryLength = 30
+
+// fetchSizeHistory shows the cached restore sizes of the selected repository's
+// recent snapshots and reads the next missing one. Each result fetches the
+// next, so at most one restic stats runs at a time and none start while a
+// backup or restore is running.
+func (m Model) fetchSizeHistory() tea.Cmd {
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return nil
+	}
+	repoConfig := m.config.Repositories[m.currentRepoIndex]
+	m.metricsPanel.SetSizeHistory(repoConfig.Name, m.snapPanel.RecentRestoreSizes(sizeHistoryLength))
+
+	if m.backupInProgress || m.restoreInProgress {
+		return nil
+	}
+	id, ok := m.snapPanel.NextRecentRestoreSize(sizeHistoryLength)
+	if !ok {
+		return nil
+	}
+
+	client := m.newClient(repoConfig)
+	return func() tea.Msg {
+		stats, err := client.GetSnapshotStats(id)
+		return SnapshotStatsMsg{ID: id, Stats: stats, Error: err, ForHistory: true}
+	}
+}
+
+// fetchDuplicateStats measures how much of the selected repository's latest
+// snapshot repeats the contents of other files in it
+func (m Model) fetchDuplicateStats() tea.Cmd {
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return nil
+	}
+
+	repoConfig := m.config.Repositories[m.currentRepoIndex]
+	client := m.newClient(repoConfig)
+	return func() tea.Msg {
+		stats, err := client.GetDuplicateStats()
+		return DuplicateStatsMsg{RepoName: repoConfig.Name, RepoPath: repoConfig.Path, Stats: stats, Error: err}
+	}
+}
+
+// findFiles searches all snapshots of the current repository for pattern
+func (m Model) findFiles(pattern string) tea.Cmd {
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return nil
+	}
+
+	client := m.newClient(m.config.Repositories[m.currentRepoIndex])
+	return func() tea.Msg {
+		results, err := client.Find(pattern)
+		return FindResultsMsg{Pattern: pattern, Results: results, Error: err}
+	}
+}
+
+// rerunTimeout bounds a re-run command, which may be a long check or backup
+const rerunTimeout = 2 * time.Hour
+
+// rerunCommand runs the recorded restic command again
+func rerunCommand(ctx context.Context, cmd restic.Command) tea.Cmd {
+	return func() tea.Msg {
+		output, err := restic.Rerun(ctx, cmd, rerunTimeout)
+		return CommandRerunMsg{RepoName: cmd.RepoName(), Command: cmd.String(), Output: string(output), Error: err}
+	}
+}
+
+// startRerun runs a recorded command again once nothing else is running and
+// its repository is free
+func (m *Model) startRerun(last restic.Command) tea.Cmd {
+	if m.isBusy() {
+		m.opsPanel.Warning("Wait for the current operation to finish before re-running")
+		return nil
+	}
+	if !m.claimRepo(last.RepoName()) {
+		return nil
+	}
+	m.opsPanel.Info(fmt.Sprintf("Re-running: %s", last))
+	return m.startOperation("re-run", rerunCommand(m.operationContext(), last))
+}
+
+// rerunOutputLines caps how much output of a re-run command is logged
+const rerunOutputLines = 20
@@ -0,0 +1,139 @@
+package model
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+func TestRunRepoCommand(t *testing.T) {
+	m := Model{config: &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "home", Path: "/tmp/home"}}}}
+
+	var got ResticClient
+	msg := m.runRepoCommand("cache cleanup", func(client ResticClient) (string, error) {
+		got = client
+		return "removed 2 old cache directories", nil
+	})()
+
+	if got == nil {
+		t.Fatal("The command should run with a client for the current repository")
+	}
+	result, ok := msg.(CommandResultMsg)
+	if !ok {
+		t.Fatalf("Expected CommandResultMsg, got %T", msg)
+	}
+	if result.Name != "cache cleanup" || result.Output != "removed 2 old cache directories" || result.Error != nil {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+func TestRunRepoCommand_NoRepository(t *testing.T) {
+	m := Model{config: &types.ResticConfig{}}
+
+	msg := m.runRepoCommand("unlock", func(ResticClient) (string, error) {
+		t.Error("The command should not run without a repository")
+		return "", nil
+	})()
+
+	result := msg.(CommandResultMsg)
+	if result.Name != "unlock" || result.Error == nil {
+		t.Errorf("Expected a no repository error, got %+v", result)
+	}
+}
+
+func TestRunRepoCommand_FakeClient(t *testing.T) {
+	client := &fakeClient{err: errors.New("repository is locked exclusively")}
+
+	output, err := ResticClient.UnlockAll(client)
+	msg := CommandResultMsg{Name: "unlock --remove-all", Output: output, Error: err}
+
+	m := Model{config: &types.ResticConfig{}, opsPanel: ui.NewOperationsPanel(), operationInProgress: "unlock --remove-all"}
+	updated, cmd := m.Update(msg)
+	m = updated.(Model)
+	if cmd != nil || m.operationInProgress != "" {
+		t.Errorf("A failed command should finish the operation without follow-up, got cmd %v", cmd)
+	}
+
+	var logged bool
+	for _, entry := range m.opsPanel.FilteredLogs() {
+		if entry.Level == "error" && strings.HasPrefix(entry.Message, "Unlock --remove-all failed:") {
+			logged = true
+		}
+	}
+	if !logged {
+		t.Errorf("Expected the failure in the log, got %+v", m.opsPanel.FilteredLogs())
+	}
+}
+
+func TestHandleCommandResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		success string
+		refresh bool
+	}{
+		{"unlock", "✓ Repository unlocked successfully", true},
+		{"cache cleanup", "✓ Cache cleanup completed successfully", false},
+		{"tag", "✓ Tag completed", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Model{config: &types.ResticConfig{}, opsPanel: ui.NewOperationsPanel()}
+			cmd := m.handleCommandResult(CommandResultMsg{Name: tt.name, Output: "done"})
+			if (cmd != nil) != tt.refresh {
+				t.Errorf("Refresh command = %v, want refresh %v", cmd != nil, tt.refresh)
+			}
+
+			logs := m.opsPanel.FilteredLogs()
+			if len(logs) < 2 || logs[0].Message != tt.success || logs[1].Message != "done" {
+				t.Errorf("Expected %q followed by the output, got %+v", tt.success, logs)
+			}
+		})
+	}
+}
+
+func TestProtect_RunsThroughRepoCommand(t *testing.T) {
+	factory := &fakeFactory{}
+	snapPanel := ui.NewSnapshotPanel()
+	snapPanel.SetSnapshots([]types.Snapshot{{ID: "aaaa1111aaaa1111", ShortID: "aaaa1111"}})
+	m := Model{
+		config:        &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "home", Path: "/tmp/home"}}},
+		repositories:  []types.Repository{{Name: "home", Path: "/tmp/home"}},
+		snapPanel:     snapPanel,
+		opsPanel:      ui.NewOperationsPanel(),
+		clientFactory: factory,
+		keys:          DefaultKeyMap(),
+		activePanel:   types.PanelSnapshots,
+	}
+
+	m, cmd := pressKeys(m, typeText("P")...)
+	if m.operationInProgress != "protect" || m.busyRepos["home"] != 1 {
+		t.Fatalf("Protecting should hold the repository, got operation %q and busy %v", m.operationInProgress, m.busyRepos)
+	}
+	msg, ok := cmd().(tea.BatchMsg)[0]().(CommandResultMsg)
+	if !ok || msg.Name != "protect" || msg.RepoName != "home" {
+		t.Fatalf("Expected a protect CommandResultMsg, got %+v", msg)
+	}
+
+	updated, cmd := m.Update(msg)
+	m = updated.(Model)
+	if cmd == nil || m.operationInProgress != "" {
+		t.Error("A protected snapshot gets a new ID, so snapshots should reload")
+	}
+	if !reflect.DeepEqual(factory.calls, []string{"SetProtected"}) {
+		t.Errorf("Expected one SetProtected call, got %v", factory.calls)
+	}
+	logs := m.opsPanel.FilteredLogs()
+	var found bool
+	for _, entry := range logs {
+		found = found || entry.Message == "✓ Snapshot protected - forget policies will always keep it"
+	}
+	if !found {
+		t.Errorf("Expected the protect success line, got %+v", logs)
+	}
+}
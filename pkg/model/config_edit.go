@@ -0,0 +1,88 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/config"
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+// defaultConfigLoader reads the config file with the config package
+type defaultConfigLoader struct{}
+
+// LoadOrDefault loads the config, returning an empty one on error
+func (defaultConfigLoader) LoadOrDefault(path string) *types.ResticConfig {
+	return config.LoadOrDefault(path)
+}
+
+// LoadAndValidate loads and validates the config
+func (defaultConfigLoader) LoadAndValidate(path string) (*types.ResticConfig, error) {
+	return config.LoadAndValidate(path)
+}
+
+// editorCommand returns the user's editor from $VISUAL or $EDITOR split into
+// program and arguments (e.g. "code --wait"), or nil when neither is set
+func editorCommand() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(name)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return nil
+}
+
+// editConfig suspends the TUI and opens the config file in the user's editor.
+// Without an editor the config path is copied to the clipboard instead.
+func (m *Model) editConfig() tea.Cmd {
+	path := config.DefaultConfigPath()
+	editor := editorCommand()
+	if editor == nil {
+		clip := m.clipboard
+		if clip == nil {
+			clip = osc52Clipboard{}
+		}
+		if err := clip.Copy(path); err != nil {
+			m.opsPanel.Warning(fmt.Sprintf("Could not copy to clipboard: %v", err))
+		} else {
+			m.opsPanel.Success("Copied config path to clipboard")
+		}
+		m.opsPanel.Info(fmt.Sprintf("Config: %s", path))
+		m.opsPanel.Dimmed("Set $EDITOR to edit the config without leaving LazyRestic")
+		return nil
+	}
+
+	m.opsPanel.Info(fmt.Sprintf("Opening %s in %s...", path, editor[0]))
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return ConfigEditedMsg{Path: path, Error: err}
+	})
+}
+
+// reloadConfig loads the config again after it was edited. A config that fails
+// to load or validate is reported and the current one is kept.
+func (m *Model) reloadConfig(path string) tea.Cmd {
+	loader := m.configLoader
+	if loader == nil {
+		loader = defaultConfigLoader{}
+	}
+
+	cfg, err := loader.LoadAndValidate(path)
+	if err != nil {
+		m.opsPanel.Error(fmt.Sprintf("✗ Config error: %v", err))
+		m.opsPanel.Warning(fmt.Sprintf("Keeping the previous configuration - fix the file and press %s to edit it again", keyLabel(m.keyMap().EditConfig)))
+		return nil
+	}
+
+	m.config = cfg
+	if m.currentRepoIndex >= len(cfg.Repositories) {
+		m.currentRepoIndex = 0
+	}
+	m.opsPanel.Success(fmt.Sprintf("✓ Config reloaded: %d repositories", len(cfg.Repositories)))
+	m.opsPanel.Dimmed("Key binding and theme changes apply after a restart")
+	m.loadingRepositories = true
+	return m.loadRepositories
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/config"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+// fakeConfigLoader returns a fixed config or error and records the path it loaded
+type fakeConfigLoader struct {
+	cfg    *types.ResticConfig
+	err    error
+	loaded *string
+}
+
+func (l fakeConfigLoader) LoadOrDefault(path string) *types.ResticConfig {
+	return l.cfg
+}
+
+func (l fakeConfigLoader) LoadAndValidate(path string) (*types.ResticConfig, error) {
+	*l.loaded = path
+	return l.cfg, l.err
+}
+
+func TestConfigEdited_ReloadsConfig(t *testing.T) {
+	var loaded string
+	edited := &types.ResticConfig{Repositories: []types.RepositoryConfig{
+		{Name: "home", Path: "/tmp/home"},
+		{Name: "offsite", Path: "/tmp/offsite"},
+	}}
+	m := Model{
+		config:       &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "old", Path: "/tmp/old"}}},
+		opsPanel:     ui.NewOperationsPanel(),
+		configLoader: fakeConfigLoader{cfg: edited, loaded: &loaded},
+	}
+
+	updated, cmd := m.Update(ConfigEditedMsg{Path: "/tmp/config.yaml"})
+	m = updated.(Model)
+	if loaded != "/tmp/config.yaml" {
+		t.Errorf("Loader read %q, want the edited file", loaded)
+	}
+	if m.config != edited {
+		t.Fatal("The edited config should replace the current one")
+	}
+	if cmd == nil || !m.loadingRepositories {
+		t.Error("Reloading the config should reload the repositories")
+	}
+}
+
+func TestConfigEdited_InvalidConfigKeepsCurrent(t *testing.T) {
+	var loaded string
+	current := &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "old", Path: "/tmp/old"}}}
+	m := Model{
+		config:       current,
+		opsPanel:     ui.NewOperationsPanel(),
+		configLoader: fakeConfigLoader{err: errors.New("repository 1: path is required"), loaded: &loaded},
+	}
+
+	updated, cmd := m.Update(ConfigEditedMsg{Path: "/tmp/config.yaml"})
+	m = updated.(Model)
+	if m.config != current || cmd != nil {
+		t.Fatal("An invalid config should be reported without replacing the current one")
+	}
+
+	var reported bool
+	for _, entry := range m.opsPanel.FilteredLogs() {
+		if entry.Level == "error" && strings.Contains(entry.Message, "path is required") {
+			reported = true
+		}
+	}
+	if !reported {
+		t.Errorf("Expected the validation error in the log, got %+v", m.opsPanel.FilteredLogs())
+	}
+}
+
+func TestEditConfig_WithoutEditorCopiesPath(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+
+	var copied string
+	m := Model{
+		config:    &types.ResticConfig{},
+		opsPanel:  ui.NewOperationsPanel(),
+		clipboard: fakeClipboard{copied: &copied},
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("O")})
+	m = updated.(Model)
+	if cmd != nil {
+		t.Error("Without an editor no process should be started")
+	}
+	if copied != config.DefaultConfigPath() {
+		t.Errorf("Copied %q, want the config path %q", copied, config.DefaultConfigPath())
+	}
+}
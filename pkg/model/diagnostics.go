@@ -0,0 +1,140 @@
+package model
+
+import (
+	"fmt"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/config"
+	"github.com/craigderington/lazyrestic/pkg/restic"
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+// DiagnosticCheck is one line of the diagnostics checklist
+type DiagnosticCheck struct {
+	Name   string
+	Passed bool
+	Detail string // Version, path or the reason the check failed
+}
+
+// diagnosticProbes are the environment checks behind the diagnostics
+// checklist, replaceable in tests
+type diagnosticProbes struct {
+	resticInstalled func() bool
+	resticVersion   func() (string, error)
+	configFile      func(path string) error
+	passwordMethod  func(repo types.RepositoryConfig) error
+}
+
+// defaultDiagnosticProbes checks the real restic binary and config
+func defaultDiagnosticProbes() diagnosticProbes {
+	return diagnosticProbes{
+		resticInstalled: restic.IsResticInstalled,
+		resticVersion:   restic.GetResticVersion,
+		configFile:      config.ValidateConfigFile,
+		passwordMethod:  config.ValidatePasswordMethod,
+	}
+}
+
+// runDiagnostics checks restic, the config file and every repository's
+// password method and reachability. Repositories are pinged in parallel with
+// at most maxConcurrency clients; checks keep the order of the config.
+func runDiagnostics(repos []types.RepositoryConfig, configPath string, probes diagnosticProbes, factory ResticClientFactory, maxConcurrency int) []DiagnosticCheck {
+	checks := []DiagnosticCheck{checkRestic(probes)}
+
+	configCheck := DiagnosticCheck{Name: "Config file permissions", Passed: true, Detail: configPath}
+	if err := probes.configFile(configPath); err != nil {
+		configCheck.Passed = false
+		configCheck.Detail = err.Error()
+	}
+	checks = append(checks, configCheck)
+
+	if maxConcurrency < 1 {
+		maxConcurrency = DefaultMaxConcurrency
+	}
+
+	pings := make([]DiagnosticCheck, len(repos))
+	sem := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(i int, repo types.RepositoryConfig) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			pings[i] = DiagnosticCheck{Name: fmt.Sprintf("%s: reachable", repo.Name), Passed: true, Detail: repo.Path}
+			if err := factory.NewClient(repo).Ping(); err != nil {
+				pings[i].Passed = false
+				pings[i].Detail = err.Error()
+			}
+		}(i, repo)
+	}
+	wg.Wait()
+
+	for i, repo := range repos {
+		password := DiagnosticCheck{Name: fmt.Sprintf("%s: password", repo.Name), Passed: true, Detail: passwordMethodName(repo)}
+		if err := probes.passwordMethod(repo); err != nil {
+			password.Passed = false
+			password.Detail = err.Error()
+		}
+		checks = append(checks, password, pings[i])
+	}
+
+	return checks
+}
+
+// checkRestic reports whether restic is on the PATH and which version it is
+func checkRestic(probes diagnosticProbes) DiagnosticCheck {
+	check := DiagnosticCheck{Name: "restic installed"}
+	if !probes.resticInstalled() {
+		check.Detail = "restic not found in PATH"
+		return check
+	}
+
+	version, err := probes.resticVersion()
+	if err != nil {
+		check.Detail = fmt.Sprintf("restic version failed: %v", err)
+		return check
+	}
+	check.Passed = true
+	check.Detail = version
+	return check
+}
+
+// passwordMethodName names the password method a repository uses
+func passwordMethodName(repo types.RepositoryConfig) string {
+	switch {
+	case repo.PasswordFile != "":
+		return "password_file " + repo.PasswordFile
+	case repo.PasswordCommand != "":
+		return "password_command"
+	case repo.PasswordEnv != "":
+		return "password_env " + repo.PasswordEnv
+	}
+	return ""
+}
+
+// diagnosticsPassed counts the checks that passed
+func diagnosticsPassed(checks []DiagnosticCheck) int {
+	passed := 0
+	for _, check := range checks {
+		if check.Passed {
+			passed++
+		}
+	}
+	return passed
+}
+
+// runDiagnosticsCmd runs the diagnostics checklist in the background
+func (m Model) runDiagnosticsCmd() tea.Cmd {
+	repos := append([]types.RepositoryConfig(nil), m.config.Repositories...)
+	factory := m.clients()
+	maxConcurrency := m.config.MaxConcurrency
+	return func() tea.Msg {
+		checks := runDiagnostics(repos, config.DefaultConfigPath(), defaultDiagnosticProbes(), factory, maxConcurrency)
+		return DiagnosticsMsg{Checks: checks}
+	}
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+func TestRunDiagnostics(t *testing.T) {
+	repos := []types.RepositoryConfig{
+		{Name: "home", Path: "/backup/home", PasswordEnv: "RESTIC_PASSWORD"},
+		{Name: "offsite", Path: "sftp:host:/repo", PasswordFile: "/missing"},
+	}
+	probes := diagnosticProbes{
+		resticInstalled: func() bool { return true },
+		resticVersion:   func() (string, error) { return "restic 0.17.0", nil },
+		configFile:      func(string) error { return nil },
+		passwordMethod: func(repo types.RepositoryConfig) error {
+			if repo.PasswordFile != "" {
+				return errors.New("password file not found")
+			}
+			return nil
+		},
+	}
+
+	checks := runDiagnostics(repos, "/cfg/config.yaml", probes, &fakeFactory{failFor: "offsite"}, 2)
+
+	want := []struct {
+		name   string
+		passed bool
+	}{
+		{"restic installed", true},
+		{"Config file permissions", true},
+		{"home: password", true},
+		{"home: reachable", true},
+		{"offsite: password", false},
+		{"offsite: reachable", false},
+	}
+	if len(checks) != len(want) {
+		t.Fatalf("Expected %d checks, got %+v", len(want), checks)
+	}
+	for i, w := range want {
+		if checks[i].Name != w.name || checks[i].Passed != w.passed {
+			t.Errorf("Check %d = %q passed %v, want %q passed %v", i, checks[i].Name, checks[i].Passed, w.name, w.passed)
+		}
+	}
+	if checks[0].Detail != "restic 0.17.0" {
+		t.Errorf("Expected the restic version as detail, got %q", checks[0].Detail)
+	}
+	if got := diagnosticsPassed(checks); got != 4 {
+		t.Errorf("diagnosticsPassed() = %d, want 4", got)
+	}
+}
+
+func TestRunDiagnostics_ResticMissing(t *testing.T) {
+	probes := diagnosticProbes{
+		resticInstalled: func() bool { return false },
+		resticVersion: func() (string, error) {
+			t.Error("The version should not be read without restic")
+			return "", nil
+		},
+		configFile:     func(string) error { return errors.New("config file permissions are -rw-r--r--") },
+		passwordMethod: func(types.RepositoryConfig) error { return nil },
+	}
+
+	checks := runDiagnostics(nil, "/cfg/config.yaml", probes, &fakeFactory{}, 0)
+	if len(checks) != 2 || checks[0].Passed || checks[1].Passed {
+		t.Errorf("Expected failed restic and config checks, got %+v", checks)
+	}
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/craigderington/lazyrestic/pkg/restic"
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+// errFreeSpaceUnsupported is returned by freeDiskSpace on systems where free
+// space can't be queried
+var errFreeSpaceUnsupported = errors.New("free space can't be checked on this system")
+
+// existingDir returns path, or its nearest existing parent when restic would
+// still have to create it, since free space can only be queried on existing paths
+func existingDir(path string) string {
+	path = filepath.Clean(path)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// restoreSpace is the outcome of comparing a restore's size with the free
+// space at its target
+type restoreSpace struct {
+	Required  int64  // Restore size of the snapshot in bytes
+	Available uint64 // Free bytes at the target
+	Skipped   string // Why the check couldn't run (empty when it did)
+}
+
+// hasEnoughSpace reports whether required bytes fit in available bytes
+func hasEnoughSpace(required int64, available uint64) bool {
+	return required <= 0 || uint64(required) <= available
+}
+
+// insufficient reports whether the check ran and found too little free space
+func (s restoreSpace) insufficient() bool {
+	return s.Skipped == "" && !hasEnoughSpace(s.Required, s.Available)
+}
+
+// restoreSpaceCheck compares the restore size of opts' snapshot with the free
+// space at the restore target. If either can't be determined the check is
+// skipped and the reason returned rather than blocking the restore.
+func restoreSpaceCheck(opts types.RestoreOptions, restoreSize func() (int64, error), freeSpace func(string) (uint64, error)) restoreSpace {
+	switch {
+	case opts.Target == "":
+		return restoreSpace{Skipped: "restoring to original locations"}
+	case len(opts.Include) > 0 || opts.IncludeFile != "":
+		// restic only reports the size of whole snapshots
+		return restoreSpace{Skipped: "restoring selected paths"}
+	}
+
+	available, err := freeSpace(existingDir(opts.Target))
+	if err != nil {
+		return restoreSpace{Skipped: fmt.Sprintf("free space at %s is unknown: %v", opts.Target, err)}
+	}
+	required, err := restoreSize()
+	if err != nil {
+		return restoreSpace{Skipped: fmt.Sprintf("restore size is unknown: %s", restic.Describe(err))}
+	}
+	return restoreSpace{Required: required, Available: available}
+}
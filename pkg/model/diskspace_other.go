@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package model
+
+// freeDiskSpace can't query free space here, so restores skip the check
+func freeDiskSpace(path string) (uint64, error) {
+	return 0, errFreeSpaceUnsupported
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+func TestRestoreSpaceCheck(t *testing.T) {
+	full := types.RestoreOptions{SnapshotID: "abc123", Target: "/srv/restore"}
+	size := func(n int64) func() (int64, error) {
+		return func() (int64, error) { return n, nil }
+	}
+	free := func(n uint64) func(string) (uint64, error) {
+		return func(string) (uint64, error) { return n, nil }
+	}
+	failSize := func() (int64, error) { return 0, errors.New("restic command failed: exit status 1") }
+	failFree := func(string) (uint64, error) { return 0, errFreeSpaceUnsupported }
+
+	tests := []struct {
+		name         string
+		opts         types.RestoreOptions
+		restoreSize  func() (int64, error)
+		freeSpace    func(string) (uint64, error)
+		insufficient bool
+		skipped      string
+	}{
+		{"Plenty of room", full, size(10 << 30), free(50 << 30), false, ""},
+		{"Exactly enough", full, size(1 << 30), free(1 << 30), false, ""},
+		{"Too little room", full, size(10 << 30), free(2 << 30), true, ""},
+		{"Empty snapshot", full, size(0), free(0), false, ""},
+		{"Original location", types.RestoreOptions{SnapshotID: "abc123"}, size(10 << 30), free(0), false, "original locations"},
+		{"Selected paths", types.RestoreOptions{SnapshotID: "abc123", Target: "/srv/restore", Include: []string{"/etc"}}, size(10 << 30), free(0), false, "selected paths"},
+		{"Free space unknown", full, size(10 << 30), failFree, false, "free space at /srv/restore is unknown"},
+		{"Restore size unknown", full, failSize, free(0), false, "restore size is unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			space := restoreSpaceCheck(tt.opts, tt.restoreSize, tt.freeSpace)
+			if got := space.insufficient(); got != tt.insufficient {
+				t.Errorf("insufficient() = %v, want %v (space %+v)", got, tt.insufficient, space)
+			}
+			if tt.skipped == "" && space.Skipped != "" {
+				t.Errorf("Check should run, skipped: %s", space.Skipped)
+			}
+			if !strings.Contains(space.Skipped, tt.skipped) {
+				t.Errorf("Skipped = %q, want it to mention %q", space.Skipped, tt.skipped)
+			}
+		})
+	}
+}
+
+func TestExistingDir_FindsNearestParent(t *testing.T) {
+	dir := t.TempDir()
+
+	if got := existingDir(filepath.Join(dir, "new", "nested")); got != dir {
+		t.Errorf("existingDir() = %q, want %q", got, dir)
+	}
+	if got := existingDir(dir); got != dir {
+		t.Errorf("existingDir() = %q, want the path itself", got)
+	}
+}
+
+func TestRestorePreview_LowSpaceNeedsConfirmation(t *testing.T) {
+	m := Model{
+		config:       &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo"}}},
+		repositories: []types.Repository{{Name: "repo", Path: "/tmp/repo"}},
+		opsPanel:     ui.NewOperationsPanel(),
+	}
+	opts := types.RestoreOptions{SnapshotID: "abc123def456", Target: "/tmp/restore"}
+
+	updated, _ := m.Update(RestorePreviewMsg{
+		Options: opts,
+		Paths:   []string{"/tmp/restore/a"},
+		Space:   restoreSpace{Required: 10 << 30, Available: 1 << 30},
+	})
+	m = updated.(Model)
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.restoreInProgress || !m.showSpaceConfirm {
+		t.Fatal("A restore that doesn't fit should ask for confirmation first")
+	}
+
+	for _, r := range "RESTORE" {
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = updated.(Model)
+	}
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.showSpaceConfirm || !m.restoreInProgress || cmd == nil {
+		t.Error("Typing RESTORE should start the restore anyway")
+	}
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/craigderington/lazyrestic/pkg/config"
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+// ExportSnapshots writes snapshots to path as a JSON array in the same shape as
+// `restic snapshots --json`. The file is written atomically, so readers never
+// see a partial export.
+func ExportSnapshots(path string, snapshots []types.Snapshot) error {
+	if snapshots == nil {
+		snapshots = []types.Snapshot{} // Export [] rather than null
+	}
+	data, err := json.MarshalIndent(snapshots, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshots: %w", err)
+	}
+
+	if err := writeFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to path with 0600 permissions, creating its
+// directory if needed. The data goes to a temporary file in the same
+// directory that is renamed into place, so readers never see a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	// Clean up the temporary file unless the rename succeeds
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// snapshotExportPath returns a timestamped path for exporting a repository's snapshots
+func snapshotExportPath(repoName string, now time.Time) string {
+	return filepath.Join(filepath.Dir(config.DefaultConfigPath()), "exports",
+		fmt.Sprintf("snapshots-%s-%s.json", safeFileName(repoName), now.Format("20060102-150405")))
+}
+
+// safeFileName replaces path separators and spaces in a repository name so
+// it can be part of a file name
+func safeFileName(repoName string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, repoName)
+}
@@ -0,0 +1,103 @@
+package model
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+func TestExportSnapshots_RoundTrip(t *testing.T) {
+	snapshots := []types.Snapshot{
+		{
+			ID:       "aaaa1111aaaa1111",
+			ShortID:  "aaaa1111",
+			Time:     time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+			Hostname: "laptop",
+			Username: "craig",
+			Paths:    []string{"/home/craig"},
+			Tags:     []string{"daily"},
+		},
+		{ID: "bbbb2222bbbb2222", ShortID: "bbbb2222", Time: time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)},
+	}
+	path := filepath.Join(t.TempDir(), "exports", "snapshots.json")
+
+	if err := ExportSnapshots(path, snapshots); err != nil {
+		t.Fatalf("ExportSnapshots() failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Export file missing: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("Export file permissions = %o, want 600", perm)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []types.Snapshot
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Export is not valid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(got, snapshots) {
+		t.Errorf("Round trip mismatch:\n got %+v\nwant %+v", got, snapshots)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected only the export file in the directory, found %d entries", len(entries))
+	}
+}
+
+func TestExportKey_ExportsFilteredSnapshots(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	snapPanel := ui.NewSnapshotPanel()
+	snapPanel.SetSize(80, 20)
+	snapPanel.SetSnapshots([]types.Snapshot{
+		{ID: "aaaa1111aaaa1111", ShortID: "aaaa1111", Paths: []string{"/home"}},
+		{ID: "bbbb2222bbbb2222", ShortID: "bbbb2222", Paths: []string{"/etc"}},
+	})
+	snapPanel.SetFilter("etc")
+
+	m := Model{
+		config:       &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo"}}},
+		repositories: []types.Repository{{Name: "repo", Path: "/tmp/repo"}},
+		opsPanel:     ui.NewOperationsPanel(),
+		snapPanel:    snapPanel,
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("X")})
+	m = updated.(Model)
+
+	var path string
+	for _, entry := range m.opsPanel.FilteredLogs() {
+		if entry.Level == "success" {
+			path = entry.Message[strings.LastIndex(entry.Message, " ")+1:]
+		}
+	}
+	if path == "" {
+		t.Fatalf("Expected a success log with the export path, got %+v", m.opsPanel.FilteredLogs())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Export file missing: %v", err)
+	}
+	var got []types.Snapshot
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ShortID != "bbbb2222" {
+		t.Errorf("Export should contain only the filtered snapshot, got %+v", got)
+	}
+}
@@ -0,0 +1,330 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/craigderington/lazyrestic/pkg/restic"
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+// fakeClient is a ResticClient returning canned results without running restic.
+// GetRepositoryInfo sleeps for delay to simulate a slow backend.
+type fakeClient struct {
+	config     types.RepositoryConfig
+	delay      time.Duration
+	fail       bool
+	err        error // Returned instead of the generic failure when set
+	snapshots  []types.Snapshot
+	files      []types.FileNode
+	migrations []string
+	excluded   *types.ExclusionSummary
+	calls      *[]string // Records the methods called, when set
+	restores   *[]types.RestoreOptions
+	locks      []types.Lock
+	keys       []types.RepoKey
+	inFlight   *int32
+	maxSeen    *int32
+}
+
+// record notes a method call for tests that check what the model ran
+func (c *fakeClient) record(method string) {
+	if c.calls != nil {
+		*c.calls = append(*c.calls, method)
+	}
+}
+
+func (c *fakeClient) Ping() error {
+	c.record("Ping")
+	if c.fail && c.err == nil {
+		return fmt.Errorf("repository unreachable")
+	}
+	return c.err
+}
+
+func (c *fakeClient) GetRepositoryInfo() (*types.Repository, error) {
+	if c.inFlight != nil {
+		current := atomic.AddInt32(c.inFlight, 1)
+		defer atomic.AddInt32(c.inFlight, -1)
+
+		for {
+			seen := atomic.LoadInt32(c.maxSeen)
+			if current <= seen || atomic.CompareAndSwapInt32(c.maxSeen, seen, current) {
+				break
+			}
+		}
+	}
+
+	time.Sleep(c.delay)
+
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.fail {
+		return nil, fmt.Errorf("repository unreachable")
+	}
+	return &types.Repository{Status: "healthy", SnapshotCount: len(c.snapshots)}, nil
+}
+
+func (c *fakeClient) GetConfigBlob() (*types.RepoConfigBlob, error) {
+	return &types.RepoConfigBlob{Version: 2}, c.err
+}
+
+func (c *fakeClient) Init(opts types.InitOptions) error {
+	c.record("Init")
+	return c.err
+}
+
+func (c *fakeClient) InitWithOptions(repoVersion int, compression string) error {
+	c.record("InitWithOptions")
+	return c.err
+}
+
+func (c *fakeClient) Mount(mountpoint string) (*exec.Cmd, error) {
+	return nil, fmt.Errorf("mount is not supported by the fake client")
+}
+
+func (c *fakeClient) ListSnapshots() ([]types.Snapshot, error) {
+	c.record("ListSnapshots")
+	return c.snapshots, c.err
+}
+
+func (c *fakeClient) ListSnapshotsFiltered(host string, tags []string, paths []string) ([]types.Snapshot, error) {
+	c.record("ListSnapshotsFiltered")
+	var matched []types.Snapshot
+	for _, snap := range c.snapshots {
+		if host == "" || snap.Hostname == host {
+			matched = append(matched, snap)
+		}
+	}
+	return matched, c.err
+}
+
+func (c *fakeClient) ListFiles(snapshotID string, path string) ([]types.FileNode, error) {
+	c.record("ListFiles")
+	return c.files, c.err
+}
+
+func (c *fakeClient) ListFilesStream(ctx context.Context, snapshotID string, path string, out chan<- types.FileNode) error {
+	defer close(out)
+	for _, file := range c.files {
+		select {
+		case out <- file:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return c.err
+}
+
+func (c *fakeClient) DumpFileLimit(snapshotID string, path string, limit int64) ([]byte, error) {
+	return nil, c.err
+}
+
+func (c *fakeClient) Find(pattern string) ([]types.FindResult, error) {
+	return nil, c.err
+}
+
+func (c *fakeClient) GetSnapshotStats(snapshotID string) (*types.SnapshotStats, error) {
+	return &types.SnapshotStats{}, c.err
+}
+
+func (c *fakeClient) GetSnapshotSize(snapshotID string) (*types.SnapshotStats, error) {
+	return &types.SnapshotStats{}, c.err
+}
+
+func (c *fakeClient) SetProtected(snapshotID string, protected bool) error {
+	c.record("SetProtected")
+	return c.err
+}
+
+func (c *fakeClient) BackupWithChannel(ctx context.Context, opts types.BackupOptions, updates chan<- restic.BackupMessage) {
+	defer close(updates)
+	c.record("Backup")
+	if c.err != nil {
+		updates <- restic.BackupMessage{Error: c.err}
+		return
+	}
+	updates <- restic.BackupMessage{Summary: &types.BackupSummary{MessageType: "summary", SnapshotID: "fake"}}
+}
+
+func (c *fakeClient) Restore(opts types.RestoreOptions) error {
+	c.record("Restore")
+	return c.err
+}
+
+func (c *fakeClient) RestoreWithChannel(ctx context.Context, opts types.RestoreOptions, updates chan<- restic.RestoreMessage) {
+	defer close(updates)
+	c.record("Restore")
+	if c.restores != nil {
+		*c.restores = append(*c.restores, opts)
+	}
+	if c.err != nil {
+		updates <- restic.RestoreMessage{Error: c.err}
+		return
+	}
+	updates <- restic.RestoreMessage{Summary: &types.RestoreSummary{}}
+}
+
+func (c *fakeClient) RestoreDryRun(opts types.RestoreOptions) ([]string, error) {
+	return nil, c.err
+}
+
+func (c *fakeClient) EstimateExcluded(opts types.BackupOptions, backup *types.BackupSummary) (*types.ExclusionSummary, error) {
+	c.record("EstimateExcluded")
+	return c.excluded, c.err
+}
+
+func (c *fakeClient) ForgetDryRun(policy types.ForgetPolicy) ([]types.ForgetResult, error) {
+	c.record("ForgetDryRun")
+	return nil, c.err
+}
+
+func (c *fakeClient) Forget(policy types.ForgetPolicy) error {
+	c.record("Forget")
+	return c.err
+}
+
+func (c *fakeClient) ForgetByID(ids []string) error {
+	c.record("ForgetByID")
+	return c.err
+}
+
+func (c *fakeClient) PruneDryRun() (string, error) {
+	c.record("PruneDryRun")
+	return "", c.err
+}
+
+func (c *fakeClient) Prune() error {
+	c.record("Prune")
+	return c.err
+}
+
+func (c *fakeClient) CheckRepositoryDataWithChannel(ctx context.Context, subset string, updates chan<- restic.CheckMessage) {
+	defer close(updates)
+	c.record("Check")
+	if c.err != nil {
+		updates <- restic.CheckMessage{Error: c.err}
+	}
+}
+
+func (c *fakeClient) RepairIndexWithChannel(ctx context.Context, updates chan<- restic.CheckMessage) {
+	defer close(updates)
+	c.record("RepairIndex")
+	if c.err != nil {
+		updates <- restic.CheckMessage{Error: c.err}
+	}
+}
+
+func (c *fakeClient) ListMigrations() ([]string, error) {
+	c.record("ListMigrations")
+	return c.migrations, c.err
+}
+
+func (c *fakeClient) ApplyMigrationWithChannel(ctx context.Context, name string, updates chan<- restic.CheckMessage) {
+	defer close(updates)
+	c.record("Migrate " + name)
+	if c.err != nil {
+		updates <- restic.CheckMessage{Error: c.err}
+		return
+	}
+	updates <- restic.CheckMessage{Line: "applying migration " + name + "..."}
+}
+
+func (c *fakeClient) RunWithChannel(ctx context.Context, args []string, updates chan<- restic.CheckMessage) {
+	defer close(updates)
+	c.record("Run " + strings.Join(args, " "))
+	if c.err != nil {
+		updates <- restic.CheckMessage{Error: c.err}
+		return
+	}
+	updates <- restic.CheckMessage{Line: "ran " + strings.Join(args, " ")}
+}
+
+func (c *fakeClient) CleanupCache() (string, error) {
+	c.record("CleanupCache")
+	return "", c.err
+}
+
+func (c *fakeClient) Unlock() (string, error) {
+	c.record("Unlock")
+	return "", c.err
+}
+
+func (c *fakeClient) ListLocks() ([]types.Lock, error) {
+	c.record("ListLocks")
+	return c.locks, c.err
+}
+
+func (c *fakeClient) UnlockAll() (string, error) {
+	c.record("UnlockAll")
+	return "", c.err
+}
+
+func (c *fakeClient) GetDuplicateStats() (*types.DuplicateStats, error) {
+	c.record("GetDuplicateStats")
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &types.DuplicateStats{SnapshotID: "cafe1234cafe1234", TotalSize: 3000, TotalFiles: 30, UniqueSize: 1000, UniqueFiles: 10}, nil
+}
+
+func (c *fakeClient) ListKeys() ([]types.RepoKey, error) {
+	c.record("ListKeys")
+	return c.keys, c.err
+}
+
+func (c *fakeClient) AddKey(newPassword string) error {
+	c.record("AddKey " + newPassword)
+	return c.err
+}
+
+func (c *fakeClient) RemoveKey(id string) error {
+	c.record("RemoveKey " + id)
+	return c.err
+}
+
+func (c *fakeClient) ChangePassword(newPassword string) error {
+	c.record("ChangePassword " + newPassword)
+	return c.err
+}
+
+// fakeFactory creates fakeClients sharing concurrency counters and canned results
+type fakeFactory struct {
+	delay      time.Duration
+	failFor    string
+	err        error
+	snapshots  []types.Snapshot
+	files      []types.FileNode
+	migrations []string
+	excluded   *types.ExclusionSummary
+	calls      []string
+	restores   []types.RestoreOptions
+	locks      []types.Lock
+	keys       []types.RepoKey
+	inFlight   int32
+	maxSeen    int32
+}
+
+func (f *fakeFactory) NewClient(config types.RepositoryConfig) ResticClient {
+	return &fakeClient{
+		config:     config,
+		delay:      f.delay,
+		fail:       config.Name == f.failFor,
+		err:        f.err,
+		snapshots:  f.snapshots,
+		files:      f.files,
+		migrations: f.migrations,
+		excluded:   f.excluded,
+		calls:      &f.calls,
+		restores:   &f.restores,
+		locks:      f.locks,
+		keys:       f.keys,
+		inFlight:   &f.inFlight,
+		maxSeen:    &f.maxSeen,
+	}
+}
@@ -0,0 +1,164 @@
+package model
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+// fileBatchSize is how many streamed entries are added to the file browser at once
+const fileBatchSize = 500
+
+// maxFileNodes caps the entries of one directory held in memory; larger
+// directories are paged through in windows of this size
+const maxFileNodes = 10000
+
+// fileStream is a running restic ls whose entries are read in batches. Only
+// one batch is read at a time, so its counters need no locking. Once a window
+// is full restic is stopped, so it doesn't keep running and holding its lock
+// while the entries are read; the next window lists the directory again.
+type fileStream struct {
+	nodes  chan types.FileNode
+	done   chan error // Listing result, sent once nodes is closed
+	ctx    context.Context
+	cancel context.CancelFunc
+	skip   int // Entries still to discard before the window starts
+	window int // Maximum entries per window
+	held   int // Entries delivered in the window
+}
+
+// newFileStream prepares a listing that skips the first skip entries and
+// delivers at most window entries before stopping
+func newFileStream(skip, window int) *fileStream {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &fileStream{
+		nodes:  make(chan types.FileNode, fileBatchSize),
+		done:   make(chan error, 1),
+		ctx:    ctx,
+		cancel: cancel,
+		skip:   skip,
+		window: window,
+	}
+}
+
+// run lists the directory into the stream; call it in its own goroutine
+func (s *fileStream) run(client ResticClient, snapshotID, path string) {
+	s.done <- client.ListFilesStream(s.ctx, snapshotID, path, s.nodes)
+}
+
+// next reads the next batch of entries. It returns FilesBatchMsg while the
+// listing continues and FilesLoadedMsg with the last entries once it ends.
+func (s *fileStream) next() tea.Msg {
+	batch := make([]types.FileNode, 0, fileBatchSize)
+	for len(batch) < fileBatchSize && s.held+len(batch) < s.window {
+		node, ok := <-s.nodes
+		if !ok {
+			s.held += len(batch)
+			return FilesLoadedMsg{Files: batch, Error: <-s.done, Stream: s}
+		}
+		if s.skip > 0 {
+			s.skip--
+			continue
+		}
+		batch = append(batch, node)
+	}
+	s.held += len(batch)
+
+	if s.held < s.window {
+		return FilesBatchMsg{Files: batch, Stream: s}
+	}
+
+	// The window is full: peek at one more entry to tell whether the listing
+	// ends here, then stop restic until the next window is requested
+	if _, ok := <-s.nodes; !ok {
+		return FilesLoadedMsg{Files: batch, Error: <-s.done, Stream: s}
+	}
+	s.cancel()
+	return FilesBatchMsg{Files: batch, Full: true, Stream: s}
+}
+
+// listenForFiles reads the next batch of a streamed listing
+func listenForFiles(stream *fileStream) tea.Cmd {
+	return func() tea.Msg {
+		return stream.next()
+	}
+}
+
+// loadFiles streams the current directory of the file browser from the start
+func (m *Model) loadFiles() tea.Cmd {
+	return m.loadFilesFrom(0)
+}
+
+// loadFilesFrom streams the current directory of the file browser into it,
+// starting at entry offset of the listing. A listing still running is stopped.
+func (m *Model) loadFilesFrom(offset int) tea.Cmd {
+	m.stopFileStream()
+
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return func() tea.Msg {
+			return FilesLoadedMsg{Error: fmt.Errorf("no repository selected")}
+		}
+	}
+	if m.fileBrowser == nil || m.fileBrowser.GetSnapshot() == nil {
+		return func() tea.Msg {
+			return FilesLoadedMsg{Error: fmt.Errorf("no snapshot selected for browsing")}
+		}
+	}
+
+	stream := newFileStream(offset, m.fileWindowSize())
+	m.fileStream = stream
+
+	m.fileBrowser.SetFiles(nil)
+	m.fileBrowser.SetWindow(offset, false)
+	m.fileBrowser.SetLoading(true)
+
+	client := m.newClient(m.config.Repositories[m.currentRepoIndex])
+	snapshotID := m.fileBrowser.GetSnapshot().ID
+	path := m.fileBrowser.GetCurrentPath()
+	return func() tea.Msg {
+		go stream.run(client, snapshotID, path)
+		return stream.next()
+	}
+}
+
+// nextFileWindow replaces the entries in the file browser with the next
+// window, listing the directory again from where the current window ends
+func (m *Model) nextFileWindow() tea.Cmd {
+	if m.fileBrowser == nil || !m.fileBrowser.HasMore() {
+		return nil
+	}
+
+	offset := m.fileBrowser.Offset() + m.fileBrowser.Count()
+	m.opsPanel.Info(fmt.Sprintf("Loading entries from %d...", offset+1))
+	return m.loadFilesFrom(offset)
+}
+
+// prevFileWindow lists the directory again up to the window before the
+// current one, since entries already passed are not kept
+func (m *Model) prevFileWindow() tea.Cmd {
+	if m.fileBrowser == nil || m.fileBrowser.Offset() == 0 {
+		return nil
+	}
+
+	offset := max(m.fileBrowser.Offset()-m.fileWindowSize(), 0)
+	m.opsPanel.Info(fmt.Sprintf("Loading entries from %d...", offset+1))
+	return m.loadFilesFrom(offset)
+}
+
+// fileWindowSize returns how many entries of a directory the browser holds at once
+func (m *Model) fileWindowSize() int {
+	if m.fileWindow > 0 {
+		return m.fileWindow
+	}
+	return maxFileNodes
+}
+
+// stopFileStream cancels the running listing, if any
+func (m *Model) stopFileStream() {
+	if m.fileStream != nil {
+		m.fileStream.cancel()
+		m.fileStream = nil
+	}
+}
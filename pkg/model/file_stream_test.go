@@ -0,0 +1,120 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+// makeFileNodes returns n files named file00, file01, ... in /data
+func makeFileNodes(n int) []types.FileNode {
+	files := make([]types.FileNode, n)
+	for i := range files {
+		name := fmt.Sprintf("file%02d", i)
+		files[i] = types.FileNode{MessageType: "node", Name: name, Type: "file", Path: "/data/" + name}
+	}
+	return files
+}
+
+// runFileCmd runs cmd and feeds its message back into the model
+func runFileCmd(t *testing.T, m Model, cmd tea.Cmd) (Model, tea.Msg) {
+	t.Helper()
+	if cmd == nil {
+		t.Fatal("Expected a command loading files")
+	}
+	msg := cmd()
+	updated, _ := m.Update(msg)
+	return updated.(Model), msg
+}
+
+func TestFileStream_PagesThroughWindows(t *testing.T) {
+	snapshot := &types.Snapshot{ID: "abc123", ShortID: "abc123"}
+	m := Model{
+		config:          &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo"}}},
+		opsPanel:        ui.NewOperationsPanel(),
+		fileBrowser:     ui.NewFileBrowser(snapshot),
+		showFileBrowser: true,
+		clientFactory:   &fakeFactory{files: makeFileNodes(25)},
+		fileWindow:      10,
+	}
+
+	// The first window fills and the listing stops
+	cmd := m.loadFiles()
+	if !m.fileBrowser.IsLoading() {
+		t.Error("The browser should show the loading indicator while entries stream in")
+	}
+	m, msg := runFileCmd(t, m, cmd)
+	batch, ok := msg.(FilesBatchMsg)
+	if !ok || !batch.Full {
+		t.Fatalf("Expected a full window, got %#v", msg)
+	}
+	if batch.Stream.ctx.Err() == nil || m.fileStream != nil {
+		t.Error("restic ls should be stopped while the full window is shown")
+	}
+	if m.fileBrowser.Count() != 10 || !m.fileBrowser.HasMore() || m.fileBrowser.IsLoading() {
+		t.Fatalf("Window 1: count %d, more %v, loading %v", m.fileBrowser.Count(), m.fileBrowser.HasMore(), m.fileBrowser.IsLoading())
+	}
+
+	// n on the last page lists the directory again from the next window
+	next := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")}
+	updated, cmd := m.Update(next)
+	m, _ = runFileCmd(t, updated.(Model), cmd)
+	if m.fileBrowser.Offset() != 10 || m.fileBrowser.Count() != 10 || !m.fileBrowser.HasMore() {
+		t.Fatalf("Window 2: offset %d, count %d, more %v", m.fileBrowser.Offset(), m.fileBrowser.Count(), m.fileBrowser.HasMore())
+	}
+	if got := m.fileBrowser.GetSelected().Name; got != "file10" {
+		t.Errorf("Window 2 starts at %q, want file10", got)
+	}
+
+	updated, cmd = m.Update(next)
+	m, msg = runFileCmd(t, updated.(Model), cmd)
+	if _, ok := msg.(FilesLoadedMsg); !ok {
+		t.Fatalf("The last window should end the listing, got %T", msg)
+	}
+	if m.fileBrowser.Offset() != 20 || m.fileBrowser.Count() != 5 || m.fileBrowser.HasMore() || m.fileStream != nil {
+		t.Fatalf("Window 3: offset %d, count %d, more %v", m.fileBrowser.Offset(), m.fileBrowser.Count(), m.fileBrowser.HasMore())
+	}
+
+	// p on the first page lists the directory again from the previous window
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	m, _ = runFileCmd(t, updated.(Model), cmd)
+	if m.fileBrowser.Offset() != 10 || m.fileBrowser.Count() != 10 || !m.fileBrowser.HasMore() {
+		t.Fatalf("Previous window: offset %d, count %d, more %v", m.fileBrowser.Offset(), m.fileBrowser.Count(), m.fileBrowser.HasMore())
+	}
+	if got := m.fileBrowser.GetSelected().Name; got != "file10" {
+		t.Errorf("Previous window starts at %q, want file10", got)
+	}
+}
+
+func TestFileStream_StaleBatchesIgnored(t *testing.T) {
+	snapshot := &types.Snapshot{ID: "abc123", ShortID: "abc123"}
+	m := Model{
+		config:          &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo"}}},
+		opsPanel:        ui.NewOperationsPanel(),
+		fileBrowser:     ui.NewFileBrowser(snapshot),
+		showFileBrowser: true,
+		clientFactory:   &fakeFactory{files: makeFileNodes(3)},
+	}
+
+	m.loadFiles()
+	stale := m.fileStream
+	m.loadFiles()
+	if stale.ctx.Err() == nil {
+		t.Error("Starting a new listing should cancel the running one")
+	}
+
+	updated, cmd := m.Update(FilesBatchMsg{Files: makeFileNodes(2), Stream: stale})
+	m = updated.(Model)
+	if cmd != nil || m.fileBrowser.Count() != 0 {
+		t.Errorf("A batch from a cancelled listing should be dropped, browser holds %d", m.fileBrowser.Count())
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if m.fileStream != nil {
+		t.Error("Closing the browser should stop the listing")
+	}
+}
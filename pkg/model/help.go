@@ -0,0 +1,56 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fixedHelp lists keys that can't be rebound, shown above the configurable actions
+var fixedHelp = [][2]string{
+	{"↑/k", "Move up"},
+	{"↓/j", "Move down"},
+	{"Tab/→/l", "Next panel"},
+	{"Shift+Tab/←/h", "Previous panel"},
+	{"Enter", "Select / View details"},
+	{"PgUp/PgDn", "Scroll the operations log"},
+}
+
+// helpText builds the help screen from the key map, grouping the configurable
+// actions by category so it always matches the active bindings
+func helpText(keys KeyMap) string {
+	var b strings.Builder
+	row := func(key, desc string) {
+		fmt.Fprintf(&b, "  %-14s %s\n", key, desc)
+	}
+
+	b.WriteString("LazyRestic v0.1.0 - Keyboard Shortcuts\n\nNavigation:\n")
+	for _, entry := range fixedHelp {
+		row(entry[0], entry[1])
+	}
+
+	actions := keys.actionList()
+	for _, category := range helpCategories {
+		fmt.Fprintf(&b, "\n%s:\n", category)
+		for _, action := range actions {
+			if action.category == category {
+				row(keyLabel(*action.key), action.desc)
+			}
+		}
+	}
+
+	b.WriteString(`
+Filter mode:
+  Type to search by ID, path, tag, or hostname
+  host:NAME tag:NAME path:DIR filter in restic (Snapshots)
+  Enter to apply, Esc to cancel
+
+Panels:
+  Left:   Repositories list
+  Right:  Snapshots for selected repository
+  Bottom: Operations and logs
+
+Keys can be changed in the keybindings section of the config file.
+`)
+	fmt.Fprintf(&b, "Press %s or Esc to close this help.", keyLabel(keys.Help))
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestActionList_CoversEveryKeyMapField(t *testing.T) {
+	keys := DefaultKeyMap()
+	covered := make(map[*string]string)
+	for _, action := range keys.actionList() {
+		if other, dup := covered[action.key]; dup {
+			t.Errorf("Actions %s and %s share a KeyMap field", other, action.name)
+		}
+		covered[action.key] = action.name
+	}
+
+	v := reflect.ValueOf(&keys).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i).Addr().Interface().(*string)
+		if _, ok := covered[field]; !ok {
+			t.Errorf("KeyMap.%s has no entry in actionList()", v.Type().Field(i).Name)
+		}
+	}
+}
+
+func TestHelpText_ListsEveryAction(t *testing.T) {
+	// actionList covers every KeyMap field, so every configurable key has a row
+	keys := DefaultKeyMap()
+	help := helpText(keys)
+
+	categories := make(map[string]bool, len(helpCategories))
+	for _, category := range helpCategories {
+		categories[category] = true
+	}
+	for _, action := range keys.actionList() {
+		if !categories[action.category] {
+			t.Errorf("Action %s is in category %q, which the help does not show", action.name, action.category)
+		}
+		row := fmt.Sprintf("  %-14s %s\n", keyLabel(*action.key), action.desc)
+		if !strings.Contains(help, row) {
+			t.Errorf("Help is missing the row for %s: %q", action.name, row)
+		}
+	}
+}
+
+func TestHelpText_FollowsRemappedKeys(t *testing.T) {
+	keys, warnings := ResolveKeyMap(map[string]string{"backup": "W"})
+	if len(warnings) != 0 {
+		t.Fatalf("Unexpected warnings: %v", warnings)
+	}
+
+	help := helpText(keys)
+	if !strings.Contains(help, "  W              Start a backup") {
+		t.Errorf("Help should show the remapped backup key, got:\n%s", help)
+	}
+	if strings.Contains(help, "  b              Start a backup") {
+		t.Error("Help should not show the default backup key after remapping")
+	}
+}
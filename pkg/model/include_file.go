@@ -0,0 +1,31 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// includeFileThreshold is the number of selected files above which a restore
+// lists them in a temporary --include-file instead of one --include flag each,
+// keeping the restic command line short
+const includeFileThreshold = 50
+
+// writeIncludeFile writes paths, one per line, to a new temporary file for
+// restic restore --include-file and returns its path. The caller removes it.
+func writeIncludeFile(paths []string) (string, error) {
+	file, err := os.CreateTemp("", "lazyrestic-include-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create include file: %w", err)
+	}
+	if _, err := file.WriteString(strings.Join(paths, "\n") + "\n"); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write include file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write include file: %w", err)
+	}
+	return file.Name(), nil
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+func TestPrefillRestorePaths_SmallSelectionUsesIncludeFlags(t *testing.T) {
+	m := &Model{
+		config:   &types.ResticConfig{},
+		opsPanel: ui.NewOperationsPanel(),
+	}
+	m.openRestoreForm(&types.Snapshot{ShortID: "abc123"})
+
+	m.prefillRestorePaths([]string{"/etc/hosts", "/etc/fstab"})
+
+	if got := m.restoreForm.GetInclude(); strings.Join(got, " ") != "/etc/hosts /etc/fstab" {
+		t.Errorf("GetInclude() = %v, want the selected paths", got)
+	}
+	if m.restoreForm.GetIncludeFile() != "" || m.restoreIncludeTemp != "" {
+		t.Error("A small selection should not write an include file")
+	}
+}
+
+func TestPrefillRestorePaths_LargeSelectionWritesIncludeFile(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	m := &Model{
+		config:   &types.ResticConfig{},
+		opsPanel: ui.NewOperationsPanel(),
+	}
+	m.openRestoreForm(&types.Snapshot{ShortID: "abc123"})
+
+	var paths []string
+	for i := 0; i <= includeFileThreshold; i++ {
+		paths = append(paths, fmt.Sprintf("/data/file-%03d.txt", i))
+	}
+	m.prefillRestorePaths(paths)
+
+	path := m.restoreForm.GetIncludeFile()
+	if path == "" || path != m.restoreIncludeTemp {
+		t.Fatalf("GetIncludeFile() = %q, want the temporary file %q", path, m.restoreIncludeTemp)
+	}
+	if got := m.restoreForm.GetInclude(); len(got) != 0 {
+		t.Errorf("GetInclude() = %v, want no --include flags", got)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Include file missing: %v", err)
+	}
+	if got := strings.Split(strings.TrimSpace(string(data)), "\n"); strings.Join(got, " ") != strings.Join(paths, " ") {
+		t.Errorf("Include file lists %d paths, want all %d selected", len(got), len(paths))
+	}
+
+	m.removeRestoreIncludeTemp()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Include file should be removed, stat error: %v", err)
+	}
+	if m.restoreIncludeTemp != "" {
+		t.Error("restoreIncludeTemp should be cleared")
+	}
+}
+
+func TestOpenRestoreForm_DefaultRestoreTarget(t *testing.T) {
+	m := &Model{
+		config: &types.ResticConfig{Repositories: []types.RepositoryConfig{
+			{Name: "home", Path: "/srv/restic", DefaultRestoreTarget: "/srv/restores"},
+		}},
+		opsPanel: ui.NewOperationsPanel(),
+	}
+
+	m.openRestoreForm(&types.Snapshot{ShortID: "abc123"})
+
+	if got := m.restoreForm.GetTarget(); got != "/srv/restores" {
+		t.Errorf("GetTarget() = %q, want the repository's default_restore_target", got)
+	}
+}
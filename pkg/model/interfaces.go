@@ -1,10 +1,17 @@
 package model
 
-import "github.com/craigderington/lazyrestic/pkg/types"
+import (
+	"context"
+	"os/exec"
+
+	"github.com/craigderington/lazyrestic/pkg/restic"
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
 
 // ConfigLoader interface for loading configuration
 type ConfigLoader interface {
 	LoadOrDefault(path string) *types.ResticConfig
+	LoadAndValidate(path string) (*types.ResticConfig, error)
 }
 
 // ResticClientFactory interface for creating restic clients
@@ -12,9 +19,62 @@ type ResticClientFactory interface {
 	NewClient(config types.RepositoryConfig) ResticClient
 }
 
-// ResticClient interface for restic operations
+// Clipboard interface for copying text to the user's clipboard
+type Clipboard interface {
+	Copy(text string) error
+}
+
+// ResticClient interface for restic operations; restic.Client implements it
+// and tests substitute a fake through the model's ResticClientFactory
 type ResticClient interface {
+	// Repository
+	Ping() error
+	GetRepositoryInfo() (*types.Repository, error)
+	GetConfigBlob() (*types.RepoConfigBlob, error)
+	Init(opts types.InitOptions) error
+	InitWithOptions(repoVersion int, compression string) error
+	Mount(mountpoint string) (*exec.Cmd, error)
+
+	// Snapshots and files
 	ListSnapshots() ([]types.Snapshot, error)
+	ListSnapshotsFiltered(host string, tags []string, paths []string) ([]types.Snapshot, error)
 	ListFiles(snapshotID string, path string) ([]types.FileNode, error)
-	GetRepositoryInfo() (*types.Repository, error)
+	ListFilesStream(ctx context.Context, snapshotID string, path string, out chan<- types.FileNode) error
+	DumpFileLimit(snapshotID string, path string, limit int64) ([]byte, error)
+	Find(pattern string) ([]types.FindResult, error)
+	GetSnapshotStats(snapshotID string) (*types.SnapshotStats, error)
+	GetSnapshotSize(snapshotID string) (*types.SnapshotStats, error)
+	GetDuplicateStats() (*types.DuplicateStats, error)
+	SetProtected(snapshotID string, protected bool) error
+
+	// Backup and restore
+	BackupWithChannel(ctx context.Context, opts types.BackupOptions, updates chan<- restic.BackupMessage)
+	Restore(opts types.RestoreOptions) error
+	RestoreWithChannel(ctx context.Context, opts types.RestoreOptions, updates chan<- restic.RestoreMessage)
+	RestoreDryRun(opts types.RestoreOptions) ([]string, error)
+	EstimateExcluded(opts types.BackupOptions, backup *types.BackupSummary) (*types.ExclusionSummary, error)
+
+	// Forget and prune
+	ForgetDryRun(policy types.ForgetPolicy) ([]types.ForgetResult, error)
+	Forget(policy types.ForgetPolicy) error
+	ForgetByID(ids []string) error
+	PruneDryRun() (string, error)
+	Prune() error
+
+	// Maintenance
+	CheckRepositoryDataWithChannel(ctx context.Context, subset string, updates chan<- restic.CheckMessage)
+	RepairIndexWithChannel(ctx context.Context, updates chan<- restic.CheckMessage)
+	ListMigrations() ([]string, error)
+	ApplyMigrationWithChannel(ctx context.Context, name string, updates chan<- restic.CheckMessage)
+	RunWithChannel(ctx context.Context, args []string, updates chan<- restic.CheckMessage)
+	CleanupCache() (string, error)
+	Unlock() (string, error)
+	ListLocks() ([]types.Lock, error)
+	UnlockAll() (string, error)
+
+	// Keys
+	ListKeys() ([]types.RepoKey, error)
+	AddKey(newPassword string) error
+	RemoveKey(id string) error
+	ChangePassword(newPassword string) error
 }
@@ -0,0 +1,307 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KeyMap holds the key bound to each configurable action in the main view.
+// Navigation keys (arrows, hjkl, Tab, Enter) and Ctrl+C are fixed, as are
+// the keys used inside forms, dialogs and the file browser.
+type KeyMap struct {
+	Quit           string
+	Help           string
+	Add            string
+	Scan           string
+	QuickSwitch    string
+	Refresh        string
+	RefreshRepo    string
+	ToggleGroup    string
+	OverdueFirst   string
+	Backup         string
+	QuickBackup    string
+	History        string
+	Restore        string
+	Sandbox        string
+	Remove         string
+	Rename         string
+	Unlock         string
+	UnlockAll      string
+	Cache          string
+	Verify         string
+	Repair         string
+	Migrate        string
+	Keys           string
+	RepoConfig     string
+	Totals         string
+	Duplicates     string
+	Mount          string
+	Find           string
+	Mark           string
+	CompareMark    string
+	SnapshotInfo   string
+	Forget         string
+	CopyID         string
+	Protect        string
+	Export         string
+	Sizes          string
+	RetrySnapshots string
+	PauseRefresh   string
+	ExportLogs     string
+	Rerun          string
+	VerboseErrors  string
+	EditConfig     string
+	Diagnostics    string
+	Command        string
+	Filter         string
+	ClearFilter    string
+	TagFilter      string
+	HostFilter     string
+	LevelFilter    string
+}
+
+// reservedKeys cannot be bound to actions because navigation handles them first
+var reservedKeys = []string{"ctrl+c", "tab", "shift+tab", "enter", "esc", "up", "down", "left", "right", "h", "j", "k", "l", "pgup", "pgdown"}
+
+// DefaultKeyMap returns the built-in key bindings
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Quit:           "q",
+		Help:           "?",
+		Add:            "a",
+		Scan:           "s",
+		QuickSwitch:    "ctrl+p",
+		Refresh:        "r",
+		RefreshRepo:    "g",
+		ToggleGroup:    "z",
+		OverdueFirst:   "o",
+		Backup:         "b",
+		QuickBackup:    "B",
+		History:        "J",
+		Restore:        "R",
+		Sandbox:        "w",
+		Remove:         "x",
+		Rename:         "E",
+		Unlock:         "u",
+		UnlockAll:      "U",
+		Cache:          "C",
+		Verify:         "V",
+		Repair:         "I",
+		Migrate:        "G",
+		Keys:           "K",
+		RepoConfig:     "M",
+		Totals:         "A",
+		Duplicates:     "N",
+		Mount:          "n",
+		Find:           "f",
+		Mark:           " ",
+		CompareMark:    "m",
+		SnapshotInfo:   "i",
+		Forget:         "F",
+		CopyID:         "y",
+		Protect:        "P",
+		Export:         "X",
+		Sizes:          "S",
+		RetrySnapshots: "t",
+		PauseRefresh:   "p",
+		ExportLogs:     "L",
+		Rerun:          ".",
+		VerboseErrors:  "v",
+		EditConfig:     "O",
+		Diagnostics:    "d",
+		Command:        ":",
+		Filter:         "/",
+		ClearFilter:    "c",
+		TagFilter:      "T",
+		HostFilter:     "H",
+		LevelFilter:    "e",
+	}
+}
+
+// Help screen categories, in display order
+const (
+	categoryRepositories = "Repositories"
+	categoryBackup       = "Backup & Restore"
+	categorySnapshots    = "Snapshots"
+	categoryMaintenance  = "Maintenance"
+	categoryFiltering    = "Filtering (Snapshots and Operations panels)"
+	categoryGeneral      = "General"
+)
+
+// helpCategories lists the categories in the order the help screen shows them
+var helpCategories = []string{
+	categoryRepositories, categoryBackup, categorySnapshots,
+	categoryMaintenance, categoryFiltering, categoryGeneral,
+}
+
+// keyAction describes one configurable action: its name in the keybindings
+// config section, where and how the help screen lists it, and its key
+type keyAction struct {
+	name     string
+	category string
+	desc     string
+	key      *string
+}
+
+// actionList returns every configurable action. It is the single source for
+// config action names and the help screen, so a new KeyMap field must be
+// added here to be bindable and documented.
+func (k *KeyMap) actionList() []keyAction {
+	return []keyAction{
+		{"add", categoryRepositories, "Add new repository", &k.Add},
+		{"scan", categoryRepositories, "Scan for existing repositories", &k.Scan},
+		{"quick_switch", categoryRepositories, "Quick switch to a repository by name, recent first", &k.QuickSwitch},
+		{"remove", categoryRepositories, "Remove repository from config", &k.Remove},
+		{"rename", categoryRepositories, "Rename repository", &k.Rename},
+		{"refresh_repo", categoryRepositories, "Refresh selected repository only", &k.RefreshRepo},
+		{"toggle_group", categoryRepositories, "Collapse/expand repository group", &k.ToggleGroup},
+		{"overdue_first", categoryRepositories, "List repositories overdue for backup first", &k.OverdueFirst},
+		{"repo_config", categoryRepositories, "Show repository config (version, ID)", &k.RepoConfig},
+		{"totals", categoryRepositories, "Show totals across all repositories", &k.Totals},
+		{"duplicates", categoryRepositories, "Measure duplicate files in the latest snapshot (restic stats --mode files-by-contents)", &k.Duplicates},
+		{"mount", categoryRepositories, "Mount/unmount repository with FUSE", &k.Mount},
+
+		{"backup", categoryBackup, "Start a backup", &k.Backup},
+		{"quick_backup", categoryBackup, "Back up the repository's default paths (no form)", &k.QuickBackup},
+		{"history", categoryBackup, "Show the selected repository's backup history and trends", &k.History},
+		{"restore", categoryBackup, "Restore selected snapshot", &k.Restore},
+		{"sandbox", categoryBackup, "Restore selected snapshot to a temporary directory", &k.Sandbox},
+		{"find", categoryBackup, "Find a file across all snapshots", &k.Find},
+
+		{"mark", categorySnapshots, "Mark/unmark snapshot for forget", &k.Mark},
+		{"compare_mark", categorySnapshots, "Mark snapshot ①/② for compare", &k.CompareMark},
+		{"snapshot_info", categorySnapshots, "Show selected snapshot details", &k.SnapshotInfo},
+		{"forget", categorySnapshots, "Forget marked snapshots", &k.Forget},
+		{"copy_id", categorySnapshots, "Copy selected snapshot ID to clipboard", &k.CopyID},
+		{"protect", categorySnapshots, "Protect/unprotect the selected snapshot from forget policies", &k.Protect},
+		{"export_snapshots", categorySnapshots, "Export listed snapshots as JSON", &k.Export},
+		{"sizes", categorySnapshots, "Toggle snapshot size column", &k.Sizes},
+		{"retry_snapshots", categorySnapshots, "Retry a failed snapshot load", &k.RetrySnapshots},
+
+		{"verify", categoryMaintenance, "Verify repository data subset", &k.Verify},
+		{"repair", categoryMaintenance, "Repair repository index", &k.Repair},
+		{"migrate", categoryMaintenance, "List and apply repository migrations (restic migrate)", &k.Migrate},
+		{"keys", categoryMaintenance, "Manage repository access keys (restic key)", &k.Keys},
+		{"unlock", categoryMaintenance, "Unlock repository", &k.Unlock},
+		{"unlock_all", categoryMaintenance, "Remove all locks, even live ones (unlock --remove-all)", &k.UnlockAll},
+		{"cache", categoryMaintenance, "Clean up cache", &k.Cache},
+
+		{"filter", categoryFiltering, "Enter filter mode", &k.Filter},
+		{"clear_filter", categoryFiltering, "Clear active filter (Esc also works)", &k.ClearFilter},
+		{"tag_filter", categoryFiltering, "Filter snapshots by tag (empty clears the tag filter)", &k.TagFilter},
+		{"host_filter", categoryFiltering, "Filter snapshots by host (empty clears the host filter)", &k.HostFilter},
+		{"level_filter", categoryFiltering, "Cycle log level: all → warnings+errors → errors (Operations)", &k.LevelFilter},
+
+		{"refresh", categoryGeneral, "Refresh data", &k.Refresh},
+		{"pause_refresh", categoryGeneral, "Pause/resume auto-refresh", &k.PauseRefresh},
+		{"export_logs", categoryGeneral, "Export operations log to a file", &k.ExportLogs},
+		{"rerun", categoryGeneral, "Re-run the last restic command", &k.Rerun},
+		{"verbose_errors", categoryGeneral, "Toggle restic's raw output in error messages", &k.VerboseErrors},
+		{"edit_config", categoryGeneral, "Edit the config file in $EDITOR (copies its path if unset)", &k.EditConfig},
+		{"command", categoryGeneral, "Run a restic subcommand against the selected repository", &k.Command},
+		{"diagnostics", categoryGeneral, "Run diagnostics: restic, config file, passwords and reachability", &k.Diagnostics},
+		{"help", categoryGeneral, "Toggle this help", &k.Help},
+		{"quit", categoryGeneral, "Quit (asks first while an operation runs; Ctrl+C twice quits at once)", &k.Quit},
+	}
+}
+
+// actions maps the config action names to the fields they set
+func (k *KeyMap) actions() map[string]*string {
+	list := k.actionList()
+	actions := make(map[string]*string, len(list))
+	for _, action := range list {
+		actions[action.name] = action.key
+	}
+	return actions
+}
+
+// normalizeKey converts a configured key to the form tea.KeyMsg.String() reports
+func normalizeKey(key string) string {
+	if strings.EqualFold(strings.TrimSpace(key), "space") {
+		return " "
+	}
+	if key == " " {
+		return key
+	}
+	return strings.TrimSpace(key)
+}
+
+// keyLabel returns a key as shown in help text
+func keyLabel(key string) string {
+	if key == " " {
+		return "Space"
+	}
+	return key
+}
+
+// ResolveKeyMap applies configured overrides to the defaults. Unknown actions
+// and empty keys are skipped with a warning. If the result would bind two
+// actions to the same key, or an action to a navigation key, all overrides
+// are discarded and the defaults are returned with a warning.
+func ResolveKeyMap(bindings map[string]string) (KeyMap, []string) {
+	keys := DefaultKeyMap()
+	if len(bindings) == 0 {
+		return keys, nil
+	}
+
+	var warnings []string
+	actions := keys.actions()
+
+	// Sort for deterministic warnings
+	names := make([]string, 0, len(bindings))
+	for name := range bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field, ok := actions[strings.ToLower(name)]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("Unknown keybinding action '%s' ignored", name))
+			continue
+		}
+		key := normalizeKey(bindings[name])
+		if key == "" {
+			warnings = append(warnings, fmt.Sprintf("Empty key for action '%s' ignored", name))
+			continue
+		}
+		*field = key
+	}
+
+	if err := keys.validate(); err != nil {
+		warnings = append(warnings, fmt.Sprintf("%v - using default keybindings", err))
+		return DefaultKeyMap(), warnings
+	}
+
+	return keys, warnings
+}
+
+// validate checks that every action has a distinct, non-reserved key
+func (k KeyMap) validate() error {
+	reserved := make(map[string]bool, len(reservedKeys))
+	for _, key := range reservedKeys {
+		reserved[key] = true
+	}
+
+	actions := k.actions()
+	names := make([]string, 0, len(actions))
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	owner := make(map[string]string, len(actions))
+	for _, name := range names {
+		key := *actions[name]
+		if reserved[key] {
+			return fmt.Errorf("keybinding '%s' for %s is reserved for navigation", key, name)
+		}
+		if other, taken := owner[key]; taken {
+			return fmt.Errorf("keybinding '%s' is used by both %s and %s", key, other, name)
+		}
+		owner[key] = name
+	}
+
+	return nil
+}
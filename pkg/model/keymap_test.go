@@ -0,0 +1,88 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+func TestDefaultKeyMap_Valid(t *testing.T) {
+	if err := DefaultKeyMap().validate(); err != nil {
+		t.Fatalf("Default key map is invalid: %v", err)
+	}
+}
+
+func TestResolveKeyMap_Overrides(t *testing.T) {
+	keys, warnings := ResolveKeyMap(map[string]string{
+		"backup": "W",
+		"remove": "D",
+		"mark":   "space",
+		"bogus":  "z",
+	})
+
+	if keys.Backup != "W" || keys.Remove != "D" {
+		t.Errorf("Overrides not applied: backup=%q remove=%q", keys.Backup, keys.Remove)
+	}
+	if keys.Mark != " " {
+		t.Errorf("'space' should normalize to a literal space, got %q", keys.Mark)
+	}
+	if keys.Quit != "q" {
+		t.Errorf("Unset actions should keep defaults, quit=%q", keys.Quit)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "bogus") {
+		t.Errorf("Expected a warning for the unknown action, got %v", warnings)
+	}
+}
+
+func TestResolveKeyMap_ConflictsKeepDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		bindings map[string]string
+		want     string
+	}{
+		{"Two actions on one key", map[string]string{"backup": "x"}, "used by both"},
+		{"Swapped keys collide with untouched default", map[string]string{"backup": "r", "remove": "b"}, "used by both"},
+		{"Navigation key", map[string]string{"find": "j"}, "reserved"},
+		{"Page key", map[string]string{"history": "pgdown"}, "reserved"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, warnings := ResolveKeyMap(tt.bindings)
+			if keys != DefaultKeyMap() {
+				t.Error("Conflicting bindings should fall back to the defaults")
+			}
+			if len(warnings) == 0 || !strings.Contains(warnings[len(warnings)-1], tt.want) {
+				t.Errorf("Expected warning containing %q, got %v", tt.want, warnings)
+			}
+		})
+	}
+
+	// Swapping two keys is fine when both are rebound
+	keys, warnings := ResolveKeyMap(map[string]string{"backup": "x", "remove": "b"})
+	if keys.Backup != "x" || keys.Remove != "b" || len(warnings) != 0 {
+		t.Errorf("Swapped bindings should apply cleanly, got backup=%q remove=%q warnings=%v", keys.Backup, keys.Remove, warnings)
+	}
+}
+
+func TestRemappedKeyTriggersAction(t *testing.T) {
+	keys, _ := ResolveKeyMap(map[string]string{"help": "Z"})
+	m := Model{
+		config:   &types.ResticConfig{},
+		opsPanel: ui.NewOperationsPanel(),
+		keys:     keys,
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'?'}})
+	if updated.(Model).showHelp {
+		t.Error("The old help key should no longer open help")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'Z'}})
+	if !updated.(Model).showHelp {
+		t.Error("The remapped help key should open help")
+	}
+}
@@ -0,0 +1,259 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+// listKeys asks restic for the access keys of the selected repository
+func (m Model) listKeys() tea.Cmd {
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return nil
+	}
+
+	repoConfig := m.config.Repositories[m.currentRepoIndex]
+	client := m.newClient(repoConfig)
+	return func() tea.Msg {
+		keys, err := client.ListKeys()
+		return KeysListedMsg{RepoName: repoConfig.Name, Keys: keys, Error: err}
+	}
+}
+
+// handleKeysListed shows the key overlay for a listed repository, or refreshes
+// it after a key was added or removed
+func (m *Model) handleKeysListed(msg KeysListedMsg) {
+	if msg.Error != nil {
+		m.opsPanel.Error(fmt.Sprintf("Failed to list keys of '%s': %s", msg.RepoName, m.errorText(msg.Error)))
+		return
+	}
+	m.repoKeys = msg.Keys
+	m.keysRepo = msg.RepoName
+	if m.keyCursor >= len(m.repoKeys) {
+		m.keyCursor = len(m.repoKeys) - 1
+	}
+	if m.keyCursor < 0 {
+		m.keyCursor = 0
+	}
+	m.showKeys = true
+}
+
+// updateKeys handles a key press in the key overlay, including the password
+// prompt for a new key
+func (m *Model) updateKeys(msg tea.KeyMsg) tea.Cmd {
+	if m.keyPasswordActive {
+		return m.updateKeyPassword(msg)
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		m.showKeys = false
+	case "up", "k":
+		if m.keyCursor > 0 {
+			m.keyCursor--
+		}
+	case "down", "j":
+		if m.keyCursor < len(m.repoKeys)-1 {
+			m.keyCursor++
+		}
+	case "a":
+		if m.isBusy() {
+			m.opsPanel.Warning("Another operation is in progress - try again when it finishes")
+			return nil
+		}
+		m.keyPasswordActive = true
+		m.keyPasswordText = ""
+		m.keyPasswordFirst = ""
+	case "x", "delete":
+		if len(m.repoKeys) == 0 {
+			return nil
+		}
+		key := m.repoKeys[m.keyCursor]
+		if key.Current {
+			m.opsPanel.Warning(fmt.Sprintf("Key %s is the one LazyRestic opened '%s' with - it can't be removed", key.ID, m.keysRepo))
+			return nil
+		}
+		if m.isBusy() {
+			m.opsPanel.Warning("Another operation is in progress - try again when it finishes")
+			return nil
+		}
+		m.keyAction = "remove"
+		m.keyActionID = key.ID
+		return m.confirmKeyAction(
+			"REMOVE KEY",
+			fmt.Sprintf("Remove key %s from '%s'?\n\nUser: %s\nHost: %s\nCreated: %s\n\nAnyone using this key's password loses access to the repository.", key.ID, m.keysRepo, key.UserName, key.HostName, key.Created),
+			"REMOVE",
+		)
+	}
+	return nil
+}
+
+// updateKeyPassword handles typing the new key's password, which is entered
+// twice so a typo can't lock anyone out
+func (m *Model) updateKeyPassword(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.keyPasswordActive = false
+		m.keyPasswordText = ""
+		m.keyPasswordFirst = ""
+		return nil
+
+	case "enter":
+		if m.keyPasswordText == "" {
+			m.opsPanel.Warning("Enter a password for the new key")
+			return nil
+		}
+		if m.keyPasswordFirst == "" {
+			m.keyPasswordFirst = m.keyPasswordText
+			m.keyPasswordText = ""
+			return nil
+		}
+		if m.keyPasswordText != m.keyPasswordFirst {
+			m.opsPanel.Warning("Passwords don't match - enter the new password again")
+			m.keyPasswordText = ""
+			m.keyPasswordFirst = ""
+			return nil
+		}
+		m.keyPasswordActive = false
+		m.keyAction = "add"
+		m.newKeyPassword = m.keyPasswordText
+		m.keyPasswordText = ""
+		m.keyPasswordFirst = ""
+		return m.confirmKeyAction(
+			"ADD KEY",
+			fmt.Sprintf("Add a new key to '%s'?\n\nThe new password opens the repository just like the\ncurrent one. Keep it somewhere safe.", m.keysRepo),
+			"ADD",
+		)
+
+	case "backspace":
+		if text := []rune(m.keyPasswordText); len(text) > 0 {
+			m.keyPasswordText = string(text[:len(text)-1])
+		}
+		return nil
+
+	default:
+		// Runes rather than msg.String() so non-ASCII and pasted text arrive whole
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			m.keyPasswordText += string(msg.Runes)
+		}
+		return nil
+	}
+}
+
+// confirmKeyAction asks for the typed confirmation of the pending key action
+func (m *Model) confirmKeyAction(title, body, word string) tea.Cmd {
+	m.keyConfirmDialog = ui.NewConfirmationDialog(title, body, word)
+	m.keyConfirmDialog.SetSize(m.width*3/4, m.height*3/4)
+	m.keyConfirmDialog.SetTimeoutSeconds(confirmTimeoutSeconds)
+	m.showKeyConfirm = true
+	m.opsPanel.Warning(fmt.Sprintf("⚠️  Type '%s' to %s the key", word, m.keyAction))
+	return scheduleConfirmTimeout(m.keyConfirmDialog)
+}
+
+// cancelKeyAction drops the pending key action and the password it carried
+func (m *Model) cancelKeyAction() {
+	m.showKeyConfirm = false
+	m.keyConfirmDialog = nil
+	m.keyAction = ""
+	m.keyActionID = ""
+	m.newKeyPassword = ""
+}
+
+// executeKeyAction runs the confirmed key action against the key overlay's repository
+func (m *Model) executeKeyAction() tea.Cmd {
+	action, id, password, repoName := m.keyAction, m.keyActionID, m.newKeyPassword, m.keysRepo
+	m.cancelKeyAction()
+
+	var repoConfig *types.RepositoryConfig
+	for i := range m.config.Repositories {
+		if m.config.Repositories[i].Name == repoName {
+			repoConfig = &m.config.Repositories[i]
+		}
+	}
+	if repoConfig == nil {
+		m.opsPanel.Error(fmt.Sprintf("Repository '%s' not found", repoName))
+		return nil
+	}
+
+	if !m.claimRepo(repoName) {
+		return nil
+	}
+
+	name := "key " + action
+	if action == "remove" {
+		m.opsPanel.Info(fmt.Sprintf("Removing key %s from '%s'...", id, repoName))
+		m.opsPanel.Dimmed(fmt.Sprintf("Command: restic -r %s key remove %s", repoConfig.Path, id))
+		return m.startOperation(name, m.runRepoCommandOn(*repoConfig, name, func(client ResticClient) (string, error) {
+			return "", client.RemoveKey(id)
+		}))
+	}
+	m.opsPanel.Info(fmt.Sprintf("Adding a key to '%s'...", repoName))
+	m.opsPanel.Dimmed(fmt.Sprintf("Command: restic -r %s key add --new-password-file <temporary file>", repoConfig.Path))
+	return m.startOperation(name, m.runRepoCommandOn(*repoConfig, name, func(client ResticClient) (string, error) {
+		return "", client.AddKey(password)
+	}))
+}
+
+// relistKeys lists the keys of the named repository again after one was added
+// or removed
+func (m Model) relistKeys(repoName string) tea.Cmd {
+	for _, repoConfig := range m.config.Repositories {
+		if repoConfig.Name == repoName {
+			client := m.newClient(repoConfig)
+			return func() tea.Msg {
+				keys, err := client.ListKeys()
+				return KeysListedMsg{RepoName: repoName, Keys: keys, Error: err}
+			}
+		}
+	}
+	return nil
+}
+
+// renderKeys renders the access keys of a repository as a selectable list
+func (m Model) renderKeys() string {
+	dimmedStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Dimmed)
+
+	var b strings.Builder
+	b.WriteString(ui.TitleStyle.Render(fmt.Sprintf("Keys for '%s'", m.keysRepo)) + "\n\n")
+	b.WriteString(dimmedStyle.Render(fmt.Sprintf("  %-10s %-12s %-16s %s", "ID", "User", "Host", "Created")) + "\n")
+	for i, key := range m.repoKeys {
+		line := fmt.Sprintf("%-10s %-12s %-16s %s", key.ID, key.UserName, key.HostName, key.Created)
+		if key.Current {
+			line += "  (current)"
+		}
+		if i == m.keyCursor {
+			b.WriteString(ui.ListItemSelectedStyle.Render("▶ "+line) + "\n")
+		} else {
+			b.WriteString(ui.ListItemStyle.Render("  "+line) + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	switch {
+	case m.keyPasswordActive && m.keyPasswordFirst == "":
+		b.WriteString("New key password: " + strings.Repeat("*", len(m.keyPasswordText)) + "_\n")
+		b.WriteString(dimmedStyle.Render("Enter to continue • Esc to cancel"))
+	case m.keyPasswordActive:
+		b.WriteString("Repeat password: " + strings.Repeat("*", len(m.keyPasswordText)) + "_\n")
+		b.WriteString(dimmedStyle.Render("Enter to continue • Esc to cancel"))
+	default:
+		b.WriteString(dimmedStyle.Render("a add key • x remove key • Esc to close"))
+	}
+
+	borderStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.CurrentTheme().Active).
+		Padding(1, 2)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		borderStyle.Render(b.String()),
+	)
+}
@@ -0,0 +1,153 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+// newKeysTestModel returns a model with the key overlay open for a repository
+// with a current key and one other key
+func newKeysTestModel(t *testing.T) (Model, *fakeFactory) {
+	t.Helper()
+	factory := &fakeFactory{keys: []types.RepoKey{
+		{Current: true, ID: "4a9c5a2e", UserName: "alice", HostName: "laptop"},
+		{ID: "b7d1e3f0", UserName: "backup", HostName: "nas"},
+	}}
+	m := Model{
+		config:        &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo"}}},
+		opsPanel:      ui.NewOperationsPanel(),
+		clientFactory: factory,
+	}
+
+	updated, _ := m.Update(m.listKeys()())
+	m = updated.(Model)
+	if !m.showKeys || m.keysRepo != "repo" || len(m.repoKeys) != 2 {
+		t.Fatalf("Expected the key list for 'repo', got %+v", m.repoKeys)
+	}
+	return m, factory
+}
+
+// pressKeys sends each key to the model in turn
+func pressKeys(m Model, keys ...tea.KeyMsg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	for _, key := range keys {
+		var updated tea.Model
+		updated, cmd = m.Update(key)
+		m = updated.(Model)
+	}
+	return m, cmd
+}
+
+// typeText returns the key presses that type s
+func typeText(s string) []tea.KeyMsg {
+	var keys []tea.KeyMsg
+	for _, r := range s {
+		keys = append(keys, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return keys
+}
+
+// finishKeyChange runs a confirmed key change and the key list that follows it
+func finishKeyChange(t *testing.T, m Model, cmd tea.Cmd) Model {
+	t.Helper()
+	if cmd == nil {
+		t.Fatal("Expected the key change to start")
+	}
+	// The change runs first; the operation spinner batched with it only ticks later
+	updated, cmd := m.Update(cmd().(tea.BatchMsg)[0]())
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("Expected the keys to be listed again")
+	}
+	updated, _ = m.Update(cmd())
+	return updated.(Model)
+}
+
+func TestKeys_RemoveCurrentKeyBlocked(t *testing.T) {
+	m, factory := newKeysTestModel(t)
+
+	m, _ = pressKeys(m, typeText("x")...)
+	if m.showKeyConfirm {
+		t.Fatal("Removing the current key should not ask for confirmation")
+	}
+	for _, call := range factory.calls {
+		if call == "RemoveKey 4a9c5a2e" {
+			t.Fatal("The current key must not be removed")
+		}
+	}
+}
+
+func TestKeys_RemoveAfterConfirmation(t *testing.T) {
+	m, factory := newKeysTestModel(t)
+
+	m, _ = pressKeys(m, append([]tea.KeyMsg{{Type: tea.KeyDown}}, typeText("x")...)...)
+	if !m.showKeyConfirm || m.keyActionID != "b7d1e3f0" {
+		t.Fatal("Removing a key should ask for confirmation")
+	}
+
+	keys := append(typeText("REMOVE"), tea.KeyMsg{Type: tea.KeyEnter})
+	m, cmd := pressKeys(m, keys...)
+	m = finishKeyChange(t, m, cmd)
+
+	want := []string{"ListKeys", "RemoveKey b7d1e3f0", "ListKeys"}
+	if !reflect.DeepEqual(factory.calls, want) {
+		t.Errorf("Expected calls %v, got %v", want, factory.calls)
+	}
+	if m.showKeyConfirm || !m.showKeys || m.operationInProgress != "" {
+		t.Error("Expected the refreshed key list once the key is removed")
+	}
+}
+
+func TestKeys_AddNeedsMatchingPasswords(t *testing.T) {
+	m, factory := newKeysTestModel(t)
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	m, _ = pressKeys(m, typeText("a")...)
+	if !m.keyPasswordActive {
+		t.Fatal("a should prompt for the new key's password")
+	}
+
+	keys := append(typeText("first"), enter)
+	keys = append(keys, typeText("other")...)
+	m, _ = pressKeys(m, append(keys, enter)...)
+	if m.showKeyConfirm || m.keyPasswordFirst != "" {
+		t.Fatal("Passwords that don't match should start the prompt over")
+	}
+
+	keys = append(typeText("s3cret"), enter)
+	keys = append(keys, typeText("s3cret")...)
+	m, _ = pressKeys(m, append(keys, enter)...)
+	if !m.showKeyConfirm || m.keyPasswordActive {
+		t.Fatal("Matching passwords should ask for confirmation")
+	}
+
+	m, cmd := pressKeys(m, append(typeText("ADD"), enter)...)
+	m = finishKeyChange(t, m, cmd)
+
+	want := []string{"ListKeys", "AddKey s3cret", "ListKeys"}
+	if !reflect.DeepEqual(factory.calls, want) {
+		t.Errorf("Expected calls %v, got %v", want, factory.calls)
+	}
+	if m.newKeyPassword != "" {
+		t.Error("The new password should not be kept once the key is added")
+	}
+}
+
+func TestKeys_PasswordKeepsPastedAndNonASCIIText(t *testing.T) {
+	m, _ := newKeysTestModel(t)
+	m, _ = pressKeys(m, typeText("a")...)
+
+	m, _ = pressKeys(m,
+		tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("pässwörd"), Paste: true},
+		tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}},
+		tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("日本")},
+		tea.KeyMsg{Type: tea.KeyBackspace},
+	)
+	if want := "pässwörd 日"; m.keyPasswordText != want {
+		t.Errorf("keyPasswordText = %q, want %q", m.keyPasswordText, want)
+	}
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"sync"
+
+	"github.com/craigderington/lazyrestic/pkg/restic"
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+// DefaultMaxConcurrency is the number of repositories loaded in parallel
+// when the config does not specify max_concurrency
+const DefaultMaxConcurrency = 4
+
+// defaultClientFactory creates real restic clients
+type defaultClientFactory struct{}
+
+// NewClient creates a restic client for the repository
+func (defaultClientFactory) NewClient(config types.RepositoryConfig) ResticClient {
+	return restic.NewClient(config)
+}
+
+// clients returns the model's client factory, or the real one when unset
+func (m Model) clients() ResticClientFactory {
+	if m.clientFactory == nil {
+		return defaultClientFactory{}
+	}
+	return m.clientFactory
+}
+
+// newClient creates a client for repoConfig through the model's client factory
+func (m Model) newClient(repoConfig types.RepositoryConfig) ResticClient {
+	return m.clients().NewClient(repoConfig)
+}
+
+// loadRepositoryInfos fetches repository information for every config using at
+// most maxConcurrency clients at once. Results keep the order of configs.
+func loadRepositoryInfos(configs []types.RepositoryConfig, factory ResticClientFactory, maxConcurrency int) []types.Repository {
+	if maxConcurrency < 1 {
+		maxConcurrency = DefaultMaxConcurrency
+	}
+
+	repos := make([]types.Repository, len(configs))
+	sem := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+
+	for i, repoConfig := range configs {
+		wg.Add(1)
+		go func(i int, repoConfig types.RepositoryConfig) {
+			defer wg.Done()
+
+			// Acquire a slot so slow backends don't overwhelm the system
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			repos[i] = loadRepositoryInfo(repoConfig, factory.NewClient(repoConfig))
+		}(i, repoConfig)
+	}
+
+	wg.Wait()
+	return repos
+}
+
+// loadRepositoryInfo fetches information for a single repository
+func loadRepositoryInfo(repoConfig types.RepositoryConfig, client ResticClient) types.Repository {
+	// Get comprehensive repository information
+	repoInfo, err := client.GetRepositoryInfo()
+	if err != nil || repoInfo == nil {
+		// If we can't get info, create a minimal repo entry
+		status := "error"
+		if restic.IsNotInitialized(err) {
+			status = "uninitialized"
+		}
+		return types.Repository{
+			Name:     repoConfig.Name,
+			Path:     repoConfig.Path,
+			Status:   status,
+			Group:    repoConfig.Group,
+			Schedule: repoConfig.Schedule,
+		}
+	}
+
+	// Set the name, path, group and schedule from config
+	repoInfo.Name = repoConfig.Name
+	repoInfo.Path = repoConfig.Path
+	repoInfo.Group = repoConfig.Group
+	repoInfo.Schedule = repoConfig.Schedule
+
+	return *repoInfo
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/craigderington/lazyrestic/pkg/restic"
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+func makeConfigs(n int) []types.RepositoryConfig {
+	configs := make([]types.RepositoryConfig, n)
+	for i := range configs {
+		configs[i] = types.RepositoryConfig{
+			Name: fmt.Sprintf("repo-%d", i),
+			Path: fmt.Sprintf("/tmp/repo-%d", i),
+		}
+	}
+	return configs
+}
+
+func TestLoadRepositoryInfos_PreservesOrder(t *testing.T) {
+	configs := makeConfigs(6)
+	factory := &fakeFactory{delay: 5 * time.Millisecond, failFor: "repo-2"}
+
+	repos := loadRepositoryInfos(configs, factory, 3)
+
+	if len(repos) != len(configs) {
+		t.Fatalf("Repositories count = %v, want %v", len(repos), len(configs))
+	}
+
+	for i, repo := range repos {
+		if repo.Name != configs[i].Name || repo.Path != configs[i].Path {
+			t.Errorf("Repo %d = %s (%s), want %s (%s)", i, repo.Name, repo.Path, configs[i].Name, configs[i].Path)
+		}
+	}
+
+	if repos[2].Status != "error" {
+		t.Errorf("Failed repo status = %v, want error", repos[2].Status)
+	}
+	if repos[0].Status != "healthy" {
+		t.Errorf("Repo status = %v, want healthy", repos[0].Status)
+	}
+}
+
+func TestLoadRepositoryInfos_BoundedConcurrency(t *testing.T) {
+	configs := makeConfigs(8)
+	delay := 50 * time.Millisecond
+	factory := &fakeFactory{delay: delay}
+
+	start := time.Now()
+	loadRepositoryInfos(configs, factory, 4)
+	elapsed := time.Since(start)
+
+	if factory.maxSeen > 4 {
+		t.Errorf("Max concurrent loads = %v, want <= 4", factory.maxSeen)
+	}
+
+	// 8 repos with 4 workers should take ~2 rounds, far less than the serial sum
+	serial := time.Duration(len(configs)) * delay
+	if elapsed >= serial {
+		t.Errorf("Loading took %v, expected less than serial time %v", elapsed, serial)
+	}
+	if elapsed < 2*delay {
+		t.Errorf("Loading took %v, expected at least %v with 4 workers", elapsed, 2*delay)
+	}
+}
+
+func TestLoadRepositoryInfos_DefaultConcurrency(t *testing.T) {
+	factory := &fakeFactory{delay: 10 * time.Millisecond}
+
+	loadRepositoryInfos(makeConfigs(10), factory, 0)
+
+	if factory.maxSeen > DefaultMaxConcurrency {
+		t.Errorf("Max concurrent loads = %v, want <= %v", factory.maxSeen, DefaultMaxConcurrency)
+	}
+}
+
+func BenchmarkLoadRepositoryInfos(b *testing.B) {
+	configs := makeConfigs(8)
+	factory := &fakeFactory{delay: time.Millisecond}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = loadRepositoryInfos(configs, factory, DefaultMaxConcurrency)
+	}
+}
+
+func TestLoadRepositoryInfo_Uninitialized(t *testing.T) {
+	cfg := types.RepositoryConfig{Name: "new", Path: "/tmp/new-repo"}
+	client := &fakeClient{
+		config:   cfg,
+		err:      fmt.Errorf("%w: restic command failed", restic.ErrNotInitialized),
+		inFlight: new(int32),
+		maxSeen:  new(int32),
+	}
+
+	repo := loadRepositoryInfo(cfg, client)
+	if repo.Status != "uninitialized" {
+		t.Errorf("Status = %q, want uninitialized", repo.Status)
+	}
+	if repo.Name != "new" || repo.Path != "/tmp/new-repo" {
+		t.Errorf("Expected name and path from config, got %q at %q", repo.Name, repo.Path)
+	}
+}
+
+func TestLoadRepositoryInfo_CopiesGroup(t *testing.T) {
+	cfg := types.RepositoryConfig{Name: "wiki", Path: "/tmp/wiki", Group: "work"}
+	newClient := func(fail bool) *fakeClient {
+		return &fakeClient{config: cfg, fail: fail, inFlight: new(int32), maxSeen: new(int32)}
+	}
+
+	if repo := loadRepositoryInfo(cfg, newClient(false)); repo.Group != "work" {
+		t.Errorf("Group = %q, want work", repo.Group)
+	}
+	if repo := loadRepositoryInfo(cfg, newClient(true)); repo.Group != "work" {
+		t.Errorf("Group on failure = %q, want work", repo.Group)
+	}
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+// lockPollInterval is how often a running operation checks for locks held by others
+const lockPollInterval = 10 * time.Second
+
+// pollLocks starts checking the selected repository for locks while an
+// operation runs, so a stall behind another process's lock is explained.
+// Polling stops once no operation is running; starting it again replaces
+// the previous poll.
+func (m *Model) pollLocks() tea.Cmd {
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return nil
+	}
+
+	m.lockPollGen++
+	m.lockPollStarted = time.Now()
+	m.lockWarning = ""
+	return scheduleLockPoll(m.lockPollGen, m.config.Repositories[m.currentRepoIndex])
+}
+
+// scheduleLockPoll waits for the next lock check of a poll
+func scheduleLockPoll(gen int, repo types.RepositoryConfig) tea.Cmd {
+	return tea.Tick(lockPollInterval, func(time.Time) tea.Msg {
+		return LockPollMsg{Gen: gen, Repo: repo}
+	})
+}
+
+// lockPollActive reports whether a lock poll tick or result still belongs to a running operation
+func (m Model) lockPollActive(gen int) bool {
+	return gen == m.lockPollGen && m.runningOperation() != ""
+}
+
+// listLocks reads the locks of a polled repository
+func (m Model) listLocks(gen int, repo types.RepositoryConfig) tea.Cmd {
+	client := m.newClient(repo)
+	return func() tea.Msg {
+		locks, err := client.ListLocks()
+		return LocksListedMsg{Gen: gen, Repo: repo, Locks: locks, Error: err}
+	}
+}
+
+// foreignLocks returns the locks taken before since. The running operation's
+// own lock is created after it starts, so older locks belong to other processes.
+func foreignLocks(locks []types.Lock, since time.Time) []types.Lock {
+	var foreign []types.Lock
+	for _, lock := range locks {
+		if lock.Time.Before(since) {
+			foreign = append(foreign, lock)
+		}
+	}
+	return foreign
+}
+
+// describeLocks summarizes the locks an operation is waiting on, e.g.
+// "nas:4242 (exclusive, since 2 hours ago)"
+func describeLocks(locks []types.Lock) string {
+	holders := make([]string, 0, len(locks))
+	for _, lock := range locks {
+		kind := "shared"
+		if lock.Exclusive {
+			kind = "exclusive"
+		}
+		holders = append(holders, fmt.Sprintf("%s:%d (%s, since %s)", lock.Hostname, lock.PID, kind, ui.FormatTimeAgo(lock.Time)))
+	}
+	return strings.Join(holders, ", ")
+}
+
+// handleLocksListed reports locks held by other processes and schedules the next check
+func (m *Model) handleLocksListed(msg LocksListedMsg) tea.Cmd {
+	if !m.lockPollActive(msg.Gen) {
+		return nil
+	}
+
+	// A failed check is retried on the next tick; the operation reports its own errors
+	if msg.Error == nil {
+		holders := ""
+		if foreign := foreignLocks(msg.Locks, m.lockPollStarted); len(foreign) > 0 {
+			holders = describeLocks(foreign)
+		}
+		if holders != "" && holders != m.lockWarning {
+			m.opsPanel.Warning(fmt.Sprintf("Waiting on lock held by %s", holders))
+			m.opsPanel.Dimmed("If that process is gone, the lock is stale: unlock the repository once this operation gives up")
+		}
+		m.lockWarning = holders
+	}
+	return scheduleLockPoll(msg.Gen, msg.Repo)
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+func TestForeignLocks(t *testing.T) {
+	start := time.Now()
+	locks := []types.Lock{
+		{Hostname: "nas", PID: 4242, Exclusive: true, Time: start.Add(-2 * time.Hour)},
+		{Hostname: "laptop", PID: 99, Time: start.Add(time.Second)},
+	}
+
+	foreign := foreignLocks(locks, start)
+	if len(foreign) != 1 || foreign[0].Hostname != "nas" {
+		t.Fatalf("Expected only the lock taken before the operation, got %+v", foreign)
+	}
+	if got := describeLocks(foreign); !strings.HasPrefix(got, "nas:4242 (exclusive, since ") {
+		t.Errorf("describeLocks() = %q", got)
+	}
+}
+
+func TestLocksListed_WarnsOnceAndStopsWithOperation(t *testing.T) {
+	repo := types.RepositoryConfig{Name: "repo", Path: "/tmp/repo"}
+	m := Model{
+		config:           &types.ResticConfig{Repositories: []types.RepositoryConfig{repo}},
+		opsPanel:         ui.NewOperationsPanel(),
+		clientFactory:    &fakeFactory{},
+		backupInProgress: true,
+	}
+	if cmd := m.pollLocks(); cmd == nil {
+		t.Fatal("Expected lock polling to start")
+	}
+
+	stale := LocksListedMsg{
+		Gen:   m.lockPollGen,
+		Repo:  repo,
+		Locks: []types.Lock{{Hostname: "nas", PID: 4242, Exclusive: true, Time: m.lockPollStarted.Add(-time.Hour)}},
+	}
+	warnings := func() int {
+		n := 0
+		for _, entry := range m.opsPanel.FilteredLogs() {
+			if entry.Level == "warning" && strings.Contains(entry.Message, "Waiting on lock held by nas:4242") {
+				n++
+			}
+		}
+		return n
+	}
+
+	updated, cmd := m.Update(stale)
+	m = updated.(Model)
+	if cmd == nil {
+		t.Error("Polling should continue while the backup runs")
+	}
+	updated, _ = m.Update(stale)
+	m = updated.(Model)
+	if got := warnings(); got != 1 {
+		t.Errorf("Expected the lock warning once, got %d", got)
+	}
+
+	// A result from an earlier operation is ignored
+	old := stale
+	old.Gen--
+	if _, cmd := m.Update(old); cmd != nil {
+		t.Error("A stale poll should not be rescheduled")
+	}
+
+	m.backupInProgress = false
+	if _, cmd := m.Update(stale); cmd != nil {
+		t.Error("Polling should stop once the operation finishes")
+	}
+}
@@ -1,6 +1,10 @@
 package model
 
 import (
+	"context"
+	"os/exec"
+	"time"
+
 	"github.com/craigderington/lazyrestic/pkg/restic"
 	"github.com/craigderington/lazyrestic/pkg/types"
 	"github.com/craigderington/lazyrestic/pkg/ui"
@@ -17,11 +21,19 @@ type Model struct {
 	config *types.ResticConfig
 
 	// Current state
-	activePanel        types.Panel
-	repositories       []types.Repository
-	currentRepoIndex   int
-	loadingSnapshots   bool
+	activePanel         types.Panel
+	repositories        []types.Repository
+	currentRepoIndex    int
+	loadingSnapshots    bool
+	snapshotLoadError   error // Why the last snapshot load failed, nil once one succeeds
 	loadingRepositories bool
+	verboseErrors       bool // Log restic's raw output instead of a short explanation
+
+	// Auto-refresh state
+	autoRefreshing    bool      // Current reload was started by the auto-refresh timer
+	autoRefreshPaused bool      // User paused auto-refresh (e.g. while inspecting details)
+	autoRefreshGen    int       // Incremented to invalidate pending ticks when the timer is reset
+	lastRefreshed     time.Time // When repositories were last reloaded
 
 	// UI Panels
 	repoPanel    *ui.RepositoryPanel
@@ -31,24 +43,105 @@ type Model struct {
 	showHelp     bool
 
 	// Repo creation
-	showRepoForm bool
-	repoForm     *ui.RepoForm
+	showRepoForm         bool
+	repoForm             *ui.RepoForm
+	compressionSupported bool // restic can create version 2 (compressed) repositories
 
 	// Backup state
 	showBackupForm        bool
 	backupForm            *ui.BackupForm
 	backupInProgress      bool
 	currentBackupProgress *types.BackupProgress
+	lastBackupOptions     types.BackupOptions
+	backupRepo            string // Repository the running backup writes to
 
 	// Restore state
 	showRestoreForm        bool
 	restoreForm            *ui.RestoreForm
 	restoreInProgress      bool
+	restoreRepo            string // Repository the running restore reads from
 	currentRestoreProgress *types.RestoreProgress
+	showRestorePreview     bool
+	restorePreviewOpts     types.RestoreOptions // Restore waiting for confirmation
+	restorePreviewPaths    []string             // Paths the restore would write
+	restorePreviewOffset   int                  // First path shown in the preview
+	restorePreviewSpace    restoreSpace         // Free-space check of the previewed restore
+	showSpaceConfirm       bool
+	spaceConfirmDialog     *ui.ConfirmationDialog // Acknowledges restoring to a target that looks too small
+	restoreIncludeTemp     string               // Temporary --include-file for a large file selection
 
 	// Filter state
 	filterInputActive bool
 	filterInputText   string
+	filterFacet       string // "tag" or "host" while editing a single snapshot facet, "" for the text filter
+	filterFacetPrev   string // Facet value restored when its input is cancelled
+	snapshotQuery     snapshotQuery // restic-side snapshot filter (host:, tag:, path:)
+
+	// Data check state (restic check --read-data-subset)
+	checkPromptActive bool
+	checkPromptText   string
+	checkInProgress   bool
+	checkRepo         string // Repository the running data check verifies
+
+	// Long-running operation indicator (spinner and elapsed time)
+	operationInProgress string // Name of the running operation, empty when idle
+	operationStarted    time.Time
+	operationGen        int // Spinner generation; ticks from a finished operation are ignored
+	spinnerFrame        int
+	cancelOperation     context.CancelFunc // Interrupts the latest backup, restore or maintenance command
+
+	// Lock polling while an operation runs
+	lockPollGen     int       // Poll generation; ticks from an earlier operation are ignored
+	lockPollStarted time.Time // Locks older than this belong to other processes
+	lockWarning     string    // Lock holders last reported, to avoid repeating the warning
+
+	// Commands in flight per repository name; commands the user starts wait
+	// until none are running against the same repository
+	busyRepos map[string]int
+
+	// Quit confirmation while an operation runs
+	showQuitConfirm bool
+	quitPending     bool // Waiting for the cancelled operation to stop before quitting
+
+	// Snapshots restored into temporary directories for inspection
+	sandboxTarget      string   // Directory of the running sandbox restore
+	sandboxes          []string // Finished sandboxes, offered for removal on quit
+	showSandboxCleanup bool
+
+	// Find state (restic find across all snapshots)
+	findPromptActive bool
+	findPromptText   string
+	findInProgress   bool
+	showFindResults  bool
+	findPattern      string
+	findResults      []types.FindResult
+	selectedFind     int
+
+	// Repository config view (restic cat config)
+	showRepoConfig bool
+	repoConfig     *types.RepoConfigBlob
+	repoConfigName string
+
+	// Repository rename prompt
+	renamePromptActive bool
+	renamePromptText   string
+	repoToRename       string
+
+	// Running restic mount (FUSE), at most one at a time
+	mountCmd   *exec.Cmd
+	mountPoint string
+	mountRepo  string
+
+	// Snapshot detail overlay
+	showSnapshotInfo bool
+
+	// Totals across all repositories, recomputed whenever repositories load
+	showTotals bool
+	repoTotals RepositoryTotals
+
+	// Diagnostics checklist overlay
+	showDiagnostics bool
+	diagnostics     []DiagnosticCheck
 
 	// File browser state
 	showFileBrowser bool
@@ -66,16 +159,92 @@ type Model struct {
 	forgetPreview        *ui.ForgetPreview
 	showForgetConfirm    bool
 	forgetConfirmDialog  *ui.ConfirmationDialog
+	forgetIDs            []string // Snapshots to forget by ID (batch forget)
+	forgetInProgress     bool
 	forgetPreviewResults []types.ForgetResult
 	forgetPolicy         types.ForgetPolicy
 	showPruneConfirm     bool
 	pruneConfirmDialog   *ui.ConfirmationDialog
 	pruneDryRunOutput    string
 
+	// Index repair state
+	showRepairConfirm   bool
+	repairConfirmDialog *ui.ConfirmationDialog
+	repairInProgress    bool
+
+	// Repository migration state
+	showMigrations       bool
+	migrations           []string // Migrations available for migrationRepo
+	migrationCursor      int
+	migrationRepo        string
+	showMigrateConfirm   bool
+	migrateConfirmDialog *ui.ConfirmationDialog
+	migrateInProgress    bool
+
+	// Key management state
+	showKeys          bool
+	repoKeys          []types.RepoKey // Access keys of keysRepo
+	keyCursor         int
+	keysRepo          string
+	keyPasswordActive bool   // Entering the password of a new key
+	keyPasswordText   string // Password being typed (never rendered)
+	keyPasswordFirst  string // First entry, kept until the repeat matches
+	keyAction         string // "add" or "remove", awaiting confirmation
+	keyActionID       string // Key to remove
+	newKeyPassword    string // Password of the key to add
+	showKeyConfirm    bool
+	keyConfirmDialog  *ui.ConfirmationDialog
+
+	// Typed restic command state
+	commandPromptActive     bool
+	commandPromptText       string
+	rawCommand              []string // Destructive command awaiting confirmation
+	showRawCommandConfirm   bool
+	rawCommandConfirmDialog *ui.ConfirmationDialog
+	rawCommandInProgress    bool
+
+	// Quick switch state
+	showQuickSwitch   bool
+	quickSwitchQuery  string
+	quickSwitchCursor int
+	recentRepos       []string // Recently selected repository names, most recent first
+	recentReposPath   string   // State file for recentRepos ("" keeps them in memory only)
+
+	// Backup history state
+	showBackupHistory bool
+	backupHistory     []types.BackupRecord // Recorded backups of backupHistoryRepo, oldest first
+	backupHistoryRepo string
+	historyDir        string // Directory of the backup history files ("" keeps no history)
+
+	// Re-run confirmation state (commands that may change the repository)
+	showRerunConfirm   bool
+	rerunConfirmDialog *ui.ConfirmationDialog
+	rerunPending       *restic.Command
+
+	// Remove-all unlock state
+	showUnlockAllConfirm   bool
+	unlockAllConfirmDialog *ui.ConfirmationDialog
+
 	// Remove repository state
 	showRemoveConfirm   bool
 	removeConfirmDialog *ui.ConfirmationDialog
 	repoToRemove        string // Name of repository to remove
+
+	// Streamed listing of the file browser's directory (nil when none is running)
+	fileStream *fileStream
+	fileWindow int // Entries held per window of a huge directory (maxFileNodes when 0)
+
+	// Clipboard used for copying snapshot IDs (OSC52 by default)
+	clipboard Clipboard
+
+	// Loader used to reload the config after editing it (the config package by default)
+	configLoader ConfigLoader
+
+	// Creates the restic clients for every command (real restic clients by default)
+	clientFactory ResticClientFactory
+
+	// Key bindings for main view actions (see ResolveKeyMap)
+	keys KeyMap
 }
 
 // RepositoriesLoadedMsg is sent when repositories are loaded
@@ -83,10 +252,62 @@ type RepositoriesLoadedMsg struct {
 	Repositories []types.Repository
 }
 
+// AutoRefreshTickMsg is sent when the auto-refresh interval elapses
+type AutoRefreshTickMsg struct {
+	Gen int // Timer generation; stale ticks from a reset timer are ignored
+}
+
+// OperationTickMsg advances the spinner of a running operation
+type OperationTickMsg struct {
+	Gen int // Operation generation; ticks from an earlier operation are ignored
+}
+
+// RawCommandOutputMsg is sent for each line of output from a restic command typed at the prompt
+type RawCommandOutputMsg struct {
+	RepoName string
+	Command  string
+	Line     string
+	Updates  <-chan restic.CheckMessage
+}
+
+// RawCommandCompleteMsg is sent when a restic command typed at the prompt finishes
+type RawCommandCompleteMsg struct {
+	RepoName string
+	Command  string
+	Error    error
+}
+
+// LockPollMsg is sent when it's time to check the repository for locks held by others
+type LockPollMsg struct {
+	Gen  int
+	Repo types.RepositoryConfig
+}
+
+// LocksListedMsg is sent when a lock check of a running operation's repository completes
+type LocksListedMsg struct {
+	Gen   int
+	Repo  types.RepositoryConfig
+	Locks []types.Lock
+	Error error
+}
+
+// QuitWaitMsg is sent periodically after quitting cancelled a running operation,
+// to quit once it has stopped
+type QuitWaitMsg struct {
+	Started time.Time // When the operation was cancelled
+}
+
+// ConfirmTimeoutMsg is sent when a confirmation dialog's inactivity timeout elapses
+type ConfirmTimeoutMsg struct {
+	Dialog   *ui.ConfirmationDialog
+	Activity int // Dialog activity when the timer started; newer input makes it stale
+}
+
 // SnapshotsLoadStartMsg is sent when snapshot loading starts
 type SnapshotsLoadStartMsg struct {
 	RepoName string
 	RepoPath string
+	Flags    string // restic-side filter flags (e.g. "--host web01"), empty for all snapshots
 }
 
 // SnapshotsLoadedMsg is sent when snapshots are loaded
@@ -97,30 +318,134 @@ type SnapshotsLoadedMsg struct {
 	CmdLog        SnapshotsLoadStartMsg
 }
 
+// FilePreviewMsg is sent when the head of a snapshot file has been loaded for preview
+type FilePreviewMsg struct {
+	Name  string
+	Size  int64
+	Data  []byte
+	Error error
+}
+
+// FindResultsMsg is sent when a restic find search completes
+type FindResultsMsg struct {
+	Pattern string
+	Results []types.FindResult
+	Error   error
+}
+
+// ConfigEditedMsg is sent when the editor opened on the config file exits
+type ConfigEditedMsg struct {
+	Path  string
+	Error error // The editor failed to start or exited with an error
+}
+
+// RepoConfigMsg is sent when a repository's config blob has been read
+type RepoConfigMsg struct {
+	RepoName string
+	Config   *types.RepoConfigBlob
+	Error    error
+}
+
+// RepoMountedMsg is sent when restic mount is serving a repository, or failed to start
+type RepoMountedMsg struct {
+	RepoName   string
+	MountPoint string
+	Cmd        *exec.Cmd // Running restic mount process
+	Error      error
+}
+
+// RepoUnmountedMsg is sent when a restic mount has been stopped
+type RepoUnmountedMsg struct {
+	RepoName   string
+	MountPoint string
+	Error      error
+}
+
+// RepositoryInfoLoadedMsg is sent when a single repository has been refreshed
+type RepositoryInfoLoadedMsg struct {
+	Index      int
+	Repository types.Repository
+}
+
+// SnapshotStatsMsg is sent when the restore size of the selected snapshot has been computed
+type SnapshotStatsMsg struct {
+	ID         string
+	Stats      *types.SnapshotStats
+	Error      error
+	ForHistory bool // Read for the metrics size history rather than the selected snapshot
+}
+
+// DuplicateStatsMsg is sent when the duplicate files in a repository's latest snapshot are known
+type DuplicateStatsMsg struct {
+	RepoName string
+	RepoPath string // Repository path, for the logged command
+	Stats    *types.DuplicateStats
+	Error    error
+}
+
+// SnapshotSizeMsg is sent when the on-disk size of a snapshot has been computed
+type SnapshotSizeMsg struct {
+	ID    string
+	Size  int64
+	Error error
+}
+
 // FilesLoadedMsg is sent when files are loaded from a snapshot
 type FilesLoadedMsg struct {
-	Files []types.FileNode
-	Error error
+	Files  []types.FileNode
+	Error  error
+	Stream *fileStream // Listing the last entries came from; nil when Files is the whole listing
+}
+
+// FilesBatchMsg is sent for each batch of entries while a directory listing streams in
+type FilesBatchMsg struct {
+	Files  []types.FileNode
+	Full   bool // The window is full; the listing is stopped until the next one is requested
+	Stream *fileStream
 }
 
 // BackupProgressMsg is sent during backup operations
 type BackupProgressMsg struct {
-	Progress *types.BackupProgress
-	Updates  <-chan restic.BackupMessage // Channel to continue listening
+	Progress   *types.BackupProgress
+	StderrLine string                      // Warning written by restic, logged as it arrives
+	Updates    <-chan restic.BackupMessage // Channel to continue listening
 }
 
 // BackupSummaryMsg is sent when backup completes
 type BackupSummaryMsg struct {
 	Summary *types.BackupSummary
+	Warning string // Set when the snapshot was created but some files were skipped
 	Error   error
 }
 
+// SandboxSpaceMsg is sent when the free space for a sandbox restore has been checked
+type SandboxSpaceMsg struct {
+	SnapshotID string
+	Space      restoreSpace
+}
+
+// ExclusionSummaryMsg is sent when the post-backup check of what the exclude
+// rules skipped completes
+type ExclusionSummaryMsg struct {
+	RepoName string
+	Summary  *types.ExclusionSummary
+	Error    error
+}
+
 // RestoreProgressMsg is sent during restore operations
 type RestoreProgressMsg struct {
 	Progress *types.RestoreProgress
 	Updates  <-chan restic.RestoreMessage
 }
 
+// RestorePreviewMsg is sent when a restore dry run has listed the paths it would write
+type RestorePreviewMsg struct {
+	Options types.RestoreOptions
+	Paths   []string
+	Space   restoreSpace // Free space at the target compared with the restore size
+	Error   error
+}
+
 // RestoreSummaryMsg is sent when restore completes
 type RestoreSummaryMsg struct {
 	Summary *types.RestoreSummary
@@ -136,7 +461,9 @@ type ForgetDryRunMsg struct {
 
 // ForgetCompleteMsg is sent when forget operation completes
 type ForgetCompleteMsg struct {
-	Error error
+	IDs      []string // Snapshots forgotten by ID (empty for policy-based forget)
+	RepoName string
+	Error    error
 }
 
 // PruneDryRunMsg is sent when prune dry-run completes
@@ -150,21 +477,98 @@ type PruneCompleteMsg struct {
 	Error error
 }
 
+// CheckOutputMsg is sent for each line of output from a data check
+type CheckOutputMsg struct {
+	Line    string
+	Updates <-chan restic.CheckMessage // Channel to continue listening
+}
+
+// CheckCompleteMsg is sent when a data check finishes
+type CheckCompleteMsg struct {
+	Error error
+}
+
+// RepairOutputMsg is sent for each line of output from an index repair
+type RepairOutputMsg struct {
+	RepoName string
+	Line     string
+	Updates  <-chan restic.CheckMessage // Channel to continue listening
+}
+
+// RepairCompleteMsg is sent when an index repair finishes
+type RepairCompleteMsg struct {
+	RepoName string
+	Error    error
+}
+
+// MigrationsListedMsg is sent when the available migrations of a repository are known
+type MigrationsListedMsg struct {
+	RepoName   string
+	Migrations []string
+	Error      error
+}
+
+// KeysListedMsg is sent when the access keys of a repository are known
+type KeysListedMsg struct {
+	RepoName string
+	Keys     []types.RepoKey
+	Error    error
+}
+
+// MigrationOutputMsg is sent for each line of output from a repository migration
+type MigrationOutputMsg struct {
+	RepoName  string
+	Migration string
+	Line      string
+	Updates   <-chan restic.CheckMessage // Channel to continue listening
+}
+
+// MigrationCompleteMsg is sent when a repository migration finishes
+type MigrationCompleteMsg struct {
+	RepoName  string
+	Migration string
+	Error     error
+}
+
 // ScannedReposMsg is sent when repository scanning completes
 type ScannedReposMsg struct {
-	FoundRepos []types.RepositoryConfig
+	FoundRepos   []types.RepositoryConfig
+	ScannedPaths []string // Locations that were scanned, as configured
+	Errors       []string // Per-location scan failures ("path: reason")
 }
 
-// CacheCleanupMsg is sent when cache cleanup completes
-type CacheCleanupMsg struct {
-	Output string
-	Error  error
+// CommandResultMsg is sent when a command started with runRepoCommand completes
+type CommandResultMsg struct {
+	Name     string // Operation name, e.g. "unlock" or "cache cleanup"
+	RepoName string
+	Output   string
+	Error    error
 }
 
-// UnlockMsg is sent when repository unlock completes
-type UnlockMsg struct {
-	Output string
-	Error  error
+// DiagnosticsMsg is sent when the diagnostics checklist has run
+type DiagnosticsMsg struct {
+	Checks []DiagnosticCheck
+}
+
+// CommandRerunMsg is sent when a re-run of the last restic command completes
+type CommandRerunMsg struct {
+	RepoName string
+	Command  string
+	Output   string
+	Error    error
+}
+
+// RepoInitializedMsg is sent when restic init completes for an existing config entry
+type RepoInitializedMsg struct {
+	RepoName string
+	Error    error
+}
+
+// RepoRenamedMsg is sent when a repository has been renamed in the config
+type RepoRenamedMsg struct {
+	OldName string
+	NewName string
+	Error   error
 }
 
 // RepoRemovedMsg is sent when a repository is removed from config
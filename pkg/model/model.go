@@ -1,12 +1,16 @@
 package model
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -18,8 +22,9 @@ import (
 
 // NewModel creates a new instance of the application model
 func NewModel() Model {
-	// Load configuration
-	cfg := config.LoadOrDefault("")
+	// Load configuration, repairing password file permissions if allowed; on
+	// failure start empty and report why in the operations log
+	cfg, cfgErr := config.LoadOrDefaultWithError("")
 
 	// Initialize panels
 	repoPanel := ui.NewRepositoryPanel()
@@ -28,11 +33,25 @@ func NewModel() Model {
 	opsPanel := ui.NewOperationsPanel()
 	backupForm := ui.NewBackupForm()
 	repoForm := ui.NewRepoForm()
+	compressionSupported := false
+
+	opsPanel.SetMaxEntries(cfg.LogEntries)
+
+	// Append the operations log to a file if configured
+	if cfg.LogFile != "" {
+		if err := opsPanel.SetLogFile(expandHome(cfg.LogFile)); err != nil {
+			opsPanel.Error(fmt.Sprintf("Log file disabled: %v", err))
+		}
+	}
 
 	// Initial log messages - polished startup
 	opsPanel.Success("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	opsPanel.Success("✓ LazyRestic TUI started successfully")
 	opsPanel.Dimmed("Version 0.1.0 - Terminal UI for restic backup management")
+	for _, note := range cfg.Migrated {
+		opsPanel.Info("Config migration: " + note)
+	}
+	logConfigLoad(opsPanel, cfg.Repaired, cfgErr)
 
 	if !restic.IsResticInstalled() {
 		opsPanel.Error("✗ restic binary not found in PATH")
@@ -41,9 +60,31 @@ func NewModel() Model {
 		if version, err := restic.GetResticVersion(); err == nil {
 			opsPanel.Success(fmt.Sprintf("✓ %s detected", version))
 			opsPanel.Dimmed("Ready for backup operations")
+			compressionSupported = restic.SupportsCompression(version)
+			repoForm.SetCompressionSupported(compressionSupported)
+		}
+
+		minVersion := cfg.MinVersion
+		if minVersion == "" {
+			minVersion = restic.DefaultMinVersion
+		}
+		if err := restic.RequireMinVersion(minVersion); err != nil {
+			opsPanel.Warning(fmt.Sprintf("⚠ %v", err))
+			opsPanel.Dimmed("Some features may not work - upgrade restic: https://restic.net")
 		}
 	}
-	opsPanel.Info("Press '?' for help or 'q' to quit")
+	keys, keyWarnings := ResolveKeyMap(cfg.Keybindings)
+	for _, warning := range keyWarnings {
+		opsPanel.Warning(warning)
+	}
+	metricsPanel.SetUnlockKey(keyLabel(keys.Unlock))
+	snapPanel.SetRetryKey(keyLabel(keys.RetrySnapshots))
+	theme, themeWarnings := ui.ResolveTheme(cfg.Theme)
+	for _, warning := range themeWarnings {
+		opsPanel.Warning(warning)
+	}
+	ui.ApplyTheme(theme)
+	opsPanel.Info(fmt.Sprintf("Press '%s' for help or '%s' to quit", keys.Help, keys.Quit))
 	opsPanel.Success("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
 	return Model{
@@ -61,6 +102,7 @@ func NewModel() Model {
 		showHelp:               false,
 		showRepoForm:           false,
 		repoForm:               repoForm,
+		compressionSupported:   compressionSupported,
 		showBackupForm:         false,
 		backupForm:             backupForm,
 		backupInProgress:       false,
@@ -69,51 +111,177 @@ func NewModel() Model {
 		restoreForm:            nil, // Created when needed
 		restoreInProgress:      false,
 		currentRestoreProgress: nil,
+		clipboard:              osc52Clipboard{},
+		keys:                   keys,
+		recentRepos:            loadRecentRepos(defaultRecentReposPath()),
+		recentReposPath:        defaultRecentReposPath(),
+		historyDir:             defaultBackupHistoryDir(),
 	}
 }
 
 // Init is called when the program starts
 func (m Model) Init() tea.Cmd {
-	return m.loadRepositories
+	return tea.Batch(m.loadRepositories, m.scheduleAutoRefresh())
 }
 
-// loadRepositories loads repository information
-func (m Model) loadRepositories() tea.Msg {
-	var repos []types.Repository
+// logConfigLoad reports password files whose permissions were fixed at startup and
+// why the config could not be loaded, so an empty repository list isn't mistaken for
+// a missing config
+func logConfigLoad(opsPanel *ui.OperationsPanel, repaired []string, err error) {
+	for _, path := range repaired {
+		opsPanel.Warning(fmt.Sprintf("⚠ Password file %s was readable by others - permissions changed to 0600", path))
+	}
+	if err == nil {
+		return
+	}
+
+	opsPanel.Error(fmt.Sprintf("✗ Config error: %v", err))
+	var permErr *config.PasswordFilePermissionError
+	if errors.As(err, &permErr) {
+		opsPanel.Warning(fmt.Sprintf("Run 'chmod 600 %s' or set auto_fix_permissions: true in the config", permErr.Path))
+	}
+	opsPanel.Warning(fmt.Sprintf("Starting with no repositories - fix %s and restart", config.DefaultConfigPath()))
+}
 
-	for _, repoConfig := range m.config.Repositories {
-		client := restic.NewClient(repoConfig)
+// newRepoForm returns an empty repository form, offering the repository
+// version and compression when the installed restic supports them
+func (m Model) newRepoForm() *ui.RepoForm {
+	form := ui.NewRepoForm()
+	form.SetCompressionSupported(m.compressionSupported)
+	return form
+}
 
-		// Get comprehensive repository information
-		repoInfo, err := client.GetRepositoryInfo()
-		if err != nil {
-			// If we can't get info, create a minimal repo entry
-			repo := types.Repository{
-				Name:   repoConfig.Name,
-				Path:   repoConfig.Path,
-				Status: "error",
-			}
-			repos = append(repos, repo)
-			continue
-		}
+// keyMap returns the resolved key bindings, falling back to the defaults
+func (m Model) keyMap() KeyMap {
+	if m.keys == (KeyMap{}) {
+		return DefaultKeyMap()
+	}
+	return m.keys
+}
 
-		// Set the name and path from config
-		repoInfo.Name = repoConfig.Name
-		repoInfo.Path = repoConfig.Path
+// isBusy reports whether an operation is running that an auto-refresh must not disturb
+func (m Model) isBusy() bool {
+	return m.backupInProgress || m.restoreInProgress || m.checkInProgress || m.repairInProgress || m.migrateInProgress || m.forgetInProgress || m.rawCommandInProgress || m.loadingRepositories || m.loadingSnapshots || m.operationInProgress != ""
+}
+
+// resizeOverlays fits the forms, file browser, forget preview and confirmation
+// dialogs to the terminal after a resize, at the proportions they open with.
+// Overlays that aren't open are nil, apart from the forms, which are kept.
+func (m *Model) resizeOverlays() {
+	formWidth := int(float64(m.width) * ui.FormWidthRatio)
+	formHeight := int(float64(m.height) * ui.FormHeightRatio)
+	m.repoForm.SetSize(formWidth, formHeight)
+	m.backupForm.SetSize(formWidth, formHeight)
+	if m.restoreForm != nil {
+		m.restoreForm.SetSize(m.width*2/3, m.height*2/3)
+	}
+	if m.fileBrowser != nil {
+		m.fileBrowser.SetSize(m.width*2/3, m.height*2/3)
+	}
+	if m.forgetForm != nil {
+		m.forgetForm.SetSize(formWidth, formHeight)
+	}
+	if m.forgetPreview != nil {
+		m.forgetPreview.SetSize(m.width*3/4, m.height*3/4)
+	}
 
-		repos = append(repos, *repoInfo)
+	dialogs := []*ui.ConfirmationDialog{
+		m.spaceConfirmDialog,
+		m.forgetConfirmDialog,
+		m.pruneConfirmDialog,
+		m.repairConfirmDialog,
+		m.migrateConfirmDialog,
+		m.keyConfirmDialog,
+		m.rawCommandConfirmDialog,
+		m.rerunConfirmDialog,
+		m.unlockAllConfirmDialog,
+		m.removeConfirmDialog,
+	}
+	for _, dialog := range dialogs {
+		if dialog != nil {
+			dialog.SetSize(m.width*3/4, m.height*3/4)
+		}
 	}
+}
 
+// loadRepositories loads repository information for all configured repositories in parallel
+func (m Model) loadRepositories() tea.Msg {
+	repos := loadRepositoryInfos(m.config.Repositories, m.clients(), m.config.MaxConcurrency)
 	return RepositoriesLoadedMsg{Repositories: repos}
 }
 
+// loadSelectedRepositoryInfo reloads information for the selected repository only
+func (m Model) loadSelectedRepositoryInfo() tea.Cmd {
+	return m.loadRepositoryInfoAt(m.currentRepoIndex)
+}
+
+// logPrecheckError reports an operation skipped because the repository
+// failed the reachability precheck (restic cat config)
+func (m Model) logPrecheckError(operation string, err error) {
+	m.opsPanel.Error(fmt.Sprintf("✗ %s not started: repository unreachable or wrong password", operation))
+	m.opsPanel.Dimmed(fmt.Sprintf("Precheck 'restic cat config' failed: %s", strings.TrimPrefix(m.errorText(err), restic.ErrUnreachable.Error()+": ")))
+}
+
+// errorText returns the text to log for a restic error: a short explanation, or
+// restic's full output when verbose errors are on
+func (m Model) errorText(err error) string {
+	if m.verboseErrors {
+		return err.Error()
+	}
+	return restic.Describe(err)
+}
+
+// loadRepositoryInfoAt reloads the info of the repository at index
+func (m Model) loadRepositoryInfoAt(index int) tea.Cmd {
+	if index < 0 || index >= len(m.config.Repositories) {
+		return nil
+	}
+
+	repoConfig := m.config.Repositories[index]
+	return func() tea.Msg {
+		repo := loadRepositoryInfo(repoConfig, m.newClient(repoConfig))
+		return RepositoryInfoLoadedMsg{Index: index, Repository: repo}
+	}
+}
+
 // loadSnapshotsWithMessage shows loading message and loads snapshots
 func (m *Model) loadSnapshotsWithMessage() tea.Cmd {
 	m.loadingSnapshots = true
+	m.setSnapshotLoadError(nil)
 	m.opsPanel.Info("Loading snapshots...")
+	m.markSnapshotLoad()
 	return m.loadSnapshots
 }
 
+// markSnapshotLoad marks the current repository busy until its snapshots load
+func (m *Model) markSnapshotLoad() {
+	if m.currentRepoIndex < len(m.config.Repositories) {
+		m.markRepoBusy(m.config.Repositories[m.currentRepoIndex].Name)
+	}
+}
+
+// retrySnapshotLoad loads the current repository's snapshots again after a failed load
+func (m *Model) retrySnapshotLoad() tea.Cmd {
+	if m.currentRepoIndex < len(m.config.Repositories) {
+		m.opsPanel.Info(fmt.Sprintf("Retrying snapshot load for '%s'...", m.config.Repositories[m.currentRepoIndex].Name))
+	}
+	return m.loadSnapshotsWithMessage()
+}
+
+// setSnapshotLoadError records the outcome of a snapshot load so the snapshots
+// panel can offer a retry after a failure
+func (m *Model) setSnapshotLoadError(err error) {
+	m.snapshotLoadError = err
+	if m.snapPanel == nil {
+		return
+	}
+	if err == nil {
+		m.snapPanel.SetLoadError("")
+		return
+	}
+	m.snapPanel.SetLoadError(m.errorText(err))
+}
+
 // loadSnapshots loads snapshots for the current repository
 func (m Model) loadSnapshots() tea.Msg {
 	if m.currentRepoIndex >= len(m.config.Repositories) {
@@ -123,14 +291,22 @@ func (m Model) loadSnapshots() tea.Msg {
 	repoConfig := m.config.Repositories[m.currentRepoIndex]
 
 	// Log the command being executed
+	query := m.snapshotQuery
 	cmdLog := SnapshotsLoadStartMsg{
 		RepoName: repoConfig.Name,
 		RepoPath: repoConfig.Path,
+		Flags:    query.flags(),
 	}
 
-	client := restic.NewClient(repoConfig)
+	client := m.newClient(repoConfig)
 
-	snapshots, err := client.ListSnapshots()
+	var snapshots []types.Snapshot
+	var err error
+	if query.serverSide() {
+		snapshots, err = client.ListSnapshotsFiltered(query.host, query.tags, query.paths)
+	} else {
+		snapshots, err = client.ListSnapshots()
+	}
 
 	// Filter out systemd-private snapshots
 	var filteredCount int
@@ -163,36 +339,47 @@ func (m Model) loadSnapshots() tea.Msg {
 
 // cleanupCache runs restic cache --cleanup for the current repository
 func (m Model) cleanupCache() tea.Cmd {
-	return func() tea.Msg {
-		if m.currentRepoIndex >= len(m.config.Repositories) {
-			return CacheCleanupMsg{Error: fmt.Errorf("no repository selected")}
-		}
+	return m.runRepoCommand("cache cleanup", ResticClient.CleanupCache)
+}
 
-		repoConfig := m.config.Repositories[m.currentRepoIndex]
-		client := restic.NewClient(repoConfig)
+// unlockRepository runs restic unlock for the current repository; removeAll
+// also removes locks held by running processes
+func (m Model) unlockRepository(removeAll bool) tea.Cmd {
+	if removeAll {
+		return m.runRepoCommand("unlock --remove-all", ResticClient.UnlockAll)
+	}
+	return m.runRepoCommand("unlock", ResticClient.Unlock)
+}
 
-		output, err := client.CleanupCache()
-		return CacheCleanupMsg{
-			Output: output,
-			Error:  err,
-		}
+// expandHome expands a leading ~/ to the user's home directory
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, path[2:])
 	}
+	return path
 }
 
-// unlockRepository runs restic unlock for the current repository
-func (m Model) unlockRepository() tea.Cmd {
+// sessionLogPath returns a timestamped path for exporting the operations log
+func sessionLogPath(now time.Time) string {
+	return filepath.Join(filepath.Dir(config.DefaultConfigPath()), "logs",
+		fmt.Sprintf("session-%s.log", now.Format("20060102-150405")))
+}
+
+// initRepository runs restic init for the selected, not yet initialized repository
+func (m Model) initRepository() tea.Cmd {
 	return func() tea.Msg {
 		if m.currentRepoIndex >= len(m.config.Repositories) {
-			return UnlockMsg{Error: fmt.Errorf("no repository selected")}
+			return RepoInitializedMsg{Error: fmt.Errorf("no repository selected")}
 		}
 
 		repoConfig := m.config.Repositories[m.currentRepoIndex]
-		client := restic.NewClient(repoConfig)
+		client := m.newClient(repoConfig)
 
-		output, err := client.Unlock()
-		return UnlockMsg{
-			Output: output,
-			Error:  err,
+		err := client.InitWithOptions(restic.DefaultRepositoryVersion, restic.DefaultCompression)
+		return RepoInitializedMsg{
+			RepoName: repoConfig.Name,
+			Error:    err,
 		}
 	}
 }
@@ -225,80 +412,193 @@ func (m Model) removeRepository() tea.Cmd {
 	}
 }
 
+// renameRepository changes a repository's display name in the configuration
+func (m Model) renameRepository(oldName, newName string) tea.Cmd {
+	return func() tea.Msg {
+		if err := config.RenameRepository(m.config, oldName, newName); err != nil {
+			return RepoRenamedMsg{OldName: oldName, NewName: newName, Error: err}
+		}
+
+		configPath := config.DefaultConfigPath()
+		if err := config.Save(m.config, configPath); err != nil {
+			return RepoRenamedMsg{
+				OldName: oldName,
+				NewName: newName,
+				Error:   fmt.Errorf("failed to save config: %w", err),
+			}
+		}
+
+		return RepoRenamedMsg{OldName: oldName, NewName: newName}
+	}
+}
+
+// renameRepoState moves what LazyRestic keeps under a repository's name to its
+// new name: the recent repositories, the backup history and the metrics panel's
+// size history and duplicate files
+func (m *Model) renameRepoState(oldName, newName string) {
+	m.renameRecentRepo(oldName, newName)
+	if m.historyDir != "" {
+		if err := renameBackupHistory(m.historyDir, oldName, newName); err != nil {
+			m.opsPanel.Warning(fmt.Sprintf("Couldn't move the backup history of '%s': %v", oldName, err))
+		}
+	}
+	m.metricsPanel.RenameRepository(oldName, newName)
+}
+
+// defaultScanMaxDepth is how many directory levels below each scan location are searched
+const defaultScanMaxDepth = 2
+
+// scanPathTimeout bounds the scan of a single location, e.g. a slow network mount
+const scanPathTimeout = 15 * time.Second
+
+// skippedScanDirs are kernel pseudo filesystems that never hold repositories
+var skippedScanDirs = []string{"/proc", "/sys", "/dev"}
+
+// defaultScanPaths are the common locations scanned for restic repositories
+// when the config doesn't set scan_paths
+var defaultScanPaths = []string{
+	"/mnt",
+	"/media",
+	"/run/media",
+	"./",
+	"~/Documents",
+	"~/Downloads",
+	"~/Backup",
+	"/tmp",
+}
+
+// scanPaths returns the configured scan locations, or the defaults
+func (m Model) scanPaths() []string {
+	if len(m.config.ScanPaths) > 0 {
+		return m.config.ScanPaths
+	}
+	return defaultScanPaths
+}
+
 // scanForRepositories scans common locations for restic repositories
 func (m Model) scanForRepositories() tea.Cmd {
+	scanPaths := m.scanPaths()
+	maxDepth := m.scanMaxDepth()
 	return func() tea.Msg {
 		foundRepos := []types.RepositoryConfig{}
-
-		// Common locations to scan
-		scanPaths := []string{
-			"/mnt",
-			"/media",
-			"/run/media",
-			"./",
-			"~/Documents",
-			"~/Downloads",
-			"~/Backup",
-			"/tmp",
-		}
+		var scanErrors []string
 
 		for _, basePath := range scanPaths {
-			// Expand ~ to home
-			if strings.HasPrefix(basePath, "~/") {
-				home, _ := os.UserHomeDir()
-				basePath = filepath.Join(home, basePath[2:])
+			// Give each location its own deadline so one stalled mount doesn't hold up the rest
+			ctx, cancel := context.WithTimeout(context.Background(), scanPathTimeout)
+			repos, err := scanPathWithTimeout(ctx, expandHome(basePath), maxDepth)
+			cancel()
+			if err != nil {
+				scanErrors = append(scanErrors, fmt.Sprintf("%s: %v", basePath, err))
 			}
+			foundRepos = append(foundRepos, repos...)
+		}
+
+		return ScannedReposMsg{FoundRepos: foundRepos, ScannedPaths: scanPaths, Errors: scanErrors}
+	}
+}
 
-			// Scan directory for restic repos
-			foundRepos = append(foundRepos, scanDirectoryForRepos(basePath)...)
+// scanMaxDepth returns the configured scan depth, or the default
+func (m Model) scanMaxDepth() int {
+	if m.config.ScanMaxDepth > 0 {
+		return m.config.ScanMaxDepth
+	}
+	return defaultScanMaxDepth
+}
+
+// scanPathWithTimeout runs scanDirectoryForRepos in the background and gives up when ctx
+// expires, since a hung network mount can block a filesystem call indefinitely
+func scanPathWithTimeout(ctx context.Context, basePath string, maxDepth int) ([]types.RepositoryConfig, error) {
+	type result struct {
+		repos []types.RepositoryConfig
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		repos, err := scanDirectoryForRepos(ctx, basePath, maxDepth)
+		done <- result{repos, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil && ctx.Err() != nil {
+			return r.repos, fmt.Errorf("scan timed out after %s", scanPathTimeout)
 		}
+		return r.repos, r.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("scan timed out after %s", scanPathTimeout)
+	}
+}
 
-		return ScannedReposMsg{FoundRepos: foundRepos}
+// isVirtualMount reports whether path is, or is inside, a kernel pseudo filesystem
+func isVirtualMount(path string) bool {
+	for _, dir := range skippedScanDirs {
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
 	}
+	return false
 }
 
-// scanDirectoryForRepos recursively scans a directory for restic repositories
-func scanDirectoryForRepos(basePath string) []types.RepositoryConfig {
+// scanDirectoryForRepos scans a directory up to maxDepth levels deep for restic repositories.
+// Symlinked directories below basePath are not followed, so link loops can't trap the walk.
+// A missing basePath is not an error.
+func scanDirectoryForRepos(ctx context.Context, basePath string, maxDepth int) ([]types.RepositoryConfig, error) {
 	var repos []types.RepositoryConfig
 
-	// Check if basePath itself is a restic repo
-	if isResticRepo(basePath) {
-		repoName := filepath.Base(basePath)
-		if repoName == "." {
-			repoName = "local-repo"
+	// A configured location may itself be a symlink (e.g. ~/Backup -> /mnt/disk)
+	root := basePath
+	if info, err := os.Lstat(basePath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
 		}
-		// Filter out systemd repos
-		if !strings.HasPrefix(repoName, "systemd") && !strings.Contains(basePath, "systemd-private") {
-			repos = append(repos, types.RepositoryConfig{
-				Name: repoName,
-				Path: basePath,
-			})
+		return nil, err
+	} else if info.Mode()&os.ModeSymlink != 0 {
+		if root, err = filepath.EvalSymlinks(basePath); err != nil {
+			return nil, err
 		}
 	}
 
-	// Walk the directory tree (but not too deep to avoid performance issues)
-	filepath.WalkDir(basePath, func(path string, d os.DirEntry, err error) error {
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
 		if err != nil {
-			return nil // Skip errors
+			if path == root {
+				return err
+			}
+			return nil // Skip unreadable entries below the root
+		}
+		if !d.IsDir() || d.Type()&os.ModeSymlink != 0 {
+			return nil
+		}
+
+		if abs, absErr := filepath.Abs(path); absErr == nil && isVirtualMount(abs) {
+			return filepath.SkipDir
 		}
 
 		// Don't go too deep
-		relPath, _ := filepath.Rel(basePath, path)
+		relPath, _ := filepath.Rel(root, path)
 		depth := strings.Count(relPath, string(filepath.Separator))
-		if depth > 2 { // Max depth 2
-			if d.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
+		if depth > maxDepth {
+			return filepath.SkipDir
 		}
 
-		if d.IsDir() && isResticRepo(path) {
-			repoName := filepath.Base(path)
+		if isResticRepo(path) {
+			// Report paths under the location as configured, not its symlink target
+			repoPath := filepath.Join(basePath, relPath)
+			if path == root {
+				repoPath = basePath
+			}
+			repoName := filepath.Base(repoPath)
+			if repoName == "." {
+				repoName = "local-repo"
+			}
 			// Filter out systemd repos
-			if !strings.HasPrefix(repoName, "systemd") && !strings.Contains(path, "systemd-private") {
+			if !strings.HasPrefix(repoName, "systemd") && !strings.Contains(repoPath, "systemd-private") {
 				repos = append(repos, types.RepositoryConfig{
 					Name: repoName,
-					Path: path,
+					Path: repoPath,
 				})
 			}
 			return filepath.SkipDir // Don't scan inside repos
@@ -307,7 +607,7 @@ func scanDirectoryForRepos(basePath string) []types.RepositoryConfig {
 		return nil
 	})
 
-	return repos
+	return repos, err
 }
 
 // isResticRepo checks if a directory contains a restic repository
@@ -321,31 +621,41 @@ func isResticRepo(path string) bool {
 	return true
 }
 
-// loadFiles loads files from the current path in the file browser
-func (m Model) loadFiles() tea.Msg {
-	if m.currentRepoIndex >= len(m.config.Repositories) {
-		return FilesLoadedMsg{Error: fmt.Errorf("no repository selected")}
-	}
-
-	if m.fileBrowser == nil || m.fileBrowser.GetSnapshot() == nil {
-		return FilesLoadedMsg{Error: fmt.Errorf("no snapshot selected for browsing")}
+// previewLimit returns the configured preview size cap
+func (m Model) previewLimit() int64 {
+	if m.config.PreviewBytes > 0 {
+		return m.config.PreviewBytes
 	}
+	return restic.DefaultMaxDumpBytes
+}
 
-	repoConfig := m.config.Repositories[m.currentRepoIndex]
-	client := restic.NewClient(repoConfig)
-
-	currentPath := m.fileBrowser.GetCurrentPath()
-	files, err := client.ListFiles(m.fileBrowser.GetSnapshot().ID, currentPath)
+// loadPreview loads the head of a file from the browsed snapshot
+func (m Model) loadPreview(file types.FileNode) tea.Cmd {
+	return func() tea.Msg {
+		if m.currentRepoIndex >= len(m.config.Repositories) {
+			return FilePreviewMsg{Error: fmt.Errorf("no repository selected")}
+		}
+		if m.fileBrowser == nil || m.fileBrowser.GetSnapshot() == nil {
+			return FilePreviewMsg{Error: fmt.Errorf("no snapshot selected for browsing")}
+		}
 
-	return FilesLoadedMsg{
-		Files: files,
-		Error: err,
+		client := m.newClient(m.config.Repositories[m.currentRepoIndex])
+		data, err := client.DumpFileLimit(m.fileBrowser.GetSnapshot().ID, file.Path, m.previewLimit())
+		return FilePreviewMsg{
+			Name:  file.Name,
+			Size:  file.Size,
+			Data:  data,
+			Error: err,
+		}
 	}
-
 }
 
-// logSelectedSnapshot logs details about the currently selected snapshot to the operations panel
+// logSelectedSnapshot logs details about the currently selected snapshot to the operations panel.
+// It only logs when log_snapshot_details is set; otherwise details live in the snapshot info overlay.
 func (m *Model) logSelectedSnapshot() {
+	if !m.config.LogSnapshotDetails {
+		return
+	}
 	snapshot := m.snapPanel.GetSelected()
 	if snapshot == nil {
 		return
@@ -414,17 +724,43 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Right column: operations takes full height
 		m.opsPanel.SetSize(rightWidth, panelHeight)
 
-		formWidth := int(float64(m.width) * ui.FormWidthRatio)
-		formHeight := int(float64(m.height) * ui.FormHeightRatio)
-		m.repoForm.SetSize(formWidth, formHeight)
-		m.backupForm.SetSize(formWidth, formHeight)
+		m.resizeOverlays()
 
 		return m, nil
 
+	case AutoRefreshTickMsg:
+		// Ignore ticks from a timer that was reset or paused since it was scheduled
+		if msg.Gen != m.autoRefreshGen || m.autoRefreshPaused {
+			return m, nil
+		}
+		// Skip this round while an operation is running; the next tick will retry
+		if m.isBusy() || m.autoRefreshing {
+			return m, m.scheduleAutoRefresh()
+		}
+		m.autoRefreshing = true
+		return m, tea.Batch(m.loadRepositories, m.scheduleAutoRefresh())
+
 	case RepositoriesLoadedMsg:
 		m.loadingRepositories = false
 		m.repositories = msg.Repositories
+		m.repoTotals = totalRepositories(m.repositories)
+		m.lastRefreshed = time.Now()
+		if m.autoRefreshing {
+			// Quiet reload: keep the log and panels steady
+			if m.currentRepoIndex < len(m.repositories) {
+				m.metricsPanel.SetRepository(&m.repositories[m.currentRepoIndex])
+				m.markSnapshotLoad()
+				return m, m.loadSnapshots
+			}
+			m.autoRefreshing = false
+			return m, nil
+		}
 		m.opsPanel.Success(fmt.Sprintf("✓ Loaded %d repositories from config", len(msg.Repositories)))
+		for _, repo := range msg.Repositories {
+			if repo.Status == "uninitialized" {
+				m.opsPanel.Warning(fmt.Sprintf("Repository '%s' isn't initialized — select it and press Enter to run restic init", repo.Name))
+			}
+		}
 		if len(msg.Repositories) == 0 {
 			m.opsPanel.Dimmed("No repositories configured")
 			m.opsPanel.Info("Press 'a' to add repository or 's' to scan for existing repos")
@@ -435,6 +771,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Update metrics panel with currently selected repo
 			if m.currentRepoIndex < len(m.repositories) {
 				selectedRepo := &m.repositories[m.currentRepoIndex]
+				// Select its row rather than whatever is first, which may be a group header
+				m.repoPanel.SetRepositories(m.repositories)
+				m.repoPanel.SelectRepository(selectedRepo.Name)
 				m.metricsPanel.SetRepository(selectedRepo)
 				m.opsPanel.Info(fmt.Sprintf("Selected repository: '%s' at %s", selectedRepo.Name, selectedRepo.Path))
 			}
@@ -442,11 +781,54 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.loadSnapshotsWithMessage()
 		}
 
+	case RepositoryInfoLoadedMsg:
+		// Ignore results for a repository list that changed while loading
+		if msg.Index >= len(m.repositories) || m.repositories[msg.Index].Name != msg.Repository.Name {
+			return m, nil
+		}
+		m.repositories[msg.Index] = msg.Repository
+		m.repoTotals = totalRepositories(m.repositories)
+		if msg.Index == m.currentRepoIndex {
+			m.metricsPanel.SetRepository(&m.repositories[msg.Index])
+		}
+		switch msg.Repository.Status {
+		case "error":
+			m.opsPanel.Error(fmt.Sprintf("Failed to refresh repository '%s'", msg.Repository.Name))
+		case "uninitialized":
+			m.opsPanel.Warning(fmt.Sprintf("Repository '%s' isn't initialized — press Enter to run restic init", msg.Repository.Name))
+		case "locked":
+			m.opsPanel.Warning(fmt.Sprintf("Repository '%s' is locked — press %s to remove stale locks", msg.Repository.Name, keyLabel(m.keyMap().Unlock)))
+		default:
+			m.opsPanel.Success(fmt.Sprintf("✓ Refreshed repository '%s'", msg.Repository.Name))
+		}
+		return m, nil
+
 	case SnapshotsLoadedMsg:
 		m.loadingSnapshots = false
+		m.clearRepoBusy(msg.CmdLog.RepoName)
+		if m.autoRefreshing {
+			m.autoRefreshing = false
+			if msg.Error != nil {
+				m.opsPanel.Error(fmt.Sprintf("Auto-refresh failed to load snapshots from '%s': %s", msg.CmdLog.RepoName, m.errorText(msg.Error)))
+			} else {
+				m.snapPanel.SetSnapshots(msg.Snapshots)
+			}
+			m.setSnapshotLoadError(msg.Error)
+			if msg.Error != nil {
+				return m, m.fetchVisibleSnapshotSizes()
+			}
+			return m, tea.Batch(m.fetchVisibleSnapshotSizes(), m.fetchSizeHistory())
+		}
+		m.setSnapshotLoadError(msg.Error)
 		if msg.Error != nil {
-			m.opsPanel.Error(fmt.Sprintf("Failed to load snapshots from '%s': %v", msg.CmdLog.RepoName, msg.Error))
+			m.opsPanel.Error(fmt.Sprintf("Failed to load snapshots from '%s': %s", msg.CmdLog.RepoName, m.errorText(msg.Error)))
 			m.opsPanel.Dimmed(fmt.Sprintf("Repository: %s", msg.CmdLog.RepoPath))
+			if restic.IsTimeout(msg.Error) {
+				m.opsPanel.Warning("Command timed out - the repository backend is not responding")
+			}
+			if restic.IsNotInitialized(msg.Error) {
+				m.opsPanel.Warning("This repository isn't initialized — press Enter to run restic init")
+			}
 		} else {
 			m.snapPanel.SetSnapshots(msg.Snapshots)
 			m.opsPanel.Success(fmt.Sprintf("✓ Loaded %d snapshots from '%s'", len(msg.Snapshots), msg.CmdLog.RepoName))
@@ -454,29 +836,153 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if msg.FilteredCount > 0 {
 				m.opsPanel.Dimmed(fmt.Sprintf("Filtered %d systemd-private snapshots", msg.FilteredCount))
 			}
-			m.opsPanel.Info(fmt.Sprintf("Command: restic -r %s snapshots --json", msg.CmdLog.RepoPath))
+			m.opsPanel.Info(strings.TrimSpace(fmt.Sprintf("Command: restic -r %s snapshots --json %s", msg.CmdLog.RepoPath, msg.CmdLog.Flags)))
 
 			// Log the currently selected snapshot details
 			if len(msg.Snapshots) > 0 {
 				m.logSelectedSnapshot()
 			}
+			return m, tea.Batch(m.fetchVisibleSnapshotSizes(), m.fetchSelectedSnapshotStats(), m.fetchSizeHistory())
+		}
+		return m, tea.Batch(m.fetchVisibleSnapshotSizes(), m.fetchSelectedSnapshotStats())
+
+	case FindResultsMsg:
+		m.findInProgress = false
+		if msg.Error != nil {
+			m.opsPanel.Error(fmt.Sprintf("Find failed: %s", m.errorText(msg.Error)))
+			return m, nil
+		}
+		if len(msg.Results) == 0 {
+			m.opsPanel.Warning(fmt.Sprintf("No files matching '%s' found in any snapshot", msg.Pattern))
+			return m, nil
+		}
+		m.opsPanel.Success(fmt.Sprintf("Found %d matches for '%s'", len(msg.Results), msg.Pattern))
+		m.findPattern = msg.Pattern
+		m.findResults = msg.Results
+		m.selectedFind = 0
+		m.showFindResults = true
+		return m, nil
+
+	case ConfigEditedMsg:
+		if msg.Error != nil {
+			m.opsPanel.Warning(fmt.Sprintf("Editor exited with an error: %v", msg.Error))
+		}
+		return m, m.reloadConfig(msg.Path)
+
+	case RepoConfigMsg:
+		if msg.Error != nil {
+			m.opsPanel.Error(fmt.Sprintf("Failed to read config of '%s': %s", msg.RepoName, m.errorText(msg.Error)))
+			return m, nil
+		}
+		m.opsPanel.Dimmed(fmt.Sprintf("Read config of '%s' (format version %d)", msg.RepoName, msg.Config.Version))
+		m.repoConfig = msg.Config
+		m.repoConfigName = msg.RepoName
+		m.showRepoConfig = true
+		return m, nil
+
+	case SnapshotStatsMsg:
+		if msg.ForHistory {
+			// Without a size the snapshot is simply left out of the sparkline
+			if msg.Error != nil {
+				m.snapPanel.SetRestoreSizeFailed(msg.ID)
+			} else {
+				m.snapPanel.SetRestoreSize(msg.ID, msg.Stats.TotalSize)
+			}
+			return m, m.fetchSizeHistory()
+		}
+		if msg.Error != nil {
+			m.snapPanel.ClearRestoreSizePending(msg.ID)
+			m.opsPanel.Warning(fmt.Sprintf("Failed to get stats for snapshot %.8s: %s", msg.ID, m.errorText(msg.Error)))
+			return m, nil
+		}
+		m.snapPanel.SetRestoreSize(msg.ID, msg.Stats.TotalSize)
+		return m, nil
+
+	case DuplicateStatsMsg:
+		m.clearRepoBusy(msg.RepoName)
+		if msg.Error != nil {
+			m.opsPanel.Error(fmt.Sprintf("Failed to measure duplicate files in the latest snapshot of '%s': %s", msg.RepoName, m.errorText(msg.Error)))
+			return m, nil
+		}
+		m.metricsPanel.SetDuplicateStats(msg.RepoName, *msg.Stats)
+		// The latest snapshot is only known once restic listed them, so name the one measured here
+		m.opsPanel.Success(fmt.Sprintf("✓ Duplicate files in snapshot %.8s, the latest of '%s': %s",
+			msg.Stats.SnapshotID, msg.RepoName, ui.FormatDuplicateData(*msg.Stats)))
+		m.opsPanel.Dimmed(fmt.Sprintf("%s in %d files of snapshot %.8s, %s in %d distinct files",
+			ui.FormatBytes(msg.Stats.TotalSize), msg.Stats.TotalFiles, msg.Stats.SnapshotID, ui.FormatBytes(msg.Stats.UniqueSize), msg.Stats.UniqueFiles))
+		m.opsPanel.Dimmed(fmt.Sprintf("Command: restic -r %s stats --json --mode files-by-contents %s", msg.RepoPath, msg.Stats.SnapshotID))
+		return m, nil
+
+	case SnapshotSizeMsg:
+		if msg.Error != nil {
+			m.snapPanel.ClearSizePending(msg.ID)
+			m.opsPanel.Warning(fmt.Sprintf("Failed to compute size of snapshot %.8s: %s", msg.ID, m.errorText(msg.Error)))
+			return m, nil
+		}
+		m.snapPanel.SetSnapshotSize(msg.ID, msg.Size)
+		return m, nil
+
+	case FilePreviewMsg:
+		if msg.Error != nil {
+			m.opsPanel.Error(fmt.Sprintf("Failed to load preview: %s", m.errorText(msg.Error)))
+			return m, nil
+		}
+		if m.fileBrowser == nil || !m.showFileBrowser {
+			return m, nil
+		}
+		if err := m.fileBrowser.SetPreview(msg.Name, msg.Size, msg.Data); err != nil {
+			m.opsPanel.Warning(fmt.Sprintf("Cannot preview: %v", err))
+			return m, nil
 		}
 		return m, nil
 
+	case FilesBatchMsg:
+		// Batches from a listing that was stopped or replaced are dropped
+		if msg.Stream != m.fileStream || m.fileBrowser == nil {
+			return m, nil
+		}
+		m.fileBrowser.AppendFiles(msg.Files)
+		if msg.Full {
+			// The listing stopped; the next window is listed when requested
+			m.fileStream = nil
+			m.fileBrowser.SetLoading(false)
+			m.fileBrowser.SetWindow(m.fileBrowser.Offset(), true)
+			first := m.fileBrowser.Offset() + 1
+			m.opsPanel.Info(fmt.Sprintf("Showing entries %d-%d - press n on the last page to load more", first, first+m.fileBrowser.Count()-1))
+			return m, nil
+		}
+		return m, listenForFiles(msg.Stream)
+
 	case FilesLoadedMsg:
+		if msg.Stream != nil && msg.Stream != m.fileStream {
+			return m, nil
+		}
+		m.fileStream = nil
+		if m.fileBrowser != nil {
+			m.fileBrowser.SetLoading(false)
+		}
 		if msg.Error != nil {
-			m.opsPanel.Error(fmt.Sprintf("Failed to load files: %v", msg.Error))
+			m.opsPanel.Error(fmt.Sprintf("Failed to load files: %s", m.errorText(msg.Error)))
 		} else if m.fileBrowser != nil {
-			m.fileBrowser.SetFiles(msg.Files)
-			m.opsPanel.Info(fmt.Sprintf("Loaded %d files/directories", len(msg.Files)))
+			if msg.Stream == nil {
+				m.fileBrowser.SetFiles(msg.Files)
+			} else {
+				m.fileBrowser.AppendFiles(msg.Files)
+				m.fileBrowser.SetWindow(m.fileBrowser.Offset(), false)
+			}
+			m.opsPanel.Info(fmt.Sprintf("Loaded %d files/directories", m.fileBrowser.Offset()+m.fileBrowser.Count()))
 		}
 		return m, nil
 
 	case BackupProgressMsg:
-		m.currentBackupProgress = msg.Progress
+		// Log restic warnings (e.g. unreadable files) as they happen
+		if msg.StderrLine != "" {
+			m.opsPanel.Warning(msg.StderrLine)
+		}
 
 		// Update operations panel with progress
 		if msg.Progress != nil {
+			m.currentBackupProgress = msg.Progress
 			m.opsPanel.SetBackupProgress(msg.Progress)
 		}
 
@@ -489,21 +995,59 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case BackupSummaryMsg:
 		m.backupInProgress = false
+		m.clearRepoBusy(m.backupRepo)
 		m.currentBackupProgress = nil
 		m.opsPanel.ClearBackupProgress()
 
-		if msg.Error != nil {
-			m.opsPanel.Error(fmt.Sprintf("Backup failed: %v", msg.Error))
+		if restic.IsUnreachable(msg.Error) {
+			m.logPrecheckError("Backup", msg.Error)
+			return m, nil
+		} else if msg.Error != nil {
+			m.opsPanel.Error(fmt.Sprintf("Backup failed: %s", m.errorText(msg.Error)))
+		} else if m.lastBackupOptions.DryRun {
+			// Nothing was written, so there are no new snapshots to load
+			if msg.Summary != nil {
+				m.opsPanel.Preview(fmt.Sprintf("Dry run (no data written): would back up %d new, %d changed, %d unmodified files (%s added)",
+					msg.Summary.FilesNew, msg.Summary.FilesChanged, msg.Summary.FilesUnmodified, ui.FormatBytes(msg.Summary.DataAdded)))
+			} else {
+				m.opsPanel.Preview("Dry run completed (no data written)")
+			}
+			return m, nil
+		} else if msg.Summary != nil && msg.Warning != "" {
+			m.opsPanel.Warning(fmt.Sprintf("Backup completed with warnings: %s. New: %d, Changed: %d, Unmodified: %d",
+				msg.Warning, msg.Summary.FilesNew, msg.Summary.FilesChanged, msg.Summary.FilesUnmodified))
+			m.recordBackup(m.backupRepo, msg.Summary, time.Now())
 		} else if msg.Summary != nil {
 			m.opsPanel.Success(fmt.Sprintf("Backup completed! New: %d, Changed: %d, Unmodified: %d",
 				msg.Summary.FilesNew, msg.Summary.FilesChanged, msg.Summary.FilesUnmodified))
+			m.recordBackup(m.backupRepo, msg.Summary, time.Now())
 		} else {
 			m.opsPanel.Success("Backup completed successfully")
 		}
 
+		// Summarize what the exclude rules skipped, so users can confirm they behaved
+		if msg.Error == nil && msg.Summary != nil && restic.HasExclusions(m.lastBackupOptions) {
+			// The dry run reads the repository too, so it holds it like the backup did
+			m.markRepoBusy(m.backupRepo)
+			m.opsPanel.Dimmed("Checking what the exclude rules skipped (dry run without them)...")
+			return m, tea.Batch(m.loadSnapshotsWithMessage(), m.estimateExclusions(m.backupRepo, m.lastBackupOptions, msg.Summary))
+		}
+
 		// Reload snapshots to show the new backup
 		return m, m.loadSnapshotsWithMessage()
 
+	case ExclusionSummaryMsg:
+		m.clearRepoBusy(msg.RepoName)
+		if msg.Error != nil {
+			m.opsPanel.Dimmed(fmt.Sprintf("Couldn't check what the exclude rules of '%s' skipped: %s", msg.RepoName, m.errorText(msg.Error)))
+		} else if msg.Summary.ExcludedFiles == 0 {
+			m.opsPanel.Warning(fmt.Sprintf("The exclude rules of the '%s' backup skipped no files - check they behaved as intended", msg.RepoName))
+		} else {
+			m.opsPanel.Info(fmt.Sprintf("The exclude rules of the '%s' backup skipped about %d files (%s)",
+				msg.RepoName, msg.Summary.ExcludedFiles, ui.FormatBytes(msg.Summary.ExcludedBytes)))
+		}
+		return m, nil
+
 	case RestoreProgressMsg:
 		m.currentRestoreProgress = msg.Progress
 
@@ -519,23 +1063,66 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		return m, nil
 
+	case RestorePreviewMsg:
+		m.finishOperation()
+		if msg.Error != nil {
+			m.opsPanel.Error(fmt.Sprintf("Restore preview failed: %s", m.errorText(msg.Error)))
+			m.opsPanel.Dimmed("Restore not started")
+			m.removeRestoreIncludeTemp()
+			return m, nil
+		}
+		m.opsPanel.Preview(fmt.Sprintf("Restore would write %d paths to %s", len(msg.Paths), msg.Options.Target))
+		switch space := msg.Space; {
+		case space.Skipped != "":
+			m.opsPanel.Dimmed(fmt.Sprintf("Free-space check skipped: %s", space.Skipped))
+		case space.insufficient():
+			m.opsPanel.Warning(fmt.Sprintf("⚠ Not enough free space at %s: the restore needs %s, %s available",
+				msg.Options.Target, ui.FormatBytes(space.Required), ui.FormatBytes(int64(space.Available))))
+		default:
+			m.opsPanel.Dimmed(fmt.Sprintf("Free space at %s: %s available, the restore needs %s",
+				msg.Options.Target, ui.FormatBytes(int64(space.Available)), ui.FormatBytes(space.Required)))
+		}
+		m.showRestorePreview = true
+		m.restorePreviewOpts = msg.Options
+		m.restorePreviewPaths = msg.Paths
+		m.restorePreviewOffset = 0
+		m.restorePreviewSpace = msg.Space
+		return m, nil
+
+	case SandboxSpaceMsg:
+		return m.startSandboxRestore(msg)
+
 	case RestoreSummaryMsg:
 		m.restoreInProgress = false
+		m.clearRepoBusy(m.restoreRepo)
+		m.restoreRepo = ""
 		m.currentRestoreProgress = nil
+		m.removeRestoreIncludeTemp()
 
-		if msg.Error != nil {
-			m.opsPanel.Error(fmt.Sprintf("Restore failed: %v", msg.Error))
+		if restic.IsUnreachable(msg.Error) {
+			m.logPrecheckError("Restore", msg.Error)
+		} else if msg.Error != nil {
+			m.opsPanel.Error(fmt.Sprintf("Restore failed: %s", m.errorText(msg.Error)))
 		} else if msg.Summary != nil {
-			m.opsPanel.Success("Restore completed successfully")
+			m.opsPanel.Success(fmt.Sprintf("Restore completed successfully: %d files (%s) in %s",
+				msg.Summary.TotalFiles, ui.FormatBytes(msg.Summary.TotalBytes), ui.FormatSeconds(msg.Summary.SecondsElapsed)))
 		} else {
 			m.opsPanel.Success("Restore completed")
 		}
+		if m.sandboxTarget != "" {
+			m.finishSandboxRestore(msg.Error)
+		}
 
 		return m, nil
 
 	case ForgetDryRunMsg:
+		if restic.IsUnreachable(msg.Error) {
+			m.logPrecheckError("Forget", msg.Error)
+			m.showForgetForm = false
+			return m, nil
+		}
 		if msg.Error != nil {
-			m.opsPanel.Error(fmt.Sprintf("Forget dry-run failed: %v", msg.Error))
+			m.opsPanel.Error(fmt.Sprintf("Forget dry-run failed: %s", m.errorText(msg.Error)))
 			m.showForgetForm = false
 			return m, nil
 		}
@@ -555,9 +1142,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ForgetCompleteMsg:
 		m.showForgetConfirm = false
 		m.forgetConfirmDialog = nil
+		m.forgetInProgress = false
+		m.clearRepoBusy(msg.RepoName)
+
+		if restic.IsUnreachable(msg.Error) {
+			// Nothing ran, so keep the marks for a retry
+			m.logPrecheckError("Forget", msg.Error)
+			return m, nil
+		}
+
+		if len(msg.IDs) > 0 {
+			// Marks are keyed by ID; clear them whether or not restic succeeded
+			m.snapPanel.ClearMarks()
+		}
 
 		if msg.Error != nil {
-			m.opsPanel.Error(fmt.Sprintf("Forget failed: %v", msg.Error))
+			m.opsPanel.Error(fmt.Sprintf("Forget failed: %s", m.errorText(msg.Error)))
+		} else if len(msg.IDs) > 0 {
+			m.opsPanel.Success(fmt.Sprintf("✓ Forget completed: %d snapshots removed", len(msg.IDs)))
 		} else {
 			totalRemoved := 0
 			for _, result := range m.forgetPreviewResults {
@@ -570,8 +1172,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, m.loadSnapshotsWithMessage()
 
 	case PruneDryRunMsg:
+		m.finishOperation()
 		if msg.Error != nil {
-			m.opsPanel.Error(fmt.Sprintf("Prune dry-run failed: %v", msg.Error))
+			m.opsPanel.Error(fmt.Sprintf("Prune dry-run failed: %s", m.errorText(msg.Error)))
 			return m, nil
 		}
 
@@ -583,25 +1186,84 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			"PRUNE",
 		)
 		m.pruneConfirmDialog.SetSize(m.width*3/4, m.height*3/4)
+		m.pruneConfirmDialog.SetTimeoutSeconds(confirmTimeoutSeconds)
 		m.showPruneConfirm = true
 		m.opsPanel.Info("Prune dry-run complete - review and confirm")
+		return m, scheduleConfirmTimeout(m.pruneConfirmDialog)
+
+	case ConfirmTimeoutMsg:
+		// Cancel a dangerous confirmation left idle, unless the user typed since
+		if msg.Dialog == nil || msg.Activity != msg.Dialog.Activity() {
+			return m, nil
+		}
+		switch msg.Dialog {
+		case m.removeConfirmDialog:
+			m.showRemoveConfirm = false
+			m.removeConfirmDialog = nil
+			m.repoToRemove = ""
+			m.opsPanel.Warning("Repository removal confirmation timed out - cancelled")
+		case m.forgetConfirmDialog:
+			m.showForgetConfirm = false
+			m.forgetConfirmDialog = nil
+			m.forgetIDs = nil
+			m.opsPanel.Warning("Forget confirmation timed out - cancelled")
+		case m.pruneConfirmDialog:
+			m.showPruneConfirm = false
+			m.pruneConfirmDialog = nil
+			m.pruneDryRunOutput = ""
+			m.opsPanel.Warning("Prune confirmation timed out - cancelled")
+		case m.repairConfirmDialog:
+			m.showRepairConfirm = false
+			m.repairConfirmDialog = nil
+			m.opsPanel.Warning("Index repair confirmation timed out - cancelled")
+		case m.migrateConfirmDialog:
+			m.showMigrateConfirm = false
+			m.migrateConfirmDialog = nil
+			m.opsPanel.Warning("Migration confirmation timed out - cancelled")
+		case m.keyConfirmDialog:
+			m.cancelKeyAction()
+			m.opsPanel.Warning("Key confirmation timed out - cancelled")
+		case m.rawCommandConfirmDialog:
+			m.showRawCommandConfirm = false
+			m.rawCommandConfirmDialog = nil
+			m.rawCommand = nil
+			m.opsPanel.Warning("Command confirmation timed out - cancelled")
+		case m.rerunConfirmDialog:
+			m.showRerunConfirm = false
+			m.rerunConfirmDialog = nil
+			m.rerunPending = nil
+			m.opsPanel.Warning("Re-run confirmation timed out - cancelled")
+		case m.unlockAllConfirmDialog:
+			m.showUnlockAllConfirm = false
+			m.unlockAllConfirmDialog = nil
+			m.opsPanel.Warning("Unlock --remove-all confirmation timed out - cancelled")
+		case m.spaceConfirmDialog:
+			m.showSpaceConfirm = false
+			m.spaceConfirmDialog = nil
+			m.removeRestoreIncludeTemp()
+			m.opsPanel.Warning("Low disk space confirmation timed out - restore cancelled")
+		}
 		return m, nil
 
 	case PruneCompleteMsg:
+		m.finishOperation()
 		m.showPruneConfirm = false
 		m.pruneConfirmDialog = nil
 
 		if msg.Error != nil {
-			m.opsPanel.Error(fmt.Sprintf("Prune failed: %v", msg.Error))
+			m.opsPanel.Error(fmt.Sprintf("Prune failed: %s", m.errorText(msg.Error)))
 		} else {
 			m.opsPanel.Success("Prune completed successfully")
 		}
 		return m, m.loadRepositories
 
 	case ScannedReposMsg:
+		for _, scanErr := range msg.Errors {
+			m.opsPanel.Dimmed(fmt.Sprintf("Scan error in %s", scanErr))
+		}
 		if len(msg.FoundRepos) == 0 {
 			m.opsPanel.Info("No restic repositories found in scanned locations")
-			m.opsPanel.Dimmed("Scanned: /mnt, /media, /backup, /srv, /opt")
+			m.opsPanel.Dimmed(fmt.Sprintf("Scanned: %s", strings.Join(msg.ScannedPaths, ", ")))
 		} else {
 			m.opsPanel.Success(fmt.Sprintf("✓ Found %d potential repositories", len(msg.FoundRepos)))
 			m.opsPanel.Info("Select a repository and press Enter to add it")
@@ -611,37 +1273,227 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
-	case CacheCleanupMsg:
+	case RepoMountedMsg:
+		m.finishOperation()
 		if msg.Error != nil {
-			m.opsPanel.Error(fmt.Sprintf("Cache cleanup failed: %v", msg.Error))
-		} else {
-			m.opsPanel.Success("✓ Cache cleanup completed successfully")
-			if msg.Output != "" {
-				m.opsPanel.Info(msg.Output)
+			if restic.IsMountUnavailable(msg.Error) {
+				m.opsPanel.Warning("restic mount is not available on this system - install FUSE (fuse3 on Linux, macFUSE on macOS)")
+				m.opsPanel.Dimmed(msg.Error.Error())
+				return m, nil
 			}
-			m.opsPanel.Dimmed("Removed old/unused cache entries")
+			m.opsPanel.Error(fmt.Sprintf("✗ Failed to mount '%s': %s", msg.RepoName, m.errorText(msg.Error)))
+			return m, nil
 		}
+		m.mountCmd = msg.Cmd
+		m.mountPoint = msg.MountPoint
+		m.mountRepo = msg.RepoName
+		m.opsPanel.Success(fmt.Sprintf("✓ Mounted '%s' at %s", msg.RepoName, msg.MountPoint))
+		m.opsPanel.Info(fmt.Sprintf("Browse snapshots under %s with any tool - press '%s' to unmount", filepath.Join(msg.MountPoint, "snapshots"), keyLabel(m.keyMap().Mount)))
 		return m, nil
 
-	case UnlockMsg:
+	case RepoUnmountedMsg:
+		m.mountCmd = nil
+		m.mountPoint = ""
+		m.mountRepo = ""
 		if msg.Error != nil {
-			m.opsPanel.Error(fmt.Sprintf("Unlock failed: %v", msg.Error))
-		} else {
-			m.opsPanel.Success("✓ Repository unlocked successfully")
-			if msg.Output != "" {
-				m.opsPanel.Info(msg.Output)
-			}
-			m.opsPanel.Dimmed("Stale locks removed - repository is now accessible")
-			// Refresh repository info after unlock
-			return m, m.loadRepositories
+			m.opsPanel.Warning(fmt.Sprintf("Failed to stop restic mount for '%s': %s", msg.RepoName, m.errorText(msg.Error)))
+			m.opsPanel.Dimmed(fmt.Sprintf("If %s is still listed as mounted, run: fusermount -u %s", msg.MountPoint, msg.MountPoint))
+			return m, nil
 		}
+		m.opsPanel.Success(fmt.Sprintf("✓ Unmounted '%s'", msg.RepoName))
 		return m, nil
 
-	case RepoRemovedMsg:
-		m.showRemoveConfirm = false
-		m.removeConfirmDialog = nil
-		m.repoToRemove = ""
-
+	case OperationTickMsg:
+		if msg.Gen != m.operationGen || m.operationInProgress == "" {
+			return m, nil
+		}
+		m.spinnerFrame++
+		m.updateOperationActivity(time.Now())
+		return m, scheduleOperationTick(msg.Gen)
+
+	case CommandRerunMsg:
+		m.finishOperation()
+		m.clearRepoBusy(msg.RepoName)
+		lines := strings.Split(strings.TrimSpace(msg.Output), "\n")
+		if len(lines) > rerunOutputLines {
+			m.opsPanel.Dimmed(fmt.Sprintf("(%d earlier lines omitted)", len(lines)-rerunOutputLines))
+			lines = lines[len(lines)-rerunOutputLines:]
+		}
+		for _, line := range lines {
+			if strings.TrimSpace(line) != "" {
+				m.opsPanel.Dimmed(line)
+			}
+		}
+		if msg.Error != nil {
+			m.opsPanel.Error(fmt.Sprintf("✗ Re-run failed: %s", msg.Command))
+		} else {
+			m.opsPanel.Success(fmt.Sprintf("✓ Re-ran: %s", msg.Command))
+		}
+		return m, nil
+
+	case LockPollMsg:
+		if !m.lockPollActive(msg.Gen) {
+			return m, nil
+		}
+		return m, m.listLocks(msg.Gen, msg.Repo)
+
+	case LocksListedMsg:
+		cmd := m.handleLocksListed(msg)
+		return m, cmd
+
+	case QuitWaitMsg:
+		if !m.quitPending {
+			return m, nil
+		}
+		if m.runningOperation() == "" {
+			m.quitPending = false
+			cmd := m.requestQuit()
+			return m, cmd
+		}
+		if time.Since(msg.Started) > quitWaitTimeout {
+			m.opsPanel.Error(fmt.Sprintf("%s did not stop in time - quitting anyway", m.runningOperation()))
+			cmd := m.quit()
+			return m, cmd
+		}
+		return m, waitToQuit(msg.Started)
+
+	case DiagnosticsMsg:
+		passed := diagnosticsPassed(msg.Checks)
+		if passed == len(msg.Checks) {
+			m.opsPanel.Success(fmt.Sprintf("✓ Diagnostics: all %d checks passed", passed))
+		} else {
+			m.opsPanel.Warning(fmt.Sprintf("Diagnostics: %d of %d checks failed", len(msg.Checks)-passed, len(msg.Checks)))
+		}
+		m.diagnostics = msg.Checks
+		m.showDiagnostics = true
+		return m, nil
+
+	case CommandResultMsg:
+		m.finishOperation()
+		m.clearRepoBusy(msg.RepoName)
+		return m, m.handleCommandResult(msg)
+
+	case CheckOutputMsg:
+		m.opsPanel.Dimmed(msg.Line)
+		return m, listenForCheckUpdates(msg.Updates)
+
+	case CheckCompleteMsg:
+		m.checkInProgress = false
+		m.finishOperation()
+		m.clearRepoBusy(m.checkRepo)
+		m.checkRepo = ""
+		if msg.Error != nil {
+			m.opsPanel.Error(fmt.Sprintf("✗ Data check failed: %s", m.errorText(msg.Error)))
+			return m, nil
+		}
+		m.opsPanel.Success("✓ Data check completed - no errors found")
+		return m, nil
+
+	case RepairOutputMsg:
+		m.opsPanel.Dimmed(msg.Line)
+		return m, listenForRepairUpdates(msg.RepoName, msg.Updates)
+
+	case RepairCompleteMsg:
+		m.repairInProgress = false
+		if msg.Error != nil {
+			m.opsPanel.Error(fmt.Sprintf("✗ Index repair failed: %s", m.errorText(msg.Error)))
+			return m, nil
+		}
+		m.opsPanel.Success(fmt.Sprintf("✓ Index of '%s' repaired", msg.RepoName))
+		m.opsPanel.Dimmed("Run a data check to confirm the repository is healthy")
+		for i, repoConfig := range m.config.Repositories {
+			if repoConfig.Name == msg.RepoName {
+				return m, m.loadRepositoryInfoAt(i)
+			}
+		}
+		return m, nil
+
+	case MigrationsListedMsg:
+		if msg.Error != nil {
+			m.opsPanel.Error(fmt.Sprintf("Failed to list migrations of '%s': %s", msg.RepoName, m.errorText(msg.Error)))
+			return m, nil
+		}
+		if len(msg.Migrations) == 0 {
+			m.opsPanel.Info(fmt.Sprintf("No migrations available for '%s' - the repository is up to date", msg.RepoName))
+			return m, nil
+		}
+		m.opsPanel.Dimmed(fmt.Sprintf("%d migration(s) available for '%s'", len(msg.Migrations), msg.RepoName))
+		m.migrations = msg.Migrations
+		m.migrationCursor = 0
+		m.migrationRepo = msg.RepoName
+		m.showMigrations = true
+		return m, nil
+
+	case KeysListedMsg:
+		m.handleKeysListed(msg)
+		return m, nil
+
+	case MigrationOutputMsg:
+		m.opsPanel.Dimmed(msg.Line)
+		return m, listenForMigrationUpdates(msg.RepoName, msg.Migration, msg.Updates)
+
+	case MigrationCompleteMsg:
+		m.migrateInProgress = false
+		if msg.Error != nil {
+			m.opsPanel.Error(fmt.Sprintf("✗ Migration %s failed: %s", msg.Migration, m.errorText(msg.Error)))
+			return m, nil
+		}
+		m.opsPanel.Success(fmt.Sprintf("✓ Applied migration %s to '%s'", msg.Migration, msg.RepoName))
+		for i, repoConfig := range m.config.Repositories {
+			if repoConfig.Name == msg.RepoName {
+				return m, m.loadRepositoryInfoAt(i)
+			}
+		}
+		return m, nil
+
+	case RawCommandOutputMsg:
+		m.opsPanel.Dimmed(msg.Line)
+		return m, listenForRawCommandUpdates(msg.RepoName, msg.Command, msg.Updates)
+
+	case RawCommandCompleteMsg:
+		m.rawCommandInProgress = false
+		m.clearRepoBusy(msg.RepoName)
+		if msg.Error != nil {
+			m.opsPanel.Error(fmt.Sprintf("✗ restic %s failed: %s", msg.Command, m.errorText(msg.Error)))
+			return m, nil
+		}
+		m.opsPanel.Success(fmt.Sprintf("✓ restic %s finished", msg.Command))
+		// The command may have changed the repository, so reload what's shown
+		for i, repoConfig := range m.config.Repositories {
+			if repoConfig.Name == msg.RepoName {
+				return m, m.loadRepositoryInfoAt(i)
+			}
+		}
+		return m, nil
+
+	case RepoInitializedMsg:
+		if restic.IsAlreadyInitialized(msg.Error) {
+			m.opsPanel.Info(fmt.Sprintf("Repository '%s' already initialized, skipping", msg.RepoName))
+			return m, m.loadRepositories
+		}
+		if msg.Error != nil {
+			m.opsPanel.Error(fmt.Sprintf("Failed to initialize repository: %s", m.errorText(msg.Error)))
+			return m, nil
+		}
+		m.opsPanel.Success(fmt.Sprintf("✓ Repository '%s' initialized", msg.RepoName))
+		m.opsPanel.Info("Refreshing repository list...")
+		return m, m.loadRepositories
+
+	case RepoRenamedMsg:
+		if msg.Error != nil {
+			m.opsPanel.Error(fmt.Sprintf("✗ Failed to rename repository '%s': %v", msg.OldName, msg.Error))
+			return m, nil
+		}
+		m.opsPanel.Success(fmt.Sprintf("✓ Repository '%s' renamed to '%s'", msg.OldName, msg.NewName))
+		m.opsPanel.Dimmed(fmt.Sprintf("Configuration file updated: %s", config.DefaultConfigPath()))
+		m.renameRepoState(msg.OldName, msg.NewName)
+		return m, m.loadRepositories
+
+	case RepoRemovedMsg:
+		m.showRemoveConfirm = false
+		m.removeConfirmDialog = nil
+		m.repoToRemove = ""
+
 		if msg.Error != nil {
 			m.opsPanel.Error(fmt.Sprintf("✗ Failed to remove repository: %v", msg.Error))
 			m.opsPanel.Dimmed("Repository was not removed from configuration")
@@ -658,8 +1510,58 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		keys := m.keyMap()
+
+		// While quitting waits for a cancelled operation, only Ctrl+C (quit now) is handled
+		if m.quitPending {
+			if msg.String() == "ctrl+c" {
+				cmd := m.quit()
+				return m, cmd
+			}
+			return m, nil
+		}
+
+		// Handle quit confirmation while an operation runs
+		if m.showQuitConfirm {
+			switch msg.String() {
+			case "y", "Y", "enter":
+				m.showQuitConfirm = false
+				if m.runningOperation() == "" {
+					cmd := m.requestQuit()
+					return m, cmd
+				}
+				cmd := m.cancelAndQuit()
+				return m, cmd
+			case "ctrl+c":
+				// A second Ctrl+C quits without waiting
+				cmd := m.quit()
+				return m, cmd
+			case "n", "N", "esc":
+				m.showQuitConfirm = false
+				m.opsPanel.Info("Quit cancelled")
+			}
+			return m, nil
+		}
+
+		// Handle sandbox cleanup before quitting
+		if m.showSandboxCleanup {
+			switch msg.String() {
+			case "y", "Y":
+				m.removeSandboxes()
+				cmd := m.quit()
+				return m, cmd
+			case "n", "N", "ctrl+c":
+				cmd := m.quit()
+				return m, cmd
+			case "esc":
+				m.showSandboxCleanup = false
+				m.opsPanel.Info("Quit cancelled")
+			}
+			return m, nil
+		}
+
 		if m.showHelp {
-			if msg.String() == "?" || msg.String() == "esc" {
+			if msg.String() == keys.Help || msg.String() == "esc" {
 				m.showHelp = false
 			}
 			return m, nil
@@ -676,17 +1578,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Check which field is focused
 				if m.backupForm.IsValid() {
 					// Start backup
-					opts := types.BackupOptions{
-						Paths:   m.backupForm.GetPaths(),
-						Tags:    m.backupForm.GetTags(),
-						Exclude: m.backupForm.GetExclude(),
-					}
-
 					m.showBackupForm = false
-					m.backupInProgress = true
-					m.opsPanel.Info(fmt.Sprintf("Starting backup of %d paths...", len(opts.Paths)))
-
-					return m, m.executeBackup(opts)
+					return m.startBackup(m.backupFormOptions())
+				}
+				if err := m.backupForm.Validate(); err != nil {
+					m.opsPanel.Warning(fmt.Sprintf("Cannot start backup: %v", err))
 				}
 			}
 
@@ -701,6 +1597,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch msg.String() {
 			case "esc":
 				m.showRestoreForm = false
+				m.removeRestoreIncludeTemp()
 				return m, nil
 
 			case "enter":
@@ -716,16 +1613,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 					// Start restore
 					opts := types.RestoreOptions{
-						SnapshotID: selectedSnapshot.ID,
-						Target:     m.restoreForm.GetTarget(),
-						Include:    m.restoreForm.GetInclude(),
+						SnapshotID:  selectedSnapshot.ID,
+						Target:      m.restoreForm.GetTarget(),
+						Include:     m.restoreForm.GetInclude(),
+						IncludeFile: m.restoreForm.GetIncludeFile(),
+						Overwrite:   m.restoreForm.GetOverwrite(),
 					}
 
 					m.showRestoreForm = false
-					m.restoreInProgress = true
-					m.opsPanel.Info(fmt.Sprintf("Starting restore of snapshot %s...", selectedSnapshot.ShortID))
-
-					return m, m.executeRestore(opts)
+					if opts.Target != "" {
+						// Confirm the file set before writing to a new location
+						if m.operationInProgress != "" {
+							m.opsPanel.Warning(fmt.Sprintf("Wait for %s to finish", m.operationInProgress))
+							return m, nil
+						}
+						m.opsPanel.Info(fmt.Sprintf("Previewing restore of snapshot %s to %s...", selectedSnapshot.ShortID, opts.Target))
+						return m, m.startOperation("restore preview", m.previewRestore(opts))
+					}
+					m.opsPanel.Dimmed("Free-space check skipped: restoring to original locations")
+					return m.startRestore(opts)
+				}
+				if err := m.restoreForm.Validate(); err != nil {
+					m.opsPanel.Warning(fmt.Sprintf("Cannot start restore: %v", err))
 				}
 			}
 
@@ -740,15 +1649,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch msg.String() {
 			case "esc":
 				// Cancel filter input
+				if m.filterFacet != "" {
+					m.applyFilterText(m.filterFacetPrev)
+					m.filterFacet = ""
+				}
 				m.filterInputActive = false
 				m.filterInputText = ""
 				return m, nil
 
 			case "enter":
+				if m.filterFacet != "" {
+					// Apply the tag or host filter, leaving the other facets alone
+					m.applyFilterText(m.filterInputText)
+					if m.filterInputText == "" {
+						m.opsPanel.Info(fmt.Sprintf("%s filter cleared", facetLabel(m.filterFacet)))
+					} else {
+						m.opsPanel.Info(fmt.Sprintf("%s filter applied: %s", facetLabel(m.filterFacet), m.filterInputText))
+					}
+					m.filterFacet = ""
+					m.filterInputActive = false
+					return m, nil
+				}
+
 				// Apply the filter
-				m.snapPanel.SetFilter(m.filterInputText)
+				m.applyFilterText(m.filterInputText)
 				m.filterInputActive = false
 				m.opsPanel.Info(fmt.Sprintf("Filter applied: %s", m.filterInputText))
+				if m.activePanel == types.PanelSnapshots {
+					return m, m.setSnapshotQuery(parseSnapshotQuery(m.filterInputText))
+				}
 				return m, nil
 
 			case "backspace":
@@ -756,11 +1685,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if len(m.filterInputText) > 0 {
 					m.filterInputText = m.filterInputText[:len(m.filterInputText)-1]
 					// Apply filter in real-time as user types
-					if m.filterInputText == "" {
-						m.snapPanel.ClearFilter()
-					} else {
-						m.snapPanel.SetFilter(m.filterInputText)
-					}
+					m.applyFilterText(m.filterInputText)
 				}
 				return m, nil
 
@@ -769,259 +1694,877 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if len(msg.String()) == 1 {
 					m.filterInputText += msg.String()
 					// Apply filter in real-time as user types
-					m.snapPanel.SetFilter(m.filterInputText)
+					m.applyFilterText(m.filterInputText)
 				}
 				return m, nil
 			}
 		}
 
-		// Handle file browser interactions
-		if m.showFileBrowser && m.fileBrowser != nil {
+		// Handle data check subset prompt
+		if m.checkPromptActive {
 			switch msg.String() {
 			case "esc":
-				// Close file browser
-				m.showFileBrowser = false
-				m.opsPanel.Info("Closed file browser")
-				return m, nil
-
-			case "j", "down":
-				// Move down in file list
-				m.fileBrowser.MoveDown()
-				return m, nil
-
-			case "k", "up":
-				// Move up in file list
-				m.fileBrowser.MoveUp()
+				m.checkPromptActive = false
+				m.checkPromptText = ""
+				m.opsPanel.Dimmed("Data check cancelled")
 				return m, nil
 
-			case "h", "left":
-				// Go to parent directory
-				if m.fileBrowser.CanGoUp() {
-					m.fileBrowser.GoUp()
-					return m, m.loadFiles
+			case "enter":
+				subset := strings.TrimSpace(m.checkPromptText)
+				if err := restic.ValidateReadDataSubset(subset); err != nil {
+					m.opsPanel.Warning(err.Error())
+					return m, nil
 				}
-				return m, nil
-
-			case "n", "pgdown":
-				// Next page
-				m.fileBrowser.NextPage()
-				return m, nil
+				m.checkPromptActive = false
+				m.checkPromptText = ""
+				repo := m.repositories[m.currentRepoIndex]
+				if !m.claimRepo(repo.Name) {
+					return m, nil
+				}
+				m.checkRepo = repo.Name
+				m.checkInProgress = true
+				m.opsPanel.Info(fmt.Sprintf("Verifying %s of data in '%s'...", subset, repo.Name))
+				m.opsPanel.Dimmed(fmt.Sprintf("Command: restic -r %s check --read-data-subset=%s", repo.Path, subset))
+				cmd := m.executeDataCheck(m.operationContext(), subset)
+				poll := m.pollLocks()
+				return m, tea.Batch(m.startOperation("data check", cmd), poll)
 
-			case "p", "pgup":
-				// Previous page
-				m.fileBrowser.PrevPage()
+			case "backspace":
+				if len(m.checkPromptText) > 0 {
+					m.checkPromptText = m.checkPromptText[:len(m.checkPromptText)-1]
+				}
 				return m, nil
 
-			case "l", "right", "enter":
-				// Enter directory or do nothing for files
-				if newPath, entered := m.fileBrowser.EnterDirectory(); entered {
-					m.opsPanel.Info(fmt.Sprintf("Navigating to %s...", newPath))
-					return m, m.loadFiles
+			default:
+				if len(msg.String()) == 1 {
+					m.checkPromptText += msg.String()
 				}
 				return m, nil
+			}
+		}
 
-			case " ", "space":
-				// Toggle file selection
-				m.fileBrowser.ToggleSelection()
+		// Handle repository rename prompt
+		if m.renamePromptActive {
+			switch msg.String() {
+			case "esc":
+				m.renamePromptActive = false
+				m.renamePromptText = ""
+				m.repoToRename = ""
 				return m, nil
 
-			case "r":
-				// Restore selected files
-				selectedFiles := m.fileBrowser.GetSelectedFiles()
-				if len(selectedFiles) == 0 {
-					m.opsPanel.Warning("No files selected - press Space to select files")
+			case "enter":
+				newName := strings.TrimSpace(m.renamePromptText)
+				if newName == "" {
+					m.opsPanel.Warning("Enter a new name for the repository")
+					return m, nil
+				}
+				oldName := m.repoToRename
+				if m.warnRepoBusy(oldName) {
 					return m, nil
 				}
+				m.renamePromptActive = false
+				m.renamePromptText = ""
+				m.repoToRename = ""
+				if newName == oldName {
+					m.opsPanel.Dimmed("Repository name unchanged")
+					return m, nil
+				}
+				return m, m.renameRepository(oldName, newName)
 
-				// Create paths list from selected files
-				var paths []string
-				for _, file := range selectedFiles {
-					paths = append(paths, file.Path)
+			case "backspace":
+				if len(m.renamePromptText) > 0 {
+					m.renamePromptText = m.renamePromptText[:len(m.renamePromptText)-1]
 				}
+				return m, nil
 
-				// Open restore form with selected paths pre-filled
-				snapshot := m.fileBrowser.GetSnapshot()
-				m.restoreForm = ui.NewRestoreForm(snapshot)
-				m.restoreForm.SetSize(m.width*2/3, m.height*2/3)
-				// Pre-fill with selected file paths
-				m.restoreForm.SetIncludePaths(paths)
-				m.showRestoreForm = true
-				m.showFileBrowser = false
-				m.opsPanel.Info(fmt.Sprintf("Restoring %d selected files...", len(paths)))
+			default:
+				if len(msg.String()) == 1 {
+					m.renamePromptText += msg.String()
+				}
 				return m, nil
 			}
 		}
 
-		// Handle found repos selection
-		if m.showFoundRepos {
+		// Handle restic command prompt
+		if m.commandPromptActive {
 			switch msg.String() {
 			case "esc":
-				// Close found repos list
-				m.showFoundRepos = false
-				m.foundRepos = nil
-				m.selectedFound = 0
-				m.opsPanel.Info("Cancelled repository selection")
+				m.commandPromptActive = false
+				m.commandPromptText = ""
 				return m, nil
 
-			case "j", "down":
-				// Move down in found repos list
-				if m.selectedFound < len(m.foundRepos)-1 {
-					m.selectedFound++
-				}
-				return m, nil
+			case "enter":
+				cmd := m.submitCommandPrompt()
+				return m, cmd
 
-			case "k", "up":
-				// Move up in found repos list
-				if m.selectedFound > 0 {
-					m.selectedFound--
+			case "backspace":
+				if len(m.commandPromptText) > 0 {
+					m.commandPromptText = m.commandPromptText[:len(m.commandPromptText)-1]
 				}
 				return m, nil
 
-			case "enter":
-				// Add selected repo
-				if m.selectedFound >= 0 && m.selectedFound < len(m.foundRepos) {
-					selectedRepo := m.foundRepos[m.selectedFound]
-					m.showFoundRepos = false
-					m.foundRepos = nil
-					m.selectedFound = 0
-
-					// Open repo form pre-filled with the selected repo
-					m.repoForm = ui.NewRepoForm()
-					m.repoForm.SetPath(selectedRepo.Path)
-					m.repoForm.SetName(selectedRepo.Name)
-					m.showRepoForm = true
-					m.opsPanel.Info(fmt.Sprintf("Adding repository: %s", selectedRepo.Name))
+			default:
+				if len(msg.String()) == 1 {
+					m.commandPromptText += msg.String()
 				}
 				return m, nil
 			}
 		}
 
-		// Handle repo form interactions
-		// Handle remove confirmation dialog
-		if m.showRemoveConfirm && m.removeConfirmDialog != nil {
+		// Handle find pattern prompt
+		if m.findPromptActive {
 			switch msg.String() {
 			case "esc":
-				// Cancel removal
-				m.showRemoveConfirm = false
-				m.removeConfirmDialog = nil
-				m.repoToRemove = ""
-				m.opsPanel.Info("Cancelled repository removal")
+				m.findPromptActive = false
+				m.findPromptText = ""
 				return m, nil
 
 			case "enter":
-				// Check if user typed the confirmation word
-				if m.removeConfirmDialog.IsConfirmed() {
-					m.opsPanel.Info(fmt.Sprintf("✓ Confirmed - removing '%s' from configuration...", m.repoToRemove))
-					m.opsPanel.Dimmed("Updating configuration file...")
-					return m, m.removeRepository()
+				pattern := strings.TrimSpace(m.findPromptText)
+				if pattern == "" {
+					m.opsPanel.Warning("Enter a file name or pattern to find")
+					return m, nil
+				}
+				m.findPromptActive = false
+				m.findPromptText = ""
+				m.findInProgress = true
+				repo := m.repositories[m.currentRepoIndex]
+				m.opsPanel.Info(fmt.Sprintf("Searching all snapshots in '%s' for '%s'...", repo.Name, pattern))
+				m.opsPanel.Dimmed(fmt.Sprintf("Command: restic -r %s find --json %s", repo.Path, pattern))
+				return m, m.findFiles(pattern)
+
+			case "backspace":
+				if len(m.findPromptText) > 0 {
+					m.findPromptText = m.findPromptText[:len(m.findPromptText)-1]
+				}
+				return m, nil
+
+			default:
+				if len(msg.String()) == 1 {
+					m.findPromptText += msg.String()
 				}
 				return m, nil
 			}
+		}
 
-			// Pass other keys to the dialog
-			var cmd tea.Cmd
-			cmd = m.removeConfirmDialog.Update(msg)
-			return m, cmd
+		// Handle repository config view
+		if m.showRepoConfig {
+			switch msg.String() {
+			case "esc", "q", keys.RepoConfig:
+				m.showRepoConfig = false
+				m.repoConfig = nil
+			}
+			return m, nil
 		}
 
-		if m.showRepoForm && m.repoForm != nil {
+		// Handle diagnostics overlay
+		if m.showDiagnostics {
 			switch msg.String() {
-			case "esc":
-				// Cancel repo creation
-				m.showRepoForm = false
-				m.repoForm = ui.NewRepoForm() // Reset form
-				m.opsPanel.Info("Cancelled repository creation")
+			case "esc", "q", keys.Diagnostics:
+				m.showDiagnostics = false
+			}
+			return m, nil
+		}
+
+		// Handle repository totals overlay
+		if m.showTotals {
+			switch msg.String() {
+			case "esc", "q", keys.Totals:
+				m.showTotals = false
+			}
+			return m, nil
+		}
+
+		// Handle snapshot detail overlay
+		if m.showSnapshotInfo {
+			switch msg.String() {
+			case "esc", "q", keys.SnapshotInfo:
+				m.showSnapshotInfo = false
+			}
+			return m, nil
+		}
+
+		// Handle restore preview (paths a restore to a new location would write)
+		if m.showRestorePreview {
+			switch msg.String() {
+			case "esc", "q", "n":
+				m.showRestorePreview = false
+				m.restorePreviewPaths = nil
+				m.removeRestoreIncludeTemp()
+				m.opsPanel.Info("Restore cancelled")
+				return m, nil
+
+			case "j", "down":
+				if m.restorePreviewOffset < len(m.restorePreviewPaths)-1 {
+					m.restorePreviewOffset++
+				}
+				return m, nil
+
+			case "k", "up":
+				if m.restorePreviewOffset > 0 {
+					m.restorePreviewOffset--
+				}
+				return m, nil
+
+			case "enter", "y":
+				m.showRestorePreview = false
+				m.restorePreviewPaths = nil
+				if space := m.restorePreviewSpace; space.insufficient() {
+					// Restoring would likely fill the disk partway through
+					m.spaceConfirmDialog = ui.NewConfirmationDialog(
+						"LOW DISK SPACE",
+						fmt.Sprintf("The restore needs %s but only %s is free at\n%s\n\nrestic will stop when the disk fills up, leaving a partial restore behind.",
+							ui.FormatBytes(space.Required), ui.FormatBytes(int64(space.Available)), m.restorePreviewOpts.Target),
+						"RESTORE",
+					)
+					m.spaceConfirmDialog.SetSize(m.width*3/4, m.height*3/4)
+					m.spaceConfirmDialog.SetTimeoutSeconds(confirmTimeoutSeconds)
+					m.showSpaceConfirm = true
+					m.opsPanel.Warning("⚠️  Type 'RESTORE' to restore anyway")
+					return m, scheduleConfirmTimeout(m.spaceConfirmDialog)
+				}
+				return m.startRestore(m.restorePreviewOpts)
+			}
+			return m, nil
+		}
+
+		// Handle low disk space confirmation before a restore
+		if m.showSpaceConfirm && m.spaceConfirmDialog != nil {
+			switch msg.String() {
+			case "esc":
+				m.showSpaceConfirm = false
+				m.spaceConfirmDialog = nil
+				m.removeRestoreIncludeTemp()
+				m.opsPanel.Info("Restore cancelled")
 				return m, nil
 
 			case "enter":
-				// Submit form
-				if m.repoForm.GetFocusedField() == ui.FieldSubmit {
-					// Get form data
-					name := m.repoForm.GetName()
-					path := m.repoForm.GetPath()
-					passwordMethod := m.repoForm.GetPasswordMethod()
-					password := m.repoForm.GetPassword()
+				if m.spaceConfirmDialog.IsConfirmed() {
+					m.showSpaceConfirm = false
+					m.spaceConfirmDialog = nil
+					m.opsPanel.Warning("Restoring despite low disk space")
+					return m.startRestore(m.restorePreviewOpts)
+				}
+				return m, nil
+			}
 
-					if name == "" || path == "" {
-						m.opsPanel.Error("Name and path are required")
-						return m, nil
-					}
+			cmd := m.spaceConfirmDialog.Update(msg)
+			return m, tea.Batch(cmd, scheduleConfirmTimeout(m.spaceConfirmDialog))
+		}
 
-					// Create repository config
-					repoConfig := types.RepositoryConfig{
-						Name: name,
-						Path: path,
-					}
+		// Handle find results list
+		if m.showFindResults {
+			switch msg.String() {
+			case "esc", "q":
+				m.showFindResults = false
+				m.findResults = nil
+				m.selectedFind = 0
+				return m, nil
 
-					switch passwordMethod {
-					case "file":
-						var passwordFilePath string
+			case "j", "down":
+				if m.selectedFind < len(m.findResults)-1 {
+					m.selectedFind++
+				}
+				return m, nil
 
-						// Auto-generate password file if requested
-						if m.repoForm.ShouldAutoGeneratePasswordFile() {
-							// Generate password file path
-							home, err := os.UserHomeDir()
-							if err != nil {
-								m.opsPanel.Error(fmt.Sprintf("Failed to get home directory: %v", err))
-								return m, nil
-							}
+			case "k", "up":
+				if m.selectedFind > 0 {
+					m.selectedFind--
+				}
+				return m, nil
 
-							passwordDir := filepath.Join(home, ".config", "lazyrestic", "passwords")
-							passwordFilePath = filepath.Join(passwordDir, name+".txt")
+			case "enter":
+				if m.selectedFind < 0 || m.selectedFind >= len(m.findResults) {
+					return m, nil
+				}
+				result := m.findResults[m.selectedFind]
+				if !m.snapPanel.SelectByID(result.SnapshotID) {
+					m.opsPanel.Warning(fmt.Sprintf("Snapshot %.8s is not loaded - press r to refresh", result.SnapshotID))
+					return m, nil
+				}
+				m.showFindResults = false
+				m.findResults = nil
+				m.selectedFind = 0
+				m.activePanel = types.PanelSnapshots
+
+				// Open the file browser at the directory containing the match
+				snapshot := m.snapPanel.GetSelected()
+				m.fileBrowser = ui.NewFileBrowser(snapshot)
+				m.fileBrowser.SetSize(m.width*2/3, m.height*2/3)
+				m.fileBrowser.SetCurrentPath(path.Dir(result.Path))
+				m.showFileBrowser = true
+				m.opsPanel.Info(fmt.Sprintf("Browsing snapshot %s at %s...", snapshot.ShortID, path.Dir(result.Path)))
+				return m, tea.Batch(m.loadFiles(), m.fetchSelectedSnapshotStats())
+			}
+			return m, nil
+		}
 
-							// Create password directory if it doesn't exist
-							if err := os.MkdirAll(passwordDir, 0700); err != nil {
-								m.opsPanel.Error(fmt.Sprintf("Failed to create password directory: %v", err))
-								return m, nil
-							}
+		// Handle file browser interactions
+		if m.showFileBrowser && m.fileBrowser != nil && m.fileBrowser.HasPreview() {
+			switch msg.String() {
+			case "esc", "q", "v":
+				m.fileBrowser.ClosePreview()
+			case "j", "down":
+				m.fileBrowser.ScrollPreview(1)
+			case "k", "up":
+				m.fileBrowser.ScrollPreview(-1)
+			case "n", "pgdown":
+				m.fileBrowser.ScrollPreview(10)
+			case "p", "pgup":
+				m.fileBrowser.ScrollPreview(-10)
+			}
+			return m, nil
+		}
 
-							// Generate secure random password
-							generatedPassword, err := generateSecurePassword(32)
-							if err != nil {
-								m.opsPanel.Error(fmt.Sprintf("Failed to generate password: %v", err))
-								return m, nil
-							}
+		if m.showFileBrowser && m.fileBrowser != nil {
+			switch msg.String() {
+			case "v":
+				// Preview the head of the selected file
+				file := m.fileBrowser.GetSelected()
+				if file == nil || !file.IsFile() {
+					m.opsPanel.Warning("Select a file to preview")
+					return m, nil
+				}
+				limit := m.previewLimit()
+				if file.Size > limit {
+					m.opsPanel.Info(fmt.Sprintf("Previewing %s (%s, showing first %s)...", file.Name, ui.FormatBytes(file.Size), ui.FormatBytes(limit)))
+				} else {
+					m.opsPanel.Info(fmt.Sprintf("Previewing %s (%s)...", file.Name, ui.FormatBytes(file.Size)))
+				}
+				return m, m.loadPreview(*file)
 
-							// Write password file with secure permissions (0400)
-							if err := os.WriteFile(passwordFilePath, []byte(generatedPassword), 0400); err != nil {
-								m.opsPanel.Error(fmt.Sprintf("Failed to write password file: %v", err))
-								return m, nil
-							}
+			case "esc":
+				// Close file browser
+				m.stopFileStream()
+				m.showFileBrowser = false
+				m.opsPanel.Info("Closed file browser")
+				return m, nil
 
-							m.opsPanel.Success(fmt.Sprintf("Created password file: %s", passwordFilePath))
-						} else {
-							// Use manually specified password file path
-							if password == "" {
-								m.opsPanel.Error("Password file path is required")
-								return m, nil
-							}
-							passwordFilePath = password
-						}
+			case "j", "down":
+				// Move down in file list
+				m.fileBrowser.MoveDown()
+				return m, nil
 
-						repoConfig.PasswordFile = passwordFilePath
+			case "k", "up":
+				// Move up in file list
+				m.fileBrowser.MoveUp()
+				return m, nil
 
-					case "command":
-						if password == "" {
-							m.opsPanel.Error("Password command is required")
-							return m, nil
-						}
-						repoConfig.PasswordCommand = password
-					}
+			case "h", "left":
+				// Go to parent directory
+				if m.fileBrowser.CanGoUp() {
+					m.fileBrowser.GoUp()
+					return m, m.loadFiles()
+				}
+				return m, nil
 
-					// Add to config
-					m.config.Repositories = append(m.config.Repositories, repoConfig)
+			case "n", "pgdown":
+				// Next page, loading the next window of a huge directory from the last one
+				if m.fileBrowser.OnLastPage() && m.fileBrowser.HasMore() {
+					return m, m.nextFileWindow()
+				}
+				m.fileBrowser.NextPage()
+				return m, nil
 
-					// Save config
-					if err := config.Save(m.config, ""); err != nil {
-						m.opsPanel.Error(fmt.Sprintf("Failed to save config: %v", err))
+			case "p", "pgup":
+				// Previous page, reloading the previous window from the first one
+				if m.fileBrowser.OnFirstPage() && m.fileBrowser.Offset() > 0 {
+					return m, m.prevFileWindow()
+				}
+				m.fileBrowser.PrevPage()
+				return m, nil
+
+			case "l", "right", "enter":
+				// Enter directory or do nothing for files
+				if newPath, entered := m.fileBrowser.EnterDirectory(); entered {
+					m.opsPanel.Info(fmt.Sprintf("Navigating to %s...", newPath))
+					return m, m.loadFiles()
+				}
+				return m, nil
+
+			case " ", "space":
+				// Toggle file selection
+				m.fileBrowser.ToggleSelection()
+				return m, nil
+
+			case "s":
+				// Cycle the listing order
+				m.opsPanel.Dimmed(fmt.Sprintf("Sorting files by %s", m.fileBrowser.CycleSort()))
+				return m, nil
+
+			case ".":
+				// Show or hide dotfiles
+				if m.fileBrowser.ToggleHidden() {
+					m.opsPanel.Dimmed("Hiding dotfiles")
+				} else {
+					m.opsPanel.Dimmed("Showing dotfiles")
+				}
+				return m, nil
+
+			case "r":
+				// Restore selected files
+				selectedFiles := m.fileBrowser.GetSelectedFiles()
+				if len(selectedFiles) == 0 {
+					m.opsPanel.Warning("No files selected - press Space to select files")
+					return m, nil
+				}
+
+				if m.restoreInProgress {
+					m.opsPanel.Warning("Restore already in progress")
+					return m, nil
+				}
+
+				// Create paths list from selected files
+				var paths []string
+				for _, file := range selectedFiles {
+					paths = append(paths, file.Path)
+				}
+
+				// Open restore form with selected paths pre-filled
+				m.openRestoreForm(m.fileBrowser.GetSnapshot())
+				m.prefillRestorePaths(paths)
+				m.showRestoreForm = true
+				m.stopFileStream()
+				m.showFileBrowser = false
+				m.opsPanel.Info(fmt.Sprintf("Restoring %d selected files...", len(paths)))
+				return m, nil
+			}
+		}
+
+		// Handle found repos selection
+		if m.showFoundRepos {
+			switch msg.String() {
+			case "esc":
+				// Close found repos list
+				m.showFoundRepos = false
+				m.foundRepos = nil
+				m.selectedFound = 0
+				m.opsPanel.Info("Cancelled repository selection")
+				return m, nil
+
+			case "j", "down":
+				// Move down in found repos list
+				if m.selectedFound < len(m.foundRepos)-1 {
+					m.selectedFound++
+				}
+				return m, nil
+
+			case "k", "up":
+				// Move up in found repos list
+				if m.selectedFound > 0 {
+					m.selectedFound--
+				}
+				return m, nil
+
+			case "enter":
+				// Add selected repo
+				if m.selectedFound >= 0 && m.selectedFound < len(m.foundRepos) {
+					selectedRepo := m.foundRepos[m.selectedFound]
+					m.showFoundRepos = false
+					m.foundRepos = nil
+					m.selectedFound = 0
+
+					// Open repo form pre-filled with the selected repo
+					m.repoForm = m.newRepoForm()
+					m.repoForm.SetPath(selectedRepo.Path)
+					m.repoForm.SetName(selectedRepo.Name)
+					m.showRepoForm = true
+					m.opsPanel.Info(fmt.Sprintf("Adding repository: %s", selectedRepo.Name))
+				}
+				return m, nil
+			}
+		}
+
+		// Handle batch forget confirmation dialog
+		if m.showForgetConfirm && m.forgetConfirmDialog != nil {
+			switch msg.String() {
+			case "esc":
+				m.showForgetConfirm = false
+				m.forgetConfirmDialog = nil
+				m.forgetIDs = nil
+				m.opsPanel.Info("Cancelled snapshot removal")
+				return m, nil
+
+			case "enter":
+				if m.forgetConfirmDialog.IsConfirmed() {
+					ids := m.forgetIDs
+					m.showForgetConfirm = false
+					m.forgetConfirmDialog = nil
+					m.forgetIDs = nil
+					if m.currentRepoIndex < len(m.config.Repositories) && !m.claimRepo(m.config.Repositories[m.currentRepoIndex].Name) {
 						return m, nil
 					}
+					m.forgetInProgress = true
+					m.opsPanel.Info(fmt.Sprintf("Forgetting %d snapshots...", len(ids)))
+					m.opsPanel.Dimmed(fmt.Sprintf("Command: restic forget %s", strings.Join(ids, " ")))
+					return m, tea.Batch(m.executeForgetByID(ids), m.pollLocks())
+				}
+				return m, nil
+			}
 
-					// Initialize repository if requested
-					if m.repoForm.ShouldInitialize() {
-						client := restic.NewClient(repoConfig)
-						if err := client.Init(); err != nil {
-							m.opsPanel.Error(fmt.Sprintf("Failed to initialize repository: %v", err))
+			cmd := m.forgetConfirmDialog.Update(msg)
+			return m, tea.Batch(cmd, scheduleConfirmTimeout(m.forgetConfirmDialog))
+		}
+
+		// Handle index repair confirmation dialog
+		if m.showRepairConfirm && m.repairConfirmDialog != nil {
+			switch msg.String() {
+			case "esc":
+				m.showRepairConfirm = false
+				m.repairConfirmDialog = nil
+				m.opsPanel.Info("Cancelled index repair")
+				return m, nil
+
+			case "enter":
+				if m.repairConfirmDialog.IsConfirmed() {
+					m.showRepairConfirm = false
+					m.repairConfirmDialog = nil
+					m.repairInProgress = true
+					m.opsPanel.Info(fmt.Sprintf("Repairing index of '%s'...", m.repositories[m.currentRepoIndex].Name))
+					m.opsPanel.Dimmed("Command: restic repair index (rebuild-index before restic 0.16)")
+					cmd := m.executeRepairIndex(m.operationContext())
+					return m, tea.Batch(cmd, m.pollLocks())
+				}
+				return m, nil
+			}
+
+			cmd := m.repairConfirmDialog.Update(msg)
+			return m, tea.Batch(cmd, scheduleConfirmTimeout(m.repairConfirmDialog))
+		}
+
+		// Handle migration confirmation dialog
+		if m.showMigrateConfirm && m.migrateConfirmDialog != nil {
+			switch msg.String() {
+			case "esc":
+				m.showMigrateConfirm = false
+				m.migrateConfirmDialog = nil
+				m.opsPanel.Info("Cancelled migration")
+				return m, nil
+
+			case "enter":
+				if m.migrateConfirmDialog.IsConfirmed() {
+					migration := m.migrations[m.migrationCursor]
+					m.showMigrateConfirm = false
+					m.migrateConfirmDialog = nil
+					m.migrateInProgress = true
+					m.opsPanel.Info(fmt.Sprintf("Applying migration %s to '%s'...", migration, m.migrationRepo))
+					m.opsPanel.Dimmed(fmt.Sprintf("Command: restic migrate %s", migration))
+					cmd := m.executeMigration(m.operationContext(), m.migrationRepo, migration)
+					return m, tea.Batch(cmd, m.pollLocks())
+				}
+				return m, nil
+			}
+
+			cmd := m.migrateConfirmDialog.Update(msg)
+			return m, tea.Batch(cmd, scheduleConfirmTimeout(m.migrateConfirmDialog))
+		}
+
+		// Handle typed restic command confirmation dialog
+		if m.showRawCommandConfirm && m.rawCommandConfirmDialog != nil {
+			switch msg.String() {
+			case "esc":
+				m.showRawCommandConfirm = false
+				m.rawCommandConfirmDialog = nil
+				m.rawCommand = nil
+				m.opsPanel.Info("Cancelled restic command")
+				return m, nil
+
+			case "enter":
+				if m.rawCommandConfirmDialog.IsConfirmed() {
+					args := m.rawCommand
+					m.showRawCommandConfirm = false
+					m.rawCommandConfirmDialog = nil
+					m.rawCommand = nil
+					cmd := m.runRawCommand(args)
+					return m, cmd
+				}
+				return m, nil
+			}
+
+			cmd := m.rawCommandConfirmDialog.Update(msg)
+			return m, tea.Batch(cmd, scheduleConfirmTimeout(m.rawCommandConfirmDialog))
+		}
+
+		// Handle available migrations list
+		if m.showMigrations {
+			switch msg.String() {
+			case "esc", "q":
+				m.showMigrations = false
+			case "up", "k":
+				if m.migrationCursor > 0 {
+					m.migrationCursor--
+				}
+			case "down", "j":
+				if m.migrationCursor < len(m.migrations)-1 {
+					m.migrationCursor++
+				}
+			case "enter":
+				migration := m.migrations[m.migrationCursor]
+				m.showMigrations = false
+				m.migrateConfirmDialog = ui.NewConfirmationDialog(
+					"MIGRATE REPOSITORY",
+					fmt.Sprintf("Apply migration %s to '%s'?\n\nrestic rewrites repository files and holds an exclusive lock\nuntil it finishes. Older restic versions may no longer be able\nto read the repository afterwards.", migration, m.migrationRepo),
+					"MIGRATE",
+				)
+				m.migrateConfirmDialog.SetSize(m.width*3/4, m.height*3/4)
+				m.migrateConfirmDialog.SetTimeoutSeconds(confirmTimeoutSeconds)
+				m.showMigrateConfirm = true
+				m.opsPanel.Warning(fmt.Sprintf("⚠️  Type 'MIGRATE' to apply %s", migration))
+				return m, scheduleConfirmTimeout(m.migrateConfirmDialog)
+			}
+			return m, nil
+		}
+
+		// Handle key add/remove confirmation dialog
+		if m.showKeyConfirm && m.keyConfirmDialog != nil {
+			switch msg.String() {
+			case "esc":
+				m.cancelKeyAction()
+				m.opsPanel.Info("Cancelled key change")
+				return m, nil
+
+			case "enter":
+				if m.keyConfirmDialog.IsConfirmed() {
+					cmd := m.executeKeyAction()
+					return m, cmd
+				}
+				return m, nil
+			}
+
+			cmd := m.keyConfirmDialog.Update(msg)
+			return m, tea.Batch(cmd, scheduleConfirmTimeout(m.keyConfirmDialog))
+		}
+
+		// Handle repository key list
+		if m.showKeys {
+			cmd := m.updateKeys(msg)
+			return m, cmd
+		}
+
+		// Handle the backup history overlay
+		if m.showBackupHistory {
+			switch msg.String() {
+			case "esc", "q", keys.History:
+				m.showBackupHistory = false
+				m.backupHistory = nil
+			}
+			return m, nil
+		}
+
+		// Handle the repository quick switch
+		if m.showQuickSwitch {
+			cmd := m.updateQuickSwitch(msg)
+			return m, cmd
+		}
+
+		// Handle re-run confirmation dialog
+		if m.showRerunConfirm && m.rerunConfirmDialog != nil {
+			switch msg.String() {
+			case "esc":
+				m.showRerunConfirm = false
+				m.rerunConfirmDialog = nil
+				m.rerunPending = nil
+				m.opsPanel.Info("Cancelled re-run")
+				return m, nil
+
+			case "enter":
+				if m.rerunConfirmDialog.IsConfirmed() {
+					last := *m.rerunPending
+					m.showRerunConfirm = false
+					m.rerunConfirmDialog = nil
+					m.rerunPending = nil
+					return m, m.startRerun(last)
+				}
+				return m, nil
+			}
+
+			cmd := m.rerunConfirmDialog.Update(msg)
+			return m, tea.Batch(cmd, scheduleConfirmTimeout(m.rerunConfirmDialog))
+		}
+
+		// Handle remove-all unlock confirmation dialog
+		if m.showUnlockAllConfirm && m.unlockAllConfirmDialog != nil {
+			switch msg.String() {
+			case "esc":
+				m.showUnlockAllConfirm = false
+				m.unlockAllConfirmDialog = nil
+				m.opsPanel.Info("Cancelled unlock --remove-all")
+				return m, nil
+
+			case "enter":
+				if m.unlockAllConfirmDialog.IsConfirmed() {
+					m.showUnlockAllConfirm = false
+					m.unlockAllConfirmDialog = nil
+					// An operation may have started while the dialog was open
+					if operation := m.runningOperation(); operation != "" {
+						m.opsPanel.Warning(fmt.Sprintf("Cancelled unlock --remove-all: the %s holds a live lock", operation))
+						return m, nil
+					}
+					repo := m.repositories[m.currentRepoIndex]
+					if !m.claimRepo(repo.Name) {
+						return m, nil
+					}
+					m.opsPanel.Warning(fmt.Sprintf("Removing ALL locks from '%s'...", repo.Name))
+					m.opsPanel.Dimmed(fmt.Sprintf("Command: restic -r %s unlock --remove-all", repo.Path))
+					return m, m.startOperation("unlock --remove-all", m.unlockRepository(true))
+				}
+				return m, nil
+			}
+
+			cmd := m.unlockAllConfirmDialog.Update(msg)
+			return m, tea.Batch(cmd, scheduleConfirmTimeout(m.unlockAllConfirmDialog))
+		}
+
+		// Handle repo form interactions
+		// Handle remove confirmation dialog
+		if m.showRemoveConfirm && m.removeConfirmDialog != nil {
+			switch msg.String() {
+			case "esc":
+				// Cancel removal
+				m.showRemoveConfirm = false
+				m.removeConfirmDialog = nil
+				m.repoToRemove = ""
+				m.opsPanel.Info("Cancelled repository removal")
+				return m, nil
+
+			case "enter":
+				// Check if user typed the confirmation word
+				if m.removeConfirmDialog.IsConfirmed() {
+					// Clearing the input also stops a pending timeout while the config is saved
+					m.removeConfirmDialog.Reset()
+					m.opsPanel.Info(fmt.Sprintf("✓ Confirmed - removing '%s' from configuration...", m.repoToRemove))
+					m.opsPanel.Dimmed("Updating configuration file...")
+					return m, m.removeRepository()
+				}
+				return m, nil
+			}
+
+			// Pass other keys to the dialog
+			cmd := m.removeConfirmDialog.Update(msg)
+			return m, tea.Batch(cmd, scheduleConfirmTimeout(m.removeConfirmDialog))
+		}
+
+		if m.showRepoForm && m.repoForm != nil {
+			switch msg.String() {
+			case "esc":
+				// Cancel repo creation
+				m.showRepoForm = false
+				m.repoForm = m.newRepoForm() // Reset form
+				m.opsPanel.Info("Cancelled repository creation")
+				return m, nil
+
+			case "enter":
+				// Submit form
+				if m.repoForm.GetFocusedField() == ui.FieldSubmit {
+					// Get form data
+					name := m.repoForm.GetName()
+					path := m.repoForm.GetPath()
+					passwordMethod := m.repoForm.GetPasswordMethod()
+					password := m.repoForm.GetPassword()
+
+					if name == "" || path == "" {
+						m.opsPanel.Error("Name and path are required")
+						return m, nil
+					}
+
+					// Create repository config
+					repoConfig := types.RepositoryConfig{
+						Name: name,
+						Path: path,
+					}
+
+					switch passwordMethod {
+					case "file":
+						var passwordFilePath string
+
+						// Auto-generate password file if requested
+						if m.repoForm.ShouldAutoGeneratePasswordFile() {
+							// Generate password file path
+							home, err := os.UserHomeDir()
+							if err != nil {
+								m.opsPanel.Error(fmt.Sprintf("Failed to get home directory: %v", err))
+								return m, nil
+							}
+
+							passwordDir := filepath.Join(home, ".config", "lazyrestic", "passwords")
+							passwordFilePath = filepath.Join(passwordDir, name+".txt")
+
+							// Create password directory if it doesn't exist
+							if err := os.MkdirAll(passwordDir, 0700); err != nil {
+								m.opsPanel.Error(fmt.Sprintf("Failed to create password directory: %v", err))
+								return m, nil
+							}
+
+							// Generate secure random password
+							generatedPassword, err := generateSecurePassword(32)
+							if err != nil {
+								m.opsPanel.Error(fmt.Sprintf("Failed to generate password: %v", err))
+								return m, nil
+							}
+
+							// Write password file with secure permissions (0400)
+							if err := os.WriteFile(passwordFilePath, []byte(generatedPassword), 0400); err != nil {
+								m.opsPanel.Error(fmt.Sprintf("Failed to write password file: %v", err))
+								return m, nil
+							}
+
+							m.opsPanel.Success(fmt.Sprintf("Created password file: %s", passwordFilePath))
+						} else {
+							// Use manually specified password file path
+							if password == "" {
+								m.opsPanel.Error("Password file path is required")
+								return m, nil
+							}
+							passwordFilePath = password
+						}
+
+						repoConfig.PasswordFile = passwordFilePath
+
+					case "command":
+						if password == "" {
+							m.opsPanel.Error("Password command is required")
+							return m, nil
+						}
+						repoConfig.PasswordCommand = password
+
+					case "env":
+						envName := strings.TrimSpace(password)
+						if envName == "" {
+							m.opsPanel.Error("Password environment variable name is required")
+							return m, nil
+						}
+						if _, ok := os.LookupEnv(envName); !ok {
+							m.opsPanel.Warning(fmt.Sprintf("Environment variable %s is not set in this session", envName))
+						}
+						repoConfig.PasswordEnv = envName
+					}
+
+					// Keep using the chosen compression for backups and prunes
+					if m.repoForm.ShouldInitialize() {
+						repoConfig.Compression = m.repoForm.GetCompression()
+					}
+
+					// Add to config
+					m.config.Repositories = append(m.config.Repositories, repoConfig)
+
+					// Save config
+					if err := config.Save(m.config, ""); err != nil {
+						m.opsPanel.Error(fmt.Sprintf("Failed to save config: %v", err))
+						return m, nil
+					}
+
+					// Initialize repository if requested
+					if m.repoForm.ShouldInitialize() {
+						client := m.newClient(repoConfig)
+						initOpts := types.InitOptions{
+							PackSize:          m.repoForm.GetPackSize(),
+							RepositoryVersion: m.repoForm.GetRepositoryVersion(),
+							Compression:       m.repoForm.GetCompression(),
+						}
+						if err := client.Init(initOpts); restic.IsAlreadyInitialized(err) {
+							m.opsPanel.Info(fmt.Sprintf("Repository '%s' already initialized, skipping", name))
+						} else if err != nil {
+							m.opsPanel.Error(fmt.Sprintf("Failed to initialize repository: %s", m.errorText(err)))
 							// Still close form since config was saved
 						} else {
 							m.opsPanel.Success(fmt.Sprintf("Repository '%s' created and initialized", name))
@@ -1030,139 +2573,691 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.opsPanel.Success(fmt.Sprintf("Added repository '%s'", name))
 					}
 
-					// Close form and refresh
-					m.showRepoForm = false
-					m.repoForm = ui.NewRepoForm() // Reset for next use
-					m.opsPanel.Info("Refreshing repository list...")
-					return m, m.loadRepositories
-				}
-				fallthrough
+					// Close form and refresh
+					m.showRepoForm = false
+					m.repoForm = m.newRepoForm() // Reset for next use
+					m.opsPanel.Info("Refreshing repository list...")
+					return m, m.loadRepositories
+				}
+				fallthrough
+
+			default:
+				// Let the form handle the key
+				cmd := m.repoForm.Update(msg)
+				return m, cmd
+			}
+		}
+
+		switch msg.String() {
+		case "ctrl+c", keys.Quit:
+			// Ask before abandoning a running restic process, which could leave a lock
+			if operation := m.runningOperation(); operation != "" {
+				m.showQuitConfirm = true
+				m.opsPanel.Warning(fmt.Sprintf("A %s is running - cancel it and quit? (y/n)", operation))
+				return m, nil
+			}
+			cmd := m.requestQuit()
+			return m, cmd
+
+		case keys.ExportLogs:
+			// Export the operations log for sharing
+			path := sessionLogPath(time.Now())
+			if err := m.opsPanel.ExportLogs(path); err != nil {
+				m.opsPanel.Error(fmt.Sprintf("Failed to export logs: %v", err))
+				return m, nil
+			}
+			m.opsPanel.Success(fmt.Sprintf("✓ Operations log exported to %s", path))
+			return m, nil
+
+		case keys.Help:
+			m.showHelp = true
+			return m, nil
+
+		case "tab", "l", "right":
+			// Cycle panels forward (4 panels: Repos, Metrics, Snapshots, Operations)
+			m.activePanel = (m.activePanel + 1) % 4
+			return m, nil
+
+		case "shift+tab", "h", "left":
+			// Cycle panels backward (4 panels: Repos, Metrics, Snapshots, Operations)
+			m.activePanel = (m.activePanel + 3) % 4
+			return m, nil
+
+		case "j", "down":
+			// Move down in active panel
+			switch m.activePanel {
+			case types.PanelRepositories:
+				m.repoPanel.MoveDown()
+				if m.repoPanel.GetSelected() == nil {
+					// Group header selected - keep the current repository
+					return m, nil
+				}
+				m.currentRepoIndex = m.GetSelected()
+				// Update metrics panel with newly selected repo
+				if m.currentRepoIndex < len(m.repositories) {
+					m.metricsPanel.SetRepository(&m.repositories[m.currentRepoIndex])
+				}
+				// Load snapshots for selected repo
+				return m, m.loadSnapshotsWithMessage()
+			case types.PanelSnapshots:
+				m.snapPanel.MoveDown()
+				m.logSelectedSnapshot()
+				return m, tea.Batch(m.fetchVisibleSnapshotSizes(), m.fetchSelectedSnapshotStats())
+			case types.PanelOperations:
+				m.opsPanel.ScrollDown(1)
+			}
+			return m, nil
+
+		case "k", "up":
+			// Move up in active panel
+			switch m.activePanel {
+			case types.PanelRepositories:
+				m.repoPanel.MoveUp()
+				if m.repoPanel.GetSelected() == nil {
+					// Group header selected - keep the current repository
+					return m, nil
+				}
+				m.currentRepoIndex = m.GetSelected()
+				// Update metrics panel with newly selected repo
+				if m.currentRepoIndex < len(m.repositories) {
+					m.metricsPanel.SetRepository(&m.repositories[m.currentRepoIndex])
+				}
+				return m, m.loadSnapshotsWithMessage()
+			case types.PanelSnapshots:
+				m.snapPanel.MoveUp()
+				m.logSelectedSnapshot()
+				return m, tea.Batch(m.fetchVisibleSnapshotSizes(), m.fetchSelectedSnapshotStats())
+			case types.PanelOperations:
+				m.opsPanel.ScrollUp(1)
+			}
+			return m, nil
+
+		case "pgup":
+			// Page back through the operations log
+			if m.activePanel == types.PanelOperations {
+				m.opsPanel.PageUp()
+			}
+			return m, nil
+
+		case "pgdown":
+			// Page forward through the operations log
+			if m.activePanel == types.PanelOperations {
+				m.opsPanel.PageDown()
+			}
+			return m, nil
+
+		case "enter":
+			// Action on selected item
+			if m.activePanel == types.PanelRepositories {
+				// Enter on a group header expands or collapses it
+				if _, ok := m.repoPanel.SelectedGroup(); ok {
+					m.repoPanel.ToggleGroup()
+					return m, m.followListedRepository()
+				}
+				// Offer to initialize repositories that don't exist yet
+				if m.currentRepoIndex < len(m.repositories) && m.repositories[m.currentRepoIndex].Status == "uninitialized" {
+					repo := m.repositories[m.currentRepoIndex]
+					m.opsPanel.Info(fmt.Sprintf("Initializing repository '%s'...", repo.Name))
+					m.opsPanel.Dimmed(fmt.Sprintf("Command: restic -r %s init", repo.Path))
+					return m, m.initRepository()
+				}
+				if m.currentRepoIndex < len(m.repositories) {
+					m.noteRecentRepo(m.repositories[m.currentRepoIndex].Name)
+				}
+				return m, m.loadSnapshotsWithMessage()
+			}
+			// Open file browser for selected snapshot
+			if m.activePanel == types.PanelSnapshots {
+				selectedSnapshot := m.snapPanel.GetSelected()
+				if selectedSnapshot != nil {
+					m.fileBrowser = ui.NewFileBrowser(selectedSnapshot)
+					m.fileBrowser.SetSize(m.width*2/3, m.height*2/3)
+					m.showFileBrowser = true
+					m.opsPanel.Info(fmt.Sprintf("Browsing snapshot %s...", selectedSnapshot.ShortID))
+					return m, m.loadFiles()
+				}
+			}
+			return m, nil
+
+		case keys.Add:
+			// Add new repository (only in repositories panel)
+			if m.activePanel == types.PanelRepositories {
+				m.showRepoForm = true
+				m.opsPanel.Info("Add new repository")
+				return m, nil
+			}
+			return m, nil
+
+		case keys.Scan:
+			// Scan for repositories (only in repositories panel)
+			if m.activePanel == types.PanelRepositories {
+				m.opsPanel.Info("Scanning for repositories...")
+				return m, m.scanForRepositories()
+			}
+			return m, nil
+
+		case keys.Refresh:
+			// Refresh
+			if m.currentRepoIndex < len(m.repositories) && m.warnRepoBusy(m.repositories[m.currentRepoIndex].Name) {
+				return m, nil
+			}
+			m.autoRefreshing = false
+			m.opsPanel.Info("Refreshing repositories and snapshots...")
+			m.opsPanel.Dimmed("Reloading configuration and rescanning repository stats")
+			return m, tea.Batch(m.loadRepositories, m.loadSnapshotsWithMessage(), m.resetAutoRefresh())
+
+		case keys.Export:
+			// Export the listed snapshots (respecting the active filter) as JSON
+			if m.currentRepoIndex >= len(m.repositories) {
+				m.opsPanel.Warning("No repository selected")
+				return m, nil
+			}
+			snapshots := m.snapPanel.VisibleSnapshots()
+			if len(snapshots) == 0 {
+				m.opsPanel.Warning("No snapshots to export")
+				return m, nil
+			}
+			path := snapshotExportPath(m.repositories[m.currentRepoIndex].Name, time.Now())
+			if err := ExportSnapshots(path, snapshots); err != nil {
+				m.opsPanel.Error(fmt.Sprintf("Failed to export snapshots: %v", err))
+				return m, nil
+			}
+			m.opsPanel.Success(fmt.Sprintf("✓ Exported %d snapshots to %s", len(snapshots), path))
+			if m.snapPanel.IsFilterActive() || m.snapPanel.ServerFilter() != "" {
+				m.opsPanel.Dimmed("Only snapshots matching the active filter were exported")
+			}
+			return m, nil
+
+		case keys.EditConfig:
+			// Edit the config file in $EDITOR, or copy its path without one
+			if m.isBusy() {
+				m.opsPanel.Warning("Another operation is in progress - try again when it finishes")
+				return m, nil
+			}
+			return m, m.editConfig()
+
+		case keys.CopyID:
+			// Copy the selected snapshot's full ID to the clipboard
+			if m.activePanel != types.PanelSnapshots {
+				return m, nil
+			}
+			snapshot := m.snapPanel.GetSelected()
+			if snapshot == nil {
+				m.opsPanel.Warning("No snapshot selected")
+				return m, nil
+			}
+			clip := m.clipboard
+			if clip == nil {
+				clip = osc52Clipboard{}
+			}
+			if err := clip.Copy(snapshot.ID); err != nil {
+				m.opsPanel.Warning(fmt.Sprintf("Could not copy to clipboard: %v", err))
+				m.opsPanel.Info(fmt.Sprintf("Snapshot ID: %s", snapshot.ID))
+				return m, nil
+			}
+			m.opsPanel.Success(fmt.Sprintf("Copied snapshot ID %s to clipboard", snapshot.ShortID))
+			return m, nil
+
+		case keys.RefreshRepo:
+			// Targeted refresh of the selected repository only
+			if m.currentRepoIndex >= len(m.repositories) {
+				m.opsPanel.Warning("No repository selected to refresh")
+				return m, nil
+			}
+			repo := m.repositories[m.currentRepoIndex]
+			if m.warnRepoBusy(repo.Name) {
+				return m, nil
+			}
+			m.opsPanel.Info(fmt.Sprintf("Refreshing only '%s' (other repositories unchanged)...", repo.Name))
+			return m, tea.Batch(m.loadSelectedRepositoryInfo(), m.loadSnapshotsWithMessage())
+
+		case keys.Sizes:
+			// Toggle the on-disk size column in the snapshots panel
+			if m.snapPanel.ToggleSizes() {
+				m.opsPanel.Info("Showing snapshot sizes (computed in background for visible snapshots)")
+				m.opsPanel.Dimmed("Command: restic stats --json --mode raw-data <snapshot>")
+				return m, m.fetchVisibleSnapshotSizes()
+			}
+			m.opsPanel.Dimmed("Snapshot sizes hidden")
+			return m, nil
+
+		case keys.PauseRefresh:
+			// Pause/resume auto-refresh
+			if m.config.AutoRefresh <= 0 {
+				m.opsPanel.Dimmed("Auto-refresh is disabled (set auto_refresh in config)")
+				return m, nil
+			}
+			m.autoRefreshPaused = !m.autoRefreshPaused
+			if m.autoRefreshPaused {
+				m.opsPanel.Warning("⏸ Auto-refresh paused - press 'p' to resume")
+				return m, nil
+			}
+			m.opsPanel.Info(fmt.Sprintf("▶ Auto-refresh resumed (every %s)", m.config.AutoRefresh))
+			return m, m.resetAutoRefresh()
+
+		case keys.Cache:
+			// Cache cleanup
+			if m.currentRepoIndex >= len(m.repositories) {
+				m.opsPanel.Warning("No repository selected for cache cleanup")
+				return m, nil
+			}
+			if m.operationInProgress != "" {
+				m.opsPanel.Warning(fmt.Sprintf("Wait for %s to finish", m.operationInProgress))
+				return m, nil
+			}
+			repo := m.repositories[m.currentRepoIndex]
+			if !m.claimRepo(repo.Name) {
+				return m, nil
+			}
+			m.opsPanel.Info(fmt.Sprintf("Running cache cleanup for '%s'...", repo.Name))
+			m.opsPanel.Dimmed(fmt.Sprintf("Command: restic -r %s cache --cleanup", repo.Path))
+			return m, m.startOperation("cache cleanup", m.cleanupCache())
+
+		case keys.RetrySnapshots:
+			// Retry a failed snapshot load
+			if m.snapshotLoadError == nil {
+				m.opsPanel.Dimmed("Nothing to retry - the last snapshot load succeeded")
+				return m, nil
+			}
+			if m.loadingSnapshots {
+				m.opsPanel.Warning("Snapshots are already loading")
+				return m, nil
+			}
+			return m, m.retrySnapshotLoad()
+
+		case keys.VerboseErrors:
+			// Toggle between short explanations and restic's raw output
+			m.verboseErrors = !m.verboseErrors
+			if m.verboseErrors {
+				m.opsPanel.Info("Verbose errors on - failures show restic's full output")
+			} else {
+				m.opsPanel.Info("Verbose errors off - failures show a short explanation")
+			}
+			return m, nil
+
+		case keys.Rerun:
+			// Re-run the most recent restic command
+			if m.isBusy() {
+				m.opsPanel.Warning("Wait for the current operation to finish before re-running")
+				return m, nil
+			}
+			last, ok := restic.LastCommand()
+			if !ok {
+				m.opsPanel.Warning("No restic command has run yet")
+				return m, nil
+			}
+			if verb := restic.MutatingVerb(last.Args); verb != "" {
+				// Re-running may change the repository, so confirm like the original flow
+				word := strings.ToUpper(verb)
+				m.rerunPending = &last
+				m.rerunConfirmDialog = ui.NewConfirmationDialog(
+					"RE-RUN RESTIC COMMAND",
+					fmt.Sprintf("Run this command again?\n\n%s\n\n%s may change the repository and can't be undone from LazyRestic.", last, verb),
+					word,
+				)
+				m.rerunConfirmDialog.SetSize(m.width*3/4, m.height*3/4)
+				m.rerunConfirmDialog.SetTimeoutSeconds(confirmTimeoutSeconds)
+				m.showRerunConfirm = true
+				m.opsPanel.Warning(fmt.Sprintf("⚠️  Type '%s' to re-run restic %s", word, verb))
+				return m, scheduleConfirmTimeout(m.rerunConfirmDialog)
+			}
+			return m, m.startRerun(last)
+
+		case keys.Unlock:
+			// Unlock repository
+			if m.currentRepoIndex >= len(m.repositories) {
+				m.opsPanel.Warning("No repository selected for unlock")
+				return m, nil
+			}
+			if m.operationInProgress != "" {
+				m.opsPanel.Warning(fmt.Sprintf("Wait for %s to finish", m.operationInProgress))
+				return m, nil
+			}
+			repo := m.repositories[m.currentRepoIndex]
+			if !m.claimRepo(repo.Name) {
+				return m, nil
+			}
+			m.opsPanel.Info(fmt.Sprintf("Unlocking repository '%s'...", repo.Name))
+			m.opsPanel.Dimmed(fmt.Sprintf("Removing stale locks from: %s", repo.Path))
+			m.opsPanel.Dimmed(fmt.Sprintf("Command: restic -r %s unlock", repo.Path))
+			return m, m.startOperation("unlock", m.unlockRepository(false))
+
+		case keys.UnlockAll:
+			// Remove every lock, including live ones, after a typed confirmation
+			if m.currentRepoIndex >= len(m.repositories) {
+				m.opsPanel.Warning("No repository selected for unlock")
+				return m, nil
+			}
+			// Removing live locks would pull them out from under LazyRestic's own backup or restore
+			if operation := m.runningOperation(); operation != "" {
+				m.opsPanel.Warning(fmt.Sprintf("Wait for the %s to finish - it holds a live lock", operation))
+				return m, nil
+			}
+			repo := m.repositories[m.currentRepoIndex]
+			m.unlockAllConfirmDialog = ui.NewConfirmationDialog(
+				"REMOVE ALL LOCKS",
+				fmt.Sprintf("Remove ALL locks from '%s'?\n\nPath: %s\n\nUnlike %s, this also removes locks held by restic processes\nthat are still running. Only do this when you are sure no\nbackup, prune or check is using the repository.", repo.Name, repo.Path, keyLabel(keys.Unlock)),
+				"UNLOCK",
+			)
+			m.unlockAllConfirmDialog.SetSize(m.width*3/4, m.height*3/4)
+			m.unlockAllConfirmDialog.SetTimeoutSeconds(confirmTimeoutSeconds)
+			m.showUnlockAllConfirm = true
+			m.opsPanel.Warning("⚠️  Type 'UNLOCK' to remove all locks")
+			return m, scheduleConfirmTimeout(m.unlockAllConfirmDialog)
+
+		case keys.Verify:
+			// Verify repository data (only in repositories panel)
+			if m.activePanel != types.PanelRepositories {
+				return m, nil
+			}
+			if m.currentRepoIndex >= len(m.repositories) {
+				m.opsPanel.Warning("No repository selected for data check")
+				return m, nil
+			}
+			if m.checkInProgress {
+				m.opsPanel.Warning("Data check already in progress")
+				return m, nil
+			}
+			m.checkPromptActive = true
+			m.checkPromptText = "5%"
+			return m, nil
+
+		case keys.ToggleGroup:
+			// Collapse or expand the selected repository group
+			if m.activePanel != types.PanelRepositories {
+				return m, nil
+			}
+			if !m.repoPanel.ToggleGroup() {
+				m.opsPanel.Dimmed("No repository groups configured - set 'group' on repositories in the config")
+				return m, nil
+			}
+			return m, m.followListedRepository()
+
+		case keys.OverdueFirst:
+			// List repositories overdue for their backup schedule first
+			if m.activePanel != types.PanelRepositories {
+				return m, nil
+			}
+			if m.repoPanel.ToggleOverdueFirst() {
+				m.opsPanel.Info("Listing overdue repositories first")
+			} else {
+				m.opsPanel.Dimmed("Listing repositories in config order")
+			}
+			return m, nil
+
+		case keys.Repair:
+			// Repair the index of the selected repository (only in repositories panel)
+			if m.activePanel != types.PanelRepositories {
+				return m, nil
+			}
+			if m.currentRepoIndex >= len(m.repositories) {
+				m.opsPanel.Warning("No repository selected to repair")
+				return m, nil
+			}
+			if m.isBusy() {
+				m.opsPanel.Warning("Another operation is in progress - try again when it finishes")
+				return m, nil
+			}
+			repo := m.repositories[m.currentRepoIndex]
+			m.repairConfirmDialog = ui.NewConfirmationDialog(
+				"REPAIR INDEX",
+				fmt.Sprintf("Rebuild the index of '%s'?\n\nPath: %s\n\nrestic reads every pack file and writes a new index.\nThis can take a long time on large or remote repositories\nand holds an exclusive lock until it finishes.", repo.Name, repo.Path),
+				"REPAIR",
+			)
+			m.repairConfirmDialog.SetSize(m.width*3/4, m.height*3/4)
+			m.repairConfirmDialog.SetTimeoutSeconds(confirmTimeoutSeconds)
+			m.showRepairConfirm = true
+			m.opsPanel.Warning("⚠️  Type 'REPAIR' to rebuild the repository index")
+			return m, scheduleConfirmTimeout(m.repairConfirmDialog)
+
+		case keys.Command:
+			// Run a restic subcommand typed at a prompt against the selected repository
+			if m.currentRepoIndex >= len(m.config.Repositories) {
+				m.opsPanel.Warning("No repository selected to run a command against")
+				return m, nil
+			}
+			if m.isBusy() {
+				m.opsPanel.Warning("Another operation is in progress - try again when it finishes")
+				return m, nil
+			}
+			m.commandPromptActive = true
+			m.commandPromptText = ""
+			return m, nil
+
+		case keys.Migrate:
+			// List migrations available for the selected repository (only in repositories panel)
+			if m.activePanel != types.PanelRepositories {
+				return m, nil
+			}
+			if m.currentRepoIndex >= len(m.repositories) {
+				m.opsPanel.Warning("No repository selected to migrate")
+				return m, nil
+			}
+			if m.isBusy() {
+				m.opsPanel.Warning("Another operation is in progress - try again when it finishes")
+				return m, nil
+			}
+			m.opsPanel.Info(fmt.Sprintf("Checking available migrations for '%s'...", m.repositories[m.currentRepoIndex].Name))
+			return m, m.listMigrations()
+
+		case keys.History:
+			// Show the recorded backups of the selected repository
+			if m.currentRepoIndex >= len(m.repositories) {
+				m.opsPanel.Warning("No repository selected to show the backup history of")
+				return m, nil
+			}
+			m.openBackupHistory(m.repositories[m.currentRepoIndex].Name)
+			return m, nil
+
+		case keys.QuickSwitch:
+			// Jump to a repository by typing part of its name
+			if len(m.repositories) == 0 {
+				m.opsPanel.Warning("No repositories to switch to")
+				return m, nil
+			}
+			m.openQuickSwitch()
+			return m, nil
 
-			default:
-				// Let the form handle the key
-				cmd := m.repoForm.Update(msg)
-				return m, cmd
+		case keys.Keys:
+			// List the selected repository's access keys (only in repositories panel)
+			if m.activePanel != types.PanelRepositories {
+				return m, nil
 			}
-		}
+			if m.currentRepoIndex >= len(m.repositories) {
+				m.opsPanel.Warning("No repository selected to manage keys")
+				return m, nil
+			}
+			m.opsPanel.Info(fmt.Sprintf("Listing keys of '%s'...", m.repositories[m.currentRepoIndex].Name))
+			m.keyCursor = 0
+			return m, m.listKeys()
 
-		switch msg.String() {
-		case "ctrl+c", "q":
-			return m, tea.Quit
+		case keys.RepoConfig:
+			// Show the selected repository's config blob (only in repositories panel)
+			if m.activePanel != types.PanelRepositories {
+				return m, nil
+			}
+			if m.currentRepoIndex >= len(m.repositories) {
+				m.opsPanel.Warning("No repository selected")
+				return m, nil
+			}
+			m.opsPanel.Dimmed(fmt.Sprintf("Command: restic -r %s cat config", m.repositories[m.currentRepoIndex].Path))
+			return m, m.loadRepoConfig()
 
-		case "?":
-			m.showHelp = true
+		case keys.Totals:
+			// Show totals across all repositories
+			if len(m.repositories) == 0 {
+				m.opsPanel.Warning("No repositories loaded")
+				return m, nil
+			}
+			m.showTotals = true
 			return m, nil
 
-		case "tab", "l", "right":
-			// Cycle panels forward (4 panels: Repos, Metrics, Snapshots, Operations)
-			m.activePanel = (m.activePanel + 1) % 4
-			return m, nil
+		case keys.Duplicates:
+			// Measure duplicate files in the selected repository's latest snapshot
+			if m.currentRepoIndex >= len(m.repositories) {
+				m.opsPanel.Warning("No repository selected")
+				return m, nil
+			}
+			repo := m.repositories[m.currentRepoIndex]
+			if !m.claimRepo(repo.Name) {
+				return m, nil
+			}
+			m.opsPanel.Info(fmt.Sprintf("Measuring duplicate files in the latest snapshot of '%s' (reads every file, may take a while)...", repo.Name))
+			return m, m.fetchDuplicateStats()
 
-		case "shift+tab", "h", "left":
-			// Cycle panels backward (4 panels: Repos, Metrics, Snapshots, Operations)
-			m.activePanel = (m.activePanel + 3) % 4
-			return m, nil
+		case keys.Diagnostics:
+			// Check restic, the config file and every repository
+			m.opsPanel.Info("Running diagnostics...")
+			return m, m.runDiagnosticsCmd()
 
-		case "j", "down":
-			// Move down in active panel
-			switch m.activePanel {
-			case types.PanelRepositories:
-				m.repoPanel.MoveDown()
-				m.currentRepoIndex = m.GetSelected()
-				// Update metrics panel with newly selected repo
-				if m.currentRepoIndex < len(m.repositories) {
-					m.metricsPanel.SetRepository(&m.repositories[m.currentRepoIndex])
-				}
-				// Load snapshots for selected repo
-				return m, m.loadSnapshotsWithMessage()
-			case types.PanelSnapshots:
-				m.snapPanel.MoveDown()
-				m.logSelectedSnapshot()
+		case keys.SnapshotInfo:
+			// Show the selected snapshot's details (only in snapshots panel)
+			if m.activePanel != types.PanelSnapshots {
+				return m, nil
 			}
-			return m, nil
+			if m.snapPanel.GetSelected() == nil {
+				m.opsPanel.Warning("No snapshot selected")
+				return m, nil
+			}
+			m.showSnapshotInfo = true
+			return m, m.fetchSelectedSnapshotStats()
+
+		case keys.Mount:
+			// Mount the selected repository with FUSE, or unmount the running mount
+			if m.mountCmd != nil {
+				m.opsPanel.Info(fmt.Sprintf("Unmounting '%s' from %s...", m.mountRepo, m.mountPoint))
+				return m, m.unmountRepository()
+			}
+			if m.currentRepoIndex >= len(m.repositories) {
+				m.opsPanel.Warning("No repository selected to mount")
+				return m, nil
+			}
+			if m.operationInProgress != "" {
+				m.opsPanel.Warning(fmt.Sprintf("Wait for %s to finish", m.operationInProgress))
+				return m, nil
+			}
+			repo := m.repositories[m.currentRepoIndex]
+			m.opsPanel.Info(fmt.Sprintf("Mounting '%s'...", repo.Name))
+			m.opsPanel.Dimmed(fmt.Sprintf("Command: restic -r %s mount <temporary directory>", repo.Path))
+			return m, m.startOperation("mount", m.mountRepository())
 
-		case "k", "up":
-			// Move up in active panel
-			switch m.activePanel {
-			case types.PanelRepositories:
-				m.repoPanel.MoveUp()
-				m.currentRepoIndex = m.GetSelected()
-				// Update metrics panel with newly selected repo
-				if m.currentRepoIndex < len(m.repositories) {
-					m.metricsPanel.SetRepository(&m.repositories[m.currentRepoIndex])
-				}
-				return m, m.loadSnapshotsWithMessage()
-			case types.PanelSnapshots:
-				m.snapPanel.MoveUp()
-				m.logSelectedSnapshot()
+		case keys.Find:
+			// Find a file across all snapshots of the selected repository
+			if m.currentRepoIndex >= len(m.repositories) {
+				m.opsPanel.Warning("No repository selected to search")
+				return m, nil
+			}
+			if m.findInProgress {
+				m.opsPanel.Warning("Find already in progress")
+				return m, nil
 			}
+			m.findPromptActive = true
+			m.findPromptText = ""
 			return m, nil
 
-		case "enter":
-			// Action on selected item
-			if m.activePanel == types.PanelRepositories {
-				return m, m.loadSnapshotsWithMessage()
-			}
-			// Open file browser for selected snapshot
+		case keys.Mark:
+			// Mark/unmark the selected snapshot for batch forget
 			if m.activePanel == types.PanelSnapshots {
-				selectedSnapshot := m.snapPanel.GetSelected()
-				if selectedSnapshot != nil {
-					m.fileBrowser = ui.NewFileBrowser(selectedSnapshot)
-					m.fileBrowser.SetSize(m.width*2/3, m.height*2/3)
-					m.showFileBrowser = true
-					m.opsPanel.Info(fmt.Sprintf("Browsing snapshot %s...", selectedSnapshot.ShortID))
-					return m, m.loadFiles
-				}
+				m.snapPanel.ToggleMark()
 			}
 			return m, nil
 
-		case "a":
-			// Add new repository (only in repositories panel)
-			if m.activePanel == types.PanelRepositories {
-				m.showRepoForm = true
-				m.opsPanel.Info("Add new repository")
+		case keys.CompareMark:
+			// Mark the selected snapshot as one side of a comparison
+			if m.activePanel != types.PanelSnapshots {
 				return m, nil
 			}
-			return m, nil
-
-		case "s":
-			// Scan for repositories (only in repositories panel)
-			if m.activePanel == types.PanelRepositories {
-				m.opsPanel.Info("Scanning for repositories...")
-				return m, m.scanForRepositories()
+			snap := m.snapPanel.GetSelected()
+			if snap == nil {
+				return m, nil
+			}
+			switch m.snapPanel.MarkForCompare() {
+			case 0:
+				m.opsPanel.Dimmed(fmt.Sprintf("Unmarked %.8s for compare", snap.ID))
+			case 1:
+				m.opsPanel.Info(fmt.Sprintf("Marked %.8s as ① for compare - mark a second snapshot", snap.ID))
+			default:
+				a, b := m.snapPanel.GetComparePair()
+				m.opsPanel.Info(fmt.Sprintf("Compare pair: ① %.8s ② %.8s", a, b))
 			}
 			return m, nil
 
-		case "r":
-			// Refresh
-			m.opsPanel.Info("Refreshing repositories and snapshots...")
-			m.opsPanel.Dimmed("Reloading configuration and rescanning repository stats")
-			return m, tea.Batch(m.loadRepositories, m.loadSnapshotsWithMessage())
+		case keys.Protect:
+			// Toggle the protected tag that forget policies always keep
+			if m.activePanel != types.PanelSnapshots {
+				return m, nil
+			}
+			snapshot := m.snapPanel.GetSelected()
+			if snapshot == nil {
+				m.opsPanel.Warning("No snapshot selected")
+				return m, nil
+			}
+			if m.isBusy() {
+				m.opsPanel.Warning("Another operation is in progress - try again when it finishes")
+				return m, nil
+			}
+			if m.currentRepoIndex >= len(m.config.Repositories) || !m.claimRepo(m.config.Repositories[m.currentRepoIndex].Name) {
+				return m, nil
+			}
+			protect := !restic.IsProtected(*snapshot)
+			name, flag := "unprotect", "--remove"
+			if protect {
+				name, flag = "protect", "--add"
+			}
+			m.opsPanel.Info(fmt.Sprintf("Changing protection of snapshot %s...", snapshot.ShortID))
+			m.opsPanel.Dimmed(fmt.Sprintf("Command: restic tag %s %s %s", flag, restic.ProtectedTag, snapshot.ShortID))
+			return m, m.startOperation(name, m.setSnapshotProtected(*snapshot, protect))
 
-		case "C":
-			// Cache cleanup
-			if m.currentRepoIndex >= len(m.repositories) {
-				m.opsPanel.Warning("No repository selected for cache cleanup")
+		case keys.Forget:
+			// Forget the marked snapshots by ID
+			if m.activePanel != types.PanelSnapshots {
+				return m, nil
+			}
+			marked := m.snapPanel.MarkedSnapshots()
+			if len(marked) == 0 {
+				m.opsPanel.Warning("No snapshots marked - press Space to mark snapshots")
+				return m, nil
+			}
+			for _, snap := range marked {
+				if restic.IsProtected(snap) {
+					m.opsPanel.Warning(fmt.Sprintf("Snapshot %s is protected - press %s to unprotect it before forgetting", snap.ShortID, keyLabel(keys.Protect)))
+					return m, nil
+				}
+			}
+			if m.isBusy() {
+				m.opsPanel.Warning("Another operation is in progress - try again when it finishes")
 				return m, nil
 			}
-			repo := m.repositories[m.currentRepoIndex]
-			m.opsPanel.Info(fmt.Sprintf("Running cache cleanup for '%s'...", repo.Name))
-			m.opsPanel.Dimmed(fmt.Sprintf("Command: restic -r %s cache --cleanup", repo.Path))
-			return m, m.cleanupCache()
 
-		case "u":
-			// Unlock repository
+			var lines []string
+			m.forgetIDs = nil
+			for _, snap := range marked {
+				m.forgetIDs = append(m.forgetIDs, snap.ID)
+				lines = append(lines, fmt.Sprintf("  %s  %s  %s  %s", snap.ShortID, snap.Time.Format("2006-01-02 15:04"), snap.Hostname, strings.Join(snap.Paths, ", ")))
+			}
+			m.forgetConfirmDialog = ui.NewConfirmationDialog(
+				"FORGET SNAPSHOTS",
+				fmt.Sprintf("You are about to forget %d snapshots:\n\n%s\n\nRun prune afterwards to free the space they use.\nThis operation CANNOT be undone!", len(marked), strings.Join(lines, "\n")),
+				"DELETE",
+			)
+			m.forgetConfirmDialog.SetSize(m.width*3/4, m.height*3/4)
+			m.forgetConfirmDialog.SetTimeoutSeconds(confirmTimeoutSeconds)
+			m.showForgetConfirm = true
+			m.opsPanel.Warning(fmt.Sprintf("⚠️  Type 'DELETE' to forget %d marked snapshots", len(marked)))
+			return m, scheduleConfirmTimeout(m.forgetConfirmDialog)
+
+		case keys.Rename:
+			// Rename the selected repository, starting from its current name
 			if m.currentRepoIndex >= len(m.repositories) {
-				m.opsPanel.Warning("No repository selected for unlock")
+				m.opsPanel.Warning("No repository selected to rename")
 				return m, nil
 			}
-			repo := m.repositories[m.currentRepoIndex]
-			m.opsPanel.Info(fmt.Sprintf("Unlocking repository '%s'...", repo.Name))
-			m.opsPanel.Dimmed(fmt.Sprintf("Removing stale locks from: %s", repo.Path))
-			m.opsPanel.Dimmed(fmt.Sprintf("Command: restic -r %s unlock", repo.Path))
-			return m, m.unlockRepository()
+			m.repoToRename = m.repositories[m.currentRepoIndex].Name
+			m.renamePromptText = m.repoToRename
+			m.renamePromptActive = true
+			return m, nil
 
-		case "x":
+		case keys.Remove:
 			// Remove repository from LazyRestic config
 			if m.currentRepoIndex >= len(m.repositories) {
 				m.opsPanel.Warning("No repository selected to remove")
@@ -1176,18 +3271,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				"yes",
 			)
 			m.removeConfirmDialog.SetSize(m.width*3/4, m.height*3/4)
+			m.removeConfirmDialog.SetTimeoutSeconds(confirmTimeoutSeconds)
 			m.showRemoveConfirm = true
 			m.opsPanel.Success("─────────────────────────────────────────────────────────")
 			m.opsPanel.Info(fmt.Sprintf("Removal requested for repository: %s", repo.Name))
 			m.opsPanel.Dimmed(fmt.Sprintf("Path: %s", repo.Path))
 			m.opsPanel.Warning("⚠️  Type 'yes' to confirm removal from configuration")
 			m.opsPanel.Success("─────────────────────────────────────────────────────────")
-			return m, nil
+			return m, scheduleConfirmTimeout(m.removeConfirmDialog)
 
-		case "b":
+		case keys.Backup:
 			// Show backup form (only if a repository is selected and not already backing up)
 			if !m.backupInProgress && len(m.repositories) > 0 {
-				m.showBackupForm = true
+				m.openBackupForm()
 				return m, nil
 			} else if m.backupInProgress {
 				m.opsPanel.Warning("Backup already in progress")
@@ -1196,12 +3292,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 
-		case "R":
+		case keys.QuickBackup:
+			// Back up the selected repository's default paths without the form
+			if m.backupInProgress {
+				m.opsPanel.Warning("Backup already in progress")
+				return m, nil
+			}
+			if len(m.repositories) == 0 || m.currentRepoIndex >= len(m.config.Repositories) {
+				m.opsPanel.Warning("No repository selected")
+				return m, nil
+			}
+			repoConfig := m.config.Repositories[m.currentRepoIndex]
+			m.openBackupForm()
+			if len(repoConfig.DefaultPaths) == 0 {
+				m.opsPanel.Dimmed(fmt.Sprintf("No default_paths configured for %s - opening the backup form", repoConfig.Name))
+				return m, nil
+			}
+			if err := m.backupForm.Validate(); err != nil {
+				m.opsPanel.Warning(fmt.Sprintf("Cannot start backup with defaults: %v", err))
+				return m, nil
+			}
+			m.showBackupForm = false
+			m.opsPanel.Dimmed(fmt.Sprintf("Using default paths: %s", strings.Join(repoConfig.DefaultPaths, ", ")))
+			return m.startBackup(m.backupFormOptions())
+
+		case keys.Restore:
 			// Show restore form (only if a snapshot is selected and not already restoring)
 			selectedSnapshot := m.snapPanel.GetSelected()
 			if !m.restoreInProgress && selectedSnapshot != nil {
-				m.restoreForm = ui.NewRestoreForm(selectedSnapshot)
-				m.restoreForm.SetSize(m.width*2/3, m.height*2/3)
+				m.openRestoreForm(selectedSnapshot)
 				m.showRestoreForm = true
 				return m, nil
 			} else if m.restoreInProgress {
@@ -1211,9 +3330,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 
-		case "/":
-			// Enter filter mode (only when snapshot panel is active)
-			if m.activePanel == types.PanelSnapshots {
+		case keys.Sandbox:
+			// Restore the selected snapshot into a temporary directory for inspection
+			return m.restoreToSandbox()
+
+		case keys.Filter:
+			// Enter filter mode (snapshots or operations panel)
+			if m.activePanel == types.PanelSnapshots || m.activePanel == types.PanelOperations {
 				m.filterInputActive = true
 				m.filterInputText = ""
 				m.opsPanel.Info("Filter mode: type to search, Enter to confirm, Esc to cancel")
@@ -1221,29 +3344,119 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 
-		case "esc":
-			// Clear filter if active and not in input mode
-			if m.activePanel == types.PanelSnapshots && m.snapPanel.IsFilterActive() {
-				m.snapPanel.ClearFilter()
-				m.opsPanel.Info("Filter cleared")
+		case keys.TagFilter, keys.HostFilter:
+			// Edit a single snapshot filter facet; an empty value clears just that facet
+			if m.activePanel != types.PanelSnapshots || m.snapPanel == nil {
 				return m, nil
 			}
+			m.filterFacet = "tag"
+			m.filterFacetPrev = m.snapPanel.TagFilter()
+			if msg.String() == keys.HostFilter {
+				m.filterFacet = "host"
+				m.filterFacetPrev = m.snapPanel.HostFilter()
+			}
+			m.filterInputActive = true
+			m.filterInputText = m.filterFacetPrev
+			m.opsPanel.Info(fmt.Sprintf("%s filter: type to match, Enter to confirm (empty clears it), Esc to cancel", facetLabel(m.filterFacet)))
 			return m, nil
 
-		case "c":
-			// Alternative shortcut to clear filter
-			if m.activePanel == types.PanelSnapshots && m.snapPanel.IsFilterActive() {
+		case "esc", keys.ClearFilter:
+			// Clear filter if active and not in input mode ('c' is an alternative shortcut)
+			if m.activePanel == types.PanelSnapshots && (m.snapPanel.IsFilterActive() || m.snapshotQuery.serverSide()) {
 				m.snapPanel.ClearFilter()
 				m.opsPanel.Info("Filter cleared")
+				return m, m.setSnapshotQuery(snapshotQuery{})
+			}
+			if m.activePanel == types.PanelOperations && m.opsPanel.IsFilterActive() {
+				m.opsPanel.ClearFilter()
+				m.opsPanel.Info("Filter cleared")
 				return m, nil
 			}
 			return m, nil
+
+		case keys.LevelFilter:
+			// Cycle the operations log level filter: all → warnings+errors → errors
+			if m.activePanel == types.PanelOperations {
+				switch m.opsPanel.CycleLevelFilter() {
+				case "warning":
+					m.opsPanel.Info("Showing warnings and errors only")
+				case "error":
+					m.opsPanel.Info("Showing errors only")
+				default:
+					m.opsPanel.Info("Showing all log levels")
+				}
+			}
+			return m, nil
 		}
 	}
 
 	return m, nil
 }
 
+// applyFilterText applies live filter input to the panel that opened filter mode
+func (m *Model) applyFilterText(text string) {
+	if m.activePanel == types.PanelOperations {
+		m.opsPanel.SetFilter(text)
+		return
+	}
+
+	switch m.filterFacet {
+	case "tag":
+		m.snapPanel.SetTagFilter(text)
+		return
+	case "host":
+		m.snapPanel.SetHostFilter(text)
+		return
+	}
+
+	// host:, tag: and path: terms are applied by restic on Enter; only the
+	// remaining text filters live. Tag and host facets are left as they are.
+	m.snapPanel.SetFilter(parseSnapshotQuery(text).text)
+}
+
+// facetLabel names a snapshot filter facet for log messages and the prompt
+func facetLabel(facet string) string {
+	if facet == "host" {
+		return "Host"
+	}
+	return "Tag"
+}
+
+// setSnapshotQuery switches the restic-side snapshot filter, reloading the
+// snapshots when it changes
+func (m *Model) setSnapshotQuery(query snapshotQuery) tea.Cmd {
+	if query.String() == m.snapshotQuery.String() {
+		return nil
+	}
+	m.snapshotQuery = query
+	m.snapPanel.SetServerFilter(query.String())
+	if query.serverSide() {
+		m.opsPanel.Info(fmt.Sprintf("Filtering in restic: %s", query.flags()))
+	} else {
+		m.opsPanel.Info("Restic-side filter cleared - reloading all snapshots")
+	}
+	return m.loadSnapshotsWithMessage()
+}
+
+// followListedRepository keeps the current repository listed after a group is
+// collapsed: when it was hidden, the nearest listed repository is selected and
+// loaded instead. Collapsing the last expanded group is undone, since no
+// repository would be left to act on.
+func (m *Model) followListedRepository() tea.Cmd {
+	if m.currentRepoIndex >= len(m.repositories) || m.repoPanel.RepositoryListed(m.repositories[m.currentRepoIndex].Name) {
+		return nil
+	}
+	repo := m.repoPanel.SelectNearestRepository()
+	if repo == nil {
+		m.repoPanel.ToggleGroup()
+		m.opsPanel.Dimmed("At least one group stays expanded so a repository is selected")
+		return nil
+	}
+	m.currentRepoIndex = m.GetSelected()
+	m.metricsPanel.SetRepository(&m.repositories[m.currentRepoIndex])
+	return m.loadSnapshotsWithMessage()
+}
+
 // GetSelected returns the index of the currently selected repository
 func (m Model) GetSelected() int {
 	if repo := m.repoPanel.GetSelected(); repo != nil {
@@ -1261,7 +3474,7 @@ func (m Model) GetSelected() int {
 // renderLoadingPanel renders a loading placeholder panel
 func (m Model) renderLoadingPanel(title string, width, height int) string {
 	loadingText := lipgloss.NewStyle().
-		Foreground(lipgloss.Color(ui.ColorInfo)).
+		Foreground(ui.CurrentTheme().Info).
 		Bold(true).
 		Render("Loading...")
 
@@ -1270,44 +3483,124 @@ func (m Model) renderLoadingPanel(title string, width, height int) string {
 		AlignVertical(lipgloss.Center).
 		Render(loadingText)
 
-	return ui.RenderPanelWithTitle(title, content, width, height, false)
-}
+	return ui.RenderPanelWithTitle(title, content, width, height, false)
+}
+
+func (m Model) View() string {
+	if !m.ready {
+		return "Initializing LazyRestic..."
+	}
+
+	if m.tooSmall {
+		return "Terminal window too small. Please resize to at least 80x20 characters."
+	}
+
+	if m.showQuitConfirm {
+		return m.renderQuitConfirm()
+	}
+
+	if m.showSandboxCleanup {
+		return m.renderSandboxCleanup()
+	}
+
+	if m.showHelp {
+		return m.renderHelp()
+	}
+
+	if m.showBackupForm {
+		return m.renderBackupForm()
+	}
+
+	if m.showRestoreForm {
+		return m.renderRestoreForm()
+	}
+
+	if m.showRepoForm {
+		return m.renderRepoForm()
+	}
+
+	if m.showFileBrowser {
+		return m.renderFileBrowser()
+	}
+
+	if m.showFoundRepos {
+		return m.renderFoundRepos()
+	}
+
+	if m.showFindResults {
+		return m.renderFindResults()
+	}
+
+	if m.showRestorePreview {
+		return m.renderRestorePreview()
+	}
+
+	if m.showRepoConfig && m.repoConfig != nil {
+		return m.renderRepoConfig()
+	}
+
+	if m.showTotals {
+		return m.renderTotals()
+	}
+
+	if m.showDiagnostics {
+		return m.renderDiagnostics()
+	}
+
+	if m.showSnapshotInfo && m.snapPanel.GetSelected() != nil {
+		return m.renderSnapshotInfo()
+	}
+
+	if m.showRemoveConfirm {
+		return m.renderRemoveConfirm()
+	}
+
+	if m.showRepairConfirm && m.repairConfirmDialog != nil {
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.repairConfirmDialog.Render())
+	}
+
+	if m.showMigrations {
+		return m.renderMigrations()
+	}
+
+	if m.showMigrateConfirm && m.migrateConfirmDialog != nil {
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.migrateConfirmDialog.Render())
+	}
 
-func (m Model) View() string {
-	if !m.ready {
-		return "Initializing LazyRestic..."
+	if m.showKeyConfirm && m.keyConfirmDialog != nil {
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.keyConfirmDialog.Render())
 	}
 
-	if m.tooSmall {
-		return "Terminal window too small. Please resize to at least 80x20 characters."
+	if m.showKeys {
+		return m.renderKeys()
 	}
 
-	if m.showHelp {
-		return m.renderHelp()
+	if m.showQuickSwitch {
+		return m.renderQuickSwitch()
 	}
 
-	if m.showBackupForm {
-		return m.renderBackupForm()
+	if m.showBackupHistory {
+		return m.renderBackupHistory()
 	}
 
-	if m.showRestoreForm {
-		return m.renderRestoreForm()
+	if m.showRawCommandConfirm && m.rawCommandConfirmDialog != nil {
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.rawCommandConfirmDialog.Render())
 	}
 
-	if m.showRepoForm {
-		return m.renderRepoForm()
+	if m.showRerunConfirm && m.rerunConfirmDialog != nil {
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.rerunConfirmDialog.Render())
 	}
 
-	if m.showFileBrowser {
-		return m.renderFileBrowser()
+	if m.showUnlockAllConfirm && m.unlockAllConfirmDialog != nil {
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.unlockAllConfirmDialog.Render())
 	}
 
-	if m.showFoundRepos {
-		return m.renderFoundRepos()
+	if m.showSpaceConfirm && m.spaceConfirmDialog != nil {
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.spaceConfirmDialog.Render())
 	}
 
-	if m.showRemoveConfirm {
-		return m.renderRemoveConfirm()
+	if m.showForgetConfirm && m.forgetConfirmDialog != nil {
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.forgetConfirmDialog.Render())
 	}
 
 	// Update repository panel data
@@ -1316,10 +3609,30 @@ func (m Model) View() string {
 	// Title bar with version - full width
 	titleText := "📦 LazyRestic - TUI Backup Manager"
 	versionText := "v0.1.0"
+	if m.config.AutoRefresh > 0 && m.autoRefreshPaused {
+		versionText = "auto-refresh paused  " + versionText
+	} else if m.config.AutoRefresh > 0 && !m.lastRefreshed.IsZero() {
+		versionText = fmt.Sprintf("refreshed %s  %s", m.lastRefreshed.Format("15:04:05"), versionText)
+	}
+
+	// Repository health badges sit left of the version, abbreviated when narrow
+	summaryText := ""
+	if len(m.repositories) > 0 {
+		available := m.width - len(titleText) - len(versionText) - 10
+		summaryText = m.RepositorySummary().Render(false)
+		if lipgloss.Width(summaryText) > available {
+			summaryText = m.RepositorySummary().Render(true)
+		}
+		if lipgloss.Width(summaryText) > available {
+			summaryText = ""
+		} else if summaryText != "" {
+			summaryText += "  "
+		}
+	}
 
 	// Calculate padding to push version to the right
 	titleLen := len(titleText)
-	versionLen := len(versionText)
+	versionLen := len(versionText) + lipgloss.Width(summaryText)
 	paddingNeeded := m.width - titleLen - versionLen - 6 // 6 for margins/padding
 	if paddingNeeded < 1 {
 		paddingNeeded = 1
@@ -1330,18 +3643,19 @@ func (m Model) View() string {
 		Foreground(ui.TitleStyle.GetForeground()).
 		Render(titleText)
 
+	theme := ui.CurrentTheme()
 	versionRight := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#666666")).
+		Foreground(theme.Dimmed).
 		Render(versionText)
 
-	titleContent := titleLeft + strings.Repeat(" ", paddingNeeded) + versionRight
+	titleContent := titleLeft + strings.Repeat(" ", paddingNeeded) + summaryText + versionRight
 
 	title := lipgloss.NewStyle().
-		Background(lipgloss.Color("#1a1a1a")).
+		Background(theme.Background).
 		Width(m.width - 4). // Leave small margin on sides
 		Padding(0, 2).
 		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#00AA88")).
+		BorderForeground(theme.Primary).
 		BorderBottom(true).
 		MarginTop(1).
 		MarginBottom(1).
@@ -1379,18 +3693,84 @@ func (m Model) View() string {
 	var helpHint string
 	if m.filterInputActive {
 		filterPromptStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("214")). // Orange
+			Foreground(ui.CurrentTheme().Warning).
 			Bold(true)
 		filterInputStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("255")). // White
-			Background(lipgloss.Color("236")). // Dark gray
+			Foreground(ui.CurrentTheme().Text).
+			Background(ui.CurrentTheme().Active).
 			Padding(0, 1)
 
-		helpHint = filterPromptStyle.Render("Filter: ") +
+		prompt := "Filter: "
+		hint := " • Enter to apply • Esc to cancel"
+		if m.filterFacet != "" {
+			prompt = facetLabel(m.filterFacet) + " filter: "
+			hint = " • Enter to apply (empty clears it) • Esc to cancel"
+		} else if m.activePanel == types.PanelSnapshots {
+			hint = " • text, or host:NAME tag:NAME path:DIR to filter in restic • Enter to apply • Esc to cancel"
+		}
+		helpHint = filterPromptStyle.Render(prompt) +
 			filterInputStyle.Render(m.filterInputText+"_") +
-			ui.HelpStyle.Render(" • Enter to apply • Esc to cancel")
+			ui.HelpStyle.Render(hint)
+	} else if m.checkPromptActive {
+		checkPromptStyle := lipgloss.NewStyle().
+			Foreground(ui.CurrentTheme().Warning).
+			Bold(true)
+		checkInputStyle := lipgloss.NewStyle().
+			Foreground(ui.CurrentTheme().Text).
+			Background(ui.CurrentTheme().Active).
+			Padding(0, 1)
+
+		helpHint = checkPromptStyle.Render("Read data subset: ") +
+			checkInputStyle.Render(m.checkPromptText+"_") +
+			ui.HelpStyle.Render(" • e.g. 5% or 1/10 • Enter to run • Esc to cancel")
+	} else if m.renamePromptActive {
+		renamePromptStyle := lipgloss.NewStyle().
+			Foreground(ui.CurrentTheme().Warning).
+			Bold(true)
+		renameInputStyle := lipgloss.NewStyle().
+			Foreground(ui.CurrentTheme().Text).
+			Background(ui.CurrentTheme().Active).
+			Padding(0, 1)
+
+		helpHint = renamePromptStyle.Render("Rename repository: ") +
+			renameInputStyle.Render(m.renamePromptText+"_") +
+			ui.HelpStyle.Render(" • Enter to save • Esc to cancel")
+	} else if m.commandPromptActive {
+		commandPromptStyle := lipgloss.NewStyle().
+			Foreground(ui.CurrentTheme().Warning).
+			Bold(true)
+		commandInputStyle := lipgloss.NewStyle().
+			Foreground(ui.CurrentTheme().Text).
+			Background(ui.CurrentTheme().Active).
+			Padding(0, 1)
+
+		helpHint = commandPromptStyle.Render("restic ") +
+			commandInputStyle.Render(m.commandPromptText+"_") +
+			ui.HelpStyle.Render(" • subcommand and flags, e.g. stats --mode raw-data • Enter to run • Esc to cancel")
+	} else if m.findPromptActive {
+		findPromptStyle := lipgloss.NewStyle().
+			Foreground(ui.CurrentTheme().Warning).
+			Bold(true)
+		findInputStyle := lipgloss.NewStyle().
+			Foreground(ui.CurrentTheme().Text).
+			Background(ui.CurrentTheme().Active).
+			Padding(0, 1)
+
+		helpHint = findPromptStyle.Render("Find file: ") +
+			findInputStyle.Render(m.findPromptText+"_") +
+			ui.HelpStyle.Render(" • name or glob, e.g. *.conf • Enter to search • Esc to cancel")
 	} else {
-		helpHint = ui.HelpStyle.Render("?:help  q:quit  a:add  x:rm  s:scan  b:backup  R:restore  u:unlock  C:cache  /:filter  r:refresh")
+		keys := m.keyMap()
+		helpHint = ui.HelpStyle.Render(fmt.Sprintf("%s:help  %s:quit  %s:add  %s:rm  %s:scan  %s:backup  %s:restore  %s:unlock  %s:cache  %s:filter  %s:refresh",
+			keyLabel(keys.Help), keyLabel(keys.Quit), keyLabel(keys.Add), keyLabel(keys.Remove), keyLabel(keys.Scan), keyLabel(keys.Backup),
+			keyLabel(keys.Restore), keyLabel(keys.Unlock), keyLabel(keys.Cache), keyLabel(keys.Filter), keyLabel(keys.Refresh)))
+		if last, ok := restic.LastCommand(); ok {
+			// Show the most recent restic command alongside the key hints
+			lastLine := fmt.Sprintf("  │ last: %s (%s:re-run)", last, keyLabel(keys.Rerun))
+			if available := m.width - lipgloss.Width(helpHint); available > 0 {
+				helpHint += ui.HelpStyle.MaxWidth(available).Render(lastLine)
+			}
+		}
 	}
 
 	// Combine everything
@@ -1411,6 +3791,108 @@ func (m Model) View() string {
 	return content
 }
 
+// openBackupForm shows a fresh backup form pre-filled with the selected
+// repository's default paths, tags and exclude patterns
+func (m *Model) openBackupForm() {
+	m.backupForm = ui.NewBackupForm()
+	m.backupForm.SetSize(m.width*2/3, m.height*2/3)
+	if m.currentRepoIndex < len(m.config.Repositories) {
+		repoConfig := m.config.Repositories[m.currentRepoIndex]
+		m.backupForm.SetDefaults(repoConfig.BackupDefaults())
+	}
+	m.showBackupForm = true
+}
+
+// backupFormOptions returns the backup options filled in on the backup form
+func (m *Model) backupFormOptions() types.BackupOptions {
+	return types.BackupOptions{
+		Paths:         m.backupForm.GetPaths(),
+		Tags:          m.backupForm.GetTags(),
+		Exclude:       m.backupForm.GetExclude(),
+		ExcludeFile:   m.backupForm.GetExcludeFile(),
+		PackSize:      m.backupForm.GetPackSize(),
+		MaxFileSize:   m.backupForm.GetMaxFileSize(),
+		DryRun:        m.backupForm.IsDryRun(),
+		ExcludeCaches: m.backupForm.ExcludeCaches(),
+		OneFileSystem: m.backupForm.OneFileSystem(),
+	}
+}
+
+// openRestoreForm creates a fresh restore form for snapshot, pre-filled with
+// the selected repository's default restore target
+func (m *Model) openRestoreForm(snapshot *types.Snapshot) {
+	m.removeRestoreIncludeTemp()
+	m.restoreForm = ui.NewRestoreForm(snapshot)
+	m.restoreForm.SetSize(m.width*2/3, m.height*2/3)
+	if m.currentRepoIndex < len(m.config.Repositories) {
+		m.restoreForm.SetTarget(m.config.Repositories[m.currentRepoIndex].RestoreDefaults(snapshot.ID).Target)
+	}
+}
+
+// prefillRestorePaths fills the restore form's include paths. Large selections
+// go into a temporary include file so the restic command line stays short.
+func (m *Model) prefillRestorePaths(paths []string) {
+	if len(paths) <= includeFileThreshold {
+		m.restoreForm.SetIncludePaths(paths)
+		return
+	}
+	path, err := writeIncludeFile(paths)
+	if err != nil {
+		m.opsPanel.Warning(fmt.Sprintf("%v - passing paths as --include flags", err))
+		m.restoreForm.SetIncludePaths(paths)
+		return
+	}
+	m.restoreIncludeTemp = path
+	m.restoreForm.SetIncludeFile(path)
+	m.opsPanel.Dimmed(fmt.Sprintf("Listed %d selected paths in %s", len(paths), path))
+}
+
+// removeRestoreIncludeTemp deletes the temporary include file written by
+// prefillRestorePaths, once the restore it was written for is done or cancelled
+func (m *Model) removeRestoreIncludeTemp() {
+	if m.restoreIncludeTemp == "" {
+		return
+	}
+	os.Remove(m.restoreIncludeTemp)
+	m.restoreIncludeTemp = ""
+}
+
+// startBackup logs and launches a backup with the given options
+func (m Model) startBackup(opts types.BackupOptions) (tea.Model, tea.Cmd) {
+	if m.currentRepoIndex < len(m.config.Repositories) {
+		if !m.claimRepo(m.config.Repositories[m.currentRepoIndex].Name) {
+			return m, nil
+		}
+		m.backupRepo = m.config.Repositories[m.currentRepoIndex].Name
+	}
+	m.backupInProgress = true
+	m.lastBackupOptions = opts
+	if opts.DryRun {
+		m.opsPanel.Preview(fmt.Sprintf("Starting dry run of %d paths (no data will be written)...", len(opts.Paths)))
+	} else {
+		m.opsPanel.Info(fmt.Sprintf("Starting backup of %d paths...", len(opts.Paths)))
+	}
+
+	cmd := m.executeBackup(m.operationContext(), opts)
+	return m, tea.Batch(cmd, m.pollLocks())
+}
+
+// startRestore logs and launches a restore with the given options
+func (m Model) startRestore(opts types.RestoreOptions) (tea.Model, tea.Cmd) {
+	if m.currentRepoIndex < len(m.config.Repositories) {
+		if !m.claimRepo(m.config.Repositories[m.currentRepoIndex].Name) {
+			m.removeRestoreIncludeTemp()
+			return m, nil
+		}
+		m.restoreRepo = m.config.Repositories[m.currentRepoIndex].Name
+	}
+	m.restoreInProgress = true
+	m.opsPanel.Info(fmt.Sprintf("Starting restore of snapshot %.8s...", opts.SnapshotID))
+
+	cmd := m.executeRestore(m.operationContext(), opts)
+	return m, tea.Batch(cmd, m.pollLocks())
+}
+
 // renderHelp renders the help screen
 func (m Model) renderHelp() string {
 	// Make width responsive to terminal size
@@ -1424,42 +3906,11 @@ func (m Model) renderHelp() string {
 
 	helpStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("62")).
+		BorderForeground(ui.CurrentTheme().Active).
 		Padding(1, 2).
 		Width(helpWidth)
 
-	help := `LazyRestic v0.1.0 - Keyboard Shortcuts
-
-Navigation:
-  ↑/k        Move up
-  ↓/j        Move down
-  Tab/→/l    Next panel
-  Shift+Tab/←/h  Previous panel
-
-Actions:
-   Enter      Select / View details
-   a          Add new repository (repositories panel)
-   b          Start a backup
-   R          Restore selected snapshot (Shift+r)
-   r          Refresh data
-   ?          Toggle this help
-   q/Ctrl+C   Quit
-
-Filtering (in Snapshots panel):
-  /          Enter filter mode
-  Esc/c      Clear active filter
-
-   When in filter mode:
-     Type to search by ID, path, tag, or hostname
-     Enter to apply, Esc to cancel
-
-Panels:
-  Left:   Repositories list
-  Right:  Snapshots for selected repository
-  Bottom: Operations and logs
-
-Press ? or Esc to close this help.
-`
+	help := helpText(m.keyMap())
 
 	return lipgloss.Place(
 		m.width,
@@ -1545,7 +3996,7 @@ func (m Model) renderFoundRepos() string {
 	b.WriteString(title + "\n\n")
 
 	// Instructions
-	infoStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	infoStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Dimmed)
 	b.WriteString(infoStyle.Render("Found repositories - press Enter to add, Esc to cancel\n\n"))
 
 	// List found repos
@@ -1576,7 +4027,7 @@ func (m Model) renderFoundRepos() string {
 	content := b.String()
 	borderStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("62")).
+		BorderForeground(ui.CurrentTheme().Active).
 		Padding(1, 2).
 		Width(m.width - 10)
 
@@ -1589,6 +4040,363 @@ func (m Model) renderFoundRepos() string {
 	)
 }
 
+// renderRestorePreview renders the scrollable list of paths a restore would write
+func (m Model) renderRestorePreview() string {
+	var b strings.Builder
+
+	title := ui.TitleStyle.Render(fmt.Sprintf("Restore Preview: %.8s → %s", m.restorePreviewOpts.SnapshotID, m.restorePreviewOpts.Target))
+	b.WriteString(title + "\n\n")
+
+	infoStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Dimmed)
+	b.WriteString(infoStyle.Render(fmt.Sprintf("%d paths would be written - Enter to restore, Esc to cancel\n", len(m.restorePreviewPaths))))
+	switch space := m.restorePreviewSpace; {
+	case space.Skipped != "":
+		b.WriteString(infoStyle.Render("Free-space check skipped: "+space.Skipped) + "\n\n")
+	case space.insufficient():
+		b.WriteString(ui.StatusWarningStyle.Render(fmt.Sprintf("⚠ Not enough free space: needs %s, %s available", ui.FormatBytes(space.Required), ui.FormatBytes(int64(space.Available)))) + "\n\n")
+	default:
+		b.WriteString(infoStyle.Render(fmt.Sprintf("Needs %s, %s free at the target", ui.FormatBytes(space.Required), ui.FormatBytes(int64(space.Available)))) + "\n\n")
+	}
+
+	// Only render the rows that fit, starting at the scroll offset
+	maxRows := m.height - 12
+	if maxRows < 1 {
+		maxRows = 1
+	}
+	start := m.restorePreviewOffset
+	if start > len(m.restorePreviewPaths) {
+		start = len(m.restorePreviewPaths)
+	}
+	end := start + maxRows
+	if end > len(m.restorePreviewPaths) {
+		end = len(m.restorePreviewPaths)
+	}
+
+	if len(m.restorePreviewPaths) == 0 {
+		b.WriteString(infoStyle.Render("  Nothing to write - the target already matches the snapshot") + "\n")
+	}
+	if start > 0 {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("  ↑ %d more", start)) + "\n")
+	}
+	for _, path := range m.restorePreviewPaths[start:end] {
+		b.WriteString(ui.ListItemStyle.Render("  "+path) + "\n")
+	}
+	if remaining := len(m.restorePreviewPaths) - end; remaining > 0 {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("  ↓ %d more", remaining)) + "\n")
+	}
+
+	borderStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.CurrentTheme().Active).
+		Padding(1, 2).
+		Width(m.width - 10)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		borderStyle.Render(b.String()),
+	)
+}
+
+// renderFindResults renders the restic find results list
+func (m Model) renderFindResults() string {
+	var b strings.Builder
+
+	title := ui.TitleStyle.Render(fmt.Sprintf("Find: %s", m.findPattern))
+	b.WriteString(title + "\n\n")
+
+	infoStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Dimmed)
+	b.WriteString(infoStyle.Render(fmt.Sprintf("%d matches - Enter to browse snapshot, Esc to close\n\n", len(m.findResults))))
+
+	// Only render the rows that fit, keeping the selection in view
+	maxRows := m.height - 12
+	if maxRows < 1 {
+		maxRows = 1
+	}
+	start := 0
+	if m.selectedFind >= maxRows {
+		start = m.selectedFind - maxRows + 1
+	}
+	end := start + maxRows
+	if end > len(m.findResults) {
+		end = len(m.findResults)
+	}
+
+	if start > 0 {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("  ↑ %d more", start)) + "\n")
+	}
+	for i := start; i < end; i++ {
+		result := m.findResults[i]
+
+		line := "  "
+		if i == m.selectedFind {
+			line = "▶ "
+		}
+		size := ui.FormatBytes(result.Size)
+		if result.Type == "dir" {
+			size = "dir"
+		}
+		line += fmt.Sprintf("%.8s  %s  %-10s %s", result.SnapshotID, result.ModTime.Format("2006-01-02 15:04"), size, result.Path)
+
+		if i == m.selectedFind {
+			line = ui.ListItemSelectedStyle.Render(line)
+		} else {
+			line = ui.ListItemStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	if remaining := len(m.findResults) - end; remaining > 0 {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("  ↓ %d more", remaining)) + "\n")
+	}
+
+	borderStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.CurrentTheme().Active).
+		Padding(1, 2).
+		Width(m.width - 10)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		borderStyle.Render(b.String()),
+	)
+}
+
+// renderRepoConfig renders the repository config popup
+func (m Model) renderRepoConfig() string {
+	labelStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Info).Width(20)
+	dimmedStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Dimmed)
+
+	orUnknown := func(value string) string {
+		if value == "" {
+			return dimmedStyle.Render("(not set)")
+		}
+		return value
+	}
+
+	var b strings.Builder
+	b.WriteString(ui.TitleStyle.Render(fmt.Sprintf("Repository config: %s", m.repoConfigName)) + "\n\n")
+	b.WriteString(labelStyle.Render("Format version:") + formatRepoVersion(m.repoConfig.Version) + "\n")
+	b.WriteString(labelStyle.Render("Repository ID:") + orUnknown(m.repoConfig.ID) + "\n")
+	b.WriteString(labelStyle.Render("Chunker polynomial:") + orUnknown(m.repoConfig.ChunkerPolynomial) + "\n\n")
+	b.WriteString(dimmedStyle.Render("Esc to close"))
+
+	borderStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.CurrentTheme().Active).
+		Padding(1, 2)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		borderStyle.Render(b.String()),
+	)
+}
+
+// renderTotals renders the totals across all repositories in a bordered overlay
+func (m Model) renderTotals() string {
+	t := m.repoTotals
+	labelStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Info).Width(16)
+	dimmedStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Dimmed)
+
+	backup := func(at time.Time, repo string) string {
+		if at.IsZero() {
+			return dimmedStyle.Render("(no backups yet)")
+		}
+		return fmt.Sprintf("%s %s", ui.FormatTimeAgo(at), dimmedStyle.Render("("+repo+")"))
+	}
+
+	var b strings.Builder
+	b.WriteString(ui.TitleStyle.Render(fmt.Sprintf("All repositories (%d)", t.Repositories)) + "\n\n")
+	b.WriteString(labelStyle.Render("Status:") + t.Counts.Render(false) + "\n")
+	b.WriteString(labelStyle.Render("Total size:") + ui.FormatBytes(t.Size) + "\n")
+	if t.StoredSize > 0 {
+		b.WriteString(labelStyle.Render("Stored data:") + ui.FormatBytes(t.StoredSize) + "\n")
+	}
+	b.WriteString(labelStyle.Render("Files:") + fmt.Sprintf("%d", t.Files) + "\n")
+	b.WriteString(labelStyle.Render("Snapshots:") + fmt.Sprintf("%d", t.Snapshots) + "\n\n")
+	b.WriteString(labelStyle.Render("Newest backup:") + backup(t.NewestBackup, t.NewestBackupRepo) + "\n")
+	b.WriteString(labelStyle.Render("Oldest backup:") + backup(t.OldestBackup, t.OldestBackupRepo) + "\n")
+	b.WriteString(dimmedStyle.Render("  (least recent last backup of any repository)") + "\n\n")
+	b.WriteString(dimmedStyle.Render("Esc to close"))
+
+	borderStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.CurrentTheme().Active).
+		Padding(1, 2)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		borderStyle.Render(b.String()),
+	)
+}
+
+// renderQuitConfirm asks whether to cancel the running operation and quit
+func (m Model) renderQuitConfirm() string {
+	dimmedStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Dimmed)
+	operation := m.runningOperation()
+	if operation == "" {
+		operation = "operation"
+	}
+
+	var b strings.Builder
+	b.WriteString(ui.TitleStyle.Render("Quit LazyRestic?") + "\n\n")
+	b.WriteString(fmt.Sprintf("A %s is still running.\n\n", operation))
+	b.WriteString("Quitting cancels it and waits for restic to stop,\n")
+	b.WriteString("so it can release the repository lock.\n\n")
+	b.WriteString(dimmedStyle.Render("y to cancel it and quit • n or Esc to keep working • Ctrl+C to quit now"))
+
+	borderStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.CurrentTheme().Warning).
+		Padding(1, 2)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		borderStyle.Render(b.String()),
+	)
+}
+
+// renderMigrations renders the migrations available for a repository as a selectable list
+func (m Model) renderMigrations() string {
+	dimmedStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Dimmed)
+
+	var b strings.Builder
+	b.WriteString(ui.TitleStyle.Render(fmt.Sprintf("Migrations for '%s'", m.migrationRepo)) + "\n\n")
+	for i, migration := range m.migrations {
+		if i == m.migrationCursor {
+			b.WriteString(ui.ListItemSelectedStyle.Render("▶ "+migration) + "\n")
+		} else {
+			b.WriteString(ui.ListItemStyle.Render("  "+migration) + "\n")
+		}
+	}
+	b.WriteString("\n" + dimmedStyle.Render("Enter to apply • Esc to close"))
+
+	borderStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.CurrentTheme().Active).
+		Padding(1, 2)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		borderStyle.Render(b.String()),
+	)
+}
+
+// renderDiagnostics renders the diagnostics checklist in a bordered overlay
+func (m Model) renderDiagnostics() string {
+	passStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Success)
+	failStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Error)
+	dimmedStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Dimmed)
+
+	var b strings.Builder
+	b.WriteString(ui.TitleStyle.Render("Diagnostics") + "\n\n")
+	for _, check := range m.diagnostics {
+		icon := passStyle.Render("✓")
+		if !check.Passed {
+			icon = failStyle.Render("✗")
+		}
+		b.WriteString(fmt.Sprintf("%s %s", icon, check.Name))
+		if check.Detail != "" {
+			b.WriteString("  " + dimmedStyle.Render(check.Detail))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(fmt.Sprintf("\n%d of %d checks passed\n\n", diagnosticsPassed(m.diagnostics), len(m.diagnostics)))
+	b.WriteString(dimmedStyle.Render("Esc to close"))
+
+	borderStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.CurrentTheme().Active).
+		Padding(1, 2)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		borderStyle.Render(b.String()),
+	)
+}
+
+// renderSnapshotInfo renders the selected snapshot's metadata in a bordered overlay
+func (m Model) renderSnapshotInfo() string {
+	snapshot := m.snapPanel.GetSelected()
+	labelStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Info).Width(10)
+	dimmedStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Dimmed)
+
+	orNone := func(values []string) string {
+		if len(values) == 0 {
+			return dimmedStyle.Render("(none)")
+		}
+		return strings.Join(values, ", ")
+	}
+
+	size := dimmedStyle.Render("calculating…")
+	if bytes, ok := m.snapPanel.RestoreSize(snapshot.ID); ok {
+		size = ui.FormatBytes(bytes)
+	}
+
+	user := snapshot.Username
+	if user == "" {
+		user = dimmedStyle.Render("(unknown)")
+	}
+
+	var b strings.Builder
+	b.WriteString(ui.TitleStyle.Render(fmt.Sprintf("📸 Snapshot %s", snapshot.ShortID)) + "\n\n")
+	b.WriteString(labelStyle.Render("ID:") + snapshot.ID + "\n")
+	b.WriteString(labelStyle.Render("Time:") + snapshot.Time.Format("2006-01-02 15:04:05") +
+		dimmedStyle.Render(fmt.Sprintf(" (%s)", ui.FormatTimeAgo(snapshot.Time))) + "\n")
+	b.WriteString(labelStyle.Render("Host:") + snapshot.Hostname + "\n")
+	b.WriteString(labelStyle.Render("User:") + user + "\n")
+	b.WriteString(labelStyle.Render("Paths:") + orNone(snapshot.Paths) + "\n")
+	b.WriteString(labelStyle.Render("Tags:") + orNone(snapshot.Tags) + "\n")
+	b.WriteString(labelStyle.Render("Size:") + size + "\n\n")
+	b.WriteString(dimmedStyle.Render("Esc to close"))
+
+	borderStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.CurrentTheme().Active).
+		Padding(1, 2)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		borderStyle.Render(b.String()),
+	)
+}
+
+// formatRepoVersion describes a repository format version
+func formatRepoVersion(version int) string {
+	switch version {
+	case 0:
+		return "unknown"
+	case 1:
+		return "1 (no compression)"
+	case 2:
+		return "2 (compression, restic 0.14+)"
+	default:
+		return fmt.Sprintf("%d (newer than this LazyRestic knows)", version)
+	}
+}
+
 // renderFileBrowser renders the file browser view
 func (m Model) renderFileBrowser() string {
 	if m.fileBrowser == nil {
@@ -1599,9 +4407,12 @@ func (m Model) renderFileBrowser() string {
 
 	// Add help hint at bottom
 	helpStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(ui.CurrentTheme().Dimmed).
 		Italic(true)
-	help := helpStyle.Render("↑/↓ navigate • ←/h back • →/l enter dir • Space select • r restore • Esc close")
+	help := helpStyle.Render("↑/↓ navigate • ←/h back • →/l enter dir • Space select • s sort • . dotfiles • v preview • r restore • Esc close")
+	if m.fileBrowser.HasPreview() {
+		help = helpStyle.Render("↑/↓ scroll • n/p page • v/Esc close preview")
+	}
 
 	content := lipgloss.JoinVertical(
 		lipgloss.Left,
@@ -0,0 +1,1498 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/config"
+	"github.com/craigderington/lazyrestic/pkg/restic"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+func TestScheduleAutoRefresh_Disabled(t *testing.T) {
+	m := Model{config: &types.ResticConfig{}}
+
+	if cmd := m.scheduleAutoRefresh(); cmd != nil {
+		t.Error("scheduleAutoRefresh() should return nil when auto_refresh is not set")
+	}
+}
+
+func TestAutoRefreshTick_SkipsWhileBusy(t *testing.T) {
+	m := Model{
+		config:           &types.ResticConfig{AutoRefresh: time.Minute},
+		opsPanel:         ui.NewOperationsPanel(),
+		backupInProgress: true,
+	}
+
+	updated, cmd := m.Update(AutoRefreshTickMsg{})
+	if updated.(Model).autoRefreshing {
+		t.Error("Auto-refresh should not start while a backup is in progress")
+	}
+	if cmd == nil {
+		t.Error("Auto-refresh should be rescheduled after a skipped tick")
+	}
+}
+
+func TestAutoRefreshTick_StartsWhenIdle(t *testing.T) {
+	m := Model{
+		config:   &types.ResticConfig{AutoRefresh: time.Minute},
+		opsPanel: ui.NewOperationsPanel(),
+	}
+
+	updated, cmd := m.Update(AutoRefreshTickMsg{})
+	if !updated.(Model).autoRefreshing {
+		t.Error("Auto-refresh should start when no operation is running")
+	}
+	if cmd == nil {
+		t.Error("Auto-refresh tick should return a reload command")
+	}
+}
+
+func TestAutoRefreshTick_IgnoresStaleGeneration(t *testing.T) {
+	m := Model{
+		config:         &types.ResticConfig{AutoRefresh: time.Minute},
+		opsPanel:       ui.NewOperationsPanel(),
+		autoRefreshGen: 2,
+	}
+
+	updated, cmd := m.Update(AutoRefreshTickMsg{Gen: 1})
+	if updated.(Model).autoRefreshing {
+		t.Error("Stale tick should not start a refresh")
+	}
+	if cmd != nil {
+		t.Error("Stale tick should not reschedule the timer")
+	}
+}
+
+func TestAutoRefreshPauseToggle(t *testing.T) {
+	m := Model{
+		config:   &types.ResticConfig{AutoRefresh: time.Minute},
+		opsPanel: ui.NewOperationsPanel(),
+	}
+	pauseKey := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}}
+
+	updated, cmd := m.Update(pauseKey)
+	m = updated.(Model)
+	if !m.autoRefreshPaused {
+		t.Fatal("'p' should pause auto-refresh")
+	}
+	if cmd != nil {
+		t.Error("Pausing should not schedule a tick")
+	}
+
+	// A tick already in flight must not refresh while paused
+	updated, _ = m.Update(AutoRefreshTickMsg{Gen: m.autoRefreshGen})
+	if updated.(Model).autoRefreshing {
+		t.Error("Tick should be ignored while paused")
+	}
+
+	gen := m.autoRefreshGen
+	updated, cmd = m.Update(pauseKey)
+	m = updated.(Model)
+	if m.autoRefreshPaused {
+		t.Error("Second 'p' should resume auto-refresh")
+	}
+	if cmd == nil || m.autoRefreshGen == gen {
+		t.Error("Resuming should restart the timer")
+	}
+}
+
+func TestDataCheckPrompt_RejectsInvalidSubset(t *testing.T) {
+	m := Model{
+		config:       &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo"}}},
+		repositories: []types.Repository{{Name: "repo", Path: "/tmp/repo"}},
+		activePanel:  types.PanelRepositories,
+		opsPanel:     ui.NewOperationsPanel(),
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'V'}})
+	m = updated.(Model)
+	if !m.checkPromptActive {
+		t.Fatal("'V' should open the data check prompt")
+	}
+
+	m.checkPromptText = "lots"
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if !m.checkPromptActive || m.checkInProgress {
+		t.Error("Invalid subset should keep the prompt open without starting a check")
+	}
+	if cmd != nil {
+		t.Error("Invalid subset should not run restic")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).checkPromptActive {
+		t.Error("Esc should close the data check prompt")
+	}
+}
+
+func TestBackupSummary_DryRunSkipsSnapshotReload(t *testing.T) {
+	m := Model{
+		config:            &types.ResticConfig{},
+		opsPanel:          ui.NewOperationsPanel(),
+		backupInProgress:  true,
+		lastBackupOptions: types.BackupOptions{Paths: []string{"/etc"}, DryRun: true},
+	}
+
+	updated, cmd := m.Update(BackupSummaryMsg{Summary: &types.BackupSummary{FilesNew: 3}})
+	m = updated.(Model)
+	if m.backupInProgress {
+		t.Error("Dry run summary should finish the backup operation")
+	}
+	if cmd != nil {
+		t.Error("Dry run should not reload snapshots")
+	}
+
+	logs := m.opsPanel.FilteredLogs()
+	last := logs[len(logs)-1]
+	if last.Level != "preview" || !strings.Contains(last.Message, "no data written") {
+		t.Errorf("Expected a preview log mentioning no data written, got %+v", last)
+	}
+}
+
+func TestQuickBackup_UsesRepositoryDefaults(t *testing.T) {
+	dir := t.TempDir()
+	m := Model{
+		config: &types.ResticConfig{Repositories: []types.RepositoryConfig{{
+			Name:         "repo",
+			Path:         "/tmp/repo",
+			DefaultPaths: []string{dir},
+			DefaultTags:  []string{"nightly"},
+		}}},
+		repositories: []types.Repository{{Name: "repo", Path: "/tmp/repo"}},
+		opsPanel:     ui.NewOperationsPanel(),
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("B")})
+	m = updated.(Model)
+	if m.showBackupForm {
+		t.Error("Quick backup with defaults should not leave the form open")
+	}
+	if !m.backupInProgress || cmd == nil {
+		t.Fatal("Quick backup should start a backup")
+	}
+	if got := m.lastBackupOptions; len(got.Paths) != 1 || got.Paths[0] != dir || len(got.Tags) != 1 || got.Tags[0] != "nightly" {
+		t.Errorf("Backup options = %+v, want the repository defaults", got)
+	}
+
+	found := false
+	for _, entry := range m.opsPanel.FilteredLogs() {
+		if strings.Contains(entry.Message, "Using default paths: "+dir) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Quick backup should log the paths being used")
+	}
+}
+
+func TestQuickBackup_WithoutDefaultsOpensForm(t *testing.T) {
+	m := Model{
+		config:       &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo", DefaultTags: []string{"laptop"}}}},
+		repositories: []types.Repository{{Name: "repo", Path: "/tmp/repo"}},
+		opsPanel:     ui.NewOperationsPanel(),
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("B")})
+	m = updated.(Model)
+	if cmd != nil || m.backupInProgress {
+		t.Error("Quick backup without default paths should not start a backup")
+	}
+	if !m.showBackupForm {
+		t.Fatal("Quick backup without default paths should open the backup form")
+	}
+	if tags := m.backupForm.GetTags(); len(tags) != 1 || tags[0] != "laptop" {
+		t.Errorf("Backup form tags = %v, want the configured default", tags)
+	}
+}
+
+func TestBackupSummary_WarningLogsYellow(t *testing.T) {
+	m := Model{
+		config:            &types.ResticConfig{},
+		opsPanel:          ui.NewOperationsPanel(),
+		backupInProgress:  true,
+		lastBackupOptions: types.BackupOptions{Paths: []string{"/srv"}},
+	}
+
+	updated, cmd := m.Update(BackupSummaryMsg{Summary: &types.BackupSummary{FilesNew: 5}, Warning: "2 files could not be read"})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Error("A backup with warnings created a snapshot, so snapshots should reload")
+	}
+
+	found := false
+	for _, entry := range m.opsPanel.FilteredLogs() {
+		if strings.Contains(entry.Message, "completed with warnings: 2 files could not be read") {
+			found = entry.Level == "warning"
+		}
+		if entry.Level == "error" {
+			t.Errorf("A backup with warnings should not log an error: %+v", entry)
+		}
+	}
+	if !found {
+		t.Error("Expected a warning-level log for the skipped files")
+	}
+}
+
+func TestBackupSummary_ReportsWhatExcludesSkipped(t *testing.T) {
+	factory := &fakeFactory{excluded: &types.ExclusionSummary{ExcludedFiles: 1204, ExcludedBytes: 3 << 20}}
+	m := Model{
+		config:            &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "laptop", Path: "/tmp/laptop"}}},
+		repositories:      []types.Repository{{Name: "laptop", Path: "/tmp/laptop"}},
+		snapPanel:         ui.NewSnapshotPanel(),
+		opsPanel:          ui.NewOperationsPanel(),
+		clientFactory:     factory,
+		backupInProgress:  true,
+		backupRepo:        "laptop",
+		busyRepos:         map[string]int{"laptop": 1},
+		lastBackupOptions: types.BackupOptions{Paths: []string{"/home"}, ExcludeCaches: true},
+	}
+
+	updated, cmd := m.Update(BackupSummaryMsg{Summary: &types.BackupSummary{FilesNew: 5, TotalFilesProcessed: 300}})
+	m = updated.(Model)
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("Expected a snapshot reload and the exclusion check, got %T", cmd())
+	}
+	// The snapshot reload and the exclusion dry run each hold the repository
+	if m.busyRepos["laptop"] != 2 {
+		t.Errorf("The exclusion dry run should hold the repository, busy count %d", m.busyRepos["laptop"])
+	}
+
+	updated, _ = m.Update(batch[1]())
+	m = updated.(Model)
+	if m.busyRepos["laptop"] != 1 {
+		t.Errorf("The exclusion check should release the repository, busy count %d", m.busyRepos["laptop"])
+	}
+	logs := m.opsPanel.FilteredLogs()
+	if last := logs[len(logs)-1].Message; !strings.Contains(last, "skipped about 1204 files (3.0 MiB)") {
+		t.Errorf("Last log = %q, want the number of files the excludes skipped", last)
+	}
+}
+
+func TestFindResults_EnterOpensSnapshotAtDirectory(t *testing.T) {
+	snapPanel := ui.NewSnapshotPanel()
+	snapPanel.SetSize(80, 20)
+	snapPanel.SetSnapshots([]types.Snapshot{
+		{ID: "aaaa1111aaaa1111", ShortID: "aaaa1111"},
+		{ID: "bbbb2222bbbb2222", ShortID: "bbbb2222"},
+	})
+
+	m := Model{
+		config:       &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo"}}},
+		repositories: []types.Repository{{Name: "repo", Path: "/tmp/repo"}},
+		opsPanel:     ui.NewOperationsPanel(),
+		snapPanel:    snapPanel,
+	}
+
+	updated, _ := m.Update(FindResultsMsg{Pattern: "nothing"})
+	m = updated.(Model)
+	if m.showFindResults {
+		t.Error("An empty result set should not open the results list")
+	}
+
+	updated, _ = m.Update(FindResultsMsg{Pattern: "notes.txt", Results: []types.FindResult{
+		{SnapshotID: "aaaa1111aaaa1111", Path: "/home/user/notes.txt", Type: "file"},
+		{SnapshotID: "bbbb2222bbbb2222", Path: "/home/user/old/notes.txt", Type: "file"},
+	}})
+	m = updated.(Model)
+	if !m.showFindResults {
+		t.Fatal("Results should be shown")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updated, cmd := updated.(Model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+
+	if m.showFindResults || !m.showFileBrowser {
+		t.Fatal("Enter should close the results and open the file browser")
+	}
+	if got := m.snapPanel.GetSelected(); got == nil || got.ShortID != "bbbb2222" {
+		t.Errorf("Selected snapshot = %v, want bbbb2222", got)
+	}
+	if got := m.fileBrowser.GetCurrentPath(); got != "/home/user/old" {
+		t.Errorf("File browser path = %q, want /home/user/old", got)
+	}
+	if cmd == nil {
+		t.Error("Opening the file browser should load files")
+	}
+}
+
+func TestForgetMarkedSnapshots(t *testing.T) {
+	snapPanel := ui.NewSnapshotPanel()
+	snapPanel.SetSize(80, 20)
+	snapPanel.SetSnapshots([]types.Snapshot{
+		{ID: "aaaa1111aaaa1111", ShortID: "aaaa1111"},
+		{ID: "bbbb2222bbbb2222", ShortID: "bbbb2222"},
+	})
+
+	m := Model{
+		config:       &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo"}}},
+		repositories: []types.Repository{{Name: "repo", Path: "/tmp/repo"}},
+		activePanel:  types.PanelSnapshots,
+		opsPanel:     ui.NewOperationsPanel(),
+		snapPanel:    snapPanel,
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'F'}})
+	if updated.(Model).showForgetConfirm {
+		t.Fatal("'F' without marks should not open the confirmation")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	updated, _ = updated.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'F'}})
+	m = updated.(Model)
+	if !m.showForgetConfirm {
+		t.Fatal("'F' should open the confirmation dialog")
+	}
+	if len(m.forgetIDs) != 1 || m.forgetIDs[0] != "aaaa1111aaaa1111" {
+		t.Errorf("forgetIDs = %v, want the marked snapshot", m.forgetIDs)
+	}
+
+	// Enter without typing DELETE does nothing
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if !m.showForgetConfirm || cmd != nil {
+		t.Error("Enter without confirmation should keep the dialog open")
+	}
+
+	updated, cmd = m.Update(ForgetCompleteMsg{IDs: []string{"aaaa1111aaaa1111"}})
+	m = updated.(Model)
+	if len(m.snapPanel.MarkedSnapshots()) != 0 {
+		t.Error("Marks should be cleared after forget completes")
+	}
+	if cmd == nil {
+		t.Error("Snapshots should be reloaded after forget completes")
+	}
+}
+
+func TestRepoInitialized_AlreadyInitializedIsInfo(t *testing.T) {
+	m := Model{
+		config:   &types.ResticConfig{},
+		opsPanel: ui.NewOperationsPanel(),
+	}
+
+	err := fmt.Errorf("%w: exit status 1", restic.ErrAlreadyInitialized)
+	updated, cmd := m.Update(RepoInitializedMsg{RepoName: "repo", Error: err})
+	m = updated.(Model)
+
+	logs := m.opsPanel.FilteredLogs()
+	last := logs[len(logs)-1]
+	if last.Level != "info" || !strings.Contains(last.Message, "already initialized, skipping") {
+		t.Errorf("Expected an info log about skipping init, got %+v", last)
+	}
+	if cmd == nil {
+		t.Error("Repositories should still be refreshed")
+	}
+}
+
+func TestRepositoryInfoLoaded_UpdatesOnlyThatRepository(t *testing.T) {
+	m := Model{
+		config: &types.ResticConfig{},
+		repositories: []types.Repository{
+			{Name: "alpha", Status: "healthy", SnapshotCount: 1},
+			{Name: "beta", Status: "error"},
+		},
+		currentRepoIndex: 1,
+		opsPanel:         ui.NewOperationsPanel(),
+		metricsPanel:     ui.NewRepoMetricsPanel(),
+	}
+
+	updated, _ := m.Update(RepositoryInfoLoadedMsg{Index: 1, Repository: types.Repository{Name: "beta", Status: "healthy", SnapshotCount: 7}})
+	m = updated.(Model)
+	if m.repositories[1].Status != "healthy" || m.repositories[1].SnapshotCount != 7 {
+		t.Errorf("Selected repository not updated: %+v", m.repositories[1])
+	}
+	if m.repositories[0].SnapshotCount != 1 {
+		t.Error("Other repositories should be left unchanged")
+	}
+
+	// A stale result for a repository that moved is ignored
+	updated, _ = m.Update(RepositoryInfoLoadedMsg{Index: 0, Repository: types.Repository{Name: "beta", Status: "error"}})
+	if updated.(Model).repositories[0].Name != "alpha" {
+		t.Error("Result for a different repository name should be ignored")
+	}
+}
+
+// fakeClipboard records copied text or fails when err is set
+type fakeClipboard struct {
+	copied *string
+	err    error
+}
+
+func (c fakeClipboard) Copy(text string) error {
+	if c.err != nil {
+		return c.err
+	}
+	*c.copied = text
+	return nil
+}
+
+func TestCopySnapshotID(t *testing.T) {
+	snapPanel := ui.NewSnapshotPanel()
+	snapPanel.SetSnapshots([]types.Snapshot{{ID: "aaaa1111bbbb2222cccc3333", ShortID: "aaaa1111"}})
+
+	var copied string
+	m := Model{
+		config:      &types.ResticConfig{},
+		activePanel: types.PanelSnapshots,
+		opsPanel:    ui.NewOperationsPanel(),
+		snapPanel:   snapPanel,
+		clipboard:   fakeClipboard{copied: &copied},
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	if copied != "aaaa1111bbbb2222cccc3333" {
+		t.Errorf("Copied %q, want the full snapshot ID", copied)
+	}
+	logs := updated.(Model).opsPanel.FilteredLogs()
+	if last := logs[len(logs)-1]; last.Level != "success" {
+		t.Errorf("Expected success log, got %+v", last)
+	}
+
+	// On failure the ID is printed to the log instead
+	m.clipboard = fakeClipboard{err: fmt.Errorf("no terminal")}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	logs = updated.(Model).opsPanel.FilteredLogs()
+	if last := logs[len(logs)-1]; !strings.Contains(last.Message, "aaaa1111bbbb2222cccc3333") {
+		t.Errorf("Expected the full ID in the log on failure, got %+v", last)
+	}
+}
+
+func TestRepairIndex_RequiresTypedConfirmation(t *testing.T) {
+	m := Model{
+		config: &types.ResticConfig{Repositories: []types.RepositoryConfig{
+			{Name: "alpha", Path: "/tmp/alpha"},
+		}},
+		repositories: []types.Repository{{Name: "alpha", Path: "/tmp/alpha", Status: "error"}},
+		activePanel:  types.PanelRepositories,
+		opsPanel:     ui.NewOperationsPanel(),
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'I'}})
+	m = updated.(Model)
+	if !m.showRepairConfirm {
+		t.Fatal("Repair key should open the confirmation dialog")
+	}
+
+	// Enter without the confirmation word does nothing
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if cmd != nil || m.repairInProgress {
+		t.Error("Repair should not start before 'REPAIR' is typed")
+	}
+
+	for _, r := range "REPAIR" {
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = updated.(Model)
+	}
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if cmd == nil || !m.repairInProgress || m.showRepairConfirm {
+		t.Error("Typing 'REPAIR' and Enter should start the repair and close the dialog")
+	}
+	if !m.isBusy() {
+		t.Error("A running repair should count as busy")
+	}
+}
+
+func TestRemoveConfirm_TimesOutAfterInactivity(t *testing.T) {
+	m := Model{
+		config:       &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "alpha", Path: "/tmp/alpha"}}},
+		repositories: []types.Repository{{Name: "alpha", Path: "/tmp/alpha"}},
+		activePanel:  types.PanelRepositories,
+		opsPanel:     ui.NewOperationsPanel(),
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	m = updated.(Model)
+	if !m.showRemoveConfirm || cmd == nil {
+		t.Fatal("Remove key should open the confirmation dialog and start its timeout")
+	}
+	dialog := m.removeConfirmDialog
+	opened := ConfirmTimeoutMsg{Dialog: dialog, Activity: dialog.Activity()}
+
+	// Typing restarts the timer, so the tick scheduled on open is stale
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	m = updated.(Model)
+	updated, _ = m.Update(opened)
+	m = updated.(Model)
+	if !m.showRemoveConfirm {
+		t.Fatal("A timeout from before the last key press should be ignored")
+	}
+
+	updated, _ = m.Update(ConfirmTimeoutMsg{Dialog: dialog, Activity: dialog.Activity()})
+	m = updated.(Model)
+	if m.showRemoveConfirm || m.removeConfirmDialog != nil || m.repoToRemove != "" {
+		t.Error("An idle confirmation should be cancelled")
+	}
+	logs := m.opsPanel.FilteredLogs()
+	if last := logs[len(logs)-1]; !strings.Contains(last.Message, "confirmation timed out") {
+		t.Errorf("Expected a 'confirmation timed out' log, got %+v", last)
+	}
+}
+
+func TestRepoConfig_ShowsAndCloses(t *testing.T) {
+	m := Model{
+		config:   &types.ResticConfig{},
+		opsPanel: ui.NewOperationsPanel(),
+		width:    100,
+		height:   30,
+	}
+
+	updated, _ := m.Update(RepoConfigMsg{RepoName: "alpha", Config: &types.RepoConfigBlob{Version: 1, ID: "abcdef"}})
+	m = updated.(Model)
+	if !m.showRepoConfig {
+		t.Fatal("A loaded config should open the config view")
+	}
+	view := m.renderRepoConfig()
+	for _, want := range []string{"alpha", "1 (no compression)", "abcdef", "(not set)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Config view missing %q", want)
+		}
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if m.showRepoConfig || m.repoConfig != nil {
+		t.Error("Esc should close the config view")
+	}
+
+	updated, _ = m.Update(RepoConfigMsg{RepoName: "alpha", Error: fmt.Errorf("wrong password")})
+	m = updated.(Model)
+	if m.showRepoConfig {
+		t.Error("A failed read should not open the config view")
+	}
+}
+
+func TestSnapshotInfo_ShowsAndCloses(t *testing.T) {
+	snapPanel := ui.NewSnapshotPanel()
+	snapPanel.SetSnapshots([]types.Snapshot{{
+		ID:       "aaaa1111bbbb2222",
+		ShortID:  "aaaa1111",
+		Time:     time.Now().Add(-2 * time.Hour),
+		Hostname: "web01",
+		Paths:    []string{"/home", "/etc"},
+	}})
+	snapPanel.SetRestoreSize("aaaa1111bbbb2222", 2048)
+	m := Model{
+		config:      &types.ResticConfig{},
+		opsPanel:    ui.NewOperationsPanel(),
+		snapPanel:   snapPanel,
+		activePanel: types.PanelSnapshots,
+		width:       100,
+		height:      30,
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")})
+	m = updated.(Model)
+	if !m.showSnapshotInfo {
+		t.Fatal("i should open the snapshot info overlay")
+	}
+	view := m.renderSnapshotInfo()
+	for _, want := range []string{"aaaa1111bbbb2222", "web01", "/home, /etc", "(none)", "(unknown)", "2 hours ago", "2.0 KiB"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Snapshot info missing %q", want)
+		}
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if m.showSnapshotInfo {
+		t.Error("Esc should close the snapshot info overlay")
+	}
+}
+
+func TestLogSelectedSnapshot_OnlyWhenConfigured(t *testing.T) {
+	snapPanel := ui.NewSnapshotPanel()
+	snapPanel.SetSnapshots([]types.Snapshot{{ID: "aaaa1111", ShortID: "aaaa1111", Hostname: "web01"}})
+	m := Model{
+		config:    &types.ResticConfig{},
+		opsPanel:  ui.NewOperationsPanel(),
+		snapPanel: snapPanel,
+	}
+
+	m.logSelectedSnapshot()
+	if logs := m.opsPanel.FilteredLogs(); len(logs) != 0 {
+		t.Errorf("Snapshot details should not be logged by default, got %d logs", len(logs))
+	}
+
+	m.config.LogSnapshotDetails = true
+	m.logSelectedSnapshot()
+	found := false
+	for _, log := range m.opsPanel.FilteredLogs() {
+		if strings.Contains(log.Message, "Hostname: web01") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("log_snapshot_details should log the selected snapshot")
+	}
+}
+
+func TestRename_PromptSeedsNameAndSaves(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	m := Model{
+		config: &types.ResticConfig{Repositories: []types.RepositoryConfig{
+			{Name: "alpha", Path: "/repo/alpha", PasswordEnv: "RESTIC_PASSWORD"},
+			{Name: "beta", Path: "/repo/beta", PasswordEnv: "RESTIC_PASSWORD"},
+		}},
+		repositories: []types.Repository{{Name: "alpha"}, {Name: "beta"}},
+		metricsPanel: ui.NewRepoMetricsPanel(),
+		opsPanel:     ui.NewOperationsPanel(),
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("E")})
+	m = updated.(Model)
+	if !m.renamePromptActive || m.renamePromptText != "alpha" {
+		t.Fatalf("Rename prompt should open seeded with the current name, got %v %q", m.renamePromptActive, m.renamePromptText)
+	}
+
+	for _, key := range []tea.KeyMsg{
+		{Type: tea.KeyBackspace}, {Type: tea.KeyBackspace}, {Type: tea.KeyBackspace},
+		{Type: tea.KeyBackspace}, {Type: tea.KeyBackspace},
+		{Type: tea.KeyRunes, Runes: []rune("b")}, {Type: tea.KeyRunes, Runes: []rune("e")},
+		{Type: tea.KeyRunes, Runes: []rune("t")}, {Type: tea.KeyRunes, Runes: []rune("a")},
+	} {
+		updated, _ = m.Update(key)
+		m = updated.(Model)
+	}
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.renamePromptActive || cmd == nil {
+		t.Fatal("Enter should close the prompt and start the rename")
+	}
+	msg, ok := cmd().(RepoRenamedMsg)
+	if !ok || msg.Error == nil || !strings.Contains(msg.Error.Error(), "already exists") {
+		t.Fatalf("Renaming to an existing name should fail, got %+v", msg)
+	}
+	if msg = m.renameRepository("gamma", "delta")().(RepoRenamedMsg); msg.Error == nil || strings.Contains(msg.Error.Error(), "already exists") {
+		t.Errorf("Renaming an unknown repository should say so, got %v", msg.Error)
+	}
+
+	cmd = m.renameRepository("alpha", "offsite")
+	msg = cmd().(RepoRenamedMsg)
+	if msg.Error != nil {
+		t.Fatalf("renameRepository() failed: %v", msg.Error)
+	}
+	saved, err := config.Load(filepath.Join(home, ".config", "lazyrestic", "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if saved.Repositories[0].Name != "offsite" || saved.Repositories[0].Path != "/repo/alpha" {
+		t.Errorf("Saved config should have the new name and same path, got %+v", saved.Repositories[0])
+	}
+
+	updated, cmd = m.Update(msg)
+	m = updated.(Model)
+	if cmd == nil {
+		t.Error("A successful rename should reload repositories")
+	}
+}
+
+func TestRename_MovesStateKeptByName(t *testing.T) {
+	dir := t.TempDir()
+	m := Model{
+		opsPanel:        ui.NewOperationsPanel(),
+		metricsPanel:    ui.NewRepoMetricsPanel(),
+		recentRepos:     []string{"beta", "alpha"},
+		recentReposPath: filepath.Join(dir, "recent.json"),
+		historyDir:      filepath.Join(dir, "history"),
+	}
+	if err := appendBackupHistory(backupHistoryPath(m.historyDir, "alpha"), types.BackupRecord{SnapshotID: "aaaa1111"}); err != nil {
+		t.Fatal(err)
+	}
+	m.metricsPanel.SetDuplicateStats("alpha", types.DuplicateStats{TotalSize: 2048, UniqueSize: 1024})
+
+	updated, _ := m.Update(RepoRenamedMsg{OldName: "alpha", NewName: "offsite"})
+	m = updated.(Model)
+
+	want := []string{"beta", "offsite"}
+	if got := loadRecentRepos(m.recentReposPath); !reflect.DeepEqual(got, want) {
+		t.Errorf("Saved recent repositories = %q, want %q", got, want)
+	}
+	records, err := readBackupHistory(backupHistoryPath(m.historyDir, "offsite"))
+	if err != nil || len(records) != 1 || records[0].SnapshotID != "aaaa1111" {
+		t.Errorf("Backup history under the new name = %v, %v; want the recorded backup", records, err)
+	}
+	if _, err := os.Stat(backupHistoryPath(m.historyDir, "alpha")); err == nil {
+		t.Error("The history file under the old name should be gone")
+	}
+
+	m.metricsPanel.SetSize(100, 30)
+	m.metricsPanel.SetRepository(&types.Repository{Name: "offsite", Status: "healthy"})
+	if !strings.Contains(m.metricsPanel.Render(), "1.0 KiB (50%)") {
+		t.Error("Duplicate files should follow the repository to its new name")
+	}
+}
+
+func TestDuplicates_LogsTheSnapshotMeasured(t *testing.T) {
+	repos := []types.Repository{{Name: "photos", Path: "/tmp/photos"}}
+	m := Model{
+		config:        &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "photos", Path: "/tmp/photos"}}},
+		repositories:  repos,
+		metricsPanel:  ui.NewRepoMetricsPanel(),
+		opsPanel:      ui.NewOperationsPanel(),
+		clientFactory: &fakeFactory{},
+		keys:          DefaultKeyMap(),
+	}
+
+	m, cmd := pressKeys(m, typeText("N")...)
+	if cmd == nil {
+		t.Fatal("N should measure duplicate files")
+	}
+	updated, _ := m.Update(cmd())
+	m = updated.(Model)
+
+	var logs []string
+	for _, entry := range m.opsPanel.FilteredLogs() {
+		logs = append(logs, entry.Message)
+	}
+	text := strings.Join(logs, "\n")
+	for _, want := range []string{
+		"Duplicate files in snapshot cafe1234, the latest of 'photos'",
+		"in 30 files of snapshot cafe1234,",
+		"Command: restic -r /tmp/photos stats --json --mode files-by-contents cafe1234cafe1234",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Logs should contain %q, got:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "across all snapshots") || strings.Contains(text, "files-by-contents latest") {
+		t.Errorf("Logs should name the measured snapshot, got:\n%s", text)
+	}
+}
+
+func TestScanForRepositories_ReportsScannedPaths(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	m := Model{
+		config:   &types.ResticConfig{ScanPaths: []string{first, second}},
+		opsPanel: ui.NewOperationsPanel(),
+	}
+
+	msg := m.scanForRepositories()().(ScannedReposMsg)
+	updated, _ := m.Update(msg)
+	m = updated.(Model)
+
+	logs := m.opsPanel.FilteredLogs()
+	want := fmt.Sprintf("Scanned: %s, %s", first, second)
+	if len(logs) == 0 || logs[len(logs)-1].Message != want {
+		t.Errorf("Last log should be %q, got %+v", want, logs)
+	}
+}
+
+func TestScanPaths_DefaultsWhenUnset(t *testing.T) {
+	m := Model{config: &types.ResticConfig{}}
+	if got := m.scanPaths(); !reflect.DeepEqual(got, defaultScanPaths) {
+		t.Errorf("scanPaths() = %v, want defaults %v", got, defaultScanPaths)
+	}
+}
+
+func TestOperationSpinner_SetAndClearedAroundUnlock(t *testing.T) {
+	m := Model{
+		config:       &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "alpha"}}},
+		repositories: []types.Repository{{Name: "alpha", Path: "/repo/alpha"}},
+		opsPanel:     ui.NewOperationsPanel(),
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("u")})
+	m = updated.(Model)
+	if cmd == nil || m.operationInProgress != "unlock" {
+		t.Fatalf("Unlock should start an operation, got %q", m.operationInProgress)
+	}
+	if !m.isBusy() {
+		t.Error("A running operation should keep auto-refresh away")
+	}
+	if activity := m.opsPanel.Activity(); !strings.Contains(activity, "Running unlock... 0s") {
+		t.Errorf("Activity = %q, want the unlock spinner", activity)
+	}
+
+	// Ticks advance the spinner and keep ticking while the operation runs
+	m.operationStarted = time.Now().Add(-3 * time.Second)
+	updated, cmd = m.Update(OperationTickMsg{Gen: m.operationGen})
+	m = updated.(Model)
+	if cmd == nil || m.spinnerFrame != 1 || !strings.Contains(m.opsPanel.Activity(), "3s") {
+		t.Errorf("Tick should advance the spinner, got frame %d, activity %q", m.spinnerFrame, m.opsPanel.Activity())
+	}
+
+	// A second operation is refused while one runs
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("C")})
+	m = updated.(Model)
+	if m.operationInProgress != "unlock" {
+		t.Errorf("Cache cleanup should wait for unlock, got %q", m.operationInProgress)
+	}
+
+	updated, _ = m.Update(CommandResultMsg{Name: "unlock"})
+	m = updated.(Model)
+	if m.operationInProgress != "" || m.opsPanel.Activity() != "" {
+		t.Errorf("Unlock result should clear the spinner, got %q / %q", m.operationInProgress, m.opsPanel.Activity())
+	}
+
+	// A tick still in flight from the finished operation stops the ticker
+	if _, cmd = m.Update(OperationTickMsg{Gen: m.operationGen}); cmd != nil {
+		t.Error("Ticks after the operation finished should not reschedule")
+	}
+}
+
+func TestLogConfigLoad_PasswordFileRepairs(t *testing.T) {
+	opsPanel := ui.NewOperationsPanel()
+	permErr := fmt.Errorf("config validation failed: %w", &config.PasswordFilePermissionError{Path: "/pw/beta", Mode: 0644})
+
+	logConfigLoad(opsPanel, []string{"/pw/alpha"}, permErr)
+
+	var messages []string
+	for _, log := range opsPanel.FilteredLogs() {
+		messages = append(messages, log.Level+": "+log.Message)
+	}
+	joined := strings.Join(messages, "\n")
+	for _, want := range []string{
+		"warning: ⚠ Password file /pw/alpha was readable by others",
+		"error: ✗ Config error: config validation failed: password file permissions are -rw-r--r--",
+		"chmod 600 /pw/beta",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("Logs missing %q, got:\n%s", want, joined)
+		}
+	}
+}
+
+func TestLogConfigLoad_ReportsValidationError(t *testing.T) {
+	opsPanel := ui.NewOperationsPanel()
+	logConfigLoad(opsPanel, nil, nil)
+	if logs := opsPanel.FilteredLogs(); len(logs) != 0 {
+		t.Errorf("A clean load should log nothing, got %+v", logs)
+	}
+
+	err := fmt.Errorf("config validation failed: repository 1 (s3-repo) validation failed: password file does not exist")
+	logConfigLoad(opsPanel, nil, err)
+	logs := opsPanel.FilteredLogs()
+	if len(logs) == 0 || logs[0].Level != "error" || logs[0].Message != "✗ Config error: "+err.Error() {
+		t.Fatalf("Expected the config error first, got %+v", logs)
+	}
+	if !strings.Contains(logs[len(logs)-1].Message, "Starting with no repositories") {
+		t.Errorf("Expected a note about the empty repository list, got %q", logs[len(logs)-1].Message)
+	}
+}
+
+func TestMount_UnavailableWarnsAndMountToggles(t *testing.T) {
+	m := Model{
+		config:       &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "alpha"}}},
+		repositories: []types.Repository{{Name: "alpha", Path: "/repo/alpha"}},
+		opsPanel:     ui.NewOperationsPanel(),
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	m = updated.(Model)
+	if cmd == nil || m.operationInProgress != "mount" {
+		t.Fatal("n should start mounting the selected repository")
+	}
+
+	updated, _ = m.Update(RepoMountedMsg{RepoName: "alpha", Error: fmt.Errorf("%w: fusermount not found", restic.ErrMountUnavailable)})
+	m = updated.(Model)
+	logs := m.opsPanel.FilteredLogs()
+	if m.mountCmd != nil || m.operationInProgress != "" {
+		t.Error("A failed mount should leave nothing running")
+	}
+	found := false
+	for _, log := range logs {
+		if log.Level == "warning" && strings.Contains(log.Message, "install FUSE") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Missing FUSE should be explained, got %+v", logs)
+	}
+
+	mountCmd := &exec.Cmd{}
+	updated, _ = m.Update(RepoMountedMsg{RepoName: "alpha", MountPoint: "/tmp/lazyrestic-mount-1", Cmd: mountCmd})
+	m = updated.(Model)
+	if m.mountCmd != mountCmd || m.mountPoint != "/tmp/lazyrestic-mount-1" {
+		t.Fatal("A successful mount should be tracked")
+	}
+	if last := m.opsPanel.FilteredLogs(); !strings.Contains(last[len(last)-1].Message, "/tmp/lazyrestic-mount-1/snapshots") {
+		t.Errorf("The mount path should be shown, got %q", last[len(last)-1].Message)
+	}
+
+	// A second press unmounts
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("n should unmount while a mount is running")
+	}
+	updated, _ = m.Update(cmd())
+	m = updated.(Model)
+	if m.mountCmd != nil || m.mountPoint != "" {
+		t.Error("Unmounting should clear the tracked mount")
+	}
+}
+
+func TestRepairComplete_ReloadsRepository(t *testing.T) {
+	m := Model{
+		config: &types.ResticConfig{Repositories: []types.RepositoryConfig{
+			{Name: "alpha"}, {Name: "beta"},
+		}},
+		repairInProgress: true,
+		opsPanel:         ui.NewOperationsPanel(),
+	}
+
+	updated, cmd := m.Update(RepairCompleteMsg{RepoName: "beta"})
+	m = updated.(Model)
+	if m.repairInProgress {
+		t.Error("repairInProgress should be cleared")
+	}
+	if cmd == nil {
+		t.Error("A successful repair should reload the repository info")
+	}
+
+	m.repairInProgress = true
+	updated, cmd = m.Update(RepairCompleteMsg{RepoName: "beta", Error: fmt.Errorf("exit status 1")})
+	m = updated.(Model)
+	if cmd != nil {
+		t.Error("A failed repair should not reload the repository")
+	}
+	logs := m.opsPanel.FilteredLogs()
+	if last := logs[len(logs)-1]; last.Level != "error" || !strings.Contains(last.Message, "Index repair failed") {
+		t.Errorf("Unexpected last log entry: %+v", last)
+	}
+}
+
+func TestForgetComplete_UnreachableKeepsMarks(t *testing.T) {
+	snapPanel := ui.NewSnapshotPanel()
+	snapPanel.SetSize(80, 20)
+	snapPanel.SetSnapshots([]types.Snapshot{{ID: "aaaa1111aaaa1111", ShortID: "aaaa1111"}})
+	snapPanel.ToggleMark()
+
+	m := Model{
+		config:           &types.ResticConfig{},
+		opsPanel:         ui.NewOperationsPanel(),
+		snapPanel:        snapPanel,
+		forgetInProgress: true,
+	}
+
+	err := fmt.Errorf("%w: restic command failed: exit status 1", restic.ErrUnreachable)
+	updated, cmd := m.Update(ForgetCompleteMsg{IDs: []string{"aaaa1111aaaa1111"}, Error: err})
+	m = updated.(Model)
+
+	if m.forgetInProgress {
+		t.Error("forgetInProgress should be cleared")
+	}
+	if len(m.snapPanel.MarkedSnapshots()) != 1 {
+		t.Error("Marks should be kept when the precheck fails")
+	}
+	if cmd != nil {
+		t.Error("Snapshots should not be reloaded when nothing ran")
+	}
+
+	logs := m.opsPanel.FilteredLogs()
+	var found bool
+	for _, entry := range logs {
+		if entry.Level == "error" && strings.Contains(entry.Message, "repository unreachable or wrong password") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected an unreachable error in the log, got %+v", logs)
+	}
+}
+
+func TestSnapshotFilter_HostQueryReloadsFromRestic(t *testing.T) {
+	snapPanel := ui.NewSnapshotPanel()
+	snapPanel.SetSnapshots([]types.Snapshot{{ID: "aaaa1111", ShortID: "aaaa1111", Hostname: "web01"}})
+
+	m := Model{
+		config:       &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo"}}},
+		repositories: []types.Repository{{Name: "repo", Path: "/tmp/repo"}},
+		activePanel:  types.PanelSnapshots,
+		opsPanel:     ui.NewOperationsPanel(),
+		snapPanel:    snapPanel,
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	m = updated.(Model)
+	for _, r := range "host:web01" {
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = updated.(Model)
+	}
+	if m.snapPanel.IsFilterActive() {
+		t.Error("host: terms should not filter the loaded list as text while typing")
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("Enter with a host: term should reload snapshots")
+	}
+	if m.snapshotQuery.host != "web01" || m.snapPanel.ServerFilter() != "host:web01" {
+		t.Errorf("Restic-side filter not set: query=%+v panel=%q", m.snapshotQuery, m.snapPanel.ServerFilter())
+	}
+
+	// Clearing the filter drops the restic-side query and reloads everything
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if cmd == nil || m.snapshotQuery.serverSide() || m.snapPanel.ServerFilter() != "" {
+		t.Error("Esc should clear the restic-side filter and reload snapshots")
+	}
+}
+
+func TestSnapshotFilter_TagAndHostFacets(t *testing.T) {
+	snapPanel := ui.NewSnapshotPanel()
+	snapPanel.SetSnapshots([]types.Snapshot{
+		{ID: "aaaa1111", ShortID: "aaaa1111", Hostname: "web", Tags: []string{"daily"}},
+		{ID: "bbbb2222", ShortID: "bbbb2222", Hostname: "web", Tags: []string{"weekly"}},
+		{ID: "cccc3333", ShortID: "cccc3333", Hostname: "db", Tags: []string{"daily"}},
+	})
+	m := Model{
+		activePanel: types.PanelSnapshots,
+		opsPanel:    ui.NewOperationsPanel(),
+		snapPanel:   snapPanel,
+	}
+
+	enterFacet := func(key, value string) {
+		t.Helper()
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		m = updated.(Model)
+		for len(m.filterInputText) > 0 {
+			updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+			m = updated.(Model)
+		}
+		for _, r := range value {
+			updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+			m = updated.(Model)
+		}
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = updated.(Model)
+	}
+
+	enterFacet("H", "web")
+	enterFacet("T", "daily")
+	if m.snapPanel.HostFilter() != "web" || m.snapPanel.TagFilter() != "daily" {
+		t.Fatalf("Facets not set: host=%q tag=%q", m.snapPanel.HostFilter(), m.snapPanel.TagFilter())
+	}
+	if visible := m.snapPanel.VisibleSnapshots(); len(visible) != 1 || visible[0].ID != "aaaa1111" {
+		t.Errorf("host=web tag=daily should leave only aaaa1111, got %+v", visible)
+	}
+
+	// An empty tag clears only the tag facet
+	enterFacet("T", "")
+	if m.snapPanel.TagFilter() != "" || m.snapPanel.HostFilter() != "web" {
+		t.Errorf("Only the tag facet should clear: host=%q tag=%q", m.snapPanel.HostFilter(), m.snapPanel.TagFilter())
+	}
+
+	// c clears every facet
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	m = updated.(Model)
+	if m.snapPanel.IsFilterActive() {
+		t.Error("c should clear all snapshot filters")
+	}
+}
+
+func TestRestorePreview_ConfirmStartsRestore(t *testing.T) {
+	m := Model{
+		config:       &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo"}}},
+		repositories: []types.Repository{{Name: "repo", Path: "/tmp/repo"}},
+		opsPanel:     ui.NewOperationsPanel(),
+	}
+	opts := types.RestoreOptions{SnapshotID: "abc123def456", Target: "/tmp/restore"}
+
+	updated, _ := m.Update(RestorePreviewMsg{Options: opts, Paths: []string{"/tmp/restore/a", "/tmp/restore/b"}})
+	m = updated.(Model)
+	if !m.showRestorePreview || len(m.restorePreviewPaths) != 2 {
+		t.Fatalf("Preview should open with the dry-run paths, got %v %v", m.showRestorePreview, m.restorePreviewPaths)
+	}
+	if m.restoreInProgress {
+		t.Fatal("Restore must not start before confirmation")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	m = updated.(Model)
+	if m.restorePreviewOffset != 1 {
+		t.Errorf("j should scroll the preview, offset = %d", m.restorePreviewOffset)
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.showRestorePreview || !m.restoreInProgress || cmd == nil {
+		t.Error("Enter should close the preview and start the restore")
+	}
+}
+
+func TestRestorePreview_EscCancels(t *testing.T) {
+	m := Model{opsPanel: ui.NewOperationsPanel()}
+
+	updated, _ := m.Update(RestorePreviewMsg{Options: types.RestoreOptions{SnapshotID: "abc", Target: "/tmp/restore"}})
+	m = updated.(Model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if m.showRestorePreview || m.restoreInProgress {
+		t.Error("Esc should close the preview without restoring")
+	}
+}
+
+func TestOperationsPanel_PgUpScrollsLogWhenActive(t *testing.T) {
+	opsPanel := ui.NewOperationsPanel()
+	opsPanel.SetSize(100, 18)
+	for i := 0; i < 20; i++ {
+		opsPanel.Info(fmt.Sprintf("entry %d", i))
+	}
+	m := Model{opsPanel: opsPanel, activePanel: types.PanelSnapshots, snapPanel: ui.NewSnapshotPanel()}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	m = updated.(Model)
+	if m.opsPanel.ScrollOffset() != 0 {
+		t.Error("PgUp should only scroll the log when the Operations panel is active")
+	}
+
+	m.activePanel = types.PanelOperations
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	m = updated.(Model)
+	if m.opsPanel.ScrollOffset() == 0 {
+		t.Error("PgUp should scroll the operations log back")
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	m = updated.(Model)
+	if m.opsPanel.ScrollOffset() != 0 {
+		t.Errorf("PgDown should scroll back to the newest entries, offset = %d", m.opsPanel.ScrollOffset())
+	}
+}
+
+func TestSnapshotLoadError_RetryLoadsAgain(t *testing.T) {
+	snapPanel := ui.NewSnapshotPanel()
+	snapPanel.SetRetryKey("t")
+	snapPanel.SetSize(80, 20)
+	m := Model{
+		config:       &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo"}}},
+		repositories: []types.Repository{{Name: "repo", Path: "/tmp/repo"}},
+		activePanel:  types.PanelSnapshots,
+		opsPanel:     ui.NewOperationsPanel(),
+		snapPanel:    snapPanel,
+		metricsPanel: ui.NewRepoMetricsPanel(),
+	}
+	retryKey := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}}
+
+	// Nothing to retry before a load has failed
+	if _, cmd := m.Update(retryKey); cmd != nil {
+		t.Error("Retry should do nothing when the last load succeeded")
+	}
+
+	m.loadingSnapshots = true
+	loadErr := fmt.Errorf("restic command failed: exit status 1 (output: Fatal: dial tcp 10.0.0.5:443: i/o timeout)")
+	updated, _ := m.Update(SnapshotsLoadedMsg{Error: loadErr, CmdLog: SnapshotsLoadStartMsg{RepoName: "repo", RepoPath: "/tmp/repo"}})
+	m = updated.(Model)
+	if m.snapshotLoadError == nil {
+		t.Fatal("The failed load should be tracked on the model")
+	}
+	if view := m.snapPanel.Render(true); !strings.Contains(view, "press t to retry") || !strings.Contains(view, "network timeout") {
+		t.Errorf("Snapshots panel should explain the failure and offer a retry, got:\n%s", view)
+	}
+
+	updated, cmd := m.Update(retryKey)
+	m = updated.(Model)
+	if cmd == nil || !m.loadingSnapshots {
+		t.Fatal("Retry should start loading snapshots again")
+	}
+
+	updated, _ = m.Update(SnapshotsLoadedMsg{
+		Snapshots: []types.Snapshot{{ID: "aaaa1111", ShortID: "aaaa1111"}},
+		CmdLog:    SnapshotsLoadStartMsg{RepoName: "repo", RepoPath: "/tmp/repo"},
+	})
+	m = updated.(Model)
+	if m.snapshotLoadError != nil {
+		t.Errorf("A successful retry should clear the error, got %v", m.snapshotLoadError)
+	}
+	if view := m.snapPanel.Render(true); strings.Contains(view, "retry") || !strings.Contains(view, "aaaa1111") {
+		t.Errorf("Snapshots panel should list the loaded snapshots without the retry hint, got:\n%s", view)
+	}
+}
+
+func TestLoadSnapshots_FakeClient(t *testing.T) {
+	factory := &fakeFactory{snapshots: []types.Snapshot{
+		{ID: "aaaa1111", ShortID: "aaaa1111", Hostname: "web01", Paths: []string{"/srv"}},
+		{ID: "bbbb2222", ShortID: "bbbb2222", Hostname: "web01", Paths: []string{"/tmp/systemd-private-abc"}},
+		{ID: "cccc3333", ShortID: "cccc3333", Hostname: "db01", Paths: []string{"/var/lib/db"}},
+	}}
+	snapPanel := ui.NewSnapshotPanel()
+	snapPanel.SetSize(80, 20)
+	m := Model{
+		config:        &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo"}}},
+		opsPanel:      ui.NewOperationsPanel(),
+		snapPanel:     snapPanel,
+		metricsPanel:  ui.NewRepoMetricsPanel(),
+		clientFactory: factory,
+	}
+
+	msg := m.loadSnapshots()
+	loaded, ok := msg.(SnapshotsLoadedMsg)
+	if !ok {
+		t.Fatalf("Expected SnapshotsLoadedMsg, got %T", msg)
+	}
+	if len(loaded.Snapshots) != 2 || loaded.FilteredCount != 1 {
+		t.Fatalf("Expected 2 snapshots and 1 systemd-private filtered, got %d and %d", len(loaded.Snapshots), loaded.FilteredCount)
+	}
+	if len(factory.calls) != 1 || factory.calls[0] != "ListSnapshots" {
+		t.Errorf("Expected a single ListSnapshots call, got %v", factory.calls)
+	}
+
+	updated, _ := m.Update(loaded)
+	m = updated.(Model)
+	if view := m.snapPanel.Render(true); !strings.Contains(view, "aaaa1111") || !strings.Contains(view, "cccc3333") {
+		t.Errorf("Snapshots panel should list the fake snapshots, got:\n%s", view)
+	}
+
+	// Server-side queries use the filtered listing
+	factory.calls = nil
+	m.snapshotQuery = parseSnapshotQuery("host:db01")
+	loaded = m.loadSnapshots().(SnapshotsLoadedMsg)
+	if len(loaded.Snapshots) != 1 || loaded.Snapshots[0].ID != "cccc3333" {
+		t.Errorf("Expected only the db01 snapshot, got %+v", loaded.Snapshots)
+	}
+	if len(factory.calls) != 1 || factory.calls[0] != "ListSnapshotsFiltered" {
+		t.Errorf("Expected a ListSnapshotsFiltered call, got %v", factory.calls)
+	}
+}
+
+func TestUnlock_FakeClient(t *testing.T) {
+	factory := &fakeFactory{err: errors.New("repository is already locked by PID 42")}
+	m := Model{
+		config:        &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo"}}},
+		opsPanel:      ui.NewOperationsPanel(),
+		clientFactory: factory,
+	}
+
+	msg := m.unlockRepository(false)()
+	if len(factory.calls) != 1 || factory.calls[0] != "Unlock" {
+		t.Fatalf("Expected an Unlock call, got %v", factory.calls)
+	}
+	result := msg.(CommandResultMsg)
+	if result.Name != "unlock" || result.Error == nil {
+		t.Errorf("Expected the fake's error in the result, got %+v", result)
+	}
+}
+
+func TestMigrate_FakeClient(t *testing.T) {
+	factory := &fakeFactory{migrations: []string{"upgrade_repo_v2"}}
+	m := Model{
+		config:        &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo"}}},
+		opsPanel:      ui.NewOperationsPanel(),
+		clientFactory: factory,
+	}
+
+	updated, _ := m.Update(m.listMigrations()())
+	m = updated.(Model)
+	if !m.showMigrations || m.migrationRepo != "repo" || len(m.migrations) != 1 {
+		t.Fatalf("Expected the migration list for 'repo', got %v", m.migrations)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if !m.showMigrateConfirm || m.migrateConfirmDialog == nil {
+		t.Fatal("Enter should ask for confirmation before migrating")
+	}
+
+	cmd := m.executeMigration(context.Background(), "repo", "upgrade_repo_v2")
+	msg := cmd()
+	for {
+		output, ok := msg.(MigrationOutputMsg)
+		if !ok {
+			break
+		}
+		msg = listenForMigrationUpdates(output.RepoName, output.Migration, output.Updates)()
+	}
+	complete := msg.(MigrationCompleteMsg)
+	if complete.Error != nil || complete.Migration != "upgrade_repo_v2" {
+		t.Errorf("Unexpected result: %+v", complete)
+	}
+	if factory.calls[len(factory.calls)-1] != "Migrate upgrade_repo_v2" {
+		t.Errorf("Expected the migration to run, got calls %v", factory.calls)
+	}
+}
+
+func TestMigrate_NoneAvailable(t *testing.T) {
+	m := Model{config: &types.ResticConfig{}, opsPanel: ui.NewOperationsPanel()}
+
+	updated, _ := m.Update(MigrationsListedMsg{RepoName: "repo"})
+	m = updated.(Model)
+	if m.showMigrations {
+		t.Error("The migration list should not open when nothing is available")
+	}
+	logs := m.opsPanel.FilteredLogs()
+	if len(logs) == 0 || !strings.Contains(logs[len(logs)-1].Message, "No migrations available") {
+		t.Errorf("Expected a no migrations message, got %+v", logs)
+	}
+}
+
+// isQuit reports whether cmd quits the program
+func isQuit(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	_, ok := cmd().(tea.QuitMsg)
+	return ok
+}
+
+func TestQuit_DeferredWhileOperationRuns(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := Model{
+		config:           &types.ResticConfig{},
+		opsPanel:         ui.NewOperationsPanel(),
+		backupInProgress: true,
+		cancelOperation:  cancel,
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	m = updated.(Model)
+	if isQuit(cmd) || !m.showQuitConfirm {
+		t.Fatal("Quitting during a backup should ask for confirmation first")
+	}
+
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	m = updated.(Model)
+	if ctx.Err() == nil {
+		t.Error("Confirming should cancel the running backup")
+	}
+	if !m.quitPending || cmd == nil {
+		t.Fatal("Quitting should wait for the backup to stop")
+	}
+
+	// Still running: keep waiting
+	updated, cmd = m.Update(QuitWaitMsg{Started: time.Now()})
+	m = updated.(Model)
+	if isQuit(cmd) {
+		t.Fatal("Quit before the cancelled backup stopped")
+	}
+
+	// The cancelled backup reports back, then quitting proceeds
+	updated, _ = m.Update(BackupSummaryMsg{Error: context.Canceled})
+	m = updated.(Model)
+	_, cmd = m.Update(QuitWaitMsg{Started: time.Now()})
+	if !isQuit(cmd) {
+		t.Error("Expected to quit once the backup stopped")
+	}
+}
+
+func TestQuit_ConfirmDeclined(t *testing.T) {
+	m := Model{config: &types.ResticConfig{}, opsPanel: ui.NewOperationsPanel(), operationInProgress: "cache cleanup"}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	m = updated.(Model)
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if isQuit(cmd) || m.showQuitConfirm || m.quitPending {
+		t.Error("Declining should keep LazyRestic running")
+	}
+}
+
+func TestQuit_IdleQuitsImmediately(t *testing.T) {
+	m := Model{config: &types.ResticConfig{}, opsPanel: ui.NewOperationsPanel(), loadingSnapshots: true}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if !isQuit(cmd) {
+		t.Error("Quitting without a running operation should not ask")
+	}
+}
+
+func TestWindowResize_ResizesOpenOverlays(t *testing.T) {
+	m := Model{
+		config:       &types.ResticConfig{},
+		repoPanel:    ui.NewRepositoryPanel(),
+		metricsPanel: ui.NewRepoMetricsPanel(),
+		snapPanel:    ui.NewSnapshotPanel(),
+		opsPanel:     ui.NewOperationsPanel(),
+		repoForm:     ui.NewRepoForm(),
+		backupForm:   ui.NewBackupForm(),
+		width:        120,
+		height:       40,
+	}
+	m.openRestoreForm(&types.Snapshot{ID: "abcd1234"})
+	m.showRestoreForm = true
+
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 180, Height: 60})
+	m = updated.(Model)
+	if w, h := m.restoreForm.GetWidth(), m.restoreForm.GetHeight(); w != 120 || h != 40 {
+		t.Errorf("Restore form size after resize = %dx%d, want 120x40", w, h)
+	}
+}
+
+func TestSizeHistory_OneStatsAtATimeAndPausedDuringBackup(t *testing.T) {
+	m := Model{
+		config:        &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo"}}},
+		opsPanel:      ui.NewOperationsPanel(),
+		snapPanel:     ui.NewSnapshotPanel(),
+		metricsPanel:  ui.NewRepoMetricsPanel(),
+		clientFactory: &fakeFactory{},
+	}
+	m.snapPanel.SetSnapshots([]types.Snapshot{
+		{ID: "aaaa1111", Time: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "bbbb2222", Time: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)},
+	})
+
+	m.backupInProgress = true
+	if cmd := m.fetchSizeHistory(); cmd != nil {
+		t.Fatal("No stats should run while a backup is running")
+	}
+	m.backupInProgress = false
+
+	cmd := m.fetchSizeHistory()
+	if cmd == nil {
+		t.Fatal("Expected the newest snapshot's size to be read")
+	}
+	updated, next := m.Update(cmd())
+	m = updated.(Model)
+	if _, ok := m.snapPanel.RestoreSize("bbbb2222"); !ok || next == nil {
+		t.Fatal("The size should be cached and the next snapshot read")
+	}
+	updated, next = m.Update(next())
+	m = updated.(Model)
+	if next != nil {
+		t.Error("Every recent snapshot is known, nothing more to read")
+	}
+	if _, ok := m.snapPanel.RestoreSize("aaaa1111"); !ok {
+		t.Error("The older snapshot's size should be cached too")
+	}
+}
+
+func TestRepoGroups_CurrentRepositoryStaysListed(t *testing.T) {
+	repos := []types.Repository{
+		{Name: "laptop", Group: "personal"},
+		{Name: "scratch"},
+		{Name: "b2", Group: "offsite"},
+	}
+	m := Model{
+		config: &types.ResticConfig{Repositories: []types.RepositoryConfig{
+			{Name: "laptop", Path: "/tmp/laptop"}, {Name: "scratch", Path: "/tmp/scratch"}, {Name: "b2", Path: "/tmp/b2"},
+		}},
+		repoPanel:     ui.NewRepositoryPanel(),
+		metricsPanel:  ui.NewRepoMetricsPanel(),
+		snapPanel:     ui.NewSnapshotPanel(),
+		opsPanel:      ui.NewOperationsPanel(),
+		clientFactory: &fakeFactory{},
+		keys:          DefaultKeyMap(),
+	}
+
+	// The "offsite" header comes first; the loaded selection is laptop's row
+	updated, _ := m.Update(RepositoriesLoadedMsg{Repositories: repos})
+	m = updated.(Model)
+	if selected := m.repoPanel.GetSelected(); selected == nil || selected.Name != "laptop" {
+		t.Fatalf("Panel selection = %v, want the current repository laptop", selected)
+	}
+
+	// Collapsing laptop's group moves to a repository that is still listed
+	m, cmd := pressKeys(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("z")})
+	if m.repositories[m.currentRepoIndex].Name == "laptop" || cmd == nil {
+		t.Fatalf("Current repository should leave the collapsed group and load, got %s", m.repositories[m.currentRepoIndex].Name)
+	}
+	if selected := m.repoPanel.GetSelected(); selected == nil || selected.Name != m.repositories[m.currentRepoIndex].Name {
+		t.Errorf("Panel selection %v doesn't match the current repository", selected)
+	}
+}
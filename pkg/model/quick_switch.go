@@ -0,0 +1,312 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/craigderington/lazyrestic/pkg/config"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+// maxRecentRepos caps how many recently selected repositories are remembered
+const maxRecentRepos = 20
+
+// maxQuickSwitchRows caps how many matches the quick switch lists
+const maxQuickSwitchRows = 10
+
+// defaultRecentReposPath returns the state file that keeps the recently selected
+// repositories across sessions, next to the config file
+func defaultRecentReposPath() string {
+	return filepath.Join(filepath.Dir(config.DefaultConfigPath()), "recent.json")
+}
+
+// loadRecentRepos reads the recently selected repository names, most recent
+// first. A missing or unreadable state file means no history.
+func loadRecentRepos(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil
+	}
+	return names
+}
+
+// saveRecentRepos writes the recently selected repository names to path
+func saveRecentRepos(path string, names []string) error {
+	data, err := json.Marshal(names)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// noteRecentRepo moves name to the front of the recent repositories and
+// saves them when the model has a state file
+func (m *Model) noteRecentRepo(name string) {
+	recent := []string{name}
+	for _, other := range m.recentRepos {
+		if other != name && len(recent) < maxRecentRepos {
+			recent = append(recent, other)
+		}
+	}
+	m.recentRepos = recent
+
+	if m.recentReposPath == "" {
+		return
+	}
+	if err := saveRecentRepos(m.recentReposPath, recent); err != nil {
+		m.opsPanel.Dimmed(fmt.Sprintf("Couldn't save recent repositories: %v", err))
+	}
+}
+
+// renameRecentRepo replaces a renamed repository in the recent repositories
+// and saves them when it was listed
+func (m *Model) renameRecentRepo(oldName, newName string) {
+	recent := make([]string, 0, len(m.recentRepos))
+	found := false
+	for _, name := range m.recentRepos {
+		switch name {
+		case oldName:
+			recent = append(recent, newName)
+			found = true
+		case newName:
+			// A stale entry for the new name would list it twice
+		default:
+			recent = append(recent, name)
+		}
+	}
+	if !found {
+		return
+	}
+	m.recentRepos = recent
+
+	if m.recentReposPath == "" {
+		return
+	}
+	if err := saveRecentRepos(m.recentReposPath, recent); err != nil {
+		m.opsPanel.Dimmed(fmt.Sprintf("Couldn't save recent repositories: %v", err))
+	}
+}
+
+// fuzzyScore reports whether the characters of query appear in name in order,
+// ignoring case, and scores the best such match. Characters that follow the
+// previous match or start a word score higher, so "prod" ranks "prod-db" above
+// "p-r-o-d" and "pdb" ranks "prod-db" above "update-backups".
+func fuzzyScore(query, name string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	n := []rune(strings.ToLower(name))
+	if len(q) == 0 {
+		return 0, true
+	}
+
+	// best[j] is the best score of the query so far with its last character
+	// matched at n[j], or -1 when it can't be matched there
+	best := make([]int, len(n))
+	for i, r := range q {
+		next := make([]int, len(n))
+		prev := -1 // Best score of the previous characters ending before n[j-1]
+		for j := range n {
+			next[j] = -1
+			if i > 0 && j > 1 && best[j-2] > prev {
+				prev = best[j-2]
+			}
+			if n[j] != r {
+				continue
+			}
+			score := 1
+			if j == 0 || !unicode.IsLetter(n[j-1]) && !unicode.IsDigit(n[j-1]) {
+				score += 3
+			}
+			switch {
+			case i == 0:
+				next[j] = score
+			case j > 0 && best[j-1] >= 0 && best[j-1]+5 >= prev:
+				next[j] = best[j-1] + 5 + score
+			case prev >= 0:
+				next[j] = prev + score
+			}
+		}
+		best = next
+	}
+
+	top := -1
+	for _, score := range best {
+		if score > top {
+			top = score
+		}
+	}
+	return top, top >= 0
+}
+
+// rankRepos returns the names that match query, best first. Equal scores go
+// to the more recently selected repository, then the shorter name. An empty
+// query lists recent repositories first and the rest in config order.
+func rankRepos(query string, names, recent []string) []string {
+	recency := make(map[string]int, len(recent))
+	for i, name := range recent {
+		recency[name] = len(recent) - i
+	}
+
+	type match struct {
+		name  string
+		score int
+		order int
+	}
+	var matches []match
+	for i, name := range names {
+		score, ok := fuzzyScore(query, name)
+		if ok {
+			matches = append(matches, match{name, score, i})
+		}
+	}
+	sort.SliceStable(matches, func(a, b int) bool {
+		x, y := matches[a], matches[b]
+		if x.score != y.score {
+			return x.score > y.score
+		}
+		if recency[x.name] != recency[y.name] {
+			return recency[x.name] > recency[y.name]
+		}
+		if query != "" && len(x.name) != len(y.name) {
+			return len(x.name) < len(y.name)
+		}
+		return x.order < y.order
+	})
+
+	ranked := make([]string, len(matches))
+	for i, match := range matches {
+		ranked[i] = match.name
+	}
+	return ranked
+}
+
+// quickSwitchMatches ranks the loaded repositories against the typed query
+func (m Model) quickSwitchMatches() []string {
+	names := make([]string, len(m.repositories))
+	for i, repo := range m.repositories {
+		names[i] = repo.Name
+	}
+	return rankRepos(m.quickSwitchQuery, names, m.recentRepos)
+}
+
+// openQuickSwitch shows the quick switch with an empty query
+func (m *Model) openQuickSwitch() {
+	m.showQuickSwitch = true
+	m.quickSwitchQuery = ""
+	m.quickSwitchCursor = 0
+}
+
+// updateQuickSwitch handles a key press in the quick switch. Letters go to the
+// query, so the list moves with the arrow keys or Ctrl+N/Ctrl+P.
+func (m *Model) updateQuickSwitch(msg tea.KeyMsg) tea.Cmd {
+	matches := m.quickSwitchMatches()
+
+	switch msg.String() {
+	case "esc":
+		m.showQuickSwitch = false
+	case "up", "ctrl+p":
+		if m.quickSwitchCursor > 0 {
+			m.quickSwitchCursor--
+		}
+	case "down", "ctrl+n":
+		if m.quickSwitchCursor < len(matches)-1 && m.quickSwitchCursor < maxQuickSwitchRows-1 {
+			m.quickSwitchCursor++
+		}
+	case "enter":
+		if len(matches) == 0 {
+			return nil
+		}
+		m.showQuickSwitch = false
+		return m.switchToRepository(matches[m.quickSwitchCursor])
+	case "backspace":
+		if query := []rune(m.quickSwitchQuery); len(query) > 0 {
+			m.quickSwitchQuery = string(query[:len(query)-1])
+			m.quickSwitchCursor = 0
+		}
+	default:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			m.quickSwitchQuery += string(msg.Runes)
+			m.quickSwitchCursor = 0
+		}
+	}
+	return nil
+}
+
+// switchToRepository selects the named repository in the repositories panel,
+// shows its metrics and loads its snapshots
+func (m *Model) switchToRepository(name string) tea.Cmd {
+	for i := range m.repositories {
+		if m.repositories[i].Name != name {
+			continue
+		}
+		m.repoPanel.SelectRepository(name)
+		m.currentRepoIndex = i
+		m.metricsPanel.SetRepository(&m.repositories[i])
+		m.activePanel = types.PanelRepositories
+		m.noteRecentRepo(name)
+		return m.loadSnapshotsWithMessage()
+	}
+	m.opsPanel.Warning(fmt.Sprintf("Repository '%s' not found", name))
+	return nil
+}
+
+// renderQuickSwitch renders the query and the best matching repositories
+func (m Model) renderQuickSwitch() string {
+	dimmedStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Dimmed)
+
+	var b strings.Builder
+	b.WriteString(ui.TitleStyle.Render("Switch repository") + "\n\n")
+	b.WriteString("> " + m.quickSwitchQuery + "_\n\n")
+
+	recent := make(map[string]bool, len(m.recentRepos))
+	for _, name := range m.recentRepos {
+		recent[name] = true
+	}
+
+	matches := m.quickSwitchMatches()
+	if len(matches) == 0 {
+		b.WriteString(dimmedStyle.Render("  No matching repositories") + "\n")
+	}
+	for i, name := range matches {
+		if i == maxQuickSwitchRows {
+			b.WriteString(dimmedStyle.Render(fmt.Sprintf("  … %d more", len(matches)-maxQuickSwitchRows)) + "\n")
+			break
+		}
+		line := name
+		if recent[name] {
+			line += dimmedStyle.Render("  recent")
+		}
+		if i == m.quickSwitchCursor {
+			b.WriteString(ui.ListItemSelectedStyle.Render("▶ "+line) + "\n")
+		} else {
+			b.WriteString(ui.ListItemStyle.Render("  "+line) + "\n")
+		}
+	}
+
+	b.WriteString("\n" + dimmedStyle.Render("Type to filter • ↑/↓ select • Enter to switch • Esc to close"))
+
+	borderStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.CurrentTheme().Active).
+		Padding(1, 2).
+		Width(50)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		borderStyle.Render(b.String()),
+	)
+}
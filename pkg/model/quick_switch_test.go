@@ -0,0 +1,81 @@
+package model
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+func TestRankRepos(t *testing.T) {
+	names := []string{"update-backups", "p-r-o-d", "prod-db", "photos", "prod"}
+
+	tests := []struct {
+		name   string
+		query  string
+		recent []string
+		want   []string
+	}{
+		{"consecutive match first", "prod", nil, []string{"prod", "prod-db", "p-r-o-d"}},
+		{"word starts beat scattered letters", "pdb", nil, []string{"prod-db", "update-backups"}},
+		{"ignores case", "PHO", nil, []string{"photos"}},
+		{"recent breaks ties", "prod", []string{"prod-db"}, []string{"prod-db", "prod", "p-r-o-d"}},
+		{"empty query lists recent first", "", []string{"photos", "prod"}, []string{"photos", "prod", "update-backups", "p-r-o-d", "prod-db"}},
+		{"no match", "xyz", nil, []string{}},
+	}
+	for _, tt := range tests {
+		if got := rankRepos(tt.query, names, tt.recent); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: rankRepos(%q) = %q, want %q", tt.name, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestRecentRepos_PersistedMostRecentFirst(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lazyrestic", "recent.json")
+	m := Model{opsPanel: ui.NewOperationsPanel(), recentReposPath: path}
+
+	m.noteRecentRepo("alpha")
+	m.noteRecentRepo("beta")
+	m.noteRecentRepo("alpha")
+
+	want := []string{"alpha", "beta"}
+	if got := loadRecentRepos(path); !reflect.DeepEqual(got, want) {
+		t.Errorf("loadRecentRepos() = %q, want %q", got, want)
+	}
+}
+
+func TestQuickSwitch_JumpsToRepository(t *testing.T) {
+	factory := &fakeFactory{}
+	repos := []types.Repository{{Name: "home"}, {Name: "photos"}, {Name: "media"}}
+	m := Model{
+		config: &types.ResticConfig{Repositories: []types.RepositoryConfig{
+			{Name: "home", Path: "/tmp/home"}, {Name: "photos", Path: "/tmp/photos"}, {Name: "media", Path: "/tmp/media"},
+		}},
+		repositories:  repos,
+		repoPanel:     ui.NewRepositoryPanel(),
+		metricsPanel:  ui.NewRepoMetricsPanel(),
+		snapPanel:     ui.NewSnapshotPanel(),
+		opsPanel:      ui.NewOperationsPanel(),
+		clientFactory: factory,
+		keys:          DefaultKeyMap(),
+	}
+	m.repoPanel.SetRepositories(repos)
+
+	m, _ = pressKeys(m, tea.KeyMsg{Type: tea.KeyCtrlP})
+	if !m.showQuickSwitch {
+		t.Fatal("Ctrl+P should open the quick switch")
+	}
+	m, cmd := pressKeys(m, append(typeText("med"), tea.KeyMsg{Type: tea.KeyEnter})...)
+	if m.showQuickSwitch || m.currentRepoIndex != 2 || cmd == nil {
+		t.Fatalf("Expected 'media' selected with its snapshots loading, got index %d", m.currentRepoIndex)
+	}
+	if selected := m.repoPanel.GetSelected(); selected == nil || selected.Name != "media" {
+		t.Errorf("Repositories panel selection = %v, want media", selected)
+	}
+	if !reflect.DeepEqual(m.recentRepos, []string{"media"}) {
+		t.Errorf("recentRepos = %q, want [media]", m.recentRepos)
+	}
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/config"
+	"github.com/craigderington/lazyrestic/pkg/restic"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+// splitCommandLine splits a line typed at the command prompt into restic
+// arguments. The line is split on whitespace and never handed to a shell, so
+// shell metacharacters are rejected rather than silently passed to restic.
+// A leading "restic" is dropped.
+func splitCommandLine(line string) ([]string, error) {
+	if char := config.ShellMetacharacter(line); char != "" {
+		return nil, fmt.Errorf("command contains shell character '%s' - commands don't run through a shell", char)
+	}
+
+	args := strings.Fields(line)
+	if len(args) > 0 && args[0] == "restic" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("enter a restic subcommand, e.g. stats --mode raw-data")
+	}
+	return args, nil
+}
+
+// submitCommandPrompt runs the command typed at the prompt, asking for
+// confirmation first unless it only reads the repository
+func (m *Model) submitCommandPrompt() tea.Cmd {
+	args, err := splitCommandLine(m.commandPromptText)
+	if err != nil {
+		m.opsPanel.Warning(err.Error())
+		return nil
+	}
+	m.commandPromptActive = false
+	m.commandPromptText = ""
+
+	verb := restic.MutatingVerb(args)
+	if verb == "" {
+		return m.runRawCommand(args)
+	}
+
+	word := strings.ToUpper(verb)
+	if strings.Trim(word, "ABCDEFGHIJKLMNOPQRSTUVWXYZ-") != "" {
+		// A flag value taken for the subcommand makes a poor confirmation word
+		word = "RUN"
+	}
+	m.rawCommand = args
+	m.rawCommandConfirmDialog = ui.NewConfirmationDialog(
+		"RUN RESTIC COMMAND",
+		fmt.Sprintf("Run this command against '%s'?\n\nrestic %s\n\n%s may change the repository and can't be undone from LazyRestic.", m.config.Repositories[m.currentRepoIndex].Name, strings.Join(args, " "), verb),
+		word,
+	)
+	m.rawCommandConfirmDialog.SetSize(m.width*3/4, m.height*3/4)
+	m.rawCommandConfirmDialog.SetTimeoutSeconds(confirmTimeoutSeconds)
+	m.showRawCommandConfirm = true
+	m.opsPanel.Warning(fmt.Sprintf("⚠️  Type '%s' to run restic %s", word, verb))
+	return scheduleConfirmTimeout(m.rawCommandConfirmDialog)
+}
+
+// runRawCommand runs a restic subcommand against the selected repository,
+// streaming its output to the operations panel
+func (m *Model) runRawCommand(args []string) tea.Cmd {
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return nil
+	}
+
+	repoConfig := m.config.Repositories[m.currentRepoIndex]
+	if !m.claimRepo(repoConfig.Name) {
+		return nil
+	}
+	command := strings.Join(args, " ")
+	m.rawCommandInProgress = true
+	m.opsPanel.Info(fmt.Sprintf("Running restic %s on '%s'...", args[0], repoConfig.Name))
+	m.opsPanel.Dimmed(fmt.Sprintf("Command: restic -r %s %s", repoConfig.Path, command))
+
+	client := m.newClient(repoConfig)
+	ctx := m.operationContext()
+	run := func() tea.Msg {
+		updates := make(chan restic.CheckMessage, 10)
+		go client.RunWithChannel(ctx, args, updates)
+		return waitForRawCommandUpdate(repoConfig.Name, command, updates)
+	}
+	return tea.Batch(run, m.pollLocks())
+}
+
+// waitForRawCommandUpdate waits for the next line or the final result of a typed command
+func waitForRawCommandUpdate(repoName, command string, updates <-chan restic.CheckMessage) tea.Msg {
+	msg, ok := <-updates
+	if !ok {
+		return RawCommandCompleteMsg{RepoName: repoName, Command: command}
+	}
+	if msg.Error != nil {
+		return RawCommandCompleteMsg{RepoName: repoName, Command: command, Error: msg.Error}
+	}
+	return RawCommandOutputMsg{RepoName: repoName, Command: command, Line: msg.Line, Updates: updates}
+}
+
+// listenForRawCommandUpdates continues listening for a typed command's output
+func listenForRawCommandUpdates(repoName, command string, updates <-chan restic.CheckMessage) tea.Cmd {
+	return func() tea.Msg {
+		return waitForRawCommandUpdate(repoName, command, updates)
+	}
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"stats --mode raw-data", []string{"stats", "--mode", "raw-data"}},
+		{"  snapshots\t--host  nas ", []string{"snapshots", "--host", "nas"}},
+		{"restic key list", []string{"key", "list"}},
+		{"find *.conf", []string{"find", "*.conf"}},
+	}
+	for _, tt := range tests {
+		got, err := splitCommandLine(tt.line)
+		if err != nil {
+			t.Errorf("splitCommandLine(%q) failed: %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommandLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestSplitCommandLine_Rejects(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"restic",
+		"snapshots; rm -rf /",
+		"stats | tee out",
+		"ls latest > files.txt",
+		"snapshots --host $(hostname)",
+		"cat config `id`",
+		"check & sleep 1",
+	} {
+		if args, err := splitCommandLine(line); err == nil {
+			t.Errorf("splitCommandLine(%q) = %q, want an error", line, args)
+		}
+	}
+}
+
+func TestCommandPrompt_DestructiveNeedsConfirmation(t *testing.T) {
+	factory := &fakeFactory{}
+	m := Model{
+		config:        &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo"}}},
+		opsPanel:      ui.NewOperationsPanel(),
+		clientFactory: factory,
+	}
+	typeKeys := func(s string) {
+		for _, r := range s {
+			updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+			m = updated.(Model)
+		}
+	}
+
+	typeKeys(":unlock")
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if !m.showRawCommandConfirm || m.rawCommandInProgress {
+		t.Fatal("unlock should wait for confirmation")
+	}
+
+	typeKeys("UNLOCK")
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if !m.rawCommandInProgress || cmd == nil {
+		t.Fatal("Expected the command to run once confirmed")
+	}
+
+	// The command runs first; the lock poll batched with it only ticks later
+	msg := cmd().(tea.BatchMsg)[0]()
+	for {
+		updated, cmd = m.Update(msg)
+		m = updated.(Model)
+		if _, ok := msg.(RawCommandCompleteMsg); ok {
+			break
+		}
+		msg = cmd()
+	}
+	if m.rawCommandInProgress {
+		t.Error("The command should be finished")
+	}
+	if calls := factory.calls; !reflect.DeepEqual(calls, []string{"Run unlock"}) {
+		t.Errorf("Expected restic unlock to run, got %v", calls)
+	}
+}
@@ -0,0 +1,42 @@
+package model
+
+import "fmt"
+
+// markRepoBusy records a restic command starting against the named repository
+func (m *Model) markRepoBusy(name string) {
+	if m.busyRepos == nil {
+		m.busyRepos = make(map[string]int)
+	}
+	m.busyRepos[name]++
+}
+
+// clearRepoBusy records a restic command against the named repository finishing
+func (m *Model) clearRepoBusy(name string) {
+	if m.busyRepos[name] <= 1 {
+		delete(m.busyRepos, name)
+		return
+	}
+	m.busyRepos[name]--
+}
+
+// warnRepoBusy logs a warning and returns true when a restic command is
+// running against the named repository, including a background snapshot load
+func (m Model) warnRepoBusy(name string) bool {
+	if m.busyRepos[name] == 0 {
+		return false
+	}
+	m.opsPanel.Warning(fmt.Sprintf("Operation already running for '%s' - try again when it finishes", name))
+	return true
+}
+
+// claimRepo marks the named repository busy for a command the user started,
+// or rejects it while anything else runs against the repository, so rapid key
+// presses can't start restic processes that fight over its lock. The result
+// message of the command clears the mark.
+func (m *Model) claimRepo(name string) bool {
+	if m.warnRepoBusy(name) {
+		return false
+	}
+	m.markRepoBusy(name)
+	return true
+}
@@ -0,0 +1,216 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/restic"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+func TestRepoBusy_SecondCommandRejected(t *testing.T) {
+	factory := &fakeFactory{}
+	m := Model{
+		config:        &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "alpha", Path: "/repo/alpha"}}},
+		repositories:  []types.Repository{{Name: "alpha", Path: "/repo/alpha"}},
+		opsPanel:      ui.NewOperationsPanel(),
+		snapPanel:     ui.NewSnapshotPanel(),
+		metricsPanel:  ui.NewRepoMetricsPanel(),
+		clientFactory: factory,
+	}
+	press := func(key string) tea.Cmd {
+		updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		m = updated.(Model)
+		return cmd
+	}
+	lastLog := func() string {
+		logs := m.opsPanel.FilteredLogs()
+		return logs[len(logs)-1].Message
+	}
+
+	// A snapshot load in flight keeps unlock and another refresh away
+	load := m.loadSnapshotsWithMessage()
+	if cmd := press("u"); cmd != nil || m.operationInProgress != "" {
+		t.Fatal("Unlock should wait for the snapshot load")
+	}
+	if !strings.Contains(lastLog(), "Operation already running for 'alpha'") {
+		t.Errorf("Expected a busy warning, got %q", lastLog())
+	}
+	if cmd := press("r"); cmd != nil {
+		t.Error("Refresh should wait for the snapshot load")
+	}
+
+	updated, _ := m.Update(load())
+	m = updated.(Model)
+	if m.busyRepos["alpha"] > 0 {
+		t.Fatal("The snapshot result should clear the busy flag")
+	}
+
+	// Unlock runs; a second command for the same repository is rejected until its result
+	cmd := press("u")
+	if cmd == nil || m.busyRepos["alpha"] == 0 {
+		t.Fatal("Unlock should start once the repository is idle")
+	}
+	m.operationInProgress = "" // Only the per-repository flag stands in the way now
+	if press("C") != nil || !strings.Contains(lastLog(), "Operation already running for 'alpha'") {
+		t.Error("Cache cleanup should be rejected while unlock runs")
+	}
+
+	// The command runs first; the spinner tick batched with it comes later
+	updated, _ = m.Update(cmd().(tea.BatchMsg)[0]())
+	m = updated.(Model)
+	if m.busyRepos["alpha"] > 0 {
+		t.Error("The unlock result should clear the busy flag")
+	}
+	if want := []string{"ListSnapshots", "Unlock"}; !reflect.DeepEqual(factory.calls, want) {
+		t.Errorf("Expected calls %v, got %v", want, factory.calls)
+	}
+}
+
+func TestUnlockAll_BlockedDuringOwnBackup(t *testing.T) {
+	m := Model{
+		config:       &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "alpha", Path: "/repo/alpha"}}},
+		repositories: []types.Repository{{Name: "alpha", Path: "/repo/alpha"}},
+		opsPanel:     ui.NewOperationsPanel(),
+		keys:         DefaultKeyMap(),
+	}
+	m.backupInProgress = true
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("U")})
+	m = updated.(Model)
+	if m.showUnlockAllConfirm {
+		t.Fatal("unlock --remove-all should not be offered while LazyRestic's backup holds a lock")
+	}
+
+	// A backup started while the dialog was open also stops it at confirmation
+	m.backupInProgress = false
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("U")})
+	m = updated.(Model)
+	if !m.showUnlockAllConfirm {
+		t.Fatal("Expected the confirmation once idle")
+	}
+	m.backupInProgress = true
+	for _, r := range "UNLOCK" {
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = updated.(Model)
+	}
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if cmd != nil || m.operationInProgress != "" {
+		t.Error("unlock --remove-all should not run while the backup holds a lock")
+	}
+}
+
+func TestRepoBusy_BackupAndRestoreClaimRepository(t *testing.T) {
+	m := Model{
+		config:        &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "alpha", Path: "/repo/alpha"}}},
+		repositories:  []types.Repository{{Name: "alpha", Path: "/repo/alpha"}},
+		opsPanel:      ui.NewOperationsPanel(),
+		snapPanel:     ui.NewSnapshotPanel(),
+		clientFactory: &fakeFactory{},
+	}
+
+	// A snapshot load in flight keeps a backup from starting
+	m.markRepoBusy("alpha")
+	updated, cmd := m.startBackup(types.BackupOptions{Paths: []string{"/srv"}})
+	m = updated.(Model)
+	if cmd != nil || m.backupInProgress {
+		t.Fatal("Backup should wait for the snapshot load")
+	}
+	m.clearRepoBusy("alpha")
+
+	// A dry run, so its result doesn't reload the snapshots
+	updated, _ = m.startBackup(types.BackupOptions{Paths: []string{"/srv"}, DryRun: true})
+	m = updated.(Model)
+	if !m.backupInProgress || m.busyRepos["alpha"] != 1 {
+		t.Fatal("Backup should claim the idle repository")
+	}
+	updated, cmd = m.startRestore(types.RestoreOptions{SnapshotID: "aaaa1111", Target: t.TempDir()})
+	m = updated.(Model)
+	if cmd != nil || m.restoreInProgress {
+		t.Fatal("Restore should be rejected while the backup runs")
+	}
+
+	updated, _ = m.Update(BackupSummaryMsg{Summary: &types.BackupSummary{}})
+	m = updated.(Model)
+	if m.busyRepos["alpha"] != 0 {
+		t.Fatal("The backup result should release the repository")
+	}
+
+	updated, _ = m.startRestore(types.RestoreOptions{SnapshotID: "aaaa1111", Target: t.TempDir()})
+	m = updated.(Model)
+	if !m.restoreInProgress || m.busyRepos["alpha"] != 1 {
+		t.Fatal("Restore should claim the idle repository")
+	}
+	updated, _ = m.Update(RestoreSummaryMsg{})
+	m = updated.(Model)
+	if m.busyRepos["alpha"] != 0 {
+		t.Error("The restore result should release the repository")
+	}
+}
+
+func TestRerun_RefusedWhileRepositoryBusy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake restic script requires a POSIX shell")
+	}
+	// Record an unlock of alpha as the last command with a fake restic
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "restic"), []byte("#!/bin/sh\necho ok\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	repoConfig := types.RepositoryConfig{Name: "alpha", Path: "/repo/alpha"}
+	if _, err := restic.NewClient(repoConfig).Unlock(); err != nil {
+		t.Fatalf("Unlock() failed: %v", err)
+	}
+
+	m := Model{
+		config:       &types.ResticConfig{Repositories: []types.RepositoryConfig{repoConfig}},
+		repositories: []types.Repository{{Name: "alpha", Path: "/repo/alpha"}},
+		opsPanel:     ui.NewOperationsPanel(),
+		keys:         DefaultKeyMap(),
+		busyRepos:    map[string]int{"alpha": 1}, // e.g. a key change still running
+	}
+
+	// unlock may change the repository, so the re-run is confirmed first
+	m, _ = pressKeys(m, typeText(".")...)
+	if !m.showRerunConfirm {
+		t.Fatal("Re-running unlock should ask for confirmation")
+	}
+	m, cmd := pressKeys(m, append(typeText("UNLOCK"), tea.KeyMsg{Type: tea.KeyEnter})...)
+	if cmd != nil || m.operationInProgress != "" {
+		t.Fatal("The re-run should not start while the repository is busy")
+	}
+	logs := m.opsPanel.FilteredLogs()
+	if last := logs[len(logs)-1].Message; !strings.Contains(last, "Operation already running for 'alpha'") {
+		t.Errorf("Expected a busy warning, got %q", last)
+	}
+}
+
+func TestRawCommand_RefusedWhileRepositoryBusy(t *testing.T) {
+	factory := &fakeFactory{}
+	m := Model{
+		config:        &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "alpha", Path: "/repo/alpha"}}},
+		opsPanel:      ui.NewOperationsPanel(),
+		clientFactory: factory,
+		busyRepos:     map[string]int{"alpha": 1},
+	}
+
+	m, cmd := pressKeys(m, append(typeText(":snapshots"), tea.KeyMsg{Type: tea.KeyEnter})...)
+	if cmd != nil || m.rawCommandInProgress {
+		t.Fatal("A typed command should not start while the repository is busy")
+	}
+	if len(factory.calls) != 0 {
+		t.Errorf("Expected no restic calls, got %v", factory.calls)
+	}
+	logs := m.opsPanel.FilteredLogs()
+	if len(logs) == 0 || !strings.Contains(logs[len(logs)-1].Message, "Operation already running for 'alpha'") {
+		t.Errorf("Expected a busy warning, got %+v", logs)
+	}
+}
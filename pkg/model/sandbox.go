@@ -0,0 +1,176 @@
+package model
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+// sandboxPrefix names the temporary directories snapshots are restored into for inspection
+const sandboxPrefix = "lazyrestic-sandbox-"
+
+// restoreToSandbox restores the selected snapshot into a new temporary
+// directory, skipping the restore form
+func (m Model) restoreToSandbox() (tea.Model, tea.Cmd) {
+	snapshot := m.snapPanel.GetSelected()
+	if snapshot == nil {
+		m.opsPanel.Warning("No snapshot selected")
+		return m, nil
+	}
+	if m.restoreInProgress {
+		m.opsPanel.Warning("Restore already in progress")
+		return m, nil
+	}
+	// Check before creating the directory; startRestore claims the repository
+	if m.currentRepoIndex < len(m.config.Repositories) && m.warnRepoBusy(m.config.Repositories[m.currentRepoIndex].Name) {
+		return m, nil
+	}
+
+	m.opsPanel.Dimmed(fmt.Sprintf("Checking free space in %s...", os.TempDir()))
+	return m, m.checkSandboxSpace(snapshot.ID)
+}
+
+// checkSandboxSpace compares the restore size of a snapshot with the free
+// space in the temporary directory sandboxes are created in
+func (m Model) checkSandboxSpace(snapshotID string) tea.Cmd {
+	if m.currentRepoIndex >= len(m.config.Repositories) {
+		return nil
+	}
+
+	client := m.newClient(m.config.Repositories[m.currentRepoIndex])
+	restoreSize := func() (int64, error) {
+		stats, err := client.GetSnapshotStats(snapshotID)
+		if err != nil {
+			return 0, err
+		}
+		return stats.TotalSize, nil
+	}
+	if size, ok := m.snapPanel.RestoreSize(snapshotID); ok {
+		restoreSize = func() (int64, error) { return size, nil }
+	}
+
+	opts := types.RestoreOptions{SnapshotID: snapshotID, Target: os.TempDir()}
+	return func() tea.Msg {
+		return SandboxSpaceMsg{SnapshotID: snapshotID, Space: restoreSpaceCheck(opts, restoreSize, freeDiskSpace)}
+	}
+}
+
+// startSandboxRestore creates the sandbox directory and restores the snapshot
+// into it, unless the temporary directory is too small for it
+func (m Model) startSandboxRestore(msg SandboxSpaceMsg) (tea.Model, tea.Cmd) {
+	switch space := msg.Space; {
+	case space.insufficient():
+		m.opsPanel.Warning(fmt.Sprintf("⚠ Not enough free space in %s for a sandbox: the snapshot needs %s, %s available",
+			os.TempDir(), ui.FormatBytes(space.Required), ui.FormatBytes(int64(space.Available))))
+		m.opsPanel.Dimmed("Restore it elsewhere with the restore form, or set TMPDIR to a larger disk")
+		return m, nil
+	case space.Skipped != "":
+		m.opsPanel.Dimmed(fmt.Sprintf("Free-space check skipped: %s", space.Skipped))
+	}
+	// Something may have started while the space was checked
+	if m.restoreInProgress {
+		m.opsPanel.Warning("Restore already in progress")
+		return m, nil
+	}
+	if m.currentRepoIndex < len(m.config.Repositories) && m.warnRepoBusy(m.config.Repositories[m.currentRepoIndex].Name) {
+		return m, nil
+	}
+
+	target, err := os.MkdirTemp("", sandboxPrefix)
+	if err != nil {
+		m.opsPanel.Error(fmt.Sprintf("Failed to create sandbox directory: %v", err))
+		return m, nil
+	}
+	m.sandboxTarget = target
+	m.opsPanel.Dimmed(fmt.Sprintf("Sandbox: %s", target))
+
+	return m.startRestore(types.RestoreOptions{SnapshotID: msg.SnapshotID, Target: target})
+}
+
+// removeSandbox deletes a sandbox directory. restic restores the snapshot's
+// permissions, so read-only directories are made writable first.
+func removeSandbox(dir string) error {
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.IsDir() {
+			if info, err := entry.Info(); err == nil && info.Mode().Perm()&0700 != 0700 {
+				os.Chmod(path, info.Mode().Perm()|0700)
+			}
+		}
+		return nil
+	})
+	return os.RemoveAll(dir)
+}
+
+// finishSandboxRestore reports where a sandbox restore put the snapshot and
+// keeps the directory for cleanup on quit. A failed restore's directory is removed.
+func (m *Model) finishSandboxRestore(err error) {
+	target := m.sandboxTarget
+	m.sandboxTarget = ""
+
+	if err != nil {
+		removeSandbox(target)
+		return
+	}
+	m.sandboxes = append(m.sandboxes, target)
+
+	clip := m.clipboard
+	if clip == nil {
+		clip = osc52Clipboard{}
+	}
+	if err := clip.Copy(target); err != nil {
+		m.opsPanel.Info(fmt.Sprintf("Snapshot restored to %s", target))
+		return
+	}
+	m.opsPanel.Success(fmt.Sprintf("Snapshot restored to %s (path copied to clipboard)", target))
+}
+
+// removeSandboxes deletes the sandbox directories restored this session
+func (m *Model) removeSandboxes() {
+	for _, dir := range m.sandboxes {
+		if err := removeSandbox(dir); err != nil {
+			m.opsPanel.Warning(fmt.Sprintf("Failed to remove sandbox %s: %v", dir, err))
+		}
+	}
+	m.sandboxes = nil
+}
+
+// requestQuit quits, first offering to remove this session's restore sandboxes
+func (m *Model) requestQuit() tea.Cmd {
+	if len(m.sandboxes) > 0 {
+		m.showSandboxCleanup = true
+		return nil
+	}
+	return m.quit()
+}
+
+// renderSandboxCleanup asks whether to remove the restore sandboxes before quitting
+func (m Model) renderSandboxCleanup() string {
+	dimmedStyle := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Dimmed)
+
+	var b strings.Builder
+	b.WriteString(ui.TitleStyle.Render("Remove restore sandboxes?") + "\n\n")
+	for _, dir := range m.sandboxes {
+		b.WriteString("  " + dir + "\n")
+	}
+	b.WriteString("\n" + dimmedStyle.Render("y to remove them and quit • n to keep them and quit • Esc to stay"))
+
+	borderStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.CurrentTheme().Active).
+		Padding(1, 2)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		borderStyle.Render(b.String()),
+	)
+}
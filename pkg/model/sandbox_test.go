@@ -0,0 +1,123 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+func TestRestoreToSandbox(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	snapPanel := ui.NewSnapshotPanel()
+	snapPanel.SetSnapshots([]types.Snapshot{{ID: "aaaa1111bbbb2222", ShortID: "aaaa1111"}})
+
+	var copied string
+	factory := &fakeFactory{}
+	m := Model{
+		config:        &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "repo", Path: "/tmp/repo"}}},
+		opsPanel:      ui.NewOperationsPanel(),
+		snapPanel:     snapPanel,
+		clipboard:     fakeClipboard{copied: &copied},
+		clientFactory: factory,
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	m = updated.(Model)
+	if cmd == nil || m.sandboxTarget != "" {
+		t.Fatal("The free space should be checked before the sandbox is created")
+	}
+	updated, cmd = m.Update(cmd())
+	m = updated.(Model)
+	target := m.sandboxTarget
+	if target == "" || !strings.HasPrefix(filepath.Base(target), sandboxPrefix) {
+		t.Fatalf("Expected a sandbox directory, got %q", target)
+	}
+	if !m.restoreInProgress {
+		t.Fatal("The sandbox restore should start right away")
+	}
+
+	// The restore runs first; the lock poll batched with it only ticks later
+	msg := cmd().(tea.BatchMsg)[0]()
+	if len(factory.restores) != 1 || factory.restores[0].Target != target || factory.restores[0].SnapshotID != "aaaa1111bbbb2222" {
+		t.Fatalf("Expected a restore into %s, got %+v", target, factory.restores)
+	}
+
+	updated, _ = m.Update(msg)
+	m = updated.(Model)
+	if copied != target || len(m.sandboxes) != 1 || m.sandboxTarget != "" {
+		t.Errorf("Expected the sandbox to be kept and its path copied, got copied %q, sandboxes %v", copied, m.sandboxes)
+	}
+
+	// Quitting offers to remove the sandbox
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	m = updated.(Model)
+	if isQuit(cmd) || !m.showSandboxCleanup {
+		t.Fatal("Quitting should offer to remove the sandbox first")
+	}
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if !isQuit(cmd) {
+		t.Error("Expected to quit after removing the sandbox")
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("Sandbox %s should be removed, stat error %v", target, err)
+	}
+}
+
+func TestRestoreToSandbox_FailureRemovesDirectory(t *testing.T) {
+	target := t.TempDir()
+	m := Model{config: &types.ResticConfig{}, opsPanel: ui.NewOperationsPanel(), restoreInProgress: true, sandboxTarget: target}
+
+	updated, _ := m.Update(RestoreSummaryMsg{Error: os.ErrPermission})
+	m = updated.(Model)
+	if len(m.sandboxes) != 0 {
+		t.Errorf("A failed restore should not be kept as a sandbox, got %v", m.sandboxes)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Error("The failed sandbox directory should be removed")
+	}
+}
+
+func TestRestoreToSandbox_NotEnoughSpace(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	m := Model{config: &types.ResticConfig{}, opsPanel: ui.NewOperationsPanel()}
+
+	updated, cmd := m.Update(SandboxSpaceMsg{SnapshotID: "aaaa1111", Space: restoreSpace{Required: 2 << 30, Available: 1 << 30}})
+	m = updated.(Model)
+	if cmd != nil || m.restoreInProgress || m.sandboxTarget != "" {
+		t.Fatal("A sandbox should not be restored into a temporary directory that is too small")
+	}
+	if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+		t.Errorf("No sandbox directory should be created, found %d entries", len(entries))
+	}
+	logs := m.opsPanel.FilteredLogs()
+	if len(logs) < 2 || !strings.Contains(logs[len(logs)-2].Message, "Not enough free space") {
+		t.Errorf("Expected a free-space warning, got %+v", logs)
+	}
+}
+
+func TestRemoveSandbox_ReadOnlyDirectories(t *testing.T) {
+	sandbox := filepath.Join(t.TempDir(), sandboxPrefix+"1")
+	locked := filepath.Join(sandbox, "etc", "locked")
+	if err := os.MkdirAll(locked, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(locked, "file"), []byte("data"), 0444); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(locked, 0555)
+	os.Chmod(filepath.Dir(locked), 0555)
+
+	if err := removeSandbox(sandbox); err != nil {
+		t.Fatalf("removeSandbox() failed: %v", err)
+	}
+	if _, err := os.Stat(sandbox); !os.IsNotExist(err) {
+		t.Errorf("Sandbox should be removed, stat error %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+// makeFakeRepo creates the directories that identify a restic repository
+func makeFakeRepo(t *testing.T, path string) {
+	t.Helper()
+	for _, dir := range []string{"data", "keys", "snapshots"} {
+		if err := os.MkdirAll(filepath.Join(path, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(path, "config"), []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// makeScanTree builds a tree with a shallow repo, a deep repo and a symlink loop
+func makeScanTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	makeFakeRepo(t, filepath.Join(root, "a", "shallow"))
+	makeFakeRepo(t, filepath.Join(root, "b", "c", "d", "deep"))
+	if err := os.Symlink(root, filepath.Join(root, "a", "loop")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	return root
+}
+
+func repoPaths(repos []types.RepositoryConfig) []string {
+	paths := make([]string, len(repos))
+	for i, repo := range repos {
+		paths[i] = repo.Path
+	}
+	return paths
+}
+
+func TestScanDirectoryForRepos_SymlinkLoopAndDepth(t *testing.T) {
+	root := makeScanTree(t)
+
+	repos, err := scanDirectoryForRepos(context.Background(), root, defaultScanMaxDepth)
+	if err != nil {
+		t.Fatalf("scanDirectoryForRepos() failed: %v", err)
+	}
+	want := filepath.Join(root, "a", "shallow")
+	if len(repos) != 1 || repos[0].Path != want || repos[0].Name != "shallow" {
+		t.Errorf("Default depth should find only %s once, got %v", want, repoPaths(repos))
+	}
+
+	repos, err = scanDirectoryForRepos(context.Background(), root, 3)
+	if err != nil {
+		t.Fatalf("scanDirectoryForRepos() failed: %v", err)
+	}
+	if len(repos) != 2 {
+		t.Errorf("Depth 3 should also find the deep repo, got %v", repoPaths(repos))
+	}
+}
+
+func TestScanDirectoryForRepos_SymlinkedRootAndRootRepo(t *testing.T) {
+	root := makeScanTree(t)
+	link := filepath.Join(t.TempDir(), "backup")
+	if err := os.Symlink(root, link); err != nil {
+		t.Fatal(err)
+	}
+
+	repos, err := scanDirectoryForRepos(context.Background(), link, defaultScanMaxDepth)
+	if err != nil {
+		t.Fatalf("scanDirectoryForRepos() failed: %v", err)
+	}
+	if want := filepath.Join(link, "a", "shallow"); len(repos) != 1 || repos[0].Path != want {
+		t.Errorf("A symlinked scan path should be followed and reported as configured, got %v", repoPaths(repos))
+	}
+
+	repoRoot := filepath.Join(root, "a", "shallow")
+	repos, _ = scanDirectoryForRepos(context.Background(), repoRoot, defaultScanMaxDepth)
+	if len(repos) != 1 || repos[0].Path != repoRoot {
+		t.Errorf("A scan path that is a repository should be found once, got %v", repoPaths(repos))
+	}
+}
+
+func TestScanDirectoryForRepos_MissingPathAndVirtualMounts(t *testing.T) {
+	repos, err := scanDirectoryForRepos(context.Background(), filepath.Join(t.TempDir(), "missing"), defaultScanMaxDepth)
+	if err != nil || len(repos) != 0 {
+		t.Errorf("A missing scan path should be skipped quietly, got %v, %v", repos, err)
+	}
+
+	for _, path := range []string{"/proc", "/proc/1", "/sys/class", "/dev"} {
+		if !isVirtualMount(path) {
+			t.Errorf("isVirtualMount(%q) = false, want true", path)
+		}
+	}
+	for _, path := range []string{"/", "/processes", "/mnt/dev"} {
+		if isVirtualMount(path) {
+			t.Errorf("isVirtualMount(%q) = true, want false", path)
+		}
+	}
+}
+
+func TestScanPathWithTimeout_ExpiredContext(t *testing.T) {
+	root := makeScanTree(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := scanPathWithTimeout(ctx, root, defaultScanMaxDepth); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("An expired context should report a timeout, got %v", err)
+	}
+}
+
+func TestScannedRepos_LogsScanErrorsDimmed(t *testing.T) {
+	m := Model{config: &types.ResticConfig{}, opsPanel: ui.NewOperationsPanel()}
+
+	updated, _ := m.Update(ScannedReposMsg{
+		ScannedPaths: []string{"/mnt/nas"},
+		Errors:       []string{"/mnt/nas: scan timed out after 15s"},
+	})
+	m = updated.(Model)
+
+	for _, log := range m.opsPanel.FilteredLogs() {
+		if strings.Contains(log.Message, "/mnt/nas: scan timed out") {
+			if log.Level != "dimmed" {
+				t.Errorf("Scan errors should be dimmed, got level %q", log.Level)
+			}
+			return
+		}
+	}
+	t.Error("Scan error was not logged")
+}
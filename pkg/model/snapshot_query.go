@@ -0,0 +1,79 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// snapshotQuery is a snapshot filter split into the parts restic can filter
+// on (host:, tag:, path:) and free text matched client-side by the panel
+type snapshotQuery struct {
+	host  string
+	tags  []string
+	paths []string
+	text  string
+}
+
+// parseSnapshotQuery splits a filter such as "host:web01 tag:daily etc" into
+// restic-side terms and the remaining text. A later host: replaces an earlier
+// one; tag: and path: may repeat. Terms with an empty value are kept as text.
+func parseSnapshotQuery(query string) snapshotQuery {
+	var q snapshotQuery
+	var text []string
+
+	for _, field := range strings.Fields(query) {
+		key, value, ok := strings.Cut(field, ":")
+		if !ok || value == "" {
+			text = append(text, field)
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "host":
+			q.host = value
+		case "tag":
+			q.tags = append(q.tags, value)
+		case "path":
+			q.paths = append(q.paths, value)
+		default:
+			text = append(text, field)
+		}
+	}
+
+	q.text = strings.Join(text, " ")
+	return q
+}
+
+// serverSide reports whether the query needs restic to reload snapshots
+func (q snapshotQuery) serverSide() bool {
+	return q.host != "" || len(q.tags) > 0 || len(q.paths) > 0
+}
+
+// String describes the restic-side terms in query syntax, e.g. "host:web01 tag:daily"
+func (q snapshotQuery) String() string {
+	var terms []string
+	if q.host != "" {
+		terms = append(terms, "host:"+q.host)
+	}
+	for _, tag := range q.tags {
+		terms = append(terms, "tag:"+tag)
+	}
+	for _, path := range q.paths {
+		terms = append(terms, "path:"+path)
+	}
+	return strings.Join(terms, " ")
+}
+
+// flags returns the restic snapshots flags for the restic-side terms
+func (q snapshotQuery) flags() string {
+	var flags []string
+	if q.host != "" {
+		flags = append(flags, fmt.Sprintf("--host %s", q.host))
+	}
+	for _, tag := range q.tags {
+		flags = append(flags, fmt.Sprintf("--tag %s", tag))
+	}
+	for _, path := range q.paths {
+		flags = append(flags, fmt.Sprintf("--path %s", path))
+	}
+	return strings.Join(flags, " ")
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSnapshotQuery(t *testing.T) {
+	tests := []struct {
+		query      string
+		host       string
+		tags       string
+		paths      string
+		text       string
+		serverSide bool
+	}{
+		{"home", "", "", "", "home", false},
+		{"host:web01", "web01", "", "", "", true},
+		{"HOST:old host:web01 tag:daily tag:db,prod", "web01", "daily|db,prod", "", "", true},
+		{"path:/home etc tag:daily", "", "daily", "/home", "etc", true},
+		{"host: abc:def", "", "", "", "host: abc:def", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q := parseSnapshotQuery(tt.query)
+			if q.host != tt.host {
+				t.Errorf("host = %q, want %q", q.host, tt.host)
+			}
+			if got := strings.Join(q.tags, "|"); got != tt.tags {
+				t.Errorf("tags = %q, want %q", got, tt.tags)
+			}
+			if got := strings.Join(q.paths, "|"); got != tt.paths {
+				t.Errorf("paths = %q, want %q", got, tt.paths)
+			}
+			if q.text != tt.text {
+				t.Errorf("text = %q, want %q", q.text, tt.text)
+			}
+			if q.serverSide() != tt.serverSide {
+				t.Errorf("serverSide() = %v, want %v", q.serverSide(), tt.serverSide)
+			}
+		})
+	}
+}
+
+func TestSnapshotQuery_Describe(t *testing.T) {
+	q := parseSnapshotQuery("path:/home host:web01 tag:daily")
+	if got := q.String(); got != "host:web01 tag:daily path:/home" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := q.flags(); got != "--host web01 --tag daily --path /home" {
+		t.Errorf("flags() = %q", got)
+	}
+}
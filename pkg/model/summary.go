@@ -0,0 +1,121 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/craigderington/lazyrestic/pkg/types"
+	"github.com/craigderington/lazyrestic/pkg/ui"
+)
+
+// RepositoryCounts tallies repositories by health status
+type RepositoryCounts struct {
+	Healthy int
+	Warning int
+	Error   int
+	Unknown int
+}
+
+// countRepositories groups repositories using the same status buckets as ui.StatusStyle
+func countRepositories(repos []types.Repository) RepositoryCounts {
+	var counts RepositoryCounts
+	for _, repo := range repos {
+		switch repo.Status {
+		case "healthy", "ready":
+			counts.Healthy++
+		case "warning", "uninitialized", "locked":
+			counts.Warning++
+		case "error", "failed":
+			counts.Error++
+		default:
+			counts.Unknown++
+		}
+	}
+	return counts
+}
+
+// RepositoryTotals aggregates the metrics of all loaded repositories
+type RepositoryTotals struct {
+	Repositories int
+	Size         int64 // Sum of repository sizes in bytes
+	StoredSize   int64 // Sum of known deduplicated snapshot data sizes
+	Files        int64
+	Snapshots    int
+	Counts       RepositoryCounts
+
+	// Least and most recent of the repositories' last backups; zero when no
+	// repository has a backup yet
+	OldestBackup     time.Time
+	OldestBackupRepo string
+	NewestBackup     time.Time
+	NewestBackupRepo string
+}
+
+// totalRepositories sums sizes, file and snapshot counts across repos and finds
+// the repositories with the least and most recent last backup
+func totalRepositories(repos []types.Repository) RepositoryTotals {
+	totals := RepositoryTotals{
+		Repositories: len(repos),
+		Counts:       countRepositories(repos),
+	}
+	for _, repo := range repos {
+		totals.Size += repo.Size
+		totals.StoredSize += repo.StoredSize
+		totals.Files += repo.TotalFiles
+		totals.Snapshots += repo.SnapshotCount
+
+		if repo.LastBackup.IsZero() {
+			continue
+		}
+		if totals.OldestBackup.IsZero() || repo.LastBackup.Before(totals.OldestBackup) {
+			totals.OldestBackup = repo.LastBackup
+			totals.OldestBackupRepo = repo.Name
+		}
+		if repo.LastBackup.After(totals.NewestBackup) {
+			totals.NewestBackup = repo.LastBackup
+			totals.NewestBackupRepo = repo.Name
+		}
+	}
+	return totals
+}
+
+// RepositorySummary counts the loaded repositories by status
+func (m Model) RepositorySummary() RepositoryCounts {
+	return countRepositories(m.repositories)
+}
+
+// Render returns a colored badge line such as "5 healthy · 1 warning · 1 error";
+// compact uses symbols ("5✓ 1⚠ 1✗") for narrow terminals. Zero counts are omitted.
+func (c RepositoryCounts) Render(compact bool) string {
+	dimmed := lipgloss.NewStyle().Foreground(ui.CurrentTheme().Dimmed)
+	badges := []struct {
+		count  int
+		label  string
+		symbol string
+		style  lipgloss.Style
+	}{
+		{c.Healthy, "healthy", "✓", ui.StatusHealthyStyle},
+		{c.Warning, "warning", "⚠", ui.StatusWarningStyle},
+		{c.Error, "error", "✗", ui.StatusErrorStyle},
+		{c.Unknown, "unknown", "?", dimmed},
+	}
+
+	var parts []string
+	for _, badge := range badges {
+		if badge.count == 0 {
+			continue
+		}
+		if compact {
+			parts = append(parts, badge.style.Render(fmt.Sprintf("%d%s", badge.count, badge.symbol)))
+		} else {
+			parts = append(parts, badge.style.Render(fmt.Sprintf("%d %s", badge.count, badge.label)))
+		}
+	}
+
+	if compact {
+		return strings.Join(parts, " ")
+	}
+	return strings.Join(parts, dimmed.Render(" · "))
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+func TestCountRepositories(t *testing.T) {
+	repos := []types.Repository{
+		{Name: "a", Status: "healthy"},
+		{Name: "b", Status: "healthy"},
+		{Name: "c", Status: "ready"},
+		{Name: "d", Status: "warning"},
+		{Name: "e", Status: "uninitialized"},
+		{Name: "f", Status: "error"},
+		{Name: "g", Status: "unknown"},
+		{Name: "h", Status: ""},
+	}
+
+	got := countRepositories(repos)
+	want := RepositoryCounts{Healthy: 3, Warning: 2, Error: 1, Unknown: 2}
+	if got != want {
+		t.Errorf("countRepositories() = %+v, want %+v", got, want)
+	}
+
+	if empty := countRepositories(nil); empty != (RepositoryCounts{}) {
+		t.Errorf("countRepositories(nil) = %+v, want zero counts", empty)
+	}
+}
+
+func TestTotalRepositories(t *testing.T) {
+	older := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	newer := older.Add(48 * time.Hour)
+	repos := []types.Repository{
+		{Name: "home", Status: "healthy", Size: 1000, StoredSize: 400, TotalFiles: 10, SnapshotCount: 3, LastBackup: newer},
+		{Name: "media", Status: "warning", Size: 5000, TotalFiles: 50, SnapshotCount: 1, LastBackup: older},
+		{Name: "fresh", Status: "uninitialized"},
+	}
+
+	got := totalRepositories(repos)
+	want := RepositoryTotals{
+		Repositories:     3,
+		Size:             6000,
+		StoredSize:       400,
+		Files:            60,
+		Snapshots:        4,
+		Counts:           RepositoryCounts{Healthy: 1, Warning: 2},
+		OldestBackup:     older,
+		OldestBackupRepo: "media",
+		NewestBackup:     newer,
+		NewestBackupRepo: "home",
+	}
+	if got != want {
+		t.Errorf("totalRepositories() = %+v, want %+v", got, want)
+	}
+
+	if empty := totalRepositories(nil); empty != (RepositoryTotals{}) {
+		t.Errorf("totalRepositories(nil) = %+v, want zero totals", empty)
+	}
+}
+
+func TestRepositoryCounts_Render(t *testing.T) {
+	counts := RepositoryCounts{Healthy: 5, Warning: 1, Error: 1}
+
+	full := stripANSI(counts.Render(false))
+	if full != "5 healthy · 1 warning · 1 error" {
+		t.Errorf("Render(false) = %q", full)
+	}
+
+	compact := stripANSI(counts.Render(true))
+	if compact != "5✓ 1⚠ 1✗" {
+		t.Errorf("Render(true) = %q", compact)
+	}
+	if lipgloss.Width(compact) >= lipgloss.Width(full) {
+		t.Error("Compact summary should be narrower than the full one")
+	}
+}
+
+// stripANSI removes terminal color sequences from rendered output
+func stripANSI(s string) string {
+	var b strings.Builder
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case r == '\x1b':
+			inEscape = true
+		case inEscape && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'):
+			inEscape = false
+		case !inEscape:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
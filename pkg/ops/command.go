@@ -0,0 +1,111 @@
+package ops
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/craigderington/lazyrestic/pkg/restic"
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+// Commands lists the subcommands Run accepts, for usage messages
+var Commands = []string{
+	"backup <repo> [path...]             back up the paths (default: the repository's default_paths)",
+	"restore <repo> <snapshot> [target]  restore a snapshot (default target: default_restore_target)",
+	"forget <repo> <snapshot-id...>      remove snapshots by ID (protected snapshots are refused)",
+}
+
+// IsCommand reports whether name is a subcommand Run handles
+func IsCommand(name string) bool {
+	switch name {
+	case "backup", "restore", "forget":
+		return true
+	}
+	return false
+}
+
+// Run executes the subcommand in args against the configured repositories and
+// writes its result to stdout, as JSON when asJSON is set. Without JSON a
+// failure goes to stderr instead. The returned error is the operation's
+// failure, already included in the written result.
+func Run(cfg *types.ResticConfig, args []string, stdout, stderr io.Writer, asJSON bool) error {
+	var result Result
+	if len(args) > 0 {
+		result.Operation = args[0]
+	}
+	if len(args) > 1 {
+		result.Repository = args[1]
+	}
+	start := time.Now()
+	err := run(cfg, args, &result)
+	result.Finish(start, err)
+
+	var writeErr error
+	switch {
+	case asJSON:
+		writeErr = WriteJSON(stdout, result)
+	case err != nil:
+		writeErr = WriteText(stderr, result)
+	default:
+		writeErr = WriteText(stdout, result)
+	}
+	if err != nil {
+		return err
+	}
+	return writeErr
+}
+
+// run performs the operation named by args[0], filling in result
+func run(cfg *types.ResticConfig, args []string, result *Result) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: lazyrestic [--json] <command> <repo> ...")
+	}
+	repoConfig, err := FindRepository(cfg, args[1])
+	if err != nil {
+		return err
+	}
+	client := restic.NewClient(repoConfig)
+	rest := args[2:]
+
+	switch args[0] {
+	case "backup":
+		opts := repoConfig.BackupDefaults()
+		if len(rest) > 0 {
+			opts.Paths = rest
+		}
+		if len(opts.Paths) == 0 {
+			return fmt.Errorf("no paths given and no default_paths configured for %s", repoConfig.Name)
+		}
+		summary, warning, err := Backup(client, opts)
+		result.Backup = summary
+		result.Warning = warning
+		return err
+
+	case "restore":
+		if len(rest) == 0 {
+			return fmt.Errorf("restore needs a snapshot ID")
+		}
+		opts := repoConfig.RestoreDefaults(rest[0])
+		if len(rest) > 1 {
+			opts.Target = rest[1]
+		}
+		if opts.Target == "" {
+			return fmt.Errorf("no target given and no default_restore_target configured for %s", repoConfig.Name)
+		}
+		result.SnapshotID = opts.SnapshotID
+		result.Target = opts.Target
+		return Restore(client, opts)
+
+	case "forget":
+		if len(rest) == 0 {
+			return fmt.Errorf("forget needs at least one snapshot ID")
+		}
+		if err := ForgetByID(client, rest); err != nil {
+			return err
+		}
+		result.Forgotten = rest
+		return nil
+	}
+	return fmt.Errorf("unknown command %q", args[0])
+}
@@ -0,0 +1,147 @@
+// Package ops runs backup, restore and forget operations to completion without
+// the TUI, for the command line and for callers that don't need progress updates.
+package ops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/craigderington/lazyrestic/pkg/restic"
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+// Result describes a finished operation; it is what --json writes to stdout
+type Result struct {
+	Operation  string               `json:"operation"`
+	Repository string               `json:"repository"`
+	Success    bool                 `json:"success"`
+	Error      string               `json:"error,omitempty"`
+	Warning    string               `json:"warning,omitempty"`
+	Duration   float64              `json:"duration_seconds"`
+	Backup     *types.BackupSummary `json:"backup,omitempty"`
+	SnapshotID string               `json:"snapshot_id,omitempty"` // Snapshot restored
+	Target     string               `json:"target,omitempty"`      // Restore target
+	Forgotten  []string             `json:"forgotten,omitempty"`   // Snapshot IDs removed by forget
+}
+
+// Client is the part of the restic client the operations use
+type Client interface {
+	Ping() error
+	ListSnapshots() ([]types.Snapshot, error)
+	BackupWithChannel(ctx context.Context, opts types.BackupOptions, updates chan<- restic.BackupMessage)
+	Restore(opts types.RestoreOptions) error
+	Forget(policy types.ForgetPolicy) error
+	ForgetByID(ids []string) error
+}
+
+// FindRepository returns the configured repository called name
+func FindRepository(cfg *types.ResticConfig, name string) (types.RepositoryConfig, error) {
+	for _, repo := range cfg.Repositories {
+		if repo.Name == name {
+			return repo, nil
+		}
+	}
+	return types.RepositoryConfig{}, fmt.Errorf("repository %q not found in config", name)
+}
+
+// Backup runs a backup and waits for restic to finish. The warning is set when
+// the snapshot was created but some source files could not be read.
+func Backup(client Client, opts types.BackupOptions) (summary *types.BackupSummary, warning string, err error) {
+	if err := client.Ping(); err != nil {
+		return nil, "", err
+	}
+
+	updates := make(chan restic.BackupMessage, 10)
+	go client.BackupWithChannel(context.Background(), opts, updates)
+
+	// Drain every message so the backup goroutine never blocks
+	for msg := range updates {
+		switch {
+		case msg.Error != nil:
+			err = msg.Error
+		case msg.Summary != nil:
+			summary, warning = msg.Summary, msg.Warning
+		}
+	}
+	if err == nil && summary == nil {
+		err = fmt.Errorf("backup finished without a summary")
+	}
+	return summary, warning, err
+}
+
+// Restore restores a snapshot and waits for restic to finish
+func Restore(client Client, opts types.RestoreOptions) error {
+	if err := client.Ping(); err != nil {
+		return err
+	}
+	return client.Restore(opts)
+}
+
+// Forget applies a retention policy to the repository
+func Forget(client Client, policy types.ForgetPolicy) error {
+	if err := client.Ping(); err != nil {
+		return err
+	}
+	return client.Forget(policy)
+}
+
+// ForgetByID removes the given snapshots. It refuses to remove any snapshot
+// that is protected, the same as forget policies that always keep them.
+func ForgetByID(client Client, ids []string) error {
+	if err := client.Ping(); err != nil {
+		return err
+	}
+	snapshots, err := client.ListSnapshots()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		for _, snapshot := range snapshots {
+			if strings.HasPrefix(snapshot.ID, id) && restic.IsProtected(snapshot) {
+				return fmt.Errorf("snapshot %s is protected - remove the %s tag before forgetting it", snapshot.ShortID, restic.ProtectedTag)
+			}
+		}
+	}
+	return client.ForgetByID(ids)
+}
+
+// Finish records the outcome of an operation that started at start
+func (r *Result) Finish(start time.Time, err error) {
+	r.Duration = time.Since(start).Seconds()
+	r.Success = err == nil
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+// WriteJSON writes r to w as a single line of JSON
+func WriteJSON(w io.Writer, r Result) error {
+	return json.NewEncoder(w).Encode(r)
+}
+
+// WriteText writes a short human-readable description of r to w
+func WriteText(w io.Writer, r Result) error {
+	if !r.Success {
+		_, err := fmt.Fprintf(w, "%s of %s failed: %s\n", r.Operation, r.Repository, restic.Describe(fmt.Errorf("%s", r.Error)))
+		return err
+	}
+
+	var err error
+	switch {
+	case r.Backup != nil:
+		_, err = fmt.Fprintf(w, "Backup of %s complete: snapshot %s, %d new, %d changed, %d unmodified files, %d bytes added (%.1fs)\n",
+			r.Repository, r.Backup.SnapshotID, r.Backup.FilesNew, r.Backup.FilesChanged, r.Backup.FilesUnmodified, r.Backup.DataAdded, r.Duration)
+	case r.Operation == "restore":
+		_, err = fmt.Fprintf(w, "Restored snapshot %s of %s to %s (%.1fs)\n", r.SnapshotID, r.Repository, r.Target, r.Duration)
+	default:
+		_, err = fmt.Fprintf(w, "%s of %s complete (%.1fs)\n", r.Operation, r.Repository, r.Duration)
+	}
+	if err == nil && r.Warning != "" {
+		_, err = fmt.Fprintf(w, "Warning: %s\n", r.Warning)
+	}
+	return err
+}
@@ -0,0 +1,158 @@
+package ops
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+// installFakeRestic puts a shell script named restic first in PATH for the test
+func installFakeRestic(t *testing.T, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake restic script requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "restic")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatalf("Failed to write fake restic: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestWriteJSON_BackupSummary(t *testing.T) {
+	result := Result{
+		Operation:  "backup",
+		Repository: "home",
+		Success:    true,
+		Duration:   1.5,
+		Backup:     &types.BackupSummary{MessageType: "summary", FilesNew: 3, DataAdded: 2048, SnapshotID: "abc123"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, result); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	if strings.Count(buf.String(), "\n") != 1 {
+		t.Errorf("Expected a single JSON line, got %q", buf.String())
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if decoded["operation"] != "backup" || decoded["repository"] != "home" || decoded["success"] != true {
+		t.Errorf("Unexpected result fields: %v", decoded)
+	}
+	backup, ok := decoded["backup"].(map[string]any)
+	if !ok || backup["snapshot_id"] != "abc123" || backup["files_new"] != float64(3) {
+		t.Errorf("Backup summary not emitted: %v", decoded["backup"])
+	}
+	if _, ok := decoded["error"]; ok {
+		t.Error("Successful results should omit the error field")
+	}
+}
+
+func TestRun_BackupJSON(t *testing.T) {
+	installFakeRestic(t, `case "$*" in
+  *backup*) echo '{"message_type":"summary","files_new":2,"data_added":100,"snapshot_id":"deadbeef"}' ;;
+esac
+exit 0`)
+	cfg := &types.ResticConfig{Repositories: []types.RepositoryConfig{
+		{Name: "home", Path: "/tmp/repo", DefaultPaths: []string{"/home/user"}},
+	}}
+
+	var buf bytes.Buffer
+	if err := Run(cfg, []string{"backup", "home"}, &buf, &buf, true); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var result Result
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("Output is not valid JSON: %v (%q)", err, buf.String())
+	}
+	if !result.Success || result.Backup == nil || result.Backup.SnapshotID != "deadbeef" || result.Backup.FilesNew != 2 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+func TestRun_FailureIsReported(t *testing.T) {
+	cfg := &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "home", Path: "/tmp/repo"}}}
+
+	var buf bytes.Buffer
+	if err := Run(cfg, []string{"backup", "missing"}, &buf, &buf, true); err == nil {
+		t.Fatal("Run() should fail for an unknown repository")
+	}
+
+	var result Result
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("Failures should still be written as JSON: %v", err)
+	}
+	if result.Success || !strings.Contains(result.Error, "missing") {
+		t.Errorf("Unexpected failure result: %+v", result)
+	}
+}
+
+func TestRun_TextFailureGoesToStderr(t *testing.T) {
+	cfg := &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "home", Path: "/tmp/repo"}}}
+
+	var stdout, stderr bytes.Buffer
+	if err := Run(cfg, []string{"backup", "missing"}, &stdout, &stderr, false); err == nil {
+		t.Fatal("Run() should fail for an unknown repository")
+	}
+	if stdout.Len() != 0 || !strings.Contains(stderr.String(), "backup of missing failed") {
+		t.Errorf("Expected the failure on stderr only, got stdout %q, stderr %q", stdout.String(), stderr.String())
+	}
+}
+
+func TestRun_RestoreDefaultsToOverwriteIfChanged(t *testing.T) {
+	argsFile := filepath.Join(t.TempDir(), "args")
+	installFakeRestic(t, `case "$1" in
+  version) echo "restic 0.17.3 compiled with go1.22.5 on linux/amd64" ;;
+  restore) echo "$*" > '`+argsFile+`' ;;
+esac
+exit 0`)
+	cfg := &types.ResticConfig{Repositories: []types.RepositoryConfig{
+		{Name: "home", Path: "/tmp/repo", DefaultRestoreTarget: "/tmp/restore"},
+	}}
+
+	var buf bytes.Buffer
+	if err := Run(cfg, []string{"restore", "home", "abc123"}, &buf, &buf, false); err != nil {
+		t.Fatalf("Run() error = %v (%q)", err, buf.String())
+	}
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("restic restore was not run: %v", err)
+	}
+	if !strings.Contains(string(args), "--overwrite if-changed") || !strings.Contains(string(args), "--target /tmp/restore") {
+		t.Errorf("restore args = %q, want --overwrite if-changed and the default target", args)
+	}
+}
+
+func TestRun_ForgetRefusesProtectedSnapshots(t *testing.T) {
+	forgotten := filepath.Join(t.TempDir(), "forgotten")
+	installFakeRestic(t, `case "$*" in
+  *snapshots*) echo '[{"id":"aaaa1111ffff","short_id":"aaaa1111","tags":["protected"]},{"id":"bbbb2222ffff","short_id":"bbbb2222"}]' ;;
+  *forget*) touch '`+forgotten+`' ;;
+esac
+exit 0`)
+	cfg := &types.ResticConfig{Repositories: []types.RepositoryConfig{{Name: "home", Path: "/tmp/repo"}}}
+
+	var stdout, stderr bytes.Buffer
+	if err := Run(cfg, []string{"forget", "home", "bbbb2222", "aaaa1111"}, &stdout, &stderr, false); err == nil {
+		t.Fatal("Run() should refuse to forget a protected snapshot")
+	}
+	if !strings.Contains(stderr.String(), "aaaa1111 is protected") {
+		t.Errorf("Expected the protected snapshot named on stderr, got %q", stderr.String())
+	}
+	if _, err := os.Stat(forgotten); err == nil {
+		t.Error("restic forget should not run when a snapshot is protected")
+	}
+}
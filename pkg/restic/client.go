@@ -2,27 +2,84 @@ package restic
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/craigderington/lazyrestic/pkg/types"
 )
 
+// DefaultTimeout bounds metadata commands (snapshots, stats, unlock, ...)
+const DefaultTimeout = 30 * time.Second
+
+// CancelGracePeriod is how long an interrupted backup, restore or maintenance
+// command may take to release its lock before it is killed
+const CancelGracePeriod = 10 * time.Second
+
+// DefaultMaxDumpBytes caps how much of a file DumpFile reads for previews
+const DefaultMaxDumpBytes = 64 * 1024
+
 // Client handles restic command execution
 type Client struct {
 	config types.RepositoryConfig
+
+	// Timeout applies to short metadata commands; zero disables the deadline
+	Timeout time.Duration
+	// LongTimeout applies to long-running operations (check, prune, forget,
+	// restore, ls); zero disables the deadline
+	LongTimeout time.Duration
+	// MaxDumpBytes caps the bytes DumpFile returns
+	MaxDumpBytes int64
 }
 
 // NewClient creates a new restic client for a repository
 func NewClient(config types.RepositoryConfig) *Client {
 	return &Client{
-		config: config,
+		config:       config,
+		Timeout:      DefaultTimeout,
+		MaxDumpBytes: DefaultMaxDumpBytes,
+	}
+}
+
+// interruptOnCancel makes cancelling cmd's context interrupt restic instead of
+// killing it, so restic can remove its lock before exiting. It is killed if it
+// is still running after CancelGracePeriod.
+func interruptOnCancel(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = CancelGracePeriod
+}
+
+// IsTimeout reports whether err was caused by a command deadline
+func IsTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// timeoutError reports a restic subcommand that exceeded its deadline.
+// The result wraps context.DeadlineExceeded.
+func timeoutError(subcommand string, timeout time.Duration) error {
+	return fmt.Errorf("restic %s: command timed out after %s: %w", subcommand, timeout, context.DeadlineExceeded)
+}
+
+// commandContext returns a context bounded by timeout, or an unbounded one when timeout is zero
+func commandContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(context.Background())
 	}
+	return context.WithTimeout(context.Background(), timeout)
 }
 
 // buildEnv creates environment variables for restic commands
@@ -31,78 +88,218 @@ func (c *Client) buildEnv() []string {
 		fmt.Sprintf("RESTIC_REPOSITORY=%s", c.config.Path),
 	}
 
-	// Only password_file and password_command are supported (no plain-text passwords)
+	// Only password_file, password_command and password_env are supported (no plain-text passwords)
 	if c.config.PasswordFile != "" {
 		env = append(env, fmt.Sprintf("RESTIC_PASSWORD_FILE=%s", c.config.PasswordFile))
 	}
 	if c.config.PasswordCommand != "" {
-		env = append(env, fmt.Sprintf("RESTIC_PASSWORD_COMMAND=%s", c.config.PasswordCommand))
+		if password, ok := c.cachedPassword(); ok {
+			env = append(env, fmt.Sprintf("RESTIC_PASSWORD=%s", password))
+		} else {
+			env = append(env, fmt.Sprintf("RESTIC_PASSWORD_COMMAND=%s", c.config.PasswordCommand))
+		}
+	}
+	if c.config.PasswordEnv != "" {
+		// Resolve the named variable at run time so secrets never touch the config file
+		if password, ok := os.LookupEnv(c.config.PasswordEnv); ok {
+			env = append(env, fmt.Sprintf("RESTIC_PASSWORD=%s", password))
+		}
 	}
 
 	return env
 }
 
-// execCommand executes a restic command and returns the output
+// checkPasswordEnv reports a password_env naming a variable that isn't set.
+// restic would get no password and prompt for one or fail with "wrong
+// password", hiding the cause, so commands refuse to start instead.
+func (c *Client) checkPasswordEnv() error {
+	if c.config.PasswordEnv == "" {
+		return nil
+	}
+	if _, ok := os.LookupEnv(c.config.PasswordEnv); !ok {
+		return fmt.Errorf("password_env %s is not set", c.config.PasswordEnv)
+	}
+	return nil
+}
+
+// buildArgs prepends repository-wide global flags to a restic subcommand
+func (c *Client) buildArgs(args ...string) []string {
+	var global []string
+
+	if c.config.InsecureTLS {
+		global = append(global, "--insecure-tls")
+	}
+	if c.config.CACert != "" {
+		global = append(global, "--cacert", c.config.CACert)
+	}
+	// Only the commands that write data use the repository's compression mode
+	if c.config.Compression != "" && len(args) > 0 && (args[0] == "backup" || args[0] == "prune") {
+		global = append(global, "--compression", c.config.Compression)
+	}
+
+	return append(global, args...)
+}
+
+// execCommand executes a restic command bounded by the client's Timeout and returns the output
 func (c *Client) execCommand(args ...string) ([]byte, error) {
-	cmd := exec.Command("restic", args...)
+	return c.execCommandTimeout(c.Timeout, args...)
+}
 
-	// Start with parent environment and add our custom vars
-	cmd.Env = append(os.Environ(), c.buildEnv()...)
+// execCommandTimeout executes a restic command with an explicit deadline (zero for none)
+func (c *Client) execCommandTimeout(timeout time.Duration, args ...string) ([]byte, error) {
+	return c.execCommandEnv(timeout, nil, args...)
+}
+
+// execCommandEnv executes a restic command like execCommandTimeout with extra
+// environment variables for this command only
+func (c *Client) execCommandEnv(timeout time.Duration, env []string, args ...string) ([]byte, error) {
+	stdout, _, err := c.runCommand(timeout, env, args...)
+	return stdout, err
+}
+
+// execUserCommand executes a restic command the user started like
+// execCommandTimeout and records it as the last command
+func (c *Client) execUserCommand(timeout time.Duration, args ...string) ([]byte, error) {
+	c.recordCommand(args)
+	return c.execCommandTimeout(timeout, args...)
+}
+
+// runCommand executes a restic command and returns its stdout and stderr
+// separately. Only stdout is parsed, so warnings restic prints on stderr can't
+// corrupt JSON output; stderr is included in the error when the command fails.
+func (c *Client) runCommand(timeout time.Duration, env []string, args ...string) ([]byte, []byte, error) {
+	if err := c.checkPasswordEnv(); err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := commandContext(timeout)
+	defer cancel()
+
+	cmd := c.command(ctx, args...)
+	cmd.Env = append(cmd.Env, env...)
+	// Don't wait forever on children (e.g. password commands) holding the output pipes
+	cmd.WaitDelay = time.Second
 
-	output, err := cmd.CombinedOutput()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return stdout.Bytes(), stderr.Bytes(), timeoutError(args[0], timeout)
+	}
 	if err != nil {
-		// Return both the error and output for better debugging
-		return output, fmt.Errorf("restic command failed: %w (output: %s)", err, string(output))
+		output := stderr.Bytes()
+		return stdout.Bytes(), output, classifyError(fmt.Errorf("restic command failed: %w (output: %s)", err, strings.TrimSpace(string(output))), output)
 	}
 
-	return output, nil
+	return stdout.Bytes(), stderr.Bytes(), nil
 }
 
 // ListSnapshots retrieves all snapshots from the repository
 func (c *Client) ListSnapshots() ([]types.Snapshot, error) {
-	output, err := c.execCommand("snapshots", "--json")
+	return c.ListSnapshotsFiltered("", nil, nil)
+}
+
+// ListSnapshotsFiltered retrieves the snapshots matching host, tags and paths,
+// letting restic do the filtering. Empty arguments don't filter.
+func (c *Client) ListSnapshotsFiltered(host string, tags []string, paths []string) ([]types.Snapshot, error) {
+	output, err := c.execCommand(buildSnapshotsArgs(host, tags, paths)...)
 	if err != nil {
 		return nil, err
 	}
+	return parseSnapshots(output)
+}
 
-	var snapshots []types.Snapshot
+// parseSnapshots decodes `restic snapshots --json` output. A repository
+// without snapshots prints [] or, on some restic versions, null or nothing at
+// all; each is an empty list.
+func parseSnapshots(output []byte) ([]types.Snapshot, error) {
+	snapshots := []types.Snapshot{}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return snapshots, nil
+	}
 	if err := json.Unmarshal(output, &snapshots); err != nil {
 		return nil, fmt.Errorf("failed to parse snapshots JSON: %w (output: %s)", err, string(output))
 	}
-
+	if snapshots == nil {
+		snapshots = []types.Snapshot{}
+	}
 	return snapshots, nil
 }
 
+// buildSnapshotsArgs constructs the restic snapshots arguments. Each tag is a
+// separate --tag, which restic ORs; "a,b" within one tag requires both.
+func buildSnapshotsArgs(host string, tags []string, paths []string) []string {
+	args := []string{"snapshots", "--json"}
+	if host != "" {
+		args = append(args, "--host", host)
+	}
+	for _, tag := range tags {
+		args = append(args, "--tag", tag)
+	}
+	for _, path := range paths {
+		args = append(args, "--path", path)
+	}
+	return args
+}
+
 // ListFiles lists all files in a snapshot
 // If path is empty, lists all files in the snapshot
 // If path is specified, lists files in that directory
 func (c *Client) ListFiles(snapshotID string, path string) ([]types.FileNode, error) {
+	ctx, cancel := commandContext(c.LongTimeout)
+	defer cancel()
+
+	out := make(chan types.FileNode, 64)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.ListFilesStream(ctx, snapshotID, path, out)
+	}()
+
+	var nodes []types.FileNode
+	for node := range out {
+		nodes = append(nodes, node)
+	}
+	if err := <-done; err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, timeoutError("ls", c.LongTimeout)
+		}
+		return nil, err
+	}
+
+	return nodes, nil
+}
+
+// ListFilesStream sends the entries of a snapshot directory to out as restic
+// lists them, so huge directories can be shown before the listing finishes.
+// out is closed when the listing completes, fails or ctx is cancelled. No
+// timeout applies: a reader may pause between pages, so cancel ctx to stop.
+func (c *Client) ListFilesStream(ctx context.Context, snapshotID string, path string, out chan<- types.FileNode) error {
+	defer close(out)
+
 	args := []string{"ls", snapshotID, "--json"}
 	if path != "" {
 		args = append(args, path)
 	}
 
-	cmd := exec.Command("restic", args...)
-	cmd.Env = append(os.Environ(), c.buildEnv()...)
+	cmd := c.userCommand(ctx, args...)
+	cmd.WaitDelay = time.Second
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
 
 	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start ls command: %w", err)
+		return fmt.Errorf("failed to start ls command: %w", err)
 	}
 
-	var nodes []types.FileNode
 	scanner := bufio.NewScanner(stdout)
-
 	for scanner.Scan() {
-		line := scanner.Bytes()
-
 		// Parse the JSON line directly to FileNode
 		var node types.FileNode
-		if err := json.Unmarshal(line, &node); err != nil {
+		if err := json.Unmarshal(scanner.Bytes(), &node); err != nil {
 			continue // Skip malformed lines
 		}
 
@@ -111,47 +308,452 @@ func (c *Client) ListFiles(snapshotID string, path string) ([]types.FileNode, er
 			continue // Skip non-node entries (like snapshot metadata)
 		}
 
-		nodes = append(nodes, node)
+		select {
+		case out <- node:
+		case <-ctx.Done():
+			cmd.Wait()
+			return ctx.Err()
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading ls output: %w", err)
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("error reading ls output: %w", err)
 	}
 
 	if err := cmd.Wait(); err != nil {
-		return nil, fmt.Errorf("ls command failed: %w", err)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("ls command failed: %w", err)
 	}
 
-	return nodes, nil
+	return nil
+}
+
+// DumpFile returns up to MaxDumpBytes of a file's contents from a snapshot.
+// restic is stopped once the limit is reached, so large files are cheap to preview.
+func (c *Client) DumpFile(snapshotID string, path string) ([]byte, error) {
+	return c.DumpFileLimit(snapshotID, path, c.MaxDumpBytes)
+}
+
+// DumpFileLimit returns up to limit bytes of a file's contents from a snapshot,
+// or DefaultMaxDumpBytes when limit is not positive
+func (c *Client) DumpFileLimit(snapshotID string, path string, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		limit = DefaultMaxDumpBytes
+	}
+
+	ctx, cancel := commandContext(c.Timeout)
+	defer cancel()
+
+	cmd := c.userCommand(ctx, "dump", snapshotID, path)
+	cmd.WaitDelay = time.Second
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start dump command: %w", err)
+	}
+
+	data, readErr := io.ReadAll(io.LimitReader(stdout, limit))
+	truncated := int64(len(data)) == limit
+	if truncated {
+		// Stop restic instead of streaming the rest of the file
+		cancel()
+	}
+
+	waitErr := cmd.Wait()
+	if readErr != nil {
+		return nil, fmt.Errorf("error reading dump output: %w", readErr)
+	}
+	if waitErr != nil && !truncated {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, timeoutError("dump", c.Timeout)
+		}
+		return nil, classifyError(fmt.Errorf("dump command failed: %w (stderr: %s)", waitErr, strings.TrimSpace(stderr.String())), []byte(stderr.String()))
+	}
+
+	return data, nil
 }
 
 // CheckRepository verifies repository integrity
 func (c *Client) CheckRepository() error {
-	_, err := c.execCommand("check")
+	_, err := c.execCommandTimeout(c.LongTimeout, "check")
 	return err
 }
 
+// CheckMessage represents a line of output or the final error from a streamed
+// command (data check, index repair)
+type CheckMessage struct {
+	Line  string
+	Error error
+}
+
+// readDataSubsetPattern matches "N%" (e.g. "5%", "2.5%") or "N/M" (e.g. "1/10")
+var readDataSubsetPattern = regexp.MustCompile(`^(?:(\d+(?:\.\d+)?)%|(\d+)/(\d+))$`)
+
+// ValidateReadDataSubset checks a --read-data-subset argument: a percentage in (0, 100]
+// or an N/M group selector with 1 <= N <= M
+func ValidateReadDataSubset(subset string) error {
+	m := readDataSubsetPattern.FindStringSubmatch(subset)
+	if m == nil {
+		return fmt.Errorf("invalid subset %q: use a percentage (e.g. 5%%) or N/M (e.g. 1/10)", subset)
+	}
+
+	if m[1] != "" {
+		percent, _ := strconv.ParseFloat(m[1], 64)
+		if percent <= 0 || percent > 100 {
+			return fmt.Errorf("invalid subset %q: percentage must be between 0 and 100", subset)
+		}
+		return nil
+	}
+
+	n, _ := strconv.Atoi(m[2])
+	total, _ := strconv.Atoi(m[3])
+	if n < 1 || total < 1 || n > total {
+		return fmt.Errorf("invalid subset %q: N/M requires 1 <= N <= M", subset)
+	}
+	return nil
+}
+
+// CheckRepositoryData verifies repository structure and reads back a subset of the pack data
+func (c *Client) CheckRepositoryData(subset string) (string, error) {
+	updates := make(chan CheckMessage, 10)
+	go c.CheckRepositoryDataWithChannel(context.Background(), subset, updates)
+
+	var lines []string
+	var err error
+	for msg := range updates {
+		if msg.Error != nil {
+			err = msg.Error
+			continue
+		}
+		lines = append(lines, msg.Line)
+	}
+	return strings.Join(lines, "\n"), err
+}
+
+// CheckRepositoryDataWithChannel runs `restic check --read-data-subset` and streams each
+// output line through the channel. The channel is closed when the check finishes.
+func (c *Client) CheckRepositoryDataWithChannel(ctx context.Context, subset string, updates chan<- CheckMessage) {
+	defer close(updates)
+
+	if err := ValidateReadDataSubset(subset); err != nil {
+		updates <- CheckMessage{Error: err}
+		return
+	}
+
+	if c.LongTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.LongTimeout)
+		defer cancel()
+	}
+
+	if err := c.streamCommand(ctx, updates, "check", "--read-data-subset="+subset); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			updates <- CheckMessage{Error: timeoutError("check", c.LongTimeout)}
+			return
+		}
+		updates <- CheckMessage{Error: err}
+	}
+}
+
+// streamCommand runs a restic command bound to ctx and sends each non-empty
+// output line through updates. It does not close the channel.
+func (c *Client) streamCommand(ctx context.Context, updates chan<- CheckMessage, args ...string) error {
+	cmd := c.userCommand(ctx, args...)
+	interruptOnCancel(cmd)
+
+	// Merge stderr into stdout so progress and errors arrive in order
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	cmd.Stderr = cmd.Stdout
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", args[0], err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			updates <- CheckMessage{Line: line}
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("%s failed: %w", args[0], err)
+	}
+	return nil
+}
+
+// repairIndexMinVersion is the first restic release with `repair index`;
+// older releases only have `rebuild-index`
+const repairIndexMinVersion = "0.16.0"
+
+// repairIndexArgs returns the index repair subcommand for a `restic version`
+// string. Unknown versions get `repair index`, the current name.
+func repairIndexArgs(version string) []string {
+	if _, _, _, err := ParseResticVersion(version); err == nil {
+		if checkMinVersion(version, repairIndexMinVersion) != nil {
+			return []string{"rebuild-index"}
+		}
+	}
+	return []string{"repair", "index"}
+}
+
+// RepairIndex rebuilds the repository index and returns restic's output
+func (c *Client) RepairIndex() (string, error) {
+	updates := make(chan CheckMessage, 10)
+	go c.RepairIndexWithChannel(context.Background(), updates)
+
+	var lines []string
+	var err error
+	for msg := range updates {
+		if msg.Error != nil {
+			err = msg.Error
+			continue
+		}
+		lines = append(lines, msg.Line)
+	}
+	return strings.Join(lines, "\n"), err
+}
+
+// RepairIndexWithChannel runs `restic repair index` (`rebuild-index` before restic
+// 0.16) and streams each output line through the channel. Reading every pack can
+// take hours on large repositories, so no deadline is applied beyond ctx. The
+// channel is closed when the repair finishes.
+func (c *Client) RepairIndexWithChannel(ctx context.Context, updates chan<- CheckMessage) {
+	defer close(updates)
+
+	version, _ := GetResticVersion()
+	if err := c.streamCommand(ctx, updates, repairIndexArgs(version)...); err != nil {
+		updates <- CheckMessage{Error: err}
+	}
+}
+
+// ListMigrations returns the names of the migrations restic can apply to the
+// repository, from `restic migrate` without arguments. No migrations is an
+// empty list, not an error.
+func (c *Client) ListMigrations() ([]string, error) {
+	output, err := c.execCommand("migrate")
+	if err != nil {
+		return nil, err
+	}
+	return parseMigrations(output), nil
+}
+
+// parseMigrations extracts the migration names from `restic migrate` output:
+// the indented "name<TAB>description" lines after "available migrations:".
+// restic prints "no migrations found" instead when there are none.
+func parseMigrations(output []byte) []string {
+	var names []string
+	listing := false
+	for _, line := range strings.Split(string(output), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "available migrations") {
+			listing = true
+			continue
+		}
+		if !listing || trimmed == "" || trimmed == "no migrations found" {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			listing = false
+			continue
+		}
+		names = append(names, strings.Fields(trimmed)[0])
+	}
+	return names
+}
+
+// ApplyMigration runs `restic migrate <name>` and returns its output
+func (c *Client) ApplyMigration(name string) (string, error) {
+	updates := make(chan CheckMessage, 10)
+	go c.ApplyMigrationWithChannel(context.Background(), name, updates)
+
+	var lines []string
+	var err error
+	for msg := range updates {
+		if msg.Error != nil {
+			err = msg.Error
+			continue
+		}
+		lines = append(lines, msg.Line)
+	}
+	return strings.Join(lines, "\n"), err
+}
+
+// ApplyMigrationWithChannel runs `restic migrate <name>` and streams each output
+// line through the channel. Migrations such as upgrade_repo_v2 rewrite
+// repository files and hold an exclusive lock, so no deadline is applied beyond
+// ctx. The channel is closed when the migration finishes.
+func (c *Client) ApplyMigrationWithChannel(ctx context.Context, name string, updates chan<- CheckMessage) {
+	defer close(updates)
+
+	if err := c.streamCommand(ctx, updates, "migrate", name); err != nil {
+		updates <- CheckMessage{Error: err}
+	}
+}
+
+// RunWithChannel runs an arbitrary restic subcommand against the repository,
+// streaming its output lines to the updates channel. args start with the
+// subcommand; they are passed to restic as-is, never through a shell.
+func (c *Client) RunWithChannel(ctx context.Context, args []string, updates chan<- CheckMessage) {
+	defer close(updates)
+
+	if len(args) == 0 {
+		updates <- CheckMessage{Error: fmt.Errorf("no restic command given")}
+		return
+	}
+	if err := c.streamCommand(ctx, updates, args...); err != nil {
+		updates <- CheckMessage{Error: err}
+	}
+}
+
 // CleanupCache removes old cache entries
 func (c *Client) CleanupCache() (string, error) {
-	output, err := c.execCommand("cache", "--cleanup")
+	output, err := c.execUserCommand(c.Timeout, "cache", "--cleanup")
 	if err != nil {
 		return "", err
 	}
 	return string(output), nil
 }
 
+// Ping checks that the repository is reachable and the password works by
+// reading the small config blob with `restic cat config`. Failures wrap
+// ErrUnreachable.
+func (c *Client) Ping() error {
+	if _, err := c.execCommand("cat", "config"); err != nil {
+		return fmt.Errorf("%w: %w", ErrUnreachable, err)
+	}
+	return nil
+}
+
+// GetConfigBlob reads the repository config (format version, ID and chunker
+// polynomial) with `restic cat config`
+func (c *Client) GetConfigBlob() (*types.RepoConfigBlob, error) {
+	output, err := c.execCommand("cat", "config")
+	if err != nil {
+		return nil, err
+	}
+	return parseConfigBlob(output)
+}
+
+// parseConfigBlob parses `restic cat config` output. Unknown fields are ignored
+// and missing ones stay zero, so older repository formats still parse.
+func parseConfigBlob(output []byte) (*types.RepoConfigBlob, error) {
+	var blob types.RepoConfigBlob
+	if err := json.Unmarshal(output, &blob); err != nil {
+		return nil, fmt.Errorf("failed to parse repository config JSON: %w", err)
+	}
+	return &blob, nil
+}
+
+// ListLocks returns the locks currently held on the repository, read with
+// `restic list locks` and `restic cat lock`. Both run with --no-lock so the
+// listing doesn't add a lock of its own. Locks released while they are being
+// read are skipped.
+func (c *Client) ListLocks() ([]types.Lock, error) {
+	output, err := c.execCommand("list", "locks", "--no-lock")
+	if err != nil {
+		return nil, err
+	}
+
+	var locks []types.Lock
+	for _, id := range parseLockIDs(output) {
+		data, err := c.execCommand("cat", "lock", id, "--no-lock")
+		if err != nil {
+			continue
+		}
+		lock, err := parseLock(id, data)
+		if err != nil {
+			return nil, err
+		}
+		locks = append(locks, *lock)
+	}
+	return locks, nil
+}
+
+// parseLockIDs extracts the lock IDs from `restic list locks` output, one per line
+func parseLockIDs(output []byte) []string {
+	var ids []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if id := strings.TrimSpace(line); id != "" && !strings.ContainsAny(id, " \t") {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// parseLock parses the JSON `restic cat lock` prints for the lock with the given ID
+func parseLock(id string, output []byte) (*types.Lock, error) {
+	var lock types.Lock
+	if err := json.Unmarshal(output, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse lock %.8s: %w", id, err)
+	}
+	lock.ID = id
+	return &lock, nil
+}
+
 // Unlock removes stale locks from the repository
 func (c *Client) Unlock() (string, error) {
-	output, err := c.execCommand("unlock")
+	output, err := c.execUserCommand(c.Timeout, buildUnlockArgs(false)...)
 	if err != nil {
 		return "", err
 	}
 	return string(output), nil
 }
 
+// UnlockAll removes every lock, including ones held by processes that are still
+// running. Only safe when no other restic process is using the repository.
+func (c *Client) UnlockAll() (string, error) {
+	output, err := c.execUserCommand(c.Timeout, buildUnlockArgs(true)...)
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// buildUnlockArgs constructs the restic unlock arguments; removeAll also
+// removes locks that aren't stale
+func buildUnlockArgs(removeAll bool) []string {
+	if removeAll {
+		return []string{"unlock", "--remove-all"}
+	}
+	return []string{"unlock"}
+}
+
 // GetStats retrieves repository statistics
 func (c *Client) GetStats() (*types.RepositoryStats, error) {
-	output, err := c.execCommand("stats", "--json")
+	return c.GetStatsMode(StatsModeRestoreSize)
+}
+
+// GetStatsMode retrieves repository statistics using a restic stats mode.
+// StatsModeRawData and StatsModeFilesByContents read every snapshot's trees or
+// blobs and can be slow on large repositories.
+func (c *Client) GetStatsMode(mode string) (*types.RepositoryStats, error) {
+	return c.statsMode(mode)
+}
+
+// statsMode runs restic stats in mode over the given snapshots, or every
+// snapshot when none are given
+func (c *Client) statsMode(mode string, snapshotIDs ...string) (*types.RepositoryStats, error) {
+	timeout := c.Timeout
+	if mode == StatsModeRawData || mode == StatsModeFilesByContents {
+		timeout = c.LongTimeout
+	}
+	args := append([]string{"stats", "--json", "--mode", mode}, snapshotIDs...)
+	output, err := c.execCommandTimeout(timeout, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -164,6 +766,206 @@ func (c *Client) GetStats() (*types.RepositoryStats, error) {
 	return &stats, nil
 }
 
+// GetSnapshotStats retrieves the restore size (total size of all files) of a snapshot
+func (c *Client) GetSnapshotStats(snapshotID string) (*types.SnapshotStats, error) {
+	output, err := c.execCommand("stats", snapshotID, "--json", "--mode", "restore-size")
+	if err != nil {
+		return nil, err
+	}
+
+	var stats types.SnapshotStats
+	if err := json.Unmarshal(output, &stats); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot stats JSON: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// GetSnapshotSize retrieves the raw (deduplicated, on-disk) data size referenced by a snapshot.
+// This reads every blob of the snapshot and can be slow on large repositories.
+func (c *Client) GetSnapshotSize(snapshotID string) (*types.SnapshotStats, error) {
+	output, err := c.execCommandTimeout(c.LongTimeout, "stats", "--json", "--mode", "raw-data", snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats types.SnapshotStats
+	if err := json.Unmarshal(output, &stats); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot stats JSON: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// Find searches every snapshot for files matching pattern
+func (c *Client) Find(pattern string) ([]types.FindResult, error) {
+	output, err := c.execUserCommand(c.LongTimeout, "find", "--json", pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseFindOutput(output)
+}
+
+// findSnapshotMatches is one snapshot's entry in restic find --json output
+type findSnapshotMatches struct {
+	Snapshot string           `json:"snapshot"`
+	Matches  []types.FileNode `json:"matches"`
+}
+
+// parseFindOutput converts restic find --json output into a flat result list.
+// restic prints a single JSON array of per-snapshot entries, which may span
+// several lines; anything before it (e.g. warnings on stderr) is ignored.
+func parseFindOutput(output []byte) ([]types.FindResult, error) {
+	text := strings.TrimSpace(string(output))
+	start := strings.Index(text, "[")
+	if nl := strings.Index(text, "\n["); start != 0 && nl >= 0 {
+		start = nl + 1
+	}
+	end := strings.LastIndex(text, "]")
+	if start < 0 || end < start {
+		if text == "" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unexpected find output: %s", text)
+	}
+
+	var entries []findSnapshotMatches
+	if err := json.Unmarshal([]byte(text[start:end+1]), &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse find JSON: %w", err)
+	}
+
+	var results []types.FindResult
+	for _, entry := range entries {
+		for _, match := range entry.Matches {
+			results = append(results, types.FindResult{
+				SnapshotID: entry.Snapshot,
+				Path:       match.Path,
+				Type:       match.Type,
+				Size:       match.Size,
+				ModTime:    match.ModTime,
+			})
+		}
+	}
+
+	return results, nil
+}
+
+// Stats modes for GetStatsMode
+const (
+	StatsModeRestoreSize     = "restore-size"      // Size of all files as restored
+	StatsModeRawData         = "raw-data"          // Deduplicated size of the blobs snapshots reference
+	StatsModeFilesByContents = "files-by-contents" // Size of the distinct file contents
+)
+
+// storedSizeEntry is a cached raw-data size and the snapshot set it was computed for
+type storedSizeEntry struct {
+	fingerprint string
+	size        int64
+}
+
+// storedSizeCache keeps raw-data sizes per repository path across clients, so
+// refreshes don't rerun the expensive stats call while the snapshots are unchanged
+var storedSizeCache = struct {
+	sync.Mutex
+	entries map[string]storedSizeEntry
+}{entries: make(map[string]storedSizeEntry)}
+
+// snapshotFingerprint identifies a set of snapshots independent of their order
+func snapshotFingerprint(snapshots []types.Snapshot) string {
+	ids := make([]string, len(snapshots))
+	for i, snap := range snapshots {
+		ids[i] = snap.ID
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+// storedSize returns the repository's raw-data size, from the cache when the
+// snapshot set is unchanged. Returns 0 if restic fails to compute it.
+func (c *Client) storedSize(snapshots []types.Snapshot) int64 {
+	fingerprint := snapshotFingerprint(snapshots)
+
+	storedSizeCache.Lock()
+	entry, ok := storedSizeCache.entries[c.config.Path]
+	storedSizeCache.Unlock()
+	if ok && entry.fingerprint == fingerprint {
+		return entry.size
+	}
+
+	stats, err := c.GetStatsMode(StatsModeRawData)
+	if err != nil {
+		return 0
+	}
+
+	storedSizeCache.Lock()
+	storedSizeCache.entries[c.config.Path] = storedSizeEntry{fingerprint: fingerprint, size: stats.TotalSize}
+	storedSizeCache.Unlock()
+	return stats.TotalSize
+}
+
+// duplicateStatsEntry is cached duplicate data and the snapshot it was computed for
+type duplicateStatsEntry struct {
+	snapshotID string
+	stats      types.DuplicateStats
+}
+
+// duplicateStatsCache keeps duplicate data per repository path across clients,
+// so asking again doesn't rerun two stats passes while the latest snapshot is unchanged
+var duplicateStatsCache = struct {
+	sync.Mutex
+	entries map[string]duplicateStatsEntry
+}{entries: make(map[string]duplicateStatsEntry)}
+
+// GetDuplicateStats compares the size of all files in the latest snapshot with
+// the size of their distinct contents. Only one snapshot is measured: across
+// snapshots, every unchanged file would count as a duplicate. The result is
+// cached per repository until a newer snapshot is taken.
+func (c *Client) GetDuplicateStats() (*types.DuplicateStats, error) {
+	snapshots, err := c.ListSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("repository has no snapshots")
+	}
+	latest := snapshots[0]
+	for _, snapshot := range snapshots[1:] {
+		if snapshot.Time.After(latest.Time) {
+			latest = snapshot
+		}
+	}
+
+	duplicateStatsCache.Lock()
+	entry, ok := duplicateStatsCache.entries[c.config.Path]
+	duplicateStatsCache.Unlock()
+	if ok && entry.snapshotID == latest.ID {
+		stats := entry.stats
+		return &stats, nil
+	}
+
+	total, err := c.statsMode(StatsModeRestoreSize, latest.ID)
+	if err != nil {
+		return nil, err
+	}
+	unique, err := c.statsMode(StatsModeFilesByContents, latest.ID)
+	if err != nil {
+		return nil, err
+	}
+	stats := types.DuplicateStats{
+		SnapshotID:  latest.ID,
+		TotalSize:   total.TotalSize,
+		TotalFiles:  total.TotalFileCount,
+		UniqueSize:  unique.TotalSize,
+		UniqueFiles: unique.TotalFileCount,
+	}
+
+	duplicateStatsCache.Lock()
+	duplicateStatsCache.entries[c.config.Path] = duplicateStatsEntry{snapshotID: latest.ID, stats: stats}
+	duplicateStatsCache.Unlock()
+	return &stats, nil
+}
+
 // GetRepositoryInfo retrieves comprehensive repository information
 func (c *Client) GetRepositoryInfo() (*types.Repository, error) {
 	repo := &types.Repository{
@@ -188,6 +990,9 @@ func (c *Client) GetRepositoryInfo() (*types.Repository, error) {
 		return repo, nil
 	}
 
+	// The deduplicated size only changes with the snapshot set, so reuse it when possible
+	repo.StoredSize = c.storedSize(snapshots)
+
 	// Find the most recent snapshot
 	if len(snapshots) > 0 {
 		mostRecent := snapshots[0]
@@ -199,12 +1004,8 @@ func (c *Client) GetRepositoryInfo() (*types.Repository, error) {
 		repo.LastBackup = mostRecent.Time
 	}
 
-	// Check repository health
-	if err := c.CheckRepository(); err != nil {
-		repo.Status = "warning"
-	} else {
-		repo.Status = "healthy"
-	}
+	// Check repository health, telling a held lock apart from real damage
+	repo.Status = checkStatus(c.CheckRepository())
 
 	return repo, nil
 }
@@ -227,10 +1028,73 @@ func GetResticVersion() (string, error) {
 	return version, nil
 }
 
+// DefaultRepositoryVersion and DefaultCompression are used for new repositories
+// when restic supports them: format version 2 with compression
+const (
+	DefaultRepositoryVersion = 2
+	DefaultCompression       = "auto"
+)
+
+// compressionMinVersion is the first restic release with repository format
+// version 2 and compression
+const compressionMinVersion = "0.14.0"
+
+// SupportsCompression reports whether the given `restic version` string is new
+// enough for --repository-version and compression. Unknown versions report false.
+func SupportsCompression(version string) bool {
+	return checkMinVersion(version, compressionMinVersion) == nil
+}
+
 // Init initializes a new restic repository
-func (c *Client) Init() error {
-	_, err := c.execCommand("init")
-	return err
+func (c *Client) Init(opts types.InitOptions) error {
+	version, _ := GetResticVersion()
+	opts = initOptionsForVersion(opts, version)
+
+	args := buildInitArgs(opts)
+	c.recordCommand(args)
+	_, stderr, err := c.runCommand(c.Timeout, initEnv(opts), args...)
+	if err != nil {
+		return classifyInitError(err, stderr)
+	}
+	return nil
+}
+
+// InitWithOptions initializes a new repository with the given format version
+// and compression mode; options the installed restic lacks are left out
+func (c *Client) InitWithOptions(repoVersion int, compression string) error {
+	return c.Init(types.InitOptions{RepositoryVersion: repoVersion, Compression: compression})
+}
+
+// initOptionsForVersion drops the repository version and compression when the
+// given `restic version` string predates them. Unknown versions keep every option.
+func initOptionsForVersion(opts types.InitOptions, version string) types.InitOptions {
+	if _, _, _, err := ParseResticVersion(version); err == nil && !SupportsCompression(version) {
+		opts.RepositoryVersion = 0
+		opts.Compression = ""
+	}
+	return opts
+}
+
+// buildInitArgs constructs the restic init arguments for opts
+func buildInitArgs(opts types.InitOptions) []string {
+	args := []string{"init"}
+
+	if opts.PackSize != "" {
+		args = append(args, "--pack-size", opts.PackSize)
+	}
+	if opts.RepositoryVersion > 0 {
+		args = append(args, "--repository-version", strconv.Itoa(opts.RepositoryVersion))
+	}
+
+	return args
+}
+
+// initEnv returns the environment variables restic init needs for opts
+func initEnv(opts types.InitOptions) []string {
+	if opts.Compression == "" {
+		return nil
+	}
+	return []string{"RESTIC_COMPRESSION=" + opts.Compression}
 }
 
 // BackupProgressCallback is called for each progress update during backup
@@ -238,9 +1102,53 @@ type BackupProgressCallback func(progress *types.BackupProgress, summary *types.
 
 // BackupMessage represents a message from the backup operation
 type BackupMessage struct {
-	Progress *types.BackupProgress
-	Summary  *types.BackupSummary
-	Error    error
+	Progress   *types.BackupProgress
+	Summary    *types.BackupSummary
+	StderrLine string // A line restic wrote to stderr (e.g. unreadable file warnings)
+	Warning    string // Set with the summary when restic finished but skipped files (exit code 3)
+	Error      error
+}
+
+// exitCodeIncomplete is restic's exit code for a backup that created a
+// snapshot but could not read some source files
+const exitCodeIncomplete = 3
+
+// maxStderrTail is how many trailing stderr lines are kept for the final error message
+const maxStderrTail = 20
+
+// streamStderr forwards each stderr line as a BackupMessage as it is written and
+// returns the trailing lines for error reporting once stderr is closed, along
+// with the number of files restic reported it could not read
+func streamStderr(stderr io.Reader, updates chan<- BackupMessage) (tail []string, unreadable int) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		updates <- BackupMessage{StderrLine: line}
+		if isFileErrorLine(line) {
+			unreadable++
+		}
+
+		tail = append(tail, line)
+		if len(tail) > maxStderrTail {
+			tail = tail[1:]
+		}
+	}
+	return tail, unreadable
+}
+
+// isFileErrorLine reports whether a stderr line is restic's report of a
+// source file it failed to read, in plain ("error: ...") or JSON form
+func isFileErrorLine(line string) bool {
+	if strings.HasPrefix(line, "error: ") {
+		return true
+	}
+	var msg struct {
+		MessageType string `json:"message_type"`
+	}
+	return json.Unmarshal([]byte(line), &msg) == nil && msg.MessageType == "error"
 }
 
 // RestoreMessage represents a message from the restore operation
@@ -250,11 +1158,8 @@ type RestoreMessage struct {
 	Error    error
 }
 
-// BackupWithChannel performs a backup and sends updates through a channel
-func (c *Client) BackupWithChannel(ctx context.Context, opts types.BackupOptions, updates chan<- BackupMessage) {
-	defer close(updates)
-
-	// Build command arguments
+// buildBackupArgs constructs the restic backup arguments for the given options
+func buildBackupArgs(opts types.BackupOptions) []string {
 	args := []string{"backup", "--json"}
 
 	// Add tags
@@ -266,13 +1171,47 @@ func (c *Client) BackupWithChannel(ctx context.Context, opts types.BackupOptions
 	for _, exclude := range opts.Exclude {
 		args = append(args, "--exclude", exclude)
 	}
+	if opts.ExcludeFile != "" {
+		args = append(args, "--exclude-file", opts.ExcludeFile)
+	}
+	if opts.ExcludeCaches {
+		args = append(args, "--exclude-caches")
+	}
+	if opts.MaxFileSize != "" {
+		args = append(args, "--exclude-larger-than", opts.MaxFileSize)
+	}
+
+	// Stay on the filesystems of the given paths (skips /proc, network mounts, ...)
+	if opts.OneFileSystem {
+		args = append(args, "--one-file-system")
+	}
+
+	// Add pack size (advanced tuning for high-latency backends)
+	if opts.PackSize != "" {
+		args = append(args, "--pack-size", opts.PackSize)
+	}
+
+	// Preview only: restic still reports a summary but writes nothing
+	if opts.DryRun {
+		args = append(args, "--dry-run")
+	}
 
 	// Add paths
 	args = append(args, opts.Paths...)
 
+	return args
+}
+
+// BackupWithChannel performs a backup and sends updates through a channel
+func (c *Client) BackupWithChannel(ctx context.Context, opts types.BackupOptions, updates chan<- BackupMessage) {
+	defer close(updates)
+
+	// Build command arguments
+	args := buildBackupArgs(opts)
+
 	// Create command
-	cmd := exec.CommandContext(ctx, "restic", args...)
-	cmd.Env = append(os.Environ(), c.buildEnv()...)
+	cmd := c.userCommand(ctx, args...)
+	interruptOnCancel(cmd)
 
 	// Get stdout pipe for streaming
 	stdout, err := cmd.StdoutPipe()
@@ -294,6 +1233,21 @@ func (c *Client) BackupWithChannel(ctx context.Context, opts types.BackupOptions
 		return
 	}
 
+	// Stream stderr concurrently so warnings interleave with progress
+	type stderrResult struct {
+		tail       []string
+		unreadable int
+	}
+	stderrDone := make(chan stderrResult, 1)
+	go func() {
+		tail, unreadable := streamStderr(stderr, updates)
+		stderrDone <- stderrResult{tail, unreadable}
+	}()
+
+	// The summary is held back until restic exits, so exactly one final
+	// message (summary or error) is sent
+	var summary *types.BackupSummary
+
 	// Read and process JSON output line by line
 	scanner := bufio.NewScanner(stdout)
 	for scanner.Scan() {
@@ -320,50 +1274,56 @@ func (c *Client) BackupWithChannel(ctx context.Context, opts types.BackupOptions
 
 		case "summary":
 			// Final summary
-			var summary types.BackupSummary
-			if err := json.Unmarshal(line, &summary); err != nil {
+			var parsed types.BackupSummary
+			if err := json.Unmarshal(line, &parsed); err != nil {
 				continue
 			}
-			updates <- BackupMessage{Summary: &summary}
+			summary = &parsed
 		}
 	}
-
-	if err := scanner.Err(); err != nil {
-		updates <- BackupMessage{Error: fmt.Errorf("error reading backup output: %w", err)}
-		return
+	scanErr := scanner.Err()
+	if scanErr != nil {
+		// Keep draining so restic doesn't block on a full pipe
+		io.Copy(io.Discard, stdout)
 	}
 
-	// Check for errors on stderr
-	stderrData, _ := io.ReadAll(stderr)
+	// Drain stderr before Wait closes the pipe
+	result := <-stderrDone
+	waitErr := cmd.Wait()
 
-	// Wait for command to complete
-	if err := cmd.Wait(); err != nil {
-		updates <- BackupMessage{Error: fmt.Errorf("backup failed: %w (stderr: %s)", err, string(stderrData))}
-		return
+	var exitErr *exec.ExitError
+	switch {
+	case waitErr != nil && errors.As(waitErr, &exitErr) && exitErr.ExitCode() == exitCodeIncomplete && summary != nil:
+		// The snapshot was created, but some source files were skipped
+		updates <- BackupMessage{Summary: summary, Warning: skippedFilesWarning(result.unreadable)}
+	case waitErr != nil:
+		updates <- BackupMessage{Error: fmt.Errorf("backup failed: %w (stderr: %s)", waitErr, strings.Join(result.tail, "\n"))}
+	case scanErr != nil:
+		updates <- BackupMessage{Error: fmt.Errorf("error reading backup output: %w", scanErr)}
+	case summary != nil:
+		updates <- BackupMessage{Summary: summary}
+	}
+}
+
+// skippedFilesWarning describes the files an incomplete backup could not read
+func skippedFilesWarning(unreadable int) string {
+	switch unreadable {
+	case 0:
+		return "some source files could not be read"
+	case 1:
+		return "1 file could not be read"
+	default:
+		return fmt.Sprintf("%d files could not be read", unreadable)
 	}
 }
 
 // Backup performs a backup operation with progress tracking
 func (c *Client) Backup(opts types.BackupOptions, progressCallback BackupProgressCallback) error {
 	// Build command arguments
-	args := []string{"backup", "--json"}
-
-	// Add tags
-	for _, tag := range opts.Tags {
-		args = append(args, "--tag", tag)
-	}
-
-	// Add excludes
-	for _, exclude := range opts.Exclude {
-		args = append(args, "--exclude", exclude)
-	}
-
-	// Add paths
-	args = append(args, opts.Paths...)
+	args := buildBackupArgs(opts)
 
 	// Create command
-	cmd := exec.Command("restic", args...)
-	cmd.Env = append(os.Environ(), c.buildEnv()...)
+	cmd := c.userCommand(context.Background(), args...)
 
 	// Get stdout pipe for streaming
 	stdout, err := cmd.StdoutPipe()
@@ -439,26 +1399,101 @@ func (c *Client) Backup(opts types.BackupOptions, progressCallback BackupProgres
 	return nil
 }
 
-// RestoreWithChannel performs a restore and sends updates through a channel
-func (c *Client) RestoreWithChannel(ctx context.Context, opts types.RestoreOptions, updates chan<- RestoreMessage) {
-	defer close(updates)
+// buildStdinBackupArgs builds the arguments for backing up data read from stdin.
+// Paths are ignored since restic reads nothing from disk; filename names the
+// single file stored in the snapshot (restic uses "stdin" when it's empty).
+func buildStdinBackupArgs(opts types.BackupOptions, filename string) []string {
+	opts.Paths = nil
+	args := append(buildBackupArgs(opts), "--stdin")
+	if filename != "" {
+		args = append(args, "--stdin-filename", filename)
+	}
+	return args
+}
 
-	// Build command arguments
-	args := []string{"restore", opts.SnapshotID}
+// BackupStdin backs up everything read from reader as a single file named
+// filename, e.g. a database dump piped from another command. It returns
+// restic's summary of the new snapshot.
+func (c *Client) BackupStdin(reader io.Reader, filename string, opts types.BackupOptions) (*types.BackupSummary, error) {
+	if err := c.checkPasswordEnv(); err != nil {
+		return nil, err
+	}
 
-	// Add target directory
-	if opts.Target != "" {
-		args = append(args, "--target", opts.Target)
+	// Not recorded as the last command: the piped data can't be replayed
+	cmd := exec.Command("restic", c.buildArgs(buildStdinBackupArgs(opts, filename)...)...)
+	cmd.Env = append(os.Environ(), c.buildEnv()...)
+	cmd.Stdin = reader
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		output := stderr.Bytes()
+		return nil, classifyError(fmt.Errorf("backup failed: %w (stderr: %s)", err, strings.TrimSpace(string(output))), output)
 	}
 
-	// Add include paths if specified
-	for _, include := range opts.Include {
-		args = append(args, "--include", include)
+	return parseBackupSummary(stdout.Bytes())
+}
+
+// parseBackupSummary returns the summary line of restic backup --json output
+func parseBackupSummary(output []byte) (*types.BackupSummary, error) {
+	for _, line := range bytes.Split(output, []byte("\n")) {
+		var summary types.BackupSummary
+		if err := json.Unmarshal(line, &summary); err != nil || summary.MessageType != "summary" {
+			continue
+		}
+		return &summary, nil
+	}
+	return nil, fmt.Errorf("backup finished without a summary (output: %s)", string(output))
+}
+
+// HasExclusions reports whether a backup with opts skips anything by rule:
+// exclude patterns, an exclude file, cache directories or a size limit
+func HasExclusions(opts types.BackupOptions) bool {
+	return len(opts.Exclude) > 0 || opts.ExcludeFile != "" || opts.ExcludeCaches || opts.MaxFileSize != ""
+}
+
+// buildExclusionDryRunArgs constructs the arguments for a dry run of the
+// backup with opts without its exclude rules
+func buildExclusionDryRunArgs(opts types.BackupOptions) []string {
+	opts.Exclude = nil
+	opts.ExcludeFile = ""
+	opts.ExcludeCaches = false
+	opts.MaxFileSize = ""
+	opts.DryRun = true
+	return buildBackupArgs(opts)
+}
+
+// EstimateExcluded measures what the exclude rules of a completed backup
+// skipped. restic doesn't report excluded files, so a dry run of the same paths
+// without the rules is compared with the backup's summary; files that changed
+// in between make the result approximate.
+func (c *Client) EstimateExcluded(opts types.BackupOptions, backup *types.BackupSummary) (*types.ExclusionSummary, error) {
+	output, err := c.execCommandTimeout(c.LongTimeout, buildExclusionDryRunArgs(opts)...)
+	if err != nil {
+		return nil, err
+	}
+	all, err := parseBackupSummary(output)
+	if err != nil {
+		return nil, err
 	}
+	return &types.ExclusionSummary{
+		ExcludedFiles: max(all.TotalFilesProcessed-backup.TotalFilesProcessed, 0),
+		ExcludedBytes: max(all.TotalBytesProcessed-backup.TotalBytesProcessed, 0),
+	}, nil
+}
+
+// RestoreWithChannel performs a restore and sends updates through a channel
+func (c *Client) RestoreWithChannel(ctx context.Context, opts types.RestoreOptions, updates chan<- RestoreMessage) {
+	defer close(updates)
+
+	version, _ := GetResticVersion()
+	opts = restoreOptionsForVersion(opts, version)
 
 	// Create command
-	cmd := exec.CommandContext(ctx, "restic", args...)
-	cmd.Env = append(os.Environ(), c.buildEnv()...)
+	cmd := c.userCommand(ctx, buildRestoreArgs(opts)...)
+	interruptOnCancel(cmd)
 
 	// Get stdout pipe for streaming
 	stdout, err := cmd.StdoutPipe()
@@ -480,6 +1515,13 @@ func (c *Client) RestoreWithChannel(ctx context.Context, opts types.RestoreOptio
 		return
 	}
 
+	// Collect stderr concurrently so a chatty restic can't block on a full pipe
+	stderrDone := make(chan []byte, 1)
+	go func() {
+		data, _ := io.ReadAll(stderr)
+		stderrDone <- data
+	}()
+
 	// Read and process output line by line
 	scanner := bufio.NewScanner(stdout)
 	for scanner.Scan() {
@@ -496,84 +1538,201 @@ func (c *Client) RestoreWithChannel(ctx context.Context, opts types.RestoreOptio
 			}
 		}
 	}
-
-	if err := scanner.Err(); err != nil {
-		updates <- RestoreMessage{Error: fmt.Errorf("error reading restore output: %w", err)}
-		return
-	}
-
-	// Check for errors on stderr
-	stderrData, _ := io.ReadAll(stderr)
-
-	// Wait for command to complete
-	if err := cmd.Wait(); err != nil {
-		updates <- RestoreMessage{Error: fmt.Errorf("restore failed: %w (stderr: %s)", err, string(stderrData))}
-		return
-	}
-
-	// Send completion summary
-	updates <- RestoreMessage{
-		Summary: &types.RestoreSummary{
-			MessageType: "summary",
-		},
+	scanErr := scanner.Err()
+	if scanErr != nil {
+		// Keep restic from blocking on a full stdout pipe so Wait can return
+		io.Copy(io.Discard, stdout)
+	}
+
+	// Drain stderr before Wait closes the pipe
+	stderrData := <-stderrDone
+
+	// Always reap the process, then report exactly one outcome: an error, or
+	// a summary once restic has confirmed success by exiting cleanly
+	waitErr := cmd.Wait()
+	switch {
+	case waitErr != nil:
+		updates <- RestoreMessage{Error: fmt.Errorf("restore failed: %w (stderr: %s)", waitErr, strings.TrimSpace(string(stderrData)))}
+	case scanErr != nil:
+		updates <- RestoreMessage{Error: fmt.Errorf("error reading restore output: %w", scanErr)}
+	default:
+		updates <- RestoreMessage{
+			Summary: &types.RestoreSummary{
+				MessageType: "summary",
+			},
+		}
 	}
 }
 
 // Restore performs a restore operation (synchronous version for compatibility)
 func (c *Client) Restore(opts types.RestoreOptions) error {
+	version, _ := GetResticVersion()
+	opts = restoreOptionsForVersion(opts, version)
+	_, err := c.execUserCommand(c.LongTimeout, buildRestoreArgs(opts)...)
+	return err
+}
+
+// buildRestoreArgs constructs the restic restore arguments for opts
+func buildRestoreArgs(opts types.RestoreOptions) []string {
 	args := []string{"restore", opts.SnapshotID}
 
+	// Add target directory
 	if opts.Target != "" {
 		args = append(args, "--target", opts.Target)
 	}
 
+	// Add include paths if specified
 	for _, include := range opts.Include {
 		args = append(args, "--include", include)
 	}
+	if opts.IncludeFile != "" {
+		args = append(args, "--include-file", opts.IncludeFile)
+	}
 
-	_, err := c.execCommand(args...)
-	return err
+	if opts.Overwrite != "" {
+		args = append(args, "--overwrite", opts.Overwrite)
+	}
+
+	return args
 }
 
-// ForgetDryRun performs a dry-run of forget to preview what would be removed
-func (c *Client) ForgetDryRun(policy types.ForgetPolicy) ([]types.ForgetResult, error) {
-	args := []string{"forget", "--dry-run", "--json"}
+// overwriteMinVersion is the first restic release with restore --overwrite
+const overwriteMinVersion = "0.17.0"
 
-	// Add policy flags
-	if policy.KeepLast > 0 {
-		args = append(args, "--keep-last", fmt.Sprintf("%d", policy.KeepLast))
-	}
-	if policy.KeepHourly > 0 {
-		args = append(args, "--keep-hourly", fmt.Sprintf("%d", policy.KeepHourly))
+// includeFileMinVersion is the first restic release with restore --include-file
+const includeFileMinVersion = "0.17.0"
+
+// restoreOptionsForVersion drops options the given `restic version` string
+// doesn't support. Older restic always overwrites, so the overwrite mode is
+// dropped rather than failing the restore, and an include file is expanded
+// into --include flags. Unknown versions keep every option.
+func restoreOptionsForVersion(opts types.RestoreOptions, version string) types.RestoreOptions {
+	if _, _, _, err := ParseResticVersion(version); err == nil {
+		if checkMinVersion(version, overwriteMinVersion) != nil {
+			opts.Overwrite = ""
+		}
+		if opts.IncludeFile != "" && checkMinVersion(version, includeFileMinVersion) != nil {
+			// If the file can't be read, keep it so restic reports the problem
+			if includes, err := readIncludeFile(opts.IncludeFile); err == nil {
+				opts.Include = append(append([]string{}, opts.Include...), includes...)
+				opts.IncludeFile = ""
+			}
+		}
 	}
-	if policy.KeepDaily > 0 {
-		args = append(args, "--keep-daily", fmt.Sprintf("%d", policy.KeepDaily))
+	return opts
+}
+
+// readIncludeFile reads the paths of an --include-file, skipping blank lines
+// and # comments like restic does
+func readIncludeFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
-	if policy.KeepWeekly > 0 {
-		args = append(args, "--keep-weekly", fmt.Sprintf("%d", policy.KeepWeekly))
+	var includes []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		includes = append(includes, line)
 	}
-	if policy.KeepMonthly > 0 {
-		args = append(args, "--keep-monthly", fmt.Sprintf("%d", policy.KeepMonthly))
+	return includes, nil
+}
+
+// buildRestoreDryRunArgs constructs the arguments for a restore dry run. Verbosity 2
+// makes restic report every file it would write.
+func buildRestoreDryRunArgs(opts types.RestoreOptions) []string {
+	return append(buildRestoreArgs(opts), "--dry-run", "--json", "--verbose=2")
+}
+
+// restoreVerboseStatus is a per-file line of restic restore --json --verbose=2 output
+type restoreVerboseStatus struct {
+	MessageType string `json:"message_type"`
+	Action      string `json:"action"`
+	Item        string `json:"item"`
+}
+
+// RestoreDryRun returns the paths a restore with opts would write, without
+// writing anything. restic before 0.17 has no restore --dry-run, so the
+// snapshot's files are listed and filtered by the include paths instead.
+func (c *Client) RestoreDryRun(opts types.RestoreOptions) ([]string, error) {
+	version, _ := GetResticVersion()
+	opts = restoreOptionsForVersion(opts, version)
+
+	args := buildRestoreDryRunArgs(opts)
+	c.recordCommand(args)
+	output, stderr, err := c.runCommand(c.LongTimeout, nil, args...)
+	if err != nil {
+		if strings.Contains(string(stderr), "unknown flag: --dry-run") {
+			return c.restorePathsFromListing(opts)
+		}
+		return nil, err
 	}
-	if policy.KeepYearly > 0 {
-		args = append(args, "--keep-yearly", fmt.Sprintf("%d", policy.KeepYearly))
+
+	var paths []string
+	for _, line := range strings.Split(string(output), "\n") {
+		var status restoreVerboseStatus
+		if err := json.Unmarshal([]byte(line), &status); err != nil {
+			continue // Skip non-JSON lines
+		}
+		if status.MessageType != "verbose_status" || status.Item == "" {
+			continue
+		}
+		// Files that already match the snapshot aren't written
+		if status.Action == "unchanged" {
+			continue
+		}
+		paths = append(paths, status.Item)
 	}
-	if policy.KeepWithin != "" {
-		args = append(args, "--keep-within", policy.KeepWithin)
+	return paths, nil
+}
+
+// restorePathsFromListing approximates RestoreDryRun with restic ls for restic
+// versions without restore --dry-run
+func (c *Client) restorePathsFromListing(opts types.RestoreOptions) ([]string, error) {
+	nodes, err := c.ListFiles(opts.SnapshotID, "")
+	if err != nil {
+		return nil, err
 	}
 
-	// Add filters
-	if policy.Host != "" {
-		args = append(args, "--host", policy.Host)
+	var paths []string
+	for _, node := range nodes {
+		if !matchesRestoreInclude(node.Path, opts.Include) {
+			continue
+		}
+		if opts.Target != "" {
+			paths = append(paths, filepath.Join(opts.Target, node.Path))
+		} else {
+			paths = append(paths, node.Path)
+		}
 	}
-	for _, tag := range policy.Tags {
-		args = append(args, "--tag", tag)
+	return paths, nil
+}
+
+// matchesRestoreInclude reports whether path is selected by restic restore's
+// --include patterns: the pattern itself, anything below it, or a glob match
+func matchesRestoreInclude(path string, includes []string) bool {
+	if len(includes) == 0 {
+		return true
 	}
-	for _, path := range policy.Paths {
-		args = append(args, "--path", path)
+	for _, include := range includes {
+		include = strings.TrimSuffix(include, "/")
+		if path == include || strings.HasPrefix(path, include+"/") {
+			return true
+		}
+		if matched, _ := filepath.Match(include, path); matched {
+			return true
+		}
 	}
+	return false
+}
+
+// ForgetDryRun performs a dry-run of forget to preview what would be removed
+func (c *Client) ForgetDryRun(policy types.ForgetPolicy) ([]types.ForgetResult, error) {
+	args := buildForgetArgs(withProtectedTag(policy), "--dry-run", "--json")
 
-	output, err := c.execCommand(args...)
+	output, err := c.execUserCommand(c.Timeout, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -588,9 +1747,53 @@ func (c *Client) ForgetDryRun(policy types.ForgetPolicy) ([]types.ForgetResult,
 
 // Forget removes snapshots according to policy
 func (c *Client) Forget(policy types.ForgetPolicy) error {
-	args := []string{"forget"}
+	args := buildForgetArgs(withProtectedTag(policy))
+	_, err := c.execUserCommand(c.LongTimeout, args...)
+	return err
+}
+
+// ProtectedTag marks snapshots that retention policies must always keep
+const ProtectedTag = "protected"
+
+// hasKeepRules reports whether policy says which snapshots to keep. restic
+// refuses to forget anything without one.
+func hasKeepRules(policy types.ForgetPolicy) bool {
+	return policy.KeepLast > 0 || policy.KeepHourly > 0 || policy.KeepDaily > 0 ||
+		policy.KeepWeekly > 0 || policy.KeepMonthly > 0 || policy.KeepYearly > 0 ||
+		policy.KeepWithin != "" || len(policy.KeepTags) > 0
+}
+
+// IsProtected reports whether a snapshot carries the tag forget policies always keep
+func IsProtected(snapshot types.Snapshot) bool {
+	for _, tag := range snapshot.Tags {
+		if tag == ProtectedTag {
+			return true
+		}
+	}
+	return false
+}
+
+// withProtectedTag adds ProtectedTag to the policy's keep tags. A policy with
+// no keep rules is left alone: --keep-tag alone would forget every snapshot
+// that isn't protected.
+func withProtectedTag(policy types.ForgetPolicy) types.ForgetPolicy {
+	if !hasKeepRules(policy) {
+		return policy
+	}
+	for _, tag := range policy.KeepTags {
+		if tag == ProtectedTag {
+			return policy
+		}
+	}
+	policy.KeepTags = append(append([]string{}, policy.KeepTags...), ProtectedTag)
+	return policy
+}
+
+// buildForgetArgs constructs the restic forget arguments for policy, followed by extra flags
+func buildForgetArgs(policy types.ForgetPolicy, extra ...string) []string {
+	args := append([]string{"forget"}, extra...)
 
-	// Add policy flags (same as dry-run)
+	// Add policy flags
 	if policy.KeepLast > 0 {
 		args = append(args, "--keep-last", fmt.Sprintf("%d", policy.KeepLast))
 	}
@@ -612,6 +1815,9 @@ func (c *Client) Forget(policy types.ForgetPolicy) error {
 	if policy.KeepWithin != "" {
 		args = append(args, "--keep-within", policy.KeepWithin)
 	}
+	for _, tag := range policy.KeepTags {
+		args = append(args, "--keep-tag", tag)
+	}
 
 	// Add filters
 	if policy.Host != "" {
@@ -624,18 +1830,44 @@ func (c *Client) Forget(policy types.ForgetPolicy) error {
 		args = append(args, "--path", path)
 	}
 
-	_, err := c.execCommand(args...)
+	return args
+}
+
+// SetProtected adds or removes ProtectedTag on a snapshot. restic rewrites the
+// snapshot, so it gets a new ID.
+func (c *Client) SetProtected(snapshotID string, protected bool) error {
+	_, err := c.execUserCommand(c.Timeout, buildProtectArgs(snapshotID, protected)...)
+	return err
+}
+
+// buildProtectArgs constructs the restic tag arguments that protect or unprotect a snapshot
+func buildProtectArgs(snapshotID string, protected bool) []string {
+	flag := "--remove"
+	if protected {
+		flag = "--add"
+	}
+	return []string{"tag", flag, ProtectedTag, snapshotID}
+}
+
+// ForgetByID removes the given snapshots regardless of any retention policy
+func (c *Client) ForgetByID(ids []string) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("no snapshots specified")
+	}
+
+	args := append([]string{"forget"}, ids...)
+	_, err := c.execUserCommand(c.LongTimeout, args...)
 	return err
 }
 
 // PruneDryRun performs a dry-run of prune to preview what would be removed
 func (c *Client) PruneDryRun() (string, error) {
-	output, err := c.execCommand("prune", "--dry-run")
+	output, err := c.execUserCommand(c.LongTimeout, "prune", "--dry-run")
 	return string(output), err
 }
 
 // Prune removes unreferenced data from the repository
 func (c *Client) Prune() error {
-	_, err := c.execCommand("prune")
+	_, err := c.execUserCommand(c.LongTimeout, "prune")
 	return err
 }
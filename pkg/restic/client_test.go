@@ -1,10 +1,18 @@
 package restic
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/craigderington/lazyrestic/pkg/types"
 )
@@ -30,6 +38,71 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
+// installFakeRestic puts a shell script named restic first in PATH for the duration of the test
+func installFakeRestic(t *testing.T, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake restic script requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "restic")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatalf("Failed to write fake restic: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestNewClient_DefaultTimeout(t *testing.T) {
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+
+	if client.Timeout != DefaultTimeout {
+		t.Errorf("Timeout = %v, want %v", client.Timeout, DefaultTimeout)
+	}
+	if client.LongTimeout != 0 {
+		t.Errorf("LongTimeout = %v, want no limit", client.LongTimeout)
+	}
+}
+
+func TestClient_execCommand_Timeout(t *testing.T) {
+	installFakeRestic(t, "exec sleep 5")
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	client.Timeout = 100 * time.Millisecond
+
+	start := time.Now()
+	_, err := client.Unlock()
+	if err == nil {
+		t.Fatal("Expected timeout error from hung command")
+	}
+	if !IsTimeout(err) {
+		t.Errorf("IsTimeout(%v) = false, want true", err)
+	}
+	if !strings.Contains(err.Error(), "command timed out") {
+		t.Errorf("Error should mention timeout, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Command took %v, deadline was not enforced", elapsed)
+	}
+}
+
+func TestClient_execCommand_LongTimeoutOverride(t *testing.T) {
+	installFakeRestic(t, "sleep 0.3; echo done")
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	client.Timeout = 50 * time.Millisecond
+	client.LongTimeout = 5 * time.Second
+
+	// Prune uses LongTimeout, so the short metadata timeout must not apply
+	output, err := client.PruneDryRun()
+	if err != nil {
+		t.Fatalf("PruneDryRun() failed: %v", err)
+	}
+	if !strings.Contains(output, "done") {
+		t.Errorf("Unexpected output: %q", output)
+	}
+}
+
 func TestClient_buildEnv(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -62,6 +135,19 @@ func TestClient_buildEnv(t *testing.T) {
 				"RESTIC_PASSWORD_COMMAND=pass show restic",
 			},
 		},
+		{
+			name: "Password env",
+			client: &Client{
+				config: types.RepositoryConfig{
+					Path:        "/tmp/repo",
+					PasswordEnv: "LAZYRESTIC_TEST_PASSWORD",
+				},
+			},
+			contains: []string{
+				"RESTIC_REPOSITORY=/tmp/repo",
+				"RESTIC_PASSWORD=from-env",
+			},
+		},
 		{
 			name: "No password",
 			client: &Client{
@@ -75,24 +161,990 @@ func TestClient_buildEnv(t *testing.T) {
 		},
 	}
 
+	t.Setenv("LAZYRESTIC_TEST_PASSWORD", "from-env")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := tt.client.buildEnv()
+
+			for _, expected := range tt.contains {
+				found := false
+				for _, envVar := range env {
+					if envVar == expected {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("buildEnv() missing expected var: %v", expected)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_UnsetPasswordEnvStopsBeforeRestic(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "ran")
+	installFakeRestic(t, `touch `+marker+`
+echo '[]'`)
+	t.Setenv("LAZYRESTIC_UNSET_PASSWORD", "") // Restores the variable afterwards
+	os.Unsetenv("LAZYRESTIC_UNSET_PASSWORD")
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test", PasswordEnv: "LAZYRESTIC_UNSET_PASSWORD"})
+	want := "password_env LAZYRESTIC_UNSET_PASSWORD is not set"
+
+	if _, err := client.ListSnapshots(); err == nil || err.Error() != want {
+		t.Errorf("ListSnapshots() error = %v, want %q", err, want)
+	}
+
+	updates := make(chan BackupMessage, 10)
+	go client.BackupWithChannel(context.Background(), types.BackupOptions{Paths: []string{"/srv"}}, updates)
+	var backupErr error
+	for msg := range updates {
+		if msg.Error != nil {
+			backupErr = msg.Error
+		}
+	}
+	if backupErr == nil || !strings.Contains(backupErr.Error(), want) {
+		t.Errorf("BackupWithChannel() error = %v, want it to mention %q", backupErr, want)
+	}
+
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("restic should not run when password_env is not set")
+	}
+}
+
+func TestClient_buildArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   types.RepositoryConfig
+		args     []string
+		expected []string
+	}{
+		{
+			name:     "No TLS options",
+			config:   types.RepositoryConfig{Path: "/tmp/repo"},
+			args:     []string{"snapshots", "--json"},
+			expected: []string{"snapshots", "--json"},
+		},
+		{
+			name:     "Insecure TLS",
+			config:   types.RepositoryConfig{Path: "rest:https://host:8000/", InsecureTLS: true},
+			args:     []string{"snapshots", "--json"},
+			expected: []string{"--insecure-tls", "snapshots", "--json"},
+		},
+		{
+			name:     "Custom CA certificate",
+			config:   types.RepositoryConfig{Path: "rest:https://host:8000/", CACert: "/etc/ca.pem"},
+			args:     []string{"check"},
+			expected: []string{"--cacert", "/etc/ca.pem", "check"},
+		},
+		{
+			name:     "Compression for backup",
+			config:   types.RepositoryConfig{Path: "/tmp/repo", Compression: "max"},
+			args:     []string{"backup", "--json", "/home"},
+			expected: []string{"--compression", "max", "backup", "--json", "/home"},
+		},
+		{
+			name:     "Compression for prune",
+			config:   types.RepositoryConfig{Path: "/tmp/repo", Compression: "off"},
+			args:     []string{"prune"},
+			expected: []string{"--compression", "off", "prune"},
+		},
+		{
+			name:     "No compression for read-only commands",
+			config:   types.RepositoryConfig{Path: "/tmp/repo", Compression: "max"},
+			args:     []string{"snapshots", "--json"},
+			expected: []string{"snapshots", "--json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(tt.config)
+			got := client.buildArgs(tt.args...)
+
+			if len(got) != len(tt.expected) {
+				t.Fatalf("buildArgs() = %v, want %v", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("buildArgs()[%d] = %v, want %v", i, got[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestClient_BackupWithChannel_StreamsStderr(t *testing.T) {
+	installFakeRestic(t, `echo '{"message_type":"status","percent_done":0.5}'
+sleep 0.1
+echo 'error: open /root/secret: permission denied' >&2
+sleep 0.1
+echo '{"message_type":"status","percent_done":1}'
+sleep 0.1
+echo 'warning: file changed during backup' >&2
+sleep 0.1
+echo '{"message_type":"summary","snapshot_id":"abc123"}'
+exit 3`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	updates := make(chan BackupMessage, 10)
+	go client.BackupWithChannel(context.Background(), types.BackupOptions{Paths: []string{"/tmp"}}, updates)
+
+	var events []string
+	var finalErr error
+	var warning string
+	for msg := range updates {
+		switch {
+		case msg.Progress != nil:
+			events = append(events, "progress")
+		case msg.StderrLine != "":
+			events = append(events, "stderr:"+msg.StderrLine)
+		case msg.Summary != nil:
+			events = append(events, "summary")
+			warning = msg.Warning
+		case msg.Error != nil:
+			finalErr = msg.Error
+		}
+	}
+
+	want := []string{
+		"progress",
+		"stderr:error: open /root/secret: permission denied",
+		"progress",
+		"stderr:warning: file changed during backup",
+		"summary",
+	}
+	if strings.Join(events, "|") != strings.Join(want, "|") {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+
+	// Exit code 3 means the snapshot was created with some files skipped
+	if finalErr != nil {
+		t.Errorf("Exit code 3 should not be reported as an error, got: %v", finalErr)
+	}
+	if warning != "1 file could not be read" {
+		t.Errorf("Summary warning = %q, want the unreadable file count", warning)
+	}
+}
+
+func TestClient_BackupWithChannel_FailureSendsOnlyError(t *testing.T) {
+	installFakeRestic(t, `echo '{"message_type":"status","percent_done":0.5}'
+echo '{"message_type":"error","error":{"message":"open /srv: permission denied"},"during":"archival","item":"/srv"}' >&2
+echo 'Fatal: unable to save snapshot: disk full' >&2
+echo '{"message_type":"summary","snapshot_id":"abc123"}'
+exit 1`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	updates := make(chan BackupMessage, 10)
+	go client.BackupWithChannel(context.Background(), types.BackupOptions{Paths: []string{"/tmp"}}, updates)
+
+	var summaries int
+	var errs []error
+	for msg := range updates {
+		if msg.Summary != nil {
+			summaries++
+		}
+		if msg.Error != nil {
+			errs = append(errs, msg.Error)
+		}
+	}
+
+	if summaries != 0 {
+		t.Errorf("A failed backup should not deliver a summary, got %d", summaries)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "disk full") {
+		t.Errorf("Expected one error with the stderr tail, got %v", errs)
+	}
+}
+
+func TestClient_BackupStdin(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	stdinFile := filepath.Join(dir, "stdin")
+	installFakeRestic(t, `echo "$*" > `+argsFile+`
+cat > `+stdinFile+`
+echo '{"message_type":"status","percent_done":1}'
+echo '{"message_type":"summary","files_new":1,"total_bytes_processed":11,"snapshot_id":"abc123"}'`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	summary, err := client.BackupStdin(bytes.NewReader([]byte("hello world")), "dump.sql", types.BackupOptions{
+		Paths: []string{"/ignored"},
+		Tags:  []string{"db"},
+	})
+	if err != nil {
+		t.Fatalf("BackupStdin() failed: %v", err)
+	}
+	if summary.SnapshotID != "abc123" || summary.TotalBytesProcessed != 11 {
+		t.Errorf("summary = %+v, want snapshot abc123 with 11 bytes", summary)
+	}
+
+	args, _ := os.ReadFile(argsFile)
+	if got, want := strings.TrimSpace(string(args)), "backup --json --tag db --stdin --stdin-filename dump.sql"; got != want {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+	if data, _ := os.ReadFile(stdinFile); string(data) != "hello world" {
+		t.Errorf("restic read %q from stdin, want the piped data", data)
+	}
+	if last, ok := LastCommand(); ok && strings.Contains(last.String(), "--stdin") {
+		t.Errorf("A stdin backup should not be recorded for re-running, got %q", last)
+	}
+}
+
+func TestClient_EstimateExcluded_DiffsDryRunWithoutRules(t *testing.T) {
+	argsFile := filepath.Join(t.TempDir(), "args")
+	installFakeRestic(t, `echo "$*" > `+argsFile+`
+echo '{"message_type":"verbose_status","action":"new","item":"/home/me/.cache/pip/x"}'
+echo '{"message_type":"summary","dry_run":true,"total_files_processed":1504,"total_bytes_processed":9000}'`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	opts := types.BackupOptions{
+		Paths:         []string{"/home/me"},
+		Tags:          []string{"laptop"},
+		Exclude:       []string{"*.tmp"},
+		ExcludeCaches: true,
+		MaxFileSize:   "1G",
+	}
+	backup := &types.BackupSummary{TotalFilesProcessed: 300, TotalBytesProcessed: 1000}
+	summary, err := client.EstimateExcluded(opts, backup)
+	if err != nil {
+		t.Fatalf("EstimateExcluded() failed: %v", err)
+	}
+	if summary.ExcludedFiles != 1204 || summary.ExcludedBytes != 8000 {
+		t.Errorf("summary = %+v, want 1204 files and 8000 bytes", summary)
+	}
+
+	args, _ := os.ReadFile(argsFile)
+	if got, want := strings.TrimSpace(string(args)), "backup --json --tag laptop --dry-run /home/me"; got != want {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+	if last, ok := LastCommand(); ok && strings.Contains(last.String(), "--dry-run /home/me") {
+		t.Errorf("The exclusion dry run should not be recorded for re-running, got %q", last)
+	}
+}
+
+func TestHasExclusions(t *testing.T) {
+	tests := []struct {
+		opts types.BackupOptions
+		want bool
+	}{
+		{types.BackupOptions{Paths: []string{"/srv"}, Tags: []string{"daily"}}, false},
+		{types.BackupOptions{Exclude: []string{"*.tmp"}}, true},
+		{types.BackupOptions{ExcludeFile: "/etc/restic/excludes"}, true},
+		{types.BackupOptions{ExcludeCaches: true}, true},
+		{types.BackupOptions{MaxFileSize: "100M"}, true},
+	}
+	for _, tt := range tests {
+		if got := HasExclusions(tt.opts); got != tt.want {
+			t.Errorf("HasExclusions(%+v) = %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestClient_BackupStdin_Failure(t *testing.T) {
+	installFakeRestic(t, `cat > /dev/null
+echo 'Fatal: wrong password or no key found' >&2
+exit 1`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	summary, err := client.BackupStdin(strings.NewReader("data"), "", types.BackupOptions{})
+	if err == nil || summary != nil {
+		t.Fatalf("BackupStdin() = %v, %v, want an error and no summary", summary, err)
+	}
+	if !strings.Contains(err.Error(), "wrong password") {
+		t.Errorf("Error should include restic's stderr, got: %v", err)
+	}
+}
+
+func TestIsFileErrorLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"error: open /root/secret: permission denied", true},
+		{`{"message_type":"error","error":{"message":"permission denied"},"item":"/root"}`, true},
+		{"warning: file changed during backup", false},
+		{"Warning: at least one source file could not be read", false},
+		{`{"message_type":"status","percent_done":1}`, false},
+	}
+
+	for _, tt := range tests {
+		if got := isFileErrorLine(tt.line); got != tt.want {
+			t.Errorf("isFileErrorLine(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+// collectRestoreMessages drains a restore channel into counts of each message kind
+func collectRestoreMessages(updates <-chan RestoreMessage) (progress, summaries int, errs []error) {
+	for msg := range updates {
+		switch {
+		case msg.Error != nil:
+			errs = append(errs, msg.Error)
+		case msg.Summary != nil:
+			summaries++
+		case msg.Progress != nil:
+			progress++
+		}
+	}
+	return progress, summaries, errs
+}
+
+func TestClient_RestoreWithChannel_FailureSendsOnlyError(t *testing.T) {
+	installFakeRestic(t, `echo 'restoring <Snapshot abc123 of [/home]> to /tmp/restore'
+echo 'Fatal: cannot create /tmp/restore/home: permission denied' >&2
+exit 1`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	updates := make(chan RestoreMessage, 10)
+	go client.RestoreWithChannel(context.Background(), types.RestoreOptions{SnapshotID: "abc123", Target: "/tmp/restore"}, updates)
+
+	progress, summaries, errs := collectRestoreMessages(updates)
+	if progress != 1 {
+		t.Errorf("progress messages = %d, want 1", progress)
+	}
+	if summaries != 0 {
+		t.Errorf("summaries = %d, want none after a failed restore", summaries)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("errors = %v, want exactly one", errs)
+	}
+	if !strings.Contains(errs[0].Error(), "permission denied") {
+		t.Errorf("Error should include stderr, got: %v", errs[0])
+	}
+}
+
+func TestClient_RestoreWithChannel_SuccessSendsOneSummary(t *testing.T) {
+	// A clean exit confirms success even when restic printed nothing
+	installFakeRestic(t, "exit 0")
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	updates := make(chan RestoreMessage, 10)
+	go client.RestoreWithChannel(context.Background(), types.RestoreOptions{SnapshotID: "abc123", Target: "/tmp/restore"}, updates)
+
+	_, summaries, errs := collectRestoreMessages(updates)
+	if summaries != 1 || len(errs) != 0 {
+		t.Errorf("summaries = %d, errors = %v, want one summary and no errors", summaries, errs)
+	}
+}
+
+func TestBuildRestoreDryRunArgs(t *testing.T) {
+	opts := types.RestoreOptions{
+		SnapshotID: "abc123",
+		Target:     "/tmp/restore",
+		Include:    []string{"/home/craig/docs", "/etc/hosts"},
+	}
+
+	args := buildRestoreDryRunArgs(opts)
+	want := []string{"restore", "abc123", "--target", "/tmp/restore",
+		"--include", "/home/craig/docs", "--include", "/etc/hosts",
+		"--dry-run", "--json", "--verbose=2"}
+	if strings.Join(args, " ") != strings.Join(want, " ") {
+		t.Errorf("buildRestoreDryRunArgs() = %v, want %v", args, want)
+	}
+}
+
+func TestBuildRestoreArgs_Overwrite(t *testing.T) {
+	tests := []struct {
+		name string
+		opts types.RestoreOptions
+		want string
+	}{
+		{"restic default", types.RestoreOptions{SnapshotID: "abc123", Target: "/tmp/restore"},
+			"restore abc123 --target /tmp/restore"},
+		{"if-changed", types.RestoreOptions{SnapshotID: "abc123", Target: "/tmp/restore", Overwrite: types.OverwriteIfChanged},
+			"restore abc123 --target /tmp/restore --overwrite if-changed"},
+		{"never to original location", types.RestoreOptions{SnapshotID: "abc123", Include: []string{"/etc"}, Overwrite: types.OverwriteNever},
+			"restore abc123 --include /etc --overwrite never"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(buildRestoreArgs(tt.opts), " "); got != tt.want {
+				t.Errorf("buildRestoreArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildRestoreArgs_IncludeFile(t *testing.T) {
+	got := strings.Join(buildRestoreArgs(types.RestoreOptions{
+		SnapshotID:  "abc123",
+		Target:      "/tmp/restore",
+		IncludeFile: "/tmp/include.txt",
+		Overwrite:   types.OverwriteNever,
+	}), " ")
+	if want := "restore abc123 --target /tmp/restore --include-file /tmp/include.txt --overwrite never"; got != want {
+		t.Errorf("buildRestoreArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestRestoreOptionsForVersion_IncludeFile(t *testing.T) {
+	includeFile := filepath.Join(t.TempDir(), "include.txt")
+	if err := os.WriteFile(includeFile, []byte("# selected files\n/etc/hosts\n\n  /home/user/notes.txt\n"), 0600); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	opts := types.RestoreOptions{SnapshotID: "abc123", Include: []string{"/srv"}, IncludeFile: includeFile}
+
+	// restic 0.17+ reads the file itself
+	got := restoreOptionsForVersion(opts, "restic 0.17.0 compiled with go1.22.5 on linux/amd64")
+	if got.IncludeFile != includeFile || len(got.Include) != 1 {
+		t.Errorf("restic 0.17 should keep the include file, got %+v", got)
+	}
+
+	// Older restic gets the paths as --include flags
+	got = restoreOptionsForVersion(opts, "restic 0.16.4 compiled with go1.21.6 on linux/amd64")
+	if got.IncludeFile != "" {
+		t.Errorf("IncludeFile = %q, want it expanded for restic 0.16", got.IncludeFile)
+	}
+	if want := "/srv /etc/hosts /home/user/notes.txt"; strings.Join(got.Include, " ") != want {
+		t.Errorf("Include = %v, want %q", got.Include, want)
+	}
+	if len(opts.Include) != 1 {
+		t.Errorf("The caller's Include slice should not change, got %v", opts.Include)
+	}
+}
+
+func TestRestoreOptionsForVersion(t *testing.T) {
+	opts := types.RestoreOptions{SnapshotID: "abc123", Overwrite: types.OverwriteIfNewer}
+
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"restic 0.17.0 compiled with go1.22.5 on linux/amd64", types.OverwriteIfNewer},
+		{"restic 0.18.1 compiled with go1.24.2 on linux/amd64", types.OverwriteIfNewer},
+		{"restic 0.16.4 compiled with go1.21.6 on linux/amd64", ""},
+		{"", types.OverwriteIfNewer}, // Unknown version keeps the option
+	}
+
+	for _, tt := range tests {
+		if got := restoreOptionsForVersion(opts, tt.version).Overwrite; got != tt.want {
+			t.Errorf("restoreOptionsForVersion(%q).Overwrite = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestClient_RestoreDryRun_ListsWrittenPaths(t *testing.T) {
+	installFakeRestic(t, `case "$*" in
+*--dry-run*) ;;
+*) echo "unexpected args: $*" >&2; exit 1 ;;
+esac
+echo '{"message_type":"verbose_status","action":"restored","item":"/tmp/restore/home/a.txt","size":10}'
+echo '{"message_type":"verbose_status","action":"unchanged","item":"/tmp/restore/home/b.txt","size":10}'
+echo '{"message_type":"verbose_status","action":"updated","item":"/tmp/restore/home/c.txt","size":10}'
+echo '{"message_type":"summary","total_files":3}'`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	paths, err := client.RestoreDryRun(types.RestoreOptions{SnapshotID: "abc123", Target: "/tmp/restore"})
+	if err != nil {
+		t.Fatalf("RestoreDryRun() failed: %v", err)
+	}
+
+	want := []string{"/tmp/restore/home/a.txt", "/tmp/restore/home/c.txt"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("RestoreDryRun() = %v, want %v", paths, want)
+	}
+}
+
+func TestClient_RestoreDryRun_FallsBackToListing(t *testing.T) {
+	// restic before 0.17 has no restore --dry-run
+	installFakeRestic(t, `case "$1" in
+restore) echo 'unknown flag: --dry-run' >&2; exit 1 ;;
+ls)
+  echo '{"message_type":"snapshot","id":"abc123"}'
+  echo '{"message_type":"node","name":"docs","type":"dir","path":"/home/docs"}'
+  echo '{"message_type":"node","name":"a.txt","type":"file","path":"/home/docs/a.txt"}'
+  echo '{"message_type":"node","name":"hosts","type":"file","path":"/etc/hosts"}'
+  ;;
+esac`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	paths, err := client.RestoreDryRun(types.RestoreOptions{
+		SnapshotID: "abc123",
+		Target:     "/tmp/restore",
+		Include:    []string{"/home/docs"},
+	})
+	if err != nil {
+		t.Fatalf("RestoreDryRun() failed: %v", err)
+	}
+
+	want := []string{"/tmp/restore/home/docs", "/tmp/restore/home/docs/a.txt"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("RestoreDryRun() = %v, want %v (only included paths, under the target)", paths, want)
+	}
+}
+
+func TestValidateReadDataSubset(t *testing.T) {
+	tests := []struct {
+		subset  string
+		wantErr bool
+	}{
+		{"5%", false},
+		{"2.5%", false},
+		{"100%", false},
+		{"1/10", false},
+		{"10/10", false},
+		{"0%", true},
+		{"101%", true},
+		{"0/10", true},
+		{"11/10", true},
+		{"5", true},
+		{"abc", true},
+		{"", true},
+		{"5%; rm -rf /", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subset, func(t *testing.T) {
+			err := ValidateReadDataSubset(tt.subset)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateReadDataSubset(%q) error = %v, wantErr %v", tt.subset, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_CheckRepositoryData(t *testing.T) {
+	installFakeRestic(t, `echo "args: $*"
+echo 'read 1/10 of packs' >&2
+echo 'no errors were found'`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+
+	output, err := client.CheckRepositoryData("1/10")
+	if err != nil {
+		t.Fatalf("CheckRepositoryData() failed: %v", err)
+	}
+
+	want := "args: check --read-data-subset=1/10\nread 1/10 of packs\nno errors were found"
+	if output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+
+	if _, err := client.CheckRepositoryData("garbage"); err == nil {
+		t.Error("Expected invalid subset to be rejected before running restic")
+	}
+}
+
+func TestClient_DumpFile(t *testing.T) {
+	installFakeRestic(t, `if [ "$1" = "dump" ] && [ "$2" = "abc123" ] && [ "$3" = "/etc/hosts" ]; then
+  printf '127.0.0.1 localhost\n'
+  exit 0
+fi
+if [ "$3" = "/big" ]; then
+  exec yes lazyrestic
+fi
+echo "Fatal: cannot dump file: path $3 not found in snapshot" >&2
+exit 1`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+
+	data, err := client.DumpFile("abc123", "/etc/hosts")
+	if err != nil {
+		t.Fatalf("DumpFile() failed: %v", err)
+	}
+	if string(data) != "127.0.0.1 localhost\n" {
+		t.Errorf("DumpFile() = %q", string(data))
+	}
+
+	// Endless output must be capped and restic stopped
+	client.MaxDumpBytes = 100
+	data, err = client.DumpFile("abc123", "/big")
+	if err != nil {
+		t.Fatalf("DumpFile() of large file failed: %v", err)
+	}
+	if len(data) != 100 {
+		t.Errorf("DumpFile() returned %d bytes, want 100", len(data))
+	}
+
+	if _, err := client.DumpFile("abc123", "/missing"); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestClient_GetSnapshotStats(t *testing.T) {
+	installFakeRestic(t, `if [ "$*" = "stats abc123 --json --mode restore-size" ]; then
+  echo '{"total_size":4096,"total_file_count":12}'
+  exit 0
+fi
+echo "unexpected args: $*" >&2
+exit 1`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+
+	stats, err := client.GetSnapshotStats("abc123")
+	if err != nil {
+		t.Fatalf("GetSnapshotStats() failed: %v", err)
+	}
+	if stats.TotalSize != 4096 || stats.TotalFileCount != 12 {
+		t.Errorf("GetSnapshotStats() = %+v", stats)
+	}
+}
+
+func TestClient_GetStatsMode_RawData(t *testing.T) {
+	// Shape of restic 0.16 raw-data output for a v2 (compressed) repository
+	installFakeRestic(t, `if [ "$*" = "stats --json --mode raw-data" ]; then
+  echo '{"total_size":1048576,"total_uncompressed_size":2097152,"compression_ratio":2,"compression_progress":100,"compression_space_saving":50,"total_blob_count":321,"snapshots_count":4}'
+  exit 0
+fi
+echo "unexpected args: $*" >&2
+exit 1`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+
+	stats, err := client.GetStatsMode(StatsModeRawData)
+	if err != nil {
+		t.Fatalf("GetStatsMode() failed: %v", err)
+	}
+	if stats.TotalSize != 1048576 || stats.TotalBlobCount != 321 || stats.SnapshotsCount != 4 {
+		t.Errorf("GetStatsMode() = %+v", stats)
+	}
+	if stats.TotalFileCount != 0 {
+		t.Errorf("raw-data output has no file count, got %d", stats.TotalFileCount)
+	}
+}
+
+func TestClient_GetStats_IgnoresStderrNoise(t *testing.T) {
+	// restic writes progress and warnings to stderr, sometimes between JSON lines
+	installFakeRestic(t, `echo "using parent snapshot abc123" >&2
+echo '{"total_size":2048,"total_file_count":7,"snapshots_count":2}'
+echo "Warning: the repository cache is outdated" >&2`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+
+	stats, err := client.GetStats()
+	if err != nil {
+		t.Fatalf("Noise on stderr should not break the JSON: %v", err)
+	}
+	if stats.TotalSize != 2048 || stats.TotalFileCount != 7 || stats.SnapshotsCount != 2 {
+		t.Errorf("GetStats() = %+v", stats)
+	}
+}
+
+func TestClient_GetRepositoryInfo_CachesStoredSize(t *testing.T) {
+	calls := filepath.Join(t.TempDir(), "raw-data-calls")
+	snapshots := filepath.Join(t.TempDir(), "snapshots.json")
+	if err := os.WriteFile(snapshots, []byte(`[{"id":"aaaa","time":"2024-01-01T00:00:00Z"}]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	installFakeRestic(t, `case "$*" in
+  "stats --json --mode restore-size") echo '{"total_size":4000,"total_file_count":10,"snapshots_count":1}' ;;
+  "stats --json --mode raw-data") echo x >> `+calls+`; echo '{"total_size":1000,"total_blob_count":5}' ;;
+  "snapshots --json") cat `+snapshots+` ;;
+  "check") ;;
+  *) echo "unexpected args: $*" >&2; exit 1 ;;
+esac`)
+
+	config := types.RepositoryConfig{Name: "test", Path: filepath.Join(t.TempDir(), "repo")}
+	countCalls := func() int {
+		data, _ := os.ReadFile(calls)
+		return strings.Count(string(data), "x")
+	}
+
+	for i := 0; i < 2; i++ {
+		repo, err := NewClient(config).GetRepositoryInfo()
+		if err != nil {
+			t.Fatalf("GetRepositoryInfo() failed: %v", err)
+		}
+		if repo.Size != 4000 || repo.StoredSize != 1000 {
+			t.Errorf("Size = %d, StoredSize = %d, want 4000 and 1000", repo.Size, repo.StoredSize)
+		}
+	}
+	if n := countCalls(); n != 1 {
+		t.Errorf("raw-data stats ran %d times for an unchanged snapshot set, want 1", n)
+	}
+
+	// A new snapshot invalidates the cached size
+	if err := os.WriteFile(snapshots, []byte(`[{"id":"aaaa","time":"2024-01-01T00:00:00Z"},{"id":"bbbb","time":"2024-01-02T00:00:00Z"}]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewClient(config).GetRepositoryInfo(); err != nil {
+		t.Fatalf("GetRepositoryInfo() failed: %v", err)
+	}
+	if n := countCalls(); n != 2 {
+		t.Errorf("raw-data stats ran %d times after a new snapshot, want 2", n)
+	}
+}
+
+func TestClient_GetDuplicateStats(t *testing.T) {
+	calls := filepath.Join(t.TempDir(), "files-by-contents-calls")
+	// Shape of restic 0.16 stats --json output in each mode
+	installFakeRestic(t, `case "$*" in
+  "stats --json --mode restore-size bbbb") echo '{"total_size":10485760,"total_file_count":1200,"snapshots_count":1}' ;;
+  "stats --json --mode files-by-contents bbbb") echo x >> `+calls+`; echo '{"total_size":4194304,"total_file_count":350,"snapshots_count":1}' ;;
+  "snapshots --json") echo '[{"id":"bbbb","time":"2024-02-01T00:00:00Z"},{"id":"aaaa","time":"2024-01-01T00:00:00Z"}]' ;;
+  *) echo "unexpected args: $*" >&2; exit 1 ;;
+esac`)
+
+	config := types.RepositoryConfig{Name: "test", Path: filepath.Join(t.TempDir(), "repo")}
+	for i := 0; i < 2; i++ {
+		stats, err := NewClient(config).GetDuplicateStats()
+		if err != nil {
+			t.Fatalf("GetDuplicateStats() failed: %v", err)
+		}
+		want := types.DuplicateStats{SnapshotID: "bbbb", TotalSize: 10485760, TotalFiles: 1200, UniqueSize: 4194304, UniqueFiles: 350}
+		if *stats != want {
+			t.Errorf("GetDuplicateStats() = %+v, want %+v", *stats, want)
+		}
+	}
+
+	data, _ := os.ReadFile(calls)
+	if n := strings.Count(string(data), "x"); n != 1 {
+		t.Errorf("files-by-contents stats ran %d times for an unchanged latest snapshot, want 1", n)
+	}
+}
+
+func TestClient_Find(t *testing.T) {
+	installFakeRestic(t, `if [ "$*" = "find --json notes.txt" ]; then
+  echo '[{"matches":[{"path":"/home/user/notes.txt","type":"file","size":42,"mtime":"2024-01-02T03:04:05Z"}],"hits":1,"snapshot":"aaaa1111"},{"matches":[{"path":"/home/user/old/notes.txt","type":"file","size":7,"mtime":"2023-06-01T00:00:00Z"}],"hits":1,"snapshot":"bbbb2222"}]'
+  exit 0
+fi
+if [ "$*" = "find --json missing" ]; then
+  echo '[]'
+  exit 0
+fi
+echo "unexpected args: $*" >&2
+exit 1`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+
+	results, err := client.Find("notes.txt")
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Find() returned %d results, want 2", len(results))
+	}
+	first := results[0]
+	if first.SnapshotID != "aaaa1111" || first.Path != "/home/user/notes.txt" || first.Size != 42 {
+		t.Errorf("Find() first result = %+v", first)
+	}
+	if first.ModTime.Year() != 2024 {
+		t.Errorf("Find() mtime = %v, want 2024", first.ModTime)
+	}
+	if results[1].SnapshotID != "bbbb2222" {
+		t.Errorf("Find() second result snapshot = %s, want bbbb2222", results[1].SnapshotID)
+	}
+
+	results, err = client.Find("missing")
+	if err != nil {
+		t.Fatalf("Find() with no matches failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Find() with no matches returned %d results", len(results))
+	}
+}
+
+func TestClient_ForgetByID(t *testing.T) {
+	installFakeRestic(t, `if [ "$*" = "forget aaaa1111 bbbb2222" ]; then
+  echo "removed snapshot aaaa1111"
+  echo "removed snapshot bbbb2222"
+  exit 0
+fi
+echo "unexpected args: $*" >&2
+exit 1`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+
+	if err := client.ForgetByID([]string{"aaaa1111", "bbbb2222"}); err != nil {
+		t.Errorf("ForgetByID() failed: %v", err)
+	}
+	if err := client.ForgetByID(nil); err == nil {
+		t.Error("ForgetByID() with no IDs should fail without running restic")
+	}
+}
+
+func TestParseFindOutput_SkipsLeadingWarnings(t *testing.T) {
+	output := []byte("Load(<lock/1234>) returned error [retrying]\n[{\"matches\":[{\"path\":\"/a\",\"type\":\"dir\"}],\"hits\":1,\"snapshot\":\"cccc\"}]\n")
+
+	results, err := parseFindOutput(output)
+	if err != nil {
+		t.Fatalf("parseFindOutput() failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Path != "/a" || results[0].Type != "dir" {
+		t.Errorf("parseFindOutput() = %+v", results)
+	}
+
+	if _, err := parseFindOutput([]byte("Fatal: something went wrong")); err == nil {
+		t.Error("Expected error for non-JSON output")
+	}
+}
+
+func TestParseConfigBlob(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   types.RepoConfigBlob
+	}{
+		{
+			name: "Current format",
+			output: `{
+  "version": 2,
+  "id": "3a1c2e9f6b4d8a0e7c5f1b3d9e2a4c6f8b0d1e3a5c7f9b2d4e6a8c0f1b3d5e7",
+  "chunker_polynomial": "3a6f3e6b5e1ae9"
+}`,
+			want: types.RepoConfigBlob{
+				Version:           2,
+				ID:                "3a1c2e9f6b4d8a0e7c5f1b3d9e2a4c6f8b0d1e3a5c7f9b2d4e6a8c0f1b3d5e7",
+				ChunkerPolynomial: "3a6f3e6b5e1ae9",
+			},
+		},
+		{
+			name:   "Older format without chunker polynomial",
+			output: `{"version":1,"id":"abcdef"}`,
+			want:   types.RepoConfigBlob{Version: 1, ID: "abcdef"},
+		},
+		{
+			name:   "Unknown fields ignored",
+			output: `{"version":2,"id":"abcdef","chunker_polynomial":"1","future":true}`,
+			want:   types.RepoConfigBlob{Version: 2, ID: "abcdef", ChunkerPolynomial: "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blob, err := parseConfigBlob([]byte(tt.output))
+			if err != nil {
+				t.Fatalf("parseConfigBlob() failed: %v", err)
+			}
+			if *blob != tt.want {
+				t.Errorf("parseConfigBlob() = %+v, want %+v", *blob, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseConfigBlob([]byte("Fatal: wrong password or no key found")); err == nil {
+		t.Error("Expected error for non-JSON output")
+	}
+}
+
+func TestClient_GetConfigBlob(t *testing.T) {
+	installFakeRestic(t, `if [ "$*" = "cat config" ]; then
+  echo '{"version":2,"id":"abcdef","chunker_polynomial":"3a6f3e6b5e1ae9"}'
+  exit 0
+fi
+echo "unexpected args: $*" >&2
+exit 1`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+
+	blob, err := client.GetConfigBlob()
+	if err != nil {
+		t.Fatalf("GetConfigBlob() failed: %v", err)
+	}
+	if blob.Version != 2 || blob.ID != "abcdef" {
+		t.Errorf("GetConfigBlob() = %+v", blob)
+	}
+}
+
+func TestBuildBackupArgs(t *testing.T) {
+	opts := types.BackupOptions{
+		Paths:    []string{"/home/user", "/etc"},
+		Tags:     []string{"daily"},
+		Exclude:  []string{"*.tmp"},
+		PackSize: "64",
+	}
+
+	expected := []string{
+		"backup", "--json",
+		"--tag", "daily",
+		"--exclude", "*.tmp",
+		"--pack-size", "64",
+		"/home/user", "/etc",
+	}
+
+	got := buildBackupArgs(opts)
+	if strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Errorf("buildBackupArgs() = %v, want %v", got, expected)
+	}
+
+	// Exclude file is passed through alongside inline excludes
+	opts.ExcludeFile = "/home/user/.excludes"
+	got = buildBackupArgs(opts)
+	if !strings.Contains(strings.Join(got, " "), "--exclude *.tmp --exclude-file /home/user/.excludes") {
+		t.Errorf("buildBackupArgs() = %v, want --exclude-file after inline excludes", got)
+	}
+	opts.ExcludeFile = ""
+
+	// Dry run adds --dry-run before the paths
+	opts.DryRun = true
+	got = buildBackupArgs(opts)
+	if !strings.Contains(strings.Join(got, " "), "--dry-run /home/user /etc") {
+		t.Errorf("buildBackupArgs() = %v, want --dry-run before paths", got)
+	}
+	opts.DryRun = false
+	for _, arg := range buildBackupArgs(opts) {
+		if arg == "--dry-run" {
+			t.Error("buildBackupArgs() should not include --dry-run unless requested")
+		}
+	}
+
+	// Large files are skipped with --exclude-larger-than
+	opts.MaxFileSize = "100M"
+	got = buildBackupArgs(opts)
+	if !strings.Contains(strings.Join(got, " "), "--exclude *.tmp --exclude-larger-than 100M") {
+		t.Errorf("buildBackupArgs() = %v, want --exclude-larger-than 100M", got)
+	}
+	opts.DryRun = true
+	if got := strings.Join(buildBackupArgs(opts), " "); !strings.Contains(got, "--exclude-larger-than 100M") || !strings.Contains(got, "--dry-run") {
+		t.Errorf("buildBackupArgs() = %v, want the size limit in dry runs too", got)
+	}
+	opts.DryRun = false
+	opts.MaxFileSize = ""
+	for _, arg := range buildBackupArgs(opts) {
+		if arg == "--exclude-larger-than" {
+			t.Error("buildBackupArgs() should not include --exclude-larger-than when unset")
+		}
+	}
+
+	// Pack size is omitted when unset
+	opts.PackSize = ""
+	for _, arg := range buildBackupArgs(opts) {
+		if arg == "--pack-size" {
+			t.Error("buildBackupArgs() should not include --pack-size when unset")
+		}
+	}
+}
+
+func TestBuildBackupArgs_FilesystemFlags(t *testing.T) {
+	tests := []struct {
+		name          string
+		excludeCaches bool
+		oneFileSystem bool
+		want          string
+	}{
+		{"Neither", false, false, "backup --json /data"},
+		{"Exclude caches", true, false, "backup --json --exclude-caches /data"},
+		{"One file system", false, true, "backup --json --one-file-system /data"},
+		{"Both", true, true, "backup --json --exclude-caches --one-file-system /data"},
+	}
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			env := tt.client.buildEnv()
-
-			for _, expected := range tt.contains {
-				found := false
-				for _, envVar := range env {
-					if envVar == expected {
-						found = true
-						break
-					}
-				}
-				if !found {
-					t.Errorf("buildEnv() missing expected var: %v", expected)
-				}
+			got := buildBackupArgs(types.BackupOptions{
+				Paths:         []string{"/data"},
+				ExcludeCaches: tt.excludeCaches,
+				OneFileSystem: tt.oneFileSystem,
+			})
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("buildBackupArgs() = %q, want %q", strings.Join(got, " "), tt.want)
 			}
 		})
 	}
+
+	// Flags sit with the other options, before the paths
+	got := strings.Join(buildBackupArgs(types.BackupOptions{
+		Paths:         []string{"/data"},
+		Exclude:       []string{"*.tmp"},
+		ExcludeCaches: true,
+		OneFileSystem: true,
+		DryRun:        true,
+	}), " ")
+	if want := "backup --json --exclude *.tmp --exclude-caches --one-file-system --dry-run /data"; got != want {
+		t.Errorf("buildBackupArgs() = %q, want %q", got, want)
+	}
 }
 
 func TestIsResticInstalled(t *testing.T) {
@@ -339,3 +1391,547 @@ func BenchmarkNewClient(b *testing.B) {
 		_ = NewClient(config)
 	}
 }
+
+func TestRepairIndexArgs(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"restic 0.17.3 compiled with go1.23.3 on linux/amd64", "repair index"},
+		{"restic 0.16.0 compiled with go1.21.0 on linux/amd64", "repair index"},
+		{"restic 0.15.2 compiled with go1.20.3 on linux/amd64", "rebuild-index"},
+		{"", "repair index"},
+	}
+
+	for _, tt := range tests {
+		if got := strings.Join(repairIndexArgs(tt.version), " "); got != tt.want {
+			t.Errorf("repairIndexArgs(%q) = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestRepairIndex_UsesRebuildIndexOnOldRestic(t *testing.T) {
+	installFakeRestic(t, `case "$1" in
+version) echo "restic 0.15.2 compiled with go1.20.3 on linux/amd64" ;;
+*) echo "ran $*"; echo "saved new indexes" ;;
+esac`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	// A short LongTimeout must not cut the repair short
+	client.LongTimeout = time.Nanosecond
+
+	output, err := client.RepairIndex()
+	if err != nil {
+		t.Fatalf("RepairIndex() failed: %v", err)
+	}
+	if !strings.Contains(output, "ran rebuild-index") || !strings.Contains(output, "saved new indexes") {
+		t.Errorf("Unexpected output: %q", output)
+	}
+}
+
+func TestRepairIndex_Failure(t *testing.T) {
+	installFakeRestic(t, `case "$1" in
+version) echo "restic 0.17.0" ;;
+*) echo "Fatal: unable to open repository"; exit 1 ;;
+esac`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+
+	output, err := client.RepairIndex()
+	if err == nil {
+		t.Fatal("Expected error from failed repair")
+	}
+	if !strings.Contains(output, "unable to open repository") {
+		t.Errorf("Output should include restic's message, got %q", output)
+	}
+}
+
+func TestBuildSnapshotsArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		host  string
+		tags  []string
+		paths []string
+		want  string
+	}{
+		{"No filters", "", nil, nil, "snapshots --json"},
+		{"Host", "web01", nil, nil, "snapshots --json --host web01"},
+		{"Tags and paths", "", []string{"daily", "db,prod"}, []string{"/home"}, "snapshots --json --tag daily --tag db,prod --path /home"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(buildSnapshotsArgs(tt.host, tt.tags, tt.paths), " "); got != tt.want {
+				t.Errorf("buildSnapshotsArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListSnapshotsFiltered_PassesFlags(t *testing.T) {
+	installFakeRestic(t, `if [ "$*" = "snapshots --json --host web01 --tag daily" ]; then
+  echo '[{"id":"abc123","short_id":"abc123","hostname":"web01","tags":["daily"]}]'
+  exit 0
+fi
+echo "unexpected args: $*" >&2
+exit 1`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	snapshots, err := client.ListSnapshotsFiltered("web01", []string{"daily"}, nil)
+	if err != nil {
+		t.Fatalf("ListSnapshotsFiltered() failed: %v", err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Hostname != "web01" {
+		t.Errorf("Unexpected snapshots: %+v", snapshots)
+	}
+}
+
+func TestListSnapshots_EmptyRepository(t *testing.T) {
+	for _, output := range []string{"null", "[]", ""} {
+		installFakeRestic(t, fmt.Sprintf("printf '%%s\\n' '%s'", output))
+
+		client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+		snapshots, err := client.ListSnapshots()
+		if err != nil {
+			t.Errorf("ListSnapshots() with output %q failed: %v", output, err)
+			continue
+		}
+		if snapshots == nil || len(snapshots) != 0 {
+			t.Errorf("ListSnapshots() with output %q = %#v, want an empty list", output, snapshots)
+		}
+	}
+}
+
+func TestListSnapshots_IgnoresStderrWarnings(t *testing.T) {
+	installFakeRestic(t, `echo "Warning: the repository cache is outdated" >&2
+echo '[{"id":"abc123","short_id":"abc123","hostname":"web01"}]'`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	snapshots, err := client.ListSnapshots()
+	if err != nil {
+		t.Fatalf("A warning on stderr should not break the JSON: %v", err)
+	}
+	if len(snapshots) != 1 || snapshots[0].ID != "abc123" {
+		t.Errorf("Unexpected snapshots: %+v", snapshots)
+	}
+}
+
+func TestListSnapshots_FailureReportsStderr(t *testing.T) {
+	installFakeRestic(t, `echo "Fatal: wrong password or no key found" >&2; exit 1`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	if _, err := client.ListSnapshots(); err == nil || !strings.Contains(err.Error(), "wrong password") {
+		t.Errorf("Expected restic's error message, got %v", err)
+	}
+}
+
+func TestBuildUnlockArgs(t *testing.T) {
+	if got := strings.Join(buildUnlockArgs(false), " "); got != "unlock" {
+		t.Errorf("buildUnlockArgs(false) = %q, want %q", got, "unlock")
+	}
+	if got := strings.Join(buildUnlockArgs(true), " "); got != "unlock --remove-all" {
+		t.Errorf("buildUnlockArgs(true) = %q, want %q", got, "unlock --remove-all")
+	}
+}
+
+func TestBuildInitArgs(t *testing.T) {
+	opts := types.InitOptions{PackSize: "32", RepositoryVersion: 2, Compression: "max"}
+
+	if got := strings.Join(buildInitArgs(opts), " "); got != "init --pack-size 32 --repository-version 2" {
+		t.Errorf("buildInitArgs() = %q", got)
+	}
+	if got := initEnv(opts); len(got) != 1 || got[0] != "RESTIC_COMPRESSION=max" {
+		t.Errorf("initEnv() = %v, want RESTIC_COMPRESSION=max", got)
+	}
+
+	if got := strings.Join(buildInitArgs(types.InitOptions{}), " "); got != "init" {
+		t.Errorf("buildInitArgs(defaults) = %q, want %q", got, "init")
+	}
+	if got := initEnv(types.InitOptions{}); got != nil {
+		t.Errorf("initEnv(defaults) = %v, want none", got)
+	}
+}
+
+func TestInitOptionsForVersion(t *testing.T) {
+	opts := types.InitOptions{PackSize: "32", RepositoryVersion: 2, Compression: "auto"}
+
+	if got := initOptionsForVersion(opts, "restic 0.14.0 compiled with go1.19 on linux/amd64"); got != opts {
+		t.Errorf("restic 0.14 should keep every option, got %+v", got)
+	}
+	// Older restic has no repository versions or compression
+	got := initOptionsForVersion(opts, "restic 0.13.1 compiled with go1.18 on linux/amd64")
+	if got.RepositoryVersion != 0 || got.Compression != "" || got.PackSize != "32" {
+		t.Errorf("restic 0.13 should drop version and compression only, got %+v", got)
+	}
+	if len(initEnv(got)) != 0 || strings.Contains(strings.Join(buildInitArgs(got), " "), "--repository-version") {
+		t.Error("Dropped options should produce no flags or environment")
+	}
+	if got := initOptionsForVersion(opts, "unknown"); got != opts {
+		t.Errorf("Unknown versions should keep every option, got %+v", got)
+	}
+}
+
+func TestClient_InitWithOptions(t *testing.T) {
+	record := filepath.Join(t.TempDir(), "init.txt")
+	installFakeRestic(t, `case "$1" in
+  version) echo "restic 0.16.4 compiled with go1.21.6 on linux/amd64" ;;
+  *) echo "$* compression=$RESTIC_COMPRESSION" > `+record+` ;;
+esac`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	if err := client.InitWithOptions(DefaultRepositoryVersion, DefaultCompression); err != nil {
+		t.Fatalf("InitWithOptions() error = %v", err)
+	}
+
+	data, err := os.ReadFile(record)
+	if err != nil {
+		t.Fatalf("restic init was not run: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "init --repository-version 2 compression=auto" {
+		t.Errorf("restic init ran as %q", got)
+	}
+}
+
+func TestBuildForgetArgs_KeepTags(t *testing.T) {
+	policy := types.ForgetPolicy{KeepDaily: 7, KeepTags: []string{"release", "audit"}, Host: "web"}
+
+	got := strings.Join(buildForgetArgs(policy, "--dry-run"), " ")
+	want := "forget --dry-run --keep-daily 7 --keep-tag release --keep-tag audit --host web"
+	if got != want {
+		t.Errorf("buildForgetArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestWithProtectedTag(t *testing.T) {
+	policy := types.ForgetPolicy{KeepLast: 3, KeepTags: []string{"release"}}
+	got := withProtectedTag(policy)
+	if strings.Join(got.KeepTags, ",") != "release,protected" {
+		t.Errorf("KeepTags = %v, want the protected tag added", got.KeepTags)
+	}
+	if len(policy.KeepTags) != 1 {
+		t.Error("withProtectedTag must not modify the caller's policy")
+	}
+	if again := withProtectedTag(got); len(again.KeepTags) != 2 {
+		t.Errorf("The protected tag should only be added once, got %v", again.KeepTags)
+	}
+
+	// Without keep rules, --keep-tag alone would forget everything unprotected
+	if empty := withProtectedTag(types.ForgetPolicy{Host: "web"}); len(empty.KeepTags) != 0 {
+		t.Errorf("A policy without keep rules should be left alone, got %v", empty.KeepTags)
+	}
+}
+
+func TestClient_ForgetDryRun_KeepsProtected(t *testing.T) {
+	// The fake only keeps the protected snapshot when asked to with --keep-tag
+	installFakeRestic(t, `case "$*" in
+  *"--keep-tag protected"*) echo '[{"keep":[{"id":"aaa","short_id":"aaa","tags":["protected"]}],"remove":[{"id":"bbb","short_id":"bbb"}]}]' ;;
+  *) echo '[{"keep":[],"remove":[{"id":"aaa","short_id":"aaa","tags":["protected"]},{"id":"bbb","short_id":"bbb"}]}]' ;;
+esac`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	results, err := client.ForgetDryRun(types.ForgetPolicy{KeepLast: 1})
+	if err != nil {
+		t.Fatalf("ForgetDryRun() error = %v", err)
+	}
+	if len(results) != 1 || len(results[0].SnapshotsToKeep) != 1 || results[0].SnapshotsToKeep[0].ID != "aaa" {
+		t.Fatalf("The protected snapshot should be in the keep set, got %+v", results)
+	}
+	for _, snap := range results[0].SnapshotsToRemove {
+		if snap.ID == "aaa" {
+			t.Error("The protected snapshot must not be removed")
+		}
+	}
+}
+
+func TestBuildProtectArgs(t *testing.T) {
+	if got := strings.Join(buildProtectArgs("abc123", true), " "); got != "tag --add protected abc123" {
+		t.Errorf("buildProtectArgs(protect) = %q", got)
+	}
+	if got := strings.Join(buildProtectArgs("abc123", false), " "); got != "tag --remove protected abc123" {
+		t.Errorf("buildProtectArgs(unprotect) = %q", got)
+	}
+}
+
+func TestClient_Forget_PassesHourlyAndKeepTags(t *testing.T) {
+	record := filepath.Join(t.TempDir(), "args.txt")
+	installFakeRestic(t, `echo "$*" >> `+record+`
+case "$*" in
+  *--dry-run*) echo '[]' ;;
+esac`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	policy := types.ForgetPolicy{KeepHourly: 24, KeepTags: []string{"release"}}
+	if _, err := client.ForgetDryRun(policy); err != nil {
+		t.Fatalf("ForgetDryRun() error = %v", err)
+	}
+	if err := client.Forget(policy); err != nil {
+		t.Fatalf("Forget() error = %v", err)
+	}
+
+	data, err := os.ReadFile(record)
+	if err != nil {
+		t.Fatalf("restic was not run: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected a dry run and a forget, got %q", lines)
+	}
+	for _, line := range lines {
+		for _, flag := range []string{"--keep-hourly 24", "--keep-tag release"} {
+			if !strings.Contains(line, flag) {
+				t.Errorf("%q is missing %s", line, flag)
+			}
+		}
+	}
+}
+
+// lsScript prints a snapshot line followed by count node lines, then runs then
+func lsScript(count int, then string) string {
+	return fmt.Sprintf(`echo '{"message_type":"snapshot","id":"abc123"}'
+i=0
+while [ $i -lt %d ]; do
+  echo "{\"message_type\":\"node\",\"name\":\"file$i\",\"type\":\"file\",\"path\":\"/data/file$i\",\"size\":$i}"
+  i=$((i+1))
+done
+%s`, count, then)
+}
+
+func TestClient_ListFilesStream(t *testing.T) {
+	installFakeRestic(t, lsScript(3000, "exit 0"))
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	out := make(chan types.FileNode, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.ListFilesStream(context.Background(), "abc123", "/data", out)
+	}()
+
+	var count int
+	for node := range out {
+		if want := fmt.Sprintf("file%d", count); node.Name != want {
+			t.Fatalf("Node %d = %q, want %q in listing order", count, node.Name, want)
+		}
+		count++
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("ListFilesStream() error = %v", err)
+	}
+	if count != 3000 {
+		t.Errorf("Received %d nodes, want 3000 (the snapshot line is skipped)", count)
+	}
+}
+
+func TestClient_ListFilesStream_DeliversBeforeListingEnds(t *testing.T) {
+	// The listing stalls after the first nodes, as restic does on a slow backend
+	installFakeRestic(t, lsScript(500, "exec sleep 5"))
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan types.FileNode)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.ListFilesStream(ctx, "abc123", "/data", out)
+	}()
+
+	for i := 0; i < 500; i++ {
+		select {
+		case <-out:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Only %d nodes arrived while restic was still running", i)
+		}
+	}
+
+	// Cancelling stops restic and closes the channel
+	start := time.Now()
+	cancel()
+	for range out {
+	}
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("ListFilesStream() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Cancel took %v, restic should be stopped right away", elapsed)
+	}
+}
+
+func TestClient_ListFilesStream_Failure(t *testing.T) {
+	installFakeRestic(t, lsScript(10, "echo 'Fatal: no snapshot found' >&2; exit 1"))
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	out := make(chan types.FileNode, 100)
+	err := client.ListFilesStream(context.Background(), "missing", "/", out)
+	if err == nil {
+		t.Fatal("ListFilesStream() should fail when restic exits with an error")
+	}
+
+	var count int
+	for range out {
+		count++
+	}
+	if count != 10 {
+		t.Errorf("Nodes sent before the failure = %d, want 10 and a closed channel", count)
+	}
+}
+
+func TestParseMigrations(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{
+			name:   "one available",
+			output: "repository 3f2a1b opened (version 1)\navailable migrations:\n  upgrade_repo_v2\tupgrade a repository to version 2\n",
+			want:   []string{"upgrade_repo_v2"},
+		},
+		{
+			name:   "several available",
+			output: "available migrations:\n  s3_layout\tmove files to 'default' layout for S3 backends\n  upgrade_repo_v2\tupgrade a repository to version 2\n\n",
+			want:   []string{"s3_layout", "upgrade_repo_v2"},
+		},
+		{
+			name:   "none available",
+			output: "repository 3f2a1b opened (version 2, compression level auto)\navailable migrations:\nno migrations found\n",
+			want:   nil,
+		},
+		{
+			name:   "empty output",
+			output: "",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseMigrations([]byte(tt.output))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("parseMigrations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyMigration(t *testing.T) {
+	installFakeRestic(t, `echo "ran $*"; echo "applying migration upgrade_repo_v2..."`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	client.LongTimeout = time.Nanosecond
+
+	output, err := client.ApplyMigration("upgrade_repo_v2")
+	if err != nil {
+		t.Fatalf("ApplyMigration() failed: %v", err)
+	}
+	if !strings.Contains(output, "ran migrate upgrade_repo_v2") || !strings.Contains(output, "applying migration") {
+		t.Errorf("Unexpected output: %q", output)
+	}
+}
+
+func TestRunWithChannel(t *testing.T) {
+	installFakeRestic(t, `echo "ran $*"`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	updates := make(chan CheckMessage, 10)
+	go client.RunWithChannel(context.Background(), []string{"stats", "--mode", "raw-data"}, updates)
+
+	var lines []string
+	for msg := range updates {
+		if msg.Error != nil {
+			t.Fatalf("RunWithChannel() failed: %v", msg.Error)
+		}
+		lines = append(lines, msg.Line)
+	}
+	if len(lines) != 1 || !strings.HasSuffix(lines[0], "stats --mode raw-data") {
+		t.Errorf("Expected the arguments to reach restic unchanged, got %q", lines)
+	}
+}
+
+func TestStreamCommand_InterruptsOnCancel(t *testing.T) {
+	installFakeRestic(t, `trap 'echo "interrupted, removing lock"; exit 130' INT
+echo started
+while :; do sleep 0.05; done`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan CheckMessage, 10)
+	go client.ApplyMigrationWithChannel(ctx, "upgrade_repo_v2", updates)
+
+	if msg := <-updates; msg.Line != "started" {
+		t.Fatalf("Expected restic to start, got %+v", msg)
+	}
+	start := time.Now()
+	cancel()
+
+	var lines []string
+	var err error
+	for msg := range updates {
+		if msg.Error != nil {
+			err = msg.Error
+			continue
+		}
+		lines = append(lines, msg.Line)
+	}
+	if err == nil {
+		t.Error("A cancelled command should report an error")
+	}
+	if len(lines) != 1 || lines[0] != "interrupted, removing lock" {
+		t.Errorf("restic should be interrupted and get to clean up, got %q", lines)
+	}
+	if elapsed := time.Since(start); elapsed > CancelGracePeriod/2 {
+		t.Errorf("Cancelled command took %v to exit", elapsed)
+	}
+}
+
+func TestParseLockIDs(t *testing.T) {
+	output := "repository 3f2a1b opened (version 2)\n" +
+		"b3ab4a6d1d1c2e8f5a7c9e0b2d4f6a8c0e2f4a6c8e0b2d4f6a8c0e2f4a6c8e0b\n" +
+		"\n" +
+		"  1f4e6c8a0b2d4f6a8c0e2f4a6c8e0b2d4f6a8c0e2f4a6c8e0b2d4f6a8c0e2f4a  \n"
+
+	got := parseLockIDs([]byte(output))
+	want := []string{
+		"b3ab4a6d1d1c2e8f5a7c9e0b2d4f6a8c0e2f4a6c8e0b2d4f6a8c0e2f4a6c8e0b",
+		"1f4e6c8a0b2d4f6a8c0e2f4a6c8e0b2d4f6a8c0e2f4a6c8e0b2d4f6a8c0e2f4a",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("parseLockIDs() = %v, want %v", got, want)
+	}
+	if ids := parseLockIDs(nil); len(ids) != 0 {
+		t.Errorf("No output should mean no locks, got %v", ids)
+	}
+}
+
+func TestParseLock(t *testing.T) {
+	output := `{"time":"2024-05-01T10:30:00.123456789+02:00","exclusive":true,"hostname":"nas","username":"backup","pid":4242,"uid":1000,"gid":1000}`
+
+	lock, err := parseLock("b3ab4a6d", []byte(output))
+	if err != nil {
+		t.Fatalf("parseLock() failed: %v", err)
+	}
+	if lock.ID != "b3ab4a6d" || !lock.Exclusive || lock.Hostname != "nas" || lock.Username != "backup" || lock.PID != 4242 {
+		t.Errorf("Unexpected lock: %+v", lock)
+	}
+	if lock.Time.UTC() != time.Date(2024, 5, 1, 8, 30, 0, 123456789, time.UTC) {
+		t.Errorf("Time = %v", lock.Time)
+	}
+
+	if _, err := parseLock("bad", []byte("not json")); err == nil {
+		t.Error("Expected an error for malformed lock JSON")
+	}
+}
+
+func TestListLocks(t *testing.T) {
+	installFakeRestic(t, `case "$1 $2" in
+"list locks") echo aaaa1111; echo bbbb2222 ;;
+"cat lock") if [ "$3" = bbbb2222 ]; then echo "Fatal: lock does not exist" >&2; exit 1; fi
+  echo '{"time":"2024-05-01T10:30:00Z","exclusive":false,"hostname":"laptop","username":"me","pid":77}' ;;
+esac`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	locks, err := client.ListLocks()
+	if err != nil {
+		t.Fatalf("ListLocks() failed: %v", err)
+	}
+	if len(locks) != 1 || locks[0].ID != "aaaa1111" || locks[0].Hostname != "laptop" || locks[0].PID != 77 {
+		t.Errorf("Expected the one lock still held, got %+v", locks)
+	}
+}
@@ -0,0 +1,161 @@
+package restic
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotInitialized is returned when the configured path holds no restic repository
+var ErrNotInitialized = errors.New("repository not initialized")
+
+// notInitializedMarkers are fragments restic prints when a repository's config file is missing
+var notInitializedMarkers = []string{
+	"unable to open config file",
+	"is there a repository at the following location",
+	"repository does not exist",
+}
+
+// ErrLocked is returned when another restic process (or a stale lock) holds the repository lock
+var ErrLocked = errors.New("repository is locked")
+
+// lockedMarkers are fragments restic prints when another lock holds the
+// repository. "unable to create lock" alone isn't one: restic also prints it
+// when the lock file can't be written, e.g. on a read-only or full backend.
+var lockedMarkers = []string{
+	"repository is already locked",
+}
+
+// ErrAlreadyInitialized is returned by Init when a repository already exists at the path
+var ErrAlreadyInitialized = errors.New("repository already initialized")
+
+// alreadyInitializedMarkers are fragments restic init prints when the target already holds a repository
+var alreadyInitializedMarkers = []string{
+	"repository master key and config already initialized",
+	"config file already exists",
+	"already exists",
+}
+
+// ErrUnreachable is returned by Ping when the repository can't be opened,
+// usually because the backend is offline or the password is wrong
+var ErrUnreachable = errors.New("repository unreachable or wrong password")
+
+// IsNotInitialized reports whether err indicates an uninitialized repository
+func IsNotInitialized(err error) bool {
+	return errors.Is(err, ErrNotInitialized)
+}
+
+// classifyError wraps err with a sentinel describing the failure when restic's
+// output matches a known condition
+func classifyError(err error, output []byte) error {
+	lower := strings.ToLower(string(output))
+	for _, marker := range notInitializedMarkers {
+		if strings.Contains(lower, marker) {
+			return fmt.Errorf("%w: %w", ErrNotInitialized, err)
+		}
+	}
+	for _, marker := range lockedMarkers {
+		if strings.Contains(lower, marker) {
+			return fmt.Errorf("%w: %w", ErrLocked, err)
+		}
+	}
+	return err
+}
+
+// IsLocked reports whether err was caused by a repository lock
+func IsLocked(err error) bool {
+	return errors.Is(err, ErrLocked)
+}
+
+// checkStatus maps the result of restic check to a repository status: "healthy",
+// "locked" when a lock stopped the check (often stale, fixed by unlock), or
+// "error" for integrity problems and other failures
+func checkStatus(err error) string {
+	switch {
+	case err == nil:
+		return "healthy"
+	case IsLocked(err):
+		return "locked"
+	default:
+		return "error"
+	}
+}
+
+// IsUnreachable reports whether err came from a failed reachability precheck
+func IsUnreachable(err error) bool {
+	return errors.Is(err, ErrUnreachable)
+}
+
+// IsAlreadyInitialized reports whether err indicates restic init found an existing repository
+func IsAlreadyInitialized(err error) bool {
+	return errors.Is(err, ErrAlreadyInitialized)
+}
+
+// classifyInitError wraps an init failure with ErrAlreadyInitialized when restic
+// refused to overwrite an existing repository. It is separate from classifyError
+// because "already exists" is only meaningful for init.
+func classifyInitError(err error, output []byte) error {
+	lower := strings.ToLower(string(output))
+	for _, marker := range alreadyInitializedMarkers {
+		if strings.Contains(lower, marker) {
+			return fmt.Errorf("%w: %w", ErrAlreadyInitialized, err)
+		}
+	}
+	return err
+}
+
+// friendlyErrors maps fragments of restic's output to short explanations, checked
+// in order so the most specific cause wins
+var friendlyErrors = []struct {
+	markers []string
+	message string
+}{
+	{[]string{"wrong password or no key found"}, "wrong password - check password_file, password_command or password_env"},
+	{notInitializedMarkers, "repository not found - check the path or initialize it"},
+	{lockedMarkers, "repository is locked by another restic process - unlock it if the lock is stale"},
+	{[]string{"no space left on device", "disk quota exceeded", "not enough space"}, "out of disk space"},
+	{[]string{"i/o timeout", "connection timed out", "operation timed out", "tls handshake timeout"}, "network timeout - the repository backend is not responding"},
+	{[]string{"connection refused", "no such host", "network is unreachable", "connection reset"}, "network error - cannot reach the repository backend"},
+}
+
+// classifyResticError returns a short human-readable message for restic output
+// that matches a known failure, or "" when the cause isn't recognized
+func classifyResticError(output string) string {
+	lower := strings.ToLower(output)
+	for _, known := range friendlyErrors {
+		for _, marker := range known.markers {
+			if strings.Contains(lower, marker) {
+				return known.message
+			}
+		}
+	}
+	return ""
+}
+
+// Describe returns a short message for err: an explanation when restic's output
+// shows a known failure, else the first "Fatal:" line restic printed, else the
+// full error text. Timeouts enforced by the client are returned as they are.
+func Describe(err error) string {
+	if err == nil {
+		return ""
+	}
+	text := err.Error()
+	if IsTimeout(err) {
+		return text // Our own timeout message already says what happened
+	}
+	if message := classifyResticError(text); message != "" {
+		return message
+	}
+	lines := strings.Split(text, "\n")
+	for n, line := range lines {
+		if i := strings.Index(line, "Fatal: "); i >= 0 {
+			line = line[i+len("Fatal: "):]
+			if n == len(lines)-1 && strings.Contains(text, "(output: ") {
+				// Drop the parenthesis closing execCommand's "(output: ...)"
+				line = strings.TrimSuffix(line, ")")
+			}
+			return strings.TrimSpace(line)
+		}
+	}
+	return text
+}
@@ -0,0 +1,254 @@
+package restic
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+func TestCheckStatus_ClassifiesCheckErrors(t *testing.T) {
+	base := errors.New("exit status 1")
+
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name:   "Exclusive lock held",
+			output: "Fatal: unable to create lock in backend: repository is already locked exclusively by PID 4242 on backup-host by root (UID 0, GID 0)\nlock was created at 2024-03-01 02:00:00 (3h12m ago)",
+			want:   "locked",
+		},
+		{
+			name:   "Shared lock held",
+			output: "unable to create lock in backend: repository is already locked by PID 99 on laptop",
+			want:   "locked",
+		},
+		{
+			name:   "Lock file can't be written",
+			output: "Fatal: unable to create lock in backend: open /mnt/backup/locks/7f3a: read-only file system",
+			want:   "error",
+		},
+		{
+			name:   "Corrupted pack",
+			output: "error for tree 1a2b3c4d:\n  tree 1a2b3c4d: file \"x\" blob 0 size could not be found\nFatal: repository contains errors",
+			want:   "error",
+		},
+		{
+			name:   "Wrong password",
+			output: "Fatal: wrong password or no key found",
+			want:   "error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkStatus(classifyError(base, []byte(tt.output))); got != tt.want {
+				t.Errorf("checkStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := checkStatus(nil); got != "healthy" {
+		t.Errorf("checkStatus(nil) = %q, want healthy", got)
+	}
+}
+
+func TestClassifyError_NotInitialized(t *testing.T) {
+	base := errors.New("exit status 1")
+
+	tests := []struct {
+		name   string
+		output string
+		want   bool
+	}{
+		{
+			name:   "Missing config file",
+			output: "Fatal: unable to open config file: stat /srv/repo/config: no such file or directory\nIs there a repository at the following location?\n/srv/repo",
+			want:   true,
+		},
+		{
+			name:   "Newer restic wording",
+			output: "Fatal: repository does not exist: unable to open config file",
+			want:   true,
+		},
+		{
+			name:   "Wrong password",
+			output: "Fatal: wrong password or no key found",
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyError(base, []byte(tt.output))
+			if IsNotInitialized(err) != tt.want {
+				t.Errorf("IsNotInitialized() = %v, want %v", IsNotInitialized(err), tt.want)
+			}
+			if !errors.Is(err, base) {
+				t.Error("Classified error should still wrap the original error")
+			}
+		})
+	}
+}
+
+func TestClient_execCommand_NotInitialized(t *testing.T) {
+	installFakeRestic(t, `echo 'Fatal: unable to open config file: stat /srv/repo/config: no such file or directory' >&2
+echo 'Is there a repository at the following location?' >&2
+exit 1`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/srv/repo"})
+
+	_, err := client.ListSnapshots()
+	if !IsNotInitialized(err) {
+		t.Errorf("Expected not-initialized error, got: %v", err)
+	}
+}
+
+func TestClient_Init_AlreadyInitialized(t *testing.T) {
+	installFakeRestic(t, `if [ "$1" = "init" ] && [ -n "$FAKE_EXISTING" ]; then
+  echo "Fatal: create repository at /srv/repo failed: Fatal: config file already exists" >&2
+  exit 1
+fi
+if [ "$1" = "init" ]; then
+  echo "created restic repository 3f2a1b9c4d at /srv/repo"
+  echo ""
+  echo "Please note that knowledge of your password is required to access"
+  echo "the repository. Losing your password means that your data is"
+  echo "irrecoverably lost."
+  exit 0
+fi
+exit 1`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/srv/repo"})
+
+	if err := client.Init(types.InitOptions{}); err != nil {
+		t.Fatalf("Init() of a fresh repository failed: %v", err)
+	}
+
+	t.Setenv("FAKE_EXISTING", "1")
+	err := client.Init(types.InitOptions{})
+	if !IsAlreadyInitialized(err) {
+		t.Errorf("Expected already-initialized error, got: %v", err)
+	}
+	if IsNotInitialized(err) {
+		t.Error("An existing repository should not be reported as uninitialized")
+	}
+}
+
+func TestClassifyInitError(t *testing.T) {
+	base := errors.New("exit status 1")
+
+	if err := classifyInitError(base, []byte("Fatal: repository master key and config already initialized")); !IsAlreadyInitialized(err) {
+		t.Errorf("Expected older restic wording to be recognized, got: %v", err)
+	}
+	if err := classifyInitError(base, []byte("Fatal: create repository at /srv/repo failed: permission denied")); IsAlreadyInitialized(err) {
+		t.Error("Unrelated init failures should not be classified as already initialized")
+	}
+}
+
+func TestClient_Ping(t *testing.T) {
+	installFakeRestic(t, `if [ "$1 $2" = "cat config" ] && [ -f "$RESTIC_REPOSITORY/config" ]; then
+  echo '{"version":2,"id":"3f2a1b9c"}'
+  exit 0
+fi
+echo "Fatal: unable to open config file: stat $RESTIC_REPOSITORY/config: no such file or directory" >&2
+exit 1`)
+
+	repoDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repoDir, "config"), []byte("blob"), 0600); err != nil {
+		t.Fatalf("Failed to write repository config: %v", err)
+	}
+
+	if err := NewClient(types.RepositoryConfig{Name: "ok", Path: repoDir}).Ping(); err != nil {
+		t.Errorf("Ping() of a reachable repository failed: %v", err)
+	}
+
+	err := NewClient(types.RepositoryConfig{Name: "missing", Path: filepath.Join(repoDir, "does-not-exist")}).Ping()
+	if err == nil {
+		t.Fatal("Ping() of a nonexistent repository should fail")
+	}
+	if !IsUnreachable(err) {
+		t.Errorf("Expected unreachable error, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "unable to open config file") {
+		t.Errorf("Error should keep restic's output, got: %v", err)
+	}
+}
+
+func TestClassifyResticError(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name:   "Wrong password",
+			output: "restic command failed: exit status 1 (output: Fatal: wrong password or no key found)",
+			want:   "wrong password - check password_file, password_command or password_env",
+		},
+		{
+			name:   "Repository not found",
+			output: "Fatal: unable to open config file: stat /srv/backup/config: no such file or directory\nIs there a repository at the following location?",
+			want:   "repository not found - check the path or initialize it",
+		},
+		{
+			name:   "Locked",
+			output: "unable to create lock in backend: repository is already locked by PID 99 on laptop",
+			want:   "repository is locked by another restic process - unlock it if the lock is stale",
+		},
+		{
+			name:   "Network timeout",
+			output: "Fatal: unable to open repository at sftp:backup@host:/srv: ssh: dial tcp 10.0.0.5:22: i/o timeout",
+			want:   "network timeout - the repository backend is not responding",
+		},
+		{
+			name:   "Connection refused",
+			output: "Fatal: rest: Get \"http://localhost:8000/config\": dial tcp 127.0.0.1:8000: connect: connection refused",
+			want:   "network error - cannot reach the repository backend",
+		},
+		{
+			name:   "Out of space",
+			output: "Fatal: unable to save snapshot: write /mnt/repo/data/ab/abcd: no space left on device",
+			want:   "out of disk space",
+		},
+		{
+			name:   "Unrecognized",
+			output: "Fatal: repository contains errors",
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyResticError(tt.output); got != tt.want {
+				t.Errorf("classifyResticError() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	if got := Describe(nil); got != "" {
+		t.Errorf("Describe(nil) = %q, want empty", got)
+	}
+
+	err := errors.New("restic command failed: exit status 1 (output: Fatal: wrong password or no key found)")
+	if got := Describe(err); got != "wrong password - check password_file, password_command or password_env" {
+		t.Errorf("Describe() = %q, want the friendly message", got)
+	}
+
+	err = errors.New("restic command failed: exit status 1 (output: reading index\nFatal: repository contains errors)")
+	if got := Describe(err); got != "repository contains errors" {
+		t.Errorf("Describe() = %q, want the Fatal line", got)
+	}
+
+	err = errors.New("something unexpected")
+	if got := Describe(err); got != "something unexpected" {
+		t.Errorf("Describe() = %q, want the raw error", got)
+	}
+}
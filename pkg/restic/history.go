@@ -0,0 +1,155 @@
+package restic
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+// Command is a restic invocation recorded so it can be shown and re-run.
+// Only arguments are kept; passwords live in the environment and are never recorded.
+type Command struct {
+	// Args are the subcommand arguments, without repository-wide global flags
+	Args []string
+
+	config types.RepositoryConfig
+}
+
+// credentialsPattern matches the password part of user:password@ in repository URLs
+var credentialsPattern = regexp.MustCompile(`(//[^/@:\s]*):[^/@\s]*@`)
+
+// redact hides credentials embedded in a repository URL or argument
+func redact(s string) string {
+	return credentialsPattern.ReplaceAllString(s, "$1:***@")
+}
+
+// Words returns the command line as restic would see it, with credentials redacted
+func (c Command) Words() []string {
+	client := &Client{config: c.config}
+	words := []string{"restic", "-r", redact(c.config.Path)}
+	for _, arg := range client.buildArgs(c.Args...) {
+		words = append(words, redact(arg))
+	}
+	return words
+}
+
+// RepoName returns the name of the repository the command ran against
+func (c Command) RepoName() string {
+	return c.config.Name
+}
+
+// String returns the redacted command line
+func (c Command) String() string {
+	return strings.Join(c.Words(), " ")
+}
+
+// lastCommand holds the most recent command started by any client
+var lastCommand struct {
+	sync.Mutex
+	cmd *Command
+}
+
+// LastCommand returns the most recently started restic command, if any
+func LastCommand() (Command, bool) {
+	lastCommand.Lock()
+	defer lastCommand.Unlock()
+	if lastCommand.cmd == nil {
+		return Command{}, false
+	}
+	return *lastCommand.cmd, true
+}
+
+// recordCommand remembers args as the last command run against the client's repository
+func (c *Client) recordCommand(args []string) {
+	lastCommand.Lock()
+	defer lastCommand.Unlock()
+	lastCommand.cmd = &Command{Args: append([]string(nil), args...), config: c.config}
+}
+
+// command builds a restic command bound to ctx with the repository environment.
+// It is not recorded; see userCommand.
+func (c *Client) command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "restic", c.buildArgs(args...)...)
+	// Start with parent environment and add our custom vars
+	cmd.Env = append(os.Environ(), c.buildEnv()...)
+	// Starting the command fails with this error before restic runs
+	if err := c.checkPasswordEnv(); err != nil {
+		cmd.Err = err
+	}
+	return cmd
+}
+
+// userCommand builds a restic command like command and records it as the last
+// command. Only actions the user starts use it: queries LazyRestic runs on its
+// own to load snapshots, sizes, locks and repository status are not recorded,
+// so re-running repeats what the user last did rather than the latest refresh.
+func (c *Client) userCommand(ctx context.Context, args ...string) *exec.Cmd {
+	c.recordCommand(args)
+	return c.command(ctx, args...)
+}
+
+// Rerun executes a recorded command again and returns its combined output.
+// It stops when ctx is cancelled or after timeout (zero for none); restic is
+// interrupted first so it can remove its lock.
+func Rerun(ctx context.Context, cmd Command, timeout time.Duration) ([]byte, error) {
+	if len(cmd.Args) == 0 {
+		return nil, nil
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	run := NewClient(cmd.config).userCommand(ctx, cmd.Args...)
+	interruptOnCancel(run)
+	output, err := run.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return output, timeoutError(cmd.Args[0], timeout)
+	}
+	if err != nil {
+		return output, classifyError(fmt.Errorf("restic command failed: %w (output: %s)", err, strings.TrimSpace(string(output))), output)
+	}
+	return output, nil
+}
+
+// readOnlyVerbs are the restic subcommands that never change a repository
+var readOnlyVerbs = []string{"snapshots", "stats", "ls", "find", "cat", "diff", "check", "list", "dump", "version", "help"}
+
+// MutatingVerb returns the subcommand of args when it may change the
+// repository, or "" when it only reads. The subcommand is the first non-flag
+// argument; "key" only reads when listing. Anything not known to be read-only
+// counts as mutating, including a global flag's value mistaken for the
+// subcommand, since asking for confirmation needlessly is the safe mistake.
+func MutatingVerb(args []string) string {
+	var words []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			words = append(words, arg)
+		}
+	}
+	if len(words) == 0 {
+		return ""
+	}
+
+	verb := words[0]
+	if verb == "key" {
+		if len(words) > 1 && words[1] == "list" {
+			return ""
+		}
+		return verb
+	}
+	for _, readOnly := range readOnlyVerbs {
+		if verb == readOnly {
+			return ""
+		}
+	}
+	return verb
+}
@@ -0,0 +1,79 @@
+package restic
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+// ListKeys returns the repository's access keys
+func (c *Client) ListKeys() ([]types.RepoKey, error) {
+	output, err := c.execUserCommand(c.Timeout, "key", "list", "--json")
+	if err != nil {
+		return nil, err
+	}
+	return parseKeys(output)
+}
+
+// parseKeys parses `restic key list --json` output
+func parseKeys(output []byte) ([]types.RepoKey, error) {
+	keys := []types.RepoKey{}
+	if trimmed := strings.TrimSpace(string(output)); trimmed == "" || trimmed == "null" {
+		return keys, nil
+	}
+	if err := json.Unmarshal(output, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse key list: %w", err)
+	}
+	return keys, nil
+}
+
+// AddKey adds an access key with newPassword to the repository
+func (c *Client) AddKey(newPassword string) error {
+	return withNewPasswordFile(newPassword, func(path string) error {
+		_, err := c.execUserCommand(c.Timeout, "key", "add", "--new-password-file", path)
+		return err
+	})
+}
+
+// RemoveKey removes the access key with the given ID. restic refuses to remove
+// the key the repository was opened with.
+func (c *Client) RemoveKey(id string) error {
+	_, err := c.execUserCommand(c.Timeout, "key", "remove", id)
+	return err
+}
+
+// ChangePassword replaces the password of the key the repository was opened
+// with. The configured password source must be updated to match afterwards.
+func (c *Client) ChangePassword(newPassword string) error {
+	return withNewPasswordFile(newPassword, func(path string) error {
+		_, err := c.execUserCommand(c.Timeout, "key", "passwd", "--new-password-file", path)
+		return err
+	})
+}
+
+// withNewPasswordFile writes password to a temporary 0600 file for restic's
+// --new-password-file, keeping it off the command line, and removes the file
+// once run returns
+func withNewPasswordFile(password string, run func(path string) error) error {
+	if password == "" {
+		return fmt.Errorf("the new password is empty")
+	}
+
+	file, err := os.CreateTemp("", "lazyrestic-key-*")
+	if err != nil {
+		return fmt.Errorf("failed to create password file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(password + "\n"); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write password file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write password file: %w", err)
+	}
+	return run(file.Name())
+}
@@ -0,0 +1,105 @@
+package restic
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+func TestParseKeys(t *testing.T) {
+	// Shape of restic 0.16 key list --json output
+	output := []byte(`[{"current":true,"id":"4a9c5a2e","userName":"alice","hostName":"laptop","created":"2024-01-02 15:04:05"},` +
+		`{"current":false,"id":"b7d1e3f0","userName":"backup","hostName":"nas","created":"2023-06-30 08:00:00"}]`)
+
+	keys, err := parseKeys(output)
+	if err != nil {
+		t.Fatalf("parseKeys() failed: %v", err)
+	}
+	want := []types.RepoKey{
+		{Current: true, ID: "4a9c5a2e", UserName: "alice", HostName: "laptop", Created: "2024-01-02 15:04:05"},
+		{ID: "b7d1e3f0", UserName: "backup", HostName: "nas", Created: "2023-06-30 08:00:00"},
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("parseKeys() = %+v, want %+v", keys, want)
+	}
+}
+
+func TestParseKeys_EmptyAndInvalid(t *testing.T) {
+	for _, output := range []string{"", "null\n"} {
+		if keys, err := parseKeys([]byte(output)); err != nil || keys == nil || len(keys) != 0 {
+			t.Errorf("parseKeys(%q) = %#v, %v, want an empty list", output, keys, err)
+		}
+	}
+	if _, err := parseKeys([]byte("ID  User  Host\n")); err == nil {
+		t.Error("Expected an error for non-JSON output")
+	}
+}
+
+func TestClient_ListKeys(t *testing.T) {
+	installFakeRestic(t, `if [ "$*" = "key list --json" ]; then
+  echo '[{"current":true,"id":"4a9c5a2e","userName":"alice","hostName":"laptop","created":"2024-01-02 15:04:05"}]'
+  exit 0
+fi
+echo "unexpected args: $*" >&2
+exit 1`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	keys, err := client.ListKeys()
+	if err != nil {
+		t.Fatalf("ListKeys() failed: %v", err)
+	}
+	if len(keys) != 1 || !keys[0].Current || keys[0].ID != "4a9c5a2e" {
+		t.Errorf("ListKeys() = %+v", keys)
+	}
+}
+
+func TestClient_AddKey_PassesPasswordInFile(t *testing.T) {
+	seen := filepath.Join(t.TempDir(), "seen")
+	installFakeRestic(t, `if [ "$1 $2 $3" != "key add --new-password-file" ]; then
+  echo "unexpected args: $*" >&2
+  exit 1
+fi
+echo "$4" > `+seen+`
+cat "$4" >> `+seen)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	if err := client.AddKey("s3cret"); err != nil {
+		t.Fatalf("AddKey() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(seen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || lines[1] != "s3cret" {
+		t.Fatalf("restic should read the password from the file, got %q", data)
+	}
+	if _, err := os.Stat(lines[0]); !os.IsNotExist(err) {
+		t.Errorf("The password file %s should be removed afterwards", lines[0])
+	}
+
+	if err := client.AddKey(""); err == nil {
+		t.Error("Expected an error for an empty password")
+	}
+}
+
+func TestClient_RemoveKey(t *testing.T) {
+	installFakeRestic(t, `if [ "$*" = "key remove 4a9c5a2e" ]; then
+  echo "Fatal: refusing to remove key currently used to access repository" >&2
+  exit 1
+fi
+[ "$*" = "key remove b7d1e3f0" ] || { echo "unexpected args: $*" >&2; exit 1; }`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	if err := client.RemoveKey("b7d1e3f0"); err != nil {
+		t.Errorf("RemoveKey() failed: %v", err)
+	}
+	if err := client.RemoveKey("4a9c5a2e"); err == nil || !strings.Contains(err.Error(), "refusing to remove") {
+		t.Errorf("Expected restic's refusal, got %v", err)
+	}
+}
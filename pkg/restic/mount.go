@@ -0,0 +1,162 @@
+package restic
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrMountUnavailable is returned by Mount when restic can't mount on this system,
+// usually because FUSE isn't installed or the platform doesn't support restic mount
+var ErrMountUnavailable = errors.New("restic mount is not available")
+
+// mountUnavailableMarkers are fragments restic prints when FUSE mounting can't work
+var mountUnavailableMarkers = []string{
+	"fusermount",
+	"/dev/fuse",
+	"fuse: device not found",
+	"unknown command \"mount\"",
+	"not supported",
+}
+
+// mountReadyMarker is printed by restic mount once the repository is being served
+const mountReadyMarker = "Now serving the repository"
+
+// unmountTimeout is how long Unmount waits for restic to exit after interrupting it
+const unmountTimeout = 5 * time.Second
+
+// runningMounts tracks when each process started by Mount exits, since only
+// the goroutine that called Wait may look at its state
+var runningMounts = struct {
+	sync.Mutex
+	exited map[*exec.Cmd]chan struct{}
+}{exited: make(map[*exec.Cmd]chan struct{})}
+
+// IsMountUnavailable reports whether err means restic mount can't run on this system
+func IsMountUnavailable(err error) bool {
+	return errors.Is(err, ErrMountUnavailable)
+}
+
+// Mount starts `restic mount` on mountpoint as a long-lived process and returns it
+// once restic reports the repository is being served. The mount lasts until Unmount.
+// It fails if restic exits first or isn't ready within the client's Timeout.
+func (c *Client) Mount(mountpoint string) (*exec.Cmd, error) {
+	if err := c.checkPasswordEnv(); err != nil {
+		return nil, err
+	}
+
+	// Not recorded as the last command: a mount can't be re-run as a one-shot command
+	cmd := exec.Command("restic", c.buildArgs("mount", mountpoint)...)
+	cmd.Env = append(os.Environ(), c.buildEnv()...)
+	// Don't wait forever on children (e.g. password commands) holding the output pipes
+	cmd.WaitDelay = time.Second
+
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start restic mount: %w", err)
+	}
+
+	// Close the pipe when restic exits so the reader below sees EOF
+	exited := make(chan error, 1)
+	done := make(chan struct{})
+	runningMounts.Lock()
+	runningMounts.exited[cmd] = done
+	runningMounts.Unlock()
+	go func() {
+		err := cmd.Wait()
+		writer.Close()
+		runningMounts.Lock()
+		delete(runningMounts.exited, cmd)
+		runningMounts.Unlock()
+		close(done)
+		exited <- err
+	}()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		// Keep draining if the scanner gives up (e.g. on an overlong line)
+		io.Copy(io.Discard, reader)
+	}()
+
+	var deadline <-chan time.Time
+	if c.Timeout > 0 {
+		timer := time.NewTimer(c.Timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	var output []string
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				err := <-exited
+				return nil, classifyMountError(fmt.Errorf("restic mount exited: %v (output: %s)", err, strings.Join(output, "\n")), output)
+			}
+			if strings.Contains(line, mountReadyMarker) {
+				// Discard later output so restic never blocks writing it
+				go func() {
+					for range lines {
+					}
+				}()
+				return cmd, nil
+			}
+			output = append(output, line)
+		case <-deadline:
+			cmd.Process.Kill()
+			return nil, timeoutError("mount", c.Timeout)
+		}
+	}
+}
+
+// classifyMountError wraps err with ErrMountUnavailable when restic's output shows
+// FUSE mounting isn't possible here
+func classifyMountError(err error, output []string) error {
+	lower := strings.ToLower(strings.Join(output, "\n"))
+	for _, marker := range mountUnavailableMarkers {
+		if strings.Contains(lower, marker) {
+			return fmt.Errorf("%w: %w", ErrMountUnavailable, err)
+		}
+	}
+	return err
+}
+
+// Unmount stops a mount started by Mount. restic unmounts cleanly on interrupt;
+// if it doesn't exit within unmountTimeout it is killed.
+func Unmount(cmd *exec.Cmd) error {
+	if cmd == nil || cmd.Process == nil {
+		return nil
+	}
+	runningMounts.Lock()
+	done, running := runningMounts.exited[cmd]
+	runningMounts.Unlock()
+	if !running {
+		return nil // Already exited
+	}
+
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		// Interrupts aren't supported everywhere (e.g. Windows)
+		return cmd.Process.Kill()
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(unmountTimeout):
+		return cmd.Process.Kill()
+	}
+}
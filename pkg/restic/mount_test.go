@@ -0,0 +1,86 @@
+package restic
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+func TestClient_MountAndUnmount(t *testing.T) {
+	stopped := filepath.Join(t.TempDir(), "stopped")
+	installFakeRestic(t, `if [ "$1" != "mount" ]; then
+  echo "unexpected args: $*" >&2
+  exit 1
+fi
+trap 'echo x > `+stopped+`; exit 0' INT
+echo "repository abc123 opened"
+echo "Now serving the repository at $2"
+while true; do sleep 0.1; done`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	mountPoint := t.TempDir()
+
+	cmd, err := client.Mount(mountPoint)
+	if err != nil {
+		t.Fatalf("Mount() failed: %v", err)
+	}
+	if got := strings.Join(cmd.Args[1:], " "); got != "mount "+mountPoint {
+		t.Errorf("Args = %q, want mount %s", got, mountPoint)
+	}
+
+	if err := Unmount(cmd); err != nil {
+		t.Fatalf("Unmount() failed: %v", err)
+	}
+	if _, err := os.Stat(stopped); err != nil {
+		t.Error("Unmount should interrupt restic so it can unmount cleanly")
+	}
+
+	// Unmounting again is a no-op
+	if err := Unmount(cmd); err != nil {
+		t.Errorf("Second Unmount() = %v, want nil", err)
+	}
+}
+
+func TestClient_Mount_FuseUnavailable(t *testing.T) {
+	installFakeRestic(t, `echo "repository abc123 opened"
+echo "fusermount: exec: \"fusermount\": executable file not found in \$PATH" >&2
+echo "unable to umount (maybe already umounted or still in use?)" >&2
+exit 1`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+
+	_, err := client.Mount(t.TempDir())
+	if !IsMountUnavailable(err) {
+		t.Fatalf("Expected ErrMountUnavailable, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "fusermount") {
+		t.Errorf("Error should include restic's output, got %v", err)
+	}
+}
+
+func TestClient_Mount_OtherFailure(t *testing.T) {
+	installFakeRestic(t, `echo "Fatal: wrong password or no key found" >&2
+exit 1`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+
+	_, err := client.Mount(t.TempDir())
+	if err == nil || IsMountUnavailable(err) {
+		t.Fatalf("Expected a plain mount error, got %v", err)
+	}
+}
+
+func TestClient_Mount_Timeout(t *testing.T) {
+	installFakeRestic(t, `while true; do sleep 0.1; done`)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test"})
+	client.Timeout = 200 * time.Millisecond
+
+	if _, err := client.Mount(t.TempDir()); !IsTimeout(err) {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package restic
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+// DefaultPasswordCacheTTL is how long a cached password_command result is reused
+// when cache_password_ttl isn't set
+const DefaultPasswordCacheTTL = 15 * time.Minute
+
+// passwordCommandTimeout bounds a password_command run, long enough to answer
+// a prompt from a password manager
+var passwordCommandTimeout = 2 * time.Minute
+
+// passwordCacheEntry is a password_command result held in memory only
+type passwordCacheEntry struct {
+	password string
+	expires  time.Time
+}
+
+// passwordLookup is a password_command run in progress; lookups for the same
+// repository wait for it instead of running the command again
+type passwordLookup struct {
+	done     chan struct{} // Closed once password and err are set
+	password string
+	err      error
+}
+
+// passwordCache holds resolved passwords for repositories with cache_password,
+// keyed by repository path and password command. It is never written anywhere.
+var passwordCache = struct {
+	sync.Mutex
+	entries map[string]passwordCacheEntry
+	pending map[string]*passwordLookup
+}{entries: make(map[string]passwordCacheEntry), pending: make(map[string]*passwordLookup)}
+
+// ClearPasswordCache forgets all cached passwords
+func ClearPasswordCache() {
+	passwordCache.Lock()
+	defer passwordCache.Unlock()
+	passwordCache.entries = make(map[string]passwordCacheEntry)
+}
+
+// cachedPassword returns the password for repositories with cache_password set.
+// If the password command fails, restic is left to run it and report the error.
+func (c *Client) cachedPassword() (string, bool) {
+	if !c.config.CachePassword {
+		return "", false
+	}
+	password, err := resolveCachedPassword(c.config)
+	return password, err == nil
+}
+
+// resolveCachedPassword returns the repository's password, running its
+// password_command only when there is no unexpired cached result. Parallel
+// loads of the same repository share one run, so the user is prompted once,
+// while other repositories resolve their passwords independently.
+func resolveCachedPassword(config types.RepositoryConfig) (string, error) {
+	key := config.Path + "\x00" + config.PasswordCommand
+	ttl := config.CachePasswordTTL
+	if ttl <= 0 {
+		ttl = DefaultPasswordCacheTTL
+	}
+
+	passwordCache.Lock()
+	if entry, ok := passwordCache.entries[key]; ok && time.Now().Before(entry.expires) {
+		passwordCache.Unlock()
+		return entry.password, nil
+	}
+	lookup, running := passwordCache.pending[key]
+	if !running {
+		lookup = &passwordLookup{done: make(chan struct{})}
+		passwordCache.pending[key] = lookup
+	}
+	passwordCache.Unlock()
+
+	if running {
+		<-lookup.done
+		return lookup.password, lookup.err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), passwordCommandTimeout)
+	defer cancel()
+	lookup.password, lookup.err = runPasswordCommand(ctx, config.PasswordCommand)
+
+	passwordCache.Lock()
+	delete(passwordCache.pending, key)
+	if lookup.err == nil {
+		passwordCache.entries[key] = passwordCacheEntry{password: lookup.password, expires: time.Now().Add(ttl)}
+	}
+	passwordCache.Unlock()
+	close(lookup.done)
+
+	return lookup.password, lookup.err
+}
+
+// runPasswordCommand runs a password_command the way restic does: split into
+// words, honouring single and double quotes, and started without a shell. The
+// output is left out of errors since it may hold the secret.
+func runPasswordCommand(ctx context.Context, command string) (string, error) {
+	args, err := splitCommandLine(command)
+	if err != nil {
+		return "", fmt.Errorf("invalid password command: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("password command timed out after %s", passwordCommandTimeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("password command failed: %w", err)
+	}
+	password := strings.TrimSpace(string(output))
+	if password == "" {
+		return "", fmt.Errorf("password command returned an empty password")
+	}
+	return password, nil
+}
+
+// splitCommandLine splits a command line into words at whitespace. Quotes
+// group words and are removed; an unterminated quote is an error.
+func splitCommandLine(command string) ([]string, error) {
+	var (
+		args   []string
+		word   strings.Builder
+		inWord bool
+		quote  rune
+	)
+	for _, r := range command {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case unicode.IsSpace(r):
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("command is empty")
+	}
+	return args, nil
+}
@@ -0,0 +1,174 @@
+package restic
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+// countingPasswordCommand returns a password_command that prints secret and
+// counts its runs in a file, plus a func reading the count
+func countingPasswordCommand(t *testing.T, secret string) (string, func() int) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("password command test requires a POSIX shell")
+	}
+	t.Cleanup(ClearPasswordCache)
+
+	// restic runs password commands without a shell, so count in a script
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "runs")
+	script := filepath.Join(dir, "password.sh")
+	content := "#!/bin/sh\necho run >> '" + counter + "'\nsleep 0.05\necho \"$1\"\n"
+	if err := os.WriteFile(script, []byte(content), 0700); err != nil {
+		t.Fatal(err)
+	}
+	command := script + " " + secret
+	return command, func() int {
+		data, _ := os.ReadFile(counter)
+		return strings.Count(string(data), "run")
+	}
+}
+
+func TestBuildEnv_CachedPasswordRunsCommandOnceWithinTTL(t *testing.T) {
+	command, runs := countingPasswordCommand(t, "s3cret")
+	client := NewClient(types.RepositoryConfig{
+		Name:            "test",
+		Path:            "/tmp/test",
+		PasswordCommand: command,
+		CachePassword:   true,
+	})
+
+	for i := 0; i < 3; i++ {
+		env := client.buildEnv()
+		if !slices.Contains(env, "RESTIC_PASSWORD=s3cret") {
+			t.Fatalf("buildEnv() = %v, want the cached password", env)
+		}
+		for _, v := range env {
+			if strings.HasPrefix(v, "RESTIC_PASSWORD_COMMAND=") {
+				t.Errorf("Cached password should replace RESTIC_PASSWORD_COMMAND, got %v", env)
+			}
+		}
+	}
+
+	// A second client for the same repository shares the cache
+	NewClient(client.config).buildEnv()
+
+	if got := runs(); got != 1 {
+		t.Errorf("password command ran %d times, want once within the TTL", got)
+	}
+}
+
+func TestBuildEnv_CachedPasswordExpires(t *testing.T) {
+	command, runs := countingPasswordCommand(t, "s3cret")
+	client := NewClient(types.RepositoryConfig{
+		Name:             "test",
+		Path:             "/tmp/test",
+		PasswordCommand:  command,
+		CachePassword:    true,
+		CachePasswordTTL: time.Nanosecond,
+	})
+
+	client.buildEnv()
+	time.Sleep(time.Millisecond)
+	client.buildEnv()
+
+	if got := runs(); got != 2 {
+		t.Errorf("password command ran %d times, want it re-run after the TTL", got)
+	}
+}
+
+func TestBuildEnv_PasswordCommandNotCachedByDefault(t *testing.T) {
+	command, runs := countingPasswordCommand(t, "s3cret")
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test", PasswordCommand: command})
+
+	env := client.buildEnv()
+	if !slices.Contains(env, "RESTIC_PASSWORD_COMMAND="+command) {
+		t.Errorf("buildEnv() = %v, want restic to run the password command", env)
+	}
+	if got := runs(); got != 0 {
+		t.Errorf("password command ran %d times, want restic to run it instead", got)
+	}
+}
+
+func TestBuildEnv_FailingPasswordCommandFallsBackToRestic(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("password command test requires a POSIX shell")
+	}
+	t.Cleanup(ClearPasswordCache)
+
+	client := NewClient(types.RepositoryConfig{Name: "test", Path: "/tmp/test", PasswordCommand: "exit 1", CachePassword: true})
+
+	env := client.buildEnv()
+	if !slices.Contains(env, "RESTIC_PASSWORD_COMMAND=exit 1") {
+		t.Errorf("buildEnv() = %v, want restic to run the command and report the failure", env)
+	}
+}
+
+func TestResolveCachedPassword_ParallelLookupsRunCommandOnce(t *testing.T) {
+	command, runs := countingPasswordCommand(t, "s3cret")
+	config := types.RepositoryConfig{Name: "test", Path: "/tmp/test", PasswordCommand: command, CachePassword: true}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if password, err := resolveCachedPassword(config); err != nil || password != "s3cret" {
+				t.Errorf("resolveCachedPassword() = %q, %v", password, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := runs(); got != 1 {
+		t.Errorf("password command ran %d times, want one run shared by parallel lookups", got)
+	}
+}
+
+func TestRunPasswordCommand_TimesOut(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("password command test requires sleep")
+	}
+	defer func(timeout time.Duration) { passwordCommandTimeout = timeout }(passwordCommandTimeout)
+	passwordCommandTimeout = 50 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), passwordCommandTimeout)
+	defer cancel()
+	start := time.Now()
+	if _, err := runPasswordCommand(ctx, "sleep 5"); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("runPasswordCommand() error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("runPasswordCommand() took %s, want it stopped at the timeout", elapsed)
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+		wantErr bool
+	}{
+		{"pass show restic/home", []string{"pass", "show", "restic/home"}, false},
+		{`security find-generic-password -s "restic backup" -w`, []string{"security", "find-generic-password", "-s", "restic backup", "-w"}, false},
+		{"echo 'two  spaces'", []string{"echo", "two  spaces"}, false},
+		{"  padded\tcommand  ", []string{"padded", "command"}, false},
+		{`echo "unterminated`, nil, true},
+		{"   ", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := splitCommandLine(tt.command)
+		if (err != nil) != tt.wantErr || !slices.Equal(got, tt.want) {
+			t.Errorf("splitCommandLine(%q) = %q, %v; want %q (error %v)", tt.command, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
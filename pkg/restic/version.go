@@ -0,0 +1,65 @@
+package restic
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// DefaultMinVersion is the oldest restic release with the JSON output LazyRestic relies on
+// (restore --json progress, forget --json)
+const DefaultMinVersion = "0.15.0"
+
+// versionPattern matches the first dotted version number, e.g. "0.16.4" in
+// "restic 0.16.4 compiled with go1.21.6 on linux/amd64" or "v0.17.0-dev"
+var versionPattern = regexp.MustCompile(`v?(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseResticVersion extracts major, minor and patch numbers from `restic version`
+// output or a bare version string. Pre-release suffixes (-dev, -beta.1) are ignored;
+// a missing patch number is treated as 0.
+func ParseResticVersion(s string) (major, minor, patch int, err error) {
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, 0, 0, fmt.Errorf("unrecognized restic version: %q", s)
+	}
+
+	major, _ = strconv.Atoi(m[1])
+	minor, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		patch, _ = strconv.Atoi(m[3])
+	}
+	return major, minor, patch, nil
+}
+
+// RequireMinVersion checks that the installed restic is at least min
+func RequireMinVersion(min string) error {
+	version, err := GetResticVersion()
+	if err != nil {
+		return fmt.Errorf("failed to determine restic version: %w", err)
+	}
+	return checkMinVersion(version, min)
+}
+
+// checkMinVersion compares a `restic version` string against min
+func checkMinVersion(version, min string) error {
+	major, minor, patch, err := ParseResticVersion(version)
+	if err != nil {
+		return err
+	}
+	minMajor, minMinor, minPatch, err := ParseResticVersion(min)
+	if err != nil {
+		return fmt.Errorf("invalid minimum version: %w", err)
+	}
+
+	have := [3]int{major, minor, patch}
+	want := [3]int{minMajor, minMinor, minPatch}
+	for i := range have {
+		if have[i] > want[i] {
+			return nil
+		}
+		if have[i] < want[i] {
+			return fmt.Errorf("restic %d.%d.%d detected; restore progress requires %d.%d+", major, minor, patch, minMajor, minMinor)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package restic
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseResticVersion(t *testing.T) {
+	tests := []struct {
+		input   string
+		major   int
+		minor   int
+		patch   int
+		wantErr bool
+	}{
+		{"restic 0.16.4 compiled with go1.21.6 on linux/amd64", 0, 16, 4, false},
+		{"restic 0.17.0-dev (compiled manually) compiled with go1.22.0 on darwin/arm64", 0, 17, 0, false},
+		{"restic v0.18.0-beta.1 compiled with go1.23.1 on linux/amd64", 0, 18, 0, false},
+		{"0.15", 0, 15, 0, false},
+		{"1.2.3", 1, 2, 3, false},
+		{"restic (unknown version)", 0, 0, 0, true},
+		{"", 0, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			major, minor, patch, err := ParseResticVersion(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseResticVersion(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if major != tt.major || minor != tt.minor || patch != tt.patch {
+				t.Errorf("ParseResticVersion(%q) = %d.%d.%d, want %d.%d.%d",
+					tt.input, major, minor, patch, tt.major, tt.minor, tt.patch)
+			}
+		})
+	}
+}
+
+func TestCheckMinVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		min     string
+		wantErr bool
+	}{
+		{"restic 0.16.4 compiled with go1.21.6 on linux/amd64", "0.15.0", false},
+		{"restic 0.15.0 compiled with go1.20 on linux/amd64", "0.15.0", false},
+		{"restic 0.14.0 compiled with go1.19 on linux/amd64", "0.15.0", true},
+		{"restic 1.0.0 compiled with go1.24 on linux/amd64", "0.15.0", false},
+		{"restic 0.15.1-dev (compiled manually)", "0.15.2", true},
+		{"restic (unknown version)", "0.15.0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version+"_"+tt.min, func(t *testing.T) {
+			err := checkMinVersion(tt.version, tt.min)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkMinVersion(%q, %q) error = %v, wantErr %v", tt.version, tt.min, err, tt.wantErr)
+			}
+		})
+	}
+
+	err := checkMinVersion("restic 0.14.0 compiled with go1.19 on linux/amd64", "0.15.0")
+	if err == nil || !strings.Contains(err.Error(), "0.15+") {
+		t.Errorf("Expected upgrade message mentioning 0.15+, got: %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// backendSchemes are the repository location prefixes restic recognizes, in
+// the form "scheme:location"
+var backendSchemes = []string{"local", "sftp", "rest", "s3", "b2", "azure", "gs", "swift", "rclone"}
+
+// DetectBackend classifies a repository path by restic's location conventions:
+// "s3:host/bucket" is "s3", "sftp:user@host:/srv/repo" is "sftp" and so on.
+// Paths without a scheme, including Windows drive paths, are "local". An
+// unknown scheme or a scheme without a location is an error.
+func DetectBackend(path string) (string, error) {
+	p := strings.TrimSpace(path)
+	if p == "" {
+		return "", fmt.Errorf("empty repository path")
+	}
+
+	scheme, location, found := strings.Cut(p, ":")
+	if !found || len(scheme) == 1 || strings.ContainsAny(scheme, `/\.~`) {
+		// No scheme, a drive letter (C:\backup) or a path with a colon further on
+		return "local", nil
+	}
+
+	for _, known := range backendSchemes {
+		if strings.EqualFold(scheme, known) {
+			if strings.TrimSpace(location) == "" {
+				return "", fmt.Errorf("missing location after %s:", known)
+			}
+			return known, nil
+		}
+	}
+	return "", fmt.Errorf("unknown backend %q (use a local path or one of %s)", scheme+":", strings.Join(backendSchemes[1:], ", "))
+}
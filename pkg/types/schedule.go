@@ -0,0 +1,131 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// namedSchedules maps schedule keywords (and their cron @ forms) to intervals
+var namedSchedules = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+}
+
+// ParseSchedule returns the backup interval for a schedule. Accepted forms:
+//   - keywords: hourly, daily, weekly, monthly (optionally with a leading @)
+//   - durations: 12h, 90m, or days such as 3d
+//   - five-field cron expressions, reduced to their repeat interval:
+//     "0 2 * * *" is daily, "0 3 * * 0" weekly, "0 */6 * * *" every 6 hours
+//
+// Only the interval matters because LazyRestic flags staleness rather than
+// running backups.
+func ParseSchedule(schedule string) (time.Duration, error) {
+	s := strings.ToLower(strings.TrimSpace(schedule))
+	if s == "" {
+		return 0, fmt.Errorf("empty schedule")
+	}
+
+	if interval, ok := namedSchedules[strings.TrimPrefix(s, "@")]; ok {
+		return interval, nil
+	}
+
+	if fields := strings.Fields(s); len(fields) == 5 {
+		return parseCronInterval(fields)
+	}
+
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid schedule %q: days must be a positive whole number", schedule)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	interval, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schedule %q: use daily, weekly, a duration such as 12h or 3d, or a cron expression", schedule)
+	}
+	if interval < time.Minute {
+		return 0, fmt.Errorf("invalid schedule %q: interval must be at least 1m", schedule)
+	}
+	return interval, nil
+}
+
+// parseCronInterval derives the repeat interval of a cron expression
+// (minute hour day-of-month month day-of-week) from its most frequent field
+func parseCronInterval(fields []string) (time.Duration, error) {
+	for _, field := range fields {
+		if !strings.ContainsAny(field, "0123456789*") {
+			return 0, fmt.Errorf("invalid cron schedule %q", strings.Join(fields, " "))
+		}
+	}
+	minute, hour, dom, month, dow := fields[0], fields[1], fields[2], fields[3], fields[4]
+
+	step := func(field string, unit time.Duration) (time.Duration, bool) {
+		if n, ok := strings.CutPrefix(field, "*/"); ok {
+			if v, err := strconv.Atoi(n); err == nil && v > 0 {
+				return time.Duration(v) * unit, true
+			}
+		}
+		return 0, false
+	}
+
+	switch {
+	case minute == "*":
+		return time.Minute, nil
+	case strings.HasPrefix(minute, "*/"):
+		if interval, ok := step(minute, time.Minute); ok {
+			return interval, nil
+		}
+	case hour == "*":
+		return time.Hour, nil
+	case strings.HasPrefix(hour, "*/"):
+		if interval, ok := step(hour, time.Hour); ok {
+			return interval, nil
+		}
+	case dom == "*" && dow == "*":
+		return 24 * time.Hour, nil
+	case dom == "*" && month == "*":
+		return namedSchedules["weekly"], nil
+	case month == "*":
+		return namedSchedules["monthly"], nil
+	default:
+		return 365 * 24 * time.Hour, nil
+	}
+	return 0, fmt.Errorf("invalid cron schedule %q", strings.Join(fields, " "))
+}
+
+// NextDue returns when the repository's next backup is due: its last backup
+// plus the schedule interval. It returns the zero time when the repository has
+// no valid schedule or has never been backed up.
+func NextDue(repo Repository) time.Time {
+	if repo.Schedule == "" || repo.LastBackup.IsZero() {
+		return time.Time{}
+	}
+	interval, err := ParseSchedule(repo.Schedule)
+	if err != nil {
+		return time.Time{}
+	}
+	return repo.LastBackup.Add(interval)
+}
+
+// IsOverdue reports whether a scheduled repository has gone longer than its
+// interval without a backup at now. A scheduled repository that has never been
+// backed up is overdue; one whose info failed to load is not flagged, since
+// its last backup is unknown.
+func IsOverdue(repo Repository, now time.Time) bool {
+	if repo.Schedule == "" || repo.Status == "error" {
+		return false
+	}
+	if _, err := ParseSchedule(repo.Schedule); err != nil {
+		return false
+	}
+	if repo.LastBackup.IsZero() {
+		return true
+	}
+	return now.After(NextDue(repo))
+}
@@ -0,0 +1,98 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		schedule string
+		want     time.Duration
+		wantErr  bool
+	}{
+		{"daily", 24 * time.Hour, false},
+		{"Weekly", 7 * 24 * time.Hour, false},
+		{"@hourly", time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"3d", 72 * time.Hour, false},
+		{"0 2 * * *", 24 * time.Hour, false},
+		{"30 3 * * 0", 7 * 24 * time.Hour, false},
+		{"0 4 1 * *", 30 * 24 * time.Hour, false},
+		{"0 */6 * * *", 6 * time.Hour, false},
+		{"*/15 * * * *", 15 * time.Minute, false},
+		{"", 0, true},
+		{"fortnightly", 0, true},
+		{"0d", 0, true},
+		{"10s", 0, true},
+		{"*/x * * * *", 0, true},
+		{"a b c d e", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schedule, func(t *testing.T) {
+			got, err := ParseSchedule(tt.schedule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSchedule(%q) error = %v, wantErr %v", tt.schedule, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSchedule(%q) = %v, want %v", tt.schedule, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextDueAndIsOverdue(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		repo        Repository
+		wantDue     time.Time
+		wantOverdue bool
+	}{
+		{
+			name:        "Recent daily backup",
+			repo:        Repository{Schedule: "daily", LastBackup: now.Add(-2 * time.Hour), Status: "healthy"},
+			wantDue:     now.Add(22 * time.Hour),
+			wantOverdue: false,
+		},
+		{
+			name:        "Stale daily backup",
+			repo:        Repository{Schedule: "daily", LastBackup: now.Add(-30 * time.Hour), Status: "healthy"},
+			wantDue:     now.Add(-6 * time.Hour),
+			wantOverdue: true,
+		},
+		{
+			name:        "No schedule",
+			repo:        Repository{LastBackup: now.Add(-1000 * time.Hour), Status: "healthy"},
+			wantOverdue: false,
+		},
+		{
+			name:        "Never backed up",
+			repo:        Repository{Schedule: "weekly", Status: "healthy"},
+			wantOverdue: true,
+		},
+		{
+			name:        "Info failed to load",
+			repo:        Repository{Schedule: "daily", Status: "error"},
+			wantOverdue: false,
+		},
+		{
+			name:        "Invalid schedule",
+			repo:        Repository{Schedule: "sometimes", LastBackup: now.Add(-1000 * time.Hour), Status: "healthy"},
+			wantOverdue: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextDue(tt.repo); !got.Equal(tt.wantDue) {
+				t.Errorf("NextDue() = %v, want %v", got, tt.wantDue)
+			}
+			if got := IsOverdue(tt.repo, now); got != tt.wantOverdue {
+				t.Errorf("IsOverdue() = %v, want %v", got, tt.wantOverdue)
+			}
+		})
+	}
+}
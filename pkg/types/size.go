@@ -0,0 +1,47 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits maps accepted size suffixes to the single-letter unit restic expects
+var sizeUnits = map[string]string{
+	"":    "",
+	"B":   "",
+	"K":   "K",
+	"KB":  "K",
+	"KIB": "K",
+	"M":   "M",
+	"MB":  "M",
+	"MIB": "M",
+	"G":   "G",
+	"GB":  "G",
+	"GIB": "G",
+	"T":   "T",
+	"TB":  "T",
+	"TIB": "T",
+}
+
+// ParseFileSize normalizes a file size such as "100M", "2 GiB" or "512k" into the
+// form restic's --exclude-larger-than accepts ("100M", "2G", "512K"). A plain
+// number is a size in bytes; units are binary and fractions are not supported.
+func ParseFileSize(size string) (string, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	if s == "" {
+		return "", fmt.Errorf("empty size")
+	}
+
+	number := strings.TrimRightFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	unit, ok := sizeUnits[strings.TrimSpace(s[len(number):])]
+	if !ok {
+		return "", fmt.Errorf("invalid size %q (use a number with K, M, G or T, e.g. 100M)", size)
+	}
+
+	n, err := strconv.ParseUint(number, 10, 64)
+	if err != nil || n == 0 {
+		return "", fmt.Errorf("invalid size %q (use a number with K, M, G or T, e.g. 100M)", size)
+	}
+	return strconv.FormatUint(n, 10) + unit, nil
+}
@@ -0,0 +1,38 @@
+package types
+
+import "testing"
+
+func TestParseFileSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    string
+		wantErr bool
+	}{
+		{"100M", "100M", false},
+		{"100m", "100M", false},
+		{" 2 GiB ", "2G", false},
+		{"512KB", "512K", false},
+		{"1T", "1T", false},
+		{"4096", "4096", false},
+		{"4096B", "4096", false},
+		{"", "", true},
+		{"M", "", true},
+		{"0M", "", true},
+		{"1.5G", "", true},
+		{"-5M", "", true},
+		{"100X", "", true},
+		{"large", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			got, err := ParseFileSize(tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFileSize(%q) error = %v, wantErr %v", tt.size, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFileSize(%q) = %q, want %q", tt.size, got, tt.want)
+			}
+		})
+	}
+}
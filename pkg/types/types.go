@@ -8,9 +8,12 @@ type Repository struct {
 	Path          string    // Repository path (local or remote)
 	LastBackup    time.Time // Timestamp of last backup
 	Size          int64     // Total repository size in bytes
+	StoredSize    int64     // Deduplicated size of the data snapshots reference (0 if unknown)
 	TotalFiles    int64     // Total number of files
 	SnapshotCount int       // Number of snapshots
-	Status        string    // "healthy", "warning", "error", "unknown"
+	Status        string    // "healthy", "warning", "locked", "error", "unknown"
+	Group         string    // Display group from the config (empty for ungrouped)
+	Schedule      string    // Expected backup schedule from the config (see ParseSchedule)
 }
 
 // Snapshot represents a restic snapshot
@@ -34,9 +37,48 @@ type SnapshotStats struct {
 type RepositoryStats struct {
 	TotalSize      int64 `json:"total_size"`
 	TotalFileCount int64 `json:"total_file_count"`
+	TotalBlobCount int64 `json:"total_blob_count"` // raw-data mode only
 	SnapshotsCount int   `json:"snapshots_count"`
 }
 
+// DuplicateStats compares the size of every file in a snapshot with the size
+// of their distinct contents (restic stats restore-size vs files-by-contents).
+// The difference is files in the snapshot whose contents repeat another file.
+type DuplicateStats struct {
+	SnapshotID  string // Snapshot measured, the latest in the repository
+	TotalSize   int64
+	TotalFiles  int64
+	UniqueSize  int64
+	UniqueFiles int64
+}
+
+// RepoConfigBlob represents the repository config printed by `restic cat config`.
+// Fields that an older repository format doesn't write are left zero.
+type RepoConfigBlob struct {
+	Version           int    `json:"version"`
+	ID                string `json:"id"`
+	ChunkerPolynomial string `json:"chunker_polynomial"`
+}
+
+// Lock is a repository lock as printed by `restic cat lock`
+type Lock struct {
+	ID        string    `json:"-"`
+	Time      time.Time `json:"time"`
+	Exclusive bool      `json:"exclusive"`
+	Hostname  string    `json:"hostname"`
+	Username  string    `json:"username"`
+	PID       int       `json:"pid"`
+}
+
+// RepoKey is a repository access key as listed by `restic key list --json`
+type RepoKey struct {
+	Current  bool   `json:"current"` // The key this client opened the repository with
+	ID       string `json:"id"`
+	UserName string `json:"userName"`
+	HostName string `json:"hostName"`
+	Created  string `json:"created"` // Local time as printed by restic, e.g. 2024-01-02 15:04:05
+}
+
 // BackupProgress represents the progress of a backup operation
 type BackupProgress struct {
 	MessageType      string   `json:"message_type"`
@@ -67,20 +109,62 @@ type BackupSummary struct {
 	SnapshotID          string `json:"snapshot_id"`
 }
 
+// BackupRecord is a completed backup kept in a repository's backup history
+type BackupRecord struct {
+	SnapshotID   string    `json:"snapshot_id"`
+	Time         time.Time `json:"time"`
+	FilesNew     int64     `json:"files_new"`
+	FilesChanged int64     `json:"files_changed"`
+	DataAdded    int64     `json:"data_added"`
+}
+
+// ExclusionSummary is what a backup's exclude rules skipped, measured as the
+// difference to a dry run of the same paths without them
+type ExclusionSummary struct {
+	ExcludedFiles int64 // Files the dry run processed that the backup did not
+	ExcludedBytes int64 // Size of those files
+}
+
 // BackupOptions represents options for a backup operation
 type BackupOptions struct {
-	Paths   []string
-	Tags    []string
-	Exclude []string
+	Paths         []string
+	Tags          []string
+	Exclude       []string
+	ExcludeFile   string // File with one exclude pattern per line (--exclude-file)
+	PackSize      string // Target pack size in MiB (empty for restic default)
+	MaxFileSize   string // Skip files larger than this size, e.g. "100M" (--exclude-larger-than)
+	DryRun        bool   // Simulate the backup without writing any data (--dry-run)
+	ExcludeCaches bool   // Skip directories marked with CACHEDIR.TAG (--exclude-caches)
+	OneFileSystem bool   // Don't cross filesystem boundaries (--one-file-system)
+}
+
+// InitOptions represents options for initializing a new repository
+type InitOptions struct {
+	PackSize          string // Target pack size in MiB (empty for restic default)
+	RepositoryVersion int    // --repository-version, 2 enables compression (0 for restic default; restic 0.14+)
+	Compression       string // RESTIC_COMPRESSION: auto, max or off (empty for restic default; restic 0.14+)
 }
 
 // RestoreOptions represents options for a restore operation
 type RestoreOptions struct {
-	SnapshotID string
-	Target     string   // Target directory (empty for original location)
-	Include    []string // Specific paths to restore (empty for all)
+	SnapshotID  string
+	Target      string   // Target directory (empty for original location)
+	Include     []string // Specific paths to restore (empty for all)
+	IncludeFile string   // File with one path to restore per line (--include-file, restic 0.17+)
+	Overwrite   string   // --overwrite mode (restic 0.17+); empty for restic's default
 }
 
+// Overwrite modes for restic restore --overwrite
+const (
+	OverwriteAlways    = "always"
+	OverwriteIfChanged = "if-changed"
+	OverwriteIfNewer   = "if-newer"
+	OverwriteNever     = "never"
+)
+
+// OverwriteModes lists the restore overwrite modes, safest default first
+var OverwriteModes = []string{OverwriteIfChanged, OverwriteIfNewer, OverwriteNever, OverwriteAlways}
+
 // RestoreProgress represents the progress of a restore operation
 type RestoreProgress struct {
 	MessageType      string  `json:"message_type"`
@@ -103,17 +187,83 @@ type RestoreSummary struct {
 
 // ResticConfig represents the application configuration
 type ResticConfig struct {
-	Repositories []RepositoryConfig `yaml:"repositories"`
+	Version            int                `yaml:"version,omitempty"` // Config schema version; older configs are migrated on load
+	Repositories       []RepositoryConfig `yaml:"repositories"`
+	MaxConcurrency     int                `yaml:"max_concurrency,omitempty"`      // Repositories loaded in parallel (default 4)
+	AutoRefresh        time.Duration      `yaml:"auto_refresh,omitempty"`         // Periodic dashboard refresh interval (e.g. "5m"); off when zero
+	MinVersion         string             `yaml:"min_restic_version,omitempty"`   // Warn at startup below this restic version (default 0.15.0)
+	PreviewBytes       int64              `yaml:"preview_max_bytes,omitempty"`    // Bytes loaded when previewing a file (default 64 KiB)
+	LogFile            string             `yaml:"log_file,omitempty"`             // Append the operations log to this file (rolled over at 5 MiB)
+	LogEntries         int                `yaml:"log_entries,omitempty"`          // Operations log entries kept in memory (default 100, at most 5000)
+	Keybindings        map[string]string  `yaml:"keybindings,omitempty"`          // Action name to key overrides (e.g. backup: B)
+	Theme              ThemeConfig        `yaml:"theme,omitempty"`                // Color theme and per-color overrides
+	ScanPaths          []string           `yaml:"scan_paths,omitempty"`           // Directories searched by the repository scan (defaults to common mount and home locations)
+	ScanMaxDepth       int                `yaml:"scan_max_depth,omitempty"`       // Directory levels searched below each scan path (default 2)
+	AutoFixPermissions bool               `yaml:"auto_fix_permissions,omitempty"` // chmod password files that are too permissive to 0600 instead of refusing to load
+	LogSnapshotDetails bool               `yaml:"log_snapshot_details,omitempty"` // Also log the selected snapshot's details to the operations panel
+
+	Migrated []string `yaml:"-"` // What loading migrated from an older config version, for the log
+	Repaired []string `yaml:"-"` // Password files whose permissions loading changed to 0600, for the log
+}
+
+// ThemeConfig selects a built-in color theme, optionally overrides
+// individual colors with hex values and sets the snapshot age thresholds
+type ThemeConfig struct {
+	Name     string            `yaml:"name,omitempty"`      // default, high-contrast or light
+	Colors   map[string]string `yaml:"colors,omitempty"`    // Color name to hex override (e.g. primary: "#FF8800")
+	FreshAge time.Duration     `yaml:"fresh_age,omitempty"` // Snapshots younger than this show in the success color (default 24h)
+	StaleAge time.Duration     `yaml:"stale_age,omitempty"` // Snapshots older than this show in the error color (default 168h)
 }
 
 // RepositoryConfig represents a configured repository
 type RepositoryConfig struct {
-	Name            string `yaml:"name"`
-	Path            string `yaml:"path"`
-	PasswordCommand string `yaml:"password_command,omitempty"`
-	PasswordFile    string `yaml:"password_file,omitempty"`
+	Name                 string        `yaml:"name"`
+	Path                 string        `yaml:"path"`
+	PasswordCommand      string        `yaml:"password_command,omitempty"`
+	PasswordFile         string        `yaml:"password_file,omitempty"`
+	PasswordEnv          string        `yaml:"password_env,omitempty"`            // Name of an environment variable holding the password
+	CachePassword        bool          `yaml:"cache_password,omitempty"`          // Run password_command once and reuse the password in memory
+	CachePasswordTTL     time.Duration `yaml:"cache_password_ttl,omitempty"`      // How long a cached password is reused (default 15m)
+	InsecureTLS          bool          `yaml:"insecure_tls,omitempty"`            // Skip TLS certificate verification (rest-server with self-signed certs)
+	CACert               string        `yaml:"cacert,omitempty"`                  // Path to a custom CA certificate for TLS backends
+	Compression          string        `yaml:"compression,omitempty"`             // --compression for backup and prune: auto, max or off (restic 0.14+; empty for restic default)
+	Group                string        `yaml:"group,omitempty"`                   // Display group in the repositories panel (e.g. work, offsite)
+	Schedule             string        `yaml:"schedule,omitempty"`                // Expected backup interval: daily, weekly, 12h, 3d or a cron expression
+	DefaultPaths         []string      `yaml:"default_paths,omitempty"`           // Paths backed up by the quick backup action and pre-filled in the form
+	DefaultTags          []string      `yaml:"default_tags,omitempty"`            // Tags applied to quick backups and pre-filled in the form
+	DefaultExclude       []string      `yaml:"default_exclude,omitempty"`         // Exclude patterns for quick backups and pre-filled in the form
+	DefaultExcludeCaches bool          `yaml:"default_exclude_caches,omitempty"`  // Pass --exclude-caches to quick backups and tick it in the form
+	DefaultOneFileSystem bool          `yaml:"default_one_file_system,omitempty"` // Pass --one-file-system to quick backups and tick it in the form
+	DefaultMaxFileSize   string        `yaml:"default_max_file_size,omitempty"`   // Skip larger files in quick backups and pre-fill the form (e.g. 100M)
+	DefaultRestoreTarget string        `yaml:"default_restore_target,omitempty"`  // Restore location pre-filled in the restore form
 	// Note: Plain-text passwords are no longer supported for security reasons
-	// Use password_file or password_command instead
+	// Use password_file, password_command or password_env instead
+
+	Raw *RepositoryConfig `yaml:"-"` // Path fields as written in the config file, before ~ and $VAR expansion
+}
+
+// BackupDefaults returns the repository's configured backup defaults as
+// options for the backup form, the quick backup action and the backup command
+func (r RepositoryConfig) BackupDefaults() BackupOptions {
+	return BackupOptions{
+		Paths:         r.DefaultPaths,
+		Tags:          r.DefaultTags,
+		Exclude:       r.DefaultExclude,
+		ExcludeCaches: r.DefaultExcludeCaches,
+		OneFileSystem: r.DefaultOneFileSystem,
+		MaxFileSize:   r.DefaultMaxFileSize,
+	}
+}
+
+// RestoreDefaults returns restore options for snapshotID built from the
+// repository's configured defaults and the safest overwrite mode, for the
+// restore form and the restore command
+func (r RepositoryConfig) RestoreDefaults(snapshotID string) RestoreOptions {
+	return RestoreOptions{
+		SnapshotID: snapshotID,
+		Target:     r.DefaultRestoreTarget,
+		Overwrite:  OverwriteModes[0],
+	}
 }
 
 // Panel represents which panel is currently focused
@@ -172,6 +322,15 @@ func (n FileNode) IsFile() bool {
 	return n.Type == "file"
 }
 
+// FindResult represents a file matched by restic find in a snapshot
+type FindResult struct {
+	SnapshotID string
+	Path       string
+	Type       string // "file" or "dir"
+	Size       int64
+	ModTime    time.Time
+}
+
 // ForgetPolicy represents retention policy for snapshots
 type ForgetPolicy struct {
 	KeepLast    int      // Keep the last n snapshots
@@ -1,11 +1,16 @@
 package ui
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/craigderington/lazyrestic/pkg/types"
 )
 
 // BackupFormField represents which field is being edited
@@ -15,17 +20,30 @@ const (
 	BackupFieldPaths BackupFormField = iota
 	BackupFieldTags
 	BackupFieldExclude
+	BackupFieldExcludeFile
+	BackupFieldMaxFileSize
+	BackupFieldPackSize
+	BackupFieldExcludeCaches
+	BackupFieldOneFileSystem
+	BackupFieldDryRun
 	BackupFieldSubmit
 )
 
 // BackupForm represents a form for configuring a backup operation
 type BackupForm struct {
-	pathsInput   textinput.Model
-	tagsInput    textinput.Model
-	excludeInput textinput.Model
-	focusedField BackupFormField
-	width        int
-	height       int
+	pathsInput       textinput.Model
+	tagsInput        textinput.Model
+	excludeInput     textinput.Model
+	excludeFileInput textinput.Model
+	maxFileSizeInput textinput.Model
+	packSizeInput    textinput.Model
+	excludeCaches    bool
+	oneFileSystem    bool
+	dryRun           bool
+	focusedField     BackupFormField
+	completer        pathCompleter
+	width            int
+	height           int
 }
 
 // NewBackupForm creates a new backup configuration form
@@ -43,14 +61,40 @@ func NewBackupForm() *BackupForm {
 	excludeInput.Placeholder = "*.tmp, *.cache (optional)"
 	excludeInput.CharLimit = 200
 
+	excludeFileInput := textinput.New()
+	excludeFileInput.Placeholder = "~/.config/restic/excludes.txt (optional)"
+	excludeFileInput.CharLimit = 500
+
+	maxFileSizeInput := textinput.New()
+	maxFileSizeInput.Placeholder = "100M (optional, skip larger files)"
+	maxFileSizeInput.CharLimit = 10
+
+	packSizeInput := textinput.New()
+	packSizeInput.Placeholder = "64M (optional, 4-128 MiB)"
+	packSizeInput.CharLimit = 10
+
 	return &BackupForm{
-		pathsInput:   pathsInput,
-		tagsInput:    tagsInput,
-		excludeInput: excludeInput,
-		focusedField: BackupFieldPaths,
+		pathsInput:       pathsInput,
+		tagsInput:        tagsInput,
+		excludeInput:     excludeInput,
+		excludeFileInput: excludeFileInput,
+		maxFileSizeInput: maxFileSizeInput,
+		packSizeInput:    packSizeInput,
+		focusedField:     BackupFieldPaths,
 	}
 }
 
+// SetDefaults fills the paths, tags, exclude, size limit and checkbox fields with a
+// repository's configured defaults, replacing whatever was entered before
+func (f *BackupForm) SetDefaults(defaults types.BackupOptions) {
+	f.pathsInput.SetValue(strings.Join(defaults.Paths, ", "))
+	f.tagsInput.SetValue(strings.Join(defaults.Tags, ", "))
+	f.excludeInput.SetValue(strings.Join(defaults.Exclude, ", "))
+	f.maxFileSizeInput.SetValue(defaults.MaxFileSize)
+	f.excludeCaches = defaults.ExcludeCaches
+	f.oneFileSystem = defaults.OneFileSystem
+}
+
 // Update handles form input
 func (f *BackupForm) Update(msg tea.Msg) tea.Cmd {
 	var cmd tea.Cmd
@@ -58,13 +102,21 @@ func (f *BackupForm) Update(msg tea.Msg) tea.Cmd {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "tab", "down":
+		case "tab":
+			// Tab at the end of a path completes it before moving on
+			if input := f.pathField(); input != nil && f.completer.complete(input) {
+				return nil
+			}
+			f.NextField()
+			return nil
+		case "down":
 			f.NextField()
 			return nil
 		case "shift+tab", "up":
 			f.PrevField()
 			return nil
 		}
+		f.completer.reset()
 	}
 
 	// Update the focused input
@@ -75,13 +127,43 @@ func (f *BackupForm) Update(msg tea.Msg) tea.Cmd {
 		f.tagsInput, cmd = f.tagsInput.Update(msg)
 	case BackupFieldExclude:
 		f.excludeInput, cmd = f.excludeInput.Update(msg)
+	case BackupFieldExcludeFile:
+		f.excludeFileInput, cmd = f.excludeFileInput.Update(msg)
+	case BackupFieldMaxFileSize:
+		f.maxFileSizeInput, cmd = f.maxFileSizeInput.Update(msg)
+	case BackupFieldPackSize:
+		f.packSizeInput, cmd = f.packSizeInput.Update(msg)
+	case BackupFieldExcludeCaches:
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == " " {
+			f.excludeCaches = !f.excludeCaches
+		}
+	case BackupFieldOneFileSystem:
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == " " {
+			f.oneFileSystem = !f.oneFileSystem
+		}
+	case BackupFieldDryRun:
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == " " {
+			f.dryRun = !f.dryRun
+		}
 	}
 
 	return cmd
 }
 
+// pathField returns the focused input if it holds filesystem paths
+func (f *BackupForm) pathField() *textinput.Model {
+	switch f.focusedField {
+	case BackupFieldPaths:
+		return &f.pathsInput
+	case BackupFieldExcludeFile:
+		return &f.excludeFileInput
+	}
+	return nil
+}
+
 // NextField moves to the next form field
 func (f *BackupForm) NextField() {
+	f.completer.reset()
 	f.BlurAll()
 
 	f.focusedField++
@@ -94,6 +176,7 @@ func (f *BackupForm) NextField() {
 
 // PrevField moves to the previous form field
 func (f *BackupForm) PrevField() {
+	f.completer.reset()
 	f.BlurAll()
 
 	f.focusedField--
@@ -109,6 +192,9 @@ func (f *BackupForm) BlurAll() {
 	f.pathsInput.Blur()
 	f.tagsInput.Blur()
 	f.excludeInput.Blur()
+	f.excludeFileInput.Blur()
+	f.maxFileSizeInput.Blur()
+	f.packSizeInput.Blur()
 }
 
 // FocusCurrent focuses the current field
@@ -120,6 +206,12 @@ func (f *BackupForm) FocusCurrent() {
 		f.tagsInput.Focus()
 	case BackupFieldExclude:
 		f.excludeInput.Focus()
+	case BackupFieldExcludeFile:
+		f.excludeFileInput.Focus()
+	case BackupFieldMaxFileSize:
+		f.maxFileSizeInput.Focus()
+	case BackupFieldPackSize:
+		f.packSizeInput.Focus()
 	}
 }
 
@@ -174,9 +266,114 @@ func (f *BackupForm) GetExclude() []string {
 	return trimmedExcludes
 }
 
+// GetExcludeFile returns the exclude file path with ~ expanded, or empty if not set
+func (f *BackupForm) GetExcludeFile() string {
+	path := strings.TrimSpace(f.excludeFileInput.Value())
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+// GetMaxFileSize returns the normalized size above which files are skipped,
+// or empty when no limit is set
+func (f *BackupForm) GetMaxFileSize() string {
+	size, err := types.ParseFileSize(f.maxFileSizeInput.Value())
+	if err != nil {
+		return ""
+	}
+	return size
+}
+
+// GetPackSize returns the pack size in MiB, or empty for restic's default
+func (f *BackupForm) GetPackSize() string {
+	size, err := parsePackSize(f.packSizeInput.Value())
+	if err != nil || size == 0 {
+		return ""
+	}
+	return strconv.Itoa(size)
+}
+
+// ExcludeCaches returns whether directories tagged with CACHEDIR.TAG are skipped
+func (f *BackupForm) ExcludeCaches() bool {
+	return f.excludeCaches
+}
+
+// OneFileSystem returns whether the backup stays on the paths' filesystems
+func (f *BackupForm) OneFileSystem() bool {
+	return f.oneFileSystem
+}
+
+// IsDryRun returns whether the backup should only be simulated
+func (f *BackupForm) IsDryRun() bool {
+	return f.dryRun
+}
+
+// Validate checks the form and returns the first problem found
+func (f *BackupForm) Validate() error {
+	if _, err := parsePackSize(f.packSizeInput.Value()); err != nil {
+		return err
+	}
+	if err := f.validateMaxFileSize(); err != nil {
+		return err
+	}
+
+	if err := validatePatterns("exclude", f.GetExclude()); err != nil {
+		return err
+	}
+
+	if excludeFile := f.GetExcludeFile(); excludeFile != "" {
+		info, err := os.Stat(excludeFile)
+		if err != nil {
+			return fmt.Errorf("exclude file not found: %s", excludeFile)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("exclude file is a directory: %s", excludeFile)
+		}
+	}
+
+	paths := f.GetPaths()
+	if len(paths) == 0 {
+		return fmt.Errorf("no paths to back up")
+	}
+
+	// At least one path must exist, otherwise the backup would be a no-op
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("none of the paths exist: %s", strings.Join(paths, ", "))
+}
+
+// validateMaxFileSize checks the large file threshold, which may be left empty
+func (f *BackupForm) validateMaxFileSize() error {
+	if strings.TrimSpace(f.maxFileSizeInput.Value()) == "" {
+		return nil
+	}
+	if _, err := types.ParseFileSize(f.maxFileSizeInput.Value()); err != nil {
+		return fmt.Errorf("max file size: %w", err)
+	}
+	return nil
+}
+
+// validatePatterns checks the glob syntax of patterns restic matches paths
+// against, so a malformed pattern is caught before restic rejects it. kind
+// names the patterns in the error, e.g. "exclude".
+func validatePatterns(kind string, patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(strings.TrimPrefix(pattern, "!"), ""); err != nil {
+			return fmt.Errorf("invalid %s pattern %q: check for an unclosed [ or a trailing \\", kind, pattern)
+		}
+	}
+	return nil
+}
+
 // IsValid checks if the form is valid
 func (f *BackupForm) IsValid() bool {
-	return len(f.GetPaths()) > 0
+	return f.Validate() == nil
 }
 
 // SetSize sets the form dimensions
@@ -186,6 +383,9 @@ func (f *BackupForm) SetSize(width, height int) {
 	f.pathsInput.Width = width - 20
 	f.tagsInput.Width = width - 20
 	f.excludeInput.Width = width - 20
+	f.excludeFileInput.Width = width - 20
+	f.maxFileSizeInput.Width = width - 20
+	f.packSizeInput.Width = width - 20
 }
 
 // Render renders the form
@@ -194,21 +394,24 @@ func (f *BackupForm) Render() string {
 
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("86")).
+		Foreground(colorPrimary).
 		Padding(0, 1)
 
 	labelStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(colorDimmed).
 		Width(20)
 
 	focusedStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("86")).
+		Foreground(colorPrimary).
 		Bold(true)
 
 	helpStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(colorDimmed).
 		Padding(1, 0)
 
+	hintStyle := lipgloss.NewStyle().
+		Foreground(colorDimmed)
+
 	title := titleStyle.Render("Configure Backup")
 	b.WriteString(title + "\n\n")
 
@@ -218,7 +421,11 @@ func (f *BackupForm) Render() string {
 		pathsLabel = focusedStyle.Render("▶ Paths to Backup:")
 	}
 	b.WriteString(pathsLabel + "\n")
-	b.WriteString(f.pathsInput.View() + "\n\n")
+	b.WriteString(f.pathsInput.View() + "\n")
+	if f.focusedField == BackupFieldPaths {
+		b.WriteString(f.completer.view())
+	}
+	b.WriteString("\n")
 
 	// Tags field
 	tagsLabel := labelStyle.Render("Tags:")
@@ -236,27 +443,103 @@ func (f *BackupForm) Render() string {
 	b.WriteString(excludeLabel + "\n")
 	b.WriteString(f.excludeInput.View() + "\n\n")
 
+	// Exclude file field
+	excludeFileLabel := labelStyle.Render("Exclude File:")
+	if f.focusedField == BackupFieldExcludeFile {
+		excludeFileLabel = focusedStyle.Render("▶ Exclude File:")
+	}
+	b.WriteString(excludeFileLabel + "\n")
+	b.WriteString(f.excludeFileInput.View() + "\n")
+	if f.focusedField == BackupFieldExcludeFile {
+		b.WriteString(f.completer.view())
+	}
+	b.WriteString("\n")
+
+	// Max file size field
+	maxFileSizeLabel := labelStyle.Render("Max File Size:")
+	if f.focusedField == BackupFieldMaxFileSize {
+		maxFileSizeLabel = focusedStyle.Render("▶ Max File Size:")
+	}
+	b.WriteString(maxFileSizeLabel + "\n")
+	b.WriteString(f.maxFileSizeInput.View() + "\n\n")
+
+	// Pack size field (advanced)
+	packSizeLabel := labelStyle.Render("Advanced: Pack Size")
+	if f.focusedField == BackupFieldPackSize {
+		packSizeLabel = focusedStyle.Render("▶ Advanced: Pack Size")
+	}
+	b.WriteString(packSizeLabel + "\n")
+	b.WriteString(f.packSizeInput.View() + "\n\n")
+
+	// Exclude caches option
+	excludeCachesLabel := "  [ ] Exclude cache directories (--exclude-caches)"
+	if f.excludeCaches {
+		excludeCachesLabel = "  [✓] Exclude cache directories (--exclude-caches)"
+	}
+	if f.focusedField == BackupFieldExcludeCaches {
+		excludeCachesLabel = focusedStyle.Render("▶ " + excludeCachesLabel)
+	}
+	b.WriteString(excludeCachesLabel + "\n")
+
+	// One file system option
+	oneFileSystemLabel := "  [ ] Stay on one file system (--one-file-system)"
+	if f.oneFileSystem {
+		oneFileSystemLabel = "  [✓] Stay on one file system (--one-file-system)"
+	}
+	if f.focusedField == BackupFieldOneFileSystem {
+		oneFileSystemLabel = focusedStyle.Render("▶ " + oneFileSystemLabel)
+	}
+	b.WriteString(oneFileSystemLabel + "\n")
+
+	// Dry run option
+	dryRunLabel := "  [ ] Dry run (preview only, no data written)"
+	if f.dryRun {
+		dryRunLabel = "  [✓] Dry run (preview only, no data written)"
+	}
+	if f.focusedField == BackupFieldDryRun {
+		dryRunLabel = focusedStyle.Render("▶ " + dryRunLabel)
+	}
+	b.WriteString(dryRunLabel + "\n")
+	switch f.focusedField {
+	case BackupFieldExcludeCaches, BackupFieldOneFileSystem, BackupFieldDryRun:
+		b.WriteString(hintStyle.Render("  Press space to toggle") + "\n")
+	}
+	b.WriteString("\n")
+
 	// Submit button
-	submitLabel := "  [ Start Backup ]"
+	action := "Start Backup"
+	if f.dryRun {
+		action = "Preview Backup"
+	}
+	submitLabel := "  [ " + action + " ]"
 	if f.focusedField == BackupFieldSubmit {
-		submitLabel = focusedStyle.Render("▶ [ Start Backup ]")
+		submitLabel = focusedStyle.Render("▶ [ " + action + " ]")
 	}
 	b.WriteString(submitLabel + "\n\n")
 
 	// Help text
-	help := "Tab/↑↓: Navigate • Enter: Start Backup • Esc: Cancel"
+	help := "Tab/↑↓: Navigate • Enter: " + action + " • Esc: Cancel"
 	b.WriteString(helpStyle.Render(help))
 
 	// Validation message
-	if !f.IsValid() && f.focusedField == BackupFieldSubmit {
-		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
-		b.WriteString("\n" + errorStyle.Render("⚠ At least one path is required"))
+	if _, err := parsePackSize(f.packSizeInput.Value()); err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(colorError)
+		b.WriteString("\n" + errorStyle.Render("⚠ "+err.Error()))
+	} else if err := f.validateMaxFileSize(); err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(colorError)
+		b.WriteString("\n" + errorStyle.Render("⚠ "+err.Error()))
+	} else if err := validatePatterns("exclude", f.GetExclude()); err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(colorError)
+		b.WriteString("\n" + errorStyle.Render("⚠ "+err.Error()))
+	} else if err := f.Validate(); err != nil && f.focusedField == BackupFieldSubmit {
+		errorStyle := lipgloss.NewStyle().Foreground(colorError)
+		b.WriteString("\n" + errorStyle.Render("⚠ "+err.Error()))
 	}
 
 	// Wrap in border
 	borderStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("86")).
+		BorderForeground(colorPrimary).
 		Padding(1, 2).
 		Width(f.width - 4)
 
@@ -1,9 +1,14 @@
 package ui
 
 import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/types"
 )
 
 func TestBackupFormCreation(t *testing.T) {
@@ -27,13 +32,41 @@ func TestBackupFormValidation(t *testing.T) {
 	}
 
 	// Simulate entering a path
-	form.pathsInput.SetValue("/home/user")
+	form.pathsInput.SetValue(t.TempDir())
 
 	if !form.IsValid() {
 		t.Error("Form should be valid with at least one path")
 	}
 }
 
+func TestBackupFormValidation_EmptyAfterTrimming(t *testing.T) {
+	existing := t.TempDir()
+
+	tests := []struct {
+		name  string
+		input string
+		valid bool
+	}{
+		{"whitespace only", "   ", false},
+		{"commas only", ",,,", false},
+		{"commas and spaces", " , ,  , ", false},
+		{"nonexistent path", "/nonexistent/lazyrestic/path", false},
+		{"existing path among blanks", " , " + existing + " ,", true},
+		{"existing and missing path", existing + ", /nonexistent/lazyrestic/path", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := NewBackupForm()
+			form.pathsInput.SetValue(tt.input)
+
+			if form.IsValid() != tt.valid {
+				t.Errorf("IsValid() = %v, want %v (err: %v)", form.IsValid(), tt.valid, form.Validate())
+			}
+		})
+	}
+}
+
 func TestBackupFormGetPaths(t *testing.T) {
 	form := NewBackupForm()
 
@@ -127,6 +160,213 @@ func TestBackupFormGetTags(t *testing.T) {
 	}
 }
 
+func TestBackupFormSetDefaults(t *testing.T) {
+	form := NewBackupForm()
+	form.pathsInput.SetValue("/typed/before")
+
+	form.SetDefaults(types.BackupOptions{
+		Paths:         []string{"/home/user", "/etc"},
+		Tags:          []string{"nightly"},
+		Exclude:       []string{"*.tmp", ".cache"},
+		OneFileSystem: true,
+		MaxFileSize:   "100M",
+	})
+
+	if got := strings.Join(form.GetPaths(), "|"); got != "/home/user|/etc" {
+		t.Errorf("GetPaths() = %q, want the defaults", got)
+	}
+	if got := strings.Join(form.GetTags(), "|"); got != "nightly" {
+		t.Errorf("GetTags() = %q, want the defaults", got)
+	}
+	if got := strings.Join(form.GetExclude(), "|"); got != "*.tmp|.cache" {
+		t.Errorf("GetExclude() = %q, want the defaults", got)
+	}
+	if got := form.GetMaxFileSize(); got != "100M" {
+		t.Errorf("GetMaxFileSize() = %q, want the default", got)
+	}
+	if form.ExcludeCaches() || !form.OneFileSystem() {
+		t.Errorf("Checkboxes = exclude-caches %v, one-file-system %v, want the defaults", form.ExcludeCaches(), form.OneFileSystem())
+	}
+}
+
+func TestBackupFormFilesystemToggles(t *testing.T) {
+	form := NewBackupForm()
+	if form.ExcludeCaches() || form.OneFileSystem() {
+		t.Fatal("Filesystem options should be off by default")
+	}
+
+	form.focusedField = BackupFieldExcludeCaches
+	form.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	if !form.ExcludeCaches() || form.OneFileSystem() || form.IsDryRun() {
+		t.Error("Space should only toggle the focused exclude caches field")
+	}
+
+	form.NextField()
+	form.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	if !form.OneFileSystem() {
+		t.Error("Space on the one file system field should enable it")
+	}
+	if !strings.Contains(form.Render(), "[✓] Stay on one file system") {
+		t.Error("Render() should show the ticked one file system option")
+	}
+}
+
+func TestParsePackSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+		wantErr  bool
+	}{
+		{"", 0, false},
+		{"64", 64, false},
+		{"128M", 128, false},
+		{"16MiB", 16, false},
+		{" 32 mb ", 32, false},
+		{"2", 0, true},
+		{"256M", 0, true},
+		{"1G", 0, true},
+		{"abc", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			size, err := parsePackSize(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePackSize(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if size != tt.expected {
+				t.Errorf("parsePackSize(%q) = %d, want %d", tt.input, size, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBackupFormPackSize(t *testing.T) {
+	form := NewBackupForm()
+	form.pathsInput.SetValue(t.TempDir())
+
+	if form.GetPackSize() != "" {
+		t.Errorf("Expected empty pack size by default, got %q", form.GetPackSize())
+	}
+
+	form.packSizeInput.SetValue("128M")
+	if form.GetPackSize() != "128" {
+		t.Errorf("GetPackSize() = %q, want 128", form.GetPackSize())
+	}
+	if !form.IsValid() {
+		t.Error("Form should be valid with a well-formed pack size")
+	}
+
+	form.packSizeInput.SetValue("1G")
+	if form.IsValid() {
+		t.Error("Form should be invalid with an out-of-range pack size")
+	}
+}
+
+func TestBackupFormMaxFileSize(t *testing.T) {
+	form := NewBackupForm()
+	form.pathsInput.SetValue(t.TempDir())
+
+	if form.GetMaxFileSize() != "" {
+		t.Errorf("Expected no size limit by default, got %q", form.GetMaxFileSize())
+	}
+
+	form.maxFileSizeInput.SetValue("100mb")
+	if form.GetMaxFileSize() != "100M" {
+		t.Errorf("GetMaxFileSize() = %q, want 100M", form.GetMaxFileSize())
+	}
+	if !form.IsValid() {
+		t.Error("Form should be valid with a well-formed max file size")
+	}
+
+	form.maxFileSizeInput.SetValue("1.5G")
+	if form.IsValid() {
+		t.Error("Form should be invalid with a malformed max file size")
+	}
+	if form.GetMaxFileSize() != "" {
+		t.Errorf("GetMaxFileSize() = %q, want empty for an invalid size", form.GetMaxFileSize())
+	}
+}
+
+func TestBackupFormExcludeFile(t *testing.T) {
+	form := NewBackupForm()
+	form.pathsInput.SetValue(t.TempDir())
+
+	if form.GetExcludeFile() != "" {
+		t.Errorf("Expected empty exclude file by default, got %q", form.GetExcludeFile())
+	}
+
+	excludeFile := filepath.Join(t.TempDir(), "excludes.txt")
+	if err := os.WriteFile(excludeFile, []byte("*.tmp\nnode_modules\n"), 0644); err != nil {
+		t.Fatalf("Failed to write exclude file: %v", err)
+	}
+
+	form.excludeFileInput.SetValue("  " + excludeFile + " ")
+	if form.GetExcludeFile() != excludeFile {
+		t.Errorf("GetExcludeFile() = %q, want %q", form.GetExcludeFile(), excludeFile)
+	}
+	if !form.IsValid() {
+		t.Errorf("Form should be valid with an existing exclude file: %v", form.Validate())
+	}
+
+	// Inline excludes keep working alongside the file
+	form.excludeInput.SetValue("*.log")
+	if len(form.GetExclude()) != 1 || !form.IsValid() {
+		t.Error("Inline excludes should be usable together with an exclude file")
+	}
+
+	form.excludeFileInput.SetValue(filepath.Join(t.TempDir(), "missing.txt"))
+	if form.IsValid() {
+		t.Error("Form should be invalid when the exclude file does not exist")
+	}
+
+	form.excludeFileInput.SetValue(t.TempDir())
+	if form.IsValid() {
+		t.Error("Form should be invalid when the exclude file is a directory")
+	}
+}
+
+func TestBackupFormExcludeFile_ExpandsHome(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	form := NewBackupForm()
+	form.excludeFileInput.SetValue("~/excludes.txt")
+
+	if want := filepath.Join(home, "excludes.txt"); form.GetExcludeFile() != want {
+		t.Errorf("GetExcludeFile() = %q, want %q", form.GetExcludeFile(), want)
+	}
+}
+
+func TestBackupFormDryRunToggle(t *testing.T) {
+	form := NewBackupForm()
+	if form.IsDryRun() {
+		t.Fatal("Dry run should be off by default")
+	}
+
+	// Space only toggles when the dry run field is focused
+	form.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	if form.IsDryRun() {
+		t.Error("Space in the paths field should not toggle dry run")
+	}
+
+	form.focusedField = BackupFieldDryRun
+	form.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	if !form.IsDryRun() {
+		t.Error("Space on the dry run field should enable dry run")
+	}
+	if !strings.Contains(form.Render(), "Preview Backup") {
+		t.Error("Submit button should read Preview Backup when dry run is enabled")
+	}
+
+	form.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	if form.IsDryRun() {
+		t.Error("Second space should disable dry run")
+	}
+}
+
 func TestBackupFormNavigation(t *testing.T) {
 	form := NewBackupForm()
 
@@ -146,6 +386,35 @@ func TestBackupFormNavigation(t *testing.T) {
 		t.Errorf("Expected BackupFieldExclude after NextField(), got %v", form.focusedField)
 	}
 
+	form.NextField()
+	if form.focusedField != BackupFieldExcludeFile {
+		t.Errorf("Expected BackupFieldExcludeFile after NextField(), got %v", form.focusedField)
+	}
+	form.NextField()
+	if form.focusedField != BackupFieldMaxFileSize {
+		t.Errorf("Expected BackupFieldMaxFileSize after NextField(), got %v", form.focusedField)
+	}
+
+	form.NextField()
+	if form.focusedField != BackupFieldPackSize {
+		t.Errorf("Expected BackupFieldPackSize after NextField(), got %v", form.focusedField)
+	}
+
+	form.NextField()
+	if form.focusedField != BackupFieldExcludeCaches {
+		t.Errorf("Expected BackupFieldExcludeCaches after NextField(), got %v", form.focusedField)
+	}
+
+	form.NextField()
+	if form.focusedField != BackupFieldOneFileSystem {
+		t.Errorf("Expected BackupFieldOneFileSystem after NextField(), got %v", form.focusedField)
+	}
+
+	form.NextField()
+	if form.focusedField != BackupFieldDryRun {
+		t.Errorf("Expected BackupFieldDryRun after NextField(), got %v", form.focusedField)
+	}
+
 	form.NextField()
 	if form.focusedField != BackupFieldSubmit {
 		t.Errorf("Expected BackupFieldSubmit after NextField(), got %v", form.focusedField)
@@ -214,3 +483,37 @@ func TestBackupFormRender(t *testing.T) {
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && (s[:len(substr)] == substr || contains(s[1:], substr)))
 }
+
+func TestBackupFormValidation_ExcludePatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		exclude string
+		bad     string // Pattern the error should name; empty when valid
+	}{
+		{"plain globs", "*.tmp, node_modules, **/.cache", ""},
+		{"character class", "*.[ch], log[0-9]*", ""},
+		{"negated pattern", "*.log, !keep.log", ""},
+		{"unclosed bracket", "*.tmp, cache[0-9", "cache[0-9"},
+		{"trailing backslash", `*.bak\`, `*.bak\`},
+		{"bad negated pattern", "![abc", "![abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := NewBackupForm()
+			form.pathsInput.SetValue(t.TempDir())
+			form.excludeInput.SetValue(tt.exclude)
+
+			err := form.Validate()
+			if tt.bad == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want valid", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "invalid exclude pattern") || !strings.Contains(err.Error(), strconv.Quote(tt.bad)) {
+				t.Errorf("Validate() = %v, want an error naming %q", err, tt.bad)
+			}
+		})
+	}
+}
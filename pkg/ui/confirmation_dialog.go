@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -16,6 +17,8 @@ type ConfirmationDialog struct {
 	input            textinput.Model
 	width            int
 	height           int
+	timeoutSeconds   int // Cancel after this many seconds without input (0 = never)
+	activity         int // Bumped on every key press and Reset, to spot stale timeouts
 }
 
 // NewConfirmationDialog creates a new confirmation dialog
@@ -36,11 +39,37 @@ func NewConfirmationDialog(title, message, confirmationWord string) *Confirmatio
 
 // Update handles input events
 func (cd *ConfirmationDialog) Update(msg tea.Msg) tea.Cmd {
+	if _, ok := msg.(tea.KeyMsg); ok {
+		cd.activity++
+	}
 	var cmd tea.Cmd
 	cd.input, cmd = cd.input.Update(msg)
 	return cmd
 }
 
+// Reset clears the typed text so a reused dialog starts empty
+func (cd *ConfirmationDialog) Reset() {
+	cd.input.Reset()
+	cd.activity++
+}
+
+// SetTimeoutSeconds sets how long the dialog may sit without input before it
+// is cancelled. Zero disables the timeout.
+func (cd *ConfirmationDialog) SetTimeoutSeconds(seconds int) {
+	cd.timeoutSeconds = seconds
+}
+
+// TimeoutSeconds returns the inactivity timeout (0 when disabled)
+func (cd *ConfirmationDialog) TimeoutSeconds() int {
+	return cd.timeoutSeconds
+}
+
+// Activity returns a counter that changes whenever the user types in the
+// dialog; a timeout scheduled at an older value is stale
+func (cd *ConfirmationDialog) Activity() int {
+	return cd.activity
+}
+
 // IsConfirmed returns true if the user typed the correct confirmation word
 func (cd *ConfirmationDialog) IsConfirmed() bool {
 	return cd.input.Value() == cd.confirmationWord
@@ -64,33 +93,32 @@ func (cd *ConfirmationDialog) Render() string {
 
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("15")).
-		Background(lipgloss.Color("196")). // Bright red
+		Foreground(colorBlack).
+		Background(colorError).
 		Padding(0, 2)
 
 	warningStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("208")).
+		Foreground(colorWarning).
 		Bold(true).
 		Padding(1, 2)
 
 	messageStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("255")).
 		Padding(1, 0).
 		Width(cd.width - 10)
 
 	labelStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("214")).
+		Foreground(colorWarning).
 		Bold(true).
 		MarginTop(1)
 
 	inputStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("196")).
+		BorderForeground(colorError).
 		Padding(0, 1).
 		MarginTop(1)
 
 	helpStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(colorDimmed).
 		Italic(true).
 		MarginTop(1)
 
@@ -113,12 +141,12 @@ func (cd *ConfirmationDialog) Render() string {
 	// Show if input matches (visual feedback)
 	if cd.IsConfirmed() {
 		correctStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("40")).
+			Foreground(colorSuccess).
 			Bold(true)
 		inputView = correctStyle.Render("✓ " + inputView)
 	} else if len(cd.input.Value()) > 0 {
 		wrongStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("196"))
+			Foreground(colorError)
 		inputView = wrongStyle.Render("✗ " + inputView)
 	}
 
@@ -127,18 +155,21 @@ func (cd *ConfirmationDialog) Render() string {
 	// Help text
 	if cd.IsConfirmed() {
 		confirmHelpStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("40")).
+			Foreground(colorSuccess).
 			Bold(true).
 			MarginTop(1)
 		b.WriteString(confirmHelpStyle.Render("✓ Press Enter to execute • Esc to cancel") + "\n")
 	} else {
 		b.WriteString(helpStyle.Render("Type the word above, then press Enter • Esc to cancel") + "\n")
 	}
+	if cd.timeoutSeconds > 0 {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("Cancels automatically after %ds without input", cd.timeoutSeconds)) + "\n")
+	}
 
 	// Border
 	boxStyle := lipgloss.NewStyle().
 		Border(lipgloss.DoubleBorder()).
-		BorderForeground(lipgloss.Color("196")).
+		BorderForeground(colorError).
 		Padding(2, 3).
 		Width(cd.width - 4)
 
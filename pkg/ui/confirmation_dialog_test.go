@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestConfirmationDialog_Reset(t *testing.T) {
+	dialog := NewConfirmationDialog("REMOVE", "Remove it?", "yes")
+	for _, r := range "yes" {
+		dialog.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if !dialog.IsConfirmed() {
+		t.Fatal("Typing the confirmation word should confirm the dialog")
+	}
+
+	before := dialog.Activity()
+	dialog.Reset()
+	if dialog.GetInput() != "" || dialog.IsConfirmed() {
+		t.Errorf("Reset() should clear the input, got %q", dialog.GetInput())
+	}
+	if dialog.Activity() == before {
+		t.Error("Reset() should count as activity so pending timeouts go stale")
+	}
+}
+
+func TestConfirmationDialog_TimeoutHint(t *testing.T) {
+	dialog := NewConfirmationDialog("REMOVE", "Remove it?", "yes")
+	dialog.SetSize(80, 30)
+	if strings.Contains(dialog.Render(), "Cancels automatically") {
+		t.Error("Dialog without a timeout should not mention one")
+	}
+
+	dialog.SetTimeoutSeconds(30)
+	if !strings.Contains(dialog.Render(), "after 30s without input") {
+		t.Error("Dialog with a timeout should say when it cancels")
+	}
+}
@@ -19,8 +19,9 @@ const (
 
 // UI spacing
 const (
-	TitleAndHelpHeight = 4  // Space reserved for title and help text
-	MaxLogEntries      = 100
+	TitleAndHelpHeight = 4    // Space reserved for title and help text
+	MaxLogEntries      = 100  // Operations log entries kept in memory by default
+	LogEntriesLimit    = 5000 // Most operations log entries log_entries can keep in memory
 	MaxPathDisplayLen  = 50
 )
 
@@ -49,9 +50,13 @@ const (
 	StatusHealthy = "healthy"
 	StatusWarning = "warning"
 	StatusError   = "error"
+	StatusLocked  = "locked"
 	StatusPending = "pending"
 )
 
+// OverdueBadge marks repositories whose last backup is older than their schedule
+const OverdueBadge = "⏰ overdue"
+
 // Color scheme (muted, easy on the eyes)
 const (
 	ColorSuccess      = "#00AA00"
@@ -64,3 +69,6 @@ const (
 	ColorBorder       = "#444444"
 	ColorBorderActive = "#00CCAA"
 )
+
+// SpinnerFrames animate the indicator shown while a long operation runs
+var SpinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
@@ -3,25 +3,70 @@ package ui
 import (
 	"fmt"
 	"path"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/craigderington/lazyrestic/pkg/types"
 )
 
+// FileSortMode is the order entries are listed in the file browser
+type FileSortMode int
+
+const (
+	SortDirsFirst FileSortMode = iota // Directories before files, each by name
+	SortByName                        // By name, directories mixed in
+	SortBySize                        // Largest first
+	SortByModTime                     // Most recently modified first
+)
+
+// fileSortModeNames are the labels shown for each FileSortMode
+var fileSortModeNames = []string{"dirs first", "name", "size", "modified"}
+
+// String returns the label of the sort mode
+func (s FileSortMode) String() string {
+	if s < 0 || int(s) >= len(fileSortModeNames) {
+		return "unknown"
+	}
+	return fileSortModeNames[s]
+}
+
 // FileBrowser represents the file browser panel for browsing snapshot contents
 type FileBrowser struct {
 	snapshot    *types.Snapshot  // The snapshot being browsed
 	currentPath string           // Current directory path
-	files       []types.FileNode // Files in current directory
-	selected    int              // Selected file index
+	allFiles    []types.FileNode // Listing of the current directory as loaded
+	files       []types.FileNode // Visible files in current directory, sorted
+	selected    int              // Selected index within the current page
 	width       int
 	height      int
 	multiSelect bool // Enable multi-selection mode
 
+	// Listing options
+	sortMode   FileSortMode
+	hideHidden bool            // Hide dotfiles
+	marked     map[string]bool // Multi-select marks by path, kept across re-sorts
+
 	// Pagination
 	pageSize    int // Number of files per page
 	currentPage int // Current page (0-based)
+
+	// Streamed listing: only a window of a huge directory is held at once
+	loading bool // Entries are still arriving
+	offset  int  // Position of the first held entry in the full listing
+	more    bool // The listing continues past the held entries
+
+	// Content preview (nil when closed)
+	preview *filePreview
+}
+
+// filePreview holds the loaded head of a file shown in the preview modal
+type filePreview struct {
+	name      string
+	size      int64 // Full file size from the snapshot listing
+	lines     []string
+	truncated bool
+	offset    int // First visible line
 }
 
 // NewFileBrowser creates a new file browser for a snapshot
@@ -30,6 +75,7 @@ func NewFileBrowser(snapshot *types.Snapshot) *FileBrowser {
 		snapshot:    snapshot,
 		currentPath: "/",
 		files:       []types.FileNode{},
+		marked:      make(map[string]bool),
 		selected:    0,
 		multiSelect: true,
 		pageSize:    50, // Show 50 files per page
@@ -39,7 +85,14 @@ func NewFileBrowser(snapshot *types.Snapshot) *FileBrowser {
 
 // SetFiles updates the list of files for the current directory
 func (fb *FileBrowser) SetFiles(files []types.FileNode) {
-	fb.files = files
+	fb.allFiles = files
+	fb.marked = make(map[string]bool)
+	for _, file := range files {
+		if file.Selected {
+			fb.marked[file.Path] = true
+		}
+	}
+	fb.applyView()
 	fb.currentPage = 0 // Reset to first page
 
 	// Adjust selection if out of bounds
@@ -61,6 +114,142 @@ func (fb *FileBrowser) SetFiles(files []types.FileNode) {
 	}
 }
 
+// AppendFiles adds streamed entries to the listing, keeping the cursor on
+// the entry it was on
+func (fb *FileBrowser) AppendFiles(files []types.FileNode) {
+	fb.allFiles = append(fb.allFiles, files...)
+	for _, file := range files {
+		if file.Selected {
+			fb.marked[file.Path] = true
+		}
+	}
+	fb.refreshView()
+}
+
+// Count returns the number of entries held, including hidden dotfiles
+func (fb *FileBrowser) Count() int {
+	return len(fb.allFiles)
+}
+
+// SetLoading shows or hides the loading indicator while entries stream in
+func (fb *FileBrowser) SetLoading(loading bool) {
+	fb.loading = loading
+}
+
+// IsLoading reports whether entries are still streaming in
+func (fb *FileBrowser) IsLoading() bool {
+	return fb.loading
+}
+
+// SetWindow records where the held entries start in the full listing and
+// whether the listing continues past them
+func (fb *FileBrowser) SetWindow(offset int, more bool) {
+	fb.offset = offset
+	fb.more = more
+}
+
+// Offset returns the position of the first held entry in the full listing
+func (fb *FileBrowser) Offset() int {
+	return fb.offset
+}
+
+// HasMore reports whether the listing continues past the held entries
+func (fb *FileBrowser) HasMore() bool {
+	return fb.more
+}
+
+// OnFirstPage reports whether the first page of held entries is shown
+func (fb *FileBrowser) OnFirstPage() bool {
+	return fb.currentPage == 0
+}
+
+// OnLastPage reports whether the last page of held entries is shown
+func (fb *FileBrowser) OnLastPage() bool {
+	return fb.currentPage >= fb.getTotalPages()-1
+}
+
+// applyView rebuilds the visible file list from the loaded listing: dotfiles
+// are dropped when hidden, the rest sorted by the current mode and marked
+func (fb *FileBrowser) applyView() {
+	fb.files = make([]types.FileNode, 0, len(fb.allFiles))
+	for _, file := range fb.allFiles {
+		if fb.hideHidden && strings.HasPrefix(file.Name, ".") {
+			continue
+		}
+		file.Selected = fb.marked[file.Path]
+		fb.files = append(fb.files, file)
+	}
+	sortFiles(fb.files, fb.sortMode)
+}
+
+// sortFiles orders files by mode, falling back to the name so the order is stable
+func sortFiles(files []types.FileNode, mode FileSortMode) {
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		switch mode {
+		case SortDirsFirst:
+			if a.IsDir() != b.IsDir() {
+				return a.IsDir()
+			}
+		case SortBySize:
+			if a.Size != b.Size {
+				return a.Size > b.Size
+			}
+		case SortByModTime:
+			if !a.ModTime.Equal(b.ModTime) {
+				return a.ModTime.After(b.ModTime)
+			}
+		}
+		if la, lb := strings.ToLower(a.Name), strings.ToLower(b.Name); la != lb {
+			return la < lb
+		}
+		return a.Name < b.Name
+	})
+}
+
+// refreshView re-applies the listing options, keeping the cursor on the same
+// entry when it is still visible and moving it to the top otherwise
+func (fb *FileBrowser) refreshView() {
+	var current string
+	if file := fb.GetSelected(); file != nil {
+		current = file.Path
+	}
+	fb.applyView()
+
+	fb.currentPage, fb.selected = 0, 0
+	for i, file := range fb.files {
+		if file.Path == current {
+			fb.currentPage = i / fb.pageSize
+			fb.selected = i % fb.pageSize
+			break
+		}
+	}
+}
+
+// SetSortMode sets the listing order
+func (fb *FileBrowser) SetSortMode(mode FileSortMode) {
+	fb.sortMode = mode
+	fb.refreshView()
+}
+
+// CycleSort switches to the next sort mode and returns it
+func (fb *FileBrowser) CycleSort() FileSortMode {
+	fb.SetSortMode((fb.sortMode + 1) % FileSortMode(len(fileSortModeNames)))
+	return fb.sortMode
+}
+
+// SortMode returns the current listing order
+func (fb *FileBrowser) SortMode() FileSortMode {
+	return fb.sortMode
+}
+
+// ToggleHidden hides or shows dotfiles and reports whether they are now hidden
+func (fb *FileBrowser) ToggleHidden() bool {
+	fb.hideHidden = !fb.hideHidden
+	fb.refreshView()
+	return fb.hideHidden
+}
+
 // SetSize updates the panel dimensions
 func (fb *FileBrowser) SetSize(width, height int) {
 	fb.width = width
@@ -112,41 +301,72 @@ func (fb *FileBrowser) MoveUp() {
 	}
 }
 
-// MoveDown moves the selection down
+// MoveDown moves the selection down within the current page
 func (fb *FileBrowser) MoveDown() {
-	if fb.selected < len(fb.files)-1 {
+	if fb.selected < len(fb.getFilesOnCurrentPage())-1 {
 		fb.selected++
 	}
 }
 
+// selectedIndex returns the index into fb.files of the selected entry, or -1
+func (fb *FileBrowser) selectedIndex() int {
+	if fb.selected < 0 || fb.selected >= len(fb.getFilesOnCurrentPage()) {
+		return -1
+	}
+	return fb.currentPage*fb.pageSize + fb.selected
+}
+
 // GetSelected returns the currently selected file node
 func (fb *FileBrowser) GetSelected() *types.FileNode {
-	if fb.selected >= 0 && fb.selected < len(fb.files) {
-		return &fb.files[fb.selected]
+	if idx := fb.selectedIndex(); idx >= 0 {
+		return &fb.files[idx]
 	}
 	return nil
 }
 
 // ToggleSelection toggles the selection state of the current file
 func (fb *FileBrowser) ToggleSelection() {
-	if fb.selected >= 0 && fb.selected < len(fb.files) {
-		fb.files[fb.selected].Selected = !fb.files[fb.selected].Selected
+	if idx := fb.selectedIndex(); idx >= 0 {
+		path := fb.files[idx].Path
+		fb.marked[path] = !fb.marked[path]
+		if !fb.marked[path] {
+			delete(fb.marked, path)
+		}
+		fb.files[idx].Selected = fb.marked[path]
 	}
 }
 
-// GetSelectedFiles returns all files marked as selected
+// GetSelectedFiles returns all files marked as selected in listing order,
+// including marked dotfiles that are currently hidden
 func (fb *FileBrowser) GetSelectedFiles() []types.FileNode {
 	var selected []types.FileNode
-	for _, file := range fb.files {
-		if file.Selected {
+	for _, file := range fb.allFiles {
+		if fb.marked[file.Path] {
+			file.Selected = true
 			selected = append(selected, file)
 		}
 	}
+	sortFiles(selected, fb.sortMode)
 	return selected
 }
 
+// hiddenSelectedCount returns how many marked files the dotfile filter hides
+func (fb *FileBrowser) hiddenSelectedCount() int {
+	if !fb.hideHidden {
+		return 0
+	}
+	count := 0
+	for _, file := range fb.allFiles {
+		if fb.marked[file.Path] && strings.HasPrefix(file.Name, ".") {
+			count++
+		}
+	}
+	return count
+}
+
 // ClearSelection clears all selections
 func (fb *FileBrowser) ClearSelection() {
+	fb.marked = make(map[string]bool)
 	for i := range fb.files {
 		fb.files[i].Selected = false
 	}
@@ -194,8 +414,102 @@ func (fb *FileBrowser) EnterDirectory() (string, bool) {
 	return fb.currentPath, false
 }
 
+// SetPreview opens the preview modal with the loaded file contents.
+// Returns an error without opening the modal if the data looks binary.
+func (fb *FileBrowser) SetPreview(name string, size int64, data []byte) error {
+	if IsBinaryContent(data) {
+		return fmt.Errorf("%s appears to be a binary file", name)
+	}
+
+	fb.preview = &filePreview{
+		name:      name,
+		size:      size,
+		lines:     strings.Split(strings.TrimRight(string(data), "\n"), "\n"),
+		truncated: int64(len(data)) < size,
+	}
+	return nil
+}
+
+// HasPreview returns true if the preview modal is open
+func (fb *FileBrowser) HasPreview() bool {
+	return fb.preview != nil
+}
+
+// ClosePreview closes the preview modal
+func (fb *FileBrowser) ClosePreview() {
+	fb.preview = nil
+}
+
+// previewVisibleLines returns how many content lines fit in the preview modal
+func (fb *FileBrowser) previewVisibleLines() int {
+	visible := fb.height - 10 // Border, title, info and footer lines
+	if visible < 1 {
+		visible = 1
+	}
+	return visible
+}
+
+// ScrollPreview moves the preview viewport by delta lines
+func (fb *FileBrowser) ScrollPreview(delta int) {
+	if fb.preview == nil {
+		return
+	}
+	maxOffset := len(fb.preview.lines) - fb.previewVisibleLines()
+	if maxOffset < 0 {
+		maxOffset = 0
+	}
+	fb.preview.offset += delta
+	if fb.preview.offset > maxOffset {
+		fb.preview.offset = maxOffset
+	}
+	if fb.preview.offset < 0 {
+		fb.preview.offset = 0
+	}
+}
+
+// renderPreview renders the file preview modal
+func (fb *FileBrowser) renderPreview() string {
+	var b strings.Builder
+	p := fb.preview
+
+	dimStyle := lipgloss.NewStyle().Foreground(colorDimmed)
+	b.WriteString(PanelTitleActiveStyle.Render("👁 Preview") + " " + dimStyle.Render(p.name) + "\n\n")
+
+	info := fmt.Sprintf("Size: %s", FormatBytes(p.size))
+	if p.truncated {
+		info += " (truncated preview)"
+	}
+	b.WriteString(dimStyle.Italic(true).Render(info) + "\n\n")
+
+	end := p.offset + fb.previewVisibleLines()
+	if end > len(p.lines) {
+		end = len(p.lines)
+	}
+	maxWidth := fb.width - 8
+	for _, line := range p.lines[p.offset:end] {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		if runes := []rune(line); maxWidth > 0 && len(runes) > maxWidth {
+			line = string(runes[:maxWidth])
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if len(p.lines) > end-p.offset {
+		b.WriteString("\n" + dimStyle.Render(fmt.Sprintf("Lines %d-%d of %d", p.offset+1, end, len(p.lines))))
+	}
+
+	return PanelBorderActiveStyle.
+		Width(fb.width - 4).
+		Height(fb.height - 4).
+		Render(b.String())
+}
+
 // Render renders the file browser panel
 func (fb *FileBrowser) Render(active bool) string {
+	if fb.preview != nil {
+		return fb.renderPreview()
+	}
+
 	var b strings.Builder
 
 	// Panel title with breadcrumb
@@ -207,7 +521,7 @@ func (fb *FileBrowser) Render(active bool) string {
 	}
 
 	// Breadcrumb path
-	pathStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	pathStyle := lipgloss.NewStyle().Foreground(colorDimmed)
 	displayPath := fb.currentPath
 	if len(displayPath) > 40 {
 		displayPath = "..." + displayPath[len(displayPath)-37:]
@@ -219,14 +533,24 @@ func (fb *FileBrowser) Render(active bool) string {
 	// Show snapshot info
 	if fb.snapshot != nil {
 		infoStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
+			Foreground(colorDimmed).
 			Italic(true)
-		b.WriteString(infoStyle.Render(fmt.Sprintf("Snapshot: %s", fb.snapshot.ShortID)) + "\n\n")
+		info := fmt.Sprintf("Snapshot: %s • Sort: %s", fb.snapshot.ShortID, fb.sortMode)
+		if hidden := len(fb.allFiles) - len(fb.files); hidden > 0 {
+			info += fmt.Sprintf(" • %d hidden", hidden)
+		}
+		if fb.loading {
+			info += fmt.Sprintf(" • Loading... %d entries", fb.offset+len(fb.allFiles))
+		}
+		b.WriteString(infoStyle.Render(info) + "\n\n")
 	}
 
 	// File list
-	if len(fb.files) == 0 {
-		emptyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	if len(fb.files) == 0 && fb.loading {
+		emptyStyle := lipgloss.NewStyle().Foreground(colorDimmed)
+		b.WriteString(emptyStyle.Render("Loading directory..."))
+	} else if len(fb.files) == 0 {
+		emptyStyle := lipgloss.NewStyle().Foreground(colorDimmed)
 		b.WriteString(emptyStyle.Render("No files in this directory\n"))
 		if fb.CanGoUp() {
 			b.WriteString(emptyStyle.Render("Press ← or h to go back"))
@@ -234,7 +558,7 @@ func (fb *FileBrowser) Render(active bool) string {
 	} else {
 		// Add ".." entry if we can go up
 		if fb.CanGoUp() {
-			backStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+			backStyle := lipgloss.NewStyle().Foreground(colorWarning)
 			b.WriteString(backStyle.Render("  .. (parent directory)") + "\n")
 		}
 
@@ -268,8 +592,8 @@ func (fb *FileBrowser) Render(active bool) string {
 
 			// Add size and time for files
 			if file.IsFile() {
-				sizeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
-				line += sizeStyle.Render(fmt.Sprintf(" (%s)", formatBytes(file.Size)))
+				sizeStyle := lipgloss.NewStyle().Foreground(colorDimmed)
+				line += sizeStyle.Render(fmt.Sprintf(" (%s)", FormatBytes(file.Size)))
 			}
 
 			// Style the line
@@ -286,16 +610,26 @@ func (fb *FileBrowser) Render(active bool) string {
 		selectedCount := len(fb.GetSelectedFiles())
 		if selectedCount > 0 {
 			selectionStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("214")).
+				Foreground(colorWarning).
 				Bold(true)
-			b.WriteString("\n" + selectionStyle.Render(fmt.Sprintf("%d files selected", selectedCount)))
+			text := fmt.Sprintf("%d files selected", selectedCount)
+			if hidden := fb.hiddenSelectedCount(); hidden > 0 {
+				text += fmt.Sprintf(" (%d hidden)", hidden)
+			}
+			b.WriteString("\n" + selectionStyle.Render(text))
 		}
 
 		// Pagination info
 		totalPages := fb.getTotalPages()
-		if totalPages > 1 {
-			pageStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+		if totalPages > 1 || fb.offset > 0 || fb.more {
+			pageStyle := lipgloss.NewStyle().Foreground(colorDimmed)
 			pageInfo := fmt.Sprintf("Page %d/%d (%d files)", fb.currentPage+1, totalPages, len(fb.files))
+			if fb.offset > 0 || fb.more {
+				pageInfo += fmt.Sprintf(" • entries %d-%d", fb.offset+1, fb.offset+len(fb.allFiles))
+			}
+			if fb.more {
+				pageInfo += " • n on the last page loads more"
+			}
 			b.WriteString("\n" + pageStyle.Render(pageInfo))
 		}
 	}
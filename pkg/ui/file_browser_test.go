@@ -0,0 +1,297 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+func TestFileBrowser_SetPreview(t *testing.T) {
+	fb := NewFileBrowser(&types.Snapshot{ID: "abc123", ShortID: "abc123"})
+	fb.SetSize(80, 20)
+
+	if err := fb.SetPreview("image.png", 100, []byte("\x89PNG\x00\x00")); err == nil {
+		t.Error("Expected binary content to be refused")
+	}
+	if fb.HasPreview() {
+		t.Error("Preview should not open for binary content")
+	}
+
+	if err := fb.SetPreview("notes.txt", 1000, []byte("line one\nline two\n")); err != nil {
+		t.Fatalf("SetPreview() failed: %v", err)
+	}
+	if !fb.HasPreview() {
+		t.Fatal("Preview should be open")
+	}
+
+	output := fb.Render(true)
+	if !strings.Contains(output, "line two") {
+		t.Error("Preview should render file contents")
+	}
+	if !strings.Contains(output, "truncated") {
+		t.Error("Preview should flag content shorter than the file size as truncated")
+	}
+
+	fb.ClosePreview()
+	if fb.HasPreview() {
+		t.Error("ClosePreview() should close the preview")
+	}
+}
+
+func TestFileBrowser_ScrollPreview(t *testing.T) {
+	fb := NewFileBrowser(&types.Snapshot{ID: "abc123"})
+	fb.SetSize(80, 15) // 5 visible lines
+
+	var lines []string
+	for i := 0; i < 12; i++ {
+		lines = append(lines, "line")
+	}
+	content := strings.Join(lines, "\n")
+	if err := fb.SetPreview("log.txt", int64(len(content)), []byte(content)); err != nil {
+		t.Fatalf("SetPreview() failed: %v", err)
+	}
+
+	fb.ScrollPreview(-3)
+	if fb.preview.offset != 0 {
+		t.Errorf("offset = %d, want 0 when scrolling above the top", fb.preview.offset)
+	}
+
+	fb.ScrollPreview(100)
+	if fb.preview.offset != 7 {
+		t.Errorf("offset = %d, want 7 (last page)", fb.preview.offset)
+	}
+}
+
+func TestIsBinaryContent(t *testing.T) {
+	if IsBinaryContent([]byte("plain text\n")) {
+		t.Error("Plain text should not be binary")
+	}
+	if !IsBinaryContent([]byte{'E', 'L', 'F', 0}) {
+		t.Error("Content with NUL bytes should be binary")
+	}
+}
+
+// newPagedFileBrowser returns a browser holding more files than fit on one page
+func newPagedFileBrowser(count int) *FileBrowser {
+	fb := NewFileBrowser(&types.Snapshot{ID: "abc123", ShortID: "abc123"})
+	files := make([]types.FileNode, count)
+	for i := range files {
+		name := fmt.Sprintf("file%03d", i)
+		files[i] = types.FileNode{Name: name, Path: "/" + name, Type: "file"}
+	}
+	files[fb.pageSize+2].Type = "dir"
+	fb.SetSortMode(SortByName) // Keep the directory at its listing position
+	fb.SetFiles(files)
+	return fb
+}
+
+func TestFileBrowser_SelectionOnSecondPage(t *testing.T) {
+	fb := newPagedFileBrowser(120)
+
+	fb.NextPage()
+	fb.MoveDown()
+	fb.MoveDown()
+
+	want := fmt.Sprintf("file%03d", fb.pageSize+2)
+	if got := fb.GetSelected(); got == nil || got.Name != want {
+		t.Fatalf("GetSelected() on page 2 = %v, want %s", got, want)
+	}
+
+	fb.ToggleSelection()
+	selected := fb.GetSelectedFiles()
+	if len(selected) != 1 || selected[0].Name != want {
+		t.Errorf("ToggleSelection() marked %v, want only %s", selected, want)
+	}
+
+	path, ok := fb.EnterDirectory()
+	if !ok || path != "/"+want {
+		t.Errorf("EnterDirectory() = %q, %v, want %q, true", path, ok, "/"+want)
+	}
+}
+
+func TestFileBrowser_MoveDownStopsAtPageEnd(t *testing.T) {
+	fb := newPagedFileBrowser(120)
+
+	// Last page holds the remaining 20 files
+	fb.NextPage()
+	fb.NextPage()
+	for i := 0; i < 100; i++ {
+		fb.MoveDown()
+	}
+
+	if got := fb.GetSelected(); got == nil || got.Name != "file119" {
+		t.Errorf("GetSelected() at end of last page = %v, want file119", got)
+	}
+
+	// First page stops at its own last entry rather than the list's
+	fb.PrevPage()
+	fb.PrevPage()
+	for i := 0; i < 100; i++ {
+		fb.MoveDown()
+	}
+	want := fmt.Sprintf("file%03d", fb.pageSize-1)
+	if got := fb.GetSelected(); got == nil || got.Name != want {
+		t.Errorf("GetSelected() at end of first page = %v, want %s", got, want)
+	}
+}
+
+// newSortableFileBrowser returns a browser listing a mix of files, directories and dotfiles
+func newSortableFileBrowser() *FileBrowser {
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	fb := NewFileBrowser(&types.Snapshot{ID: "abc123", ShortID: "abc123"})
+	fb.SetFiles([]types.FileNode{
+		{Name: "b.txt", Path: "/b.txt", Type: "file", Size: 300, ModTime: base.Add(1 * time.Hour)},
+		{Name: "docs", Path: "/docs", Type: "dir", ModTime: base.Add(4 * time.Hour)},
+		{Name: ".bashrc", Path: "/.bashrc", Type: "file", Size: 10, ModTime: base},
+		{Name: "A.log", Path: "/A.log", Type: "file", Size: 500, ModTime: base.Add(3 * time.Hour)},
+		{Name: "cache", Path: "/cache", Type: "dir", ModTime: base.Add(2 * time.Hour)},
+	})
+	return fb
+}
+
+// fileNames returns the names of the browser's visible files in order
+func fileNames(fb *FileBrowser) string {
+	var names []string
+	for _, file := range fb.files {
+		names = append(names, file.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestFileBrowser_SortModes(t *testing.T) {
+	tests := []struct {
+		mode FileSortMode
+		want string
+	}{
+		{SortDirsFirst, "cache docs .bashrc A.log b.txt"},
+		{SortByName, ".bashrc A.log b.txt cache docs"},
+		{SortBySize, "A.log b.txt .bashrc cache docs"},
+		{SortByModTime, "docs A.log cache b.txt .bashrc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			fb := newSortableFileBrowser()
+			fb.SetSortMode(tt.mode)
+			if got := fileNames(fb); got != tt.want {
+				t.Errorf("Order = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileBrowser_DirsFirstByDefault(t *testing.T) {
+	fb := newSortableFileBrowser()
+
+	if fb.SortMode() != SortDirsFirst {
+		t.Errorf("SortMode() = %v, want dirs first", fb.SortMode())
+	}
+	for i, file := range fb.files {
+		if file.IsDir() && i > 0 && !fb.files[i-1].IsDir() {
+			t.Fatalf("Directory %s listed after a file: %q", file.Name, fileNames(fb))
+		}
+	}
+
+	if got := fb.CycleSort(); got != SortByName {
+		t.Errorf("CycleSort() = %v, want name", got)
+	}
+	fb.CycleSort()
+	fb.CycleSort()
+	if got := fb.CycleSort(); got != SortDirsFirst {
+		t.Errorf("CycleSort() should wrap around to dirs first, got %v", got)
+	}
+}
+
+func TestFileBrowser_MarksAndCursorSurviveResort(t *testing.T) {
+	fb := newSortableFileBrowser()
+
+	// Mark b.txt (last in dirs-first order) and keep the cursor on it
+	for i := 0; i < 4; i++ {
+		fb.MoveDown()
+	}
+	fb.ToggleSelection()
+
+	fb.SetSortMode(SortBySize)
+	if got := fb.GetSelected(); got == nil || got.Name != "b.txt" {
+		t.Errorf("Cursor should follow b.txt after re-sorting, got %v", got)
+	}
+	selected := fb.GetSelectedFiles()
+	if len(selected) != 1 || selected[0].Path != "/b.txt" {
+		t.Errorf("GetSelectedFiles() = %v, want only /b.txt", selected)
+	}
+}
+
+func TestFileBrowser_ToggleHidden(t *testing.T) {
+	fb := newSortableFileBrowser()
+	fb.SetSortMode(SortByName) // .bashrc first; the cursor stays on cache
+	for i := 0; i < 4; i++ {
+		fb.MoveUp()
+	}
+
+	fb.ToggleSelection() // Mark .bashrc
+	if !fb.ToggleHidden() {
+		t.Fatal("ToggleHidden() should report dotfiles hidden")
+	}
+	if got := fileNames(fb); got != "A.log b.txt cache docs" {
+		t.Errorf("Visible files = %q, want dotfiles hidden", got)
+	}
+	if got := fb.GetSelected(); got == nil || got.Name != "A.log" {
+		t.Errorf("Cursor should move to the top when its entry is hidden, got %v", got)
+	}
+	if !strings.Contains(fb.Render(true), "1 hidden") {
+		t.Error("Render should show how many entries are hidden")
+	}
+	if selected := fb.GetSelectedFiles(); len(selected) != 1 || selected[0].Name != ".bashrc" {
+		t.Errorf("A marked dotfile should stay selected while hidden, got %v", selected)
+	}
+	if !strings.Contains(fb.Render(true), "1 files selected (1 hidden)") {
+		t.Error("Render should say the selection includes hidden files")
+	}
+
+	if fb.ToggleHidden() {
+		t.Fatal("ToggleHidden() should report dotfiles shown again")
+	}
+	if selected := fb.GetSelectedFiles(); len(selected) != 1 || selected[0].Name != ".bashrc" {
+		t.Errorf("Mark on .bashrc should survive hiding, got %v", selected)
+	}
+}
+
+func TestFileBrowser_AppendFilesWhileLoading(t *testing.T) {
+	fb := NewFileBrowser(&types.Snapshot{ShortID: "abc123"})
+	fb.SetSize(80, 30)
+	fb.SetLoading(true)
+
+	if view := fb.Render(true); !strings.Contains(view, "Loading directory...") {
+		t.Errorf("An empty listing still loading should say so, got:\n%s", view)
+	}
+
+	fb.AppendFiles([]types.FileNode{
+		{Name: "b.txt", Type: "file", Path: "/b.txt"},
+		{Name: "d.txt", Type: "file", Path: "/d.txt"},
+	})
+	fb.MoveDown()
+	fb.AppendFiles([]types.FileNode{
+		{Name: "a.txt", Type: "file", Path: "/a.txt"},
+		{Name: "c.txt", Type: "file", Path: "/c.txt"},
+	})
+
+	if fb.Count() != 4 {
+		t.Fatalf("Count() = %d, want 4", fb.Count())
+	}
+	if got := fb.GetSelected(); got == nil || got.Name != "d.txt" {
+		t.Errorf("The cursor should stay on d.txt as entries arrive, got %v", got)
+	}
+	if view := fb.Render(true); !strings.Contains(view, "Loading... 4 entries") {
+		t.Errorf("Render() should show the loading indicator, got:\n%s", view)
+	}
+
+	fb.SetLoading(false)
+	fb.SetWindow(10000, true)
+	view := fb.Render(true)
+	if strings.Contains(view, "Loading") || !strings.Contains(view, "entries 10001-10004") || !strings.Contains(view, "loads more") {
+		t.Errorf("Render() should show the window and that more entries follow, got:\n%s", view)
+	}
+}
@@ -15,6 +15,7 @@ type ForgetFormField int
 
 const (
 	ForgetFieldKeepLast ForgetFormField = iota
+	ForgetFieldKeepHourly
 	ForgetFieldKeepDaily
 	ForgetFieldKeepWeekly
 	ForgetFieldKeepMonthly
@@ -23,9 +24,13 @@ const (
 	ForgetFieldPreview
 )
 
+// forgetFieldCount is the number of focusable fields, including the preview button
+const forgetFieldCount = int(ForgetFieldPreview) + 1
+
 // ForgetForm represents the forget policy configuration form
 type ForgetForm struct {
 	keepLastInput    textinput.Model
+	keepHourlyInput  textinput.Model
 	keepDailyInput   textinput.Model
 	keepWeeklyInput  textinput.Model
 	keepMonthlyInput textinput.Model
@@ -45,6 +50,11 @@ func NewForgetForm() *ForgetForm {
 	keepLast.CharLimit = 5
 	keepLast.Width = 20
 
+	keepHourly := textinput.New()
+	keepHourly.Placeholder = "e.g., 24"
+	keepHourly.CharLimit = 5
+	keepHourly.Width = 20
+
 	keepDaily := textinput.New()
 	keepDaily.Placeholder = "e.g., 7"
 	keepDaily.CharLimit = 5
@@ -72,6 +82,7 @@ func NewForgetForm() *ForgetForm {
 
 	form := &ForgetForm{
 		keepLastInput:    keepLast,
+		keepHourlyInput:  keepHourly,
 		keepDailyInput:   keepDaily,
 		keepWeeklyInput:  keepWeekly,
 		keepMonthlyInput: keepMonthly,
@@ -104,6 +115,8 @@ func (f *ForgetForm) Update(msg tea.Msg) tea.Cmd {
 	switch f.focusedField {
 	case ForgetFieldKeepLast:
 		f.keepLastInput, cmd = f.keepLastInput.Update(msg)
+	case ForgetFieldKeepHourly:
+		f.keepHourlyInput, cmd = f.keepHourlyInput.Update(msg)
 	case ForgetFieldKeepDaily:
 		f.keepDailyInput, cmd = f.keepDailyInput.Update(msg)
 	case ForgetFieldKeepWeekly:
@@ -122,20 +135,21 @@ func (f *ForgetForm) Update(msg tea.Msg) tea.Cmd {
 // NextField moves to the next field
 func (f *ForgetForm) NextField() {
 	f.BlurAll()
-	f.focusedField = (f.focusedField + 1) % 7
+	f.focusedField = ForgetFormField((int(f.focusedField) + 1) % forgetFieldCount)
 	f.FocusCurrent()
 }
 
 // PrevField moves to the previous field
 func (f *ForgetForm) PrevField() {
 	f.BlurAll()
-	f.focusedField = (f.focusedField + 6) % 7
+	f.focusedField = ForgetFormField((int(f.focusedField) + forgetFieldCount - 1) % forgetFieldCount)
 	f.FocusCurrent()
 }
 
 // BlurAll blurs all input fields
 func (f *ForgetForm) BlurAll() {
 	f.keepLastInput.Blur()
+	f.keepHourlyInput.Blur()
 	f.keepDailyInput.Blur()
 	f.keepWeeklyInput.Blur()
 	f.keepMonthlyInput.Blur()
@@ -148,6 +162,8 @@ func (f *ForgetForm) FocusCurrent() {
 	switch f.focusedField {
 	case ForgetFieldKeepLast:
 		f.keepLastInput.Focus()
+	case ForgetFieldKeepHourly:
+		f.keepHourlyInput.Focus()
 	case ForgetFieldKeepDaily:
 		f.keepDailyInput.Focus()
 	case ForgetFieldKeepWeekly:
@@ -170,6 +186,11 @@ func (f *ForgetForm) GetPolicy() types.ForgetPolicy {
 			policy.KeepLast = n
 		}
 	}
+	if val := f.keepHourlyInput.Value(); val != "" {
+		if n, err := strconv.Atoi(val); err == nil {
+			policy.KeepHourly = n
+		}
+	}
 	if val := f.keepDailyInput.Value(); val != "" {
 		if n, err := strconv.Atoi(val); err == nil {
 			policy.KeepDaily = n
@@ -198,7 +219,7 @@ func (f *ForgetForm) GetPolicy() types.ForgetPolicy {
 // IsValid checks if at least one retention rule is specified
 func (f *ForgetForm) IsValid() bool {
 	policy := f.GetPolicy()
-	hasRule := policy.KeepLast > 0 || policy.KeepDaily > 0 || policy.KeepWeekly > 0 ||
+	hasRule := policy.KeepLast > 0 || policy.KeepHourly > 0 || policy.KeepDaily > 0 || policy.KeepWeekly > 0 ||
 		policy.KeepMonthly > 0 || policy.KeepYearly > 0 || policy.KeepWithin != ""
 
 	if !hasRule {
@@ -227,26 +248,26 @@ func (f *ForgetForm) Render() string {
 
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("214")). // Orange for warning
+		Foreground(colorWarning).
 		Padding(0, 1)
 
 	warningStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("208")).
+		Foreground(colorWarning).
 		Bold(true).
 		Padding(1, 2)
 
 	labelStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(colorDimmed).
 		Width(25)
 
 	buttonStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("15")).
-		Background(lipgloss.Color("62")).
+		Foreground(colorBlack).
+		Background(colorDimmed).
 		Padding(0, 2).
 		MarginTop(1)
 
 	buttonFocusedStyle := buttonStyle.Copy().
-		Background(lipgloss.Color("205")).
+		Background(colorActive).
 		Bold(true)
 
 	// Title
@@ -258,7 +279,7 @@ func (f *ForgetForm) Render() string {
 
 	// Description
 	descStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(colorDimmed).
 		Italic(true).
 		Width(f.width - 10)
 	desc := "Specify retention rules. Snapshots not matching any rule will be REMOVED."
@@ -266,6 +287,7 @@ func (f *ForgetForm) Render() string {
 
 	// Input fields
 	b.WriteString(labelStyle.Render("Keep Last N Snapshots:") + "  " + f.keepLastInput.View() + "\n")
+	b.WriteString(labelStyle.Render("Keep Hourly (last N hours):") + "  " + f.keepHourlyInput.View() + "\n")
 	b.WriteString(labelStyle.Render("Keep Daily (last N days):") + "  " + f.keepDailyInput.View() + "\n")
 	b.WriteString(labelStyle.Render("Keep Weekly (last N weeks):") + "  " + f.keepWeeklyInput.View() + "\n")
 	b.WriteString(labelStyle.Render("Keep Monthly (last N months):") + "  " + f.keepMonthlyInput.View() + "\n")
@@ -274,7 +296,7 @@ func (f *ForgetForm) Render() string {
 
 	// Examples
 	exampleStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(colorDimmed).
 		Italic(true).
 		MarginTop(1)
 	b.WriteString(exampleStyle.Render("  Examples: keep-last 10, keep-daily 7, keep-within 1y6m") + "\n")
@@ -282,7 +304,7 @@ func (f *ForgetForm) Render() string {
 	// Error message
 	if f.errorMsg != "" {
 		errorStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("196")).
+			Foreground(colorError).
 			Bold(true).
 			MarginTop(1)
 		b.WriteString(errorStyle.Render("⚠ "+f.errorMsg) + "\n")
@@ -298,7 +320,7 @@ func (f *ForgetForm) Render() string {
 
 	// Help text
 	helpStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(colorDimmed).
 		Italic(true).
 		MarginTop(1)
 	b.WriteString(helpStyle.Render("Tab: next field • Enter: preview • Esc: cancel") + "\n")
@@ -306,7 +328,7 @@ func (f *ForgetForm) Render() string {
 	// Border
 	boxStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("214")).
+		BorderForeground(colorWarning).
 		Padding(1, 2).
 		Width(f.width - 4)
 
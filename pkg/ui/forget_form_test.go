@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestForgetForm_KeepHourly(t *testing.T) {
+	form := NewForgetForm()
+	if form.IsValid() {
+		t.Fatal("An empty form should not be valid")
+	}
+
+	form.NextField()
+	if form.focusedField != ForgetFieldKeepHourly {
+		t.Fatalf("Second field = %v, want keep hourly", form.focusedField)
+	}
+	for _, r := range "24" {
+		form.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+
+	if got := form.GetPolicy().KeepHourly; got != 24 {
+		t.Errorf("KeepHourly = %d, want 24", got)
+	}
+	if !form.IsValid() {
+		t.Error("A keep-hourly rule alone should make the form valid")
+	}
+}
+
+func TestForgetForm_NavigationWraps(t *testing.T) {
+	form := NewForgetForm()
+
+	form.PrevField()
+	if !form.IsPreviewButton() {
+		t.Errorf("Going back from the first field should focus the preview button, got %v", form.focusedField)
+	}
+	form.NextField()
+	if form.focusedField != ForgetFieldKeepLast {
+		t.Errorf("Going forward from the preview button should wrap to the first field, got %v", form.focusedField)
+	}
+}
@@ -55,36 +55,36 @@ func (fp *ForgetPreview) Render() string {
 
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("196")). // Red
-		Background(lipgloss.Color("52")).   // Dark red background
+		Foreground(colorBlack).
+		Background(colorError).
 		Padding(0, 2)
 
 	warningStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("208")).
+		Foreground(colorWarning).
 		Bold(true).
 		Padding(1, 2)
 
 	headerStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("205")).
+		Foreground(colorActive).
 		MarginTop(1)
 
 	keepStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("40")). // Green
+		Foreground(colorSuccess).
 		Padding(0, 2)
 
 	removeStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("196")). // Red
+		Foreground(colorError).
 		Padding(0, 2)
 
 	summaryStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("15")).
-		Background(lipgloss.Color("52")).
+		Foreground(colorBlack).
+		Background(colorError).
 		Padding(1, 2).
 		MarginTop(1)
 
 	confirmStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("214")).
+		Foreground(colorWarning).
 		Bold(true).
 		MarginTop(1)
 
@@ -111,7 +111,7 @@ func (fp *ForgetPreview) Render() string {
 
 	if totalRemove == 0 {
 		noDeleteStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("40")).
+			Foreground(colorSuccess).
 			Bold(true)
 		b.WriteString(noDeleteStyle.Render("✓ No snapshots will be deleted with this policy.") + "\n")
 	} else {
@@ -178,7 +178,7 @@ func (fp *ForgetPreview) Render() string {
 	// Border
 	boxStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("196")).
+		BorderForeground(colorError).
 		Padding(1, 2).
 		Width(fp.width - 4)
 
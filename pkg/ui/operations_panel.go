@@ -2,6 +2,8 @@ package ui
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -12,37 +14,151 @@ import (
 // LogEntry represents a log message
 type LogEntry struct {
 	Timestamp time.Time
-	Level     string // "info", "success", "warning", "error"
+	Level     string // "info", "dimmed", "preview", "success", "warning", "error"
 	Message   string
 }
 
+// MaxLogFileSize is the size at which the auto-append log file is rolled over to <path>.1
+const MaxLogFileSize = 5 * 1024 * 1024
+
+// throughputSamples is how many per-second throughput samples the backup
+// speed is averaged over
+const throughputSamples = 5
+
 // OperationsPanel represents the operations/logs panel
 type OperationsPanel struct {
 	logs             []LogEntry
+	maxEntries       int // Entries kept in memory; older ones are dropped
 	width            int
 	height           int
 	backupProgress   *types.BackupProgress
 	backupInProgress bool
+	rateSample       *types.BackupProgress // Update the next throughput sample is measured from
+	rates            []float64             // Recent throughput samples in bytes per second
+	activity         string                // Spinner line for a running operation (empty when idle)
+
+	// Entries scrolled back from the newest; 0 follows new entries as they arrive
+	scrollOffset int
+
+	// Filter state
+	filterActive bool
+	filterText   string
+	filterLevel  string // "", "warning" (warnings and errors) or "error"
+
+	// Optional file every entry is appended to
+	logFile *os.File
 }
 
 // NewOperationsPanel creates a new operations panel
 func NewOperationsPanel() *OperationsPanel {
 	return &OperationsPanel{
-		logs: []LogEntry{},
+		logs:       []LogEntry{},
+		maxEntries: MaxLogEntries,
+	}
+}
+
+// SetMaxEntries sets how many entries are kept in memory, dropping the oldest
+// beyond it. Zero or less keeps MaxLogEntries; more than LogEntriesLimit keeps
+// LogEntriesLimit. The log file, if any, still receives every entry.
+func (p *OperationsPanel) SetMaxEntries(n int) {
+	switch {
+	case n <= 0:
+		n = MaxLogEntries
+	case n > LogEntriesLimit:
+		n = LogEntriesLimit
+	}
+	p.maxEntries = n
+	p.trimLogs()
+}
+
+// trimLogs drops the oldest entries beyond maxEntries. Reslicing keeps this
+// cheap per entry; append copies the kept entries to a fresh array once the
+// backing array fills up, so dropped entries don't pile up in memory.
+func (p *OperationsPanel) trimLogs() {
+	if len(p.logs) > p.maxEntries {
+		p.logs = p.logs[len(p.logs)-p.maxEntries:]
 	}
 }
 
 // AddLog adds a log entry
 func (p *OperationsPanel) AddLog(level, message string) {
-	p.logs = append(p.logs, LogEntry{
+	entry := LogEntry{
 		Timestamp: time.Now(),
 		Level:     level,
 		Message:   message,
-	})
+	}
+	p.logs = append(p.logs, entry)
+
+	// Keep the view still while scrolled back; at the bottom, follow new entries
+	if p.scrollOffset > 0 && (!p.IsFilterActive() || p.matchesFilter(entry)) {
+		p.scrollOffset++
+	}
+
+	p.trimLogs()
 
-	// Keep only last 100 entries
-	if len(p.logs) > 100 {
-		p.logs = p.logs[len(p.logs)-100:]
+	if p.logFile != nil {
+		if _, err := p.logFile.WriteString(formatLogEntry(p.logs[len(p.logs)-1])); err != nil {
+			// Stop appending and surface the failure in-panel
+			p.logFile.Close()
+			p.logFile = nil
+			p.Error(fmt.Sprintf("Failed to write log file: %v", err))
+		}
+	}
+}
+
+// formatLogEntry formats an entry as a single line with an RFC3339 timestamp and level prefix
+func formatLogEntry(entry LogEntry) string {
+	return fmt.Sprintf("%s [%s] %s\n", entry.Timestamp.Format(time.RFC3339), strings.ToUpper(entry.Level), entry.Message)
+}
+
+// ExportLogs writes all log entries to path, creating parent directories as needed.
+// The file is created with 0600 permissions since logs may contain repository paths.
+func (p *OperationsPanel) ExportLogs(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	var b strings.Builder
+	for _, entry := range p.logs {
+		b.WriteString(formatLogEntry(entry))
+	}
+
+	if err := os.WriteFile(path, []byte(b.String()), 0600); err != nil {
+		return fmt.Errorf("failed to write log file: %w", err)
+	}
+	return nil
+}
+
+// SetLogFile starts appending every new entry to path. An existing file larger than
+// MaxLogFileSize is rolled over to path.1 first.
+func (p *OperationsPanel) SetLogFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	if info, err := os.Stat(path); err == nil && info.Size() > MaxLogFileSize {
+		if err := os.Rename(path, path+".1"); err != nil {
+			return fmt.Errorf("failed to roll over log file: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	if p.logFile != nil {
+		p.logFile.Close()
+	}
+	p.logFile = file
+	return nil
+}
+
+// CloseLogFile stops appending entries to the log file
+func (p *OperationsPanel) CloseLogFile() {
+	if p.logFile != nil {
+		p.logFile.Close()
+		p.logFile = nil
 	}
 }
 
@@ -56,6 +172,11 @@ func (p *OperationsPanel) Dimmed(message string) {
 	p.AddLog("dimmed", message)
 }
 
+// Preview adds a log for a simulated (dry-run) operation
+func (p *OperationsPanel) Preview(message string) {
+	p.AddLog("preview", message)
+}
+
 // Success adds a success log
 func (p *OperationsPanel) Success(message string) {
 	p.AddLog("success", message)
@@ -71,16 +192,212 @@ func (p *OperationsPanel) Error(message string) {
 	p.AddLog("error", message)
 }
 
+// SetFilter sets a case-insensitive message filter
+func (p *OperationsPanel) SetFilter(text string) {
+	p.filterText = text
+	p.filterActive = true
+	p.scrollOffset = 0
+}
+
+// SetLevelFilter limits the log to entries at or above level ("warning" or "error");
+// an empty level shows all entries
+func (p *OperationsPanel) SetLevelFilter(level string) {
+	p.filterLevel = level
+	p.filterActive = true
+	p.scrollOffset = 0
+}
+
+// CycleLevelFilter steps the level filter through all → warnings+errors → errors
+// and returns the new level
+func (p *OperationsPanel) CycleLevelFilter() string {
+	switch p.filterLevel {
+	case "":
+		p.SetLevelFilter("warning")
+	case "warning":
+		p.SetLevelFilter("error")
+	default:
+		p.SetLevelFilter("")
+	}
+	return p.filterLevel
+}
+
+// ClearFilter removes all filters
+func (p *OperationsPanel) ClearFilter() {
+	p.filterActive = false
+	p.filterText = ""
+	p.filterLevel = ""
+	p.scrollOffset = 0
+}
+
+// IsFilterActive returns true if any filter is currently active
+func (p *OperationsPanel) IsFilterActive() bool {
+	return p.filterActive && (p.filterText != "" || p.filterLevel != "")
+}
+
+// matchesFilter checks if a log entry matches the current filter criteria
+func (p *OperationsPanel) matchesFilter(entry LogEntry) bool {
+	switch p.filterLevel {
+	case "warning":
+		if entry.Level != "warning" && entry.Level != "error" {
+			return false
+		}
+	case "error":
+		if entry.Level != "error" {
+			return false
+		}
+	}
+
+	if p.filterText != "" {
+		return strings.Contains(strings.ToLower(entry.Message), strings.ToLower(p.filterText))
+	}
+	return true
+}
+
+// FilteredLogs returns the log entries matching the current filter
+func (p *OperationsPanel) FilteredLogs() []LogEntry {
+	if !p.IsFilterActive() {
+		return p.logs
+	}
+
+	var filtered []LogEntry
+	for _, entry := range p.logs {
+		if p.matchesFilter(entry) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// pageSize returns how many log entries fit in the panel
+func (p *OperationsPanel) pageSize() int {
+	maxEntries := (p.height - 8) / 2 // Each entry takes ~2 lines
+	if maxEntries < 1 {
+		maxEntries = 1
+	}
+	return maxEntries
+}
+
+// maxScrollOffset returns how far back the log can scroll while still filling the panel
+func (p *OperationsPanel) maxScrollOffset() int {
+	max := len(p.FilteredLogs()) - p.pageSize()
+	if max < 0 {
+		return 0
+	}
+	return max
+}
+
+// ScrollUp moves the view n entries back towards older log lines
+func (p *OperationsPanel) ScrollUp(n int) {
+	p.scrollOffset += n
+	if max := p.maxScrollOffset(); p.scrollOffset > max {
+		p.scrollOffset = max
+	}
+}
+
+// ScrollDown moves the view n entries towards the newest log lines
+func (p *OperationsPanel) ScrollDown(n int) {
+	p.scrollOffset -= n
+	if p.scrollOffset < 0 {
+		p.scrollOffset = 0
+	}
+}
+
+// PageUp scrolls back by one panel of entries
+func (p *OperationsPanel) PageUp() {
+	p.ScrollUp(p.pageSize())
+}
+
+// PageDown scrolls forward by one panel of entries
+func (p *OperationsPanel) PageDown() {
+	p.ScrollDown(p.pageSize())
+}
+
+// ScrollOffset returns how many entries the view is scrolled back from the newest
+func (p *OperationsPanel) ScrollOffset() int {
+	return p.scrollOffset
+}
+
 // SetBackupProgress updates the backup progress
 func (p *OperationsPanel) SetBackupProgress(progress *types.BackupProgress) {
 	p.backupProgress = progress
 	p.backupInProgress = true
+	p.sampleRate(progress)
 }
 
 // ClearBackupProgress clears the backup progress
 func (p *OperationsPanel) ClearBackupProgress() {
 	p.backupProgress = nil
 	p.backupInProgress = false
+	p.rateSample = nil
+	p.rates = nil
+}
+
+// sampleRate adds the throughput since the previous sample. restic reports
+// elapsed time in whole seconds and updates several times a second, so a
+// sample is only taken once the elapsed time has moved on.
+func (p *OperationsPanel) sampleRate(progress *types.BackupProgress) {
+	if progress == nil {
+		return
+	}
+	prev := p.rateSample
+	if prev == nil || progress.SecondsElapsed < prev.SecondsElapsed || progress.BytesDone < prev.BytesDone {
+		// First update, or a new backup started counting again
+		p.rateSample = progress
+		p.rates = nil
+		return
+	}
+	if progress.SecondsElapsed == prev.SecondsElapsed {
+		return
+	}
+
+	rate := float64(progress.BytesDone-prev.BytesDone) / float64(progress.SecondsElapsed-prev.SecondsElapsed)
+	p.rates = append(p.rates, rate)
+	if len(p.rates) > throughputSamples {
+		p.rates = p.rates[len(p.rates)-throughputSamples:]
+	}
+	p.rateSample = progress
+}
+
+// throughput returns the moving average of the recent throughput samples in
+// bytes per second; ok is false until a sample was taken
+func (p *OperationsPanel) throughput() (rate float64, ok bool) {
+	if len(p.rates) == 0 {
+		return 0, false
+	}
+	var total float64
+	for _, r := range p.rates {
+		total += r
+	}
+	return total / float64(len(p.rates)), true
+}
+
+// backupRateText formats the backup's throughput and time remaining, e.g.
+// "Speed: 12.5 MiB/s  ETA: 4m 10s". Either part shows "…" until it is known.
+func (p *OperationsPanel) backupRateText() string {
+	speed := "…"
+	if rate, ok := p.throughput(); ok {
+		speed = FormatBytes(int64(rate)) + "/s"
+	}
+	eta := "…"
+	if p.backupProgress != nil && p.backupProgress.SecondsRemaining > 0 {
+		eta = FormatSeconds(p.backupProgress.SecondsRemaining)
+	}
+	return fmt.Sprintf("Speed: %s  ETA: %s", speed, eta)
+}
+
+// SetActivity shows a status line (e.g. a spinner and elapsed time) for a running operation
+func (p *OperationsPanel) SetActivity(text string) {
+	p.activity = text
+}
+
+// ClearActivity removes the running operation status line
+func (p *OperationsPanel) ClearActivity() {
+	p.activity = ""
+}
+
+// Activity returns the running operation status line, empty when idle
+func (p *OperationsPanel) Activity() string {
+	return p.activity
 }
 
 // SetSize updates the panel dimensions
@@ -102,8 +419,8 @@ func renderProgressBar(percent float64, width int) string {
 
 	bar := strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
 
-	barStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("86"))
-	percentStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	barStyle := lipgloss.NewStyle().Foreground(colorPrimary)
+	percentStyle := lipgloss.NewStyle().Foreground(colorDimmed)
 
 	return barStyle.Render(bar) + " " + percentStyle.Render(fmt.Sprintf("%.1f%%", percent))
 }
@@ -114,13 +431,25 @@ func (p *OperationsPanel) Render(active bool) string {
 
 	title := "[4] Operations"
 
+	// Add filter indicator if active
+	if p.IsFilterActive() {
+		filterParts := []string{}
+		if p.filterText != "" {
+			filterParts = append(filterParts, fmt.Sprintf("text=%s", p.filterText))
+		}
+		if p.filterLevel != "" {
+			filterParts = append(filterParts, fmt.Sprintf("level>=%s", p.filterLevel))
+		}
+		title += fmt.Sprintf(" [%s]", strings.Join(filterParts, ", "))
+	}
+
 	// Add top margin/padding for breathing room
 	b.WriteString("\n")
 
 	// Show backup progress if active
 	if p.backupInProgress && p.backupProgress != nil {
-		progressStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("86")).Bold(true)
-		labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+		progressStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+		labelStyle := lipgloss.NewStyle().Foreground(colorDimmed)
 
 		b.WriteString(progressStyle.Render("Backup in Progress") + "\n\n")
 
@@ -134,8 +463,10 @@ func (p *OperationsPanel) Render(active bool) string {
 		// Statistics
 		b.WriteString(labelStyle.Render(fmt.Sprintf("Files: %d/%d  ",
 			p.backupProgress.FilesDone, p.backupProgress.TotalFiles)))
-		b.WriteString(labelStyle.Render(fmt.Sprintf("Data: %s/%s\n",
-			formatBytes(p.backupProgress.BytesDone), formatBytes(p.backupProgress.TotalBytes))))
+		b.WriteString(labelStyle.Render(fmt.Sprintf("Data: %s/%s  ",
+			FormatBytes(p.backupProgress.BytesDone), FormatBytes(p.backupProgress.TotalBytes))))
+		b.WriteString(labelStyle.Render(fmt.Sprintf("Elapsed: %s\n", FormatSeconds(p.backupProgress.SecondsElapsed))))
+		b.WriteString(labelStyle.Render(p.backupRateText()) + "\n")
 
 		// Current file (if available)
 		if len(p.backupProgress.CurrentFiles) > 0 {
@@ -149,25 +480,54 @@ func (p *OperationsPanel) Render(active bool) string {
 		b.WriteString("\n")
 	}
 
+	// Show the running operation below any backup progress
+	if p.activity != "" {
+		activityStyle := lipgloss.NewStyle().Foreground(colorWarning).Bold(true)
+		b.WriteString(activityStyle.Render(p.activity) + "\n\n")
+	}
+
 	// Log entries
+	logs := p.FilteredLogs()
 	if len(p.logs) == 0 {
 		b.WriteString(lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
+			Foreground(colorDimmed).
 			Render("No operations yet"))
+	} else if len(logs) == 0 {
+		// No entries match the filter
+		b.WriteString(lipgloss.NewStyle().
+			Foreground(colorWarning).
+			Render("No log entries match the current filter\n"))
+		b.WriteString(lipgloss.NewStyle().
+			Foreground(colorDimmed).
+			Render("Press Esc to clear filter"))
 	} else {
-		// Show last N entries that fit in the panel
-		maxEntries := (p.height - 8) / 2 // Each entry takes ~2 lines
-		if maxEntries < 1 {
-			maxEntries = 1
+		// Show filter count if active
+		if p.IsFilterActive() {
+			countStyle := lipgloss.NewStyle().
+				Foreground(colorDimmed).
+				Italic(true)
+			b.WriteString(countStyle.Render(fmt.Sprintf("[showing %d of %d]\n\n", len(logs), len(p.logs))))
 		}
 
-		startIdx := len(p.logs) - maxEntries
+		// Show the entries that fit in the panel, ending scrollOffset entries before the newest
+		offset := p.scrollOffset
+		if max := p.maxScrollOffset(); offset > max {
+			offset = max
+		}
+		endIdx := len(logs) - offset
+		startIdx := endIdx - p.pageSize()
 		if startIdx < 0 {
 			startIdx = 0
 		}
 
-		for i := startIdx; i < len(p.logs); i++ {
-			entry := p.logs[i]
+		// Show scroll indicators
+		if startIdx > 0 {
+			scrollTopStyle := lipgloss.NewStyle().Foreground(colorDimmed).Italic(true)
+			b.WriteString(scrollTopStyle.Render("  ▲ more above...\n"))
+		}
+
+		for i := startIdx; i < endIdx; i++ {
+			entry := logs[i]
 
 			// Style based on level
 			var levelStyle lipgloss.Style
@@ -184,15 +544,18 @@ func (p *OperationsPanel) Render(active bool) string {
 				levelStyle = StatusErrorStyle
 				levelPrefix = "✗"
 			case "dimmed":
-				levelStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("237")).Faint(true) // Dimmed and faint
+				levelStyle = lipgloss.NewStyle().Foreground(colorBorder).Faint(true) // Dimmed and faint
 				levelPrefix = "•"
+			case "preview":
+				levelStyle = lipgloss.NewStyle().Foreground(colorInfo)
+				levelPrefix = "◌"
 			default:
-				levelStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+				levelStyle = lipgloss.NewStyle().Foreground(colorDimmed)
 				levelPrefix = "•"
 			}
 
 			timestamp := entry.Timestamp.Format("15:04:05")
-			timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+			timeStyle := lipgloss.NewStyle().Foreground(colorDimmed)
 
 			line := timeStyle.Render(timestamp) + " " +
 				levelStyle.Render(levelPrefix) + " " +
@@ -200,6 +563,12 @@ func (p *OperationsPanel) Render(active bool) string {
 
 			b.WriteString(line + "\n")
 		}
+
+		// Show scroll indicator for newer entries below
+		if endIdx < len(logs) {
+			scrollBottomStyle := lipgloss.NewStyle().Foreground(colorDimmed).Italic(true)
+			b.WriteString(scrollBottomStyle.Render("  ▼ more below...\n"))
+		}
 	}
 
 	// Render panel with embedded title
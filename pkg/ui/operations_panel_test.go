@@ -1,9 +1,14 @@
 package ui
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/craigderington/lazyrestic/pkg/types"
 )
 
 func TestNewOperationsPanel(t *testing.T) {
@@ -86,6 +91,36 @@ func TestOperationsPanel_LogLimit(t *testing.T) {
 	}
 }
 
+func TestOperationsPanel_ConfiguredRetention(t *testing.T) {
+	panel := NewOperationsPanel()
+	panel.SetMaxEntries(2000)
+
+	for i := 0; i < 2500; i++ {
+		panel.Info(fmt.Sprintf("entry %d", i))
+	}
+	if len(panel.logs) != 2000 {
+		t.Fatalf("Logs length = %d, want the configured 2000", len(panel.logs))
+	}
+	if first, last := panel.logs[0].Message, panel.logs[1999].Message; first != "entry 500" || last != "entry 2499" {
+		t.Errorf("Expected the newest 2000 entries kept in order, got %q .. %q", first, last)
+	}
+
+	// Lowering the limit drops the oldest entries straight away
+	panel.SetMaxEntries(10)
+	if len(panel.logs) != 10 || panel.logs[0].Message != "entry 2490" {
+		t.Errorf("Expected the newest 10 entries, got %d starting with %q", len(panel.logs), panel.logs[0].Message)
+	}
+
+	panel.SetMaxEntries(0)
+	if panel.maxEntries != MaxLogEntries {
+		t.Errorf("Unset retention should keep the default %d, got %d", MaxLogEntries, panel.maxEntries)
+	}
+	panel.SetMaxEntries(1000000)
+	if panel.maxEntries != LogEntriesLimit {
+		t.Errorf("Retention should be capped at %d, got %d", LogEntriesLimit, panel.maxEntries)
+	}
+}
+
 func TestOperationsPanel_LogOrder(t *testing.T) {
 	panel := NewOperationsPanel()
 
@@ -286,3 +321,256 @@ func BenchmarkOperationsPanel_Render(b *testing.B) {
 		_ = panel.Render(i%2 == 0)
 	}
 }
+
+func TestOperationsPanel_LevelFilter(t *testing.T) {
+	panel := NewOperationsPanel()
+	panel.Info("info message")
+	panel.Success("success message")
+	panel.Warning("warning message")
+	panel.Error("error message")
+	panel.Dimmed("dimmed message")
+
+	tests := []struct {
+		level    string
+		expected int
+	}{
+		{"", 5},
+		{"warning", 2},
+		{"error", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run("level="+tt.level, func(t *testing.T) {
+			panel.SetLevelFilter(tt.level)
+			if got := len(panel.FilteredLogs()); got != tt.expected {
+				t.Errorf("FilteredLogs() length = %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOperationsPanel_TextFilter(t *testing.T) {
+	panel := NewOperationsPanel()
+	panel.Info("Loaded 3 repositories")
+	panel.Error("Failed to load snapshots from 'offsite'")
+	panel.Warning("Snapshot load slow for OFFSITE")
+
+	panel.SetFilter("offsite")
+	logs := panel.FilteredLogs()
+	if len(logs) != 2 {
+		t.Fatalf("FilteredLogs() length = %d, want 2 (case-insensitive match)", len(logs))
+	}
+
+	// Text and level filters combine
+	panel.SetLevelFilter("error")
+	logs = panel.FilteredLogs()
+	if len(logs) != 1 || logs[0].Level != "error" {
+		t.Errorf("Expected only the error entry, got %+v", logs)
+	}
+
+	panel.SetSize(80, 40)
+	output := panel.Render(true)
+	if !strings.Contains(output, "[showing 1 of 3]") {
+		t.Error("Render should show filtered count")
+	}
+
+	panel.ClearFilter()
+	if panel.IsFilterActive() || len(panel.FilteredLogs()) != 3 {
+		t.Error("ClearFilter() should show all entries")
+	}
+}
+
+func TestOperationsPanel_CycleLevelFilter(t *testing.T) {
+	panel := NewOperationsPanel()
+
+	for _, want := range []string{"warning", "error", ""} {
+		if got := panel.CycleLevelFilter(); got != want {
+			t.Errorf("CycleLevelFilter() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestOperationsPanel_ExportLogs(t *testing.T) {
+	panel := NewOperationsPanel()
+	panel.Info("Backup started")
+	panel.Error("Backup failed: exit status 1")
+
+	path := filepath.Join(t.TempDir(), "logs", "session.log")
+	if err := panel.ExportLogs(path); err != nil {
+		t.Fatalf("ExportLogs() failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Exported log not found: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("Log file permissions = %o, want 600", perm)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read exported log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %q", len(lines), string(data))
+	}
+	if !strings.Contains(lines[1], "[ERROR] Backup failed: exit status 1") {
+		t.Errorf("Unexpected line format: %q", lines[1])
+	}
+	if _, err := time.Parse(time.RFC3339, strings.Fields(lines[0])[0]); err != nil {
+		t.Errorf("Line should start with an RFC3339 timestamp: %q", lines[0])
+	}
+}
+
+func TestOperationsPanel_SetLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lazyrestic.log")
+
+	// Oversized existing log is rolled over
+	if err := os.WriteFile(path, make([]byte, MaxLogFileSize+1), 0600); err != nil {
+		t.Fatalf("Failed to write existing log: %v", err)
+	}
+
+	panel := NewOperationsPanel()
+	if err := panel.SetLogFile(path); err != nil {
+		t.Fatalf("SetLogFile() failed: %v", err)
+	}
+	defer panel.CloseLogFile()
+
+	panel.Warning("Repository locked")
+
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Errorf("Expected rolled-over log at %s.1: %v", path, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if !strings.Contains(string(data), "[WARNING] Repository locked") {
+		t.Errorf("Log file should contain appended entry, got %q", string(data))
+	}
+}
+
+func TestOperationsPanel_ActivityBelowBackupProgress(t *testing.T) {
+	panel := NewOperationsPanel()
+	panel.SetSize(80, 30)
+	panel.SetBackupProgress(&types.BackupProgress{PercentDone: 0.5})
+	panel.SetActivity("⠋ Running unlock... 2s")
+
+	output := panel.Render(false)
+	progress := strings.Index(output, "Backup in Progress")
+	activity := strings.Index(output, "Running unlock... 2s")
+	if progress < 0 || activity < 0 || activity < progress {
+		t.Errorf("Activity should render below the backup progress, got:\n%s", output)
+	}
+
+	panel.ClearActivity()
+	if strings.Contains(panel.Render(false), "Running unlock") {
+		t.Error("ClearActivity should remove the status line")
+	}
+}
+
+func TestOperationsPanel_ScrollBounds(t *testing.T) {
+	panel := NewOperationsPanel()
+	panel.SetSize(100, 18) // Room for 5 entries
+	for i := 0; i < 20; i++ {
+		panel.Info(fmt.Sprintf("entry %02d", i))
+	}
+
+	panel.ScrollDown(3)
+	if got := panel.ScrollOffset(); got != 0 {
+		t.Errorf("ScrollDown at the bottom: offset = %d, want 0", got)
+	}
+
+	panel.PageUp()
+	if got := panel.ScrollOffset(); got != 5 {
+		t.Errorf("PageUp: offset = %d, want 5", got)
+	}
+
+	panel.ScrollUp(100)
+	if got := panel.ScrollOffset(); got != 15 {
+		t.Errorf("ScrollUp past the top: offset = %d, want 15 (oldest page)", got)
+	}
+	output := panel.Render(true)
+	if !strings.Contains(output, "entry 00") || strings.Contains(output, "entry 19") {
+		t.Error("Scrolled to the top, the oldest entries should be shown")
+	}
+	if strings.Contains(output, "more above") || !strings.Contains(output, "more below") {
+		t.Error("At the top only the 'more below' indicator should be shown")
+	}
+
+	panel.PageDown()
+	panel.PageDown()
+	panel.PageDown()
+	panel.PageDown()
+	if got := panel.ScrollOffset(); got != 0 {
+		t.Errorf("PageDown past the bottom: offset = %d, want 0", got)
+	}
+	output = panel.Render(true)
+	if !strings.Contains(output, "entry 19") || !strings.Contains(output, "more above") || strings.Contains(output, "more below") {
+		t.Error("At the bottom the newest entries and only the 'more above' indicator should be shown")
+	}
+}
+
+func TestOperationsPanel_ScrolledViewStaysPutOnNewEntries(t *testing.T) {
+	panel := NewOperationsPanel()
+	panel.SetSize(100, 18)
+	for i := 0; i < 20; i++ {
+		panel.Info(fmt.Sprintf("entry %02d", i))
+	}
+
+	// Following the bottom, new entries come into view
+	panel.Info("newest")
+	if got := panel.ScrollOffset(); got != 0 || !strings.Contains(panel.Render(true), "newest") {
+		t.Errorf("At the bottom the panel should follow new entries, offset = %d", got)
+	}
+
+	// Scrolled back, the same entries stay in view
+	panel.ScrollUp(5)
+	before := panel.Render(true)
+	panel.Info("another")
+	if got := panel.ScrollOffset(); got != 6 {
+		t.Errorf("New entry while scrolled: offset = %d, want 6", got)
+	}
+	if after := panel.Render(true); strings.Contains(after, "another") || !strings.Contains(after, "entry 11") || !strings.Contains(before, "entry 11") {
+		t.Error("Scrolled view should not jump when new entries arrive")
+	}
+
+	panel.SetLevelFilter("error")
+	if got := panel.ScrollOffset(); got != 0 {
+		t.Errorf("Changing the filter should return to the newest entries, offset = %d", got)
+	}
+}
+
+func TestOperationsPanel_BackupThroughputAndETA(t *testing.T) {
+	panel := NewOperationsPanel()
+	panel.SetSize(80, 30)
+	panel.SetBackupProgress(&types.BackupProgress{BytesDone: 10 << 20, SecondsElapsed: 4})
+	if got := panel.backupRateText(); got != "Speed: …  ETA: …" {
+		t.Errorf("Before a second sample, backupRateText() = %q, want both unknown", got)
+	}
+
+	// Updates within the same second don't count as a sample
+	panel.SetBackupProgress(&types.BackupProgress{BytesDone: 12 << 20, SecondsElapsed: 4})
+	panel.SetBackupProgress(&types.BackupProgress{BytesDone: 30 << 20, SecondsElapsed: 6, SecondsRemaining: 250})
+	if got, want := panel.backupRateText(), "Speed: 10.0 MiB/s  ETA: 4m 10s"; got != want {
+		t.Errorf("backupRateText() = %q, want %q", got, want)
+	}
+
+	// The speed is averaged over recent samples to avoid jitter
+	panel.SetBackupProgress(&types.BackupProgress{BytesDone: 50 << 20, SecondsElapsed: 7, SecondsRemaining: 240})
+	if got, want := panel.backupRateText(), "Speed: 15.0 MiB/s  ETA: 4m 00s"; got != want {
+		t.Errorf("backupRateText() = %q, want %q", got, want)
+	}
+	if !strings.Contains(panel.Render(false), "Speed: 15.0 MiB/s") {
+		t.Error("Expected the speed below the backup progress bar")
+	}
+
+	panel.ClearBackupProgress()
+	panel.SetBackupProgress(&types.BackupProgress{BytesDone: 1 << 20, SecondsElapsed: 1})
+	if got := panel.backupRateText(); got != "Speed: …  ETA: …" {
+		t.Errorf("A new backup should start without the previous speed, got %q", got)
+	}
+}
@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxPathCandidates caps how many completion candidates are listed below a field
+const maxPathCandidates = 8
+
+// completePath completes the path being typed at the end of value against the
+// filesystem. The path starts after the last comma, so comma-separated lists
+// complete their last entry. It returns the completed value and, when several
+// entries match, their names (directories end in /). ok is false when the end
+// of value isn't a local path, so Tab can move to the next field instead.
+func completePath(value string) (completed string, candidates []string, ok bool, err error) {
+	start := strings.LastIndex(value, ",") + 1
+	for start < len(value) && value[start] == ' ' {
+		start++
+	}
+	token := value[start:]
+	if !strings.HasPrefix(token, "/") && !strings.HasPrefix(token, "~") && !strings.HasPrefix(token, ".") {
+		return value, nil, false, nil
+	}
+
+	dir, prefix := "", token
+	if i := strings.LastIndex(token, "/"); i >= 0 {
+		dir, prefix = token[:i+1], token[i+1:]
+	} else if token == "~" {
+		// Complete the home directory itself
+		return value + "/", nil, true, nil
+	}
+	if dir == "" {
+		dir = "./"
+	}
+
+	entries, err := os.ReadDir(expandHomePath(dir))
+	if err != nil {
+		return value, nil, true, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		// Hidden entries only complete when asked for
+		if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		if entry.IsDir() || isDirLink(filepath.Join(expandHomePath(dir), name), entry) {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	switch len(names) {
+	case 0:
+		return value, nil, true, nil
+	case 1:
+		return value[:start] + completedToken(token, dir, names[0]), nil, true, nil
+	}
+	return value[:start] + completedToken(token, dir, commonPrefix(names)), names, true, nil
+}
+
+// completedToken replaces the partial name in token with completion; a token
+// without a directory part keeps no "./" prefix
+func completedToken(token, dir, completion string) string {
+	if !strings.Contains(token, "/") {
+		return completion
+	}
+	return dir + completion
+}
+
+// isDirLink reports whether entry is a symlink to a directory
+func isDirLink(path string, entry fs.DirEntry) bool {
+	if entry.Type()&fs.ModeSymlink == 0 {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// expandHomePath expands a leading ~ to the home directory
+func expandHomePath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return home + path[1:]
+		}
+	}
+	return path
+}
+
+// commonPrefix returns the longest prefix shared by names
+func commonPrefix(names []string) string {
+	prefix := []rune(names[0])
+	for _, name := range names[1:] {
+		for !strings.HasPrefix(name, string(prefix)) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return string(prefix)
+}
+
+// pathCompleter adds Tab completion of filesystem paths to a text input and
+// keeps the candidates of the last completion to show below it
+type pathCompleter struct {
+	candidates []string
+	note       string // Why the last completion found nothing to offer
+	stuck      string // Value a completion couldn't extend; Tab on it again moves on
+}
+
+// complete completes the path at the end of input. It returns false when Tab
+// should keep its usual meaning: the cursor isn't at the end of the input, the
+// input doesn't end in a path, or the last Tab already couldn't extend it.
+func (c *pathCompleter) complete(input *textinput.Model) bool {
+	value := input.Value()
+	stuck := c.stuck == value
+	c.reset()
+	if stuck || input.Position() != len([]rune(value)) {
+		return false
+	}
+
+	completed, candidates, ok, err := completePath(value)
+	if !ok {
+		return false
+	}
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		c.note = "permission denied"
+	case err != nil:
+		c.note = "no such directory"
+	case completed == value && len(candidates) == 0:
+		c.note = "no matches"
+	}
+	if completed == value {
+		c.stuck = value
+	}
+	c.candidates = candidates
+	input.SetValue(completed)
+	input.CursorEnd()
+	return true
+}
+
+// reset clears the candidates shown below the input
+func (c *pathCompleter) reset() {
+	c.candidates = nil
+	c.note = ""
+	c.stuck = ""
+}
+
+// view lists the candidates of the last completion, or why there were none
+func (c *pathCompleter) view() string {
+	style := lipgloss.NewStyle().Foreground(colorDimmed)
+	if c.note != "" {
+		return style.Render("  " + c.note)
+	}
+	if len(c.candidates) == 0 {
+		return ""
+	}
+
+	shown := c.candidates
+	more := ""
+	if len(shown) > maxPathCandidates {
+		more = "  …"
+		shown = shown[:maxPathCandidates]
+	}
+	return style.Render("  " + strings.Join(shown, "  ") + more)
+}
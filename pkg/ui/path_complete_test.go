@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// makeTree creates files and directories (names ending in /) under a temp dir
+func makeTree(t *testing.T, names ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if name[len(name)-1] == '/' {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCompletePath(t *testing.T) {
+	dir := makeTree(t, "documents/", "downloads/", "music/", "notes.txt", ".hidden/")
+
+	tests := []struct {
+		name       string
+		value      string
+		want       string
+		candidates []string
+	}{
+		{"unique directory", dir + "/mu", dir + "/music/", nil},
+		{"unique file", dir + "/no", dir + "/notes.txt", nil},
+		{"common prefix", dir + "/d", dir + "/do", []string{"documents/", "downloads/"}},
+		{"list directory", dir + "/", dir + "/", []string{"documents/", "downloads/", "music/", "notes.txt"}},
+		{"hidden on request", dir + "/.h", dir + "/.hidden/", nil},
+		{"no match", dir + "/zzz", dir + "/zzz", nil},
+		{"last entry of a list", "/etc, " + dir + "/mu", "/etc, " + dir + "/music/", nil},
+	}
+	for _, tt := range tests {
+		got, candidates, ok, err := completePath(tt.value)
+		if !ok || err != nil {
+			t.Errorf("%s: completePath(%q) ok=%v err=%v", tt.name, tt.value, ok, err)
+			continue
+		}
+		if got != tt.want || !reflect.DeepEqual(candidates, tt.candidates) {
+			t.Errorf("%s: completePath(%q) = %q %q, want %q %q", tt.name, tt.value, got, candidates, tt.want, tt.candidates)
+		}
+	}
+}
+
+func TestCompletePath_HomeDirectory(t *testing.T) {
+	home := makeTree(t, "projects/")
+	t.Setenv("HOME", home)
+
+	if got, _, ok, _ := completePath("~/pro"); !ok || got != "~/projects/" {
+		t.Errorf("completePath(~/pro) = %q, want ~/projects/ with ~ kept", got)
+	}
+	if got, _, _, _ := completePath("~"); got != "~/" {
+		t.Errorf("completePath(~) = %q, want ~/", got)
+	}
+}
+
+func TestCompletePath_NotAPath(t *testing.T) {
+	for _, value := range []string{"", "s3:bucket/repo", "config, manual"} {
+		if _, _, ok, _ := completePath(value); ok {
+			t.Errorf("completePath(%q) should leave Tab to field navigation", value)
+		}
+	}
+}
+
+func TestPathCompleter_UnreadableDirectory(t *testing.T) {
+	input := textinput.New()
+	input.SetValue(filepath.Join(t.TempDir(), "missing") + "/")
+	var c pathCompleter
+	if !c.complete(&input) || c.note != "no such directory" {
+		t.Errorf("Expected a note for a missing directory, got %q", c.note)
+	}
+
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("directory permissions aren't enforced")
+	}
+	dir := makeTree(t, "locked/")
+	locked := filepath.Join(dir, "locked")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0755) })
+
+	input.SetValue(locked + "/")
+	c = pathCompleter{}
+	if !c.complete(&input) || c.note != "permission denied" || input.Value() != locked+"/" {
+		t.Errorf("Expected the value kept with a permission note, got %q, note %q", input.Value(), c.note)
+	}
+}
+
+func TestBackupForm_TabCompletesThenMovesOn(t *testing.T) {
+	dir := makeTree(t, "srv/", "src/")
+
+	form := NewBackupForm()
+	form.pathsInput.SetValue(dir + "/s")
+	form.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if form.focusedField != BackupFieldPaths || form.pathsInput.Value() != dir+"/sr" {
+		t.Fatalf("Tab should complete the common prefix in place, got %q", form.pathsInput.Value())
+	}
+	if len(form.completer.candidates) != 2 {
+		t.Errorf("Expected both candidates listed, got %q", form.completer.candidates)
+	}
+
+	// Tab that can't extend the path keeps the candidates; Tab once more moves on
+	form.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if form.focusedField != BackupFieldPaths || len(form.completer.candidates) != 2 {
+		t.Fatalf("Tab should list the candidates again before moving on, got %q", form.completer.candidates)
+	}
+	form.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if form.focusedField != BackupFieldTags {
+		t.Errorf("Another Tab should move on, focused field %d", form.focusedField)
+	}
+}
@@ -2,11 +2,15 @@ package ui
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/craigderington/lazyrestic/pkg/types"
 )
 
 // RepoFormField represents which field is being edited
@@ -19,20 +23,28 @@ const (
 	FieldPassword
 	FieldGeneratePasswordFile
 	FieldInitialize
+	FieldPackSize
+	FieldRepoVersion
+	FieldCompression
 	FieldSubmit
 )
 
 // RepoForm represents a form for creating a new repository
 type RepoForm struct {
-	nameInput               textinput.Model
-	pathInput               textinput.Model
-	passwordInput           textinput.Model
-	focusedField            RepoFormField
-	passwordMethod          string // "file" or "command"
+	nameInput                textinput.Model
+	pathInput                textinput.Model
+	passwordInput            textinput.Model
+	packSizeInput            textinput.Model
+	focusedField             RepoFormField
+	passwordMethod           string // "file", "command" or "env"
 	autoGeneratePasswordFile bool   // Whether to auto-generate password file path
-	initializeRepo          bool   // Whether to initialize the repository
-	width                   int
-	height                  int
+	initializeRepo           bool   // Whether to initialize the repository
+	compressionSupported     bool   // restic is new enough for format version 2 and compression
+	repoVersion              int    // Repository format version for init
+	compression              string // Compression mode for init: auto, max or off
+	completer                pathCompleter
+	width                    int
+	height                   int
 }
 
 // NewRepoForm creates a new repository creation form
@@ -51,13 +63,20 @@ func NewRepoForm() *RepoForm {
 	passwordInput.EchoMode = textinput.EchoNormal
 	passwordInput.CharLimit = 200
 
+	packSizeInput := textinput.New()
+	packSizeInput.Placeholder = "64M (optional, 4-128 MiB)"
+	packSizeInput.CharLimit = 10
+
 	return &RepoForm{
-		nameInput:               nameInput,
-		pathInput:               pathInput,
-		passwordInput:           passwordInput,
-		focusedField:            FieldName,
-		passwordMethod:          "file", // Default to secure file method
+		nameInput:                nameInput,
+		pathInput:                pathInput,
+		passwordInput:            passwordInput,
+		packSizeInput:            packSizeInput,
+		focusedField:             FieldName,
+		passwordMethod:           "file", // Default to secure file method
 		autoGeneratePasswordFile: true,   // Auto-generate by default
+		repoVersion:              2,      // Version 2 enables compression
+		compression:              "auto",
 	}
 }
 
@@ -68,13 +87,21 @@ func (f *RepoForm) Update(msg tea.Msg) tea.Cmd {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "tab", "down":
+		case "tab":
+			// Tab at the end of a path completes it before moving on
+			if input := f.pathField(); input != nil && f.completer.complete(input) {
+				return nil
+			}
+			f.NextField()
+			return nil
+		case "down":
 			f.NextField()
 			return nil
 		case "shift+tab", "up":
 			f.PrevField()
 			return nil
 		}
+		f.completer.reset()
 	}
 
 	// Update the focused input
@@ -85,6 +112,8 @@ func (f *RepoForm) Update(msg tea.Msg) tea.Cmd {
 		f.pathInput, cmd = f.pathInput.Update(msg)
 	case FieldPassword:
 		f.passwordInput, cmd = f.passwordInput.Update(msg)
+	case FieldPackSize:
+		f.packSizeInput, cmd = f.packSizeInput.Update(msg)
 	case FieldPasswordMethod:
 		if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == " " {
 			oldMethod := f.passwordMethod
@@ -108,46 +137,101 @@ func (f *RepoForm) Update(msg tea.Msg) tea.Cmd {
 		if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == " " {
 			f.initializeRepo = !f.initializeRepo
 		}
+	case FieldRepoVersion:
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == " " {
+			f.repoVersion = cycle(repoVersions, f.repoVersion)
+			if f.repoVersion < 2 {
+				f.compression = "off" // Version 1 repositories can't be compressed
+			}
+		}
+	case FieldCompression:
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == " " {
+			f.compression = cycle(compressionModes, f.compression)
+			if f.compression != "off" {
+				f.repoVersion = 2
+			}
+		}
 	}
 
 	return cmd
 }
 
-// NextField moves to the next form field
-func (f *RepoForm) NextField() {
-	f.BlurAll()
+// repoVersions and compressionModes are the init choices cycled with space
+var (
+	repoVersions     = []int{2, 1}
+	compressionModes = []string{"auto", "max", "off"}
+)
+
+// cycle returns the choice after current, wrapping around
+func cycle[T comparable](choices []T, current T) T {
+	for i, choice := range choices {
+		if choice == current {
+			return choices[(i+1)%len(choices)]
+		}
+	}
+	return choices[0]
+}
 
-	f.focusedField++
-	if f.focusedField > FieldSubmit {
-		f.focusedField = FieldName
+// fieldHidden reports whether field is skipped by navigation and not rendered
+func (f *RepoForm) fieldHidden(field RepoFormField) bool {
+	switch field {
+	case FieldGeneratePasswordFile:
+		// Only for the "file" password method
+		return f.passwordMethod != "file"
+	case FieldPackSize:
+		// Only used when the repository will be initialized
+		return !f.initializeRepo
+	case FieldRepoVersion, FieldCompression:
+		// Also needs restic 0.14+
+		return !f.initializeRepo || !f.compressionSupported
 	}
+	return false
+}
 
-	// Skip FieldGeneratePasswordFile if password method is "command"
-	if f.focusedField == FieldGeneratePasswordFile && f.passwordMethod == "command" {
+// pathField returns the focused input if it holds a local filesystem path
+func (f *RepoForm) pathField() *textinput.Model {
+	switch f.focusedField {
+	case FieldPath:
+		return &f.pathInput
+	case FieldPassword:
+		if f.passwordMethod == "file" && !f.autoGeneratePasswordFile {
+			return &f.passwordInput
+		}
+	}
+	return nil
+}
+
+// NextField moves to the next form field, skipping hidden ones
+func (f *RepoForm) NextField() {
+	f.completer.reset()
+	f.BlurAll()
+
+	for {
 		f.focusedField++
 		if f.focusedField > FieldSubmit {
 			f.focusedField = FieldName
 		}
+		if !f.fieldHidden(f.focusedField) {
+			break
+		}
 	}
 
 	f.FocusCurrent()
 }
 
-// PrevField moves to the previous form field
+// PrevField moves to the previous form field, skipping hidden ones
 func (f *RepoForm) PrevField() {
+	f.completer.reset()
 	f.BlurAll()
 
-	f.focusedField--
-	if f.focusedField < FieldName {
-		f.focusedField = FieldSubmit
-	}
-
-	// Skip FieldGeneratePasswordFile if password method is "command"
-	if f.focusedField == FieldGeneratePasswordFile && f.passwordMethod == "command" {
+	for {
 		f.focusedField--
 		if f.focusedField < FieldName {
 			f.focusedField = FieldSubmit
 		}
+		if !f.fieldHidden(f.focusedField) {
+			break
+		}
 	}
 
 	f.FocusCurrent()
@@ -158,6 +242,7 @@ func (f *RepoForm) BlurAll() {
 	f.nameInput.Blur()
 	f.pathInput.Blur()
 	f.passwordInput.Blur()
+	f.packSizeInput.Blur()
 }
 
 // FocusCurrent focuses the current field
@@ -169,6 +254,8 @@ func (f *RepoForm) FocusCurrent() {
 		f.pathInput.Focus()
 	case FieldPassword:
 		f.passwordInput.Focus()
+	case FieldPackSize:
+		f.packSizeInput.Focus()
 	}
 }
 
@@ -185,6 +272,9 @@ func (f *RepoForm) updatePasswordPlaceholder() {
 	case "command":
 		f.passwordInput.Placeholder = "pass show restic/my-repo"
 		f.passwordInput.EchoMode = textinput.EchoNormal
+	case "env":
+		f.passwordInput.Placeholder = "MY_REPO_PASSWORD (environment variable name)"
+		f.passwordInput.EchoMode = textinput.EchoNormal
 	}
 }
 
@@ -213,6 +303,39 @@ func (f *RepoForm) ShouldInitialize() bool {
 	return f.initializeRepo
 }
 
+// GetPackSize returns the pack size in MiB for init, or empty for restic's default
+func (f *RepoForm) GetPackSize() string {
+	size, err := parsePackSize(f.packSizeInput.Value())
+	if err != nil || size == 0 {
+		return ""
+	}
+	return strconv.Itoa(size)
+}
+
+// SetCompressionSupported shows the repository version and compression
+// selectors, which need restic 0.14+
+func (f *RepoForm) SetCompressionSupported(supported bool) {
+	f.compressionSupported = supported
+}
+
+// GetRepositoryVersion returns the repository format version for init, or 0
+// for restic's default when the installed restic can't choose one
+func (f *RepoForm) GetRepositoryVersion() int {
+	if !f.compressionSupported {
+		return 0
+	}
+	return f.repoVersion
+}
+
+// GetCompression returns the compression mode for init, or empty for restic's
+// default when the installed restic doesn't compress
+func (f *RepoForm) GetCompression() string {
+	if !f.compressionSupported {
+		return ""
+	}
+	return f.compression
+}
+
 // SetPath sets the repository path
 func (f *RepoForm) SetPath(path string) {
 	f.pathInput.SetValue(path)
@@ -223,20 +346,58 @@ func (f *RepoForm) SetName(name string) {
 	f.nameInput.SetValue(name)
 }
 
+// Backend returns the backend type of the entered path ("local", "s3", ...),
+// or an error when the path is empty or its scheme isn't one restic knows
+func (f *RepoForm) Backend() (string, error) {
+	return types.DetectBackend(f.GetPath())
+}
+
+// PathWarning returns a warning for a local path whose directory doesn't exist,
+// unless the repository will be initialized there. Empty when there's nothing to report.
+func (f *RepoForm) PathWarning() string {
+	if f.initializeRepo {
+		return ""
+	}
+	if backend, err := f.Backend(); err != nil || backend != "local" {
+		return ""
+	}
+
+	path := strings.TrimPrefix(f.GetPath(), "local:")
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "Directory does not exist yet - check 'Initialize repository' to create it"
+	}
+	return ""
+}
+
 // IsValid checks if the form is valid
 func (f *RepoForm) IsValid() bool {
 	// Name and path are always required
 	if f.GetName() == "" || f.GetPath() == "" {
 		return false
 	}
+	if _, err := f.Backend(); err != nil {
+		return false
+	}
+
+	// Pack size is optional but must be well-formed when initializing
+	if f.initializeRepo {
+		if _, err := parsePackSize(f.packSizeInput.Value()); err != nil {
+			return false
+		}
+	}
 
 	// For file method with auto-generation, password can be empty
 	if f.passwordMethod == "file" && f.autoGeneratePasswordFile {
 		return true
 	}
 
-	// Otherwise password/command is required
-	return f.GetPassword() != ""
+	// Otherwise password file path, command or env var name is required
+	return strings.TrimSpace(f.GetPassword()) != ""
 }
 
 // nextPasswordMethod cycles to the next password method
@@ -245,6 +406,8 @@ func (f *RepoForm) nextPasswordMethod() string {
 	case "file":
 		return "command"
 	case "command":
+		return "env"
+	case "env":
 		return "file"
 	default:
 		return "file"
@@ -268,6 +431,7 @@ func (f *RepoForm) SetSize(width, height int) {
 	f.nameInput.Width = width - 20
 	f.pathInput.Width = width - 20
 	f.passwordInput.Width = width - 20
+	f.packSizeInput.Width = width - 20
 }
 
 // Render renders the form
@@ -276,19 +440,19 @@ func (f *RepoForm) Render() string {
 
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("62")).
+		Foreground(colorActive).
 		Padding(0, 1)
 
 	labelStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(colorDimmed).
 		Width(20)
 
 	focusedStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("86")).
+		Foreground(colorPrimary).
 		Bold(true)
 
 	helpStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(colorDimmed).
 		Padding(1, 0)
 
 	title := titleStyle.Render("Create New Repository")
@@ -308,7 +472,22 @@ func (f *RepoForm) Render() string {
 		pathLabel = focusedStyle.Render("▶ Repository Path:")
 	}
 	b.WriteString(pathLabel + "\n")
-	b.WriteString(f.pathInput.View() + "\n\n")
+	b.WriteString(f.pathInput.View() + "\n")
+	if f.focusedField == FieldPath && f.completer.view() != "" {
+		b.WriteString(f.completer.view() + "\n")
+	}
+	if f.GetPath() != "" {
+		if backend, err := f.Backend(); err != nil {
+			errorStyle := lipgloss.NewStyle().Foreground(colorError)
+			b.WriteString(errorStyle.Render("  ⚠ "+err.Error()) + "\n")
+		} else {
+			b.WriteString(helpStyle.UnsetPadding().Render("  Backend: "+backend) + "\n")
+			if warning := f.PathWarning(); warning != "" {
+				b.WriteString(lipgloss.NewStyle().Foreground(colorWarning).Render("  ⚠ "+warning) + "\n")
+			}
+		}
+	}
+	b.WriteString("\n")
 
 	// Password method selector
 	methodLabel := labelStyle.Render("Password Method:")
@@ -317,7 +496,7 @@ func (f *RepoForm) Render() string {
 	}
 	b.WriteString(methodLabel + "\n")
 
-	methods := []string{"file", "command"}
+	methods := []string{"file", "command", "env"}
 	var methodsDisplay []string
 	for _, m := range methods {
 		if m == f.passwordMethod {
@@ -362,6 +541,9 @@ func (f *RepoForm) Render() string {
 		b.WriteString(helpStyle.Render("  (will be created at: ~/.config/lazyrestic/passwords/"+f.GetName()+".txt)") + "\n")
 	} else {
 		b.WriteString(f.passwordInput.View() + "\n")
+		if f.focusedField == FieldPassword {
+			b.WriteString(f.completer.view())
+		}
 	}
 	b.WriteString("\n")
 
@@ -381,6 +563,46 @@ func (f *RepoForm) Render() string {
 	}
 	b.WriteString("\n")
 
+	// Pack size (advanced, only used when initializing)
+	if f.initializeRepo {
+		packSizeLabel := labelStyle.Render("Advanced: Pack Size")
+		if f.focusedField == FieldPackSize {
+			packSizeLabel = focusedStyle.Render("▶ Advanced: Pack Size")
+		}
+		b.WriteString(packSizeLabel + "\n")
+		b.WriteString(f.packSizeInput.View() + "\n")
+		if _, err := parsePackSize(f.packSizeInput.Value()); err != nil {
+			errorStyle := lipgloss.NewStyle().Foreground(colorError)
+			b.WriteString(errorStyle.Render("  ⚠ "+err.Error()) + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	// Repository format and compression (only used when initializing)
+	if !f.fieldHidden(FieldRepoVersion) {
+		renderChoices := func(field RepoFormField, label string, choices []string, current string) {
+			if f.focusedField == field {
+				b.WriteString(focusedStyle.Render("▶ "+label) + "\n")
+			} else {
+				b.WriteString(labelStyle.Render(label) + "\n")
+			}
+			var display []string
+			for _, choice := range choices {
+				if choice == current {
+					choice = fmt.Sprintf("[%s]", choice)
+				}
+				display = append(display, choice)
+			}
+			b.WriteString("  " + strings.Join(display, " | ") + "\n")
+			if f.focusedField == field {
+				b.WriteString(helpStyle.Render("  Press space to cycle") + "\n")
+			}
+			b.WriteString("\n")
+		}
+		renderChoices(FieldRepoVersion, "Repository Version:", []string{"2", "1"}, strconv.Itoa(f.repoVersion))
+		renderChoices(FieldCompression, "Compression:", compressionModes, f.compression)
+	}
+
 	// Submit button
 	submitLabel := "  [ Create Repository ]"
 	if f.focusedField == FieldSubmit {
@@ -394,14 +616,14 @@ func (f *RepoForm) Render() string {
 
 	// Validation message
 	if !f.IsValid() && f.focusedField == FieldSubmit {
-		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		errorStyle := lipgloss.NewStyle().Foreground(colorError)
 		b.WriteString("\n" + errorStyle.Render("⚠ All fields are required"))
 	}
 
 	// Wrap in border
 	borderStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("62")).
+		BorderForeground(colorActive).
 		Padding(1, 2).
 		Width(f.width - 4)
 
@@ -418,6 +640,8 @@ func (f *RepoForm) getPasswordLabel() string {
 		return "Password File Path:"
 	case "command":
 		return "Password Command:"
+	case "env":
+		return "Password Env Var:"
 	default:
 		return "Password File Path:"
 	}
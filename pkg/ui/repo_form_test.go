@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepoForm_PathWarning(t *testing.T) {
+	existing := t.TempDir()
+	missing := filepath.Join(existing, "new-repo")
+
+	tests := []struct {
+		name       string
+		path       string
+		initialize bool
+		warn       bool
+	}{
+		{"existing directory", existing, false, false},
+		{"missing directory", missing, false, true},
+		{"missing directory initialized", missing, true, false},
+		{"remote backend", "s3:s3.amazonaws.com/bucket", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := NewRepoForm()
+			form.SetPath(tt.path)
+			form.initializeRepo = tt.initialize
+
+			if got := form.PathWarning() != ""; got != tt.warn {
+				t.Errorf("PathWarning() for %q = %q, want warning %v", tt.path, form.PathWarning(), tt.warn)
+			}
+		})
+	}
+}
+
+func TestRepoForm_RendersBackend(t *testing.T) {
+	form := NewRepoForm()
+	form.SetSize(100, 40)
+	form.SetName("offsite")
+	form.SetPath("b2:bucket:repo")
+
+	if output := form.Render(); !strings.Contains(output, "Backend: b2") {
+		t.Error("Render() should show the detected backend")
+	}
+
+	form.SetPath("ftp:host/repo")
+	if !strings.Contains(form.Render(), "unknown backend") || form.IsValid() {
+		t.Error("An unknown backend should be reported and keep the form invalid")
+	}
+}
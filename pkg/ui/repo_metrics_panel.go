@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/craigderington/lazyrestic/pkg/types"
@@ -14,6 +15,9 @@ type RepoMetricsPanel struct {
 	height     int
 	repository *types.Repository
 	active     bool
+	unlockKey  string             // Key shown in the hint for locked repositories
+	history    map[string][]int64 // Recent snapshot sizes per repository name, oldest first
+	duplicates map[string]types.DuplicateStats
 }
 
 // NewRepoMetricsPanel creates a new repository metrics panel
@@ -23,9 +27,17 @@ func NewRepoMetricsPanel() *RepoMetricsPanel {
 		height:     10,
 		repository: nil,
 		active:     false,
+		unlockKey:  "u",
+		history:    make(map[string][]int64),
+		duplicates: make(map[string]types.DuplicateStats),
 	}
 }
 
+// SetUnlockKey sets the key named in the unlock hint for locked repositories
+func (p *RepoMetricsPanel) SetUnlockKey(key string) {
+	p.unlockKey = key
+}
+
 // SetSize updates the panel dimensions
 func (p *RepoMetricsPanel) SetSize(width, height int) {
 	p.width = width
@@ -37,6 +49,30 @@ func (p *RepoMetricsPanel) SetRepository(repo *types.Repository) {
 	p.repository = repo
 }
 
+// SetSizeHistory sets the recent snapshot sizes of a repository, oldest
+// first, shown as a growth sparkline while it is selected
+func (p *RepoMetricsPanel) SetSizeHistory(repoName string, sizes []int64) {
+	p.history[repoName] = sizes
+}
+
+// SetDuplicateStats sets the duplicate data of a repository, shown while it is selected
+func (p *RepoMetricsPanel) SetDuplicateStats(repoName string, stats types.DuplicateStats) {
+	p.duplicates[repoName] = stats
+}
+
+// RenameRepository moves the size history and duplicate files of a renamed
+// repository to its new name
+func (p *RepoMetricsPanel) RenameRepository(oldName, newName string) {
+	if sizes, ok := p.history[oldName]; ok {
+		p.history[newName] = sizes
+		delete(p.history, oldName)
+	}
+	if stats, ok := p.duplicates[oldName]; ok {
+		p.duplicates[newName] = stats
+		delete(p.duplicates, oldName)
+	}
+}
+
 // SetActive sets whether this panel is active
 func (p *RepoMetricsPanel) SetActive(active bool) {
 	p.active = active
@@ -83,13 +119,19 @@ func (p *RepoMetricsPanel) Render() string {
 	col1 = append(col1, "")
 	col1 = append(col1, lipgloss.NewStyle().Foreground(colorInfo).Render("Status:"))
 	col1 = append(col1, "  "+StatusStyle(p.repository.Status).Render(p.repository.Status))
+	if p.repository.Status == StatusLocked {
+		col1 = append(col1, lipgloss.NewStyle().Foreground(colorDimmed).Render(fmt.Sprintf("  press %s to unlock", p.unlockKey)))
+	}
+	col1 = append(col1, "")
+	col1 = append(col1, lipgloss.NewStyle().Foreground(colorInfo).Render("Total Files:"))
+	col1 = append(col1, fmt.Sprintf("  %d", p.repository.TotalFiles))
 
-	// Column 2: Size and Files
-	col2 = append(col2, lipgloss.NewStyle().Foreground(colorInfo).Render("Total Size:"))
-	col2 = append(col2, fmt.Sprintf("  %s", formatBytes(p.repository.Size)))
+	// Column 2: Logical and stored sizes
+	col2 = append(col2, lipgloss.NewStyle().Foreground(colorInfo).Render("Logical Size:"))
+	col2 = append(col2, fmt.Sprintf("  %s", FormatBytes(p.repository.Size)))
 	col2 = append(col2, "")
-	col2 = append(col2, lipgloss.NewStyle().Foreground(colorInfo).Render("Total Files:"))
-	col2 = append(col2, fmt.Sprintf("  %d", p.repository.TotalFiles))
+	col2 = append(col2, lipgloss.NewStyle().Foreground(colorInfo).Render("Stored Size (deduplicated):"))
+	col2 = append(col2, "  "+storedSizeText(*p.repository))
 
 	// Join columns
 	col1Str := strings.Join(col1, "\n")
@@ -109,6 +151,98 @@ func (p *RepoMetricsPanel) Render() string {
 		lines = append(lines, "  "+FormatTimeAgo(p.repository.LastBackup))
 	}
 
+	// Backup schedule and when the next backup is due
+	if p.repository.Schedule != "" {
+		lines = append(lines, "")
+		lines = append(lines, lipgloss.NewStyle().Foreground(colorInfo).Render("Schedule:"))
+		lines = append(lines, "  "+p.repository.Schedule+" · "+scheduleStatus(*p.repository, time.Now()))
+	}
+
+	// Growth across recent snapshots
+	if sizes := p.history[p.repository.Name]; len(sizes) >= 2 {
+		lines = append(lines, "")
+		lines = append(lines, lipgloss.NewStyle().Foreground(colorInfo).Render(fmt.Sprintf("Growth (last %d snapshots):", len(sizes))))
+		trend := fmt.Sprintf("  %s → %s", FormatBytes(sizes[0]), FormatBytes(sizes[len(sizes)-1]))
+		lines = append(lines, "  "+Sparkline(sizes, p.width-8-lipgloss.Width(trend))+trend)
+	}
+
+	// Duplicate files in the latest snapshot, once computed on request
+	if stats, ok := p.duplicates[p.repository.Name]; ok {
+		lines = append(lines, "")
+		lines = append(lines, lipgloss.NewStyle().Foreground(colorInfo).Render("Duplicate files in latest snapshot:"))
+		lines = append(lines, "  "+FormatDuplicateData(stats))
+	}
+
 	// Render panel with embedded title
 	return RenderPanelWithTitle(title, strings.Join(lines, "\n"), p.width, p.height, p.active)
 }
+
+// FormatDuplicateData describes how much of the data in a snapshot repeats the
+// contents of other files in it, e.g. "6.0 MB (60%)"
+func FormatDuplicateData(stats types.DuplicateStats) string {
+	duplicate := stats.TotalSize - stats.UniqueSize
+	if stats.TotalSize <= 0 || duplicate <= 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%s (%.0f%%)", FormatBytes(duplicate), float64(duplicate)*100/float64(stats.TotalSize))
+}
+
+// sparkLevels are the block characters of a sparkline, lowest first
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws values as block characters scaled between their minimum
+// and maximum, one per value. When there are more values than width, the
+// latest width values are drawn. Fewer than two values draw nothing.
+func Sparkline(values []int64, width int) string {
+	if width < 2 || len(values) < 2 {
+		return ""
+	}
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+
+	low, high := values[0], values[0]
+	for _, v := range values {
+		low = min(low, v)
+		high = max(high, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		level := len(sparkLevels) / 2
+		if high > low {
+			level = int((v - low) * int64(len(sparkLevels)-1) / (high - low))
+		}
+		b.WriteRune(sparkLevels[level])
+	}
+	return b.String()
+}
+
+// storedSizeText formats the deduplicated size with the dedup ratio against the logical size
+func storedSizeText(repo types.Repository) string {
+	if repo.StoredSize <= 0 {
+		return lipgloss.NewStyle().Foreground(colorDimmed).Render("unknown")
+	}
+	text := FormatBytes(repo.StoredSize)
+	if repo.Size > 0 {
+		text += fmt.Sprintf(" · %.1fx dedup", float64(repo.Size)/float64(repo.StoredSize))
+	}
+	return text
+}
+
+// scheduleStatus describes when a scheduled repository's next backup is due
+func scheduleStatus(repo types.Repository, now time.Time) string {
+	if _, err := types.ParseSchedule(repo.Schedule); err != nil {
+		return "invalid schedule"
+	}
+	if repo.Status == "error" {
+		return "next backup unknown"
+	}
+	if types.IsOverdue(repo, now) {
+		if repo.LastBackup.IsZero() {
+			return StatusWarningStyle.Render(OverdueBadge + " (never backed up)")
+		}
+		return StatusWarningStyle.Render(fmt.Sprintf("%s by %s", OverdueBadge, FormatDuration(now.Sub(types.NextDue(repo)))))
+	}
+	return "next due in " + FormatDuration(types.NextDue(repo).Sub(now))
+}
@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+func TestRepoMetricsPanel_RendersLogicalAndStoredSize(t *testing.T) {
+	panel := NewRepoMetricsPanel()
+	panel.SetSize(100, 30)
+	panel.SetRepository(&types.Repository{Name: "home", Size: 4 * 1024 * 1024, StoredSize: 1024 * 1024, Status: "healthy"})
+
+	output := panel.Render()
+	for _, want := range []string{"Logical Size:", "4.0 MiB", "Stored Size (deduplicated):", "1.0 MiB · 4.0x dedup"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Metrics panel missing %q", want)
+		}
+	}
+}
+
+func TestStoredSizeText_Unknown(t *testing.T) {
+	if got := storedSizeText(types.Repository{Size: 4096}); !strings.Contains(got, "unknown") {
+		t.Errorf("storedSizeText() = %q, want unknown when the stored size wasn't computed", got)
+	}
+}
+
+func TestRepoMetricsPanel_LockedShowsUnlockHint(t *testing.T) {
+	panel := NewRepoMetricsPanel()
+	panel.SetSize(100, 30)
+	panel.SetUnlockKey("U")
+	panel.SetRepository(&types.Repository{Name: "home", Status: StatusLocked})
+
+	output := panel.Render()
+	if !strings.Contains(output, "locked") || !strings.Contains(output, "press U to unlock") {
+		t.Errorf("Locked repository should show an unlock hint, got:\n%s", output)
+	}
+
+	panel.SetRepository(&types.Repository{Name: "home", Status: StatusError})
+	if strings.Contains(panel.Render(), "to unlock") {
+		t.Error("Unlock hint should only appear for locked repositories")
+	}
+}
+
+func TestSparkline_ScalesBetweenMinAndMax(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []int64
+		width  int
+		want   string
+	}{
+		{"rising", []int64{10, 20, 30, 40, 50, 60, 70, 80}, 20, "▁▂▃▄▅▆▇█"},
+		{"range not from zero", []int64{1000, 1070, 1035}, 20, "▁█▄"},
+		{"flat", []int64{5, 5, 5}, 20, "▅▅▅"},
+		{"latest values fit the width", []int64{0, 100, 0, 100}, 3, "█▁█"},
+		{"single value", []int64{42}, 20, ""},
+		{"no room", []int64{1, 2}, 1, ""},
+	}
+	for _, tt := range tests {
+		if got := Sparkline(tt.values, tt.width); got != tt.want {
+			t.Errorf("%s: Sparkline(%v, %d) = %q, want %q", tt.name, tt.values, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestRepoMetricsPanel_SizeHistory(t *testing.T) {
+	panel := NewRepoMetricsPanel()
+	panel.SetSize(100, 30)
+	panel.SetRepository(&types.Repository{Name: "home", Status: "healthy"})
+
+	panel.SetSizeHistory("home", []int64{1024})
+	if strings.Contains(panel.Render(), "Growth") {
+		t.Error("A single snapshot has no trend to show")
+	}
+
+	panel.SetSizeHistory("home", []int64{1024, 2048})
+	panel.SetSizeHistory("other", []int64{1, 2, 3})
+	output := panel.Render()
+	if !strings.Contains(output, "Growth (last 2 snapshots):") || !strings.Contains(output, "▁█") {
+		t.Errorf("Expected the growth sparkline of the selected repository, got:\n%s", output)
+	}
+}
+
+func TestFormatDuplicateData(t *testing.T) {
+	tests := []struct {
+		stats types.DuplicateStats
+		want  string
+	}{
+		{types.DuplicateStats{TotalSize: 10 * 1024 * 1024, UniqueSize: 4 * 1024 * 1024}, "6.0 MiB (60%)"},
+		{types.DuplicateStats{TotalSize: 4096, UniqueSize: 4096}, "none"},
+		{types.DuplicateStats{}, "none"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuplicateData(tt.stats); got != tt.want {
+			t.Errorf("FormatDuplicateData(%+v) = %q, want %q", tt.stats, got, tt.want)
+		}
+	}
+}
+
+func TestRepoMetricsPanel_DuplicateData(t *testing.T) {
+	panel := NewRepoMetricsPanel()
+	panel.SetSize(100, 30)
+	panel.SetRepository(&types.Repository{Name: "home", Status: "healthy"})
+	if strings.Contains(panel.Render(), "Duplicate files") {
+		t.Error("Duplicate files should only show once computed")
+	}
+
+	panel.SetDuplicateStats("home", types.DuplicateStats{TotalSize: 2048, UniqueSize: 1024})
+	if output := panel.Render(); !strings.Contains(output, "Duplicate files in latest snapshot:") || !strings.Contains(output, "1.0 KiB (50%)") {
+		t.Errorf("Expected the duplicate files of the selected repository, got:\n%s", output)
+	}
+}
@@ -2,16 +2,31 @@ package ui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/craigderington/lazyrestic/pkg/types"
 )
 
+// UngroupedLabel is the header shown above repositories without a group
+const UngroupedLabel = "Ungrouped"
+
+// repoRow is one line of the panel: a group header or a repository
+type repoRow struct {
+	group  string // Group the row belongs to ("" for ungrouped)
+	header bool   // True for group headers
+	index  int    // Index into repositories for repository rows
+}
+
 // RepositoryPanel represents the repository list panel
 type RepositoryPanel struct {
 	repositories []types.Repository
-	selected     int
+	rows         []repoRow       // Visible rows (headers and repositories of expanded groups)
+	collapsed    map[string]bool // Collapsed groups by name
+	selected     int             // Index into rows
+	overdueFirst bool            // List repositories overdue for backup first
 	width        int
 	height       int
 	scrollOffset int // Viewport scroll offset
@@ -21,6 +36,7 @@ type RepositoryPanel struct {
 func NewRepositoryPanel() *RepositoryPanel {
 	return &RepositoryPanel{
 		repositories: []types.Repository{},
+		collapsed:    make(map[string]bool),
 		selected:     0,
 	}
 }
@@ -28,13 +44,90 @@ func NewRepositoryPanel() *RepositoryPanel {
 // SetRepositories updates the list of repositories
 func (p *RepositoryPanel) SetRepositories(repos []types.Repository) {
 	p.repositories = repos
-	if p.selected >= len(repos) && len(repos) > 0 {
-		p.selected = len(repos) - 1
+	p.buildRows()
+	if p.selected >= len(p.rows) && len(p.rows) > 0 {
+		p.selected = len(p.rows) - 1
 	}
 	// Reset scroll when repos change
 	p.scrollOffset = 0
 }
 
+// grouped reports whether any repository has a group; without groups the
+// panel renders a flat list with no headers
+func (p *RepositoryPanel) grouped() bool {
+	for _, repo := range p.repositories {
+		if repo.Group != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// buildRows lays out the visible rows: groups sorted by name with ungrouped
+// repositories last, members in config order (overdue first when enabled),
+// collapsed members hidden
+func (p *RepositoryPanel) buildRows() {
+	p.rows = p.rows[:0]
+	if !p.grouped() {
+		all := make([]int, len(p.repositories))
+		for i := range p.repositories {
+			all[i] = i
+		}
+		for _, i := range p.orderMembers(all) {
+			p.rows = append(p.rows, repoRow{index: i})
+		}
+		return
+	}
+
+	members := make(map[string][]int)
+	var groups []string
+	for i, repo := range p.repositories {
+		if _, seen := members[repo.Group]; !seen && repo.Group != "" {
+			groups = append(groups, repo.Group)
+		}
+		members[repo.Group] = append(members[repo.Group], i)
+	}
+	sort.Strings(groups)
+	if len(members[""]) > 0 {
+		groups = append(groups, "")
+	}
+
+	for _, group := range groups {
+		p.rows = append(p.rows, repoRow{group: group, header: true})
+		if p.collapsed[group] {
+			continue
+		}
+		for _, i := range p.orderMembers(members[group]) {
+			p.rows = append(p.rows, repoRow{group: group, index: i})
+		}
+	}
+}
+
+// orderMembers moves overdue repositories to the front when overdue-first
+// ordering is enabled, keeping config order otherwise
+func (p *RepositoryPanel) orderMembers(indices []int) []int {
+	if !p.overdueFirst {
+		return indices
+	}
+	now := time.Now()
+	sort.SliceStable(indices, func(a, b int) bool {
+		return types.IsOverdue(p.repositories[indices[a]], now) &&
+			!types.IsOverdue(p.repositories[indices[b]], now)
+	})
+	return indices
+}
+
+// groupSize returns the number of repositories in a group
+func (p *RepositoryPanel) groupSize(group string) int {
+	count := 0
+	for _, repo := range p.repositories {
+		if repo.Group == group {
+			count++
+		}
+	}
+	return count
+}
+
 // SetSize updates the panel dimensions
 func (p *RepositoryPanel) SetSize(width, height int) {
 	p.width = width
@@ -54,7 +147,7 @@ func (p *RepositoryPanel) MoveUp() {
 
 // MoveDown moves the selection down
 func (p *RepositoryPanel) MoveDown() {
-	if p.selected < len(p.repositories)-1 {
+	if p.selected < len(p.rows)-1 {
 		p.selected++
 		// Adjust scroll offset to keep selection visible
 		// Each repo takes ~3 lines (name + path + spacing)
@@ -68,10 +161,128 @@ func (p *RepositoryPanel) MoveDown() {
 	}
 }
 
-// GetSelected returns the currently selected repository
+// ToggleGroup collapses or expands the group of the selected row. Collapsing
+// from a repository moves the selection to its group header. Returns false
+// when the list has no groups.
+func (p *RepositoryPanel) ToggleGroup() bool {
+	if p.selected < 0 || p.selected >= len(p.rows) || !p.grouped() {
+		return false
+	}
+
+	if p.collapsed == nil {
+		p.collapsed = make(map[string]bool)
+	}
+	group := p.rows[p.selected].group
+	p.collapsed[group] = !p.collapsed[group]
+	p.buildRows()
+
+	for i, row := range p.rows {
+		if row.header && row.group == group {
+			p.selected = i
+			break
+		}
+	}
+	if p.selected < p.scrollOffset {
+		p.scrollOffset = p.selected
+	}
+	return true
+}
+
+// ToggleOverdueFirst switches between config order and listing repositories
+// overdue for backup first. Returns the new setting.
+func (p *RepositoryPanel) ToggleOverdueFirst() bool {
+	p.overdueFirst = !p.overdueFirst
+	p.buildRows()
+	if p.selected >= len(p.rows) && len(p.rows) > 0 {
+		p.selected = len(p.rows) - 1
+	}
+	return p.overdueFirst
+}
+
+// SelectRepository selects the repository with the given name, expanding its
+// group if it is collapsed. Returns false when no repository has that name.
+func (p *RepositoryPanel) SelectRepository(name string) bool {
+	index := -1
+	for i, repo := range p.repositories {
+		if repo.Name == name {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return false
+	}
+
+	if group := p.repositories[index].Group; p.collapsed[group] {
+		p.collapsed[group] = false
+		p.buildRows()
+	}
+	for i, row := range p.rows {
+		if !row.header && row.index == index {
+			p.selected = i
+			break
+		}
+	}
+
+	p.scrollToSelected()
+	return true
+}
+
+// SelectNearestRepository moves the selection from a group header to the
+// nearest listed repository, looking down first. Returns nil when every group
+// is collapsed.
+func (p *RepositoryPanel) SelectNearestRepository() *types.Repository {
+	for distance := 0; distance < len(p.rows); distance++ {
+		for _, i := range []int{p.selected + distance, p.selected - distance} {
+			if i >= 0 && i < len(p.rows) && !p.rows[i].header {
+				p.selected = i
+				p.scrollToSelected()
+				return &p.repositories[p.rows[i].index]
+			}
+		}
+	}
+	return nil
+}
+
+// RepositoryListed reports whether the named repository has a row, i.e. its
+// group isn't collapsed
+func (p *RepositoryPanel) RepositoryListed(name string) bool {
+	for _, row := range p.rows {
+		if !row.header && p.repositories[row.index].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// scrollToSelected keeps the selection visible, estimating rows per screen as
+// MoveDown does
+func (p *RepositoryPanel) scrollToSelected() {
+	visibleRepos := (p.height - 6) / 3
+	if visibleRepos < 1 {
+		visibleRepos = 1
+	}
+	if p.selected < p.scrollOffset {
+		p.scrollOffset = p.selected
+	} else if p.selected >= p.scrollOffset+visibleRepos {
+		p.scrollOffset = p.selected - visibleRepos + 1
+	}
+}
+
+// SelectedGroup returns the group name of the selected header; ok is false
+// when a repository (or nothing) is selected
+func (p *RepositoryPanel) SelectedGroup() (group string, ok bool) {
+	if p.selected < 0 || p.selected >= len(p.rows) || !p.rows[p.selected].header {
+		return "", false
+	}
+	return p.rows[p.selected].group, true
+}
+
+// GetSelected returns the currently selected repository, or nil when a group
+// header is selected
 func (p *RepositoryPanel) GetSelected() *types.Repository {
-	if p.selected >= 0 && p.selected < len(p.repositories) {
-		return &p.repositories[p.selected]
+	if p.selected >= 0 && p.selected < len(p.rows) && !p.rows[p.selected].header {
+		return &p.repositories[p.rows[p.selected].index]
 	}
 	return nil
 }
@@ -81,6 +292,9 @@ func (p *RepositoryPanel) Render(active bool) string {
 	var b strings.Builder
 
 	title := "[1] Repositories"
+	if p.overdueFirst {
+		title += " [overdue first]"
+	}
 
 	// Add top margin/padding for breathing room
 	b.WriteString("\n")
@@ -88,12 +302,14 @@ func (p *RepositoryPanel) Render(active bool) string {
 	// Repository list
 	if len(p.repositories) == 0 {
 		b.WriteString(lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
+			Foreground(colorDimmed).
 			Render("No repositories configured\n"))
 		b.WriteString(lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
+			Foreground(colorDimmed).
 			Render("Add repositories to ~/.config/lazyrestic/config.yaml"))
 	} else {
+		now := time.Now()
+
 		// Calculate visible area for viewport scrolling
 		// Each repo takes ~3 lines (name + path + spacing)
 		visibleRepos := (p.height - 6) / 3
@@ -101,24 +317,30 @@ func (p *RepositoryPanel) Render(active bool) string {
 			visibleRepos = 1
 		}
 
-		totalRepos := len(p.repositories)
+		totalRows := len(p.rows)
 
 		// Show scroll indicator at top
 		if p.scrollOffset > 0 {
-			scrollTopStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
+			scrollTopStyle := lipgloss.NewStyle().Foreground(colorDimmed).Italic(true)
 			b.WriteString(scrollTopStyle.Render("  ▲ more above...\n"))
 		}
 
 		// Calculate viewport bounds
 		startIdx := p.scrollOffset
 		endIdx := p.scrollOffset + visibleRepos
-		if endIdx > totalRepos {
-			endIdx = totalRepos
+		if endIdx > totalRows {
+			endIdx = totalRows
 		}
 
-		// Render only visible repositories
+		// Render only visible rows
 		for i := startIdx; i < endIdx; i++ {
-			repo := p.repositories[i]
+			row := p.rows[i]
+			if row.header {
+				b.WriteString(p.renderGroupHeader(row.group, i == p.selected, active) + "\n")
+				continue
+			}
+
+			repo := p.repositories[row.index]
 			var line string
 			if i == p.selected && active {
 				line = ListItemSelectedStyle.Render(fmt.Sprintf("▶ %s", repo.Name))
@@ -128,6 +350,10 @@ func (p *RepositoryPanel) Render(active bool) string {
 				line = ListItemStyle.Render(fmt.Sprintf("  %s", repo.Name))
 			}
 
+			if types.IsOverdue(repo, now) {
+				line += " " + StatusWarningStyle.Render(OverdueBadge)
+			}
+
 			b.WriteString(line + "\n")
 
 			// Show path in dimmed color (for all repos, not just selected)
@@ -148,8 +374,8 @@ func (p *RepositoryPanel) Render(active bool) string {
 		}
 
 		// Show scroll indicator at bottom
-		if endIdx < totalRepos {
-			scrollBottomStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
+		if endIdx < totalRows {
+			scrollBottomStyle := lipgloss.NewStyle().Foreground(colorDimmed).Italic(true)
 			b.WriteString(scrollBottomStyle.Render("  ▼ more below...\n"))
 		}
 	}
@@ -157,3 +383,21 @@ func (p *RepositoryPanel) Render(active bool) string {
 	// Render panel with embedded title
 	return RenderPanelWithTitle(title, b.String(), p.width, p.height, active)
 }
+
+// renderGroupHeader renders a group header line such as "▾ work (3)"
+func (p *RepositoryPanel) renderGroupHeader(group string, selected, active bool) string {
+	marker := "▾"
+	if p.collapsed[group] {
+		marker = "▸"
+	}
+	label := group
+	if label == "" {
+		label = UngroupedLabel
+	}
+	text := fmt.Sprintf("%s %s (%d)", marker, label, p.groupSize(group))
+
+	if selected && active {
+		return ListItemSelectedStyle.Render(text)
+	}
+	return lipgloss.NewStyle().Foreground(colorPrimary).Bold(true).Render(text)
+}
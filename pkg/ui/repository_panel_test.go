@@ -284,3 +284,194 @@ func BenchmarkRepositoryPanel_Render(b *testing.B) {
 		_ = panel.Render(i%2 == 0)
 	}
 }
+
+// groupedRepos returns repositories in config order across three groups
+func groupedRepos() []types.Repository {
+	return []types.Repository{
+		{Name: "laptop", Path: "/tmp/laptop", Group: "personal"},
+		{Name: "scratch", Path: "/tmp/scratch"},
+		{Name: "wiki", Path: "/tmp/wiki", Group: "work"},
+		{Name: "photos", Path: "/tmp/photos", Group: "personal"},
+		{Name: "b2", Path: "b2:bucket:path", Group: "offsite"},
+	}
+}
+
+// rowLabels describes the visible rows as "[group]" headers and repository names
+func rowLabels(p *RepositoryPanel) []string {
+	var labels []string
+	for _, row := range p.rows {
+		if row.header {
+			labels = append(labels, "["+row.group+"]")
+		} else {
+			labels = append(labels, p.repositories[row.index].Name)
+		}
+	}
+	return labels
+}
+
+func TestRepositoryPanel_GroupOrdering(t *testing.T) {
+	panel := NewRepositoryPanel()
+	panel.SetRepositories(groupedRepos())
+
+	// Groups sorted by name, ungrouped last, members in config order
+	want := "[offsite] b2 [personal] laptop photos [work] wiki [] scratch"
+	if got := strings.Join(rowLabels(panel), " "); got != want {
+		t.Errorf("Rows = %q, want %q", got, want)
+	}
+
+	panel.SetSize(80, 60)
+	output := panel.Render(false)
+	for _, header := range []string{"offsite (1)", "personal (2)", "work (1)", UngroupedLabel + " (1)"} {
+		if !strings.Contains(output, header) {
+			t.Errorf("Render() missing group header %q", header)
+		}
+	}
+}
+
+func TestRepositoryPanel_NoGroupsRendersFlatList(t *testing.T) {
+	panel := NewRepositoryPanel()
+	panel.SetRepositories([]types.Repository{
+		{Name: "repo1", Path: "/tmp/1"},
+		{Name: "repo2", Path: "/tmp/2"},
+	})
+
+	if got := strings.Join(rowLabels(panel), " "); got != "repo1 repo2" {
+		t.Errorf("Rows = %q, want flat list without headers", got)
+	}
+	if panel.ToggleGroup() {
+		t.Error("ToggleGroup() should do nothing without groups")
+	}
+}
+
+func TestRepositoryPanel_NavigationSkipsCollapsedGroup(t *testing.T) {
+	panel := NewRepositoryPanel()
+	panel.SetRepositories(groupedRepos())
+
+	// Select "laptop" and collapse its group from there
+	for panel.GetSelected() == nil || panel.GetSelected().Name != "laptop" {
+		panel.MoveDown()
+	}
+	if !panel.ToggleGroup() {
+		t.Fatal("ToggleGroup() should collapse the selected repository's group")
+	}
+	if group, ok := panel.SelectedGroup(); !ok || group != "personal" {
+		t.Errorf("Selection should move to the collapsed header, got %q (header=%v)", group, ok)
+	}
+
+	// Moving down from the collapsed header skips laptop and photos
+	panel.MoveDown()
+	if group, ok := panel.SelectedGroup(); !ok || group != "work" {
+		t.Errorf("MoveDown should land on the next header, got %q (header=%v)", group, ok)
+	}
+	panel.MoveDown()
+	if repo := panel.GetSelected(); repo == nil || repo.Name != "wiki" {
+		t.Errorf("Expected wiki after the work header, got %v", repo)
+	}
+
+	// Moving back up passes the collapsed header without entering the group
+	panel.MoveUp()
+	panel.MoveUp()
+	panel.MoveUp()
+	if repo := panel.GetSelected(); repo == nil || repo.Name != "b2" {
+		t.Errorf("Expected b2 above the collapsed group, got %v", repo)
+	}
+
+	// Collapsed state survives a refresh of the repository list
+	panel.SetRepositories(groupedRepos())
+	if got := strings.Join(rowLabels(panel), " "); strings.Contains(got, "laptop") {
+		t.Errorf("Collapsed members reappeared after SetRepositories: %q", got)
+	}
+
+	// Expanding from the header shows the members again
+	panel.MoveDown()
+	panel.ToggleGroup()
+	if got := strings.Join(rowLabels(panel), " "); !strings.Contains(got, "[personal] laptop photos") {
+		t.Errorf("Expanded group rows = %q", got)
+	}
+}
+
+func TestRepositoryPanel_OverdueFirst(t *testing.T) {
+	now := time.Now()
+	panel := NewRepositoryPanel()
+	panel.SetSize(80, 60)
+	panel.SetRepositories([]types.Repository{
+		{Name: "fresh", Path: "/tmp/1", Schedule: "daily", LastBackup: now.Add(-time.Hour)},
+		{Name: "unscheduled", Path: "/tmp/2"},
+		{Name: "stale", Path: "/tmp/3", Schedule: "daily", LastBackup: now.Add(-72 * time.Hour)},
+	})
+
+	// Config order by default, badge only on the overdue repository
+	if got := strings.Join(rowLabels(panel), " "); got != "fresh unscheduled stale" {
+		t.Errorf("Rows = %q, want config order", got)
+	}
+	output := panel.Render(false)
+	if strings.Count(output, OverdueBadge) != 1 {
+		t.Errorf("Render() should show exactly one %q badge, got:\n%s", OverdueBadge, output)
+	}
+
+	if !panel.ToggleOverdueFirst() {
+		t.Fatal("ToggleOverdueFirst() should enable overdue-first ordering")
+	}
+	if got := strings.Join(rowLabels(panel), " "); got != "stale fresh unscheduled" {
+		t.Errorf("Rows = %q, want overdue repository first", got)
+	}
+	if !strings.Contains(panel.Render(false), "[overdue first]") {
+		t.Error("Render() title should mention overdue-first ordering")
+	}
+
+	panel.ToggleOverdueFirst()
+	if got := strings.Join(rowLabels(panel), " "); got != "fresh unscheduled stale" {
+		t.Errorf("Rows = %q, want config order after toggling back", got)
+	}
+}
+
+func TestRepositoryPanel_SelectRepository(t *testing.T) {
+	panel := NewRepositoryPanel()
+	panel.SetRepositories([]types.Repository{
+		{Name: "home", Group: "laptop"},
+		{Name: "photos", Group: "nas"},
+		{Name: "media", Group: "nas"},
+	})
+
+	// Collapse the nas group from its header
+	panel.MoveDown()
+	panel.MoveDown()
+	panel.ToggleGroup()
+
+	if !panel.SelectRepository("media") {
+		t.Fatal("SelectRepository(media) = false, want true")
+	}
+	if selected := panel.GetSelected(); selected == nil || selected.Name != "media" {
+		t.Errorf("Selected = %v, want media with its group expanded", selected)
+	}
+	if panel.SelectRepository("missing") {
+		t.Error("SelectRepository(missing) = true, want false")
+	}
+}
+
+func TestRepositoryPanel_SelectNearestRepository(t *testing.T) {
+	panel := NewRepositoryPanel()
+	panel.SetRepositories(groupedRepos())
+
+	// Collapse "personal" from laptop; the header stays selected
+	panel.SelectRepository("laptop")
+	panel.ToggleGroup()
+	if panel.RepositoryListed("laptop") || !panel.RepositoryListed("wiki") {
+		t.Fatal("Only repositories of expanded groups should be listed")
+	}
+	if repo := panel.SelectNearestRepository(); repo == nil || repo.Name != "b2" {
+		t.Errorf("SelectNearestRepository() = %v, want b2 next to the collapsed header", repo)
+	}
+	if repo := panel.GetSelected(); repo == nil || repo.Name != "b2" {
+		t.Errorf("Selection = %v, want b2", repo)
+	}
+
+	// With every group collapsed there is nothing to select
+	for _, name := range []string{"b2", "wiki", "scratch"} {
+		panel.SelectRepository(name)
+		panel.ToggleGroup()
+	}
+	if repo := panel.SelectNearestRepository(); repo != nil {
+		t.Errorf("SelectNearestRepository() = %v with every group collapsed, want nil", repo)
+	}
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -15,18 +16,23 @@ type RestoreFormField int
 const (
 	RestoreFieldDestination RestoreFormField = iota
 	RestoreFieldInclude
+	RestoreFieldIncludeFile
+	RestoreFieldOverwrite
 	RestoreFieldSubmit
 )
 
 // RestoreForm represents a form for configuring a restore operation
 type RestoreForm struct {
-	snapshot         *types.Snapshot
-	targetInput      textinput.Model
-	includeInput     textinput.Model
-	focusedField     RestoreFormField
+	snapshot          *types.Snapshot
+	targetInput       textinput.Model
+	includeInput      textinput.Model
+	includeFileInput  textinput.Model
+	focusedField      RestoreFormField
 	restoreToOriginal bool
-	width            int
-	height           int
+	overwriteIndex    int // Index into types.OverwriteModes
+	completer         pathCompleter
+	width             int
+	height            int
 }
 
 // NewRestoreForm creates a new restore configuration form
@@ -40,10 +46,15 @@ func NewRestoreForm(snapshot *types.Snapshot) *RestoreForm {
 	includeInput.Placeholder = "path/to/file, path/to/dir (optional - leave empty for all)"
 	includeInput.CharLimit = 500
 
+	includeFileInput := textinput.New()
+	includeFileInput.Placeholder = "/path/to/include-list.txt (optional - one path per line)"
+	includeFileInput.CharLimit = 500
+
 	return &RestoreForm{
 		snapshot:          snapshot,
 		targetInput:       targetInput,
 		includeInput:      includeInput,
+		includeFileInput:  includeFileInput,
 		focusedField:      RestoreFieldDestination,
 		restoreToOriginal: false,
 	}
@@ -55,14 +66,38 @@ func (f *RestoreForm) Update(msg tea.Msg) tea.Cmd {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if msg.String() != "tab" {
+			f.completer.reset()
+		}
 		switch msg.String() {
-		case "tab", "down":
+		case "tab":
+			// Tab at the end of a path completes it before moving on
+			if input := f.pathField(); input != nil && f.completer.complete(input) {
+				return nil
+			}
+			f.NextField()
+			return nil
+		case "down":
 			f.NextField()
 			return nil
 		case "shift+tab", "up":
 			f.PrevField()
 			return nil
+		case "left", "right":
+			if f.focusedField == RestoreFieldOverwrite {
+				if msg.String() == "left" {
+					f.CycleOverwrite(-1)
+				} else {
+					f.CycleOverwrite(1)
+				}
+				return nil
+			}
 		case " ":
+			// Space cycles the overwrite mode when on that field
+			if f.focusedField == RestoreFieldOverwrite {
+				f.CycleOverwrite(1)
+				return nil
+			}
 			// Space to toggle original location when on destination field
 			if f.focusedField == RestoreFieldDestination {
 				f.restoreToOriginal = !f.restoreToOriginal
@@ -84,6 +119,8 @@ func (f *RestoreForm) Update(msg tea.Msg) tea.Cmd {
 			f.targetInput, cmd = f.targetInput.Update(msg)
 		case RestoreFieldInclude:
 			f.includeInput, cmd = f.includeInput.Update(msg)
+		case RestoreFieldIncludeFile:
+			f.includeFileInput, cmd = f.includeFileInput.Update(msg)
 		}
 	}
 
@@ -92,6 +129,7 @@ func (f *RestoreForm) Update(msg tea.Msg) tea.Cmd {
 
 // NextField moves to the next form field
 func (f *RestoreForm) NextField() {
+	f.completer.reset()
 	f.BlurAll()
 
 	f.focusedField++
@@ -104,6 +142,7 @@ func (f *RestoreForm) NextField() {
 
 // PrevField moves to the previous form field
 func (f *RestoreForm) PrevField() {
+	f.completer.reset()
 	f.BlurAll()
 
 	f.focusedField--
@@ -114,10 +153,24 @@ func (f *RestoreForm) PrevField() {
 	f.FocusCurrent()
 }
 
+// pathField returns the focused input if it holds a local filesystem path
+func (f *RestoreForm) pathField() *textinput.Model {
+	switch f.focusedField {
+	case RestoreFieldDestination:
+		if !f.restoreToOriginal {
+			return &f.targetInput
+		}
+	case RestoreFieldIncludeFile:
+		return &f.includeFileInput
+	}
+	return nil
+}
+
 // BlurAll removes focus from all inputs
 func (f *RestoreForm) BlurAll() {
 	f.targetInput.Blur()
 	f.includeInput.Blur()
+	f.includeFileInput.Blur()
 }
 
 // FocusCurrent focuses the current field
@@ -129,6 +182,8 @@ func (f *RestoreForm) FocusCurrent() {
 		}
 	case RestoreFieldInclude:
 		f.includeInput.Focus()
+	case RestoreFieldIncludeFile:
+		f.includeFileInput.Focus()
 	}
 }
 
@@ -157,15 +212,50 @@ func (f *RestoreForm) GetInclude() []string {
 	return trimmedPaths
 }
 
+// GetIncludeFile returns the path of a file listing the paths to restore
+func (f *RestoreForm) GetIncludeFile() string {
+	return strings.TrimSpace(f.includeFileInput.Value())
+}
+
+// GetOverwrite returns the selected restic --overwrite mode
+func (f *RestoreForm) GetOverwrite() string {
+	return types.OverwriteModes[f.overwriteIndex]
+}
+
+// CycleOverwrite moves the overwrite mode selection by delta, wrapping around
+func (f *RestoreForm) CycleOverwrite(delta int) {
+	n := len(types.OverwriteModes)
+	f.overwriteIndex = ((f.overwriteIndex+delta)%n + n) % n
+}
+
+// overwriteDescriptions explains each overwrite mode in the form
+var overwriteDescriptions = map[string]string{
+	types.OverwriteIfChanged: "replace files whose content differs",
+	types.OverwriteIfNewer:   "replace files only if the snapshot copy is newer",
+	types.OverwriteNever:     "never replace existing files",
+	types.OverwriteAlways:    "replace every existing file",
+}
+
 // IsRestoreToOriginal returns true if restoring to original location
 func (f *RestoreForm) IsRestoreToOriginal() bool {
 	return f.restoreToOriginal
 }
 
+// Validate checks the form and returns the first problem found
+func (f *RestoreForm) Validate() error {
+	if err := validatePatterns("include", f.GetInclude()); err != nil {
+		return err
+	}
+	// Either restore to original or have a target path
+	if !f.restoreToOriginal && f.GetTarget() == "" {
+		return fmt.Errorf("destination path is required (or enable original location)")
+	}
+	return nil
+}
+
 // IsValid checks if the form is valid
 func (f *RestoreForm) IsValid() bool {
-	// Either restore to original or have a target path
-	return f.restoreToOriginal || f.GetTarget() != ""
+	return f.Validate() == nil
 }
 
 // SetSize sets the form dimensions
@@ -174,6 +264,22 @@ func (f *RestoreForm) SetSize(width, height int) {
 	f.height = height
 	f.targetInput.Width = width - 20
 	f.includeInput.Width = width - 20
+	f.includeFileInput.Width = width - 20
+}
+
+// GetWidth returns the form width
+func (f *RestoreForm) GetWidth() int {
+	return f.width
+}
+
+// GetHeight returns the form height
+func (f *RestoreForm) GetHeight() int {
+	return f.height
+}
+
+// SetTarget pre-fills the restore location, e.g. with the repository's default_restore_target
+func (f *RestoreForm) SetTarget(target string) {
+	f.targetInput.SetValue(target)
 }
 
 // SetIncludePaths pre-fills the include paths field with the given paths
@@ -181,29 +287,34 @@ func (f *RestoreForm) SetIncludePaths(paths []string) {
 	f.includeInput.SetValue(strings.Join(paths, ", "))
 }
 
+// SetIncludeFile pre-fills the include file field with path
+func (f *RestoreForm) SetIncludeFile(path string) {
+	f.includeFileInput.SetValue(path)
+}
+
 // Render renders the form
 func (f *RestoreForm) Render() string {
 	var b strings.Builder
 
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("205")).
+		Foreground(colorActive).
 		Padding(0, 1)
 
 	labelStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(colorDimmed).
 		Width(20)
 
 	focusedStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("205")).
+		Foreground(colorActive).
 		Bold(true)
 
 	helpStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(colorDimmed).
 		Padding(1, 0)
 
 	infoStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("86"))
+		Foreground(colorPrimary)
 
 	title := titleStyle.Render("Restore Snapshot")
 	b.WriteString(title + "\n\n")
@@ -241,6 +352,9 @@ func (f *RestoreForm) Render() string {
 	// Target path input (only if not restoring to original)
 	if !f.restoreToOriginal {
 		b.WriteString(f.targetInput.View() + "\n")
+		if f.focusedField == RestoreFieldDestination {
+			b.WriteString(f.completer.view())
+		}
 	}
 	b.WriteString("\n")
 
@@ -252,6 +366,32 @@ func (f *RestoreForm) Render() string {
 	b.WriteString(includeLabel + "\n")
 	b.WriteString(f.includeInput.View() + "\n\n")
 
+	// Include file field
+	includeFileLabel := labelStyle.Render("Include File:")
+	if f.focusedField == RestoreFieldIncludeFile {
+		includeFileLabel = focusedStyle.Render("▶ Include File:")
+	}
+	b.WriteString(includeFileLabel + "\n")
+	b.WriteString(f.includeFileInput.View() + "\n")
+	if f.focusedField == RestoreFieldIncludeFile {
+		b.WriteString(f.completer.view())
+	}
+	b.WriteString("\n")
+
+	// Overwrite mode selector
+	overwriteLabel := labelStyle.Render("Existing Files:")
+	if f.focusedField == RestoreFieldOverwrite {
+		overwriteLabel = focusedStyle.Render("▶ Existing Files:")
+	}
+	b.WriteString(overwriteLabel + "\n")
+	mode := f.GetOverwrite()
+	modeStyle := labelStyle
+	if f.focusedField == RestoreFieldOverwrite {
+		modeStyle = focusedStyle
+	}
+	b.WriteString(modeStyle.UnsetWidth().Render(fmt.Sprintf("  ◀ %s ▶", mode)) + " " +
+		labelStyle.UnsetWidth().Render(overwriteDescriptions[mode]+" (restic 0.17+; older versions always replace)") + "\n\n")
+
 	// Submit button
 	submitLabel := "  [ Restore Snapshot ]"
 	if f.focusedField == RestoreFieldSubmit {
@@ -260,25 +400,32 @@ func (f *RestoreForm) Render() string {
 	b.WriteString(submitLabel + "\n\n")
 
 	// Help text
-	help := "Tab/↑↓: Navigate • Space: Toggle original location • Enter: Restore • Esc: Cancel"
+	help := "Tab/↑↓: Navigate • Space: Toggle original location / overwrite mode • ←→: Overwrite mode • Enter: Restore • Esc: Cancel"
 	b.WriteString(helpStyle.Render(help))
 
 	// Validation message
-	if !f.IsValid() && f.focusedField == RestoreFieldSubmit {
-		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	if err := validatePatterns("include", f.GetInclude()); err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(colorError)
+		b.WriteString("\n" + errorStyle.Render("⚠ "+err.Error()))
+	} else if !f.IsValid() && f.focusedField == RestoreFieldSubmit {
+		errorStyle := lipgloss.NewStyle().Foreground(colorError)
 		b.WriteString("\n" + errorStyle.Render("⚠ Destination path is required (or enable original location)"))
 	}
 
 	// Warning about original location
 	if f.restoreToOriginal {
-		warningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
-		b.WriteString("\n" + warningStyle.Render("⚠ Warning: Files will be overwritten in their original locations!"))
+		warningStyle := lipgloss.NewStyle().Foreground(colorWarning)
+		warning := "⚠ Warning: Files will be overwritten in their original locations!"
+		if f.GetOverwrite() != types.OverwriteAlways {
+			warning = fmt.Sprintf("⚠ Warning: Restoring over original files (overwrite: %s)", f.GetOverwrite())
+		}
+		b.WriteString("\n" + warningStyle.Render(warning))
 	}
 
 	// Wrap in border
 	borderStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("205")).
+		BorderForeground(colorActive).
 		Padding(1, 2).
 		Width(f.width - 4)
 
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+func TestRestoreForm_OverwriteDefaultsToIfChanged(t *testing.T) {
+	form := NewRestoreForm(&types.Snapshot{ShortID: "abc123"})
+
+	if got := form.GetOverwrite(); got != types.OverwriteIfChanged {
+		t.Errorf("GetOverwrite() = %q, want %q", got, types.OverwriteIfChanged)
+	}
+}
+
+func TestRestoreForm_CycleOverwrite(t *testing.T) {
+	form := NewRestoreForm(nil)
+	form.NextField() // Specific paths
+	form.NextField() // Include file
+	form.NextField() // Existing files
+	if form.focusedField != RestoreFieldOverwrite {
+		t.Fatalf("focusedField = %v, want RestoreFieldOverwrite", form.focusedField)
+	}
+
+	form.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if got := form.GetOverwrite(); got != types.OverwriteIfNewer {
+		t.Errorf("After right, GetOverwrite() = %q, want %q", got, types.OverwriteIfNewer)
+	}
+
+	form.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	form.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	if got := form.GetOverwrite(); got != types.OverwriteAlways {
+		t.Errorf("Left should wrap around, GetOverwrite() = %q, want %q", got, types.OverwriteAlways)
+	}
+}
+
+func TestRestoreForm_DefaultTargetAndIncludeFile(t *testing.T) {
+	form := NewRestoreForm(&types.Snapshot{ShortID: "abc123"})
+	form.SetTarget("/srv/restores")
+	form.SetIncludeFile(" /tmp/include.txt ")
+
+	if got := form.GetTarget(); got != "/srv/restores" {
+		t.Errorf("GetTarget() = %q, want the pre-filled target", got)
+	}
+	if got := form.GetIncludeFile(); got != "/tmp/include.txt" {
+		t.Errorf("GetIncludeFile() = %q, want /tmp/include.txt", got)
+	}
+	if !form.IsValid() {
+		t.Error("A pre-filled target should make the form valid")
+	}
+}
+
+func TestRestoreForm_IncludePatterns(t *testing.T) {
+	form := NewRestoreForm(&types.Snapshot{ShortID: "abc123"})
+	form.SetTarget("/srv/restores")
+
+	form.includeInput.SetValue("/home/*/docs, /etc/[a-m]*")
+	if err := form.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want valid include patterns", err)
+	}
+
+	form.includeInput.SetValue("/home/*/docs, /etc/[a-m")
+	if err := form.Validate(); err == nil || !strings.Contains(err.Error(), `invalid include pattern "/etc/[a-m"`) {
+		t.Errorf("Validate() = %v, want an error naming /etc/[a-m", err)
+	}
+	if form.IsValid() {
+		t.Error("A malformed include pattern should make the form invalid")
+	}
+}
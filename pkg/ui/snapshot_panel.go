@@ -2,6 +2,8 @@ package ui
 
 import (
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -22,14 +24,192 @@ type SnapshotPanel struct {
 	filterText   string
 	filterTag    string
 	filterHost   string
+	serverFilter string // restic-side filter the list was loaded with (e.g. "host:web01")
+
+	// Size column state (opt-in; sizes are cached by snapshot ID)
+	showSizes   bool
+	sizes       map[string]int64
+	sizePending map[string]bool
+
+	// Restore sizes of the selected and recent snapshots (cached by snapshot ID)
+	restoreSizes   map[string]int64
+	restorePending map[string]bool
+	restoreFailed  map[string]bool // Lookups for the size history that failed; not retried
+
+	// Snapshots marked for batch operations (keyed by snapshot ID)
+	marked map[string]bool
+
+	// Snapshot IDs marked for comparison, in the order they were marked
+	compare [2]string
+
+	// Last load failure, shown with a retry hint until the next load
+	loadError string
+	retryKey  string
 }
 
 // NewSnapshotPanel creates a new snapshot panel
 func NewSnapshotPanel() *SnapshotPanel {
 	return &SnapshotPanel{
-		snapshots: []types.Snapshot{},
-		selected:  0,
+		snapshots:      []types.Snapshot{},
+		selected:       0,
+		sizes:          make(map[string]int64),
+		sizePending:    make(map[string]bool),
+		restoreSizes:   make(map[string]int64),
+		restorePending: make(map[string]bool),
+		restoreFailed:  make(map[string]bool),
+		marked:         make(map[string]bool),
+	}
+}
+
+// SetRetryKey sets the key named in the retry hint after a failed load
+func (p *SnapshotPanel) SetRetryKey(key string) {
+	p.retryKey = key
+}
+
+// SetLoadError records why the last snapshot load failed; empty clears it
+func (p *SnapshotPanel) SetLoadError(message string) {
+	p.loadError = message
+}
+
+// retryHint returns the hint telling the user how to retry a failed load
+func (p *SnapshotPanel) retryHint() string {
+	if p.retryKey == "" {
+		return "refresh to try again"
+	}
+	return fmt.Sprintf("press %s to retry", p.retryKey)
+}
+
+// SelectedNeedsRestoreSize returns the selected snapshot's ID if its restore size is
+// neither cached nor being calculated, and marks it pending
+func (p *SnapshotPanel) SelectedNeedsRestoreSize() (string, bool) {
+	snapshot := p.GetSelected()
+	if snapshot == nil {
+		return "", false
+	}
+	if _, ok := p.restoreSizes[snapshot.ID]; ok || p.restorePending[snapshot.ID] {
+		return "", false
+	}
+	p.restorePending[snapshot.ID] = true
+	return snapshot.ID, true
+}
+
+// SetRestoreSize caches the restore size of a snapshot
+func (p *SnapshotPanel) SetRestoreSize(id string, size int64) {
+	p.restoreSizes[id] = size
+	delete(p.restorePending, id)
+}
+
+// RestoreSize returns the cached restore size of a snapshot and whether it is known
+func (p *SnapshotPanel) RestoreSize(id string) (int64, bool) {
+	size, ok := p.restoreSizes[id]
+	return size, ok
+}
+
+// ClearRestoreSizePending allows a failed restore size lookup to be retried
+func (p *SnapshotPanel) ClearRestoreSizePending(id string) {
+	delete(p.restorePending, id)
+}
+
+// SetRestoreSizeFailed records that the restore size of a snapshot couldn't be
+// read, so the size history doesn't ask for it again
+func (p *SnapshotPanel) SetRestoreSizeFailed(id string) {
+	delete(p.restorePending, id)
+	p.restoreFailed[id] = true
+}
+
+// recentSnapshots returns the latest n loaded snapshots, oldest first
+func (p *SnapshotPanel) recentSnapshots(n int) []types.Snapshot {
+	recent := slices.Clone(p.snapshots)
+	sort.SliceStable(recent, func(i, j int) bool {
+		return recent[i].Time.Before(recent[j].Time)
+	})
+	if len(recent) > n {
+		recent = recent[len(recent)-n:]
+	}
+	return recent
+}
+
+// NextRecentRestoreSize returns the newest of the latest n loaded snapshots
+// whose restore size is neither cached, being calculated nor failed before,
+// and marks it pending
+func (p *SnapshotPanel) NextRecentRestoreSize(n int) (string, bool) {
+	recent := p.recentSnapshots(n)
+	for i := len(recent) - 1; i >= 0; i-- {
+		id := recent[i].ID
+		if _, ok := p.restoreSizes[id]; ok || p.restorePending[id] || p.restoreFailed[id] {
+			continue
+		}
+		p.restorePending[id] = true
+		return id, true
+	}
+	return "", false
+}
+
+// RecentRestoreSizes returns the cached restore sizes of the latest n loaded
+// snapshots, oldest first, leaving out the ones not known
+func (p *SnapshotPanel) RecentRestoreSizes(n int) []int64 {
+	var sizes []int64
+	for _, snapshot := range p.recentSnapshots(n) {
+		if size, ok := p.restoreSizes[snapshot.ID]; ok {
+			sizes = append(sizes, size)
+		}
+	}
+	return sizes
+}
+
+// ToggleSizes shows or hides the on-disk size column and returns the new state
+func (p *SnapshotPanel) ToggleSizes() bool {
+	p.showSizes = !p.showSizes
+	return p.showSizes
+}
+
+// ShowingSizes returns true if the size column is enabled
+func (p *SnapshotPanel) ShowingSizes() bool {
+	return p.showSizes
+}
+
+// SetSnapshotSize caches the on-disk size of a snapshot
+func (p *SnapshotPanel) SetSnapshotSize(id string, size int64) {
+	p.sizes[id] = size
+	delete(p.sizePending, id)
+}
+
+// ClearSizePending allows a failed size lookup to be retried
+func (p *SnapshotPanel) ClearSizePending(id string) {
+	delete(p.sizePending, id)
+}
+
+// visibleRange returns the bounds of the snapshots currently in the viewport
+func (p *SnapshotPanel) visibleRange() (int, int) {
+	visibleLines := p.height - 6 // Account for borders, title, padding
+	if visibleLines < 1 {
+		visibleLines = 1
+	}
+	endIdx := p.scrollOffset + visibleLines
+	if endIdx > len(p.filteredSnapshots) {
+		endIdx = len(p.filteredSnapshots)
+	}
+	return p.scrollOffset, endIdx
+}
+
+// MissingVisibleSizes returns IDs of visible snapshots whose size is neither cached nor
+// being fetched, and marks them pending. Returns nil when the size column is hidden.
+func (p *SnapshotPanel) MissingVisibleSizes() []string {
+	if !p.showSizes {
+		return nil
+	}
+
+	var ids []string
+	startIdx, endIdx := p.visibleRange()
+	for i := startIdx; i < endIdx; i++ {
+		id := p.filteredSnapshots[i].ID
+		if _, ok := p.sizes[id]; ok || p.sizePending[id] {
+			continue
+		}
+		p.sizePending[id] = true
+		ids = append(ids, id)
 	}
+	return ids
 }
 
 // SetSnapshots updates the list of snapshots
@@ -37,6 +217,22 @@ func (p *SnapshotPanel) SetSnapshots(snapshots []types.Snapshot) {
 	p.snapshots = snapshots
 	p.ApplyFilter()
 
+	// Drop marks for snapshots that no longer exist
+	present := make(map[string]bool, len(snapshots))
+	for _, snap := range snapshots {
+		present[snap.ID] = true
+	}
+	for id := range p.marked {
+		if !present[id] {
+			delete(p.marked, id)
+		}
+	}
+	for _, id := range p.compare {
+		if id != "" && !present[id] {
+			p.unmarkCompare(id)
+		}
+	}
+
 	// Adjust selection to fit within filtered list
 	listLen := len(p.filteredSnapshots)
 	if p.selected >= listLen && listLen > 0 {
@@ -148,6 +344,16 @@ func (p *SnapshotPanel) SetHostFilter(host string) {
 	p.ApplyFilter()
 }
 
+// TagFilter returns the active tag filter, or "" when none is set
+func (p *SnapshotPanel) TagFilter() string {
+	return p.filterTag
+}
+
+// HostFilter returns the active hostname filter, or "" when none is set
+func (p *SnapshotPanel) HostFilter() string {
+	return p.filterHost
+}
+
 // ClearFilter removes all filters
 func (p *SnapshotPanel) ClearFilter() {
 	p.filterActive = false
@@ -157,6 +363,17 @@ func (p *SnapshotPanel) ClearFilter() {
 	p.ApplyFilter()
 }
 
+// SetServerFilter records the restic-side filter the snapshots were loaded
+// with so the title can show it; empty clears it
+func (p *SnapshotPanel) SetServerFilter(desc string) {
+	p.serverFilter = desc
+}
+
+// ServerFilter returns the restic-side filter shown in the title
+func (p *SnapshotPanel) ServerFilter() string {
+	return p.serverFilter
+}
+
 // IsFilterActive returns true if any filter is currently active
 func (p *SnapshotPanel) IsFilterActive() bool {
 	return p.filterActive && (p.filterText != "" || p.filterTag != "" || p.filterHost != "")
@@ -206,6 +423,120 @@ func (p *SnapshotPanel) MoveDown() {
 	}
 }
 
+// SelectByID selects the snapshot with the given full or short ID, clearing
+// any filter that hides it; returns false if the snapshot is not loaded
+func (p *SnapshotPanel) SelectByID(id string) bool {
+	index := func() int {
+		for i, snap := range p.filteredSnapshots {
+			if snap.ID == id || snap.ShortID == id || (len(id) >= 8 && strings.HasPrefix(snap.ID, id)) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	i := index()
+	if i < 0 && p.IsFilterActive() {
+		p.ClearFilter()
+		i = index()
+	}
+	if i < 0 {
+		return false
+	}
+
+	p.selected = i
+	visibleLines := p.height - 6
+	if visibleLines < 1 {
+		visibleLines = 1
+	}
+	if p.selected < p.scrollOffset || p.selected >= p.scrollOffset+visibleLines {
+		p.scrollOffset = p.selected
+	}
+	return true
+}
+
+// ToggleMark marks or unmarks the selected snapshot for a batch operation
+func (p *SnapshotPanel) ToggleMark() {
+	snap := p.GetSelected()
+	if snap == nil {
+		return
+	}
+	if p.marked[snap.ID] {
+		delete(p.marked, snap.ID)
+	} else {
+		p.marked[snap.ID] = true
+	}
+}
+
+// MarkedSnapshots returns the marked snapshots in list order, ignoring filters
+func (p *SnapshotPanel) MarkedSnapshots() []types.Snapshot {
+	var marked []types.Snapshot
+	for _, snap := range p.snapshots {
+		if p.marked[snap.ID] {
+			marked = append(marked, snap)
+		}
+	}
+	return marked
+}
+
+// ClearMarks unmarks all snapshots
+func (p *SnapshotPanel) ClearMarks() {
+	p.marked = make(map[string]bool)
+}
+
+// MarkForCompare marks the selected snapshot as the first or second side of a
+// comparison and returns its slot (1 or 2). With both slots taken, the older
+// mark is dropped and the selection becomes the second. Marking a snapshot
+// that is already marked unmarks it and returns 0.
+func (p *SnapshotPanel) MarkForCompare() int {
+	snap := p.GetSelected()
+	if snap == nil {
+		return 0
+	}
+	if p.compare[0] == snap.ID || p.compare[1] == snap.ID {
+		p.unmarkCompare(snap.ID)
+		return 0
+	}
+
+	switch {
+	case p.compare[0] == "":
+		p.compare[0] = snap.ID
+		return 1
+	case p.compare[1] == "":
+		p.compare[1] = snap.ID
+	default:
+		p.compare = [2]string{p.compare[1], snap.ID}
+	}
+	return 2
+}
+
+// unmarkCompare removes a snapshot from the comparison, keeping the other
+// mark in the first slot
+func (p *SnapshotPanel) unmarkCompare(id string) {
+	switch id {
+	case p.compare[0]:
+		p.compare = [2]string{p.compare[1], ""}
+	case p.compare[1]:
+		p.compare[1] = ""
+	}
+}
+
+// GetComparePair returns the IDs marked for comparison in mark order; either
+// is empty when that slot is not marked
+func (p *SnapshotPanel) GetComparePair() (a, b string) {
+	return p.compare[0], p.compare[1]
+}
+
+// ClearCompare removes both comparison marks
+func (p *SnapshotPanel) ClearCompare() {
+	p.compare = [2]string{}
+}
+
+// VisibleSnapshots returns the snapshots currently listed, after any filter
+func (p *SnapshotPanel) VisibleSnapshots() []types.Snapshot {
+	return append([]types.Snapshot(nil), p.filteredSnapshots...)
+}
+
 // GetSelected returns the currently selected snapshot
 func (p *SnapshotPanel) GetSelected() *types.Snapshot {
 	listLen := len(p.filteredSnapshots)
@@ -221,6 +552,10 @@ func (p *SnapshotPanel) Render(active bool) string {
 
 	title := "[3] Snapshots"
 
+	if p.serverFilter != "" {
+		title += fmt.Sprintf(" [restic: %s]", p.serverFilter)
+	}
+
 	// Add filter indicator if active
 	if p.IsFilterActive() {
 		filterParts := []string{}
@@ -236,56 +571,63 @@ func (p *SnapshotPanel) Render(active bool) string {
 		filterInfo := strings.Join(filterParts, ", ")
 		title += fmt.Sprintf(" [%s]", filterInfo)
 	}
+	if len(p.marked) > 0 {
+		title += fmt.Sprintf(" [%d marked]", len(p.marked))
+	}
 
 	// Add top margin/padding for breathing room
 	b.WriteString("\n")
 
 	// Snapshot list
-	if len(p.snapshots) == 0 {
+	if len(p.snapshots) == 0 && p.loadError != "" {
+		b.WriteString(lipgloss.NewStyle().
+			Foreground(colorError).
+			Render("Failed to load snapshots - " + p.retryHint() + "\n"))
 		b.WriteString(lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
+			Foreground(colorDimmed).
+			Render(p.loadError))
+	} else if len(p.snapshots) == 0 {
+		b.WriteString(lipgloss.NewStyle().
+			Foreground(colorDimmed).
 			Render("No snapshots found\n"))
 		b.WriteString(lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
+			Foreground(colorDimmed).
 			Render("Select a repository to view snapshots"))
 	} else if len(p.filteredSnapshots) == 0 {
 		// No snapshots match the filter
 		b.WriteString(lipgloss.NewStyle().
-			Foreground(lipgloss.Color("214")).
+			Foreground(colorWarning).
 			Render("No snapshots match the current filter\n"))
 		b.WriteString(lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
+			Foreground(colorDimmed).
 			Render("Press Esc to clear filter"))
 	} else {
+		// Keep the last list but flag that reloading it failed
+		if p.loadError != "" {
+			b.WriteString(lipgloss.NewStyle().
+				Foreground(colorWarning).
+				Render("⚠ Reload failed - " + p.retryHint() + "\n"))
+		}
+
 		// Show filter count if active
 		if p.IsFilterActive() {
 			countStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("241")).
+				Foreground(colorDimmed).
 				Italic(true)
 			b.WriteString(countStyle.Render(fmt.Sprintf("[%d of %d snapshots shown]\n\n",
 				len(p.filteredSnapshots), len(p.snapshots))))
 		}
 
-		// Calculate visible area for viewport scrolling
-		visibleLines := p.height - 6 // Account for borders, title, padding
-		if visibleLines < 1 {
-			visibleLines = 1
-		}
-
 		totalSnapshots := len(p.filteredSnapshots)
 
 		// Show scroll indicators
 		if p.scrollOffset > 0 {
-			scrollTopStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
+			scrollTopStyle := lipgloss.NewStyle().Foreground(colorDimmed).Italic(true)
 			b.WriteString(scrollTopStyle.Render("  ▲ more above...\n"))
 		}
 
 		// Calculate viewport bounds
-		startIdx := p.scrollOffset
-		endIdx := p.scrollOffset + visibleLines
-		if endIdx > totalSnapshots {
-			endIdx = totalSnapshots
-		}
+		startIdx, endIdx := p.visibleRange()
 
 		// Render only visible snapshots
 		for i := startIdx; i < endIdx; i++ {
@@ -307,17 +649,44 @@ func (p *SnapshotPanel) Render(active bool) string {
 			} else {
 				line = ListItemStyle.Render(fmt.Sprintf("  %s", shortID))
 			}
+			if p.marked[snapshot.ID] {
+				line += StatusErrorStyle.Render(" ✗")
+			}
+			compareStyle := lipgloss.NewStyle().Foreground(colorInfo).Bold(true)
+			if p.compare[0] != "" && snapshot.ID == p.compare[0] {
+				line += compareStyle.Render(" ①")
+			} else if p.compare[1] != "" && snapshot.ID == p.compare[1] {
+				line += compareStyle.Render(" ②")
+			}
 
-			// Add timestamp
-			timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
-			line += timeStyle.Render(fmt.Sprintf(" - %s", timeStr))
+			// Add timestamp, colored by how recent the snapshot is
+			timeStyle := lipgloss.NewStyle().Foreground(colorDimmed)
+			line += lipgloss.NewStyle().Foreground(ageColor(snapshot.Time)).Render(fmt.Sprintf(" - %s", timeStr))
+
+			// Add restore size detail for the active item
+			if i == p.selected && active {
+				if size, ok := p.restoreSizes[snapshot.ID]; ok {
+					line += timeStyle.Render(fmt.Sprintf(" · Size: %s", FormatBytes(size)))
+				} else if p.restorePending[snapshot.ID] {
+					line += timeStyle.Render(" · Size: calculating…")
+				}
+			}
+
+			// Add approximate on-disk size when the column is enabled
+			if p.showSizes {
+				sizeStr := "…"
+				if size, ok := p.sizes[snapshot.ID]; ok {
+					sizeStr = FormatBytes(size)
+				}
+				line += timeStyle.Render(fmt.Sprintf("  %s", sizeStr))
+			}
 
 			b.WriteString(line + "\n")
 		}
 
 		// Show scroll indicator for more content below
 		if endIdx < totalSnapshots {
-			scrollBottomStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
+			scrollBottomStyle := lipgloss.NewStyle().Foreground(colorDimmed).Italic(true)
 			b.WriteString(scrollBottomStyle.Render("  ▼ more below...\n"))
 		}
 	}
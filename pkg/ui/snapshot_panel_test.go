@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -104,6 +105,149 @@ func TestSnapshotPanel_GetSelected(t *testing.T) {
 	}
 }
 
+func TestSnapshotPanel_SelectByID(t *testing.T) {
+	panel := NewSnapshotPanel()
+	panel.SetSize(80, 20)
+	panel.SetSnapshots([]types.Snapshot{
+		{ID: "abc12345ffff", ShortID: "abc12345", Hostname: "host1"},
+		{ID: "def67890ffff", ShortID: "def67890", Hostname: "host2"},
+	})
+
+	if !panel.SelectByID("def67890ffff") {
+		t.Fatal("SelectByID() should find the snapshot by full ID")
+	}
+	if panel.GetSelected().ShortID != "def67890" {
+		t.Errorf("Selected snapshot = %v, want def67890", panel.GetSelected().ShortID)
+	}
+
+	// A filter hiding the snapshot is cleared
+	panel.SetHostFilter("host2")
+	if !panel.SelectByID("abc12345") {
+		t.Fatal("SelectByID() should clear a filter that hides the snapshot")
+	}
+	if panel.IsFilterActive() || panel.GetSelected().ShortID != "abc12345" {
+		t.Error("Expected filter cleared and abc12345 selected")
+	}
+
+	if panel.SelectByID("00000000") {
+		t.Error("SelectByID() should return false for an unknown snapshot")
+	}
+}
+
+func TestSnapshotPanel_MarksKeyedByID(t *testing.T) {
+	panel := NewSnapshotPanel()
+	panel.SetSize(80, 20)
+	panel.SetSnapshots([]types.Snapshot{
+		{ID: "aaa", ShortID: "aaa", Hostname: "host1"},
+		{ID: "bbb", ShortID: "bbb", Hostname: "host2"},
+		{ID: "ccc", ShortID: "ccc", Hostname: "host2"},
+	})
+
+	// Mark the second snapshot, then filter so it moves to index 0
+	panel.MoveDown()
+	panel.ToggleMark()
+	panel.SetHostFilter("host2")
+	panel.MoveDown()
+	panel.ToggleMark()
+
+	marked := panel.MarkedSnapshots()
+	if len(marked) != 2 || marked[0].ID != "bbb" || marked[1].ID != "ccc" {
+		t.Fatalf("MarkedSnapshots() = %v, want bbb and ccc", marked)
+	}
+
+	// Toggling again unmarks
+	panel.ToggleMark()
+	if len(panel.MarkedSnapshots()) != 1 {
+		t.Error("ToggleMark() should unmark a marked snapshot")
+	}
+
+	// Marks survive a reload in a different order, but not removal
+	panel.ClearFilter()
+	panel.SetSnapshots([]types.Snapshot{
+		{ID: "ccc", ShortID: "ccc"},
+		{ID: "aaa", ShortID: "aaa"},
+		{ID: "bbb", ShortID: "bbb"},
+	})
+	if marked := panel.MarkedSnapshots(); len(marked) != 1 || marked[0].ID != "bbb" {
+		t.Errorf("Marks should survive reordering, got %v", marked)
+	}
+	panel.SetSnapshots([]types.Snapshot{{ID: "aaa", ShortID: "aaa"}})
+	if len(panel.MarkedSnapshots()) != 0 {
+		t.Error("Marks for removed snapshots should be dropped")
+	}
+
+	panel.ToggleMark()
+	panel.ClearMarks()
+	if len(panel.MarkedSnapshots()) != 0 {
+		t.Error("ClearMarks() should unmark everything")
+	}
+}
+
+func TestSnapshotPanel_MarkForCompare(t *testing.T) {
+	panel := NewSnapshotPanel()
+	panel.SetSize(80, 20)
+	panel.SetSnapshots([]types.Snapshot{
+		{ID: "aaa", ShortID: "aaa", Hostname: "host1"},
+		{ID: "bbb", ShortID: "bbb", Hostname: "host2"},
+		{ID: "ccc", ShortID: "ccc", Hostname: "host2"},
+	})
+
+	if slot := panel.MarkForCompare(); slot != 1 {
+		t.Errorf("First mark slot = %d, want 1", slot)
+	}
+	// Filtering moves ccc to index 1; the mark on aaa is kept by ID
+	panel.SetHostFilter("host2")
+	panel.MoveDown()
+	if slot := panel.MarkForCompare(); slot != 2 {
+		t.Errorf("Second mark slot = %d, want 2", slot)
+	}
+	if a, b := panel.GetComparePair(); a != "aaa" || b != "ccc" {
+		t.Errorf("GetComparePair() = (%q, %q), want (aaa, ccc)", a, b)
+	}
+	if len(panel.MarkedSnapshots()) != 0 {
+		t.Error("Compare marks should not mark snapshots for batch operations")
+	}
+
+	// A third mark drops the oldest one
+	panel.MoveUp()
+	panel.MarkForCompare()
+	if a, b := panel.GetComparePair(); a != "ccc" || b != "bbb" {
+		t.Errorf("GetComparePair() = (%q, %q), want (ccc, bbb)", a, b)
+	}
+
+	panel.ClearFilter()
+	for _, line := range strings.Split(panel.Render(false), "\n") {
+		if strings.Contains(line, "ccc") && !strings.Contains(line, "①") {
+			t.Errorf("ccc should show the ① indicator: %q", line)
+		}
+		if strings.Contains(line, "bbb") && !strings.Contains(line, "②") {
+			t.Errorf("bbb should show the ② indicator: %q", line)
+		}
+		if strings.Contains(line, "aaa") && (strings.Contains(line, "①") || strings.Contains(line, "②")) {
+			t.Errorf("aaa should not show a compare indicator: %q", line)
+		}
+	}
+
+	// Marking a marked snapshot unmarks it and the other moves to the first slot
+	panel.SelectByID("ccc")
+	if slot := panel.MarkForCompare(); slot != 0 {
+		t.Errorf("Unmarking slot = %d, want 0", slot)
+	}
+	if a, b := panel.GetComparePair(); a != "bbb" || b != "" {
+		t.Errorf("GetComparePair() = (%q, %q), want (bbb, \"\")", a, b)
+	}
+
+	// Reloading keeps marks for snapshots that still exist
+	panel.SetSnapshots([]types.Snapshot{{ID: "ccc", ShortID: "ccc"}, {ID: "bbb", ShortID: "bbb"}})
+	if a, _ := panel.GetComparePair(); a != "bbb" {
+		t.Errorf("Compare mark should survive reordering, got %q", a)
+	}
+	panel.SetSnapshots([]types.Snapshot{{ID: "ccc", ShortID: "ccc"}})
+	if a, b := panel.GetComparePair(); a != "" || b != "" {
+		t.Errorf("Compare marks for removed snapshots should be dropped, got (%q, %q)", a, b)
+	}
+}
+
 func TestSnapshotPanel_Render_Empty(t *testing.T) {
 	panel := NewSnapshotPanel()
 	panel.SetSize(100, 30)
@@ -470,6 +614,38 @@ func TestSnapshotPanel_MultipleFilters(t *testing.T) {
 	}
 }
 
+func TestSnapshotPanel_ClearSingleFacet(t *testing.T) {
+	panel := NewSnapshotPanel()
+	panel.SetSnapshots([]types.Snapshot{
+		{ID: "snap1", ShortID: "snap1", Hostname: "webserver", Tags: []string{"production"}},
+		{ID: "snap2", ShortID: "snap2", Hostname: "webserver", Tags: []string{"test"}},
+		{ID: "snap3", ShortID: "snap3", Hostname: "database", Tags: []string{"production"}},
+	})
+	panel.SetTagFilter("production")
+	panel.SetHostFilter("webserver")
+
+	// Clearing the tag keeps the host facet
+	panel.SetTagFilter("")
+	if !panel.IsFilterActive() || panel.HostFilter() != "webserver" {
+		t.Fatalf("Host facet should remain after clearing the tag, host=%q", panel.HostFilter())
+	}
+	if len(panel.filteredSnapshots) != 2 {
+		t.Errorf("Host-only filtered count = %d, want 2", len(panel.filteredSnapshots))
+	}
+
+	// Clearing the host as well leaves no filter
+	panel.SetHostFilter("")
+	if panel.IsFilterActive() || len(panel.filteredSnapshots) != 3 {
+		t.Errorf("No facet should be active, active=%v count=%d", panel.IsFilterActive(), len(panel.filteredSnapshots))
+	}
+
+	panel.SetSize(80, 20)
+	title := panel.Render(false)
+	if strings.Contains(title, "tag=") || strings.Contains(title, "host=") {
+		t.Error("Cleared facets should not be shown in the title")
+	}
+}
+
 func TestSnapshotPanel_FilterCaseInsensitive(t *testing.T) {
 	panel := NewSnapshotPanel()
 
@@ -572,3 +748,111 @@ func BenchmarkSnapshotPanel_Filter(b *testing.B) {
 		panel.ClearFilter()
 	}
 }
+
+func TestSnapshotPanel_MissingVisibleSizes(t *testing.T) {
+	panel := NewSnapshotPanel()
+	panel.SetSize(80, 9) // 3 visible lines
+	panel.SetSnapshots([]types.Snapshot{
+		{ID: "aaa", Time: time.Now()},
+		{ID: "bbb", Time: time.Now()},
+		{ID: "ccc", Time: time.Now()},
+		{ID: "ddd", Time: time.Now()},
+	})
+
+	if ids := panel.MissingVisibleSizes(); ids != nil {
+		t.Errorf("Expected no lookups while size column is hidden, got %v", ids)
+	}
+
+	panel.ToggleSizes()
+	panel.SetSnapshotSize("bbb", 1024)
+
+	ids := panel.MissingVisibleSizes()
+	if strings.Join(ids, ",") != "aaa,ccc" {
+		t.Errorf("MissingVisibleSizes() = %v, want [aaa ccc]", ids)
+	}
+
+	// Pending lookups must not be requested twice
+	if ids := panel.MissingVisibleSizes(); len(ids) != 0 {
+		t.Errorf("Expected pending sizes to be skipped, got %v", ids)
+	}
+
+	// A failed lookup can be retried
+	panel.ClearSizePending("ccc")
+	if ids := panel.MissingVisibleSizes(); strings.Join(ids, ",") != "ccc" {
+		t.Errorf("Expected retry for cleared snapshot, got %v", ids)
+	}
+}
+
+func TestSnapshotPanel_Render_ShowsSizes(t *testing.T) {
+	panel := NewSnapshotPanel()
+	panel.SetSize(80, 20)
+	panel.SetSnapshots([]types.Snapshot{
+		{ID: "abc12345", ShortID: "abc12345", Time: time.Now()},
+	})
+
+	if strings.Contains(panel.Render(true), "1.5 KiB") {
+		t.Error("Size should not render while the column is hidden")
+	}
+
+	panel.ToggleSizes()
+	panel.SetSnapshotSize("abc12345", 1536)
+
+	if !strings.Contains(panel.Render(true), "1.5 KiB") {
+		t.Error("Render should show cached snapshot size")
+	}
+}
+
+func TestSnapshotPanel_RestoreSize(t *testing.T) {
+	panel := NewSnapshotPanel()
+	panel.SetSize(80, 20)
+	panel.SetSnapshots([]types.Snapshot{
+		{ID: "abc12345", ShortID: "abc12345", Time: time.Now()},
+	})
+
+	id, ok := panel.SelectedNeedsRestoreSize()
+	if !ok || id != "abc12345" {
+		t.Fatalf("SelectedNeedsRestoreSize() = %q, %v; want abc12345, true", id, ok)
+	}
+	if _, ok := panel.SelectedNeedsRestoreSize(); ok {
+		t.Error("Pending restore size should not be requested twice")
+	}
+	if !strings.Contains(panel.Render(true), "calculating") {
+		t.Error("Render should show placeholder while restore size is pending")
+	}
+
+	panel.SetRestoreSize("abc12345", 2048)
+	if !strings.Contains(panel.Render(true), "Size: 2.0 KiB") {
+		t.Error("Render should show cached restore size for the active item")
+	}
+	if _, ok := panel.SelectedNeedsRestoreSize(); ok {
+		t.Error("Cached restore size should not be requested again")
+	}
+}
+
+func TestSnapshotPanel_RecentRestoreSizes(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+	panel := NewSnapshotPanel()
+	panel.SetSnapshots([]types.Snapshot{
+		{ID: "cccc", Time: day(3)},
+		{ID: "aaaa", Time: day(1)},
+		{ID: "bbbb", Time: day(2)},
+	})
+
+	// The newest of the latest two comes first, then the next, then nothing
+	if id, ok := panel.NextRecentRestoreSize(2); !ok || id != "cccc" {
+		t.Fatalf("NextRecentRestoreSize() = %q, %v; want cccc", id, ok)
+	}
+	if id, ok := panel.NextRecentRestoreSize(2); !ok || id != "bbbb" {
+		t.Fatalf("NextRecentRestoreSize() = %q, %v; want bbbb while cccc is pending", id, ok)
+	}
+	panel.SetRestoreSize("cccc", 300)
+	panel.SetRestoreSizeFailed("bbbb")
+	if id, ok := panel.NextRecentRestoreSize(2); ok {
+		t.Errorf("NextRecentRestoreSize() = %q; a failed size should not be read again", id)
+	}
+
+	panel.SetRestoreSize("aaaa", 100)
+	if got := panel.RecentRestoreSizes(3); !reflect.DeepEqual(got, []int64{100, 300}) {
+		t.Errorf("RecentRestoreSizes() = %v, want the known sizes oldest first", got)
+	}
+}
@@ -7,103 +7,155 @@ import (
 )
 
 var (
-	// Enhanced color palette with better contrast and visual appeal
-	colorPrimary   = lipgloss.Color("#00AA88") // Muted cyan/teal
-	colorSecondary = lipgloss.Color("#666666") // Muted gray (no more purple!)
-	colorSuccess   = lipgloss.Color("#00AA00") // Muted green (not too bright)
-	colorWarning   = lipgloss.Color("#FFAA00") // Orange
-	colorError     = lipgloss.Color("#FF0000") // Red
-	colorInfo      = lipgloss.Color("#00AAFF") // Blue
-	colorActive    = lipgloss.Color("#00CCAA") // Cyan - active elements
-	colorDimmed    = lipgloss.Color("#666666") // Dimmed gray
-	colorBorder    = lipgloss.Color("#444444") // Default border color
-	colorBlack     = lipgloss.Color("#000000") // Black for text on colored backgrounds
+	// Palette of the active theme, set by ApplyTheme
+	colorPrimary   lipgloss.Color
+	colorSecondary lipgloss.Color
+	colorSuccess   lipgloss.Color
+	colorWarning   lipgloss.Color
+	colorError     lipgloss.Color
+	colorInfo      lipgloss.Color
+	colorActive    lipgloss.Color
+	colorDimmed    lipgloss.Color
+	colorBorder    lipgloss.Color
+	colorBlack     lipgloss.Color // Text on colored backgrounds
+
+	TitleStyle             lipgloss.Style
+	PanelTitleStyle        lipgloss.Style
+	PanelTitleActiveStyle  lipgloss.Style
+	PanelBorderStyle       lipgloss.Style
+	PanelBorderActiveStyle lipgloss.Style
+	ListItemStyle          lipgloss.Style
+	ListItemSelectedStyle  lipgloss.Style
+	StatusHealthyStyle     lipgloss.Style
+	StatusWarningStyle     lipgloss.Style
+	StatusErrorStyle       lipgloss.Style
+	StatusLockedStyle      lipgloss.Style
+	HelpStyle              lipgloss.Style
+	KeyStyle               lipgloss.Style
+	DescStyle              lipgloss.Style
+
+	currentTheme Theme
+)
+
+// init applies the default theme so styles render sensibly before a config
+// is loaded; NewModel re-applies the configured theme at startup
+func init() {
+	ApplyTheme(DefaultTheme())
+}
+
+// ApplyTheme sets the palette and rebuilds the shared styles from it
+func ApplyTheme(t Theme) {
+	currentTheme = t
+
+	colorPrimary = t.Primary
+	colorSecondary = t.Secondary
+	colorSuccess = t.Success
+	colorWarning = t.Warning
+	colorError = t.Error
+	colorInfo = t.Info
+	colorActive = t.Active
+	colorDimmed = t.Dimmed
+	colorBorder = t.Border
+	colorBlack = t.Text
 
 	// Title styles - make it pop!
 	TitleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(colorPrimary).
-			Background(lipgloss.Color("#1a1a1a")).
-			Padding(0, 2).
-			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(colorPrimary).
-			BorderBottom(true)
+		Bold(true).
+		Foreground(colorPrimary).
+		Background(t.Background).
+		Padding(0, 2).
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		BorderBottom(true)
 
 	// Panel styles - using black text on colored backgrounds for better readability
 	PanelTitleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(colorBlack).
-			Background(colorPrimary).
-			Padding(0, 1)
+		Bold(true).
+		Foreground(colorBlack).
+		Background(colorPrimary).
+		Padding(0, 1)
 
 	PanelTitleActiveStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(colorBlack).
-				Background(colorActive).
-				Padding(0, 1)
+		Bold(true).
+		Foreground(colorBlack).
+		Background(colorActive).
+		Padding(0, 1)
 
 	PanelBorderStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(colorBorder).
-				Padding(1, 2)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorBorder).
+		Padding(1, 2)
 
 	PanelBorderActiveStyle = lipgloss.NewStyle().
-				Border(lipgloss.ThickBorder()).
-				BorderForeground(colorActive).
-				Padding(1, 2)
+		Border(lipgloss.ThickBorder()).
+		BorderForeground(colorActive).
+		Padding(1, 2)
 
 	// List item styles
 	ListItemStyle = lipgloss.NewStyle().
-			Padding(0, 2).
-			Faint(true)
+		Padding(0, 2).
+		Faint(true)
 
 	ListItemSelectedStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#000000")).
-				Background(colorActive).
-				Bold(true).
-				Padding(0, 1).
-				MarginLeft(1)
+		Foreground(colorBlack).
+		Background(colorActive).
+		Bold(true).
+		Padding(0, 1).
+		MarginLeft(1)
 
 	// Status styles
 	StatusHealthyStyle = lipgloss.NewStyle().
-				Foreground(colorSuccess).
-				Bold(true)
+		Foreground(colorSuccess).
+		Bold(true)
 
 	StatusWarningStyle = lipgloss.NewStyle().
-				Foreground(colorWarning).
-				Bold(true)
+		Foreground(colorWarning).
+		Bold(true)
 
 	StatusErrorStyle = lipgloss.NewStyle().
-				Foreground(colorError).
-				Bold(true)
+		Foreground(colorError).
+		Bold(true)
+
+	// Locked is recoverable (usually a stale lock), so it's set apart from errors
+	StatusLockedStyle = lipgloss.NewStyle().
+		Foreground(colorWarning).
+		Bold(true).
+		Underline(true)
 
 	// Help text style - polished bar
 	HelpStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#999999")).
-			Background(lipgloss.Color("#1a1a1a")).
-			Padding(0, 2).
-			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(colorBorder).
-			BorderTop(true)
+		Foreground(t.Help).
+		Background(t.Background).
+		Padding(0, 2).
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(colorBorder).
+		BorderTop(true)
 
 	// Key binding styles
 	KeyStyle = lipgloss.NewStyle().
-			Foreground(colorActive).
-			Bold(true)
+		Foreground(colorActive).
+		Bold(true)
 
 	DescStyle = lipgloss.NewStyle().
-			Foreground(colorSecondary)
-)
+		Foreground(colorSecondary)
+}
+
+// CurrentTheme returns the theme most recently applied
+func CurrentTheme() Theme {
+	return currentTheme
+}
 
 // StatusStyle returns the appropriate style for a status string
 func StatusStyle(status string) lipgloss.Style {
 	switch status {
 	case "healthy", "ready":
 		return StatusHealthyStyle
-	case "warning":
+	case "warning", "uninitialized":
 		return StatusWarningStyle
 	case "error", "failed":
 		return StatusErrorStyle
+	case "locked":
+		return StatusLockedStyle
 	default:
 		return lipgloss.NewStyle()
 	}
@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+// Theme is the color palette used by the shared styles
+type Theme struct {
+	Primary    lipgloss.Color // Titles and panel headers
+	Secondary  lipgloss.Color // Descriptions
+	Success    lipgloss.Color // Healthy status, success logs
+	Warning    lipgloss.Color // Warning status and logs
+	Error      lipgloss.Color // Error status and logs
+	Info       lipgloss.Color // Info logs and metrics
+	Active     lipgloss.Color // Focused panel, selection, key hints
+	Dimmed     lipgloss.Color // Secondary text
+	Border     lipgloss.Color // Inactive panel borders
+	Text       lipgloss.Color // Text on colored backgrounds
+	Background lipgloss.Color // Title and help bar background
+	Help       lipgloss.Color // Help bar text
+
+	// Snapshot ages: younger than FreshAge shows as Success, up to StaleAge as
+	// Warning and older as Error. Zero uses the defaults.
+	FreshAge time.Duration
+	StaleAge time.Duration
+}
+
+// Default snapshot age thresholds
+const (
+	DefaultFreshAge = 24 * time.Hour
+	DefaultStaleAge = 7 * 24 * time.Hour
+)
+
+// DefaultTheme returns the muted palette LazyRestic ships with
+func DefaultTheme() Theme {
+	return Theme{
+		Primary:    lipgloss.Color(ColorPrimary),
+		Secondary:  lipgloss.Color(ColorSecondary),
+		Success:    lipgloss.Color(ColorSuccess),
+		Warning:    lipgloss.Color(ColorWarning),
+		Error:      lipgloss.Color(ColorError),
+		Info:       lipgloss.Color(ColorInfo),
+		Active:     lipgloss.Color(ColorBorderActive),
+		Dimmed:     lipgloss.Color(ColorDimmed),
+		Border:     lipgloss.Color(ColorBorder),
+		Text:       lipgloss.Color("#000000"),
+		Background: lipgloss.Color("#1a1a1a"),
+		Help:       lipgloss.Color("#999999"),
+	}
+}
+
+// HighContrastTheme returns a bright palette for low-contrast terminals
+func HighContrastTheme() Theme {
+	return Theme{
+		Primary:    lipgloss.Color("#00FFFF"),
+		Secondary:  lipgloss.Color("#DDDDDD"),
+		Success:    lipgloss.Color("#00FF00"),
+		Warning:    lipgloss.Color("#FFFF00"),
+		Error:      lipgloss.Color("#FF5555"),
+		Info:       lipgloss.Color("#55CCFF"),
+		Active:     lipgloss.Color("#FFFF00"),
+		Dimmed:     lipgloss.Color("#BBBBBB"),
+		Border:     lipgloss.Color("#FFFFFF"),
+		Text:       lipgloss.Color("#000000"),
+		Background: lipgloss.Color("#000000"),
+		Help:       lipgloss.Color("#FFFFFF"),
+	}
+}
+
+// LightTheme returns a palette for terminals with a light background
+func LightTheme() Theme {
+	return Theme{
+		Primary:    lipgloss.Color("#00796B"),
+		Secondary:  lipgloss.Color("#555555"),
+		Success:    lipgloss.Color("#2E7D32"),
+		Warning:    lipgloss.Color("#E65100"),
+		Error:      lipgloss.Color("#C62828"),
+		Info:       lipgloss.Color("#1565C0"),
+		Active:     lipgloss.Color("#00897B"),
+		Dimmed:     lipgloss.Color("#888888"),
+		Border:     lipgloss.Color("#BBBBBB"),
+		Text:       lipgloss.Color("#FFFFFF"),
+		Background: lipgloss.Color("#EEEEEE"),
+		Help:       lipgloss.Color("#444444"),
+	}
+}
+
+// builtinThemes maps theme names accepted in the config to their palettes
+var builtinThemes = map[string]func() Theme{
+	"default":       DefaultTheme,
+	"high-contrast": HighContrastTheme,
+	"light":         LightTheme,
+}
+
+var hexColorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// colors maps color names accepted in the config to the theme's fields
+func (t *Theme) colors() map[string]*lipgloss.Color {
+	return map[string]*lipgloss.Color{
+		"primary":    &t.Primary,
+		"secondary":  &t.Secondary,
+		"success":    &t.Success,
+		"warning":    &t.Warning,
+		"error":      &t.Error,
+		"info":       &t.Info,
+		"active":     &t.Active,
+		"dimmed":     &t.Dimmed,
+		"border":     &t.Border,
+		"text":       &t.Text,
+		"background": &t.Background,
+		"help":       &t.Help,
+	}
+}
+
+// ResolveTheme builds the configured theme: the named built-in (default when
+// empty or unknown) with any color overrides applied. Unknown color names and
+// invalid hex values are skipped with a warning, keeping the theme's color.
+func ResolveTheme(cfg types.ThemeConfig) (Theme, []string) {
+	var warnings []string
+
+	name := strings.ToLower(strings.TrimSpace(cfg.Name))
+	if name == "" {
+		name = "default"
+	}
+	build, ok := builtinThemes[name]
+	if !ok {
+		warnings = append(warnings, fmt.Sprintf("Unknown theme '%s' - using default theme", cfg.Name))
+		build = DefaultTheme
+	}
+	theme := build()
+
+	// Sort for deterministic warnings
+	names := make([]string, 0, len(cfg.Colors))
+	for colorName := range cfg.Colors {
+		names = append(names, colorName)
+	}
+	sort.Strings(names)
+
+	fields := theme.colors()
+	for _, colorName := range names {
+		field, ok := fields[strings.ToLower(colorName)]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("Unknown theme color '%s' ignored", colorName))
+			continue
+		}
+		value := strings.TrimSpace(cfg.Colors[colorName])
+		if !hexColorPattern.MatchString(value) {
+			warnings = append(warnings, fmt.Sprintf("Invalid hex color '%s' for %s - keeping %s", value, colorName, *field))
+			continue
+		}
+		*field = lipgloss.Color(value)
+	}
+
+	fresh, stale := cfg.FreshAge, cfg.StaleAge
+	if fresh == 0 {
+		fresh = DefaultFreshAge
+	}
+	if stale == 0 {
+		stale = DefaultStaleAge
+	}
+	if fresh < 0 || stale <= fresh {
+		warnings = append(warnings, fmt.Sprintf("Invalid snapshot ages (fresh_age %s, stale_age %s) - stale_age must be longer than fresh_age, using defaults", fresh, stale))
+	} else if cfg.FreshAge != 0 || cfg.StaleAge != 0 {
+		theme.FreshAge, theme.StaleAge = fresh, stale
+	}
+
+	return theme, warnings
+}
+
+// ageColor returns the color for a snapshot taken at t: Success while it is
+// fresh, Warning until it is stale and Error after that
+func ageColor(t time.Time) lipgloss.Color {
+	return currentTheme.ageColorFor(time.Since(t))
+}
+
+// ageColorFor returns the color for a snapshot of the given age
+func (t Theme) ageColorFor(age time.Duration) lipgloss.Color {
+	fresh, stale := t.FreshAge, t.StaleAge
+	if fresh <= 0 || stale <= fresh {
+		fresh, stale = DefaultFreshAge, DefaultStaleAge
+	}
+
+	switch {
+	case age < fresh:
+		return t.Success
+	case age < stale:
+		return t.Warning
+	default:
+		return t.Error
+	}
+}
@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/craigderington/lazyrestic/pkg/types"
+)
+
+func TestResolveTheme_BuiltinNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		want     Theme
+		warnings int
+	}{
+		{"", DefaultTheme(), 0},
+		{"default", DefaultTheme(), 0},
+		{"High-Contrast", HighContrastTheme(), 0},
+		{"light", LightTheme(), 0},
+		{"solarized", DefaultTheme(), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theme, warnings := ResolveTheme(types.ThemeConfig{Name: tt.name})
+			if theme != tt.want {
+				t.Errorf("ResolveTheme(%q) returned the wrong palette", tt.name)
+			}
+			if len(warnings) != tt.warnings {
+				t.Errorf("Got %d warnings, want %d: %v", len(warnings), tt.warnings, warnings)
+			}
+		})
+	}
+}
+
+func TestResolveTheme_ColorOverrides(t *testing.T) {
+	theme, warnings := ResolveTheme(types.ThemeConfig{
+		Name: "light",
+		Colors: map[string]string{
+			"primary": "#FF8800",
+			"Error":   "#f00",
+			"success": "green",
+			"sparkle": "#FFFFFF",
+		},
+	})
+
+	if theme.Primary != lipgloss.Color("#FF8800") {
+		t.Errorf("Primary = %q, want #FF8800", theme.Primary)
+	}
+	if theme.Error != lipgloss.Color("#f00") {
+		t.Errorf("Error = %q, want #f00", theme.Error)
+	}
+	if theme.Success != LightTheme().Success {
+		t.Errorf("Invalid hex should keep the theme color, got %q", theme.Success)
+	}
+	if theme.Info != LightTheme().Info {
+		t.Errorf("Colors without overrides should come from the named theme, got %q", theme.Info)
+	}
+
+	if len(warnings) != 2 {
+		t.Fatalf("Expected 2 warnings, got %v", warnings)
+	}
+	if !strings.Contains(warnings[0], "sparkle") || !strings.Contains(warnings[1], "green") {
+		t.Errorf("Unexpected warnings: %v", warnings)
+	}
+}
+
+func TestApplyTheme_RebuildsStyles(t *testing.T) {
+	t.Cleanup(func() { ApplyTheme(DefaultTheme()) })
+
+	ApplyTheme(HighContrastTheme())
+
+	if CurrentTheme() != HighContrastTheme() {
+		t.Error("CurrentTheme() should return the applied theme")
+	}
+	if StatusHealthyStyle.GetForeground() != HighContrastTheme().Success {
+		t.Errorf("StatusHealthyStyle foreground = %v, want %v", StatusHealthyStyle.GetForeground(), HighContrastTheme().Success)
+	}
+	if PanelTitleActiveStyle.GetBackground() != HighContrastTheme().Active {
+		t.Errorf("PanelTitleActiveStyle background = %v, want %v", PanelTitleActiveStyle.GetBackground(), HighContrastTheme().Active)
+	}
+}
+
+func TestAgeColor_Thresholds(t *testing.T) {
+	theme := DefaultTheme()
+	tests := []struct {
+		age  time.Duration
+		want lipgloss.Color
+	}{
+		{0, theme.Success},
+		{DefaultFreshAge - time.Second, theme.Success},
+		{DefaultFreshAge, theme.Warning},
+		{DefaultStaleAge - time.Second, theme.Warning},
+		{DefaultStaleAge, theme.Error},
+		{30 * 24 * time.Hour, theme.Error},
+	}
+
+	for _, tt := range tests {
+		if got := theme.ageColorFor(tt.age); got != tt.want {
+			t.Errorf("ageColorFor(%s) = %q, want %q", tt.age, got, tt.want)
+		}
+	}
+
+	if got := ageColor(time.Now().Add(-time.Hour)); got != CurrentTheme().Success {
+		t.Errorf("A snapshot from an hour ago should be fresh, got %q", got)
+	}
+}
+
+func TestResolveTheme_AgeThresholds(t *testing.T) {
+	theme, warnings := ResolveTheme(types.ThemeConfig{FreshAge: time.Hour, StaleAge: 48 * time.Hour})
+	if len(warnings) != 0 {
+		t.Fatalf("Unexpected warnings: %v", warnings)
+	}
+	if theme.ageColorFor(2*time.Hour) != theme.Warning || theme.ageColorFor(48*time.Hour) != theme.Error {
+		t.Errorf("Configured thresholds not applied: fresh %s, stale %s", theme.FreshAge, theme.StaleAge)
+	}
+
+	// A fresh age beyond the default stale age is inconsistent
+	theme, warnings = ResolveTheme(types.ThemeConfig{FreshAge: 10 * 24 * time.Hour})
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "stale_age") {
+		t.Errorf("Expected a warning about the thresholds, got %v", warnings)
+	}
+	if theme.ageColorFor(2*24*time.Hour) != theme.Warning {
+		t.Error("Invalid thresholds should fall back to the defaults")
+	}
+}
@@ -1,22 +1,62 @@
 package ui
 
 import (
+	"bytes"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// formatBytes formats bytes in human-readable format
-func formatBytes(bytes int64) string {
+// Pack size limits accepted by restic (in MiB)
+const (
+	MinPackSizeMiB = 4
+	MaxPackSizeMiB = 128
+)
+
+// FormatBytes formats bytes in binary units (B, KiB, MiB, ... EiB) with one
+// decimal place. Every panel uses it so sizes round the same way everywhere.
+func FormatBytes(bytes int64) string {
 	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
+	sign := ""
+	// Work unsigned so the most negative int64 doesn't overflow
+	magnitude := uint64(bytes)
+	if bytes < 0 {
+		sign = "-"
+		magnitude = -magnitude
 	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
+	if magnitude < unit {
+		return fmt.Sprintf("%s%d B", sign, magnitude)
+	}
+
+	value := float64(magnitude) / unit
+	exp := 0
+	// Move up a unit when rounding would otherwise show e.g. "1024.0 KiB"
+	for value >= unit-0.05 && exp < len(binaryPrefixes)-1 {
+		value /= unit
 		exp++
 	}
-	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+	return fmt.Sprintf("%s%.1f %ciB", sign, value, binaryPrefixes[exp])
+}
+
+// binaryPrefixes are the unit prefixes used by FormatBytes above bytes
+const binaryPrefixes = "KMGTPE"
+
+// FormatSeconds formats a count of seconds as reported by restic (seconds_elapsed,
+// seconds_remaining), e.g. "45s", "2m 05s" or "1h 02m 03s". Negative values show as "0s".
+func FormatSeconds(seconds int) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	hours, minutes, secs := seconds/3600, seconds/60%60, seconds%60
+	switch {
+	case hours > 0:
+		return fmt.Sprintf("%dh %02dm %02ds", hours, minutes, secs)
+	case minutes > 0:
+		return fmt.Sprintf("%dm %02ds", minutes, secs)
+	default:
+		return fmt.Sprintf("%ds", secs)
+	}
 }
 
 // formatTimeAgo formats a time as "X time ago" or a formatted date
@@ -61,3 +101,53 @@ func FormatTimeAgo(t time.Time) string {
 		return fmt.Sprintf("%d years ago", years)
 	}
 }
+
+// FormatDuration formats a duration compactly, e.g. "45m", "5h" or "3d"
+func FormatDuration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	switch {
+	case d < time.Minute:
+		return "<1m"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// parsePackSize parses a pack size such as "64", "64M" or "64MiB" into MiB.
+// An empty input returns 0, meaning restic's default pack size.
+func parsePackSize(input string) (int, error) {
+	value := strings.TrimSpace(input)
+	if value == "" {
+		return 0, nil
+	}
+
+	upper := strings.ToUpper(value)
+	for _, suffix := range []string{"MIB", "MB", "M"} {
+		if strings.HasSuffix(upper, suffix) {
+			upper = strings.TrimSpace(strings.TrimSuffix(upper, suffix))
+			break
+		}
+	}
+
+	size, err := strconv.Atoi(upper)
+	if err != nil {
+		return 0, fmt.Errorf("invalid pack size %q (use MiB, e.g. 64 or 128M)", value)
+	}
+
+	if size < MinPackSizeMiB || size > MaxPackSizeMiB {
+		return 0, fmt.Errorf("pack size must be between %d and %d MiB", MinPackSizeMiB, MaxPackSizeMiB)
+	}
+
+	return size, nil
+}
+
+// IsBinaryContent reports whether data looks like a binary file (contains NUL bytes)
+func IsBinaryContent(data []byte) bool {
+	return bytes.IndexByte(data, 0) >= 0
+}
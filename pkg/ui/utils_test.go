@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{0, "0 B"},
+		{1, "1 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{1024*1024 - 1, "1.0 MiB"},
+		{1024 * 1024, "1.0 MiB"},
+		{5 * 1024 * 1024 * 1024, "5.0 GiB"},
+		{1 << 40, "1.0 TiB"},
+		{3 << 49, "1.5 PiB"},
+		{1 << 60, "1.0 EiB"},
+		{math.MaxInt64, "8.0 EiB"},
+		{-1023, "-1023 B"},
+		{-1536, "-1.5 KiB"},
+		{math.MinInt64, "-8.0 EiB"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatBytes(tt.bytes); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSeconds(t *testing.T) {
+	tests := []struct {
+		seconds int
+		want    string
+	}{
+		{0, "0s"},
+		{-5, "0s"},
+		{59, "59s"},
+		{60, "1m 00s"},
+		{125, "2m 05s"},
+		{3599, "59m 59s"},
+		{3600, "1h 00m 00s"},
+		{3723, "1h 02m 03s"},
+		{90000, "25h 00m 00s"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatSeconds(tt.seconds); got != tt.want {
+			t.Errorf("FormatSeconds(%d) = %q, want %q", tt.seconds, got, tt.want)
+		}
+	}
+}